@@ -1,12 +1,14 @@
 // Package clip provides everything for calculating and altering polygons.
-// The external clipper lib should only be used inside of this package.
+// The polygon operations themselves are implemented by the geometry package,
+// the external clipper lib is only used directly for the infill patterns.
 package clip
 
 import (
 	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/geometry"
+	"log"
 
 	clipper "github.com/aligator/go.clipper"
-	goconvexhull2d "github.com/furstenheim/go-convex-hull-2d"
 )
 
 // Pattern is an interface for all infill types which can be used to fill layer parts.
@@ -107,6 +109,14 @@ type Clipper interface {
 	// IsCrossingPerimeter checks if the given line crosses any perimeter of the given parts. If yes, the result is true.
 	IsCrossingPerimeter(parts []data.LayerPart, line data.Path) (result, ok bool)
 
+	// IntersectLines clips the given open lines by the given parts.
+	// Only the segments of the lines which are inside of the parts remain.
+	IntersectLines(lines data.Paths, parts []data.LayerPart) (clippedLines data.Paths, ok bool)
+
+	// DifferenceLines clips the given open lines by the given parts.
+	// Only the segments of the lines which are outside of the parts remain.
+	DifferenceLines(lines data.Paths, parts []data.LayerPart) (clippedLines data.Paths, ok bool)
+
 	// Hull generates an outline around all LayerParts.
 	Hull(parts []data.LayerPart) (hull data.Path, ok bool)
 
@@ -128,11 +138,60 @@ type Clipper interface {
 }
 
 // clipperClipper implements Clipper using the external clipper library.
-type clipperClipper struct{}
+type clipperClipper struct {
+	joinType   geometry.JoinType
+	miterLimit float64
+	logger     *log.Logger
+}
+
+type option func(c *clipperClipper)
+
+// WithLogger sets the logger which receives a warning each time a polygon operation failed
+// and only succeeded after its input polygons were simplified.
+// By default no warnings are logged.
+func WithLogger(logger *log.Logger) option {
+	return func(c *clipperClipper) {
+		c.logger = logger
+	}
+}
 
 // NewClipper returns a new instance of a polygon Clipper.
-func NewClipper() Clipper {
-	return &clipperClipper{}
+// Its insets join the corners square.
+func NewClipper(clipperOptions ...option) Clipper {
+	c := &clipperClipper{
+		joinType:   geometry.JoinSquare,
+		miterLimit: 2,
+	}
+
+	for _, option := range clipperOptions {
+		option(c)
+	}
+
+	return c
+}
+
+// NewClipperWithJoin returns a new instance of a polygon Clipper whose insets join the corners using the given join type,
+// which can be "square", "round" or "miter". Unknown join types are treated as "square".
+// The miterLimit is only used by "miter" joins and is the max distance of a corner from the original corner
+// as multiple of the offset. Sharper corners are cut off.
+func NewClipperWithJoin(joinType string, miterLimit float64, clipperOptions ...option) Clipper {
+	c := &clipperClipper{
+		joinType:   geometry.JoinSquare,
+		miterLimit: miterLimit,
+	}
+
+	switch joinType {
+	case "round":
+		c.joinType = geometry.JoinRound
+	case "miter":
+		c.joinType = geometry.JoinMiter
+	}
+
+	for _, option := range clipperOptions {
+		option(c)
+	}
+
+	return c
 }
 
 // clipperPoint converts the GoSlice point representation to the
@@ -201,59 +260,17 @@ func microPaths(p clipper.Paths, simplify bool) data.Paths {
 }
 
 func (c clipperClipper) GenerateLayerParts(l data.Layer) (data.PartitionedLayer, bool) {
-	polyList := clipper.Paths{}
-	// convert all polygons to clipper polygons
+	var polygons data.Paths
 	for _, layerPolygon := range l.Polygons() {
-		polyList = append(polyList, clipperPath(layerPolygon.Simplify(-1, -1)))
+		polygons = append(polygons, layerPolygon.Simplify(-1, -1))
 	}
 
-	if len(polyList) == 0 {
-		return data.NewPartitionedLayer([]data.LayerPart{}), true
-	}
-
-	cl := clipper.NewClipper(clipper.IoNone)
-	cl.AddPaths(polyList, clipper.PtSubject, true)
-	resultPolys, ok := cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
+	parts, ok := geometry.Partition(polygons, c.logger)
 	if !ok {
 		return nil, false
 	}
 
-	return data.NewPartitionedLayer(polyTreeToLayerParts(resultPolys)), true
-}
-
-// polyTreeToLayerParts creates layer parts out of a poly tree (which is the result of clipper's Execute2).
-func polyTreeToLayerParts(tree *clipper.PolyTree) []data.LayerPart {
-	var layerParts []data.LayerPart
-
-	var polysForNextRound []*clipper.PolyNode
-
-	for _, c := range tree.Childs() {
-		polysForNextRound = append(polysForNextRound, c)
-	}
-	for {
-		if polysForNextRound == nil {
-			break
-		}
-		thisRound := polysForNextRound
-		polysForNextRound = nil
-
-		for _, p := range thisRound {
-			var holes data.Paths
-
-			for _, child := range p.Childs() {
-				// TODO: simplify, yes / no ??
-				holes = append(holes, microPath(child.Contour(), false))
-				for _, c := range child.Childs() {
-					polysForNextRound = append(polysForNextRound, c)
-				}
-			}
-
-			// TODO: simplify, yes / no ??
-			layerParts = append(layerParts, data.NewBasicLayerPart(microPath(p.Contour(), false), holes))
-		}
-	}
-
-	return layerParts
+	return data.NewPartitionedLayer(parts), true
 }
 
 func (c clipperClipper) InsetLayer(layer []data.LayerPart, offset data.Micrometer, insetCount int, initialOffset data.Micrometer) OffsetResult {
@@ -266,117 +283,37 @@ func (c clipperClipper) InsetLayer(layer []data.LayerPart, offset data.Micromete
 }
 
 func (c clipperClipper) Inset(part data.LayerPart, offset data.Micrometer, insetCount int, initialOffset data.Micrometer) [][]data.LayerPart {
-	var insets [][]data.LayerPart
-
-	co := clipper.NewClipperOffset()
-
-	currentOffset := float64(initialOffset)
-
-	for insetNr := 0; insetNr < insetCount; insetNr++ {
-		// insets for the outline
-		co.Clear()
-		co.AddPaths(clipperPaths(data.Paths{part.Outline()}), clipper.JtSquare, clipper.EtClosedPolygon)
-		co.AddPaths(clipperPaths(part.Holes()), clipper.JtSquare, clipper.EtClosedPolygon)
-
-		co.MiterLimit = 2
-		allNewInsets := co.Execute2(currentOffset)
-		insets = append(insets, polyTreeToLayerParts(allNewInsets))
-
-		currentOffset += float64(-int(offset))
-	}
-
-	return insets
+	return geometry.InsetWithJoin(part, offset, insetCount, initialOffset, c.joinType, c.miterLimit)
 }
 
 func (c clipperClipper) Difference(parts []data.LayerPart, toRemove []data.LayerPart) (clippedParts []data.LayerPart, ok bool) {
-	return c.runClipper(clipper.CtDifference, parts, toRemove)
+	return geometry.Difference(parts, toRemove, c.logger)
 }
 
 func (c clipperClipper) Intersection(parts []data.LayerPart, toIntersect []data.LayerPart) (clippedParts []data.LayerPart, ok bool) {
-	return c.runClipper(clipper.CtIntersection, parts, toIntersect)
+	return geometry.Intersection(parts, toIntersect, c.logger)
 }
 
 func (c clipperClipper) Union(parts []data.LayerPart, toMerge []data.LayerPart) (clippedParts []data.LayerPart, ok bool) {
-	return c.runClipper(clipper.CtUnion, parts, toMerge)
-}
-
-func (c clipperClipper) runClipper(clipType clipper.ClipType, parts []data.LayerPart, toClip []data.LayerPart) (clippedParts []data.LayerPart, ok bool) {
-	if parts == nil && toClip == nil {
-		return nil, true
-	}
-
-	cl := clipper.NewClipper(clipper.IoNone)
-	for _, part := range parts {
-		cl.AddPath(clipperPath(part.Outline()), clipper.PtSubject, true)
-		cl.AddPaths(clipperPaths(part.Holes()), clipper.PtSubject, true)
-	}
-
-	for _, intersect := range toClip {
-		cl.AddPath(clipperPath(intersect.Outline()), clipper.PtClip, true)
-		cl.AddPaths(clipperPaths(intersect.Holes()), clipper.PtClip, true)
-	}
-
-	tree, ok := cl.Execute2(clipType, clipper.PftEvenOdd, clipper.PftEvenOdd)
-
-	if !ok {
-		return nil, ok
-	}
-	return polyTreeToLayerParts(tree), ok
+	return geometry.Union(parts, toMerge, c.logger)
 }
 
 func (c clipperClipper) IsCrossingPerimeter(parts []data.LayerPart, line data.Path) (result, ok bool) {
-	// TODO: Is there a more performant way to detect this?
-	cl := clipper.NewClipper(clipper.IoReverseSolution) // inverse solution so that it is basically LINE - PARTS
-
-	for _, part := range parts {
-		cl.AddPaths(clipperPaths(part.Holes()), clipper.PtClip, true)
-		cl.AddPath(clipperPath(part.Outline()), clipper.PtClip, true)
-	}
-
-	cl.AddPath(clipperPath(line), clipper.PtSubject, false)
-
-	// calculate the difference of the parts and the line, then look if the (inverted) result contains any left path which would be a line not inside of the parts.
-	// If any part is left, the line crossed a perimeter.
-	tree, ok := cl.Execute2(clipper.CtDifference, clipper.PftEvenOdd, clipper.PftEvenOdd)
+	return geometry.IsCrossingPerimeter(parts, line, c.logger)
+}
 
-	if !ok {
-		return false, ok
-	}
+func (c clipperClipper) IntersectLines(lines data.Paths, parts []data.LayerPart) (clippedLines data.Paths, ok bool) {
+	return geometry.IntersectLines(lines, parts, c.logger)
+}
 
-	return tree.Total() > 0, true
+func (c clipperClipper) DifferenceLines(lines data.Paths, parts []data.LayerPart) (clippedLines data.Paths, ok bool) {
+	return geometry.DifferenceLines(lines, parts, c.logger)
 }
 
 func (c clipperClipper) Hull(parts []data.LayerPart) (hull data.Path, ok bool) {
-	var allPoints data.Path
-	for _, part := range parts {
-		allPoints = append(allPoints, part.Outline()...)
-	}
-
-	convexHull := goconvexhull2d.New(allPoints)
-
-	hullPath, ok := convexHull.(data.Path)
-	if !ok {
-		return nil, ok
-	}
-	return hullPath, true
+	return geometry.Hull(parts)
 }
 
 func (c clipperClipper) TopLevelPolygons(parts []data.LayerPart) (topLevel data.Paths, ok bool) {
-	cl := clipper.NewClipper(clipper.IoNone)
-
-	for _, part := range parts {
-		cl.AddPath(clipperPath(part.Outline()), clipper.PtSubject, true)
-	}
-
-	// this is just a dummy-call to Execute2 as I found no other way to get a tree from clipper...
-	tree, ok := cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
-	if !ok {
-		return nil, false
-	}
-
-	for _, child := range tree.Childs() {
-		topLevel = append(topLevel, microPath(child.Contour(), false))
-	}
-
-	return topLevel, true
+	return geometry.TopLevelPolygons(parts, c.logger)
 }
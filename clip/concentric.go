@@ -0,0 +1,61 @@
+// This file implements a concentric pattern which follows the contours of the part.
+
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// concentric provides an infill which consists of closed loops following the outline and the holes of the part.
+type concentric struct {
+	lineWidth    data.Micrometer
+	lineDistance data.Micrometer
+}
+
+// NewConcentricPattern provides a pattern consisting of loops which are inset from the contours of the part
+// until the part is filled. The first loop lies half a line width inside the part,
+// each further loop lineDistance inside the previous one.
+func NewConcentricPattern(lineWidth data.Micrometer, lineDistance data.Micrometer) Pattern {
+	return concentric{
+		lineWidth:    lineWidth,
+		lineDistance: lineDistance,
+	}
+}
+
+// Fill implements the Pattern interface by using closed loops as infill.
+// Each loop is returned as path which ends at its start point.
+func (p concentric) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	if p.lineDistance <= 0 {
+		return nil, nil
+	}
+
+	cl := NewClipper()
+
+	var result data.Paths
+	current := []data.LayerPart{part}
+	offset := p.lineWidth / 2
+	for len(current) > 0 {
+		var next []data.LayerPart
+		for _, c := range current {
+			insets := cl.Inset(c, p.lineDistance, 1, -offset)
+			if len(insets) > 0 {
+				next = append(next, insets[0]...)
+			}
+		}
+
+		for _, loop := range next {
+			for _, path := range append(data.Paths{loop.Outline()}, loop.Holes()...) {
+				if len(path) < 3 {
+					continue
+				}
+				closed := append(path[:len(path):len(path)], path[0])
+				result = append(result, closed)
+			}
+		}
+
+		current = next
+		offset = p.lineDistance
+	}
+
+	return result, nil
+}
@@ -0,0 +1,55 @@
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestConcentricPattern(t *testing.T) {
+	withHole := data.NewBasicLayerPart(square(10000).Outline(), data.Paths{{
+		data.NewMicroPoint(4000, 4000),
+		data.NewMicroPoint(4000, 6000),
+		data.NewMicroPoint(6000, 6000),
+		data.NewMicroPoint(6000, 4000),
+	}})
+
+	var testCases = map[string]struct {
+		part         data.LayerPart
+		lineDistance data.Micrometer
+		// expectedMinX contains the min x of each loop
+		expectedMinX []data.Micrometer
+	}{
+		"without line distance": {
+			part:         square(10000),
+			lineDistance: 0,
+		},
+		"filled square": {
+			part:         square(10000),
+			lineDistance: 1000,
+			expectedMinX: []data.Micrometer{200, 1200, 2200, 3200, 4200},
+		},
+		"around the hole": {
+			part:         withHole,
+			lineDistance: 1000,
+			// the loops around the outline and around the hole until they meet,
+			// then the corners which are not reached by the round corners of the loops around the hole
+			expectedMinX: []data.Micrometer{200, 3800, 1200, 2800, 2200, 7311, 2200, 7311},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		paths, err := NewConcentricPattern(400, testCase.lineDistance).Fill(0, testCase.part)
+		test.Ok(t, err)
+
+		var minX []data.Micrometer
+		for _, path := range paths {
+			// each loop ends at its start point
+			test.Equals(t, path[0], path[len(path)-1], microPointComparer())
+			min, _ := path.Bounds()
+			minX = append(minX, min.X())
+		}
+		test.Equals(t, testCase.expectedMinX, minX)
+	}
+}
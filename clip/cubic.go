@@ -0,0 +1,138 @@
+// This file implements the cubic pattern and an adaptive cubic pattern which fills denser near the surfaces.
+
+package clip
+
+import (
+	"errors"
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// cubic provides an infill which consists of three sets of parallel lines in the directions 0°, 120° and 240°.
+// The lines are shifted sideways with the height of the layer, so that they build up cubes standing on one of their corners.
+type cubic struct {
+	directions [3]linear
+}
+
+// NewCubicPattern provides a cubic pattern which builds up stacked cubes standing on one of their corners.
+// The lineDistance is the distance between the parallel lines of each of the three directions.
+func NewCubicPattern(lineWidth data.Micrometer, lineDistance data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) Pattern {
+	var p cubic
+	for i := range p.directions {
+		p.directions[i] = linear{
+			lineDistance: lineDistance,
+			lineWidth:    lineWidth,
+			degree:       degree + i*120,
+			min:          min,
+			max:          max,
+		}
+	}
+	return p
+}
+
+// Fill implements the Pattern interface.
+// As the height of the layer is not known, the lines are not shifted (see FillLayer).
+func (p cubic) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	return p.FillLayer(LayerInfo{Nr: layerNr}, part)
+}
+
+// FillLayer implements the LayerPattern interface by filling the part with the lines of all directions
+// shifted by the height of the layer.
+func (p cubic) FillLayer(layer LayerInfo, part data.LayerPart) (data.Paths, error) {
+	// The faces of a cube standing on its corner are tilted by atan(√2) against the bed,
+	// so that their intersection with the layer moves sideways by z / √2.
+	shift := data.Micrometer(math.Round(float64(layer.Z) / math.Sqrt2))
+
+	var result data.Paths
+	for _, direction := range p.directions {
+		direction.shift = shift
+		lines, err := direction.Fill(layer.Nr, part)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, lines...)
+	}
+
+	return result, nil
+}
+
+// adaptiveCubic provides a cubic infill which uses half of the line distance near the perimeters and the top surfaces.
+type adaptiveCubic struct {
+	sparse, dense Pattern
+	distance      data.Micrometer
+}
+
+// NewAdaptiveCubicPattern provides a cubic pattern (see NewCubicPattern) which fills the areas nearer to the
+// perimeters or to the top surfaces above (see LayerInfo.Surfaces) than the given distance with the double density.
+// The lines of the sparse areas continue in the dense areas, so that the cubes are subdivided there.
+// If the distance is 0, it fills like the cubic pattern.
+func NewAdaptiveCubicPattern(lineWidth data.Micrometer, lineDistance data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int, distance data.Micrometer) Pattern {
+	return adaptiveCubic{
+		sparse:   NewCubicPattern(lineWidth, lineDistance, min, max, degree),
+		dense:    NewCubicPattern(lineWidth, lineDistance/2, min, max, degree),
+		distance: distance,
+	}
+}
+
+// Fill implements the Pattern interface.
+// As the height of the layer and the surfaces above are not known,
+// the lines are not shifted and only the areas near the perimeters are filled denser (see FillLayer).
+func (p adaptiveCubic) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	return p.FillLayer(LayerInfo{Nr: layerNr}, part)
+}
+
+// FillLayer implements the LayerPattern interface by filling the areas near the perimeters and the surfaces
+// using the dense pattern and the rest using the sparse pattern.
+func (p adaptiveCubic) FillLayer(layer LayerInfo, part data.LayerPart) (data.Paths, error) {
+	if p.distance <= 0 {
+		return FillLayer(p.sparse, layer, part)
+	}
+
+	c := NewClipper()
+
+	// the area further away from the perimeters
+	interior := c.Inset(part, 0, 1, -p.distance)[0]
+
+	// remove the area near the surfaces above
+	var nearSurfaces []data.LayerPart
+	for _, surface := range layer.Surfaces {
+		for _, grown := range c.Inset(surface, 0, 1, p.distance)[0] {
+			var ok bool
+			nearSurfaces, ok = c.Union(nearSurfaces, []data.LayerPart{grown})
+			if !ok {
+				return nil, errors.New("could not merge the surfaces above the infill")
+			}
+		}
+	}
+	if len(interior) > 0 && len(nearSurfaces) > 0 {
+		var ok bool
+		interior, ok = c.Difference(interior, nearSurfaces)
+		if !ok {
+			return nil, errors.New("could not subtract the surfaces above from the infill")
+		}
+	}
+
+	dense, ok := c.Difference([]data.LayerPart{part}, interior)
+	if !ok {
+		return nil, errors.New("could not calculate the dense areas of the infill")
+	}
+
+	var result data.Paths
+	for _, area := range []struct {
+		pattern Pattern
+		parts   []data.LayerPart
+	}{
+		{p.sparse, interior},
+		{p.dense, dense},
+	} {
+		for _, areaPart := range area.parts {
+			lines, err := FillLayer(area.pattern, layer, areaPart)
+			if err != nil {
+				return nil, err
+			}
+			result = append(result, lines...)
+		}
+	}
+
+	return result, nil
+}
@@ -0,0 +1,162 @@
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"math"
+	"testing"
+)
+
+// square returns a part without holes covering the square from (0, 0) to (size, size).
+func square(size data.Micrometer) data.LayerPart {
+	return data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(size, 0),
+		data.NewMicroPoint(size, size),
+		data.NewMicroPoint(0, size),
+	}, nil)
+}
+
+// fakeLayerPattern records which of the fill functions is used.
+type fakeLayerPattern struct {
+	filledLayer *LayerInfo
+	filledNr    *int
+}
+
+func (f fakeLayerPattern) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	*f.filledNr = layerNr
+	return nil, nil
+}
+
+func (f fakeLayerPattern) FillLayer(layer LayerInfo, part data.LayerPart) (data.Paths, error) {
+	*f.filledLayer = layer
+	return nil, nil
+}
+
+func TestFillLayer(t *testing.T) {
+	layer := LayerInfo{Nr: 3, Z: 600}
+
+	var filledLayer LayerInfo
+	filledNr := -1
+	_, err := FillLayer(fakeLayerPattern{filledLayer: &filledLayer, filledNr: &filledNr}, layer, square(10000))
+	test.Ok(t, err)
+	test.Equals(t, layer, filledLayer)
+	test.Equals(t, -1, filledNr)
+
+	// patterns which do not depend on the layer are filled using Fill
+	linearPattern := NewLinearPattern(400, 1000, data.NewMicroPoint(0, 0), data.NewMicroPoint(10000, 10000), 0, false, false)
+	expected, err := linearPattern.Fill(3, square(10000))
+	test.Ok(t, err)
+	actual, err := FillLayer(linearPattern, layer, square(10000))
+	test.Ok(t, err)
+	test.Equals(t, expected, actual, microPointComparer())
+}
+
+func TestCubicShift(t *testing.T) {
+	var lineDistance data.Micrometer = 1000
+	part := square(10000)
+	pattern := NewCubicPattern(400, lineDistance, data.NewMicroPoint(0, 0), data.NewMicroPoint(10000, 10000), 0)
+
+	bottom, err := FillLayer(pattern, LayerInfo{Nr: 0, Z: 0}, part)
+	test.Ok(t, err)
+	test.Assert(t, len(bottom) > 0, "the part should be filled")
+
+	// without the height the lines are not shifted
+	filled, err := pattern.Fill(0, part)
+	test.Ok(t, err)
+	test.Equals(t, bottom, filled, microPointComparer())
+
+	// the lines move sideways with the height
+	shifted, err := FillLayer(pattern, LayerInfo{Nr: 0, Z: 500}, part)
+	test.Ok(t, err)
+	test.Assert(t, !pathsEqual(bottom, shifted), "the lines should be shifted")
+
+	// after a shift of one line distance the lines are the same again
+	repeated, err := FillLayer(pattern, LayerInfo{Nr: 0, Z: data.Micrometer(math.Round(float64(lineDistance) * math.Sqrt2))}, part)
+	test.Ok(t, err)
+	test.Equals(t, bottom, repeated, microPointComparer())
+}
+
+func TestAdaptiveCubic(t *testing.T) {
+	min, max := data.NewMicroPoint(0, 0), data.NewMicroPoint(20000, 20000)
+	part := square(20000)
+	layer := LayerInfo{Nr: 2, Z: 600}
+
+	sparse, err := FillLayer(NewCubicPattern(400, 2000, min, max, 0), layer, part)
+	test.Ok(t, err)
+	dense, err := FillLayer(NewCubicPattern(400, 1000, min, max, 0), layer, part)
+	test.Ok(t, err)
+
+	var testCases = map[string]struct {
+		distance data.Micrometer
+		surfaces []data.LayerPart
+		expected data.Paths
+	}{
+		"without distance": {
+			distance: 0,
+			expected: sparse,
+		},
+		"everything near the perimeters": {
+			distance: 10000,
+			expected: dense,
+		},
+		"everything near the surfaces": {
+			distance: 2000,
+			surfaces: []data.LayerPart{square(20000)},
+			expected: dense,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		pattern := NewAdaptiveCubicPattern(400, 2000, min, max, 0, testCase.distance)
+		actual, err := FillLayer(pattern, LayerInfo{Nr: layer.Nr, Z: layer.Z, Surfaces: testCase.surfaces}, part)
+		test.Ok(t, err)
+		test.Equals(t, pathsLength(testCase.expected), pathsLength(actual))
+	}
+
+	// only the border is filled denser
+	pattern := NewAdaptiveCubicPattern(400, 2000, min, max, 0, 3000)
+	border, err := FillLayer(pattern, layer, part)
+	test.Ok(t, err)
+	test.Assert(t, pathsLength(border) > pathsLength(sparse), "the border should be filled denser")
+	test.Assert(t, pathsLength(border) < pathsLength(dense), "the center should be filled sparse")
+
+	// the surfaces make the area below them denser
+	surface := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(8000, 8000),
+		data.NewMicroPoint(12000, 8000),
+		data.NewMicroPoint(12000, 12000),
+		data.NewMicroPoint(8000, 12000),
+	}, nil)
+	belowSurface, err := FillLayer(pattern, LayerInfo{Nr: layer.Nr, Z: layer.Z, Surfaces: []data.LayerPart{surface}}, part)
+	test.Ok(t, err)
+	test.Assert(t, pathsLength(belowSurface) > pathsLength(border), "the area below the surface should be filled denser")
+}
+
+// pathsLength returns the summed up length of all paths.
+func pathsLength(paths data.Paths) data.Micrometer {
+	var length data.Micrometer
+	for _, path := range paths {
+		length += path.Length()
+	}
+	return length
+}
+
+// pathsEqual returns true if both contain the same points in the same order.
+func pathsEqual(a, b data.Paths) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if len(a[i]) != len(b[i]) {
+			return false
+		}
+		for j := range a[i] {
+			if a[i][j].X() != b[i][j].X() || a[i][j].Y() != b[i][j].Y() {
+				return false
+			}
+		}
+	}
+	return true
+}
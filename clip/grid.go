@@ -0,0 +1,37 @@
+// This file implements a grid pattern which crosses the lines on each layer.
+
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// grid provides an infill which consists of two sets of parallel lines crossing each other.
+type grid struct {
+	lines, crossingLines Pattern
+}
+
+// NewGridPattern provides a grid pattern consisting of parallel lines in the given degree
+// and the same lines rotated by 90°, both on each layer.
+// The lineDistance is the distance between the parallel lines of each direction.
+func NewGridPattern(lineWidth data.Micrometer, lineDistance data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) Pattern {
+	return grid{
+		lines:         NewLinearPattern(lineWidth, lineDistance, min, max, degree, false, false),
+		crossingLines: NewLinearPattern(lineWidth, lineDistance, min, max, degree+90, false, false),
+	}
+}
+
+// Fill implements the Pattern interface by filling the part with the lines of both directions.
+func (p grid) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	lines, err := p.lines.Fill(layerNr, part)
+	if err != nil {
+		return nil, err
+	}
+
+	crossingLines, err := p.crossingLines.Fill(layerNr, part)
+	if err != nil {
+		return nil, err
+	}
+
+	return append(lines, crossingLines...), nil
+}
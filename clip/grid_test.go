@@ -0,0 +1,60 @@
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestGridPattern(t *testing.T) {
+	var testCases = map[string]struct {
+		degree int
+		// direction returns the direction of a line, 0 for the lines of the degree, 1 for the crossing ones
+		// and -1 for any other direction
+		direction func(line data.MicroPoint) int
+	}{
+		"0°": {
+			degree: 0,
+			direction: func(line data.MicroPoint) int {
+				switch {
+				case line.Y() == 0:
+					return 0
+				case line.X() == 0:
+					return 1
+				}
+				return -1
+			},
+		},
+		"45°": {
+			degree: 45,
+			direction: func(line data.MicroPoint) int {
+				x, y := line.X(), line.Y()
+				switch {
+				// allow a small rounding difference
+				case x*y > 0 && data.Max(x-y, y-x) <= 2:
+					return 0
+				case x*y < 0 && data.Max(x+y, -x-y) <= 2:
+					return 1
+				}
+				return -1
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		pattern := NewGridPattern(400, 1000, data.NewMicroPoint(0, 0), data.NewMicroPoint(10000, 10000), testCase.degree)
+		paths, err := pattern.Fill(0, square(10000))
+		test.Ok(t, err)
+
+		// the lines of both directions are printed on the same layer
+		counts := map[int]int{}
+		for _, path := range paths {
+			test.Equals(t, 2, len(path))
+			counts[testCase.direction(path[1].Sub(path[0]))]++
+		}
+		test.Equals(t, 0, counts[-1])
+		test.Assert(t, counts[0] > 0, "there should be lines in the direction of the degree")
+		test.Equals(t, counts[0], counts[1])
+	}
+}
@@ -18,6 +18,9 @@ type linear struct {
 	min, max     data.MicroPoint
 	rectlinear   bool
 	zigZag       bool
+
+	// shift moves the lines sideways, e.g. to build up the planes of the cubic pattern.
+	shift data.Micrometer
 }
 
 // NewLinearPattern provides a simple linear infill pattern consisting of simple parallel lines.
@@ -78,17 +81,23 @@ func (p linear) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
 		return nil, err
 	}
 
-	result := p.sortInfill(microPaths(resultInfill, false), p.zigZag, data.NewBasicLayerPart(outline, holes))
+	result, err := p.sortInfill(microPaths(resultInfill, false), p.zigZag, data.NewBasicLayerPart(outline, holes))
+	if err != nil {
+		return nil, err
+	}
 
 	result.Rotate(-rotation)
 
 	return result, nil
 }
 
-// sortInfill optimizes the order of the infill lines.
-func (p linear) sortInfill(unsorted data.Paths, zigZag bool, part data.LayerPart) data.Paths {
+// sortInfill optimizes the order and the direction of the infill lines.
+// It always continues with the line which has the nearest start or end point
+// to the end of the last line. If the end point is nearer, the line is reversed.
+// If zigZag is set, the lines are chained along the border of the part (see connection).
+func (p linear) sortInfill(unsorted data.Paths, zigZag bool, part data.LayerPart) (data.Paths, error) {
 	if len(unsorted) == 0 {
-		return unsorted
+		return unsorted, nil
 	}
 
 	cl := NewClipper()
@@ -103,76 +112,126 @@ func (p linear) sortInfill(unsorted data.Paths, zigZag bool, part data.LayerPart
 	isUsed := make([]bool, len(unsorted))
 	isUsed[0] = true
 
-	// Saves the last path to know where to continue.
-	lastIndex := 0
-
-	// Save if the first(0) or second(1) point from the lastPath was the last point.
-	lastPoint := 0
-
 	for savedPointsNum < len(unsorted) {
-		point := unsorted[lastIndex][lastPoint]
+		lastLine := sorted[len(sorted)-1]
+		point := lastLine[len(lastLine)-1]
 
 		bestIndex := -1
 		bestDiff := data.Micrometer(-1)
+		bestReversed := false
 
-		// get the line with the nearest point (of the same side)
+		// get the line with the nearest start or end point
 		for i, line := range unsorted {
 			if isUsed[i] {
 				continue
 			}
 
-			point2 := line[lastPoint]
+			for _, reversed := range []bool{false, true} {
+				point2 := line[0]
+				if reversed {
+					point2 = line[len(line)-1]
+				}
 
-			differenceVec := point.Sub(point2)
-			if bestDiff == -1 || differenceVec.ShorterThanOrEqual(bestDiff) {
-				bestIndex = i
-				bestDiff = differenceVec.Size()
-				continue
+				differenceVec := point.Sub(point2)
+				if bestDiff == -1 || differenceVec.ShorterThanOrEqual(bestDiff) {
+					bestIndex = i
+					bestDiff = differenceVec.Size()
+					bestReversed = reversed
+				}
 			}
 		}
 
-		if bestIndex > -1 {
-			lastIndex = bestIndex
-
-			if zigZag {
-				p1 := sorted[len(sorted)-1][1]
-				p2 := unsorted[lastIndex][1-lastPoint]
+		if bestIndex == -1 {
+			return nil, errors.New("could not find the next infill line")
+		}
 
-				if p1.Sub(p2).ShorterThanOrEqual(p.lineWidth + p.lineDistance*2) {
+		next := unsorted[bestIndex]
+		if bestReversed {
+			next = next.Reversed()
+		}
 
-					connectionLine := []data.MicroPoint{p1, p2}
+		savedPointsNum++
+		isUsed[bestIndex] = true
 
-					isCrossing, ok := cl.IsCrossingPerimeter([]data.LayerPart{part}, connectionLine)
+		if zigZag {
+			connection, ok, err := p.connection(cl, part, point, next[0])
+			if err != nil {
+				return nil, err
+			}
+			if ok {
+				// Chain the next line to the last one, so that all connected lines are printed as one continuous path.
+				// The capacity is limited so that appending never changes the unsorted lines.
+				chained := append(lastLine[:len(lastLine):len(lastLine)], connection[1:]...)
+				sorted[len(sorted)-1] = append(chained, next[1:]...)
+				continue
+			}
+		}
 
-					if !ok {
-						// TODO: return error
-						panic("could not calculate the difference between the current layer and the non-extrusion-move")
-					}
+		sorted = append(sorted, next)
+	}
 
-					if !isCrossing {
-						sorted = append(sorted, connectionLine)
-					}
-				}
-			}
+	return sorted, nil
+}
 
-			sorted = append(sorted, unsorted[lastIndex])
-			savedPointsNum++
-			isUsed[bestIndex] = true
-			lastPoint = 1 - lastPoint
-		} else {
-			// should never go here
-			panic("there should always be a bestIndex > -1")
+// connection returns the path which connects the end p1 of a line with the start p2 of the next one.
+// It follows the contour of the part between them, so that the connection stays at the border of the infill.
+// If both points are not near the same contour, they are connected directly if they are near enough to each other
+// and the connection does not cross the perimeters.
+// It returns false if there is no such connection.
+func (p linear) connection(cl Clipper, part data.LayerPart, p1, p2 data.MicroPoint) (data.Path, bool, error) {
+	maxLength := p.lineWidth + p.lineDistance*2
+
+	contours := append(data.Paths{part.Outline()}, part.Holes()...)
+	from, ok1 := nearestContourPosition(contours, p1, p.lineWidth)
+	to, ok2 := nearestContourPosition(contours, p2, p.lineWidth)
+	if ok1 && ok2 && from.contour == to.contour {
+		contour := contours[from.contour]
+		walk := data.ShortestContourWalk(contour, from.segment, from.point, to.segment, to.point)
+
+		// the border between two neighbouring lines is longer than their distance if it is slanted
+		if walk.Length() <= maxLength+p.lineDistance {
+			return append(append(data.Path{p1}, walk...), p2), true, nil
 		}
+	}
+
+	if !p1.Sub(p2).ShorterThanOrEqual(maxLength) {
+		return nil, false, nil
+	}
+
+	connectionLine := data.Path{p1, p2}
+	isCrossing, ok := cl.IsCrossingPerimeter([]data.LayerPart{part}, connectionLine)
+	if !ok {
+		return nil, false, errors.New("could not calculate the difference between the current layer and the non-extrusion-move")
+	}
+	if isCrossing {
+		return nil, false, nil
+	}
+	return connectionLine, true, nil
+}
 
-		if lastPoint == 1 {
-			sorted[len(sorted)-1] = []data.MicroPoint{
-				sorted[len(sorted)-1][1],
-				sorted[len(sorted)-1][0],
+// contourPosition is a point on the segment of a closed contour which starts at the point with the index segment.
+type contourPosition struct {
+	contour int
+	segment int
+	point   data.MicroPoint
+}
+
+// nearestContourPosition returns the position on the contours which is nearest to p
+// if it is not further away than maxDistance.
+func nearestContourPosition(contours data.Paths, p data.MicroPoint, maxDistance data.Micrometer) (contourPosition, bool) {
+	var best contourPosition
+	bestDistance := data.Micrometer(-1)
+	for contourNr, contour := range contours {
+		for i := range contour {
+			point := data.ProjectOnSegment(p, contour[i], contour[(i+1)%len(contour)])
+			if distance := point.Sub(p).Size2(); bestDistance == -1 || distance < bestDistance {
+				best = contourPosition{contour: contourNr, segment: i, point: point}
+				bestDistance = distance
 			}
 		}
 	}
 
-	return sorted
+	return best, bestDistance != -1 && bestDistance <= maxDistance*maxDistance
 }
 
 // getInfill fills a polygon (with holes)
@@ -201,10 +260,18 @@ func (p linear) getInfill(min data.MicroPoint, max data.MicroPoint, outline clip
 	cl.AddPaths(exset, clipper.PtClip, true)
 	cl.AddPaths(holes, clipper.PtClip, true)
 
+	// shifted lines start one line before the bounds, so that no line is missing at the border
+	start := min.X()
+	if shift := p.shift % p.lineDistance; shift > 0 {
+		start += shift - p.lineDistance
+	} else if shift < 0 {
+		start += shift
+	}
+
 	verticalLines := clipper.Paths{}
 	numLine := 0
 	// generate the verticalLines
-	for x := min.X(); x <= max.X(); x += p.lineDistance {
+	for x := start; x <= max.X(); x += p.lineDistance {
 		verticalLines = append(verticalLines, clipper.Path{
 			&clipper.IntPoint{
 				X: clipper.CInt(x),
@@ -0,0 +1,136 @@
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// microPointComparer returns a cmp.Comparer which can handle data.MicroPoint.
+func microPointComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 data.MicroPoint) bool {
+		return p1.X() == p2.X() && p1.Y() == p2.Y()
+	})
+}
+
+// line returns the path of a line from (x1, y1) to (x2, y2).
+func line(x1, y1, x2, y2 data.Micrometer) data.Path {
+	return data.Path{data.NewMicroPoint(x1, y1), data.NewMicroPoint(x2, y2)}
+}
+
+func TestNearestContourPosition(t *testing.T) {
+	contours := data.Paths{
+		{
+			data.NewMicroPoint(0, 0),
+			data.NewMicroPoint(10000, 0),
+			data.NewMicroPoint(10000, 10000),
+			data.NewMicroPoint(0, 10000),
+		},
+		{
+			data.NewMicroPoint(4000, 4000),
+			data.NewMicroPoint(4000, 6000),
+			data.NewMicroPoint(6000, 6000),
+			data.NewMicroPoint(6000, 4000),
+		},
+	}
+
+	var testCases = map[string]struct {
+		point      data.MicroPoint
+		expected   contourPosition
+		expectedOk bool
+	}{
+		"near the outline": {
+			point:      data.NewMicroPoint(9800, 5000),
+			expected:   contourPosition{contour: 0, segment: 1, point: data.NewMicroPoint(10000, 5000)},
+			expectedOk: true,
+		},
+		"near the hole": {
+			point:      data.NewMicroPoint(5000, 3800),
+			expected:   contourPosition{contour: 1, segment: 3, point: data.NewMicroPoint(5000, 4000)},
+			expectedOk: true,
+		},
+		"too far away": {
+			point:    data.NewMicroPoint(2000, 2000),
+			expected: contourPosition{contour: 0, segment: 0, point: data.NewMicroPoint(2000, 0)},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		position, ok := nearestContourPosition(contours, testCase.point, 400)
+		test.Equals(t, testCase.expectedOk, ok)
+		test.Equals(t, testCase.expected, position, cmp.AllowUnexported(contourPosition{}), microPointComparer())
+	}
+
+	_, ok := nearestContourPosition(nil, data.NewMicroPoint(0, 0), 400)
+	test.Assert(t, !ok, "there is no position without contours")
+}
+
+func TestSortInfill(t *testing.T) {
+	part := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(-200, -200),
+		data.NewMicroPoint(2200, -200),
+		data.NewMicroPoint(2200, 1200),
+		data.NewMicroPoint(-200, 1200),
+	}, nil)
+	unsorted := data.Paths{
+		line(0, 0, 0, 1000),
+		line(2000, 0, 2000, 1000),
+		line(1000, 0, 1000, 1000),
+	}
+
+	var testCases = map[string]struct {
+		lineDistance data.Micrometer
+		zigZag       bool
+		expected     data.Paths
+	}{
+		"nearest line first": {
+			lineDistance: 1000,
+			expected: data.Paths{
+				line(0, 0, 0, 1000),
+				line(1000, 1000, 1000, 0),
+				line(2000, 0, 2000, 1000),
+			},
+		},
+		"lines chained along the border": {
+			lineDistance: 1000,
+			zigZag:       true,
+			expected: data.Paths{
+				{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(0, 1000),
+					data.NewMicroPoint(0, 1200),
+					data.NewMicroPoint(1000, 1200),
+					data.NewMicroPoint(1000, 1000),
+					data.NewMicroPoint(1000, 0),
+					data.NewMicroPoint(1000, -200),
+					data.NewMicroPoint(2000, -200),
+					data.NewMicroPoint(2000, 0),
+					data.NewMicroPoint(2000, 1000),
+				},
+			},
+		},
+		"lines too far apart for the line distance": {
+			lineDistance: 100,
+			zigZag:       true,
+			expected: data.Paths{
+				line(0, 0, 0, 1000),
+				line(1000, 1000, 1000, 0),
+				line(2000, 0, 2000, 1000),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		p := linear{lineWidth: 400, lineDistance: testCase.lineDistance}
+		sorted, err := p.sortInfill(unsorted, testCase.zigZag, part)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, sorted, microPointComparer())
+	}
+
+	sorted, err := linear{}.sortInfill(nil, true, part)
+	test.Ok(t, err)
+	test.Equals(t, 0, len(sorted))
+}
@@ -0,0 +1,178 @@
+// This file provides a registry of the infill patterns which can be selected by name
+// and a pattern which uses another pattern for the first layer.
+
+package clip
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"sort"
+	"sync"
+)
+
+// PatternOptions contains the settings which are passed to a PatternFactory.
+type PatternOptions struct {
+	// LineWidth is the width of the extruded lines.
+	LineWidth data.Micrometer
+
+	// LineDistance is the distance between the lines which results from the infill percentage.
+	LineDistance data.Micrometer
+
+	// Min and Max are the corners of the bounding box of the model.
+	Min, Max data.MicroPoint
+
+	// Degree is the rotation of the pattern.
+	Degree int
+
+	// ZigZag connects the lines if the pattern supports it.
+	ZigZag bool
+
+	// AdaptiveDistance is the distance to the perimeters and to the top surfaces above
+	// within which adaptive patterns fill denser.
+	AdaptiveDistance data.Micrometer
+}
+
+// LayerInfo describes the layer which is filled by a LayerPattern.
+type LayerInfo struct {
+	// Nr is the number of the layer.
+	Nr int
+
+	// Z is the height of the layer.
+	Z data.Micrometer
+
+	// Surfaces are the top surfaces of the layers above which lie near to the layer, projected onto the layer.
+	// They are only set if needed by the selected pattern (see modifier.NewInfillSurfacesModifier).
+	Surfaces []data.LayerPart
+}
+
+// LayerPattern is a Pattern which depends on the height of the layer or on the geometry of the layers above,
+// e.g. to build up a 3D structure or to fill denser below top surfaces.
+// The renderers call FillLayer instead of Fill for patterns implementing it.
+type LayerPattern interface {
+	Pattern
+
+	// FillLayer fills the given part of the layer.
+	FillLayer(layer LayerInfo, part data.LayerPart) (data.Paths, error)
+}
+
+// FillLayer fills the part using FillLayer if the pattern is a LayerPattern and Fill otherwise.
+func FillLayer(pattern Pattern, layer LayerInfo, part data.LayerPart) (data.Paths, error) {
+	if layerPattern, ok := pattern.(LayerPattern); ok {
+		return layerPattern.FillLayer(layer, part)
+	}
+	return pattern.Fill(layer.Nr, part)
+}
+
+// PatternFactory creates a pattern using the given options.
+// It may return nil if no infill should be generated.
+type PatternFactory func(options PatternOptions) Pattern
+
+var (
+	patternsMutex sync.RWMutex
+	patterns      = map[string]PatternFactory{
+		"linear": func(o PatternOptions) Pattern {
+			return NewLinearPattern(o.LineWidth, o.LineDistance, o.Min, o.Max, o.Degree, true, o.ZigZag)
+		},
+		"grid": func(o PatternOptions) Pattern {
+			// the lines of both directions together result in the density of the line distance
+			return NewGridPattern(o.LineWidth, o.LineDistance*2, o.Min, o.Max, o.Degree)
+		},
+		"concentric": func(o PatternOptions) Pattern {
+			return NewConcentricPattern(o.LineWidth, o.LineDistance)
+		},
+		"cubic": func(o PatternOptions) Pattern {
+			// the lines of all three directions together result in the density of the line distance
+			return NewCubicPattern(o.LineWidth, o.LineDistance*3, o.Min, o.Max, o.Degree)
+		},
+		"adaptive-cubic": func(o PatternOptions) Pattern {
+			return NewAdaptiveCubicPattern(o.LineWidth, o.LineDistance*3, o.Min, o.Max, o.Degree, o.AdaptiveDistance)
+		},
+	}
+)
+
+func init() {
+	for name := range patterns {
+		data.RegisterInfillPattern(name)
+	}
+}
+
+// RegisterPattern makes a pattern selectable by the given name, e.g. in the infill pattern option.
+// It is meant to be called from an init function of the package providing the pattern.
+// It panics if the factory is nil or a pattern with the same name is already registered.
+func RegisterPattern(name string, factory PatternFactory) {
+	patternsMutex.Lock()
+	defer patternsMutex.Unlock()
+
+	if factory == nil {
+		panic("clip: the factory of the pattern " + name + " is nil")
+	}
+	if _, ok := patterns[name]; ok {
+		panic("clip: the pattern " + name + " is registered twice")
+	}
+	patterns[name] = factory
+	data.RegisterInfillPattern(name)
+}
+
+// NewPattern creates the pattern registered with the given name.
+func NewPattern(name string, options PatternOptions) (Pattern, error) {
+	patternsMutex.RLock()
+	factory, ok := patterns[name]
+	patternsMutex.RUnlock()
+
+	if !ok {
+		return nil, fmt.Errorf("the pattern %q is unknown, possible patterns are %v", name, PatternNames())
+	}
+	return factory(options), nil
+}
+
+// PatternNames returns the sorted names of all registered patterns.
+func PatternNames() []string {
+	patternsMutex.RLock()
+	defer patternsMutex.RUnlock()
+
+	names := make([]string, 0, len(patterns))
+	for name := range patterns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// firstLayerPattern fills the first layer using another pattern than all other layers.
+type firstLayerPattern struct {
+	first, other Pattern
+}
+
+// NewFirstLayerPattern returns a pattern which fills the first layer using first and all other layers using other,
+// e.g. to use the wider lines of the first layer (see data.PrinterOptions.FirstLayerExtrusionWidth).
+// If one of the patterns is nil, no infill is generated on its layers.
+func NewFirstLayerPattern(first, other Pattern) Pattern {
+	return firstLayerPattern{
+		first: first,
+		other: other,
+	}
+}
+
+// Fill implements the Pattern interface by using the pattern of the layer.
+func (p firstLayerPattern) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	pattern := p.other
+	if layerNr == 0 {
+		pattern = p.first
+	}
+	if pattern == nil {
+		return nil, nil
+	}
+	return pattern.Fill(layerNr, part)
+}
+
+// FillLayer implements the LayerPattern interface by using the pattern of the layer.
+func (p firstLayerPattern) FillLayer(layer LayerInfo, part data.LayerPart) (data.Paths, error) {
+	pattern := p.other
+	if layer.Nr == 0 {
+		pattern = p.first
+	}
+	if pattern == nil {
+		return nil, nil
+	}
+	return FillLayer(pattern, layer, part)
+}
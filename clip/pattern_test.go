@@ -0,0 +1,69 @@
+package clip
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestRegisterPattern(t *testing.T) {
+	RegisterPattern("test-pattern", func(o PatternOptions) Pattern {
+		return NewConcentricPattern(o.LineWidth, o.LineDistance)
+	})
+
+	pattern, err := NewPattern("test-pattern", PatternOptions{LineWidth: 400, LineDistance: 400})
+	test.Ok(t, err)
+	test.Assert(t, pattern != nil, "the registered pattern should be created")
+
+	_, err = NewPattern("unknown-pattern", PatternOptions{})
+	test.Assert(t, err != nil, "an unknown pattern should return an error")
+
+	// the options accept the registered patterns only
+	options := data.DefaultOptions()
+	for name, expectedWarning := range map[string]bool{"linear": false, "test-pattern": false, "unknown-pattern": true} {
+		options.Print.InfillPattern = name
+		warned := false
+		for _, warning := range options.Validate() {
+			warned = warned || strings.Contains(warning, "infill pattern")
+		}
+		test.Equals(t, expectedWarning, warned)
+	}
+}
+
+func TestFirstLayerPattern(t *testing.T) {
+	// the first layer uses wider lines with a wider spacing
+	pattern := NewFirstLayerPattern(NewConcentricPattern(600, 600), NewConcentricPattern(400, 400))
+
+	var testCases = map[string]struct {
+		layerNr int
+		// expectedMinX contains the min x of each loop
+		expectedMinX []data.Micrometer
+	}{
+		"first layer": {
+			layerNr:      0,
+			expectedMinX: []data.Micrometer{300, 900, 1500, 2100, 2700, 3300, 3900, 4500},
+		},
+		"second layer": {
+			layerNr:      1,
+			expectedMinX: []data.Micrometer{200, 600, 1000, 1400, 1800, 2200, 2600, 3000, 3400, 3800, 4200, 4600},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		paths, err := pattern.Fill(testCase.layerNr, square(10000))
+		test.Ok(t, err)
+		// FillLayer has to choose the pattern of the layer in the same way
+		layerPaths, err := FillLayer(pattern, LayerInfo{Nr: testCase.layerNr}, square(10000))
+		test.Ok(t, err)
+		test.Equals(t, paths, layerPaths, microPointComparer())
+
+		var minX []data.Micrometer
+		for _, path := range paths {
+			min, _ := path.Bounds()
+			minX = append(minX, min.X())
+		}
+		test.Equals(t, testCase.expectedMinX, minX)
+	}
+}
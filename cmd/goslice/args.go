@@ -0,0 +1,59 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"unicode"
+)
+
+// splitArgs splits the flags of a profile into separate arguments like a shell.
+// Arguments are separated by white space. Single and double quotes group white space into one argument,
+// e.g. "--start-gcode 'G28 X0'" results in the arguments "--start-gcode" and "G28 X0".
+// A backslash outside of single quotes escapes the next character.
+func splitArgs(s string) ([]string, error) {
+	var args []string
+	var current strings.Builder
+	inArg := false
+	var quote rune
+	escaped := false
+
+	for _, r := range s {
+		switch {
+		case escaped:
+			current.WriteRune(r)
+			escaped = false
+		case r == '\\' && quote != '\'':
+			escaped = true
+			inArg = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			} else {
+				current.WriteRune(r)
+			}
+		case r == '"' || r == '\'':
+			quote = r
+			inArg = true
+		case unicode.IsSpace(r):
+			if inArg {
+				args = append(args, current.String())
+				current.Reset()
+				inArg = false
+			}
+		default:
+			current.WriteRune(r)
+			inArg = true
+		}
+	}
+
+	if escaped {
+		return nil, errors.New("the flags end with an unfinished escape")
+	}
+	if quote != 0 {
+		return nil, errors.New("the flags contain an unclosed quote")
+	}
+	if inArg {
+		args = append(args, current.String())
+	}
+	return args, nil
+}
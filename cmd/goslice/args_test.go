@@ -0,0 +1,57 @@
+package main
+
+import (
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSplitArgs(t *testing.T) {
+	var testCases = map[string]struct {
+		flags    string
+		expected []string
+		err      bool
+	}{
+		"empty": {
+			flags: "",
+		},
+		"white space": {
+			flags:    "  --layer-thickness   200\t--support-enabled ",
+			expected: []string{"--layer-thickness", "200", "--support-enabled"},
+		},
+		"double quotes": {
+			flags:    `--start-gcode "G28 X0" -f`,
+			expected: []string{"--start-gcode", "G28 X0", "-f"},
+		},
+		"single quotes": {
+			flags:    `--start-gcode='G28 "X0"'`,
+			expected: []string{`--start-gcode=G28 "X0"`},
+		},
+		"empty quotes": {
+			flags:    `--name ""`,
+			expected: []string{"--name", ""},
+		},
+		"escaped space": {
+			flags:    `--output my\ model.gcode`,
+			expected: []string{"--output", "my model.gcode"},
+		},
+		"unclosed quote": {
+			flags: `--start-gcode "G28 X0`,
+			err:   true,
+		},
+		"unfinished escape": {
+			flags: `--output model\`,
+			err:   true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		args, err := splitArgs(testCase.flags)
+		if testCase.err {
+			test.Assert(t, err != nil, "an error should be returned")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, args)
+	}
+}
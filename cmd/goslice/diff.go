@@ -0,0 +1,206 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/data"
+	"io"
+	"log"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	flag "github.com/spf13/pflag"
+)
+
+const diffUsage = `Usage of goslice diff:
+  goslice diff STATS_A STATS_B
+    compares two stats files written using --stats
+  goslice diff MODEL_FILE --a "FLAGS" --b "FLAGS"
+    slices the model with both profiles and compares them
+    e.g. goslice diff model.stl --a "--layer-thickness 200" --b "--layer-thickness 100"
+`
+
+// discardWriter is a handler.GCodeWriter which does not write anything.
+type discardWriter struct{}
+
+func (discardWriter) Write(_ string, _ string) error {
+	return nil
+}
+
+// runDiff runs the diff command with the given args and prints the result to w.
+func runDiff(args []string, w io.Writer) error {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, diffUsage)
+		fs.PrintDefaults()
+	}
+	profileA := fs.String("a", "", "The flags of the first profile.")
+	profileB := fs.String("b", "", "The flags of the second profile.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	var a, b data.Stats
+	var err error
+	switch fs.NArg() {
+	case 1:
+		a, err = sliceStats(fs.Arg(0), *profileA)
+		if err != nil {
+			return fmt.Errorf("profile a: %w", err)
+		}
+		b, err = sliceStats(fs.Arg(0), *profileB)
+		if err != nil {
+			return fmt.Errorf("profile b: %w", err)
+		}
+	case 2:
+		a, err = readStatsFile(fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		b, err = readStatsFile(fs.Arg(1))
+		if err != nil {
+			return err
+		}
+	default:
+		fs.Usage()
+		return errors.New("either a model file or two stats files have to be specified")
+	}
+
+	return writeStatsDiff(w, a, b)
+}
+
+// sliceStats slices the model with the given flags and returns the stats without writing any GCode.
+func sliceStats(modelPath string, profile string) (data.Stats, error) {
+	args, err := splitArgs(profile)
+	if err != nil {
+		return data.Stats{}, err
+	}
+	options, err := data.ParseArgs(append(args, modelPath))
+	if err != nil {
+		return data.Stats{}, err
+	}
+	options.GoSlice.OutputFilePath = os.DevNull
+	options.GoSlice.StatsFilePath = ""
+	// keep stdout clean for the comparison
+	options.GoSlice.Logger = log.New(os.Stderr, "", 0)
+
+	p := goslice.NewGoSlice(options)
+	p.Writer = discardWriter{}
+	if err := p.Process(); err != nil {
+		return data.Stats{}, err
+	}
+
+	return p.Stats()
+}
+
+func readStatsFile(path string) (data.Stats, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return data.Stats{}, err
+	}
+	defer file.Close()
+
+	stats, err := data.ReadStats(file)
+	if err != nil {
+		return data.Stats{}, fmt.Errorf("%s: %w", path, err)
+	}
+	return stats, nil
+}
+
+// writeStatsDiff prints a table with the values of both stats and the deltas.
+func writeStatsDiff(w io.Writer, a, b data.Stats) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', tabwriter.AlignRight)
+
+	durationRow := func(name string, a, b float64) {
+		delta := b - a
+		sign := "+"
+		if delta < 0 {
+			sign = ""
+		}
+		_, _ = fmt.Fprintf(tw, "%s\t%v\t%v\t%s%v\t%s\t\n", name, seconds(a), seconds(b), sign, seconds(delta), percent(a, delta))
+	}
+	lengthRow := func(name string, a, b data.Millimeter) {
+		delta := float64(b - a)
+		_, _ = fmt.Fprintf(tw, "%s\t%0.2fmm\t%0.2fmm\t%+0.2fmm\t%s\t\n", name, a, b, delta, percent(float64(a), delta))
+	}
+	countRow := func(name string, a, b int) {
+		_, _ = fmt.Fprintf(tw, "%s\t%d\t%d\t%+d\t%s\t\n", name, a, b, b-a, percent(float64(a), float64(b-a)))
+	}
+
+	_, _ = fmt.Fprintf(tw, "\ta\tb\tdelta\t\t\n")
+	durationRow("print time", a.PrintTime, b.PrintTime)
+	lengthRow("filament", a.Filament, b.Filament)
+	durationRow("travel time", a.TravelTime, b.TravelTime)
+	lengthRow("travel distance", a.TravelDistance, b.TravelDistance)
+	countRow("retractions", a.Retractions, b.Retractions)
+	durationRow("retraction time", a.RetractionTime, b.RetractionTime)
+	countRow("tool changes", a.ToolChanges, b.ToolChanges)
+	durationRow("tool change time", a.ToolChangeTime, b.ToolChangeTime)
+	countRow("layers", a.Layers, b.Layers)
+	countRow("open edges", a.Model.OpenEdges, b.Model.OpenEdges)
+	countRow("t-vertices", a.Model.TVertices, b.Model.TVertices)
+	countRow("non-manifold edges", a.Model.NonManifoldEdges, b.Model.NonManifoldEdges)
+
+	for _, feature := range featuresOf(a, b) {
+		featureA, featureB := a.Features[feature], b.Features[feature]
+		durationRow(string(feature)+" time", featureA.PrintTime, featureB.PrintTime)
+		lengthRow(string(feature)+" filament", featureA.Filament, featureB.Filament)
+		countRow(string(feature)+" retractions", featureA.Retractions, featureB.Retractions)
+	}
+
+	return tw.Flush()
+}
+
+// featuresOf returns all features contained in any of the stats.
+// The known features are returned in their default order followed by unknown ones.
+func featuresOf(stats ...data.Stats) []data.Feature {
+	var features []data.Feature
+	contains := func(feature data.Feature) bool {
+		for _, s := range stats {
+			if _, ok := s.Features[feature]; ok {
+				return true
+			}
+		}
+		return false
+	}
+
+	for _, feature := range data.Features {
+		if contains(feature) {
+			features = append(features, feature)
+		}
+	}
+
+	for _, s := range stats {
+		for feature := range s.Features {
+			if !data.IsKnownFeature(string(feature)) && !containsFeature(features, feature) {
+				features = append(features, feature)
+			}
+		}
+	}
+
+	return features
+}
+
+func containsFeature(features []data.Feature, feature data.Feature) bool {
+	for _, f := range features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// seconds converts the seconds into a time.Duration rounded to seconds.
+func seconds(s float64) time.Duration {
+	return (time.Duration(s * float64(time.Second))).Round(time.Second)
+}
+
+// percent returns the delta in percent of the base value.
+func percent(base, delta float64) string {
+	if base == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%+0.1f%%", delta/base*100)
+}
@@ -13,6 +13,24 @@ import (
 var Version = "unknown development version"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "diff" {
+		err := runDiff(os.Args[2:], os.Stdout)
+		if err != nil {
+			fmt.Println("error while comparing:", err)
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
+	if len(os.Args) > 1 && os.Args[1] == "serve" {
+		err := runServe(os.Args[2:])
+		if err != nil {
+			fmt.Println("error while serving:", err)
+			os.Exit(2)
+		}
+		os.Exit(0)
+	}
+
 	o := data.ParseFlags()
 
 	if o.GoSlice.PrintVersion {
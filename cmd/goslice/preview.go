@@ -0,0 +1,131 @@
+package main
+
+// previewPage is the web page served by "goslice serve --preview".
+// It loads the tool paths (see data.ToolPathWriter) and draws the selected layer on a canvas.
+const previewPage = `<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>GoSlice preview</title>
+<style>
+  body { margin: 0; font-family: sans-serif; background: #202124; color: #e8eaed; display: flex; flex-direction: column; height: 100vh; }
+  header { display: flex; align-items: center; gap: 1em; padding: 0.5em 1em; flex-wrap: wrap; }
+  header input[type=range] { flex: 1; min-width: 200px; }
+  #legend span { display: inline-block; margin-right: 0.8em; }
+  #legend i { display: inline-block; width: 0.8em; height: 0.8em; margin-right: 0.3em; }
+  canvas { flex: 1; width: 100%; min-height: 0; }
+</style>
+</head>
+<body>
+<header>
+  <label for="layer">Layer</label>
+  <input id="layer" type="range" min="0" max="0" value="0" disabled>
+  <span id="info">loading...</span>
+  <label><input id="travel" type="checkbox"> travel</label>
+  <label><input id="below" type="checkbox" checked> layer below</label>
+  <a href="/model.gcode" download="model.gcode" style="color: #8ab4f8">gcode</a>
+</header>
+<div id="legend" style="padding: 0 1em 0.5em"></div>
+<canvas id="canvas"></canvas>
+<script>
+const colors = {
+  "outer-wall": "#ea4335", "inner-wall": "#fbbc04", "overhang-wall": "#ff6d01", "gap-fill": "#c58af9",
+  "top-skin": "#a142f4", "bottom-skin": "#24c1e0", "supported-bottom-skin": "#4ecde6",
+  "infill": "#e37400", "bridge": "#f538a0", "support": "#34a853", "support-interface": "#81c995",
+  "skirt": "#9aa0a6", "brim": "#bdc1c6", "ooze-shield": "#5f6368", "travel": "#4285f4"
+};
+const slider = document.getElementById("layer");
+const info = document.getElementById("info");
+const travel = document.getElementById("travel");
+const below = document.getElementById("below");
+const canvas = document.getElementById("canvas");
+let layers = [];
+let bounds = null;
+
+function colorOf(feature) {
+  return colors[feature] || "#ffffff";
+}
+
+function computeBounds() {
+  const b = {minX: Infinity, minY: Infinity, maxX: -Infinity, maxY: -Infinity};
+  for (const layer of layers) {
+    for (const path of layer.paths) {
+      if (path.feature === "travel") continue;
+      for (const p of path.points) {
+        b.minX = Math.min(b.minX, p.x); b.maxX = Math.max(b.maxX, p.x);
+        b.minY = Math.min(b.minY, p.y); b.maxY = Math.max(b.maxY, p.y);
+      }
+    }
+  }
+  return isFinite(b.minX) ? b : {minX: 0, minY: 0, maxX: 1, maxY: 1};
+}
+
+function drawLayer(ctx, layer, toScreen, faint) {
+  for (const path of layer.paths) {
+    const isTravel = path.feature === "travel";
+    if (isTravel && (faint || !travel.checked)) continue;
+    ctx.strokeStyle = faint ? "#3c4043" : colorOf(path.feature);
+    ctx.setLineDash(isTravel ? [4, 4] : []);
+    ctx.beginPath();
+    path.points.forEach((p, i) => {
+      const [x, y] = toScreen(p);
+      if (i === 0) ctx.moveTo(x, y); else ctx.lineTo(x, y);
+    });
+    ctx.stroke();
+  }
+}
+
+function draw() {
+  const dpr = window.devicePixelRatio || 1;
+  canvas.width = canvas.clientWidth * dpr;
+  canvas.height = canvas.clientHeight * dpr;
+  const ctx = canvas.getContext("2d");
+  ctx.clearRect(0, 0, canvas.width, canvas.height);
+  if (layers.length === 0) return;
+
+  const margin = 20 * dpr;
+  const scale = Math.min((canvas.width - 2 * margin) / (bounds.maxX - bounds.minX || 1),
+                         (canvas.height - 2 * margin) / (bounds.maxY - bounds.minY || 1));
+  const offsetX = (canvas.width - (bounds.maxX - bounds.minX) * scale) / 2;
+  const offsetY = (canvas.height - (bounds.maxY - bounds.minY) * scale) / 2;
+  const toScreen = p => [offsetX + (p.x - bounds.minX) * scale, canvas.height - offsetY - (p.y - bounds.minY) * scale];
+  ctx.lineWidth = Math.max(1, 0.4 * scale);
+  ctx.lineJoin = "round";
+
+  const index = Number(slider.value);
+  if (below.checked && index > 0) drawLayer(ctx, layers[index - 1], toScreen, true);
+  drawLayer(ctx, layers[index], toScreen, false);
+
+  const layer = layers[index];
+  const lastPath = layer.paths[layer.paths.length - 1];
+  const time = lastPath ? lastPath.points[lastPath.points.length - 1].time : 0;
+  info.textContent = "layer " + layer.layer + " at z " + layer.z.toFixed(2) + "mm, finished after " + new Date(time * 1000).toISOString().substr(11, 8);
+}
+
+function showLegend() {
+  const features = new Set();
+  layers.forEach(l => l.paths.forEach(p => features.add(p.feature)));
+  document.getElementById("legend").innerHTML = Array.from(features)
+    .map(f => '<span><i style="background:' + colorOf(f) + '"></i>' + f + '</span>').join("");
+}
+
+fetch("/toolpath.json")
+  .then(r => r.json())
+  .then(doc => {
+    layers = doc.layers;
+    bounds = computeBounds();
+    slider.max = Math.max(0, layers.length - 1);
+    slider.disabled = layers.length === 0;
+    showLegend();
+    draw();
+  })
+  .catch(err => info.textContent = "could not load the tool paths: " + err);
+
+slider.addEventListener("input", draw);
+travel.addEventListener("change", draw);
+below.addEventListener("change", draw);
+window.addEventListener("resize", draw);
+</script>
+</body>
+</html>
+`
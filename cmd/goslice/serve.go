@@ -0,0 +1,128 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/data"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"syscall"
+
+	flag "github.com/spf13/pflag"
+)
+
+const serveUsage = `Usage of goslice serve:
+  goslice serve MODEL_FILE [--profile "FLAGS"] [--preview] [--listen ADDRESS]
+    slices the model and serves the gcode at /model.gcode and the tool paths at /toolpath.json
+    e.g. goslice serve model.stl --preview --profile "--layer-thickness 0.1"
+`
+
+// runServe runs the serve command with the given args.
+// It slices the model once and serves the results until the server is stopped.
+func runServe(args []string) error {
+	fs := flag.NewFlagSet("serve", flag.ContinueOnError)
+	fs.Usage = func() {
+		_, _ = fmt.Fprint(os.Stderr, serveUsage)
+		fs.PrintDefaults()
+	}
+	profile := fs.String("profile", "", "The flags used to slice the model.")
+	preview := fs.Bool("preview", false, "Serve a web page at / which shows the tool paths layer by layer.")
+	listen := fs.String("listen", "localhost:8080", "The address the server listens on.")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() != 1 {
+		fs.Usage()
+		return errors.New("exactly one model file has to be specified")
+	}
+
+	dir, err := ioutil.TempDir("", "goslice-serve")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+
+	gcodePath := filepath.Join(dir, "model.gcode")
+	toolPathPath := filepath.Join(dir, "toolpath.json")
+	err = sliceForServe(fs.Arg(0), *profile, gcodePath, toolPathPath)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{
+		Addr:    *listen,
+		Handler: newServeMux(gcodePath, toolPathPath, *preview),
+	}
+
+	// the server is closed on an interrupt, so that the temporary directory is removed
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, os.Interrupt, syscall.SIGTERM)
+	defer signal.Stop(stop)
+	go func() {
+		<-stop
+		_ = server.Close()
+	}()
+
+	url := "http://" + *listen
+	if *preview {
+		fmt.Printf("Preview of %s at %s/\n", fs.Arg(0), url)
+	} else {
+		fmt.Printf("Serving %s at %s/model.gcode and %s/toolpath.json\n", fs.Arg(0), url, url)
+	}
+
+	err = server.ListenAndServe()
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// newServeMux returns the handler which serves the gcode and the tool paths at the given paths
+// and the preview page if preview is set.
+func newServeMux(gcodePath string, toolPathPath string, preview bool) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/model.gcode", serveFile(gcodePath, "text/plain; charset=utf-8"))
+	mux.HandleFunc("/toolpath.json", serveFile(toolPathPath, "application/json"))
+	if preview {
+		mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+			if r.URL.Path != "/" {
+				http.NotFound(w, r)
+				return
+			}
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			_, _ = w.Write([]byte(previewPage))
+		})
+	}
+	return mux
+}
+
+// sliceForServe slices the model with the given flags and writes the gcode and the tool paths to the given paths.
+func sliceForServe(modelPath string, profile string, gcodePath string, toolPathPath string) error {
+	args, err := splitArgs(profile)
+	if err != nil {
+		return err
+	}
+	options, err := data.ParseArgs(append(args, modelPath))
+	if err != nil {
+		return err
+	}
+	options.GoSlice.OutputFilePath = gcodePath
+	options.GoSlice.ToolPathFilePath = toolPathPath
+	options.GoSlice.Force = true
+	options.GoSlice.Logger = log.New(os.Stderr, "", 0)
+
+	return goslice.NewGoSlice(options).Process()
+}
+
+// serveFile returns a handler which serves the file with the given content type.
+func serveFile(path string, contentType string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", contentType)
+		http.ServeFile(w, r, path)
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"github.com/aligator/goslice/util/test"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestServeMux(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice-serve-test")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	gcodePath := filepath.Join(dir, "model.gcode")
+	toolPathPath := filepath.Join(dir, "toolpath.json")
+	test.Ok(t, ioutil.WriteFile(gcodePath, []byte("G28\n"), 0644))
+	test.Ok(t, ioutil.WriteFile(toolPathPath, []byte(`{"version":1,"layers":[]}`), 0644))
+
+	var testCases = map[string]struct {
+		path        string
+		preview     bool
+		status      int
+		contentType string
+		body        string
+	}{
+		"gcode": {
+			path:        "/model.gcode",
+			status:      http.StatusOK,
+			contentType: "text/plain; charset=utf-8",
+			body:        "G28\n",
+		},
+		"tool paths": {
+			path:        "/toolpath.json",
+			status:      http.StatusOK,
+			contentType: "application/json",
+			body:        `{"version":1,"layers":[]}`,
+		},
+		"preview": {
+			path:        "/",
+			preview:     true,
+			status:      http.StatusOK,
+			contentType: "text/html; charset=utf-8",
+			body:        previewPage,
+		},
+		"no preview": {
+			path:   "/",
+			status: http.StatusNotFound,
+		},
+		"unknown path": {
+			path:    "/unknown",
+			preview: true,
+			status:  http.StatusNotFound,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		recorder := httptest.NewRecorder()
+		newServeMux(gcodePath, toolPathPath, testCase.preview).ServeHTTP(recorder, httptest.NewRequest(http.MethodGet, testCase.path, nil))
+
+		test.Equals(t, testCase.status, recorder.Code)
+		if testCase.status != http.StatusOK {
+			continue
+		}
+		test.Equals(t, testCase.contentType, recorder.Header().Get("Content-Type"))
+		test.Equals(t, testCase.body, recorder.Body.String())
+	}
+}
@@ -1,26 +1,101 @@
 // Package data holds basic data structures and interfaces used by GoSlice.
+//
+// The geometry types are provided by the package geometry.
+// They are available in this package as aliases, so both can be used interchangeably.
 package data
 
 import (
-	"math"
+	"github.com/aligator/goslice/geometry"
 )
 
-// Millimeter represents a value in mm
-// It should not be used for calculations, convert to micrometer.
-// using ToMicrometer() before calculating to prevent rounding
-// errors because of the float-type.
-type Millimeter float32
+// Millimeter represents a value in mm (see geometry.Millimeter).
+type Millimeter = geometry.Millimeter
 
-func (m Millimeter) ToMicrometer() Micrometer {
-	return Micrometer(math.RoundToEven(float64(m * 1000)))
+// Micrometer represents a value in 0.001 mm (see geometry.Micrometer).
+type Micrometer = geometry.Micrometer
+
+// MicroVec3 represents a point in 3d space (see geometry.MicroVec3).
+type MicroVec3 = geometry.MicroVec3
+
+// MicroPoint represents a point in 2d space (see geometry.MicroPoint).
+type MicroPoint = geometry.MicroPoint
+
+// Path is a simple list of points (see geometry.Path).
+type Path = geometry.Path
+
+// Paths is a list of Path (see geometry.Paths).
+type Paths = geometry.Paths
+
+// LayerPart represents a polygon with holes (see geometry.LayerPart).
+type LayerPart = geometry.LayerPart
+
+// MaxMicrometer is the biggest possible Micrometer value (see geometry.MaxMicrometer).
+const MaxMicrometer = geometry.MaxMicrometer
+
+// MinMicrometer is the smallest possible Micrometer value (see geometry.MinMicrometer).
+const MinMicrometer = geometry.MinMicrometer
+
+// NewMicroVec3 returns a new MicroVec3 (see geometry.NewMicroVec3).
+func NewMicroVec3(x Micrometer, y Micrometer, z Micrometer) MicroVec3 {
+	return geometry.NewMicroVec3(x, y, z)
+}
+
+// NewMicroPoint returns a new MicroPoint (see geometry.NewMicroPoint).
+func NewMicroPoint(x, y Micrometer) MicroPoint {
+	return geometry.NewMicroPoint(x, y)
+}
+
+// NewBasicLayerPart returns a new, simple LayerPart (see geometry.NewBasicLayerPart).
+func NewBasicLayerPart(outline Path, holes Paths) LayerPart {
+	return geometry.NewBasicLayerPart(outline, holes)
+}
+
+// Max returns the bigger value (see geometry.Max).
+func Max(a, b Micrometer) Micrometer {
+	return geometry.Max(a, b)
 }
 
-// Micrometer represents a value in 0.001 mm
-type Micrometer int64
+// Min returns the smaller value (see geometry.Min).
+func Min(a, b Micrometer) Micrometer {
+	return geometry.Min(a, b)
+}
+
+// DotProduct calculates the dot product of two points (see geometry.DotProduct).
+func DotProduct(a, b MicroPoint) Micrometer {
+	return geometry.DotProduct(a, b)
+}
+
+// PerpendicularDistance2 calculates the (perpendicular Distance)^2 of a point to a line (see geometry.PerpendicularDistance2).
+func PerpendicularDistance2(a, b, point MicroPoint) Micrometer {
+	return geometry.PerpendicularDistance2(a, b, point)
+}
+
+// DouglasPeucker simplifies the path (see geometry.DouglasPeucker).
+func DouglasPeucker(points Path, epsilon Micrometer) Path {
+	return geometry.DouglasPeucker(points, epsilon)
+}
 
-const MaxMicrometer = Micrometer(math.MaxInt64)
-const MinMicrometer = Micrometer(math.MinInt64)
+// ToRadians converts degree to radians (see geometry.ToRadians).
+func ToRadians(angle float64) float64 {
+	return geometry.ToRadians(angle)
+}
+
+// ProjectOnSegment returns the point of the segment from a to b which is nearest to p (see geometry.ProjectOnSegment).
+func ProjectOnSegment(p, a, b MicroPoint) MicroPoint {
+	return geometry.ProjectOnSegment(p, a, b)
+}
+
+// PartContains returns true if the point lies inside of the part (see geometry.PartContains).
+func PartContains(part LayerPart, point MicroPoint) bool {
+	return geometry.PartContains(part, point)
+}
+
+// WalkContour returns the points along the closed contour between two points on it (see geometry.WalkContour).
+func WalkContour(contour Path, fromSegment int, from MicroPoint, toSegment int, to MicroPoint, forward bool) Path {
+	return geometry.WalkContour(contour, fromSegment, from, toSegment, to, forward)
+}
 
-func (m Micrometer) ToMillimeter() Millimeter {
-	return Millimeter(float64(m) / 1000)
+// ShortestContourWalk returns the shorter walk along the closed contour between two points on it (see geometry.ShortestContourWalk).
+func ShortestContourWalk(contour Path, fromSegment int, from MicroPoint, toSegment int, to MicroPoint) Path {
+	return geometry.ShortestContourWalk(contour, fromSegment, from, toSegment, to)
 }
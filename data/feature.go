@@ -0,0 +1,56 @@
+// This file provides the types of the features which can be printed.
+
+package data
+
+// Feature identifies the type of the lines which are printed, e.g. the outer wall or the support.
+// It can be used to apply settings only to specific parts of the print.
+type Feature string
+
+const (
+	FeatureOuterWall        Feature = "outer-wall"
+	FeatureInnerWall        Feature = "inner-wall"
+	FeatureOverhangWall     Feature = "overhang-wall"
+	FeatureGapFill          Feature = "gap-fill"
+	FeatureTopSkin          Feature = "top-skin"
+	FeatureBottomSkin       Feature = "bottom-skin"
+	FeatureSupportedBottom  Feature = "supported-bottom-skin"
+	FeatureInfill           Feature = "infill"
+	FeatureBridge           Feature = "bridge"
+	FeatureSupport          Feature = "support"
+	FeatureSupportInterface Feature = "support-interface"
+	FeatureSkirt            Feature = "skirt"
+	FeatureBrim             Feature = "brim"
+	FeatureOozeShield       Feature = "ooze-shield"
+)
+
+// FeatureTravel is the role of moves which do not extrude, e.g. in a ToolPath.
+// It is no printed feature and therefore not part of Features.
+const FeatureTravel Feature = "travel"
+
+// Features contains all known features.
+var Features = []Feature{
+	FeatureOuterWall,
+	FeatureInnerWall,
+	FeatureOverhangWall,
+	FeatureGapFill,
+	FeatureTopSkin,
+	FeatureBottomSkin,
+	FeatureSupportedBottom,
+	FeatureInfill,
+	FeatureBridge,
+	FeatureSupport,
+	FeatureSupportInterface,
+	FeatureSkirt,
+	FeatureBrim,
+	FeatureOozeShield,
+}
+
+// IsKnownFeature returns true if the given name is one of the known Features.
+func IsKnownFeature(name string) bool {
+	for _, feature := range Features {
+		if string(feature) == name {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,180 @@
+// This file provides the calculation of the z and thickness of each layer.
+
+package data
+
+// layerStep is a sequence of layers with the same thickness.
+type layerStep struct {
+	// first is the number of the first layer of the step.
+	first int
+	// bottom is the z at which the first layer of the step starts.
+	bottom    Micrometer
+	thickness Micrometer
+}
+
+// LayerHeights calculates the z and the thickness of the layers.
+// The first layer uses the initial layer thickness, all other layers the layer thickness
+// or the thickness of the layer thickness range in which they start (see PrintOptions.LayerThicknessRanges).
+type LayerHeights struct {
+	initialThickness Micrometer
+	thickness        Micrometer
+
+	// steps is only set if there are layer thickness ranges.
+	steps []layerStep
+}
+
+// LayerHeights returns the LayerHeights based on the print options.
+func (p PrintOptions) LayerHeights() LayerHeights {
+	h := LayerHeights{
+		initialThickness: p.InitialLayerThickness,
+		thickness:        p.LayerThickness,
+	}
+	if len(p.LayerThicknessRanges.Ranges) == 0 {
+		return h
+	}
+
+	next := 1
+	bottom := p.InitialLayerThickness
+	// add adds a step with the given thickness which contains all layers starting below end.
+	add := func(thickness, end Micrometer) {
+		if bottom >= end || thickness <= 0 {
+			return
+		}
+		h.steps = append(h.steps, layerStep{first: next, bottom: bottom, thickness: thickness})
+		count := (end - bottom + thickness - 1) / thickness
+		next += int(count)
+		bottom += count * thickness
+	}
+
+	for _, r := range p.LayerThicknessRanges.Ranges {
+		add(p.LayerThickness, r.From)
+		add(r.LayerThickness, r.To)
+	}
+	h.steps = append(h.steps, layerStep{first: next, bottom: bottom, thickness: p.LayerThickness})
+	return h
+}
+
+// step returns the step which contains the layer with the given number (which has to be bigger than 0).
+func (h LayerHeights) step(layerNr int) layerStep {
+	i := len(h.steps) - 1
+	for i > 0 && h.steps[i].first > layerNr {
+		i--
+	}
+	return h.steps[i]
+}
+
+// Z returns the z of the top of the layer with the given number.
+func (h LayerHeights) Z(layerNr int) Micrometer {
+	if h.steps == nil || layerNr <= 0 {
+		return Micrometer(layerNr)*h.thickness + h.initialThickness
+	}
+
+	step := h.step(layerNr)
+	return step.bottom + Micrometer(layerNr-step.first+1)*step.thickness
+}
+
+// Thickness returns the thickness of the layer with the given number.
+func (h LayerHeights) Thickness(layerNr int) Micrometer {
+	if layerNr == 0 {
+		return h.initialThickness
+	}
+	if h.steps == nil {
+		return h.thickness
+	}
+	return h.step(layerNr).thickness
+}
+
+// LayersAbove returns the amount of layers directly above the layer with the given number
+// which are needed to reach at least the given distance above its top.
+func (h LayerHeights) LayersAbove(layerNr int, distance Micrometer) int {
+	count := 0
+	for h.Z(layerNr+count)-h.Z(layerNr) < distance {
+		count++
+	}
+	return count
+}
+
+// LayersBelow returns the amount of layers directly below the layer with the given number
+// which are needed to reach at least the given distance below its bottom.
+// If the layers below are not thick enough, layerNr+1 is returned as the distance reaches below the bed.
+func (h LayerHeights) LayersBelow(layerNr int, distance Micrometer) int {
+	bottom := h.Z(layerNr) - h.Thickness(layerNr)
+	count := 0
+	for bottom-(h.Z(layerNr-count)-h.Thickness(layerNr-count)) < distance {
+		if count == layerNr {
+			return layerNr + 1
+		}
+		count++
+	}
+	return count
+}
+
+// MaxLayers returns the max amount of layers which are needed to reach the given distance anywhere in the print,
+// which are the layers of the smallest thickness.
+// It can be used if the layers are not known yet, e.g. to calculate the context of a modifier.
+func (h LayerHeights) MaxLayers(distance Micrometer) int {
+	thickness := Min(h.initialThickness, h.thickness)
+	for _, step := range h.steps {
+		thickness = Min(thickness, step.thickness)
+	}
+	if distance <= 0 || thickness <= 0 {
+		return 0
+	}
+	return int((distance + thickness - 1) / thickness)
+}
+
+// Count returns the amount of layers needed for a model with the given height.
+func (h LayerHeights) Count(height Micrometer) int {
+	if h.steps == nil {
+		return int((height-h.initialThickness)/h.thickness + 1)
+	}
+
+	if height < h.initialThickness {
+		return 0
+	}
+
+	count := 1
+	for i, step := range h.steps {
+		if height < step.bottom+step.thickness {
+			break
+		}
+
+		layers := int((height - step.bottom) / step.thickness)
+		if i < len(h.steps)-1 && layers > h.steps[i+1].first-step.first {
+			layers = h.steps[i+1].first - step.first
+		}
+		count = step.first + layers
+	}
+	return count
+}
+
+// Range returns the numbers of the first and the last layer (both inclusive) whose z may lie between minZ and maxZ.
+func (h LayerHeights) Range(minZ, maxZ Micrometer) (first, last int) {
+	if h.steps == nil {
+		return int((minZ - h.initialThickness) / h.thickness), int((maxZ - h.initialThickness) / h.thickness)
+	}
+
+	return h.Count(minZ - 1), h.Count(maxZ) - 1
+}
+
+// Window returns the numbers of the first and the last layer (both inclusive) of layerCount layers
+// whose z lies between from and to. If to is 0, the window has no upper limit.
+// If no layer lies in between, last is smaller than first.
+func (h LayerHeights) Window(from, to Micrometer, layerCount int) (first, last int) {
+	for first < layerCount && h.Z(first) < from {
+		first++
+	}
+
+	last = layerCount - 1
+	if to > 0 {
+		for last >= first && h.Z(last) > to {
+			last--
+		}
+	}
+	return first, last
+}
+
+// PrintedLayers returns the numbers of the first and the last layer (both inclusive) of layerCount layers
+// which are printed based on SlicingOptions.SliceFrom and SlicingOptions.SliceTo.
+func (o Options) PrintedLayers(layerCount int) (first, last int) {
+	return o.Print.LayerHeights().Window(o.Slicing.SliceFrom.ToMicrometer(), o.Slicing.SliceTo.ToMicrometer(), layerCount)
+}
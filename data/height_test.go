@@ -0,0 +1,157 @@
+package data_test
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestLayerHeights(t *testing.T) {
+	var testCases = map[string]struct {
+		ranges            []data.LayerThicknessRange
+		expectedZ         []data.Micrometer
+		expectedThickness []data.Micrometer
+		height            data.Micrometer
+		expectedCount     int
+	}{
+		"no ranges": {
+			expectedZ:         []data.Micrometer{200, 400, 600, 800},
+			expectedThickness: []data.Micrometer{200, 200, 200, 200},
+			height:            700,
+			expectedCount:     3,
+		},
+		"thinner range": {
+			ranges: []data.LayerThicknessRange{
+				{From: 400, To: 700, LayerThickness: 100},
+			},
+			expectedZ:         []data.Micrometer{200, 400, 500, 600, 700, 900},
+			expectedThickness: []data.Micrometer{200, 200, 100, 100, 100, 200},
+			height:            800,
+			expectedCount:     5,
+		},
+		"range exceeding its end": {
+			ranges: []data.LayerThicknessRange{
+				{From: 0, To: 500, LayerThickness: 300},
+			},
+			expectedZ:         []data.Micrometer{200, 500, 700, 900},
+			expectedThickness: []data.Micrometer{200, 300, 200, 200},
+			height:            900,
+			expectedCount:     4,
+		},
+		"several ranges": {
+			ranges: []data.LayerThicknessRange{
+				{From: 200, To: 400, LayerThickness: 100},
+				{From: 600, To: 900, LayerThickness: 300},
+			},
+			expectedZ:         []data.Micrometer{200, 300, 400, 600, 900, 1100},
+			expectedThickness: []data.Micrometer{200, 100, 100, 200, 300, 200},
+			height:            1000,
+			expectedCount:     5,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.LayerThicknessRanges.Ranges = testCase.ranges
+		heights := options.Print.LayerHeights()
+
+		for layerNr := range testCase.expectedZ {
+			test.Equals(t, testCase.expectedZ[layerNr], heights.Z(layerNr))
+			test.Equals(t, testCase.expectedThickness[layerNr], heights.Thickness(layerNr))
+		}
+		test.Equals(t, testCase.expectedCount, heights.Count(testCase.height))
+	}
+}
+
+func TestLayerHeightsLayersAboveAndBelow(t *testing.T) {
+	var testCases = map[string]struct {
+		ranges        []data.LayerThicknessRange
+		layerNr       int
+		distance      data.Micrometer
+		expectedAbove int
+		expectedBelow int
+		expectedMax   int
+	}{
+		"no ranges": {
+			layerNr:       3,
+			distance:      300,
+			expectedAbove: 2,
+			expectedBelow: 2,
+			expectedMax:   2,
+		},
+		"no distance": {
+			layerNr:       3,
+			distance:      0,
+			expectedAbove: 0,
+			expectedBelow: 0,
+			expectedMax:   0,
+		},
+		"below the bed": {
+			layerNr:       2,
+			distance:      500,
+			expectedAbove: 3,
+			expectedBelow: 3,
+			expectedMax:   3,
+		},
+		"thinner range": {
+			ranges: []data.LayerThicknessRange{
+				{From: 400, To: 700, LayerThickness: 100},
+			},
+			// z: 200, 400, 500, 600, 700, 900, 1100
+			layerNr:       4,
+			distance:      300,
+			expectedAbove: 2,
+			expectedBelow: 3,
+			expectedMax:   3,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.LayerThicknessRanges.Ranges = testCase.ranges
+		heights := options.Print.LayerHeights()
+
+		test.Equals(t, testCase.expectedAbove, heights.LayersAbove(testCase.layerNr, testCase.distance))
+		test.Equals(t, testCase.expectedBelow, heights.LayersBelow(testCase.layerNr, testCase.distance))
+		test.Equals(t, testCase.expectedMax, heights.MaxLayers(testCase.distance))
+	}
+}
+
+func TestLayerHeightsWindow(t *testing.T) {
+	var testCases = map[string]struct {
+		from, to      data.Micrometer
+		expectedFirst int
+		expectedLast  int
+	}{
+		"all layers": {
+			expectedFirst: 0,
+			expectedLast:  9,
+		},
+		"from": {
+			from:          700,
+			expectedFirst: 3,
+			expectedLast:  9,
+		},
+		"from and to": {
+			from:          600,
+			to:            1000,
+			expectedFirst: 2,
+			expectedLast:  4,
+		},
+		"above the model": {
+			from:          3000,
+			expectedFirst: 10,
+			expectedLast:  9,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		first, last := options.Print.LayerHeights().Window(testCase.from, testCase.to, 10)
+		test.Equals(t, testCase.expectedFirst, first)
+		test.Equals(t, testCase.expectedLast, last)
+	}
+}
@@ -0,0 +1,168 @@
+// This file provides the overrides of settings for ranges of layers.
+
+package data
+
+import (
+	"fmt"
+	"gopkg.in/yaml.v2"
+	"io"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// layerOverrideSettings contains the flag names of the settings which can be overridden for ranges of layers.
+// Only settings which are read while the gcode of a layer is generated can change from layer to layer.
+// All others, e.g. the inset count or the layer thickness, are used by the modifiers or for the whole print.
+var layerOverrideSettings = []string{
+	"bridge-speed",
+	"fan-speed",
+	"infill-pattern",
+	"infill-percent",
+	"infill-rotation-degree",
+	"infill-zig-zag",
+	"layer-speed",
+	"outer-perimeter-speed",
+	"overhang-perimeter-speed",
+	"skin-zig-zag",
+}
+
+// LayerSettings contains the settings overridden for one layer as flag args, e.g. "--infill-percent=80".
+// The layer override modifier adds them as layer attribute and the gcode generator applies them
+// to the options used to render the layer.
+type LayerSettings []string
+
+// OverriddenSettings returns the settings overridden for the layer or nil if the layer uses the global settings.
+func OverriddenSettings(layer PartitionedLayer) LayerSettings {
+	for _, attribute := range layer.Attributes() {
+		if settings, ok := attribute.(LayerSettings); ok {
+			return settings
+		}
+	}
+
+	return nil
+}
+
+// LayerOverride overrides settings for a range of layers.
+// The settings are given as args in the same format as the command line flags, e.g. "--infill-percent=80",
+// and are applied to the options using Options.Override.
+type LayerOverride struct {
+	// From is the first layer of the range.
+	From int
+
+	// To is the last layer of the range (inclusive). -1 means that the range ends at the last layer.
+	To int
+
+	// Args contains the settings as flag args.
+	Args LayerSettings
+}
+
+// Contains returns true if the layer lies inside the range of the override.
+func (l LayerOverride) Contains(layerNr int) bool {
+	return layerNr >= l.From && (l.To < 0 || layerNr <= l.To)
+}
+
+// layerOverrideEntry is one entry of a layer overrides file.
+type layerOverrideEntry struct {
+	Layers   string            `yaml:"layers"`
+	Settings map[string]string `yaml:"settings"`
+}
+
+// ReadLayerOverrides reads layer overrides from a yaml file in the following format:
+//
+//	# overrides.yaml
+//	- layers: 30-40
+//	  settings:
+//	    infill-percent: 80
+//	    fan-speed: 0=255
+//	- layers: 100-
+//	  settings:
+//	    layer-speed: 30
+//
+// The layers are given as a single layer, a range including both ends or an open range up to the last layer.
+// The settings use the names and the formats of the command line flags. If the ranges overlap, later entries win.
+// As the fan speed of a layer is the one set last at or below it, "0=255" sets the fan speed 255 for the whole range.
+// Only the settings used while the gcode is generated can be overridden (see layerOverrideSettings),
+// other settings, unknown settings and invalid values result in an error.
+func ReadLayerOverrides(r io.Reader) ([]LayerOverride, error) {
+	var entries []layerOverrideEntry
+	decoder := yaml.NewDecoder(r)
+	decoder.SetStrict(true)
+	if err := decoder.Decode(&entries); err != nil && err != io.EOF {
+		return nil, fmt.Errorf("could not read the layer overrides: %w", err)
+	}
+
+	overrides := make([]LayerOverride, 0, len(entries))
+	for _, entry := range entries {
+		override, err := parseLayerRange(entry.Layers)
+		if err != nil {
+			return nil, err
+		}
+
+		names := make([]string, 0, len(entry.Settings))
+		for name := range entry.Settings {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !canOverride(name) {
+				return nil, fmt.Errorf("the setting %s of the layers %s cannot be overridden for ranges of layers, only %s can", name, entry.Layers, strings.Join(layerOverrideSettings, ", "))
+			}
+			override.Args = append(override.Args, fmt.Sprintf("--%s=%s", name, entry.Settings[name]))
+		}
+
+		// check the settings early, so that errors point to the file
+		if _, err := DefaultOptions().Override(override.Args); err != nil {
+			return nil, fmt.Errorf("invalid settings for the layers %s: %w", entry.Layers, err)
+		}
+
+		overrides = append(overrides, override)
+	}
+
+	return overrides, nil
+}
+
+// LoadLayerOverrides reads the layer overrides from the file at the given path (see ReadLayerOverrides).
+func LoadLayerOverrides(path string) ([]LayerOverride, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	return ReadLayerOverrides(file)
+}
+
+// canOverride returns true if the setting with the given flag name can be overridden for ranges of layers.
+func canOverride(name string) bool {
+	for _, setting := range layerOverrideSettings {
+		if setting == name {
+			return true
+		}
+	}
+	return false
+}
+
+// parseLayerRange parses a layer range in the format "from-to", "from-" or "layer".
+func parseLayerRange(s string) (LayerOverride, error) {
+	invalid := fmt.Errorf("the layer range %q has to be in the format from-to, from- or layer", s)
+
+	parts := strings.SplitN(strings.TrimSpace(s), "-", 2)
+	from, err := strconv.Atoi(strings.TrimSpace(parts[0]))
+	if err != nil || from < 0 {
+		return LayerOverride{}, invalid
+	}
+	if len(parts) == 1 {
+		return LayerOverride{From: from, To: from}, nil
+	}
+
+	if strings.TrimSpace(parts[1]) == "" {
+		return LayerOverride{From: from, To: -1}, nil
+	}
+	to, err := strconv.Atoi(strings.TrimSpace(parts[1]))
+	if err != nil || to < from {
+		return LayerOverride{}, invalid
+	}
+	return LayerOverride{From: from, To: to}, nil
+}
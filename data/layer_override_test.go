@@ -0,0 +1,132 @@
+package data_test
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestReadLayerOverrides(t *testing.T) {
+	var tests = map[string]struct {
+		file          string
+		expected      []data.LayerOverride
+		expectedError string
+	}{
+		"empty": {
+			file:     "",
+			expected: []data.LayerOverride{},
+		},
+		"ranges": {
+			file: "- layers: 30-40\n" +
+				"  settings:\n" +
+				"    infill-percent: 80\n" +
+				"    fan-speed: 0=255\n" +
+				"- layers: 100-\n" +
+				"  settings:\n" +
+				"    layer-speed: 30\n" +
+				"- layers: 5\n" +
+				"  settings:\n" +
+				"    infill-pattern: gyroid\n",
+			expected: []data.LayerOverride{
+				{From: 30, To: 40, Args: data.LayerSettings{"--fan-speed=0=255", "--infill-percent=80"}},
+				{From: 100, To: -1, Args: data.LayerSettings{"--layer-speed=30"}},
+				{From: 5, To: 5, Args: data.LayerSettings{"--infill-pattern=gyroid"}},
+			},
+		},
+		"invalid range": {
+			file: "- layers: 40-30\n" +
+				"  settings:\n" +
+				"    infill-percent: 80\n",
+			expectedError: "the layer range \"40-30\" has to be in the format",
+		},
+		"unknown setting": {
+			file: "- layers: 1-2\n" +
+				"  settings:\n" +
+				"    infill-percentage: 80\n",
+			expectedError: "the setting infill-percentage of the layers 1-2 cannot be overridden",
+		},
+		"modifier setting": {
+			file: "- layers: 1-2\n" +
+				"  settings:\n" +
+				"    inset-count: 3\n",
+			expectedError: "the setting inset-count of the layers 1-2 cannot be overridden",
+		},
+		"layer thickness": {
+			file: "- layers: 1-2\n" +
+				"  settings:\n" +
+				"    layer-thickness: 100\n",
+			expectedError: "the setting layer-thickness of the layers 1-2 cannot be overridden",
+		},
+		"invalid value": {
+			file: "- layers: 1-2\n" +
+				"  settings:\n" +
+				"    infill-percent: much\n",
+			expectedError: "invalid settings for the layers 1-2",
+		},
+		"unknown key": {
+			file: "- layer: 1-2\n" +
+				"  settings:\n" +
+				"    infill-percent: 80\n",
+			expectedError: "could not read the layer overrides",
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Log("testCase:", testName)
+		overrides, err := data.ReadLayerOverrides(strings.NewReader(testCase.file))
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "expected error %q but got %v", testCase.expectedError, err)
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, overrides)
+	}
+}
+
+func TestLayerOverrideContains(t *testing.T) {
+	test.Assert(t, data.LayerOverride{From: 3, To: 5}.Contains(3), "the first layer is contained")
+	test.Assert(t, data.LayerOverride{From: 3, To: 5}.Contains(5), "the last layer is contained")
+	test.Assert(t, !data.LayerOverride{From: 3, To: 5}.Contains(6), "layers above are not contained")
+	test.Assert(t, data.LayerOverride{From: 3, To: -1}.Contains(1000), "open ranges contain all layers above")
+}
+
+// attributedLayer is a layer with the given attributes.
+type attributedLayer struct {
+	data.PartitionedLayer
+	attributes map[string]interface{}
+}
+
+func (l attributedLayer) Attributes() map[string]interface{} {
+	return l.attributes
+}
+
+func TestOverriddenSettings(t *testing.T) {
+	var tests = map[string]struct {
+		layer    data.PartitionedLayer
+		expected data.LayerSettings
+	}{
+		"without attributes": {
+			layer: data.NewPartitionedLayer(nil),
+		},
+		"other attributes": {
+			layer: attributedLayer{
+				PartitionedLayer: data.NewPartitionedLayer(nil),
+				attributes:       map[string]interface{}{"args": []string{"--layer-speed=30"}},
+			},
+		},
+		"overridden settings": {
+			layer: attributedLayer{
+				PartitionedLayer: data.NewPartitionedLayer(nil),
+				attributes:       map[string]interface{}{"layerOverride": data.LayerSettings{"--layer-speed=30"}},
+			},
+			expected: data.LayerSettings{"--layer-speed=30"},
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, data.OverriddenSettings(testCase.layer))
+	}
+}
@@ -2,6 +2,8 @@
 
 package data
 
+import "sort"
+
 // Face represents a triangle face which is defined by three vectors.
 type Face interface {
 	Points() [3]MicroVec3
@@ -15,6 +17,34 @@ type Model interface {
 	Max() MicroVec3
 }
 
+// LazyModel is a Model which reads its faces only when they are requested, e.g. from a temporary file.
+// As Face cannot return an error, a face which cannot be read is empty
+// and the first error which occurred is returned by Err.
+type LazyModel interface {
+	Model
+
+	// Err returns the first error which occurred while reading a face, nil if all faces could be read.
+	Err() error
+}
+
+// ModelErr returns the error of the model if it is a LazyModel which could not read all requested faces.
+// The models of a ModelGroup are checked as well.
+func ModelErr(m Model) error {
+	if lazy, ok := m.(LazyModel); ok {
+		if err := lazy.Err(); err != nil {
+			return err
+		}
+	}
+	if group, ok := m.(ModelGroup); ok {
+		for _, model := range group.Models() {
+			if err := ModelErr(model); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
 // OptimizedFace represents a full but optimized face.
 // It additionally provides indices of touching faces.
 // The corresponding other faces can be found in a matching OptimizedModelInstance.
@@ -22,6 +52,12 @@ type Model interface {
 type OptimizedFace interface {
 	Face
 	TouchingFaceIndices() [3]int
+
+	// IndirectTouchingFaceIndices returns the touching faces which do not share exactly one edge with the face.
+	// These are faces at T-vertices, where an edge of the face is split into several edges of other faces,
+	// and further faces of edges which are used by more than two faces.
+	IndirectTouchingFaceIndices() []int
+
 	MinZ() Micrometer
 	MaxZ() Micrometer
 }
@@ -35,5 +71,204 @@ type OptimizedModel interface {
 	Size() MicroVec3
 
 	OptimizedFace(index int) OptimizedFace
+
+	// RaycastZ casts a vertical ray through the given point downwards, starting at maxZ.
+	// It returns the Z of the first hit with the surface of the model.
+	// If the ray does not hit the model, false is returned.
+	RaycastZ(p MicroPoint, maxZ Micrometer) (Micrometer, bool)
 	SaveDebugSTL(filename string) error
 }
+
+// ModelReport contains the errors of the mesh found while optimizing a model.
+type ModelReport struct {
+	// OpenEdges is the number of face edges which do not touch another face.
+	OpenEdges int `json:"openEdges"`
+
+	// TVertices is the number of points which lie on the edge of another face.
+	TVertices int `json:"tVertices"`
+
+	// SplitEdges is the number of open edges which are connected to faces at T-vertices.
+	SplitEdges int `json:"splitEdges"`
+
+	// NonManifoldEdges is the number of edges which are used by more than two faces.
+	NonManifoldEdges int `json:"nonManifoldEdges"`
+}
+
+// ReportedModel is an OptimizedModel which provides the errors of the mesh found while optimizing it.
+type ReportedModel interface {
+	OptimizedModel
+
+	// Report returns the errors of the mesh.
+	Report() ModelReport
+}
+
+// ZIndexedModel is an OptimizedModel which can find the faces within a Z range without checking all faces.
+// Slicers can use it to slice only a range of layers of big models efficiently.
+type ZIndexedModel interface {
+	OptimizedModel
+
+	// FacesInZRange returns the sorted indices of all faces which overlap the range from minZ to maxZ (both inclusive).
+	FacesInZRange(minZ, maxZ Micrometer) []int
+}
+
+// ZRangeModel is an OptimizedModel which does not keep its faces in memory, e.g. for very big meshes.
+// Its faces are not connected to each other, instead ZRange provides the optimized faces of a Z range.
+// Slicers have to slice the models returned by ZRange.
+type ZRangeModel interface {
+	OptimizedModel
+
+	// ZRange loads and optimizes all faces which overlap the range from minZ to maxZ (both inclusive).
+	// The returned model has the size of the whole model, so that it is sliced into the same layers.
+	ZRange(minZ, maxZ Micrometer) (OptimizedModel, error)
+}
+
+// ModelGroup is a Model which consists of several separate models,
+// e.g. if several files are sliced in one job.
+// As Model it provides the faces of all models in their original positions.
+type ModelGroup interface {
+	Model
+
+	// Models returns the separate models.
+	Models() []Model
+}
+
+// ModelCopies is a ModelGroup which contains the same model several times, e.g. if the same file is sliced several times.
+// It allows to slice the model only once and to print the layers at the position of each copy.
+type ModelCopies interface {
+	ModelGroup
+
+	// Original returns the model which is copied.
+	Original() Model
+}
+
+// InstancedModel is an OptimizedModel which is printed several times.
+// The layers are sliced only once and each instance is moved by its offset when the gcode is generated.
+type InstancedModel interface {
+	OptimizedModel
+
+	// Instances returns the offsets of all instances.
+	// The first instance is the model itself and has no offset.
+	Instances() []MicroPoint
+}
+
+// SequentialModel is an OptimizedModel which consists of several objects which are printed one after the other.
+// Each object is printed completely before the next one starts.
+type SequentialModel interface {
+	OptimizedModel
+
+	// Objects returns the objects as separate models at their position in the whole model
+	// in the order in which they are printed.
+	// It returns nil if the objects are not printed one after the other.
+	Objects() []OptimizedModel
+}
+
+// MaterialModel is an OptimizedModel which consists of bodies printed by different extruders.
+// The layers of all extruders are printed together, each body with its extruder.
+type MaterialModel interface {
+	OptimizedModel
+
+	// Materials returns for each extruder a model which contains all bodies printed by it
+	// at their position in the whole model. The index is the number of the extruder,
+	// the models of unused extruders are nil.
+	// It returns nil if all bodies are printed by the first extruder.
+	Materials() []OptimizedModel
+}
+
+type modelGroup struct {
+	models []Model
+
+	// offsets contains the index of the first face of each model.
+	offsets []int
+}
+
+// NewModelGroup combines the given models to one ModelGroup.
+func NewModelGroup(models ...Model) ModelGroup {
+	g := &modelGroup{
+		models:  models,
+		offsets: make([]int, len(models)),
+	}
+
+	count := 0
+	for i, m := range models {
+		g.offsets[i] = count
+		count += m.FaceCount()
+	}
+
+	return g
+}
+
+type modelCopies struct {
+	ModelGroup
+	original Model
+}
+
+// NewModelCopies returns a ModelCopies which contains the model count times.
+func NewModelCopies(model Model, count int) ModelCopies {
+	models := make([]Model, count)
+	for i := range models {
+		models[i] = model
+	}
+
+	return &modelCopies{
+		ModelGroup: NewModelGroup(models...),
+		original:   model,
+	}
+}
+
+func (c *modelCopies) Original() Model {
+	return c.original
+}
+
+func (g *modelGroup) Models() []Model {
+	return g.models
+}
+
+func (g *modelGroup) FaceCount() int {
+	if len(g.models) == 0 {
+		return 0
+	}
+	last := len(g.models) - 1
+	return g.offsets[last] + g.models[last].FaceCount()
+}
+
+func (g *modelGroup) Face(index int) Face {
+	// search the last model which starts at or before the index
+	i := sort.Search(len(g.offsets), func(i int) bool {
+		return g.offsets[i] > index
+	}) - 1
+	return g.models[i].Face(index - g.offsets[i])
+}
+
+func (g *modelGroup) Min() MicroVec3 {
+	var min MicroVec3
+	for _, m := range g.models {
+		if m.FaceCount() == 0 {
+			continue
+		}
+
+		other := m.Min()
+		if min == nil {
+			min = other
+			continue
+		}
+		min = NewMicroVec3(Min(min.X(), other.X()), Min(min.Y(), other.Y()), Min(min.Z(), other.Z()))
+	}
+	return min
+}
+
+func (g *modelGroup) Max() MicroVec3 {
+	var max MicroVec3
+	for _, m := range g.models {
+		if m.FaceCount() == 0 {
+			continue
+		}
+
+		other := m.Max()
+		if max == nil {
+			max = other
+			continue
+		}
+		max = NewMicroVec3(Max(max.X(), other.X()), Max(max.Y(), other.Y()), Max(max.Z(), other.Z()))
+	}
+	return max
+}
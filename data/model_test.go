@@ -0,0 +1,47 @@
+package data_test
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// testFace is a face with all points at the same position.
+type testFace data.Micrometer
+
+func (f testFace) Points() [3]data.MicroVec3 {
+	p := data.NewMicroVec3(data.Micrometer(f), data.Micrometer(f), data.Micrometer(f))
+	return [3]data.MicroVec3{p, p.Copy(), p.Copy()}
+}
+
+// testModel is a model of testFaces.
+type testModel []testFace
+
+func (m testModel) FaceCount() int {
+	return len(m)
+}
+
+func (m testModel) Face(index int) data.Face {
+	return m[index]
+}
+
+func (m testModel) Min() data.MicroVec3 {
+	return m[0].Points()[0]
+}
+
+func (m testModel) Max() data.MicroVec3 {
+	return m[len(m)-1].Points()[0]
+}
+
+func TestModelGroup(t *testing.T) {
+	group := data.NewModelGroup(testModel{1, 2}, testModel{}, testModel{-3}, testModel{4, 5, 6})
+
+	test.Equals(t, 4, len(group.Models()))
+	test.Equals(t, 6, group.FaceCount())
+	for i, expected := range []data.Micrometer{1, 2, -3, 4, 5, 6} {
+		test.Equals(t, expected, group.Face(i).Points()[0].X())
+	}
+
+	test.Equals(t, data.Micrometer(-3), group.Min().Z())
+	test.Equals(t, data.Micrometer(6), group.Max().Z())
+}
@@ -5,123 +5,233 @@ package data
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"log"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
+	"sync"
 
 	flag "github.com/spf13/pflag"
 )
 
-// implement the Value interface for all types which can occur in the options
+// NewDefaultFanSpeedOptions Creates instance FanSpeedOptions
+// and sets a of full fan (255) at layer 3.
+func NewDefaultFanSpeedOptions() FanSpeedOptions {
+	fo := FanSpeedOptions{}
+	fo.LayerToSpeedLUT = make(map[int]int)
+	fo.LayerToSpeedLUT[2] = 255
+	return fo
+}
 
-func (m Micrometer) String() string {
-	return strconv.FormatInt(int64(m), 10)
+func (f FanSpeedOptions) Type() string {
+	return "FanSpeedOptions"
 }
 
-func (m *Micrometer) Set(s string) error {
-	v, err := strconv.ParseInt(s, 0, 64)
-	*m = Micrometer(v)
-	return err
+func (f FanSpeedOptions) String() string {
+	var s []string
+	for k, v := range f.LayerToSpeedLUT {
+		s = append(s, fmt.Sprintf("%d=%d", k, v))
+	}
+	return strings.Join(s, ",")
 }
 
-func (m Micrometer) Type() string {
-	return "Micrometer"
+// Set takes string in format layerNo2=FanSpeed2,LayerNo2=FanSpeed2
+// Checks fan speed is within allowed range 0-255.
+// Also confirms layer is at at least 0 or above.
+func (f *FanSpeedOptions) Set(s string) error {
+	errMessage := "fan control needs to be in format layernum=fanspeed<0-255>,layernum=fanspeed<0-255>"
+	sp := strings.Split(s, ",")
+	lut := make(map[int]int, len(sp))
+	for _, kvp := range sp {
+		kv := strings.Split(kvp, "=")
+		if len(kv) == 2 {
+			layer, layerErr := strconv.Atoi(kv[0])
+			speed, speedErr := strconv.Atoi(kv[1])
+			if layerErr != nil || speedErr != nil || layer < 0 || speed < 0 || speed > 255 {
+				return errors.New(errMessage)
+			}
+			lut[layer] = speed
+		} else {
+			return errors.New(errMessage)
+		}
+	}
+
+	f.LayerToSpeedLUT = lut
+	return nil
 }
 
-func (m Millimeter) String() string {
-	return strconv.FormatFloat(float64(m), 'f', 3, 32)
+// SpeedAt returns the fan speed which is set last at or below the given layer.
+// If no fan speed is set up to the layer, false is returned.
+func (f FanSpeedOptions) SpeedAt(layerNr int) (int, bool) {
+	fanLayer := -1
+	for layer := range f.LayerToSpeedLUT {
+		if layer <= layerNr && layer > fanLayer {
+			fanLayer = layer
+		}
+	}
+	if fanLayer < 0 {
+		return 0, false
+	}
+	return f.LayerToSpeedLUT[fanLayer], true
 }
 
-func (m *Millimeter) Set(s string) error {
-	v, err := strconv.ParseFloat(s, 32)
-	*m = Millimeter(v)
-	return err
+// FeatureFanSpeedOptions used to override the fan speed for specific features.
+type FeatureFanSpeedOptions struct {
+	FeatureToSpeedLUT map[Feature]int
 }
 
-func (m Millimeter) Type() string {
-	return "Millimeter"
+func (f FeatureFanSpeedOptions) Type() string {
+	return "FeatureFanSpeedOptions"
 }
 
-func (v microVec3) String() string {
-	return v.X().String() + "_" + v.Y().String() + "_" + v.Z().String()
+func (f FeatureFanSpeedOptions) String() string {
+	return featureLUTString(f.FeatureToSpeedLUT)
 }
 
-func (v *microVec3) Set(s string) error {
-	const errorMsg = "the string should contain three integers separated by _"
-	parts := strings.Split(s, "_")
-	if len(parts) != 3 {
-		return errors.New(errorMsg)
+// Set takes string in format feature1=FanSpeed1,feature2=FanSpeed2
+// Checks fan speed is within allowed range 0-255.
+// Also confirms that the features are known.
+func (f *FeatureFanSpeedOptions) Set(s string) error {
+	lut, err := parseFeatureLUT(s, "feature fan control needs to be in format feature=fanspeed<0-255>,feature=fanspeed<0-255>", func(speed int) bool {
+		return speed >= 0 && speed <= 255
+	})
+	if err != nil {
+		return err
 	}
 
-	result := microVec3{}
+	f.FeatureToSpeedLUT = lut
+	return nil
+}
 
-	if err := result.x.Set(parts[0]); err != nil {
-		return errors.New(errorMsg)
-	}
+// FeatureTemperatureOptions used to change the hot end temperature for specific features.
+type FeatureTemperatureOptions struct {
+	FeatureToOffsetLUT map[Feature]int
+}
 
-	if err := result.y.Set(parts[1]); err != nil {
-		return errors.New(errorMsg)
+func (f FeatureTemperatureOptions) Type() string {
+	return "FeatureTemperatureOptions"
+}
+
+func (f FeatureTemperatureOptions) String() string {
+	return featureLUTString(f.FeatureToOffsetLUT)
+}
+
+// Set takes string in format feature1=Offset1,feature2=Offset2
+// The offsets are in °C and can be negative.
+// Also confirms that the features are known.
+func (f *FeatureTemperatureOptions) Set(s string) error {
+	lut, err := parseFeatureLUT(s, "feature temperature offsets need to be in format feature=offset,feature=offset", func(int) bool {
+		return true
+	})
+	if err != nil {
+		return err
 	}
 
-	if err := result.z.Set(parts[2]); err != nil {
-		return errors.New(errorMsg)
+	f.FeatureToOffsetLUT = lut
+	return nil
+}
+
+// parseFeatureLUT parses a string in the format feature1=value1,feature2=value2.
+// If the format is wrong or a value is not valid, an error with the given message is returned.
+func parseFeatureLUT(s string, errMessage string, valid func(value int) bool) (map[Feature]int, error) {
+	sp := strings.Split(s, ",")
+	lut := make(map[Feature]int, len(sp))
+	for _, kvp := range sp {
+		kv := strings.Split(kvp, "=")
+		if len(kv) != 2 {
+			return nil, errors.New(errMessage)
+		}
+
+		value, err := strconv.Atoi(kv[1])
+		if err != nil || !valid(value) {
+			return nil, errors.New(errMessage)
+		}
+		if !IsKnownFeature(kv[0]) {
+			return nil, fmt.Errorf("unknown feature %q", kv[0])
+		}
+		lut[Feature(kv[0])] = value
 	}
 
-	v.x = result.x
-	v.y = result.y
-	v.z = result.z
+	return lut, nil
+}
 
-	return nil
+// featureLUTString is the counterpart of parseFeatureLUT.
+// The features are sorted to get a stable result.
+func featureLUTString(lut map[Feature]int) string {
+	var s []string
+	for k, v := range lut {
+		s = append(s, fmt.Sprintf("%s=%d", k, v))
+	}
+	sort.Strings(s)
+	return strings.Join(s, ",")
 }
 
-func (v microVec3) Type() string {
-	return "Micrometer"
+// LayerThicknessRange sets the layer thickness of all layers starting within the z range From (inclusive) to To (exclusive).
+type LayerThicknessRange struct {
+	From, To       Micrometer
+	LayerThickness Micrometer
 }
 
-// NewDefaultFanSpeedOptions Creates instance FanSpeedOptions
-// and sets a of full fan (255) at layer 3.
-func NewDefaultFanSpeedOptions() FanSpeedOptions {
-	fo := FanSpeedOptions{}
-	fo.LayerToSpeedLUT = make(map[int]int)
-	fo.LayerToSpeedLUT[2] = 255
-	return fo
+// LayerThicknessRangeOptions used to print parts of the model with a different layer thickness.
+// The ranges are sorted by their start and do not overlap.
+type LayerThicknessRangeOptions struct {
+	Ranges []LayerThicknessRange
 }
 
-func (f FanSpeedOptions) Type() string {
-	return "FanSpeedOptions"
+func (l LayerThicknessRangeOptions) Type() string {
+	return "LayerThicknessRangeOptions"
 }
 
-func (f FanSpeedOptions) String() string {
+func (l LayerThicknessRangeOptions) String() string {
 	var s []string
-	for k, v := range f.LayerToSpeedLUT {
-		s = append(s, fmt.Sprintf("%d=%d", k, v))
+	for _, r := range l.Ranges {
+		s = append(s, fmt.Sprintf("%v=%d", r.rangeString(), r.LayerThickness))
 	}
 	return strings.Join(s, ",")
 }
 
-// Set takes string in format layerNo2=FanSpeed2,LayerNo2=FanSpeed2
-// Checks fan speed is within allowed range 0-255.
-// Also confirms layer is at at least 0 or above.
-func (f *FanSpeedOptions) Set(s string) error {
-	errMessage := "fan control needs to be in format layernum=fanspeed<0-255>,layernum=fanspeed<0-255>"
+// rangeString returns the z range in mm in the format from-to.
+func (r LayerThicknessRange) rangeString() string {
+	return fmt.Sprintf("%g-%g", float64(r.From)/1000, float64(r.To)/1000)
+}
+
+// Set takes string in format from1-to1=thickness1,from2-to2=thickness2
+// The ranges are in mm and the thickness in µm, e.g. 0-10=300,10-15=120.
+// Also confirms that the ranges are not empty and do not overlap.
+func (l *LayerThicknessRangeOptions) Set(s string) error {
+	errMessage := "layer thickness ranges need to be in format from-to=thickness,from-to=thickness"
 	sp := strings.Split(s, ",")
-	lut := make(map[int]int, len(sp))
+	ranges := make([]LayerThicknessRange, 0, len(sp))
 	for _, kvp := range sp {
 		kv := strings.Split(kvp, "=")
-		if len(kv) == 2 {
-			layer, layerErr := strconv.Atoi(kv[0])
-			speed, speedErr := strconv.Atoi(kv[1])
-			if layerErr != nil || speedErr != nil || layer < 0 || speed < 0 || speed > 255 {
-				return errors.New(errMessage)
-			}
-			lut[layer] = speed
-		} else {
+		if len(kv) != 2 {
 			return errors.New(errMessage)
 		}
+		fromTo := strings.Split(kv[0], "-")
+		if len(fromTo) != 2 {
+			return errors.New(errMessage)
+		}
+
+		var from, to Millimeter
+		var thickness Micrometer
+		if from.Set(fromTo[0]) != nil || to.Set(fromTo[1]) != nil || thickness.Set(kv[1]) != nil || from < 0 || to <= from || thickness <= 0 {
+			return errors.New(errMessage)
+		}
+		ranges = append(ranges, LayerThicknessRange{From: from.ToMicrometer(), To: to.ToMicrometer(), LayerThickness: thickness})
 	}
 
-	f.LayerToSpeedLUT = lut
+	sort.Slice(ranges, func(i, j int) bool {
+		return ranges[i].From < ranges[j].From
+	})
+	for i := 1; i < len(ranges); i++ {
+		if ranges[i].From < ranges[i-1].To {
+			return fmt.Errorf("the layer thickness ranges %vmm and %vmm overlap", ranges[i-1].rangeString(), ranges[i].rangeString())
+		}
+	}
+
+	l.Ranges = ranges
 	return nil
 }
 
@@ -139,18 +249,109 @@ type PrintOptions struct {
 	// MoveSpeed is the speed for all non printing moves in mm per second.
 	MoveSpeed Millimeter
 
+	// Combing routes the non printing moves between two points of the same part inside of the part
+	// instead of crossing its perimeters, so that no retraction is needed and less stringing remains on the surface.
+	Combing bool
+
 	// InitialLayerThickness is the layer thickness for the first layer.
 	InitialLayerThickness Micrometer
 
 	// LayerThickness is the thickness for all but the first layer.
 	LayerThickness Micrometer
 
+	// LayerThicknessRanges overrides the layer thickness for the layers starting within the given z ranges.
+	// The first layer always uses the InitialLayerThickness.
+	LayerThicknessRanges LayerThicknessRangeOptions
+
 	// InsetCount is the number of perimeters.
 	InsetCount int
 
+	// PerimeterOrder defines the order in which the perimeters of each part are printed:
+	// "inner-first" prints from the most inner to the outer perimeter, so that the outer perimeter
+	// leans against the inner ones, which improves overhangs. Older versions printed the second perimeter first
+	// and then outwards from the most inner one, which differs only with three or more perimeters.
+	// "outer-first" starts with the outer perimeter, which improves the dimensional accuracy.
+	PerimeterOrder string
+
+	// InsetJoinType defines how the corners of the perimeters are joined when they are inset:
+	// "square" cuts off sharp corners, "round" rounds them and "miter" keeps them sharp up to the InsetMiterLimit.
+	// Round joins improve especially small circular perimeters.
+	InsetJoinType string
+
+	// InsetMiterLimit is the max distance of a corner from the original corner as multiple of the offset
+	// if InsetJoinType is "miter". Sharper corners are cut off. It has to be at least 2.
+	InsetMiterLimit float64
+
+	// PerimeterOverlapCompensation reduces the flow where perimeters overlap each other,
+	// e.g. on thin walls which are not wide enough for all perimeter lines.
+	PerimeterOverlapCompensation bool
+
+	// VariableWidthPerimeters adapts the width of the perimeters to the width of the walls.
+	// Where a wall is too thin for the next perimeter, the perimeters beside it are widened to fill the gap,
+	// so that e.g. tapered walls keep the same amount of lines instead of getting gaps.
+	VariableWidthPerimeters bool
+
+	// GapFill fills the gaps between the perimeters which are too narrow for another perimeter
+	// with single lines of the width of the gap, so that thin features are not printed hollow.
+	GapFill bool
+
+	// GapFillMinWidth is the width of the narrowest gap which is filled if GapFill is enabled.
+	GapFillMinWidth Millimeter
+
+	// ThinWalls prints the walls which are too thin for the outer perimeter as a single line along their center,
+	// whose width follows the width of the wall, instead of dropping them.
+	ThinWalls bool
+
+	// ThinWallMinWidth is the width of the thinnest wall which is printed if ThinWalls is enabled.
+	ThinWallMinWidth Millimeter
+
+	// SeamPosition defines where each closed perimeter starts:
+	// "none" keeps the start of the calculated perimeter, "aligned" starts near the seams of the layer below,
+	// "rear" starts at the rear most point, "random" starts at a random point,
+	// "nearest" starts at the point nearest to the current position,
+	// "angle" starts at the point in the direction of SeamAngle seen from the center of the model and
+	// "sharpest-corner" starts at the sharpest concave corner of each perimeter, where the seam is hidden best.
+	SeamPosition string
+
+	// SeamAngle is the compass angle in degree of the seams if SeamPosition is "angle".
+	// 0° points to the front (-Y), 90° to the right (+X), 180° to the rear (+Y) and 270° to the left (-X).
+	SeamAngle int
+
+	// ClockwisePerimeters prints the outer contours of the parts and their perimeters clockwise and the holes counter clockwise.
+	// By default, the outer contours are printed counter clockwise and the holes clockwise.
+	ClockwisePerimeters bool
+
+	// ElephantFootCompensation moves the perimeters of the first layer inwards by this distance.
+	// This compensates the first layer being squished wider onto the bed, so that the base has the same size as the rest of the model.
+	// The inner perimeters and the infill area follow the outer perimeter, so the whole first layer shrinks
+	// and the lines keep their spacing.
+	ElephantFootCompensation Millimeter
+
+	// HoleCompensation moves the contours of all holes outwards by this distance, independent of the outer walls.
+	// This compensates holes which are always printed a bit too small.
+	HoleCompensation Millimeter
+
+	// ZOffset shifts all Z heights written to the gcode by this signed distance without changing the layers.
+	// It can be used to correct a slightly wrong probe offset of the printer.
+	// It is ignored for belt printers as their Z axis moves the belt.
+	ZOffset Millimeter
+
+	// Spiralize prints the model as a vase. Above the bottom layers only the outer contour of the largest part
+	// is printed as one continuously rising line without a z seam, so there is no infill and no top layer.
+	Spiralize bool
+
 	// InfillOverlapPercent is the percentage of overlap into the perimeters.
 	InfillOverlapPercent int
 
+	// InfillOverlap is the distance by which the infill overlaps the inner side of the perimeters.
+	// If it is greater than 0, it takes precedence over InfillOverlapPercent,
+	// so that the overlap stays the same if the extrusion width changes.
+	InfillOverlap Micrometer
+
+	// InfillTrimToPerimeter trims the infill lines exactly at the center line of the most inner perimeter
+	// instead of the outline of the infill area. InfillOverlap and InfillOverlapPercent are ignored for the perimeters if it is enabled.
+	InfillTrimToPerimeter bool
+
 	// AdditionalInternalInfillOverlapPercent is the percentage used to make the internal
 	// infill (infill not blocked by the perimeters) even bigger so that it grows a bit into the model.
 	AdditionalInternalInfillOverlapPercent int
@@ -158,21 +359,112 @@ type PrintOptions struct {
 	// InfillPercent is the amount of infill which should be generated.
 	InfillPercent int
 
+	// InfillCombineLayers is the amount of layers whose sparse infill is printed at once on the top layer of each group
+	// with multiplied flow, which saves a lot of time for sparse infill. 1 prints the infill on each layer.
+	InfillCombineLayers int
+
+	// SolidInfillEvery prints the sparse infill of every n-th layer as solid infill, which strengthens tall parts.
+	// 0 disables it.
+	SolidInfillEvery int
+
 	// InfillRotationDegree is the rotation used for the infill.
 	InfillRotationDegree int
 
+	// InfillAlignToPart aligns the infill of each part with the principal axis of its outline
+	// instead of using InfillRotationDegree, which makes elongated parts stronger.
+	InfillAlignToPart bool
+
 	// InfillZigZig sets if the infill should use connected lines in zig zag form.
+	// The lines are connected along the border of the infill, so that each region is printed as one continuous line if possible.
+	// This saves travel moves and retractions, but as the connections are extruded, the estimated print time may rise slightly.
 	InfillZigZag bool
 
+	// SkinZigZag sets if the top and bottom skins should use connected lines in zig zag form (see InfillZigZag),
+	// which avoids a travel move and a retraction between most of the skin lines.
+	SkinZigZag bool
+
+	// SkinPattern is the pattern used for the top and bottom skins (see SkinPatterns):
+	// "lines" fills them with parallel lines, "concentric" with loops following their contours,
+	// which looks much better on circular top faces.
+	SkinPattern string
+
+	// InfillPattern is the name of the pattern used for the sparse infill.
+	// Built in are "linear", "grid", "concentric", "cubic" and "adaptive-cubic",
+	// more patterns can be added using clip.RegisterPattern.
+	InfillPattern string
+
+	// InfillAdaptiveDistance is the distance to the perimeters and to the top surfaces above
+	// within which the "adaptive-cubic" infill pattern uses the double density.
+	InfillAdaptiveDistance Millimeter
+
+	// MaxSkinSpan is the max distance a top skin may bridge over the sparse infill.
+	// If the infill lines are further apart, the infill below the top skins is printed denser.
+	// 0 disables it.
+	MaxSkinSpan Millimeter
+
+	// SkinSupportLayers is the amount of layers below the top skins which are printed denser if needed.
+	SkinSupportLayers int
+
+	// GradualInfillSteps is the amount of steps below the top skins in which the infill density doubles,
+	// so that sparse infill still supports the top skins well. 0 disables the gradual infill.
+	GradualInfillSteps int
+
+	// GradualInfillStepHeight is the height of each step of the gradual infill.
+	GradualInfillStepHeight Millimeter
+
+	// SkinInset is the distance by which the top and bottom skins are pulled inside their area,
+	// e.g. to keep the skin lines away from the innermost perimeter.
+	SkinInset Millimeter
+
+	// SkinPerimeters is the amount of perimeter loops printed around the top and bottom skins.
+	SkinPerimeters int
+
 	// NumberBottomLayers is the amount of layers the bottom layers should grow into the model.
 	NumberBottomLayers int
 
 	// NumberBottomLayers is the amount of layers the bottom layers should grow into the model.
 	NumberTopLayers int
 
+	// ModelSpacing is the min distance between the models if several models are sliced together.
+	ModelSpacing Millimeter
+
+	// Transform is applied to each model before it is sliced.
+	Transform TransformOptions
+
+	// ModelTransforms can be used to transform several models differently.
+	// The transform at the index of a model is used instead of Transform.
+	// If one of them moves its model, the models are placed at their translations instead of being arranged.
+	ModelTransforms ModelTransformOptions
+
 	Support SupportOptions
 
 	BrimSkirt BrimSkirtOptions
+
+	OozeShield OozeShieldOptions
+
+	Polyhole PolyholeOptions
+
+	PrintableOverhang PrintableOverhangOptions
+
+	Hollow HollowOptions
+
+	ScarfSeam ScarfSeamOptions
+
+	Bridge BridgeOptions
+
+	OverhangPerimeter OverhangPerimeterOptions
+
+	NonPlanarTop NonPlanarTopOptions
+
+	Sequential SequentialOptions
+
+	// ModelExtruders contains the extruder which prints each model, in the order of the models.
+	// Models without an entry are printed by the first extruder (0).
+	// If the models are printed by several extruders, the bodies of each extruder are processed separately
+	// and the extruder is changed on each layer. Where the bodies overlap, the extruder with the lower number is used.
+	ModelExtruders []int
+
+	Interlocking InterlockingOptions
 }
 
 // FilamentOptions contains all Filament specific GoSlice options.
@@ -183,6 +475,10 @@ type FilamentOptions struct {
 	// InitialBedTemperature is the temperature for the heated bed for the first layers.
 	InitialBedTemperature int
 
+	// HeatSoakTime is the time in seconds waited after the bed reached its initial temperature,
+	// so that the whole bed and the chamber can heat up evenly. 0 disables it.
+	HeatSoakTime int
+
 	// InitialHotendTemperature is the temperature for the hot end for the first layers.
 	InitialHotEndTemperature int
 
@@ -205,6 +501,16 @@ type FilamentOptions struct {
 	// Primary (fan 0) speed, at given layers
 	FanSpeed FanSpeedOptions
 
+	// FeatureFanSpeed overrides the fan speed while specific features are printed.
+	FeatureFanSpeed FeatureFanSpeedOptions
+
+	// FeatureTemperatureOffset changes the hot end temperature while specific features are printed.
+	FeatureTemperatureOffset FeatureTemperatureOptions
+
+	// TemperatureHysteresis is the min difference in °C needed to change the temperature for a feature.
+	// This prevents changing the temperature for every small offset.
+	TemperatureHysteresis int
+
 	// ExtrusionMultiplier is the multiplier in % used to change the amount of filament being extruded.
 	ExtrusionMultiplier int
 }
@@ -221,13 +527,76 @@ type SupportOptions struct {
 	TopGapLayers int
 
 	// InterfaceLayers is the amount of layers which are filled differently as interface to the object.
+	// They form the roof of the support below the overhangs.
 	InterfaceLayers int
 
+	// MaxBridgeLength is the max length of overhangs anchored on both ends which are bridged instead of supported.
+	// 0 disables it, so that all overhangs are supported.
+	MaxBridgeLength Millimeter
+
+	// FloorLayers is the amount of the most bottom support layers which are filled densely where the support rests on the model.
+	FloorLayers int
+
 	// PatternSpacing is the spacing used to create the support pattern.
 	PatternSpacing Millimeter
 
+	// Pattern is the pattern used to fill the support:
+	// "zigzag" connects the lines to a zig zag, "lines" uses separate lines, "grid" crosses the lines on each layer
+	// and "concentric" uses loops following the outline of the support.
+	Pattern string
+
+	// PatternAngle is the rotation in degree of the support pattern.
+	// The support interface is rotated by 90° to it.
+	PatternAngle int
+
 	// Gap is the gap between the model and the support.
 	Gap Millimeter
+
+	// SupportedBottomDensity is the density in percent of the bottom skin which rests on support.
+	SupportedBottomDensity int
+
+	// SupportedBottomSpeed is the speed in mm per second for the bottom skin which rests on support.
+	// If it is 0, the normal speed is used.
+	SupportedBottomSpeed Millimeter
+
+	// BrimCount is the amount of brim lines around the support on the first layer
+	// whose footprint is smaller than BrimMaxArea, so that thin support columns do not detach from the bed.
+	// 0 disables the support brim.
+	BrimCount int
+
+	// BrimMaxArea is the max area in mm² of the footprint of a support column which gets a brim.
+	BrimMaxArea float64
+
+	// MinArea is the min area in mm² of an overhang which is supported.
+	// Smaller overhangs are not supported unless they get a tower (see TowerDiameter).
+	MinArea float64
+
+	// TowerDiameter is the diameter of the towers which support tiny overhangs, e.g. the fingertips of a figurine,
+	// so that they do not rest on thin support columns which may break off.
+	// The support below these overhangs is at least as wide as the towers. 0 disables the support towers.
+	TowerDiameter Millimeter
+
+	// TowerMaxArea is the max area in mm² of an overhang which is supported by a tower.
+	TowerMaxArea float64
+
+	// Type is the type of the generated support.
+	// "grid" fills the whole area below the overhangs, "tree" grows branches from the overhangs down to the bed or the model.
+	Type string
+
+	Tree TreeSupportOptions
+}
+
+// TreeSupportOptions contains the options for the tree support.
+type TreeSupportOptions struct {
+	// BranchAngle is the max angle in degree from the vertical in which the branches may grow.
+	// Bigger angles allow the branches to merge faster and to avoid the model more easily but are less stable.
+	BranchAngle int
+
+	// BranchDiameter is the diameter the branches grow to below their tips.
+	BranchDiameter Millimeter
+
+	// TipDiameter is the diameter of the tips of the branches which touch the overhangs.
+	TipDiameter Millimeter
 }
 
 // BrimSkirtOptions contains all options for the brim and skirt generation.
@@ -238,99 +607,723 @@ type BrimSkirtOptions struct {
 	// SkirtDistance is the distance between the model (or the most outer brim lines) and the most inner skirt line.
 	SkirtDistance Millimeter
 
+	// SkirtMinLength is the minimum length of all skirt lines together.
+	// If the SkirtCount lines are shorter, more lines are added until it is reached, so that the nozzle is primed enough.
+	// 0 disables it.
+	SkirtMinLength Millimeter
+
+	// SkirtHeight is the amount of layers on which the skirt is printed.
+	SkirtHeight int
+
 	// BrimCount specifies the amount of brim lines around the parts of the initial layer.
 	BrimCount int
+
+	// BrimType defines where the brim is generated.
+	// "outer" generates it around the objects, "inner" inside their holes and "both" at both places.
+	BrimType string
+
+	// BrimEars enables brim ears: the outer brim is only printed at the sharp corners of the objects,
+	// where they tend to lift, to save material.
+	BrimEars bool
+
+	// BrimEarsMaxAngle is the max angle in degree of a corner which gets a brim ear.
+	BrimEarsMaxAngle int
+
+	// OverlapPrecedence defines which feature of the initial layer is kept where the features of several objects overlap.
+	// "none" keeps the brims as they are and removes only the support below them.
+	// "brim" additionally clips the brims so that they do not overlap other objects or the brim of a larger object.
+	// "support" clips the brims in the same way and also by the support, which is then kept below the brims.
+	// The skirt surrounds the hull of all objects, brims and support, so it never overlaps them.
+	OverlapPrecedence string
 }
 
-// FanSpeedOptions used to control fan speed at given layers.
-type FanSpeedOptions struct {
-	LayerToSpeedLUT map[int]int
+// OozeShieldOptions contains all options for the ooze shield.
+// The ooze shield is a single wall around the model on each layer which is printed before the model.
+// It catches the filament oozing out of the nozzle during the travel moves to the model.
+type OozeShieldOptions struct {
+	// Enabled enables the ooze shield.
+	Enabled bool
+
+	// Distance is the distance between the model (and its support) and the ooze shield.
+	Distance Millimeter
+
+	// Angle is the max angle in degree the ooze shield may overhang, where 0° is vertical.
+	// The shield below is widened so that it supports the shield above.
+	Angle int
 }
 
-// PrinterOptions contains all Printer specific GoSlice options.
-type PrinterOptions struct {
-	// ExtrusionWidth is the diameter of your nozzle.
-	ExtrusionWidth Micrometer
+// TransformOptions contains the transformation of a model.
+// The model is scaled, mirrored and rotated around X, Y and Z in this order.
+// If AutoOrient is set, it is rotated again to the orientation which needs the least support.
+// Afterwards it is placed on the bed as usual and moved by the translation.
+type TransformOptions struct {
+	// Scale is the factor by which the model is scaled.
+	Scale float64
 
-	// Center is the point where the model is finally placed.
-	Center MicroVec3
+	// Mirror contains the axes along which the model is mirrored, e.g. "x" or "xy".
+	Mirror string
+
+	// RotateX is the rotation around the X axis in degree.
+	RotateX float64
+
+	// RotateY is the rotation around the Y axis in degree.
+	RotateY float64
+
+	// RotateZ is the rotation around the Z axis in degree.
+	RotateZ float64
+
+	// TranslateX is the distance the model is moved in X direction from the center of the bed.
+	TranslateX Millimeter
+
+	// TranslateY is the distance the model is moved in Y direction from the center of the bed.
+	TranslateY Millimeter
+
+	// AutoOrient rotates the model after the other transformations so that the least support is needed.
+	AutoOrient bool
 }
 
-// GoSliceOptions contains all options related to GoSlice itself.
-type GoSliceOptions struct {
-	// PrintVersion indicates if the GoSlice version should be printed.
-	PrintVersion bool
+// IsIdentity returns true if the transformation does not change the model.
+func (t TransformOptions) IsIdentity() bool {
+	return t.Scale == 1 && t.Mirror == "" && t.RotateX == 0 && t.RotateY == 0 && t.RotateZ == 0 && t.TranslateX == 0 && t.TranslateY == 0 && !t.AutoOrient
+}
 
-	// InputFilePath specifies the path to the input stl file.
-	InputFilePath string
+// microVec3Value adapts a MicroVec3 to the value interface needed for the options.
+// It is set in the format x_y_z in Micrometer, e.g. 100000_100000_0.
+type microVec3Value struct {
+	MicroVec3
+}
 
-	// OutputFilePath specifies the path to the output gcode file.
-	OutputFilePath string
+func (v microVec3Value) Type() string {
+	return "Micrometer"
+}
 
-	// Logger can be used to redirect the log output to anything you want.
-	// All output in GoSlice just calls this logger.
-	Logger *log.Logger
+func (v *microVec3Value) Set(s string) error {
+	const errorMsg = "the string should contain three integers separated by _"
+	parts := strings.Split(s, "_")
+	if len(parts) != 3 {
+		return errors.New(errorMsg)
+	}
+
+	var x, y, z Micrometer
+	if err := x.Set(parts[0]); err != nil {
+		return errors.New(errorMsg)
+	}
+	if err := y.Set(parts[1]); err != nil {
+		return errors.New(errorMsg)
+	}
+	if err := z.Set(parts[2]); err != nil {
+		return errors.New(errorMsg)
+	}
+
+	v.MicroVec3 = NewMicroVec3(x, y, z)
+	return nil
 }
 
-// SlicingOptions contains all options related to slice a model.
-type SlicingOptions struct {
-	// MeldDistance is the distance which two points have to be
-	// within to count them as one point.
-	MeldDistance Micrometer
+// ModelTransformOptions contains the transformations of several models, one for each model in the order of the models.
+type ModelTransformOptions []TransformOptions
 
-	// JoinPolygonSnapDistance is the distance used to check if two open
-	// polygons can be snapped together to one bigger polygon.
-	// Checked by the start and endpoints of the polygons.
-	JoinPolygonSnapDistance Micrometer
+func (m ModelTransformOptions) Type() string {
+	return "ModelTransformOptions"
+}
 
-	// FinishPolygonSnapDistance is the max distance between start end endpoint of
-	// a polygon used to check if a open polygon can be closed.
-	FinishPolygonSnapDistance Micrometer
+func (m ModelTransformOptions) String() string {
+	var s []string
+	for _, t := range m {
+		s = append(s, fmt.Sprintf("scale=%g,mirror=%s,rotate-x=%g,rotate-y=%g,rotate-z=%g,translate-x=%v,translate-y=%v,auto-orient=%v",
+			t.Scale, t.Mirror, t.RotateX, t.RotateY, t.RotateZ, t.TranslateX, t.TranslateY, t.AutoOrient))
+	}
+	return strings.Join(s, ";")
 }
 
-// Options contains all GoSlice options.
-type Options struct {
-	Slicing  SlicingOptions
-	Printer  PrinterOptions
-	Filament FilamentOptions
-	Print    PrintOptions
-	GoSlice  GoSliceOptions
+// Set adds the transform of the next model in the format key=value,key=value, e.g. scale=2,rotate-z=90,translate-x=20.
+// The keys are the names of the flags of the transform: scale, mirror, rotate-x, rotate-y, rotate-z,
+// translate-x, translate-y and auto-orient. Missing keys keep their default.
+func (m *ModelTransformOptions) Set(s string) error {
+	t := TransformOptions{Scale: 1}
+	for _, kvp := range strings.Split(s, ",") {
+		kv := strings.Split(kvp, "=")
+		if len(kv) != 2 {
+			return errors.New("model transforms need to be in format key=value,key=value")
+		}
+
+		var err error
+		switch kv[0] {
+		case "scale":
+			t.Scale, err = strconv.ParseFloat(kv[1], 64)
+		case "mirror":
+			t.Mirror = kv[1]
+		case "rotate-x":
+			t.RotateX, err = strconv.ParseFloat(kv[1], 64)
+		case "rotate-y":
+			t.RotateY, err = strconv.ParseFloat(kv[1], 64)
+		case "rotate-z":
+			t.RotateZ, err = strconv.ParseFloat(kv[1], 64)
+		case "translate-x":
+			err = t.TranslateX.Set(kv[1])
+		case "translate-y":
+			err = t.TranslateY.Set(kv[1])
+		case "auto-orient":
+			t.AutoOrient, err = strconv.ParseBool(kv[1])
+		default:
+			return fmt.Errorf("unknown model transform %q", kv[0])
+		}
+		if err != nil {
+			return fmt.Errorf("invalid value %q of the model transform %s", kv[1], kv[0])
+		}
+	}
+
+	*m = append(*m, t)
+	return nil
 }
 
-func DefaultOptions() Options {
-	return Options{
-		Slicing: SlicingOptions{
-			MeldDistance:              30,
-			JoinPolygonSnapDistance:   160,
-			FinishPolygonSnapDistance: 1000,
-		},
+// PolyholeOptions contains all options for converting small circular holes into polyholes.
+// A polyhole is a polygon with only a few vertices which is slightly bigger than the circle
+// so that the printed hole matches the nominal diameter.
+// (https://hydraraptor.blogspot.com/2011/02/polyholes.html)
+type PolyholeOptions struct {
+	// Enabled enables the conversion of circular holes to polyholes.
+	Enabled bool
+
+	// MaxDiameter is the max diameter of holes which are converted to polyholes.
+	MaxDiameter Millimeter
+}
+
+// PrintableOverhangOptions contains all options for making overhangs printable without support.
+type PrintableOverhangOptions struct {
+	// Enabled enables changing the model so that no overhang exceeds the MaxAngle.
+	Enabled bool
+
+	// MaxAngle is the max angle an overhang may have. Everything overhanging more is removed.
+	MaxAngle int
+}
+
+// ScarfSeamOptions contains all options for scarf seams which hide the seam of the outer perimeters.
+// The start of each outer perimeter rises from the layer below with increasing flow and its end
+// overlaps the start with decreasing flow, so that the seam is blended over the Length.
+type ScarfSeamOptions struct {
+	// Enabled enables scarf seams on the outer perimeters of all layers except the first one.
+	Enabled bool
+
+	// Length is the length along the perimeter over which the seam is blended.
+	Length Millimeter
+
+	// Steps is the number of steps with constant flow the blending is split into.
+	Steps int
+}
+
+// SequentialOptions contains all options for printing several objects one after the other.
+type SequentialOptions struct {
+	// Enabled prints each object completely before the next one starts.
+	// The objects are printed from the lowest to the highest one and the nozzle is lifted above
+	// the finished objects before it moves to the next one. It is not possible on belt printers.
+	Enabled bool
+
+	// ExtruderClearanceRadius is the radius around the nozzle which is free of any part of the print head.
+	// The objects are placed at least this distance apart, so that the print head cannot hit finished objects.
+	ExtruderClearanceRadius Millimeter
+
+	// GantryHeight is the distance between the nozzle tip and the lowest part of the gantry.
+	// Only the last printed object may be higher, as the gantry spans the whole bed.
+	GantryHeight Millimeter
+}
+
+// InterlockingOptions contains all options for the interlocking beams between bodies printed by different extruders.
+// Two materials often stick only weakly to each other, so the bodies are interlocked at their boundaries:
+// Near the boundary both bodies are replaced by alternating beams of both materials which reach into the other body.
+// The direction of the beams changes after some layers, so that the beams of the layers above and below hold each other.
+type InterlockingOptions struct {
+	// Enabled enables the interlocking beams.
+	Enabled bool
+
+	// BeamWidth is the width of each beam.
+	BeamWidth Millimeter
+
+	// BeamLayers is the amount of layers after which the direction of the beams changes.
+	BeamLayers int
+
+	// Depth is the distance from the boundary by which the beams reach into each body.
+	Depth Millimeter
+}
+
+// HollowOptions contains all options for hollowing the models, e.g. to reduce the weight of figurines.
+type HollowOptions struct {
+	// Enabled enables hollowing the models so that only a shell with the WallThickness remains.
+	Enabled bool
+
+	// WallThickness is the thickness of the remaining shell.
+	WallThickness Millimeter
+
+	// DrainHoleDiameter is the diameter of the holes punched through the bottom shell below each cavity.
+	// 0 disables the drain holes.
+	DrainHoleDiameter Millimeter
+}
+
+// BridgeOptions contains all options for bridges, the parts of the bottom skins which do not rest on the layer below.
+type BridgeOptions struct {
+	// Enabled detects the bridges and prints them with lines in the direction which anchors them best on both ends,
+	// using the bridge speed, flow and fan speed.
+	Enabled bool
+
+	// Speed is the speed in mm per second for the bridges. 0 uses the normal speed.
+	Speed Millimeter
+
+	// Flow is the flow in percent for the bridges.
+	Flow int
+
+	// FanSpeed is the fan speed (0-255) for the bridges.
+	// A fan speed set for the feature "bridge" using the feature fan speed takes precedence.
+	FanSpeed int
+}
+
+// OverhangPerimeterOptions contains all options for the perimeter segments which overhang the layer below.
+type OverhangPerimeterOptions struct {
+	// Enabled classifies the perimeter segments by the percentage of their width which overhangs the layer below.
+	// The segments overhanging by at least the threshold are printed with the overhang speed and fan speed.
+	Enabled bool
+
+	// Threshold is the percentage of the extrusion width which has to overhang the layer below,
+	// so that a segment is printed as overhang.
+	Threshold int
+
+	// Speed is the speed in mm per second for the overhanging segments. 0 uses the normal speed.
+	Speed Millimeter
+
+	// FanSpeed is the fan speed (0-255) for the overhanging segments.
+	// A fan speed set for the feature "overhang-wall" using the feature fan speed takes precedence.
+	FanSpeed int
+}
+
+// NonPlanarTopOptions contains all options for the experimental non-planar smoothing of top surfaces.
+type NonPlanarTopOptions struct {
+	// Enabled enables raising the top infill to the actual surface of the model.
+	Enabled bool
+
+	// MaxHeight is the max distance the nozzle may be raised above the layer.
+	MaxHeight Micrometer
+}
+
+// FanSpeedOptions used to control fan speed at given layers.
+type FanSpeedOptions struct {
+	LayerToSpeedLUT map[int]int
+}
+
+// PrinterOptions contains all Printer specific GoSlice options.
+type PrinterOptions struct {
+	// NozzleDiameter is the diameter of your nozzle.
+	NozzleDiameter Micrometer
+
+	// ExtrusionWidth is the width of the extruded lines.
+	// If only the NozzleDiameter is set by the flags, it is derived from it.
+	ExtrusionWidth Micrometer
+
+	// FirstLayerExtrusionWidth is the width of the extruded lines of the first layer.
+	// Wider lines stick better to the bed. 0 uses the ExtrusionWidth.
+	FirstLayerExtrusionWidth Micrometer
+
+	// Acceleration is the acceleration of the printer in mm/s².
+	// If it is set, the speed of extruding moves is reduced to the speed the printer can actually reach
+	// on short moves and at sharp corners, so that the extrusion matches the movement.
+	// 0 disables the speed planning.
+	Acceleration float64
+
+	// SquareCornerVelocity is the speed in mm/s the printer keeps at a 90° corner.
+	// It is used together with the Acceleration to calculate the speed at the corners.
+	SquareCornerVelocity float64
+
+	// MaxExtrusionPerMM is the max length of filament a move may extrude per mm it moves.
+	// It protects the printer from blobs caused by absurd extrusion amounts,
+	// e.g. by very short moves with a huge flow which result from broken geometry.
+	// 0 disables the check.
+	MaxExtrusionPerMM Millimeter
+
+	// ToolChangeTime is the time in seconds the printer needs to change the active extruder,
+	// e.g. to park one tool head and pick up the other one. It is added to the estimated print time for each tool change.
+	ToolChangeTime float64
+
+	// ExcessiveExtrusion defines what happens with moves which extrude more than the MaxExtrusionPerMM.
+	// "clamp" reduces their extrusion to the max and logs a warning, "error" aborts the slicing.
+	ExcessiveExtrusion string
+
+	// Center is the point where the model is finally placed.
+	// For belt printers only the X coordinate is used.
+	Center MicroVec3
+
+	// BedWidth is the size of the bed in X direction.
+	// It is used to arrange several models. 0 means that the size is unknown.
+	BedWidth Millimeter
+
+	// BedDepth is the size of the bed in Y direction.
+	// It is used to arrange several models. 0 means that the size is unknown.
+	// For belt printers it is ignored as the belt is endless.
+	BedDepth Millimeter
+
+	// Kinematics is the type of the printer.
+	// Possible values are "cartesian" and "belt".
+	Kinematics string
+
+	// Firmware is the firmware of the printer. It defines the commands used for features which differ between firmwares.
+	// Possible values are "marlin" and "klipper".
+	Firmware string
+
+	// ArcFitting replaces curved paths by arc moves (G2 / G3), which makes the gcode smaller and the motion smoother.
+	// The firmware has to support arcs, e.g. ARC_SUPPORT for marlin or [gcode_arcs] for klipper.
+	// It is not used if the positions are transformed, e.g. for belt printers.
+	ArcFitting bool
+
+	// ArcTolerance is the max distance between the points of a path and the arc which replaces them.
+	ArcTolerance Millimeter
+
+	// BedMeshProfile is the name of the bed mesh profile which is loaded at the start.
+	// For marlin it is the number of the EEPROM slot. If it is empty, no bed mesh is loaded.
+	BedMeshProfile string
+
+	Belt BeltOptions
+}
+
+// BeltOptions contains all options for belt printers.
+// On belt printers the gantry is tilted by the Angle and Y is the belt.
+// The GCode uses X and Y for the position on the tilted gantry and Z for the belt.
+type BeltOptions struct {
+	// Angle is the angle in degree between the gantry and the belt.
+	Angle int
+
+	// EjectDistance is the distance the belt is advanced after the print.
+	EjectDistance Millimeter
+}
+
+// GoSliceOptions contains all options related to GoSlice itself.
+type GoSliceOptions struct {
+	// PrintVersion indicates if the GoSlice version should be printed.
+	PrintVersion bool
+
+	// InputFilePath specifies the path to the input stl file.
+	// If it is "-", the model is read from stdin.
+	// If it is a http or https URL, the model is downloaded.
+	InputFilePath string
+
+	// InputFilePaths specifies the paths of all models if several models are sliced together.
+	// If it is empty, only InputFilePath is used.
+	InputFilePaths []string
+
+	// InputFormat is the format of the model if it is read from stdin and its format cannot be detected by the content.
+	// Possible values are "stl", "obj", "ply", "3mf", "step", "svg" and "dxf".
+	InputFormat string
+
+	// InputUnit is the unit of the coordinates of the input models.
+	// Possible values are "auto", "mm", "cm", "m" and "inch".
+	// "auto" uses the unit defined by 3mf and step files and millimeter for all other formats.
+	// All other values replace the unit of the file.
+	InputUnit string
+
+	// InputScale is the factor by which the input models are scaled after the unit is applied.
+	InputScale float64
+
+	// ExtrudeHeight is the height to which the closed outlines of 2D svg and dxf files are extruded.
+	ExtrudeHeight Millimeter
+
+	// MaxDownloadSize is the max size in MB of a model which is downloaded from a http or https URL.
+	// 0 means no limit.
+	MaxDownloadSize int
+
+	// DownloadTimeout is the max time in seconds the download of a model from a http or https URL may take.
+	// 0 means no limit.
+	DownloadTimeout int
+
+	// StepTessellator is an external command which is used to convert STEP files
+	// with surfaces GoSlice cannot tessellate itself to STL.
+	// The placeholders {input} and {output} are replaced by the paths of the STEP and the STL file.
+	StepTessellator string
+
+	// OutputFilePath specifies the path to the output gcode file.
+	OutputFilePath string
+
+	// Force allows to overwrite an existing output gcode file.
+	// The input models are never overwritten.
+	Force bool
+
+	// StatsFilePath specifies the path to a json file to which the statistics of the generated gcode are written.
+	// If it is empty, no statistics are written.
+	StatsFilePath string
+
+	// ToolPathFilePath specifies the path to a json file to which the tool paths are written (see ToolPathWriter).
+	// If it is empty, no tool paths are written.
+	ToolPathFilePath string
+
+	// SaveLayersFilePath specifies the path to a file to which the layers are written after all modifiers were applied.
+	// If it is empty, the layers are not saved.
+	SaveLayersFilePath string
+
+	// LoadLayersFilePath specifies the path to a file written using SaveLayersFilePath.
+	// If it is set, the layers are loaded from it instead of slicing and modifying the model again.
+	// The model is still read as the renderers need it.
+	LoadLayersFilePath string
+
+	// LayerOverridesFilePath specifies the path to a yaml file which overrides settings for ranges of layers (see LayerOverride).
+	// If it is empty, all layers use the same settings.
+	LayerOverridesFilePath string
+
+	// LayerWindow is the number of layers which are sliced, modified and generated at once.
+	// Limiting it reduces the memory needed for big models as only the layers of the current window,
+	// including the layers the modifiers need around it, are kept in memory.
+	// The faces of stl files are then kept in a temporary file and only the faces of the current window are loaded.
+	// 0 processes all layers at once.
+	// If a handler needs all layers, e.g. for the support generation, all layers are processed at once anyway.
+	LayerWindow int
+
+	// Workers is the number of layers which are sliced and modified in parallel.
+	// 0 uses the number of CPUs usable by GoSlice, 1 processes the layers one after another.
+	Workers int
+
+	// Summary enables a one-line summary per layer which is printed after the gcode is generated.
+	// It shows which features were printed, the number of parts and the estimated time of each layer.
+	Summary bool
+
+	// Logger can be used to redirect the log output to anything you want.
+	// All output in GoSlice just calls this logger.
+	Logger *log.Logger
+}
+
+// SlicingOptions contains all options related to slice a model.
+type SlicingOptions struct {
+	// MeldDistance is the distance which two points have to be
+	// within to count them as one point.
+	MeldDistance Micrometer
+
+	// WeldDistance is the distance which two vertices of the model have to be within
+	// to be joined to one vertex while optimizing the model.
+	// This closes hairline cracks of poorly exported meshes. 0 joins only equal vertices.
+	WeldDistance Micrometer
+
+	// JoinPolygonSnapDistance is the distance used to check if two open
+	// polygons can be snapped together to one bigger polygon.
+	// Checked by the start and endpoints of the polygons.
+	JoinPolygonSnapDistance Micrometer
+
+	// FinishPolygonSnapDistance is the max distance between start end endpoint of
+	// a polygon used to check if a open polygon can be closed.
+	FinishPolygonSnapDistance Micrometer
+
+	// EmptyLayers defines how layers which contain nothing to print are handled.
+	// Possible values are
+	//  * "travel" which moves to the height of the layer and marks it with a comment,
+	//  * "skip" which omits the layer in the gcode
+	//  * and "abort" which stops with an error.
+	EmptyLayers string
+
+	// SurfaceMode defines how the surfaces of the model are printed.
+	// Possible values are
+	//  * "normal" which prints only closed polygons as solid parts,
+	//  * "surface" which prints all slices of the surfaces, closed and open ones, as single lines without any infill,
+	//    e.g. for lampshades modelled as a single surface,
+	//  * and "both" which prints closed polygons as solid parts and the open slices additionally as single lines.
+	SurfaceMode string
+
+	// Instancing slices a model which is placed several times, e.g. if the same file is given several times, only once.
+	// The layers are then printed at the position of each copy.
+	// It is not used for belt printers and non-planar layers, as the layers of the copies differ there.
+	Instancing bool
+
+	// SliceFrom and SliceTo limit the printed layers to the layers whose top lies within this z range,
+	// e.g. to resume a failed print or to test a problematic section.
+	// The model is still sliced completely, so that e.g. the support and the skins are the same as in the complete print.
+	// A SliceTo of 0 prints all layers above SliceFrom.
+	SliceFrom Millimeter
+	SliceTo   Millimeter
+
+	Plane SlicingPlaneOptions
+
+	Repair RepairOptions
+}
+
+// RepairOptions contains the options for the repair of broken meshes before slicing.
+type RepairOptions struct {
+	// Enabled enables the repair of the model.
+	Enabled bool
+
+	// WeldDistance is the distance within which vertices are welded together.
+	WeldDistance Micrometer
+
+	// MaxHoleEdges is the max amount of edges a hole may have to be closed.
+	MaxHoleEdges int
+}
+
+// SlicingPlaneOptions contains the options for the experimental non-planar slicing modes.
+type SlicingPlaneOptions struct {
+	// Type is the shape of the layers.
+	// Possible values are "planar", "conical" and "tilted".
+	Type string
+
+	// Angle is the angle in degree of the conical or tilted layers.
+	Angle int
+}
+
+// Options contains all GoSlice options.
+type Options struct {
+	Slicing  SlicingOptions
+	Printer  PrinterOptions
+	Filament FilamentOptions
+	Print    PrintOptions
+	GoSlice  GoSliceOptions
+}
+
+func DefaultOptions() Options {
+	return Options{
+		Slicing: SlicingOptions{
+			MeldDistance:              30,
+			WeldDistance:              30,
+			JoinPolygonSnapDistance:   160,
+			FinishPolygonSnapDistance: 1000,
+			EmptyLayers:               "travel",
+			SurfaceMode:               "normal",
+			Instancing:                true,
+			SliceFrom:                 0,
+			SliceTo:                   0,
+			Plane: SlicingPlaneOptions{
+				Type:  "planar",
+				Angle: 30,
+			},
+			Repair: RepairOptions{
+				Enabled:      false,
+				WeldDistance: 1,
+				MaxHoleEdges: 20,
+			},
+		},
 		Print: PrintOptions{
 			IntialLayerSpeed:                       30,
 			LayerSpeed:                             60,
 			OuterPerimeterSpeed:                    40,
 			MoveSpeed:                              150,
+			Combing:                                false,
 			InitialLayerThickness:                  200,
 			LayerThickness:                         200,
 			InsetCount:                             2,
+			PerimeterOrder:                         "inner-first",
+			InsetJoinType:                          "square",
+			InsetMiterLimit:                        2,
+			PerimeterOverlapCompensation:           false,
+			VariableWidthPerimeters:                false,
+			GapFill:                                false,
+			GapFillMinWidth:                        Millimeter(0.1),
+			ThinWalls:                              false,
+			ThinWallMinWidth:                       Millimeter(0.1),
+			SeamPosition:                           "none",
+			SeamAngle:                              180,
+			ClockwisePerimeters:                    false,
+			ElephantFootCompensation:               0,
+			HoleCompensation:                       0,
+			ZOffset:                                0,
+			Spiralize:                              false,
 			InfillOverlapPercent:                   50,
+			InfillOverlap:                          0,
+			InfillTrimToPerimeter:                  false,
 			AdditionalInternalInfillOverlapPercent: 400,
 			InfillPercent:                          20,
+			InfillCombineLayers:                    1,
+			SolidInfillEvery:                       0,
 			InfillRotationDegree:                   45,
+			InfillAlignToPart:                      false,
 			InfillZigZag:                           false,
+			SkinZigZag:                             false,
+			SkinPattern:                            SkinPatternLines,
+			InfillPattern:                          "linear",
+			InfillAdaptiveDistance:                 Millimeter(5),
+			MaxSkinSpan:                            Millimeter(0),
+			SkinSupportLayers:                      2,
+			GradualInfillSteps:                     0,
+			GradualInfillStepHeight:                Millimeter(1.5),
+			SkinInset:                              Millimeter(0),
+			SkinPerimeters:                         0,
 			NumberBottomLayers:                     3,
 			NumberTopLayers:                        4,
+			ModelSpacing:                           Millimeter(10),
+			Transform: TransformOptions{
+				Scale: 1,
+			},
 			Support: SupportOptions{
-				Enabled:         false,
-				ThresholdAngle:  60,
-				TopGapLayers:    3,
-				InterfaceLayers: 2,
-				PatternSpacing:  Millimeter(2.5),
-				Gap:             Millimeter(0.6),
+				Enabled:                false,
+				ThresholdAngle:         60,
+				TopGapLayers:           3,
+				InterfaceLayers:        2,
+				FloorLayers:            0,
+				MaxBridgeLength:        Millimeter(0),
+				PatternSpacing:         Millimeter(2.5),
+				Pattern:                "zigzag",
+				PatternAngle:           90,
+				Gap:                    Millimeter(0.6),
+				SupportedBottomDensity: 100,
+				SupportedBottomSpeed:   0,
+				BrimCount:              0,
+				BrimMaxArea:            25,
+				MinArea:                0,
+				TowerDiameter:          Millimeter(0),
+				TowerMaxArea:           9,
+				Type:                   "grid",
+				Tree: TreeSupportOptions{
+					BranchAngle:    40,
+					BranchDiameter: Millimeter(3),
+					TipDiameter:    Millimeter(0.8),
+				},
 			},
 			BrimSkirt: BrimSkirtOptions{
-				SkirtCount:    2,
-				SkirtDistance: Millimeter(5),
-				BrimCount:     0,
+				SkirtCount:        2,
+				SkirtDistance:     Millimeter(5),
+				SkirtMinLength:    Millimeter(0),
+				SkirtHeight:       1,
+				BrimCount:         0,
+				BrimType:          "outer",
+				BrimEars:          false,
+				BrimEarsMaxAngle:  125,
+				OverlapPrecedence: "none",
+			},
+			OozeShield: OozeShieldOptions{
+				Enabled:  false,
+				Distance: Millimeter(2),
+				Angle:    60,
+			},
+			Polyhole: PolyholeOptions{
+				Enabled:     false,
+				MaxDiameter: Millimeter(10),
+			},
+			PrintableOverhang: PrintableOverhangOptions{
+				Enabled:  false,
+				MaxAngle: 55,
+			},
+			Hollow: HollowOptions{
+				Enabled:           false,
+				WallThickness:     Millimeter(2),
+				DrainHoleDiameter: 0,
+			},
+			ScarfSeam: ScarfSeamOptions{
+				Enabled: false,
+				Length:  Millimeter(10),
+				Steps:   10,
+			},
+			Bridge: BridgeOptions{
+				Enabled:  false,
+				Speed:    Millimeter(25),
+				Flow:     90,
+				FanSpeed: 255,
+			},
+			OverhangPerimeter: OverhangPerimeterOptions{
+				Enabled:   false,
+				Threshold: 50,
+				Speed:     Millimeter(20),
+				FanSpeed:  255,
+			},
+			Sequential: SequentialOptions{
+				Enabled:                 false,
+				ExtruderClearanceRadius: Millimeter(20),
+				GantryHeight:            Millimeter(20),
+			},
+			NonPlanarTop: NonPlanarTopOptions{
+				Enabled:   false,
+				MaxHeight: 200,
+			},
+			Interlocking: InterlockingOptions{
+				Enabled:    false,
+				BeamWidth:  Millimeter(0.8),
+				BeamLayers: 2,
+				Depth:      Millimeter(1.6),
 			},
 		},
 		Filament: FilamentOptions{
@@ -343,102 +1336,767 @@ func DefaultOptions() Options {
 			RetractionSpeed:              30,
 			RetractionLength:             Millimeter(2),
 			FanSpeed:                     NewDefaultFanSpeedOptions(),
+			FeatureFanSpeed:              FeatureFanSpeedOptions{FeatureToSpeedLUT: map[Feature]int{}},
+			FeatureTemperatureOffset:     FeatureTemperatureOptions{FeatureToOffsetLUT: map[Feature]int{}},
+			TemperatureHysteresis:        3,
 			ExtrusionMultiplier:          100,
 		},
 		Printer: PrinterOptions{
-			ExtrusionWidth: 400,
+			NozzleDiameter:           400,
+			ExtrusionWidth:           400,
+			FirstLayerExtrusionWidth: 0,
+			Acceleration:             0,
+			SquareCornerVelocity:     5,
+			MaxExtrusionPerMM:        0,
+			ToolChangeTime:           5,
+			ExcessiveExtrusion:       "clamp",
 			Center: NewMicroVec3(
 				Millimeter(100).ToMicrometer(),
 				Millimeter(100).ToMicrometer(),
 				0,
 			),
+			BedWidth:     Millimeter(200),
+			BedDepth:     Millimeter(200),
+			Kinematics:   "cartesian",
+			Firmware:     "marlin",
+			ArcFitting:   false,
+			ArcTolerance: Millimeter(0.02),
+			Belt: BeltOptions{
+				Angle:         45,
+				EjectDistance: Millimeter(50),
+			},
 		},
 		GoSlice: GoSliceOptions{
-			PrintVersion:   false,
-			InputFilePath:  "",
-			OutputFilePath: "",
-			Logger:         log.New(os.Stdout, "", 0),
+			PrintVersion:    false,
+			InputFilePath:   "",
+			InputFormat:     "stl",
+			InputUnit:       "auto",
+			InputScale:      1,
+			ExtrudeHeight:   Millimeter(2),
+			MaxDownloadSize: 256,
+			DownloadTimeout: 300,
+			OutputFilePath:  "",
+			Logger:          log.New(os.Stdout, "", 0),
 		},
 	}
 }
 
+// LayerExtrusionWidth returns the width of the extruded lines of the layer with the given number.
+func (p PrinterOptions) LayerExtrusionWidth(layerNr int) Micrometer {
+	if layerNr == 0 && p.FirstLayerExtrusionWidth > 0 {
+		return p.FirstLayerExtrusionWidth
+	}
+	return p.ExtrusionWidth
+}
+
+// ExtrusionWidthForNozzle returns a sensible extrusion width for the given nozzle diameter
+// which is 1.125 times the nozzle diameter.
+func ExtrusionWidthForNozzle(nozzleDiameter Micrometer) Micrometer {
+	return nozzleDiameter * 9 / 8
+}
+
+// MaxLayerThicknessForNozzle returns the max layer thickness which can be printed
+// with the given nozzle diameter which is 0.8 times the nozzle diameter.
+func MaxLayerThicknessForNozzle(nozzleDiameter Micrometer) Micrometer {
+	return nozzleDiameter * 4 / 5
+}
+
+var (
+	infillPatternsMutex sync.RWMutex
+	// infillPatterns contains the names of the infill patterns which can be selected (see RegisterInfillPattern).
+	infillPatterns = map[string]bool{}
+)
+
+// RegisterInfillPattern makes the name of an infill pattern known to Validate.
+// clip.RegisterPattern calls it for every pattern, so it does not have to be called directly.
+func RegisterInfillPattern(name string) {
+	infillPatternsMutex.Lock()
+	defer infillPatternsMutex.Unlock()
+	infillPatterns[name] = true
+}
+
+// checkInfillPattern returns false if the infill pattern is not registered.
+// It also returns the sorted names of all registered patterns.
+// If no pattern is registered at all, e.g. because the clip package is not used, every pattern is accepted.
+func checkInfillPattern(name string) (bool, []string) {
+	infillPatternsMutex.RLock()
+	defer infillPatternsMutex.RUnlock()
+
+	names := make([]string, 0, len(infillPatterns))
+	for pattern := range infillPatterns {
+		names = append(names, pattern)
+	}
+	sort.Strings(names)
+	return len(names) == 0 || infillPatterns[name], names
+}
+
+// The patterns which can be selected for the top and bottom skins (see PrintOptions.SkinPattern).
+const (
+	SkinPatternLines      = "lines"
+	SkinPatternConcentric = "concentric"
+)
+
+// SkinPatterns contains the names of all skin patterns.
+var SkinPatterns = []string{SkinPatternLines, SkinPatternConcentric}
+
+// checkSkinPattern returns false if the skin pattern is not one of SkinPatterns.
+func checkSkinPattern(name string) bool {
+	for _, pattern := range SkinPatterns {
+		if pattern == name {
+			return true
+		}
+	}
+	return false
+}
+
+// Validate checks the options for combinations which are physically impossible or at least questionable.
+// It returns a warning message for each problem found.
+// The options can still be used, but the print may fail.
+func (o Options) Validate() []string {
+	var warnings []string
+
+	nozzle := o.Printer.NozzleDiameter
+	if nozzle <= 0 {
+		return append(warnings, fmt.Sprintf("the nozzle diameter %vµm has to be bigger than 0", nozzle))
+	}
+
+	if o.Printer.ExtrusionWidth < nozzle {
+		warnings = append(warnings, fmt.Sprintf("the extrusion width %vµm is smaller than the nozzle diameter %vµm", o.Printer.ExtrusionWidth, nozzle))
+	}
+
+	if o.Printer.ExtrusionWidth > nozzle*2 {
+		warnings = append(warnings, fmt.Sprintf("the extrusion width %vµm is bigger than twice the nozzle diameter %vµm", o.Printer.ExtrusionWidth, nozzle))
+	}
+
+	if o.Printer.FirstLayerExtrusionWidth != 0 && (o.Printer.FirstLayerExtrusionWidth < nozzle || o.Printer.FirstLayerExtrusionWidth > nozzle*2) {
+		warnings = append(warnings, fmt.Sprintf("the first layer extrusion width %vµm has to be between the nozzle diameter %vµm and twice the nozzle diameter", o.Printer.FirstLayerExtrusionWidth, nozzle))
+	}
+
+	if o.Printer.Acceleration < 0 {
+		warnings = append(warnings, fmt.Sprintf("the acceleration %.3fmm/s² must not be negative", o.Printer.Acceleration))
+	}
+
+	if o.Printer.Acceleration > 0 && o.Printer.SquareCornerVelocity < 0 {
+		warnings = append(warnings, fmt.Sprintf("the square corner velocity %.3fmm/s must not be negative", o.Printer.SquareCornerVelocity))
+	}
+
+	if o.Printer.MaxExtrusionPerMM < 0 {
+		warnings = append(warnings, fmt.Sprintf("the max extrusion of %.3fmm per mm must not be negative", o.Printer.MaxExtrusionPerMM))
+	}
+
+	if o.Printer.ToolChangeTime < 0 {
+		warnings = append(warnings, fmt.Sprintf("the tool change time of %.3fs must not be negative", o.Printer.ToolChangeTime))
+	}
+
+	switch o.Printer.ExcessiveExtrusion {
+	case "clamp", "error":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the excessive extrusion handling %q is unknown", o.Printer.ExcessiveExtrusion))
+	}
+
+	maxLayerThickness := MaxLayerThicknessForNozzle(nozzle)
+	if o.Print.LayerThickness > maxLayerThickness {
+		warnings = append(warnings, fmt.Sprintf("the layer thickness %vµm is bigger than the max layer thickness %vµm for the nozzle diameter %vµm", o.Print.LayerThickness, maxLayerThickness, nozzle))
+	}
+
+	if o.Print.InitialLayerThickness > maxLayerThickness {
+		warnings = append(warnings, fmt.Sprintf("the initial layer thickness %vµm is bigger than the max layer thickness %vµm for the nozzle diameter %vµm", o.Print.InitialLayerThickness, maxLayerThickness, nozzle))
+	}
+
+	if o.Print.InfillCombineLayers < 1 {
+		warnings = append(warnings, fmt.Sprintf("the infill combine layer count %v has to be greater than 0", o.Print.InfillCombineLayers))
+	} else if combined := o.Print.LayerThickness * Micrometer(o.Print.InfillCombineLayers); o.Print.InfillCombineLayers > 1 && combined > nozzle {
+		warnings = append(warnings, fmt.Sprintf("the combined infill thickness %vµm of %v layers is bigger than the nozzle diameter %vµm", combined, o.Print.InfillCombineLayers, nozzle))
+	}
+
+	for _, r := range o.Print.LayerThicknessRanges.Ranges {
+		if r.LayerThickness > maxLayerThickness {
+			warnings = append(warnings, fmt.Sprintf("the layer thickness %vµm of the range %vmm is bigger than the max layer thickness %vµm for the nozzle diameter %vµm", r.LayerThickness, r.rangeString(), maxLayerThickness, nozzle))
+		}
+	}
+
+	switch o.Printer.Kinematics {
+	case "cartesian":
+	case "belt":
+		if o.Printer.Belt.Angle <= 0 || o.Printer.Belt.Angle >= 90 {
+			warnings = append(warnings, fmt.Sprintf("the belt angle %v° has to be between 0° and 90°", o.Printer.Belt.Angle))
+		}
+	default:
+		warnings = append(warnings, fmt.Sprintf("the kinematics %q is unknown", o.Printer.Kinematics))
+	}
+
+	switch o.Printer.Firmware {
+	case "marlin":
+		if o.Printer.BedMeshProfile != "" {
+			if slot, err := strconv.Atoi(o.Printer.BedMeshProfile); err != nil || slot < 0 {
+				warnings = append(warnings, fmt.Sprintf("the bed mesh profile %q has to be the number of an EEPROM slot for marlin", o.Printer.BedMeshProfile))
+			}
+		}
+	case "klipper":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the firmware %q is unknown", o.Printer.Firmware))
+	}
+
+	if o.Printer.ArcFitting && o.Printer.ArcTolerance <= 0 {
+		warnings = append(warnings, fmt.Sprintf("the arc tolerance %.3fmm has to be greater than 0", o.Printer.ArcTolerance))
+	}
+
+	if o.Filament.HeatSoakTime < 0 {
+		warnings = append(warnings, fmt.Sprintf("the heat soak time %vs must not be negative", o.Filament.HeatSoakTime))
+	}
+
+	switch o.Slicing.EmptyLayers {
+	case "travel", "skip", "abort":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the empty layer handling %q is unknown", o.Slicing.EmptyLayers))
+	}
+
+	if o.Print.OozeShield.Enabled {
+		if o.Print.OozeShield.Distance <= 0 {
+			warnings = append(warnings, fmt.Sprintf("the ooze shield distance %.3fmm has to be positive", o.Print.OozeShield.Distance))
+		}
+		if o.Print.OozeShield.Angle < 0 || o.Print.OozeShield.Angle > 90 {
+			warnings = append(warnings, fmt.Sprintf("the ooze shield angle %v° has to be between 0° and 90°", o.Print.OozeShield.Angle))
+		}
+	}
+
+	if o.Print.BrimSkirt.SkirtMinLength < 0 {
+		warnings = append(warnings, fmt.Sprintf("the skirt min length %.3fmm must not be negative", o.Print.BrimSkirt.SkirtMinLength))
+	}
+
+	if o.Print.BrimSkirt.SkirtCount > 0 && o.Print.BrimSkirt.SkirtHeight < 1 {
+		warnings = append(warnings, fmt.Sprintf("the skirt height of %v layers has to be at least 1", o.Print.BrimSkirt.SkirtHeight))
+	}
+
+	switch o.Print.BrimSkirt.BrimType {
+	case "outer", "inner", "both":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the brim type %q is unknown", o.Print.BrimSkirt.BrimType))
+	}
+
+	if o.Print.BrimSkirt.BrimEars && (o.Print.BrimSkirt.BrimEarsMaxAngle <= 0 || o.Print.BrimSkirt.BrimEarsMaxAngle >= 180) {
+		warnings = append(warnings, fmt.Sprintf("the brim ears max angle %v° has to be between 0° and 180°", o.Print.BrimSkirt.BrimEarsMaxAngle))
+	}
+
+	switch o.Print.BrimSkirt.OverlapPrecedence {
+	case "none", "brim", "support":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the brim overlap precedence %q is unknown", o.Print.BrimSkirt.OverlapPrecedence))
+	}
+
+	if o.Slicing.SliceFrom < 0 || o.Slicing.SliceTo < 0 {
+		warnings = append(warnings, fmt.Sprintf("the slice range from %.3fmm to %.3fmm must not be negative", o.Slicing.SliceFrom, o.Slicing.SliceTo))
+	} else if o.Slicing.SliceTo > 0 && o.Slicing.SliceTo < o.Slicing.SliceFrom {
+		warnings = append(warnings, fmt.Sprintf("the slice range from %.3fmm to %.3fmm is empty", o.Slicing.SliceFrom, o.Slicing.SliceTo))
+	}
+
+	switch o.Print.PerimeterOrder {
+	case "inner-first", "outer-first":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the perimeter order %q is unknown", o.Print.PerimeterOrder))
+	}
+
+	switch o.Print.InsetJoinType {
+	case "square", "round":
+	case "miter":
+		if o.Print.InsetMiterLimit < 2 {
+			warnings = append(warnings, fmt.Sprintf("the inset miter limit %v has to be at least 2", o.Print.InsetMiterLimit))
+		}
+	default:
+		warnings = append(warnings, fmt.Sprintf("the inset join type %q is unknown", o.Print.InsetJoinType))
+	}
+
+	switch o.Print.SeamPosition {
+	case "none", "aligned", "rear", "random", "nearest", "sharpest-corner":
+	case "angle":
+		if o.Print.SeamAngle < 0 || o.Print.SeamAngle >= 360 {
+			warnings = append(warnings, fmt.Sprintf("the seam angle %v° has to be between 0° and 359°", o.Print.SeamAngle))
+		}
+	default:
+		warnings = append(warnings, fmt.Sprintf("the seam position %q is unknown", o.Print.SeamPosition))
+	}
+
+	switch o.Slicing.SurfaceMode {
+	case "normal", "surface", "both":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the surface mode %q is unknown", o.Slicing.SurfaceMode))
+	}
+
+	switch o.GoSlice.InputUnit {
+	case "auto", "mm", "cm", "m", "inch":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the input unit %q is unknown", o.GoSlice.InputUnit))
+	}
+
+	if o.GoSlice.InputScale <= 0 {
+		warnings = append(warnings, fmt.Sprintf("the input scale %v has to be bigger than 0", o.GoSlice.InputScale))
+	}
+
+	if o.Slicing.WeldDistance < 0 {
+		warnings = append(warnings, fmt.Sprintf("the weld distance %vµm must not be negative, only equal vertices are joined", o.Slicing.WeldDistance))
+	}
+
+	if o.GoSlice.ExtrudeHeight <= 0 {
+		warnings = append(warnings, fmt.Sprintf("the extrude height %vmm has to be bigger than 0", o.GoSlice.ExtrudeHeight))
+	}
+
+	if o.GoSlice.MaxDownloadSize < 0 {
+		warnings = append(warnings, fmt.Sprintf("the max download size %vMB must not be negative, 0 means no limit", o.GoSlice.MaxDownloadSize))
+	}
+
+	if o.GoSlice.DownloadTimeout < 0 {
+		warnings = append(warnings, fmt.Sprintf("the download timeout %vs must not be negative, 0 means no limit", o.GoSlice.DownloadTimeout))
+	}
+
+	if o.GoSlice.LayerWindow < 0 {
+		warnings = append(warnings, fmt.Sprintf("the layer window %v has to be 0 or bigger, all layers are processed at once", o.GoSlice.LayerWindow))
+	}
+
+	if o.GoSlice.Workers < 0 {
+		warnings = append(warnings, fmt.Sprintf("the number of workers %v has to be 0 or bigger, the number of CPUs is used", o.GoSlice.Workers))
+	}
+
+	for _, transform := range append([]TransformOptions{o.Print.Transform}, o.Print.ModelTransforms...) {
+		if transform.Scale <= 0 {
+			warnings = append(warnings, fmt.Sprintf("the scale %v has to be bigger than 0", transform.Scale))
+		}
+		if strings.Trim(strings.ToLower(transform.Mirror), "xyz") != "" {
+			warnings = append(warnings, fmt.Sprintf("the mirror axes %q are unknown, possible axes are x, y and z", transform.Mirror))
+		}
+	}
+
+	if o.Print.GradualInfillSteps < 0 {
+		warnings = append(warnings, fmt.Sprintf("the gradual infill steps %v must not be negative", o.Print.GradualInfillSteps))
+	}
+	if o.Print.GradualInfillSteps > 0 && o.Print.GradualInfillStepHeight <= 0 {
+		warnings = append(warnings, fmt.Sprintf("the gradual infill step height %.3fmm has to be greater than 0", o.Print.GradualInfillStepHeight))
+	}
+	if o.Print.InfillAdaptiveDistance < 0 {
+		warnings = append(warnings, fmt.Sprintf("the infill adaptive distance %.3fmm must not be negative", o.Print.InfillAdaptiveDistance))
+	}
+	if o.Print.MaxSkinSpan > 0 && o.Print.MaxSkinSpan.ToMicrometer() < o.Printer.ExtrusionWidth {
+		warnings = append(warnings, fmt.Sprintf("the max skin span %vmm is smaller than the extrusion width %vµm, the extrusion width is used instead", o.Print.MaxSkinSpan, o.Printer.ExtrusionWidth))
+	}
+
+	if o.Print.SkinInset < 0 {
+		warnings = append(warnings, fmt.Sprintf("the skin inset %.3fmm must not be negative", o.Print.SkinInset))
+	}
+	if o.Print.SkinPerimeters < 0 {
+		warnings = append(warnings, fmt.Sprintf("the skin perimeter count %v must not be negative", o.Print.SkinPerimeters))
+	}
+
+	if o.Print.SolidInfillEvery < 0 {
+		warnings = append(warnings, fmt.Sprintf("the solid infill interval %v must not be negative", o.Print.SolidInfillEvery))
+	}
+
+	if o.Print.InfillOverlap < 0 {
+		warnings = append(warnings, fmt.Sprintf("the infill overlap %vµm must not be negative", o.Print.InfillOverlap))
+	}
+
+	if o.Print.GapFillMinWidth < 0 {
+		warnings = append(warnings, fmt.Sprintf("the gap fill min width %.3fmm must not be negative", o.Print.GapFillMinWidth))
+	}
+
+	if o.Print.ThinWallMinWidth < 0 {
+		warnings = append(warnings, fmt.Sprintf("the thin wall min width %.3fmm must not be negative", o.Print.ThinWallMinWidth))
+	}
+
+	if o.Print.ElephantFootCompensation < 0 {
+		warnings = append(warnings, fmt.Sprintf("the elephant foot compensation %vmm is negative, the first layer is printed wider", o.Print.ElephantFootCompensation))
+	}
+
+	if o.Print.HoleCompensation < 0 {
+		warnings = append(warnings, fmt.Sprintf("the hole compensation %vmm is negative, the holes are not changed", o.Print.HoleCompensation))
+	}
+
+	if o.Print.ZOffset != 0 && o.Printer.Kinematics == "belt" {
+		warnings = append(warnings, fmt.Sprintf("the z offset %vmm is ignored for belt printers", o.Print.ZOffset))
+	}
+
+	if o.Print.Sequential.Enabled && o.Printer.Kinematics == "belt" {
+		warnings = append(warnings, "sequential printing is not possible on belt printers, the objects are printed together")
+	}
+	if o.Print.Sequential.ExtruderClearanceRadius < 0 || o.Print.Sequential.GantryHeight < 0 {
+		warnings = append(warnings, fmt.Sprintf("the extruder clearance radius %vmm and the gantry height %vmm must not be negative", o.Print.Sequential.ExtruderClearanceRadius, o.Print.Sequential.GantryHeight))
+	}
+
+	otherExtruders := false
+	for _, extruder := range o.Print.ModelExtruders {
+		if extruder < 0 {
+			warnings = append(warnings, fmt.Sprintf("the model extruder %v must not be negative, the first extruder is used", extruder))
+		}
+		if extruder > 0 {
+			otherExtruders = true
+		}
+	}
+	if otherExtruders && o.Print.Sequential.Enabled && o.Printer.Kinematics != "belt" {
+		warnings = append(warnings, "the model extruders are ignored if the objects are printed one after the other")
+	}
+	if otherExtruders && o.Slicing.Plane.Type != "" && o.Slicing.Plane.Type != "planar" {
+		warnings = append(warnings, "the model extruders are ignored if the layers are not planar")
+	}
+	if otherExtruders && len(o.Print.ModelTransforms) > 0 {
+		warnings = append(warnings, "the model transforms are ignored if the models are printed by different extruders, the bodies are transformed together by the transform flags")
+	}
+	if otherExtruders && o.Print.PrintableOverhang.Enabled {
+		warnings = append(warnings, "the printable overhang is applied to the bodies of each extruder separately, so parts resting on the body of another extruder are cut away")
+	}
+	if o.Print.Interlocking.Enabled && (o.Print.Interlocking.BeamWidth <= 0 || o.Print.Interlocking.BeamLayers < 1 || o.Print.Interlocking.Depth <= 0) {
+		warnings = append(warnings, fmt.Sprintf("the interlocking beam width %vmm, beam layers %v and depth %vmm have to be bigger than 0", o.Print.Interlocking.BeamWidth, o.Print.Interlocking.BeamLayers, o.Print.Interlocking.Depth))
+	}
+
+	if o.Print.ScarfSeam.Enabled && (o.Print.ScarfSeam.Length <= 0 || o.Print.ScarfSeam.Steps < 1) {
+		warnings = append(warnings, fmt.Sprintf("the scarf seam length %vmm and steps %v have to be bigger than 0", o.Print.ScarfSeam.Length, o.Print.ScarfSeam.Steps))
+	}
+	if o.Print.ScarfSeam.Enabled && o.Print.VariableWidthPerimeters {
+		warnings = append(warnings, "the scarf seam is not used for the perimeters whose width is adapted by the variable width perimeters")
+	}
+
+	if o.Print.Bridge.Speed < 0 {
+		warnings = append(warnings, fmt.Sprintf("the bridge speed %.3fmm/s must not be negative", o.Print.Bridge.Speed))
+	}
+	if o.Print.Bridge.Flow <= 0 {
+		warnings = append(warnings, fmt.Sprintf("the bridge flow %v%% has to be greater than 0", o.Print.Bridge.Flow))
+	}
+	if o.Print.Bridge.FanSpeed < 0 || o.Print.Bridge.FanSpeed > 255 {
+		warnings = append(warnings, fmt.Sprintf("the bridge fan speed %v has to be between 0 and 255", o.Print.Bridge.FanSpeed))
+	}
+
+	if o.Print.OverhangPerimeter.Threshold < 1 || o.Print.OverhangPerimeter.Threshold > 100 {
+		warnings = append(warnings, fmt.Sprintf("the overhang perimeter threshold %v%% has to be between 1 and 100", o.Print.OverhangPerimeter.Threshold))
+	}
+	if o.Print.OverhangPerimeter.Speed < 0 {
+		warnings = append(warnings, fmt.Sprintf("the overhang perimeter speed %.3fmm/s must not be negative", o.Print.OverhangPerimeter.Speed))
+	}
+	if o.Print.OverhangPerimeter.FanSpeed < 0 || o.Print.OverhangPerimeter.FanSpeed > 255 {
+		warnings = append(warnings, fmt.Sprintf("the overhang perimeter fan speed %v has to be between 0 and 255", o.Print.OverhangPerimeter.FanSpeed))
+	}
+
+	if o.Print.Hollow.Enabled && o.Print.Hollow.WallThickness.ToMicrometer() < o.Printer.ExtrusionWidth {
+		warnings = append(warnings, fmt.Sprintf("the hollow wall thickness %vmm is smaller than the extrusion width %vµm", o.Print.Hollow.WallThickness, o.Printer.ExtrusionWidth))
+	}
+
+	if o.Print.Support.SupportedBottomDensity <= 0 || o.Print.Support.SupportedBottomDensity > 100 {
+		warnings = append(warnings, fmt.Sprintf("the supported bottom density %v%% has to be between 1%% and 100%%", o.Print.Support.SupportedBottomDensity))
+	}
+
+	if o.Print.Support.MaxBridgeLength < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support max bridge length %.3fmm must not be negative", o.Print.Support.MaxBridgeLength))
+	}
+	if o.Print.Support.FloorLayers < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support floor layers %v must not be negative", o.Print.Support.FloorLayers))
+	}
+	if o.Print.Support.BrimCount < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support brim count %v must not be negative", o.Print.Support.BrimCount))
+	}
+	if o.Print.Support.BrimMaxArea < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support brim max area %.3fmm² must not be negative", o.Print.Support.BrimMaxArea))
+	}
+	if o.Print.Support.MinArea < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support min area %.3fmm² must not be negative", o.Print.Support.MinArea))
+	}
+	if o.Print.Support.TowerDiameter < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support tower diameter %.3fmm must not be negative", o.Print.Support.TowerDiameter))
+	}
+	if o.Print.Support.TowerMaxArea < 0 {
+		warnings = append(warnings, fmt.Sprintf("the support tower max area %.3fmm² must not be negative", o.Print.Support.TowerMaxArea))
+	}
+
+	if !checkSkinPattern(o.Print.SkinPattern) {
+		warnings = append(warnings, fmt.Sprintf("the skin pattern %q is unknown, possible patterns are %v", o.Print.SkinPattern, SkinPatterns))
+	}
+
+	if known, names := checkInfillPattern(o.Print.InfillPattern); !known {
+		warnings = append(warnings, fmt.Sprintf("the infill pattern %q is unknown, possible patterns are %v, the linear pattern is used", o.Print.InfillPattern, names))
+	}
+
+	switch o.Print.Support.Pattern {
+	case "zigzag", "lines", "grid", "concentric":
+	default:
+		warnings = append(warnings, fmt.Sprintf("the support pattern %q is unknown", o.Print.Support.Pattern))
+	}
+
+	switch o.Print.Support.Type {
+	case "grid":
+	case "tree":
+		tree := o.Print.Support.Tree
+		if tree.BranchAngle <= 0 || tree.BranchAngle >= 90 {
+			warnings = append(warnings, fmt.Sprintf("the tree support branch angle %v° has to be between 0° and 90°", tree.BranchAngle))
+		}
+		if tree.TipDiameter <= 0 || tree.BranchDiameter < tree.TipDiameter {
+			warnings = append(warnings, fmt.Sprintf("the tree support tip diameter %.3fmm has to be bigger than 0 and not bigger than the branch diameter %.3fmm", tree.TipDiameter, tree.BranchDiameter))
+		}
+	default:
+		warnings = append(warnings, fmt.Sprintf("the support type %q is unknown", o.Print.Support.Type))
+	}
+
+	return warnings
+}
+
 // ParseFlags parses the command line flags.
 // It returns the default options but sets all passed options.
 func ParseFlags() Options {
-	options := DefaultOptions()
-
 	flag.Usage = func() {
-		_, _ = fmt.Fprintf(os.Stderr, "Usage of goslice: goslice STL_FILE [flags]\n")
+		_, _ = fmt.Fprintf(os.Stderr, "Usage of goslice: goslice STL_FILE [STL_FILE...] [flags]\nUse - as STL_FILE to read the model from stdin.\nSeveral models are placed next to each other and sliced together.\nUse \"goslice diff\" to compare the statistics of two slicings.\nUse \"goslice serve\" to preview the tool paths in the browser.\n")
 		flag.PrintDefaults()
 	}
 
+	// The command line flag set exits on errors, so no error is returned here.
+	options, _ := parseFlagSet(flag.CommandLine, os.Args[1:], DefaultOptions())
+	return options
+}
+
+// ParseArgs parses the given args in the same way as the command line flags.
+// It can be used to get the options of several profiles, e.g. to compare them.
+func ParseArgs(args []string) (Options, error) {
+	return DefaultOptions().Override(args)
+}
+
+// Override parses the given args in the same way as the command line flags
+// but uses the options as defaults, so that only the options given by the args are changed.
+func (o Options) Override(args []string) (Options, error) {
+	fs := flag.NewFlagSet("goslice", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	return parseFlagSet(fs, args, o)
+}
+
+// parseFlagSet registers all options at the flag set using the given options as defaults and parses the args.
+func parseFlagSet(fs *flag.FlagSet, args []string, options Options) (Options, error) {
+
 	// GoSlice options
-	flag.BoolVarP(&options.GoSlice.PrintVersion, "version", "v", false, "Print the GoSlice version.")
-	flag.StringVarP(&options.GoSlice.OutputFilePath, "output", "o", options.GoSlice.OutputFilePath, "File path for the output gcode file. Default is the inout file path with .gcode as file ending.")
+	fs.BoolVarP(&options.GoSlice.PrintVersion, "version", "v", false, "Print the GoSlice version.")
+	fs.StringVarP(&options.GoSlice.OutputFilePath, "output", "o", options.GoSlice.OutputFilePath, "File path for the output gcode file. Default is the inout file path with .gcode as file ending.")
+	fs.BoolVarP(&options.GoSlice.Force, "force", "f", options.GoSlice.Force, "Overwrite the output gcode file if it already exists.")
+	fs.StringVar(&options.GoSlice.InputFormat, "input-format", options.GoSlice.InputFormat, "The format of the model read from stdin if it cannot be detected by its content. Can be \"stl\", \"obj\", \"ply\", \"3mf\", \"step\", \"svg\" or \"dxf\". Compressed models can be read using \"zip\" or e.g. \"stl.gz\".")
+	fs.StringVar(&options.GoSlice.InputUnit, "input-unit", options.GoSlice.InputUnit, "The unit of the input coordinates. Can be \"auto\", \"mm\", \"cm\", \"m\" or \"inch\". \"auto\" uses the unit of 3mf and step files and mm for all other formats.")
+	fs.Float64Var(&options.GoSlice.InputScale, "input-scale", options.GoSlice.InputScale, "The factor by which the input models are scaled after the input unit is applied.")
+	fs.Var(&options.GoSlice.ExtrudeHeight, "extrude-height", "The height to which the closed outlines of 2D svg and dxf files are extruded.")
+	fs.IntVar(&options.GoSlice.MaxDownloadSize, "max-download-size", options.GoSlice.MaxDownloadSize, "The max size in MB of a model downloaded from a http or https URL. 0 means no limit.")
+	fs.IntVar(&options.GoSlice.DownloadTimeout, "download-timeout", options.GoSlice.DownloadTimeout, "The max time in seconds the download of a model from a http or https URL may take. 0 means no limit.")
+	fs.StringVar(&options.GoSlice.StepTessellator, "step-tessellator", options.GoSlice.StepTessellator, "External command used to tessellate STEP files with surfaces GoSlice cannot tessellate itself, e.g. \"gmsh {input} -2 -format stl -o {output}\". {input} and {output} are replaced by the paths of the STEP file and of the STL file to create.")
+	fs.StringVar(&options.GoSlice.ToolPathFilePath, "toolpath", options.GoSlice.ToolPathFilePath, "File path for a json file containing the tool paths with the feature, extrusion, speed and estimated time of each move, e.g. to drive machines which do not understand gcode.")
+	fs.StringVar(&options.GoSlice.StatsFilePath, "stats", options.GoSlice.StatsFilePath, "File path for a json file containing statistics about the generated gcode such as the estimated print time. These files can be compared using \"goslice diff\".")
+	fs.StringVar(&options.GoSlice.SaveLayersFilePath, "save-layers", options.GoSlice.SaveLayersFilePath, "File path to which the layers are saved after all modifiers were applied. They can be loaded using --load-layers.")
+	fs.StringVar(&options.GoSlice.LoadLayersFilePath, "load-layers", options.GoSlice.LoadLayersFilePath, "File path of layers saved using --save-layers. They are used instead of slicing and modifying the model again, e.g. while working on the gcode generation. The options used to save them should be the same.")
+	fs.StringVar(&options.GoSlice.LayerOverridesFilePath, "layer-overrides", options.GoSlice.LayerOverridesFilePath, "File path for a yaml file which overrides settings for ranges of layers, e.g. a higher infill percent for some layers. The settings are given by their flag names and are applied while the gcode is generated, so only the speeds, the fan speed and the infill pattern settings can be overridden.")
+	fs.IntVar(&options.GoSlice.LayerWindow, "layer-window", options.GoSlice.LayerWindow, "The number of layers which are sliced, modified and generated at once to reduce the memory used for the layers and faces of big models. 0 processes all layers at once. Models using support, printable overhangs or drain holes are always processed at once.")
+	fs.IntVar(&options.GoSlice.Workers, "workers", options.GoSlice.Workers, "The number of layers which are sliced and modified in parallel. 0 uses the number of CPUs.")
+	fs.BoolVar(&options.GoSlice.Summary, "summary", options.GoSlice.Summary, "Print a one-line summary per layer after generating the gcode. It shows which features were printed (P: perimeters, I: infill, S: support, T: top skin, B: bottom skin), the number of parts and the estimated time of each layer.")
 
 	// Slicing options
-	flag.Var(&options.Slicing.MeldDistance, "meld-distance", "The distance which two points have to be within to count them as one point.")
-	flag.Var(&options.Slicing.JoinPolygonSnapDistance, "join-polygon-snap-distance", "The distance used to check if two open polygons can be snapped together to one bigger polygon. Checked by the start and endpoints of the polygons.")
-	flag.Var(&options.Slicing.FinishPolygonSnapDistance, "finish-polygon-snap-distance", "The max distance between start end endpoint of a polygon used to check if a open polygon can be closed.")
+	fs.Var(&options.Slicing.MeldDistance, "meld-distance", "The distance which two points have to be within to count them as one point.")
+	fs.Var(&options.Slicing.WeldDistance, "weld-distance", "The distance which two vertices of the model have to be within to be joined to one vertex. This closes hairline cracks of poorly exported meshes. 0 joins only equal vertices.")
+	fs.Var(&options.Slicing.JoinPolygonSnapDistance, "join-polygon-snap-distance", "The distance used to check if two open polygons can be snapped together to one bigger polygon. Checked by the start and endpoints of the polygons.")
+	fs.Var(&options.Slicing.FinishPolygonSnapDistance, "finish-polygon-snap-distance", "The max distance between start end endpoint of a polygon used to check if a open polygon can be closed.")
+	fs.StringVar(&options.Slicing.SurfaceMode, "surface-mode", options.Slicing.SurfaceMode, "How the surfaces of the model are printed. Can be \"normal\" (closed polygons as solid parts), \"surface\" (all slices of the surfaces as single lines, e.g. for lampshades) or \"both\" (closed polygons as solid parts and open slices as single lines).")
+	fs.Var(&options.Slicing.SliceFrom, "slice-from", "Prints only the layers from this z on, e.g. to resume a failed print. The starting gcode lifts the nozzle above this z.")
+	fs.Var(&options.Slicing.SliceTo, "slice-to", "Prints only the layers up to this z, e.g. to test a section of the model. 0 prints all layers.")
+	fs.BoolVar(&options.Slicing.Instancing, "instancing", options.Slicing.Instancing, "Slices a model which is placed several times only once and prints the layers at the position of each copy.")
+	fs.StringVar(&options.Slicing.EmptyLayers, "empty-layers", options.Slicing.EmptyLayers, "How layers which contain nothing to print are handled. Can be \"travel\" (move to the layer height and mark it with a comment), \"skip\" (omit the layer) or \"abort\" (stop with an error).")
+	fs.StringVar(&options.Slicing.Plane.Type, "slicing-plane", options.Slicing.Plane.Type, "Experimental: the shape of the layers. Can be \"planar\", \"conical\" or \"tilted\".")
+	fs.IntVar(&options.Slicing.Plane.Angle, "slicing-plane-angle", options.Slicing.Plane.Angle, "The angle in degree of conical or tilted layers.")
+	fs.BoolVar(&options.Slicing.Repair.Enabled, "repair-enabled", options.Slicing.Repair.Enabled, "Repairs the model before slicing by welding vertices, fixing inverted normals, removing degenerate and duplicate faces and closing small holes.")
+	fs.Var(&options.Slicing.Repair.WeldDistance, "repair-weld-distance", "The distance within which vertices are welded together by the repair.")
+	fs.IntVar(&options.Slicing.Repair.MaxHoleEdges, "repair-max-hole-edges", options.Slicing.Repair.MaxHoleEdges, "The max amount of edges a hole may have to be closed by the repair.")
 
 	// print options
-	flag.Var(&options.Print.IntialLayerSpeed, "initial-layer-speed", "The speed only for the first layer in mm per second.")
-	flag.Var(&options.Print.LayerSpeed, "layer-speed", "The speed for all but the first layer in mm per second.")
-	flag.Var(&options.Print.OuterPerimeterSpeed, "outer-perimeter-speed", "The speed only for outer perimeters.")
-	flag.Var(&options.Print.MoveSpeed, "move-speed", "The speed for all non printing moves.")
-	flag.Var(&options.Print.InitialLayerThickness, "initial-layer-thickness", "The layer thickness for the first layer.")
-	flag.Var(&options.Print.LayerThickness, "layer-thickness", "The thickness for all but the first layer.")
-	flag.IntVar(&options.Print.InsetCount, "inset-count", options.Print.InsetCount, "The number of perimeters.")
-	flag.IntVar(&options.Print.InfillOverlapPercent, "infill-overlap-percent", options.Print.InfillOverlapPercent, "The percentage of overlap into the perimeters.")
-	flag.IntVar(&options.Print.AdditionalInternalInfillOverlapPercent, "additional-internal-infill-overlap-percent", options.Print.AdditionalInternalInfillOverlapPercent, "The percentage used to make the internal infill (infill not blocked by the perimeters) even bigger so that it grows a bit into the model.")
-	flag.IntVar(&options.Print.InfillPercent, "infill-percent", options.Print.InfillPercent, "The amount of infill which should be generated.")
-	flag.IntVar(&options.Print.InfillRotationDegree, "infill-rotation-degree", options.Print.InfillRotationDegree, "The rotation used for the infill.")
-	flag.BoolVar(&options.Print.InfillZigZag, "infill-zig-zag", options.Print.InfillZigZag, "Sets if the infill should use connected lines in zig zag form.")
-	flag.IntVar(&options.Print.NumberBottomLayers, "number-bottom-layers", options.Print.NumberBottomLayers, "The amount of layers the bottom layers should grow into the model.")
-	flag.IntVar(&options.Print.NumberTopLayers, "number-top-layers", options.Print.NumberTopLayers, "The amount of layers the bottom layers should grow into the model.")
+	fs.Var(&options.Print.IntialLayerSpeed, "initial-layer-speed", "The speed only for the first layer in mm per second.")
+	fs.Var(&options.Print.LayerSpeed, "layer-speed", "The speed for all but the first layer in mm per second.")
+	fs.Var(&options.Print.OuterPerimeterSpeed, "outer-perimeter-speed", "The speed only for outer perimeters.")
+	fs.Var(&options.Print.MoveSpeed, "move-speed", "The speed for all non printing moves.")
+	fs.BoolVar(&options.Print.Combing, "combing", options.Print.Combing, "Routes the non printing moves within the same part inside of the part instead of crossing its perimeters, which avoids retractions and stringing on the surface.")
+	fs.Var(&options.Print.InitialLayerThickness, "initial-layer-thickness", "The layer thickness for the first layer.")
+	fs.Var(&options.Print.LayerThickness, "layer-thickness", "The thickness for all but the first layer.")
+	fs.Var(&options.Print.LayerThicknessRanges, "layer-thickness-ranges", "Overrides the layer thickness in µm for the layers starting within z ranges in mm, e.g. 0-10=300,10-15=120.")
+	fs.IntVar(&options.Print.InsetCount, "inset-count", options.Print.InsetCount, "The number of perimeters.")
+	fs.StringVar(&options.Print.PerimeterOrder, "perimeter-order", options.Print.PerimeterOrder, "The order in which the perimeters of each part are printed. Can be \"inner-first\" (the outer perimeter last, better for overhangs) or \"outer-first\" (better dimensional accuracy).")
+	fs.StringVar(&options.Print.InsetJoinType, "inset-join-type", options.Print.InsetJoinType, "How the corners of the perimeters are joined. Can be \"square\", \"round\" or \"miter\".")
+	fs.Float64Var(&options.Print.InsetMiterLimit, "inset-miter-limit", options.Print.InsetMiterLimit, "The max distance of a corner from the original corner as multiple of the offset if inset-join-type is \"miter\". Sharper corners are cut off.")
+	fs.StringVar(&options.Print.SeamPosition, "seam-position", options.Print.SeamPosition, "Where each closed perimeter starts. Can be \"none\" (the start of the calculated perimeter), \"aligned\" (near the seams of the layer below), \"rear\" (the rear most point), \"random\", \"nearest\" (the point nearest to the current position), \"angle\" (the point in the direction of seam-angle seen from the center of the model) or \"sharpest-corner\" (the sharpest concave corner of each perimeter).")
+	fs.IntVar(&options.Print.SeamAngle, "seam-angle", options.Print.SeamAngle, "The compass angle in degree of the seams if seam-position is \"angle\". 0 is the front, 90 the right, 180 the rear and 270 the left side.")
+	fs.BoolVar(&options.Print.ClockwisePerimeters, "clockwise-perimeters", options.Print.ClockwisePerimeters, "Prints the outer contours clockwise and the holes counter clockwise instead of the other way round.")
+	fs.BoolVar(&options.Print.PerimeterOverlapCompensation, "perimeter-overlap-compensation", options.Print.PerimeterOverlapCompensation, "Reduces the flow where perimeters overlap each other, e.g. on thin walls.")
+	fs.BoolVar(&options.Print.VariableWidthPerimeters, "variable-width-perimeters", options.Print.VariableWidthPerimeters, "Widens the perimeters where the wall is too thin for the next perimeter, so that no gap remains between them.")
+	fs.BoolVar(&options.Print.GapFill, "gap-fill", options.Print.GapFill, "Fills the gaps between the perimeters which are too narrow for another perimeter with single lines of the width of the gap.")
+	fs.Var(&options.Print.GapFillMinWidth, "gap-fill-min-width", "The width of the narrowest gap which is filled if gap-fill is enabled.")
+	fs.BoolVar(&options.Print.ThinWalls, "thin-walls", options.Print.ThinWalls, "Prints the walls which are too thin for the outer perimeter as a single line along their center with the width of the wall.")
+	fs.Var(&options.Print.ThinWallMinWidth, "thin-wall-min-width", "The width of the thinnest wall which is printed if thin-walls is enabled.")
+	fs.Var(&options.Print.ElephantFootCompensation, "elephant-foot-compensation", "The distance by which the perimeters of the first layer are moved inwards to compensate the first layer being squished onto the bed. The inner perimeters and the infill follow them.")
+	fs.Var(&options.Print.HoleCompensation, "hole-compensation", "The distance by which the contours of all holes are moved outwards to compensate holes being printed too small.")
+	fs.Var(&options.Print.ZOffset, "z-offset", "Shifts all z heights in the gcode by this signed distance in mm, e.g. to correct the probe offset of the printer.")
+	fs.BoolVar(&options.Print.Spiralize, "spiralize", options.Print.Spiralize, "Prints the model as a vase: above the bottom layers only the outer contour is printed as one continuously rising line.")
+	fs.IntVar(&options.Print.InfillOverlapPercent, "infill-overlap-percent", options.Print.InfillOverlapPercent, "The percentage of overlap into the perimeters.")
+	fs.Var(&options.Print.InfillOverlap, "infill-overlap", "The overlap in µm into the perimeters. If it is greater than 0, it is used instead of infill-overlap-percent.")
+	fs.BoolVar(&options.Print.InfillTrimToPerimeter, "infill-trim-to-perimeter", options.Print.InfillTrimToPerimeter, "Trims the infill lines exactly at the center line of the most inner perimeter. The infill-overlap-percent is ignored for the perimeters if it is enabled.")
+	fs.IntVar(&options.Print.AdditionalInternalInfillOverlapPercent, "additional-internal-infill-overlap-percent", options.Print.AdditionalInternalInfillOverlapPercent, "The percentage used to make the internal infill (infill not blocked by the perimeters) even bigger so that it grows a bit into the model.")
+	fs.IntVar(&options.Print.InfillPercent, "infill-percent", options.Print.InfillPercent, "The amount of infill which should be generated.")
+	fs.IntVar(&options.Print.InfillCombineLayers, "infill-combine-layers", options.Print.InfillCombineLayers, "The amount of layers whose sparse infill is printed at once with multiplied flow. 1 prints the infill on each layer.")
+	fs.IntVar(&options.Print.SolidInfillEvery, "solid-infill-every", options.Print.SolidInfillEvery, "Prints the sparse infill of every n-th layer as solid infill to strengthen tall parts. 0 disables it.")
+	fs.IntVar(&options.Print.InfillRotationDegree, "infill-rotation-degree", options.Print.InfillRotationDegree, "The rotation used for the infill.")
+	fs.BoolVar(&options.Print.InfillAlignToPart, "infill-align-to-part", options.Print.InfillAlignToPart, "Aligns the infill of each part with the principal axis of the part instead of using the infill rotation.")
+	fs.BoolVar(&options.Print.InfillZigZag, "infill-zig-zag", options.Print.InfillZigZag, "Sets if the infill should use connected lines in zig zag form.")
+	fs.BoolVar(&options.Print.SkinZigZag, "skin-zig-zag", options.Print.SkinZigZag, "Sets if the top and bottom skins should use connected lines in zig zag form.")
+	fs.StringVar(&options.Print.SkinPattern, "skin-pattern", options.Print.SkinPattern, "The pattern used for the top and bottom skins. Can be \"lines\" or \"concentric\".")
+	fs.StringVar(&options.Print.InfillPattern, "infill-pattern", options.Print.InfillPattern, "The name of the pattern used for the sparse infill. Built in are \"linear\", \"grid\", \"concentric\", \"cubic\" and \"adaptive-cubic\", more patterns can be registered by code using clip.RegisterPattern.")
+	fs.Var(&options.Print.InfillAdaptiveDistance, "infill-adaptive-distance", "The distance to the perimeters and to the top surfaces above within which the adaptive-cubic infill pattern uses the double density.")
+	fs.Var(&options.Print.MaxSkinSpan, "max-skin-span", "The max distance a top skin may bridge over the sparse infill. If the infill lines are further apart, the infill below the top skins is printed denser. 0 disables it.")
+	fs.IntVar(&options.Print.SkinSupportLayers, "skin-support-layers", options.Print.SkinSupportLayers, "The amount of layers below the top skins which are printed denser if needed.")
+	fs.IntVar(&options.Print.GradualInfillSteps, "gradual-infill-steps", options.Print.GradualInfillSteps, "The amount of steps below the top skins in which the infill density doubles. 0 disables it.")
+	fs.Var(&options.Print.GradualInfillStepHeight, "gradual-infill-step-height", "The height of each step of the gradual infill.")
+	fs.Var(&options.Print.SkinInset, "skin-inset", "The distance by which the top and bottom skins are pulled inside their area, e.g. to keep the skin lines away from the innermost perimeter.")
+	fs.IntVar(&options.Print.SkinPerimeters, "skin-perimeters", options.Print.SkinPerimeters, "The amount of perimeter loops printed around the top and bottom skins.")
+	fs.IntVar(&options.Print.NumberBottomLayers, "number-bottom-layers", options.Print.NumberBottomLayers, "The amount of layers the bottom layers should grow into the model.")
+	fs.IntVar(&options.Print.NumberTopLayers, "number-top-layers", options.Print.NumberTopLayers, "The amount of layers the bottom layers should grow into the model.")
+	fs.Var(&options.Print.ModelSpacing, "model-spacing", "The distance between the models if several models are sliced together.")
+
+	// transform options
+	fs.Float64Var(&options.Print.Transform.Scale, "scale", options.Print.Transform.Scale, "The factor by which the model is scaled.")
+	fs.StringVar(&options.Print.Transform.Mirror, "mirror", options.Print.Transform.Mirror, "The axes along which the model is mirrored, e.g. \"x\" or \"xy\".")
+	fs.Float64Var(&options.Print.Transform.RotateX, "rotate-x", options.Print.Transform.RotateX, "The rotation of the model around the X axis in degree.")
+	fs.Float64Var(&options.Print.Transform.RotateY, "rotate-y", options.Print.Transform.RotateY, "The rotation of the model around the Y axis in degree.")
+	fs.Float64Var(&options.Print.Transform.RotateZ, "rotate-z", options.Print.Transform.RotateZ, "The rotation of the model around the Z axis in degree.")
+	fs.Var(&options.Print.Transform.TranslateX, "translate-x", "The distance the model is moved in X direction from the center of the bed.")
+	fs.Var(&options.Print.Transform.TranslateY, "translate-y", "The distance the model is moved in Y direction from the center of the bed.")
+	fs.BoolVar(&options.Print.Transform.AutoOrient, "auto-orient", options.Print.Transform.AutoOrient, "Rotates the model so that the least support is needed.")
+	fs.Var(&options.Print.ModelTransforms, "model-transform", "The transform of one of several models, which is used instead of the transform flags, e.g. scale=2,rotate-z=90,translate-x=20. It can be repeated, once for each model in the order of the models. If a model is moved, the models are placed at their translations instead of being arranged on the bed.")
+	fs.IntSliceVar(&options.Print.ModelExtruders, "model-extruders", options.Print.ModelExtruders, "The extruder which prints each model, in the order of the models, e.g. 0,1. Models without an entry are printed by the first extruder (0).")
 
 	// support options
-	flag.BoolVar(&options.Print.Support.Enabled, "support-enabled", options.Print.Support.Enabled, "Enables the generation of support structures.")
-	flag.IntVar(&options.Print.Support.ThresholdAngle, "support-threshold-angle", options.Print.Support.ThresholdAngle, "The angle up to which no support is generated.")
-	flag.IntVar(&options.Print.Support.TopGapLayers, "support-top-gap-layers", options.Print.Support.TopGapLayers, "The amount of layers without support.")
-	flag.IntVar(&options.Print.Support.InterfaceLayers, "support-interface-layers", options.Print.Support.InterfaceLayers, "The amount of layers which are filled differently as interface to the object.")
-	flag.Var(&options.Print.Support.PatternSpacing, "support-pattern-spacing", "The spacing used to create the support pattern.")
-	flag.Var(&options.Print.Support.Gap, "support-gap", "The gap between the model and the support.")
+	fs.BoolVar(&options.Print.Support.Enabled, "support-enabled", options.Print.Support.Enabled, "Enables the generation of support structures.")
+	fs.IntVar(&options.Print.Support.ThresholdAngle, "support-threshold-angle", options.Print.Support.ThresholdAngle, "The angle up to which no support is generated.")
+	fs.IntVar(&options.Print.Support.TopGapLayers, "support-top-gap-layers", options.Print.Support.TopGapLayers, "The amount of layers without support.")
+	fs.IntVar(&options.Print.Support.InterfaceLayers, "support-interface-layers", options.Print.Support.InterfaceLayers, "The amount of layers which are filled differently as interface to the object.")
+	fs.Var(&options.Print.Support.MaxBridgeLength, "support-max-bridge-length", "The max length of overhangs anchored on both ends which are bridged instead of supported. 0 supports all overhangs.")
+	fs.IntVar(&options.Print.Support.FloorLayers, "support-floor-layers", options.Print.Support.FloorLayers, "The amount of the most bottom support layers which are filled densely where the support rests on the model.")
+	fs.Var(&options.Print.Support.PatternSpacing, "support-pattern-spacing", "The spacing used to create the support pattern.")
+	fs.StringVar(&options.Print.Support.Pattern, "support-pattern", options.Print.Support.Pattern, "The pattern used to fill the support. Can be \"zigzag\", \"lines\", \"grid\" or \"concentric\".")
+	fs.IntVar(&options.Print.Support.PatternAngle, "support-pattern-angle", options.Print.Support.PatternAngle, "The rotation in degree of the support pattern. The support interface is rotated by 90 degree to it.")
+	fs.Var(&options.Print.Support.Gap, "support-gap", "The gap between the model and the support.")
+	fs.IntVar(&options.Print.Support.SupportedBottomDensity, "support-supported-bottom-density", options.Print.Support.SupportedBottomDensity, "The density in percent of the bottom skin which rests on support.")
+	fs.Var(&options.Print.Support.SupportedBottomSpeed, "support-supported-bottom-speed", "The speed for the bottom skin which rests on support. 0 uses the normal speed.")
+	fs.IntVar(&options.Print.Support.BrimCount, "support-brim-count", options.Print.Support.BrimCount, "The amount of brim lines around support columns with a small footprint on the first layer. 0 disables it.")
+	fs.Float64Var(&options.Print.Support.BrimMaxArea, "support-brim-max-area", options.Print.Support.BrimMaxArea, "The max area in mm² of the footprint of a support column which gets a brim.")
+	fs.Float64Var(&options.Print.Support.MinArea, "support-min-area", options.Print.Support.MinArea, "The min area in mm² of an overhang which is supported. Smaller overhangs are only supported by towers.")
+	fs.Var(&options.Print.Support.TowerDiameter, "support-tower-diameter", "The diameter of the towers which support tiny overhangs. 0 disables the towers.")
+	fs.Float64Var(&options.Print.Support.TowerMaxArea, "support-tower-max-area", options.Print.Support.TowerMaxArea, "The max area in mm² of an overhang which is supported by a tower.")
+	fs.StringVar(&options.Print.Support.Type, "support-type", options.Print.Support.Type, "The type of the support. Can be \"grid\" (the whole area below the overhangs is filled) or \"tree\" (branches grow from the overhangs down to the bed or the model).")
+	fs.IntVar(&options.Print.Support.Tree.BranchAngle, "support-tree-branch-angle", options.Print.Support.Tree.BranchAngle, "The max angle in degree from the vertical in which the branches of the tree support may grow.")
+	fs.Var(&options.Print.Support.Tree.BranchDiameter, "support-tree-branch-diameter", "The diameter the branches of the tree support grow to below their tips.")
+	fs.Var(&options.Print.Support.Tree.TipDiameter, "support-tree-tip-diameter", "The diameter of the tips of the tree support which touch the overhangs.")
 
 	// brim & skirt options
-	flag.IntVar(&options.Print.BrimSkirt.SkirtCount, "skirt-count", options.Print.BrimSkirt.SkirtCount, "The amount of skirt lines around the initial layer.")
-	flag.Var(&options.Print.BrimSkirt.SkirtDistance, "skirt-distance", "The distance between the model (or the most outer brim lines) and the most inner skirt line.")
-	flag.IntVar(&options.Print.BrimSkirt.BrimCount, "brim-count", options.Print.BrimSkirt.BrimCount, "The amount of brim lines around the parts of the initial layer.")
+	fs.IntVar(&options.Print.BrimSkirt.SkirtCount, "skirt-count", options.Print.BrimSkirt.SkirtCount, "The amount of skirt lines around the initial layer.")
+	fs.Var(&options.Print.BrimSkirt.SkirtDistance, "skirt-distance", "The distance between the model (or the most outer brim lines) and the most inner skirt line.")
+	fs.Var(&options.Print.BrimSkirt.SkirtMinLength, "skirt-min-length", "The minimum length of all skirt lines together. More skirt lines are added until it is reached. 0 disables it.")
+	fs.IntVar(&options.Print.BrimSkirt.SkirtHeight, "skirt-height", options.Print.BrimSkirt.SkirtHeight, "The amount of layers on which the skirt is printed.")
+	fs.IntVar(&options.Print.BrimSkirt.BrimCount, "brim-count", options.Print.BrimSkirt.BrimCount, "The amount of brim lines around the parts of the initial layer.")
+	fs.StringVar(&options.Print.BrimSkirt.BrimType, "brim-type", options.Print.BrimSkirt.BrimType, "Where the brim is generated. Can be \"outer\" (around the objects), \"inner\" (inside the holes of the objects) or \"both\".")
+	fs.BoolVar(&options.Print.BrimSkirt.BrimEars, "brim-ears", options.Print.BrimSkirt.BrimEars, "Print the outer brim only at the sharp corners of the objects to save material.")
+	fs.IntVar(&options.Print.BrimSkirt.BrimEarsMaxAngle, "brim-ears-max-angle", options.Print.BrimSkirt.BrimEarsMaxAngle, "The max angle in degree of a corner which gets a brim ear.")
+	fs.StringVar(&options.Print.BrimSkirt.OverlapPrecedence, "brim-overlap-precedence", options.Print.BrimSkirt.OverlapPrecedence, "Which feature of the initial layer is kept where the features of several objects overlap. Can be \"none\" (the support is removed below the brims), \"brim\" (the brims are also clipped by other objects and the brims of larger objects) or \"support\" (the brims are clipped by other objects, larger brims and the support).")
+
+	// polyhole options
+	fs.BoolVar(&options.Print.Polyhole.Enabled, "polyhole-enabled", options.Print.Polyhole.Enabled, "Converts small circular holes to polyholes so that they match the nominal diameter.")
+	fs.Var(&options.Print.Polyhole.MaxDiameter, "polyhole-max-diameter", "The max diameter of holes which are converted to polyholes.")
+
+	// printable overhang options
+	fs.BoolVar(&options.Print.OozeShield.Enabled, "ooze-shield-enabled", options.Print.OozeShield.Enabled, "Prints a single wall around the model on each layer before the model which wipes off the filament oozing out of the nozzle.")
+	fs.Var(&options.Print.OozeShield.Distance, "ooze-shield-distance", "The distance between the model and the ooze shield.")
+	fs.IntVar(&options.Print.OozeShield.Angle, "ooze-shield-angle", options.Print.OozeShield.Angle, "The max angle the ooze shield may overhang, where 0 is vertical.")
+	fs.BoolVar(&options.Print.PrintableOverhang.Enabled, "printable-overhang-enabled", options.Print.PrintableOverhang.Enabled, "Changes the model so that no overhang exceeds the max angle. Can be used as an alternative to support.")
+	fs.IntVar(&options.Print.PrintableOverhang.MaxAngle, "printable-overhang-max-angle", options.Print.PrintableOverhang.MaxAngle, "The max angle an overhang may have if printable-overhang-enabled is set.")
+
+	// hollow options
+	fs.BoolVar(&options.Print.Hollow.Enabled, "hollow-enabled", options.Print.Hollow.Enabled, "Hollows the models so that only a shell with the hollow wall thickness remains. The cavity is printed without infill.")
+	fs.Var(&options.Print.Hollow.WallThickness, "hollow-wall-thickness", "The thickness of the shell which remains if hollow-enabled is set.")
+	fs.Var(&options.Print.Hollow.DrainHoleDiameter, "hollow-drain-hole-diameter", "The diameter of the holes punched through the bottom shell below each cavity if hollow-enabled is set. 0 disables them.")
+
+	// interlocking options
+	fs.BoolVar(&options.Print.Interlocking.Enabled, "interlocking-enabled", options.Print.Interlocking.Enabled, "Interlocks the bodies printed by different extruders with alternating beams of both materials at their boundaries.")
+	fs.Var(&options.Print.Interlocking.BeamWidth, "interlocking-beam-width", "The width of each interlocking beam.")
+	fs.IntVar(&options.Print.Interlocking.BeamLayers, "interlocking-beam-layers", options.Print.Interlocking.BeamLayers, "The amount of layers after which the direction of the interlocking beams changes.")
+	fs.Var(&options.Print.Interlocking.Depth, "interlocking-depth", "The distance from the boundary by which the interlocking beams reach into each body.")
+
+	// sequential printing options
+	fs.BoolVar(&options.Print.Sequential.Enabled, "sequential-enabled", options.Print.Sequential.Enabled, "Prints each object completely before the next one starts if several models are sliced together.")
+	fs.Var(&options.Print.Sequential.ExtruderClearanceRadius, "sequential-extruder-clearance-radius", "The radius around the nozzle which is free of any part of the print head. 0 disables the check.")
+	fs.Var(&options.Print.Sequential.GantryHeight, "sequential-gantry-height", "The distance between the nozzle tip and the gantry. Only the last printed object may be higher. 0 disables the check.")
+
+	// scarf seam options
+	fs.BoolVar(&options.Print.ScarfSeam.Enabled, "scarf-seam-enabled", options.Print.ScarfSeam.Enabled, "Blends the seam of the outer perimeters by starting them with rising height and flow and ending them overlapping the start with falling flow.")
+	fs.Var(&options.Print.ScarfSeam.Length, "scarf-seam-length", "The length along the outer perimeter over which the seam is blended if scarf-seam-enabled is set.")
+	fs.IntVar(&options.Print.ScarfSeam.Steps, "scarf-seam-steps", options.Print.ScarfSeam.Steps, "The number of steps with constant flow the scarf seam is split into.")
+
+	// bridge options
+	fs.BoolVar(&options.Print.Bridge.Enabled, "bridge-enabled", options.Print.Bridge.Enabled, "Detects the bottom skins which do not rest on the layer below and bridges them in the direction which anchors them best.")
+	fs.Var(&options.Print.Bridge.Speed, "bridge-speed", "The speed for the bridges. 0 uses the normal speed.")
+	fs.IntVar(&options.Print.Bridge.Flow, "bridge-flow", options.Print.Bridge.Flow, "The flow in percent for the bridges.")
+	fs.IntVar(&options.Print.Bridge.FanSpeed, "bridge-fan-speed", options.Print.Bridge.FanSpeed, "The fan speed (0-255) for the bridges. A fan speed for the feature bridge set by feature-fan-speed takes precedence.")
+	fs.BoolVar(&options.Print.OverhangPerimeter.Enabled, "overhang-perimeter-enabled", options.Print.OverhangPerimeter.Enabled, "Prints the perimeter segments which overhang the layer below by at least the overhang perimeter threshold slower and with more cooling.")
+	fs.IntVar(&options.Print.OverhangPerimeter.Threshold, "overhang-perimeter-threshold", options.Print.OverhangPerimeter.Threshold, "The percentage of the extrusion width which has to overhang the layer below, so that a perimeter segment is printed as overhang.")
+	fs.Var(&options.Print.OverhangPerimeter.Speed, "overhang-perimeter-speed", "The speed for the overhanging perimeter segments. 0 uses the normal speed.")
+	fs.IntVar(&options.Print.OverhangPerimeter.FanSpeed, "overhang-perimeter-fan-speed", options.Print.OverhangPerimeter.FanSpeed, "The fan speed (0-255) for the overhanging perimeter segments. A fan speed for the feature overhang-wall set by feature-fan-speed takes precedence.")
+
+	// non-planar top options
+	fs.BoolVar(&options.Print.NonPlanarTop.Enabled, "non-planar-top-enabled", options.Print.NonPlanarTop.Enabled, "Experimental: raises the top infill to the actual surface of the model for smoother curved tops.")
+	fs.Var(&options.Print.NonPlanarTop.MaxHeight, "non-planar-top-max-height", "The max distance the nozzle may be raised above the layer if non-planar-top-enabled is set.")
 
 	// filament options
-	flag.Var(&options.Filament.FilamentDiameter, "filament-diameter", "The filament diameter used by the printer.")
-	flag.IntVar(&options.Filament.InitialBedTemperature, "initial-bed-temperature", options.Filament.InitialBedTemperature, "The temperature for the heated bed for the first layers.")
-	flag.IntVar(&options.Filament.InitialHotEndTemperature, "initial-hot-end-temperature", options.Filament.InitialHotEndTemperature, "The filament diameter used by the printer.")
-	flag.IntVar(&options.Filament.BedTemperature, "bed-temperature", options.Filament.BedTemperature, "The temperature for the heated bed after the first layers.")
-	flag.IntVar(&options.Filament.HotEndTemperature, "hot-end-temperature", options.Filament.HotEndTemperature, "The temperature for the hot end after the first layers.")
-	flag.IntVar(&options.Filament.InitialTemperatureLayerCount, "initial-temperature-layer-count", options.Filament.InitialTemperatureLayerCount, "The number of layers which use the initial temperatures. After this amount of layers, the normal temperatures are used.")
-	flag.Var(&options.Filament.RetractionSpeed, "retraction-speed", "The speed used for retraction in mm/s.")
-	flag.Var(&options.Filament.RetractionLength, "retraction-length", "The amount to retract in millimeter.")
-	flag.Var(&options.Filament.FanSpeed, "fan-speed", "Comma separated layer/primary-fan-speed. eg. --fan-speed 3=20,10=40 indicates at layer 3 set fan to 20 and at layer 10 set fan to 40. Fan speed can range from 0-255.")
-	flag.IntVar(&options.Filament.ExtrusionMultiplier, "extrusion-multiplier", options.Filament.ExtrusionMultiplier, "The multiplier in % used to change the amount of filament being extruded. Can be used to mitigate under/over extrusion.")
+	fs.Var(&options.Filament.FilamentDiameter, "filament-diameter", "The filament diameter used by the printer.")
+	fs.IntVar(&options.Filament.InitialBedTemperature, "initial-bed-temperature", options.Filament.InitialBedTemperature, "The temperature for the heated bed for the first layers.")
+	fs.IntVar(&options.Filament.HeatSoakTime, "heat-soak-time", options.Filament.HeatSoakTime, "The time in seconds waited after the bed reached its initial temperature so that the bed and the chamber heat up evenly. 0 disables it.")
+	fs.IntVar(&options.Filament.InitialHotEndTemperature, "initial-hot-end-temperature", options.Filament.InitialHotEndTemperature, "The filament diameter used by the printer.")
+	fs.IntVar(&options.Filament.BedTemperature, "bed-temperature", options.Filament.BedTemperature, "The temperature for the heated bed after the first layers.")
+	fs.IntVar(&options.Filament.HotEndTemperature, "hot-end-temperature", options.Filament.HotEndTemperature, "The temperature for the hot end after the first layers.")
+	fs.IntVar(&options.Filament.InitialTemperatureLayerCount, "initial-temperature-layer-count", options.Filament.InitialTemperatureLayerCount, "The number of layers which use the initial temperatures. After this amount of layers, the normal temperatures are used.")
+	fs.Var(&options.Filament.RetractionSpeed, "retraction-speed", "The speed used for retraction in mm/s.")
+	fs.Var(&options.Filament.RetractionLength, "retraction-length", "The amount to retract in millimeter.")
+	fs.Var(&options.Filament.FanSpeed, "fan-speed", "Comma separated layer/primary-fan-speed. eg. --fan-speed 3=20,10=40 indicates at layer 3 set fan to 20 and at layer 10 set fan to 40. Fan speed can range from 0-255.")
+	fs.Var(&options.Filament.FeatureFanSpeed, "feature-fan-speed", "Comma separated feature/primary-fan-speed which overrides the fan speed while the feature is printed. eg. --feature-fan-speed bridge=255,support-interface=128. Possible features are outer-wall, inner-wall, overhang-wall, gap-fill, top-skin, bottom-skin, supported-bottom-skin, infill, bridge, support, support-interface, skirt, brim and ooze-shield.")
+	fs.Var(&options.Filament.FeatureTemperatureOffset, "feature-temperature-offset", "Comma separated feature/temperature-offset which changes the hot end temperature while the feature is printed. eg. --feature-temperature-offset bridge=-10. The features are the same as for feature-fan-speed.")
+	fs.IntVar(&options.Filament.TemperatureHysteresis, "temperature-hysteresis", options.Filament.TemperatureHysteresis, "The min difference in °C needed to change the temperature for a feature.")
+	fs.IntVar(&options.Filament.ExtrusionMultiplier, "extrusion-multiplier", options.Filament.ExtrusionMultiplier, "The multiplier in % used to change the amount of filament being extruded. Can be used to mitigate under/over extrusion.")
 
 	// printer options
-	flag.Var(&options.Printer.ExtrusionWidth, "extrusion-width", "The diameter of your nozzle.")
-	center := microVec3{
-		options.Printer.Center.X(),
-		options.Printer.Center.Y(),
-		options.Printer.Center.Z(),
+	fs.Var(&options.Printer.NozzleDiameter, "nozzle-diameter", "The diameter of your nozzle.")
+	fs.Var(&options.Printer.ExtrusionWidth, "extrusion-width", "The width of the extruded lines. Default is derived from the nozzle diameter if only that is set.")
+	fs.Var(&options.Printer.FirstLayerExtrusionWidth, "first-layer-extrusion-width", "The width of the extruded lines of the first layer, e.g. wider lines for a better bed adhesion. 0 uses the extrusion width.")
+	fs.Float64Var(&options.Printer.Acceleration, "acceleration", options.Printer.Acceleration, "The acceleration of the printer in mm/s². If it is set, extruding moves are slowed down to the speed the printer can reach on short moves and at corners. 0 disables it.")
+	fs.Float64Var(&options.Printer.SquareCornerVelocity, "square-corner-velocity", options.Printer.SquareCornerVelocity, "The speed in mm/s the printer keeps at a 90° corner. It is used together with the acceleration.")
+	fs.Var(&options.Printer.MaxExtrusionPerMM, "max-extrusion-per-mm", "The max length of filament a move may extrude per mm it moves, to protect the printer from blobs caused by broken geometry. 0 disables it.")
+	fs.Float64Var(&options.Printer.ToolChangeTime, "tool-change-time", options.Printer.ToolChangeTime, "The time in seconds the printer needs to change the active extruder. It is added to the estimated print time for each tool change.")
+	fs.StringVar(&options.Printer.ExcessiveExtrusion, "excessive-extrusion", options.Printer.ExcessiveExtrusion, "What happens with moves which extrude more than the max extrusion per mm. Can be \"clamp\" (the extrusion is reduced and a warning is logged) or \"error\" (the slicing is aborted).")
+	center := &microVec3Value{options.Printer.Center.Copy()}
+	fs.Var(center, "center", "The point where the model is finally placed.")
+	fs.Var(&options.Printer.BedWidth, "bed-width", "The size of the bed in X direction used to arrange several models. 0 means unknown.")
+	fs.Var(&options.Printer.BedDepth, "bed-depth", "The size of the bed in Y direction used to arrange several models. 0 means unknown.")
+	fs.StringVar(&options.Printer.Kinematics, "kinematics", options.Printer.Kinematics, "The type of the printer. Can be \"cartesian\" or \"belt\".")
+	fs.StringVar(&options.Printer.Firmware, "firmware", options.Printer.Firmware, "The firmware of the printer which defines the commands used for some features. Can be \"marlin\" or \"klipper\".")
+	fs.BoolVar(&options.Printer.ArcFitting, "arc-fitting", options.Printer.ArcFitting, "Replaces curved paths by arc moves (G2 / G3). The firmware has to support arcs.")
+	fs.Var(&options.Printer.ArcTolerance, "arc-tolerance", "The max distance between the points of a path and the arc which replaces them if arc-fitting is enabled.")
+	fs.StringVar(&options.Printer.BedMeshProfile, "bed-mesh-profile", options.Printer.BedMeshProfile, "The name of the bed mesh profile loaded at the start, for marlin the number of the EEPROM slot. If it is empty, no bed mesh is loaded.")
+	fs.IntVar(&options.Printer.Belt.Angle, "belt-angle", options.Printer.Belt.Angle, "The angle in degree between the gantry and the belt of a belt printer.")
+	fs.Var(&options.Printer.Belt.EjectDistance, "belt-eject-distance", "The distance the belt is advanced after the print.")
+
+	if err := fs.Parse(args); err != nil {
+		return options, err
 	}
-	flag.Var(&center, "center", "The point where the model is finally placed.")
 
-	flag.Parse()
+	options.Printer.Center = center.MicroVec3
 
-	options.Printer.Center = &center
+	// Derive the extrusion width from the nozzle diameter if only the nozzle diameter is set.
+	if fs.Changed("nozzle-diameter") && !fs.Changed("extrusion-width") {
+		options.Printer.ExtrusionWidth = ExtrusionWidthForNozzle(options.Printer.NozzleDiameter)
+	}
 
 	// Use the first arg as path.
-	if flag.NArg() > 0 {
-		options.GoSlice.InputFilePath = flag.Args()[0]
+	// All args are used if several models are sliced together.
+	if fs.NArg() > 0 {
+		options.GoSlice.InputFilePath = fs.Args()[0]
+	}
+	if fs.NArg() > 1 {
+		options.GoSlice.InputFilePaths = fs.Args()
 	}
 
-	return options
+	return options, nil
 }
@@ -127,3 +127,776 @@ func TestSetFanSpeedString(t *testing.T) {
 		}
 	}
 }
+
+func TestSetFeatureFanSpeed(t *testing.T) {
+	var testCases = map[string]struct {
+		optionString  string
+		expectedError string
+		expected      map[data.Feature]int
+	}{
+		"MultipleGood": {
+			optionString: "bridge=255,support-interface=0",
+			expected: map[data.Feature]int{
+				data.FeatureBridge:           255,
+				data.FeatureSupportInterface: 0,
+			},
+		},
+		"UnknownFeature": {
+			optionString:  "bridges=255",
+			expectedError: "unknown feature",
+		},
+		"SpeedGreaterThan255": {
+			optionString:  "bridge=256",
+			expectedError: "feature fan control needs to be in format",
+		},
+		"MissingSpeed": {
+			optionString:  "bridge",
+			expectedError: "feature fan control needs to be in format",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		actual := data.FeatureFanSpeedOptions{}
+		err := actual.Set(testCase.optionString)
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected")
+		} else {
+			test.Ok(t, err)
+			test.Equals(t, testCase.expected, actual.FeatureToSpeedLUT)
+			test.Equals(t, testCase.optionString, actual.String())
+		}
+	}
+}
+
+func TestSetFeatureTemperatureOffset(t *testing.T) {
+	var testCases = map[string]struct {
+		optionString  string
+		expectedError string
+		expected      map[data.Feature]int
+	}{
+		"MultipleGood": {
+			optionString: "bridge=-10,top-skin=5",
+			expected: map[data.Feature]int{
+				data.FeatureBridge:  -10,
+				data.FeatureTopSkin: 5,
+			},
+		},
+		"UnknownFeature": {
+			optionString:  "first-layer=5",
+			expectedError: "unknown feature",
+		},
+		"NoNumber": {
+			optionString:  "bridge=hot",
+			expectedError: "feature temperature offsets need to be in format",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		actual := data.FeatureTemperatureOptions{}
+		err := actual.Set(testCase.optionString)
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected")
+		} else {
+			test.Ok(t, err)
+			test.Equals(t, testCase.expected, actual.FeatureToOffsetLUT)
+			test.Equals(t, testCase.optionString, actual.String())
+		}
+	}
+}
+
+func TestSetLayerThicknessRanges(t *testing.T) {
+	var testCases = map[string]struct {
+		optionString  string
+		expectedError string
+		expected      []data.LayerThicknessRange
+	}{
+		"MultipleGood": {
+			optionString: "10-15=120,0-10=300",
+			expected: []data.LayerThicknessRange{
+				{From: 0, To: 10000, LayerThickness: 300},
+				{From: 10000, To: 15000, LayerThickness: 120},
+			},
+		},
+		"EmptyRange": {
+			optionString:  "10-10=120",
+			expectedError: "layer thickness ranges need to be in format",
+		},
+		"MissingThickness": {
+			optionString:  "0-10",
+			expectedError: "layer thickness ranges need to be in format",
+		},
+		"Overlapping": {
+			optionString:  "0-10=300,5-15=120",
+			expectedError: "overlap",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		actual := data.LayerThicknessRangeOptions{}
+		err := actual.Set(testCase.optionString)
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected")
+		} else {
+			test.Ok(t, err)
+			test.Equals(t, testCase.expected, actual.Ranges)
+			test.Equals(t, "0-10=300,10-15=120", actual.String())
+		}
+	}
+}
+
+func TestSetModelTransforms(t *testing.T) {
+	var testCases = map[string]struct {
+		optionStrings []string
+		expectedError string
+		expected      data.ModelTransformOptions
+	}{
+		"MultipleModels": {
+			optionStrings: []string{"scale=2,rotate-z=90,translate-x=20", "translate-y=-10,mirror=x,auto-orient=true"},
+			expected: data.ModelTransformOptions{
+				{Scale: 2, RotateZ: 90, TranslateX: 20},
+				{Scale: 1, TranslateY: -10, Mirror: "x", AutoOrient: true},
+			},
+		},
+		"MissingValue": {
+			optionStrings: []string{"scale"},
+			expectedError: "model transforms need to be in format",
+		},
+		"UnknownKey": {
+			optionStrings: []string{"size=2"},
+			expectedError: "unknown model transform",
+		},
+		"InvalidValue": {
+			optionStrings: []string{"rotate-x=a"},
+			expectedError: "invalid value",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		var actual data.ModelTransformOptions
+		var err error
+		for _, s := range testCase.optionStrings {
+			if err = actual.Set(s); err != nil {
+				break
+			}
+		}
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected")
+		} else {
+			test.Ok(t, err)
+			test.Equals(t, testCase.expected, actual)
+		}
+	}
+}
+
+func TestOptionsValidate(t *testing.T) {
+	// the patterns are registered by the clip package which is not used here
+	data.RegisterInfillPattern("linear")
+
+	var testCases = map[string]struct {
+		modify   func(o *data.Options)
+		expected []string
+	}{
+		"DefaultOptions": {
+			modify:   func(o *data.Options) {},
+			expected: nil,
+		},
+		"ScarfSeamWithVariableWidthPerimeters": {
+			modify: func(o *data.Options) {
+				o.Print.ScarfSeam.Enabled = true
+				o.Print.VariableWidthPerimeters = true
+			},
+			expected: []string{"the scarf seam is not used for the perimeters whose width is adapted"},
+		},
+		"UnknownInfillPattern": {
+			modify: func(o *data.Options) {
+				o.Print.InfillPattern = "honeycomb"
+			},
+			expected: []string{"the infill pattern \"honeycomb\" is unknown, possible patterns are [linear]"},
+		},
+		"ExtrusionWidthTooSmall": {
+			modify: func(o *data.Options) {
+				o.Printer.ExtrusionWidth = 300
+			},
+			expected: []string{"is smaller than the nozzle diameter"},
+		},
+		"ExtrusionWidthTooBig": {
+			modify: func(o *data.Options) {
+				o.Printer.ExtrusionWidth = 900
+			},
+			expected: []string{"is bigger than twice the nozzle diameter"},
+		},
+		"FirstLayerExtrusionWidthTooBig": {
+			modify: func(o *data.Options) {
+				o.Printer.FirstLayerExtrusionWidth = 900
+			},
+			expected: []string{"the first layer extrusion width 900µm has to be between the nozzle diameter 400µm and twice the nozzle diameter"},
+		},
+		"LayerThicknessTooBig": {
+			modify: func(o *data.Options) {
+				o.Print.LayerThickness = 350
+				o.Print.InitialLayerThickness = 350
+			},
+			expected: []string{"the layer thickness", "the initial layer thickness"},
+		},
+		"RangeLayerThicknessTooBig": {
+			modify: func(o *data.Options) {
+				o.Print.LayerThicknessRanges.Ranges = []data.LayerThicknessRange{{From: 0, To: 10000, LayerThickness: 350}}
+			},
+			expected: []string{"the layer thickness 350µm of the range 0-10mm"},
+		},
+		"NoNozzleDiameter": {
+			modify: func(o *data.Options) {
+				o.Printer.NozzleDiameter = 0
+			},
+			expected: []string{"has to be bigger than 0"},
+		},
+		"UnknownKinematics": {
+			modify: func(o *data.Options) {
+				o.Printer.Kinematics = "delta"
+			},
+			expected: []string{"is unknown"},
+		},
+		"UnknownFirmware": {
+			modify: func(o *data.Options) {
+				o.Printer.Firmware = "reprap"
+			},
+			expected: []string{"the firmware \"reprap\" is unknown"},
+		},
+		"ArcFittingWithoutTolerance": {
+			modify: func(o *data.Options) {
+				o.Printer.ArcFitting = true
+				o.Printer.ArcTolerance = 0
+			},
+			expected: []string{"the arc tolerance 0.000mm has to be greater than 0"},
+		},
+		"MarlinBedMeshProfileNoSlot": {
+			modify: func(o *data.Options) {
+				o.Printer.BedMeshProfile = "pei"
+			},
+			expected: []string{"the bed mesh profile \"pei\" has to be the number of an EEPROM slot for marlin"},
+		},
+		"KlipperBedMeshProfile": {
+			modify: func(o *data.Options) {
+				o.Printer.Firmware = "klipper"
+				o.Printer.BedMeshProfile = "pei"
+			},
+		},
+		"NegativeHeatSoakTime": {
+			modify: func(o *data.Options) {
+				o.Filament.HeatSoakTime = -5
+			},
+			expected: []string{"the heat soak time -5s must not be negative"},
+		},
+		"NegativeAcceleration": {
+			modify: func(o *data.Options) {
+				o.Printer.Acceleration = -500
+			},
+			expected: []string{"the acceleration -500.000mm/s² must not be negative"},
+		},
+		"NegativeSquareCornerVelocity": {
+			modify: func(o *data.Options) {
+				o.Printer.Acceleration = 500
+				o.Printer.SquareCornerVelocity = -1
+			},
+			expected: []string{"the square corner velocity -1.000mm/s must not be negative"},
+		},
+		"NegativeToolChangeTime": {
+			modify: func(o *data.Options) {
+				o.Printer.ToolChangeTime = -1
+			},
+			expected: []string{"the tool change time of -1.000s must not be negative"},
+		},
+		"InvalidExtrusionCap": {
+			modify: func(o *data.Options) {
+				o.Printer.MaxExtrusionPerMM = -0.5
+				o.Printer.ExcessiveExtrusion = "ignore"
+			},
+			expected: []string{
+				"the max extrusion of -0.500mm per mm must not be negative",
+				"the excessive extrusion handling \"ignore\" is unknown",
+			},
+		},
+		"InvalidOozeShield": {
+			modify: func(o *data.Options) {
+				o.Print.OozeShield.Enabled = true
+				o.Print.OozeShield.Distance = 0
+				o.Print.OozeShield.Angle = 95
+			},
+			expected: []string{
+				"the ooze shield distance 0.000mm has to be positive",
+				"the ooze shield angle 95° has to be between 0° and 90°",
+			},
+		},
+		"InvalidSkirt": {
+			modify: func(o *data.Options) {
+				o.Print.BrimSkirt.SkirtMinLength = -1
+				o.Print.BrimSkirt.SkirtHeight = 0
+			},
+			expected: []string{
+				"the skirt min length -1.000mm must not be negative",
+				"the skirt height of 0 layers has to be at least 1",
+			},
+		},
+		"InvalidBrim": {
+			modify: func(o *data.Options) {
+				o.Print.BrimSkirt.BrimType = "ears"
+				o.Print.BrimSkirt.BrimEars = true
+				o.Print.BrimSkirt.BrimEarsMaxAngle = 180
+			},
+			expected: []string{
+				"the brim type \"ears\" is unknown",
+				"the brim ears max angle 180° has to be between 0° and 180°",
+			},
+		},
+		"InvalidBeltAngle": {
+			modify: func(o *data.Options) {
+				o.Printer.Kinematics = "belt"
+				o.Printer.Belt.Angle = 90
+			},
+			expected: []string{"has to be between 0° and 90°"},
+		},
+		"UnknownEmptyLayers": {
+			modify: func(o *data.Options) {
+				o.Slicing.EmptyLayers = "ignore"
+			},
+			expected: []string{"the empty layer handling \"ignore\" is unknown"},
+		},
+		"UnknownBrimOverlapPrecedence": {
+			modify: func(o *data.Options) {
+				o.Print.BrimSkirt.OverlapPrecedence = "skirt"
+			},
+			expected: []string{"the brim overlap precedence \"skirt\" is unknown"},
+		},
+		"NegativeSliceRange": {
+			modify: func(o *data.Options) {
+				o.Slicing.SliceFrom = -1
+			},
+			expected: []string{"the slice range from -1.000mm to 0.000mm must not be negative"},
+		},
+		"EmptySliceRange": {
+			modify: func(o *data.Options) {
+				o.Slicing.SliceFrom = 10
+				o.Slicing.SliceTo = 5
+			},
+			expected: []string{"the slice range from 10.000mm to 5.000mm is empty"},
+		},
+		"NoInfillCombineLayers": {
+			modify: func(o *data.Options) {
+				o.Print.InfillCombineLayers = 0
+			},
+			expected: []string{"the infill combine layer count 0 has to be greater than 0"},
+		},
+		"TooThickCombinedInfill": {
+			modify: func(o *data.Options) {
+				o.Print.InfillCombineLayers = 3
+			},
+			expected: []string{"the combined infill thickness 600µm of 3 layers is bigger than the nozzle diameter 400µm"},
+		},
+		"NegativeSolidInfillEvery": {
+			modify: func(o *data.Options) {
+				o.Print.SolidInfillEvery = -1
+			},
+			expected: []string{"the solid infill interval -1 must not be negative"},
+		},
+		"NegativeInfillOverlap": {
+			modify: func(o *data.Options) {
+				o.Print.InfillOverlap = -100
+			},
+			expected: []string{"the infill overlap -100µm must not be negative"},
+		},
+		"InvalidBridgeOptions": {
+			modify: func(o *data.Options) {
+				o.Print.Bridge.Speed = -1
+				o.Print.Bridge.Flow = 0
+				o.Print.Bridge.FanSpeed = 256
+			},
+			expected: []string{
+				"the bridge speed -1.000mm/s must not be negative",
+				"the bridge flow 0% has to be greater than 0",
+				"the bridge fan speed 256 has to be between 0 and 255",
+			},
+		},
+		"InvalidOverhangPerimeterOptions": {
+			modify: func(o *data.Options) {
+				o.Print.OverhangPerimeter.Threshold = 0
+				o.Print.OverhangPerimeter.Speed = -1
+				o.Print.OverhangPerimeter.FanSpeed = -1
+			},
+			expected: []string{
+				"the overhang perimeter threshold 0% has to be between 1 and 100",
+				"the overhang perimeter speed -1.000mm/s must not be negative",
+				"the overhang perimeter fan speed -1 has to be between 0 and 255",
+			},
+		},
+		"UnknownSkinPattern": {
+			modify: func(o *data.Options) {
+				o.Print.SkinPattern = "grid"
+			},
+			expected: []string{"the skin pattern \"grid\" is unknown, possible patterns are [lines concentric]"},
+		},
+		"UnknownPerimeterOrder": {
+			modify: func(o *data.Options) {
+				o.Print.PerimeterOrder = "random"
+			},
+			expected: []string{"the perimeter order \"random\" is unknown"},
+		},
+		"UnknownInsetJoinType": {
+			modify: func(o *data.Options) {
+				o.Print.InsetJoinType = "bevel"
+			},
+			expected: []string{"the inset join type \"bevel\" is unknown"},
+		},
+		"SmallInsetMiterLimit": {
+			modify: func(o *data.Options) {
+				o.Print.InsetJoinType = "miter"
+				o.Print.InsetMiterLimit = 1.5
+			},
+			expected: []string{"the inset miter limit 1.5 has to be at least 2"},
+		},
+		"UnknownSeamPosition": {
+			modify: func(o *data.Options) {
+				o.Print.SeamPosition = "front"
+			},
+			expected: []string{"the seam position \"front\" is unknown"},
+		},
+		"NegativeSkinInset": {
+			modify: func(o *data.Options) {
+				o.Print.SkinInset = -0.1
+				o.Print.SkinPerimeters = -1
+			},
+			expected: []string{
+				"the skin inset -0.100mm must not be negative",
+				"the skin perimeter count -1 must not be negative",
+			},
+		},
+		"InvalidSeamAngle": {
+			modify: func(o *data.Options) {
+				o.Print.SeamPosition = "angle"
+				o.Print.SeamAngle = 360
+			},
+			expected: []string{"the seam angle 360° has to be between 0° and 359°"},
+		},
+		"UnknownInputUnit": {
+			modify: func(o *data.Options) {
+				o.GoSlice.InputUnit = "furlong"
+			},
+			expected: []string{"the input unit \"furlong\" is unknown"},
+		},
+		"NegativeInputScale": {
+			modify: func(o *data.Options) {
+				o.GoSlice.InputScale = -2
+			},
+			expected: []string{"the input scale -2 has to be bigger than 0"},
+		},
+		"NegativeLayerWindow": {
+			modify: func(o *data.Options) {
+				o.GoSlice.LayerWindow = -1
+			},
+			expected: []string{"the layer window -1 has to be 0 or bigger, all layers are processed at once"},
+		},
+		"NegativeWorkers": {
+			modify: func(o *data.Options) {
+				o.GoSlice.Workers = -2
+			},
+			expected: []string{"the number of workers -2 has to be 0 or bigger, the number of CPUs is used"},
+		},
+		"UnknownMirrorAxis": {
+			modify: func(o *data.Options) {
+				o.Print.Transform.Mirror = "xw"
+			},
+			expected: []string{"the mirror axes \"xw\" are unknown, possible axes are x, y and z"},
+		},
+		"ModelTransformScale": {
+			modify: func(o *data.Options) {
+				o.Print.ModelTransforms = []data.TransformOptions{{Scale: 1}, {Scale: 0}}
+			},
+			expected: []string{"the scale 0 has to be bigger than 0"},
+		},
+		"NegativeGapFillMinWidth": {
+			modify: func(o *data.Options) {
+				o.Print.GapFillMinWidth = -0.1
+			},
+			expected: []string{"the gap fill min width -0.100mm must not be negative"},
+		},
+		"NegativeThinWallMinWidth": {
+			modify: func(o *data.Options) {
+				o.Print.ThinWallMinWidth = -0.1
+			},
+			expected: []string{"the thin wall min width -0.100mm must not be negative"},
+		},
+		"NegativeElephantFootCompensation": {
+			modify: func(o *data.Options) {
+				o.Print.ElephantFootCompensation = -0.1
+			},
+			expected: []string{"the elephant foot compensation -0.100mm is negative, the first layer is printed wider"},
+		},
+		"NegativeHoleCompensation": {
+			modify: func(o *data.Options) {
+				o.Print.HoleCompensation = -0.1
+			},
+			expected: []string{"the hole compensation -0.100mm is negative, the holes are not changed"},
+		},
+		"SequentialOnBelt": {
+			modify: func(o *data.Options) {
+				o.Print.Sequential.Enabled = true
+				o.Printer.Kinematics = "belt"
+			},
+			expected: []string{"sequential printing is not possible on belt printers, the objects are printed together"},
+		},
+		"NegativeGantryHeight": {
+			modify: func(o *data.Options) {
+				o.Print.Sequential.GantryHeight = -1
+			},
+			expected: []string{"the extruder clearance radius 20.000mm and the gantry height -1.000mm must not be negative"},
+		},
+		"NegativeModelExtruder": {
+			modify: func(o *data.Options) {
+				o.Print.ModelExtruders = []int{0, -1}
+			},
+			expected: []string{"the model extruder -1 must not be negative, the first extruder is used"},
+		},
+		"ModelExtrudersWithSequential": {
+			modify: func(o *data.Options) {
+				o.Print.ModelExtruders = []int{0, 1}
+				o.Print.Sequential.Enabled = true
+			},
+			expected: []string{"the model extruders are ignored if the objects are printed one after the other"},
+		},
+		"ModelExtrudersWithModelTransforms": {
+			modify: func(o *data.Options) {
+				o.Print.ModelExtruders = []int{0, 1}
+				o.Print.ModelTransforms = data.ModelTransformOptions{{Scale: 2}}
+			},
+			expected: []string{"the model transforms are ignored if the models are printed by different extruders, the bodies are transformed together by the transform flags"},
+		},
+		"ModelExtrudersWithPrintableOverhang": {
+			modify: func(o *data.Options) {
+				o.Print.ModelExtruders = []int{1}
+				o.Print.PrintableOverhang.Enabled = true
+			},
+			expected: []string{"the printable overhang is applied to the bodies of each extruder separately, so parts resting on the body of another extruder are cut away"},
+		},
+		"InterlockingWithoutBeamLayers": {
+			modify: func(o *data.Options) {
+				o.Print.Interlocking.Enabled = true
+				o.Print.Interlocking.BeamLayers = 0
+			},
+			expected: []string{"the interlocking beam width 0.800mm, beam layers 0 and depth 1.600mm have to be bigger than 0"},
+		},
+		"ZOffsetOnBelt": {
+			modify: func(o *data.Options) {
+				o.Print.ZOffset = -0.05
+				o.Printer.Kinematics = "belt"
+			},
+			expected: []string{"the z offset -0.050mm is ignored for belt printers"},
+		},
+		"MaxSkinSpanSmallerThanExtrusionWidth": {
+			modify: func(o *data.Options) {
+				o.Print.MaxSkinSpan = 0.2
+			},
+			expected: []string{"the max skin span 0.200mm is smaller than the extrusion width 400µm, the extrusion width is used instead"},
+		},
+		"SupportedBottomDensityTooHigh": {
+			modify: func(o *data.Options) {
+				o.Print.Support.SupportedBottomDensity = 120
+			},
+			expected: []string{"the supported bottom density 120% has to be between 1% and 100%"},
+		},
+		"NegativeSupportMaxBridgeLength": {
+			modify: func(o *data.Options) {
+				o.Print.Support.MaxBridgeLength = -1
+			},
+			expected: []string{"the support max bridge length -1.000mm must not be negative"},
+		},
+		"NegativeSupportFloorLayers": {
+			modify: func(o *data.Options) {
+				o.Print.Support.FloorLayers = -1
+			},
+			expected: []string{"the support floor layers -1 must not be negative"},
+		},
+		"NegativeSupportBrim": {
+			modify: func(o *data.Options) {
+				o.Print.Support.BrimCount = -1
+				o.Print.Support.BrimMaxArea = -2
+			},
+			expected: []string{
+				"the support brim count -1 must not be negative",
+				"the support brim max area -2.000mm² must not be negative",
+			},
+		},
+		"NegativeGradualInfillSteps": {
+			modify: func(o *data.Options) {
+				o.Print.GradualInfillSteps = -1
+			},
+			expected: []string{"the gradual infill steps -1 must not be negative"},
+		},
+		"ZeroGradualInfillStepHeight": {
+			modify: func(o *data.Options) {
+				o.Print.GradualInfillSteps = 2
+				o.Print.GradualInfillStepHeight = 0
+			},
+			expected: []string{"the gradual infill step height 0.000mm has to be greater than 0"},
+		},
+		"NegativeInfillAdaptiveDistance": {
+			modify: func(o *data.Options) {
+				o.Print.InfillAdaptiveDistance = -1
+			},
+			expected: []string{"the infill adaptive distance -1.000mm must not be negative"},
+		},
+		"NegativeSupportTowers": {
+			modify: func(o *data.Options) {
+				o.Print.Support.MinArea = -1
+				o.Print.Support.TowerDiameter = -3
+				o.Print.Support.TowerMaxArea = -9
+			},
+			expected: []string{
+				"the support min area -1.000mm² must not be negative",
+				"the support tower diameter -3.000mm must not be negative",
+				"the support tower max area -9.000mm² must not be negative",
+			},
+		},
+		"UnknownSupportPattern": {
+			modify: func(o *data.Options) {
+				o.Print.Support.Pattern = "honeycomb"
+			},
+			expected: []string{"the support pattern \"honeycomb\" is unknown"},
+		},
+		"UnknownSupportType": {
+			modify: func(o *data.Options) {
+				o.Print.Support.Type = "organic"
+			},
+			expected: []string{"the support type \"organic\" is unknown"},
+		},
+		"InvalidTreeSupport": {
+			modify: func(o *data.Options) {
+				o.Print.Support.Type = "tree"
+				o.Print.Support.Tree.BranchAngle = 90
+				o.Print.Support.Tree.TipDiameter = 4
+			},
+			expected: []string{
+				"the tree support branch angle 90° has to be between 0° and 90°",
+				"the tree support tip diameter 4.000mm has to be bigger than 0 and not bigger than the branch diameter 3.000mm",
+			},
+		},
+		"HollowWallThicknessTooSmall": {
+			modify: func(o *data.Options) {
+				o.Print.Hollow.Enabled = true
+				o.Print.Hollow.WallThickness = 0.2
+			},
+			expected: []string{"the hollow wall thickness 0.200mm is smaller than the extrusion width 400µm"},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		testCase.modify(&options)
+
+		warnings := options.Validate()
+		test.Equals(t, len(testCase.expected), len(warnings))
+		for i, expected := range testCase.expected {
+			test.Assert(t, strings.Contains(warnings[i], expected), "warning '%s' should contain '%s'", warnings[i], expected)
+		}
+	}
+}
+
+func TestParseArgs(t *testing.T) {
+	options, err := data.ParseArgs([]string{"--layer-thickness", "100", "--nozzle-diameter", "600", "--rotate-z", "45", "--mirror", "x", "model.stl"})
+	test.Ok(t, err)
+	test.Equals(t, 45.0, options.Print.Transform.RotateZ)
+	test.Equals(t, "x", options.Print.Transform.Mirror)
+	test.Equals(t, data.Micrometer(100), options.Print.LayerThickness)
+	test.Equals(t, data.ExtrusionWidthForNozzle(600), options.Printer.ExtrusionWidth)
+	test.Equals(t, "model.stl", options.GoSlice.InputFilePath)
+
+	options, err = data.ParseArgs([]string{"--center", "40_60_200"})
+	test.Ok(t, err)
+	test.Equals(t, "40_60_200", options.Printer.Center.String())
+
+	options, err = data.ParseArgs([]string{"--acceleration", "1500", "--square-corner-velocity", "7.5"})
+	test.Ok(t, err)
+	test.Equals(t, 1500.0, options.Printer.Acceleration)
+	test.Equals(t, 7.5, options.Printer.SquareCornerVelocity)
+
+	options, err = data.ParseArgs([]string{"--model-extruders", "0,1", "--interlocking-enabled", "--interlocking-depth", "2"})
+	test.Ok(t, err)
+	test.Equals(t, []int{0, 1}, options.Print.ModelExtruders)
+	test.Assert(t, options.Print.Interlocking.Enabled, "the interlocking should be enabled")
+	test.Equals(t, data.Millimeter(2), options.Print.Interlocking.Depth)
+
+	_, err = data.ParseArgs([]string{"--center", "40_60"})
+	test.Assert(t, err != nil, "error expected")
+
+	_, err = data.ParseArgs([]string{"--unknown-flag"})
+	test.Assert(t, err != nil, "error expected")
+}
+
+func TestOptionsOverride(t *testing.T) {
+	base, err := data.ParseArgs([]string{"--infill-percent", "40", "--layer-speed", "50"})
+	test.Ok(t, err)
+
+	options, err := base.Override([]string{"--infill-percent=80", "--fan-speed=0=100"})
+	test.Ok(t, err)
+	test.Equals(t, 80, options.Print.InfillPercent)
+	test.Equals(t, data.Millimeter(50), options.Print.LayerSpeed)
+	test.Equals(t, map[int]int{0: 100}, options.Filament.FanSpeed.LayerToSpeedLUT)
+
+	// the base options are not changed
+	test.Equals(t, 40, base.Print.InfillPercent)
+	test.Equals(t, map[int]int{2: 255}, base.Filament.FanSpeed.LayerToSpeedLUT)
+}
+
+func TestFanSpeedAt(t *testing.T) {
+	fanSpeed := data.FanSpeedOptions{LayerToSpeedLUT: map[int]int{2: 100, 5: 255}}
+
+	_, ok := fanSpeed.SpeedAt(1)
+	test.Assert(t, !ok, "no fan speed expected below layer 2")
+	for layerNr, expected := range map[int]int{2: 100, 4: 100, 5: 255, 100: 255} {
+		speed, ok := fanSpeed.SpeedAt(layerNr)
+		test.Assert(t, ok, "fan speed expected at layer %v", layerNr)
+		test.Equals(t, expected, speed)
+	}
+}
+
+func TestExtrusionWidthForNozzle(t *testing.T) {
+	test.Equals(t, data.Micrometer(450), data.ExtrusionWidthForNozzle(400))
+	test.Equals(t, data.Micrometer(320), data.MaxLayerThicknessForNozzle(400))
+}
+
+func TestLayerExtrusionWidth(t *testing.T) {
+	var testCases = map[string]struct {
+		firstLayerWidth data.Micrometer
+		layerNr         int
+		expected        data.Micrometer
+	}{
+		"first layer": {
+			firstLayerWidth: 600,
+			layerNr:         0,
+			expected:        600,
+		},
+		"second layer": {
+			firstLayerWidth: 600,
+			layerNr:         1,
+			expected:        400,
+		},
+		"first layer without own width": {
+			firstLayerWidth: 0,
+			layerNr:         0,
+			expected:        400,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		printer := data.PrinterOptions{ExtrusionWidth: 400, FirstLayerExtrusionWidth: testCase.firstLayerWidth}
+		test.Equals(t, testCase.expected, printer.LayerExtrusionWidth(testCase.layerNr))
+	}
+}
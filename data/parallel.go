@@ -0,0 +1,67 @@
+// This file provides the parallel processing of independent layers.
+
+package data
+
+import (
+	"runtime"
+	"sync"
+	"sync/atomic"
+)
+
+// WorkerCount returns the number of layers which are processed in parallel.
+// If Workers is 0, the number of CPUs usable by GoSlice (GOMAXPROCS) is used.
+func (o GoSliceOptions) WorkerCount() int {
+	if o.Workers <= 0 {
+		return runtime.GOMAXPROCS(0)
+	}
+	return o.Workers
+}
+
+// ForEachLayer calls fn for each layer number from 0 to count (exclusive) using at most the given number of workers.
+// fn has to be safe for concurrent use and may only change the data of its own layer.
+// If fn fails, no further layers are started and the error of the lowest failed layer number is returned.
+func ForEachLayer(workers, count int, fn func(layerNr int) error) error {
+	if workers > count {
+		workers = count
+	}
+
+	if workers <= 1 {
+		for layerNr := 0; layerNr < count; layerNr++ {
+			if err := fn(layerNr); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	errs := make([]error, count)
+	var next int64 = -1
+	var failed int32
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+			for atomic.LoadInt32(&failed) == 0 {
+				layerNr := int(atomic.AddInt64(&next, 1))
+				if layerNr >= count {
+					return
+				}
+
+				if err := fn(layerNr); err != nil {
+					errs[layerNr] = err
+					atomic.StoreInt32(&failed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
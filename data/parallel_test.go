@@ -0,0 +1,71 @@
+package data_test
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestForEachLayer(t *testing.T) {
+	var testCases = map[string]struct {
+		workers     int
+		count       int
+		failAt      []int
+		expectedErr string
+	}{
+		"sequential": {
+			workers: 1,
+			count:   10,
+		},
+		"parallel": {
+			workers: 4,
+			count:   100,
+		},
+		"more workers than layers": {
+			workers: 8,
+			count:   3,
+		},
+		"no layers": {
+			workers: 4,
+			count:   0,
+		},
+		"sequential error": {
+			workers:     1,
+			count:       10,
+			failAt:      []int{3, 5},
+			expectedErr: "layer 3 failed",
+		},
+		"parallel error": {
+			workers:     4,
+			count:       100,
+			failAt:      []int{42},
+			expectedErr: "layer 42 failed",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+
+		processed := make([]int, testCase.count)
+		err := data.ForEachLayer(testCase.workers, testCase.count, func(layerNr int) error {
+			processed[layerNr]++
+			for _, failAt := range testCase.failAt {
+				if layerNr == failAt {
+					return fmt.Errorf("layer %v failed", layerNr)
+				}
+			}
+			return nil
+		})
+
+		if testCase.expectedErr != "" {
+			test.Assert(t, err != nil, "expected the error %q", testCase.expectedErr)
+			test.Equals(t, testCase.expectedErr, err.Error())
+		} else {
+			test.Ok(t, err)
+			for layerNr, count := range processed {
+				test.Assert(t, count == 1, "layer %v was processed %v times", layerNr, count)
+			}
+		}
+	}
+}
@@ -0,0 +1,48 @@
+// This file provides built in presets of options.
+
+package data
+
+import (
+	"fmt"
+	"sort"
+)
+
+// presets contain the settings of the built in presets as flag args which are applied to the default options.
+var presets = map[string][]string{
+	"draft": {
+		"--layer-thickness=300",
+		"--number-top-layers=3",
+		"--number-bottom-layers=3",
+		"--infill-percent=15",
+		"--layer-speed=80",
+	},
+	"normal": {},
+	"fine": {
+		"--layer-thickness=100",
+		"--number-top-layers=8",
+		"--number-bottom-layers=8",
+		"--layer-speed=40",
+		"--outer-perimeter-speed=30",
+	},
+}
+
+// PresetNames returns the names of all built in presets in alphabetical order.
+func PresetNames() []string {
+	names := make([]string, 0, len(presets))
+	for name := range presets {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// PresetOptions returns the default options changed by the preset with the given name.
+// The presets are meant as a starting point for programs embedding GoSlice, which only want to offer a
+// coarse choice of the quality. Further settings can be changed using Options.Override.
+func PresetOptions(name string) (Options, error) {
+	args, ok := presets[name]
+	if !ok {
+		return Options{}, fmt.Errorf("the preset %q is unknown", name)
+	}
+	return DefaultOptions().Override(args)
+}
@@ -0,0 +1,29 @@
+package data_test
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestPresetOptions(t *testing.T) {
+	test.Equals(t, []string{"draft", "fine", "normal"}, data.PresetNames())
+
+	for _, name := range data.PresetNames() {
+		t.Log("testCase:", name)
+		options, err := data.PresetOptions(name)
+		test.Ok(t, err)
+		test.Equals(t, 0, len(options.Validate()))
+	}
+
+	normal, err := data.PresetOptions("normal")
+	test.Ok(t, err)
+	test.Equals(t, data.DefaultOptions().Print.LayerThickness, normal.Print.LayerThickness)
+
+	fine, err := data.PresetOptions("fine")
+	test.Ok(t, err)
+	test.Equals(t, data.Micrometer(100), fine.Print.LayerThickness)
+
+	_, err = data.PresetOptions("ultra")
+	test.Assert(t, err != nil, "unknown presets should fail")
+}
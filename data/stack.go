@@ -0,0 +1,152 @@
+// This file provides a stack of layers which can be used by modifiers which need to access several layers at once.
+
+package data
+
+import "fmt"
+
+// PolygonOperator provides the polygon operations needed by the LayerStack.
+// clip.Clipper implements this interface.
+type PolygonOperator interface {
+	Difference(parts []LayerPart, toRemove []LayerPart) (clippedParts []LayerPart, ok bool)
+	Inset(part LayerPart, offset Micrometer, insetCount int, initialOffset Micrometer) [][]LayerPart
+}
+
+// stackCacheKey identifies a cached result of the LayerStack.
+type stackCacheKey struct {
+	layerNr      int
+	otherLayerNr int
+	offset       Micrometer
+	difference   bool
+}
+
+// LayerStack wraps all layers of a model and provides helpers for cross-layer calculations.
+// Offsets and differences between layers are cached so that several calculations
+// based on the same layers do not have to be calculated again.
+//
+// Note that the cache is only invalidated if a layer is replaced using Set.
+// If the layers slice is modified directly, the cache may contain old results.
+type LayerStack struct {
+	layers   []PartitionedLayer
+	operator PolygonOperator
+	cache    map[stackCacheKey][]LayerPart
+}
+
+// NewLayerStack returns a new LayerStack for the given layers.
+// The layers slice is used directly, so changes made using Set are also visible in it.
+func NewLayerStack(layers []PartitionedLayer, operator PolygonOperator) *LayerStack {
+	return &LayerStack{
+		layers:   layers,
+		operator: operator,
+		cache:    map[stackCacheKey][]LayerPart{},
+	}
+}
+
+// Len returns the amount of layers.
+func (s *LayerStack) Len() int {
+	return len(s.layers)
+}
+
+// Layer returns the layer with the given number.
+// If the layer does not exist, nil is returned.
+func (s *LayerStack) Layer(layerNr int) PartitionedLayer {
+	if layerNr < 0 || layerNr >= len(s.layers) {
+		return nil
+	}
+	return s.layers[layerNr]
+}
+
+// Set replaces the layer with the given number and
+// removes all cached results which are based on it.
+func (s *LayerStack) Set(layerNr int, layer PartitionedLayer) {
+	s.layers[layerNr] = layer
+
+	for key := range s.cache {
+		if key.layerNr == layerNr || (key.difference && key.otherLayerNr == layerNr) {
+			delete(s.cache, key)
+		}
+	}
+}
+
+// Below returns up to count layers below the given layer.
+// The nearest layer is the first one.
+func (s *LayerStack) Below(layerNr int, count int) []PartitionedLayer {
+	var result []PartitionedLayer
+	for i := layerNr - 1; i >= 0 && i >= layerNr-count; i-- {
+		result = append(result, s.layers[i])
+	}
+	return result
+}
+
+// Above returns up to count layers above the given layer.
+// The nearest layer is the first one.
+func (s *LayerStack) Above(layerNr int, count int) []PartitionedLayer {
+	var result []PartitionedLayer
+	for i := layerNr + 1; i < len(s.layers) && i <= layerNr+count; i++ {
+		result = append(result, s.layers[i])
+	}
+	return result
+}
+
+// Offset returns the parts of the given layer offset by the given value.
+// A positive value makes the parts bigger, a negative one smaller.
+// If the layer does not exist, nil is returned.
+func (s *LayerStack) Offset(layerNr int, offset Micrometer) []LayerPart {
+	layer := s.Layer(layerNr)
+	if layer == nil {
+		return nil
+	}
+
+	if offset == 0 {
+		return layer.LayerParts()
+	}
+
+	key := stackCacheKey{
+		layerNr: layerNr,
+		offset:  offset,
+	}
+	if cached, ok := s.cache[key]; ok {
+		return cached
+	}
+
+	var result []LayerPart
+	for _, part := range layer.LayerParts() {
+		for _, inset := range s.operator.Inset(part, -offset, 1, offset) {
+			result = append(result, inset...)
+		}
+	}
+
+	s.cache[key] = result
+	return result
+}
+
+// Difference returns the parts of the given layer which are not covered by the other layer.
+// The other layer is offset by otherOffset before (see Offset).
+// If the other layer does not exist, the whole layer is returned.
+func (s *LayerStack) Difference(layerNr int, otherLayerNr int, otherOffset Micrometer) ([]LayerPart, error) {
+	layer := s.Layer(layerNr)
+	if layer == nil {
+		return nil, nil
+	}
+
+	if s.Layer(otherLayerNr) == nil {
+		return layer.LayerParts(), nil
+	}
+
+	key := stackCacheKey{
+		layerNr:      layerNr,
+		otherLayerNr: otherLayerNr,
+		offset:       otherOffset,
+		difference:   true,
+	}
+	if cached, ok := s.cache[key]; ok {
+		return cached, nil
+	}
+
+	result, ok := s.operator.Difference(layer.LayerParts(), s.Offset(otherLayerNr, otherOffset))
+	if !ok {
+		return nil, fmt.Errorf("could not calculate the difference between layer %d and layer %d", layerNr, otherLayerNr)
+	}
+
+	s.cache[key] = result
+	return result, nil
+}
@@ -0,0 +1,126 @@
+package data_test
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// layerPartComparer returns a cmp.Comparer which can handle LayerPart.
+// The order of the holes has to be the same.
+func layerPartComparer() cmp.Option {
+	pathEqual := func(p1, p2 data.Path) bool {
+		if len(p1) != len(p2) {
+			return false
+		}
+		for i, p := range p1 {
+			if p.X() != p2[i].X() || p.Y() != p2[i].Y() {
+				return false
+			}
+		}
+		return true
+	}
+
+	return cmp.Comparer(func(p1, p2 data.LayerPart) bool {
+		if !pathEqual(p1.Outline(), p2.Outline()) || len(p1.Holes()) != len(p2.Holes()) {
+			return false
+		}
+		for i, hole := range p1.Holes() {
+			if !pathEqual(hole, p2.Holes()[i]) {
+				return false
+			}
+		}
+		return true
+	})
+}
+
+// fakeOperator is a data.PolygonOperator which just counts the calls.
+// Difference returns the parts unchanged and Inset returns the part unchanged.
+type fakeOperator struct {
+	differenceCalls int
+	insetCalls      int
+}
+
+func (f *fakeOperator) Difference(parts []data.LayerPart, _ []data.LayerPart) ([]data.LayerPart, bool) {
+	f.differenceCalls++
+	return parts, true
+}
+
+func (f *fakeOperator) Inset(part data.LayerPart, _ data.Micrometer, _ int, _ data.Micrometer) [][]data.LayerPart {
+	f.insetCalls++
+	return [][]data.LayerPart{{part}}
+}
+
+func setupLayerStack(layerCount int) ([]data.PartitionedLayer, *fakeOperator, *data.LayerStack) {
+	layers := make([]data.PartitionedLayer, layerCount)
+	for i := range layers {
+		layers[i] = data.NewPartitionedLayer([]data.LayerPart{
+			data.NewBasicLayerPart(data.Path{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(data.Micrometer(i+1)*100, 0),
+				data.NewMicroPoint(data.Micrometer(i+1)*100, 100),
+			}, nil),
+		})
+	}
+
+	operator := &fakeOperator{}
+	return layers, operator, data.NewLayerStack(layers, operator)
+}
+
+// layerWidth returns the width of the layer which identifies the layers created by setupLayerStack.
+func layerWidth(layer data.PartitionedLayer) data.Micrometer {
+	_, max := layer.Bounds()
+	return max.X()
+}
+
+func TestLayerStackNeighbours(t *testing.T) {
+	_, _, stack := setupLayerStack(5)
+
+	test.Equals(t, 5, stack.Len())
+	test.Assert(t, stack.Layer(-1) == nil, "layer -1 should not exist")
+	test.Assert(t, stack.Layer(5) == nil, "layer 5 should not exist")
+
+	below := stack.Below(2, 5)
+	test.Equals(t, 2, len(below))
+	test.Equals(t, data.Micrometer(200), layerWidth(below[0]))
+	test.Equals(t, data.Micrometer(100), layerWidth(below[1]))
+
+	above := stack.Above(2, 1)
+	test.Equals(t, 1, len(above))
+	test.Equals(t, data.Micrometer(400), layerWidth(above[0]))
+
+	test.Equals(t, 0, len(stack.Above(4, 3)))
+}
+
+func TestLayerStackCache(t *testing.T) {
+	layers, operator, stack := setupLayerStack(3)
+
+	_, err := stack.Difference(1, 0, 50)
+	test.Ok(t, err)
+	_, err = stack.Difference(1, 0, 50)
+	test.Ok(t, err)
+	stack.Offset(0, 50)
+
+	test.Equals(t, 1, operator.differenceCalls)
+	test.Equals(t, 1, operator.insetCalls)
+
+	// a different offset is calculated again
+	_, err = stack.Difference(1, 0, 60)
+	test.Ok(t, err)
+	test.Equals(t, 2, operator.differenceCalls)
+	test.Equals(t, 2, operator.insetCalls)
+
+	// replacing a layer invalidates the cache
+	stack.Set(0, layers[2])
+	_, err = stack.Difference(1, 0, 50)
+	test.Ok(t, err)
+	test.Equals(t, 3, operator.differenceCalls)
+	test.Equals(t, 3, operator.insetCalls)
+	test.Equals(t, data.Micrometer(300), layerWidth(layers[0]))
+
+	// a missing other layer just returns the layer itself
+	parts, err := stack.Difference(0, -1, 50)
+	test.Ok(t, err)
+	test.Equals(t, layers[0].LayerParts(), parts, layerPartComparer())
+}
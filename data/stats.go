@@ -0,0 +1,153 @@
+// This file provides the statistics of the generated GCode.
+
+package data
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// Stats contains statistics about the generated GCode.
+// The times are estimated based on the speeds only and do not include any acceleration.
+// They include all moves, also the z moves between the layers, and the retractions.
+type Stats struct {
+	// PrintTime is the estimated time of the whole print in seconds.
+	PrintTime float64 `json:"printTime"`
+
+	// Filament is the length of the extruded filament.
+	Filament Millimeter `json:"filament"`
+
+	// TravelDistance is the length of all non extruding moves.
+	TravelDistance Millimeter `json:"travelDistance"`
+
+	// TravelTime is the estimated time of all non extruding moves in seconds.
+	TravelTime float64 `json:"travelTime"`
+
+	// Retractions is the number of retractions.
+	Retractions int `json:"retractions"`
+
+	// RetractionTime is the estimated time of all retractions and the following unretractions in seconds.
+	RetractionTime float64 `json:"retractionTime"`
+
+	// ToolChanges is the number of changes of the active extruder.
+	ToolChanges int `json:"toolChanges"`
+
+	// ToolChangeTime is the estimated time of all tool changes in seconds.
+	ToolChangeTime float64 `json:"toolChangeTime"`
+
+	// Layers is the number of layers.
+	Layers int `json:"layers"`
+
+	// Features contains the statistics for each printed feature.
+	Features map[Feature]FeatureStats `json:"features"`
+
+	// Model contains the errors of the mesh found while optimizing the model.
+	Model ModelReport `json:"model"`
+
+	// LayerStats contains the statistics of each layer.
+	// They are not written to the json file to keep it small.
+	LayerStats []LayerStats `json:"-"`
+}
+
+// LayerStats contains the statistics of one layer.
+type LayerStats struct {
+	// Z is the height of the layer.
+	Z Millimeter
+
+	// Parts is the number of parts of the layer.
+	Parts int
+
+	// PrintTime is the estimated time in seconds needed to print the layer.
+	PrintTime float64
+
+	// Features contains the statistics for each feature printed in the layer.
+	Features map[Feature]FeatureStats
+}
+
+// FeatureStats contains the statistics of one feature.
+type FeatureStats struct {
+	// PrintTime is the estimated time in seconds needed to print the feature.
+	PrintTime float64 `json:"printTime"`
+
+	// Filament is the length of the filament extruded for the feature.
+	Filament Millimeter `json:"filament"`
+
+	// Length is the length of all lines of the feature.
+	Length Millimeter `json:"length"`
+
+	// Retractions is the number of retractions done while moving to the lines of the feature.
+	Retractions int `json:"retractions"`
+}
+
+// NewStats returns empty Stats.
+func NewStats() Stats {
+	return Stats{
+		Features: map[Feature]FeatureStats{},
+	}
+}
+
+// Duration returns the print time as time.Duration.
+func (s Stats) Duration() time.Duration {
+	return time.Duration(s.PrintTime * float64(time.Second))
+}
+
+// WriteStats writes the stats as json.
+func WriteStats(w io.Writer, stats Stats) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(stats)
+}
+
+// ReadStats reads stats written by WriteStats.
+func ReadStats(r io.Reader) (Stats, error) {
+	stats := NewStats()
+	err := json.NewDecoder(r).Decode(&stats)
+	return stats, err
+}
+
+// summaryFlags maps the features to the flags shown in the summary.
+var summaryFlags = []struct {
+	flag     byte
+	features []Feature
+}{
+	{'P', []Feature{FeatureOuterWall, FeatureInnerWall, FeatureOverhangWall, FeatureGapFill}},
+	{'I', []Feature{FeatureInfill, FeatureBridge}},
+	{'S', []Feature{FeatureSupport, FeatureSupportInterface}},
+	{'T', []Feature{FeatureTopSkin}},
+	{'B', []Feature{FeatureBottomSkin, FeatureSupportedBottom}},
+}
+
+// WriteSummary writes one line per layer showing which features were printed in it
+// (P: perimeters, I: infill, S: support, T: top skin, B: bottom skin), the number of parts and the estimated time.
+// Features which are missing in a layer are shown as "-".
+func WriteSummary(w io.Writer, stats Stats) error {
+	_, err := fmt.Fprintln(w, "layer        z  features  parts      time")
+	if err != nil {
+		return err
+	}
+
+	for layerNr, layer := range stats.LayerStats {
+		var flags strings.Builder
+		for _, summaryFlag := range summaryFlags {
+			flag := byte('-')
+			for _, feature := range summaryFlag.features {
+				if layer.Features[feature].Length > 0 {
+					flag = summaryFlag.flag
+					break
+				}
+			}
+			flags.WriteByte(flag)
+		}
+
+		duration := time.Duration(layer.PrintTime * float64(time.Second)).Round(time.Second)
+		_, err = fmt.Fprintf(w, "%5d %6.2fmm  %-8s  %5d  %8v\n", layerNr, layer.Z, flags.String(), layer.Parts, duration)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
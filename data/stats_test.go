@@ -0,0 +1,57 @@
+package data_test
+
+import (
+	"bytes"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestReadWriteStats(t *testing.T) {
+	stats := data.NewStats()
+	stats.PrintTime = 3600.5
+	stats.Filament = 1234.5
+	stats.Retractions = 42
+	stats.RetractionTime = 12.5
+	stats.Layers = 100
+	stats.Features[data.FeatureBridge] = data.FeatureStats{PrintTime: 10, Filament: 2.5, Length: 50, Retractions: 7}
+
+	var buf bytes.Buffer
+	test.Ok(t, data.WriteStats(&buf, stats))
+
+	actual, err := data.ReadStats(&buf)
+	test.Ok(t, err)
+	test.Equals(t, stats, actual)
+}
+
+func TestWriteSummary(t *testing.T) {
+	stats := data.NewStats()
+	stats.LayerStats = []data.LayerStats{
+		{
+			Z:         0.2,
+			Parts:     1,
+			PrintTime: 61.4,
+			Features: map[data.Feature]data.FeatureStats{
+				data.FeatureOuterWall:  {Length: 100},
+				data.FeatureBottomSkin: {Length: 200},
+				data.FeatureSupport:    {Length: 50},
+			},
+		},
+		{
+			Z:         0.4,
+			Parts:     2,
+			PrintTime: 30,
+			Features: map[data.Feature]data.FeatureStats{
+				data.FeatureInnerWall: {Length: 100},
+				data.FeatureBridge:    {Length: 10},
+				data.FeatureTopSkin:   {Length: 20},
+			},
+		},
+	}
+
+	var buf bytes.Buffer
+	test.Ok(t, data.WriteSummary(&buf, stats))
+	test.Equals(t, "layer        z  features  parts      time\n"+
+		"    0   0.20mm  P-S-B         1      1m1s\n"+
+		"    1   0.40mm  PI-T-         2       30s\n", buf.String())
+}
@@ -0,0 +1,197 @@
+// This file provides the tool paths which describe the moves of the nozzle together with their role.
+
+package data
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// ToolPath is a continuous path of the nozzle which belongs to one role, e.g. an outer wall or a travel move.
+// The role is attached when the path is generated, so later passes like an analysis
+// do not have to infer it from the order of the moves.
+type ToolPath struct {
+	// Feature is the role of the path. Moves without extrusion use FeatureTravel.
+	Feature Feature
+
+	// Points contains the written positions of the nozzle, starting at the position before the first move.
+	Points []MicroVec3
+
+	// Extrusion contains the filament in mm extruded by the move to each point.
+	// The value of the start point is always 0.
+	Extrusion []Millimeter
+
+	// Speed contains the speed in mm/s of the move to each point.
+	// The value of the start point is always 0.
+	Speed []int
+}
+
+// Length returns the length of the path in mm.
+func (t ToolPath) Length() Millimeter {
+	var length Millimeter
+	for i := 1; i < len(t.Points); i++ {
+		length += t.Points[i].Sub(t.Points[i-1]).Size().ToMillimeter()
+	}
+	return length
+}
+
+// Filament returns the filament in mm extruded along the path.
+func (t ToolPath) Filament() Millimeter {
+	var filament Millimeter
+	for _, extrusion := range t.Extrusion {
+		filament += extrusion
+	}
+	return filament
+}
+
+// ToolPathStats returns the statistics of each feature extruded along the tool paths.
+// Travel paths and paths without a feature are not counted.
+// The retractions are not known by the tool paths, so they are always 0.
+func ToolPathStats(paths []ToolPath) map[Feature]FeatureStats {
+	stats := map[Feature]FeatureStats{}
+	for _, path := range paths {
+		if path.Feature == FeatureTravel || path.Feature == "" {
+			continue
+		}
+
+		featureStats := stats[path.Feature]
+		for i := 1; i < len(path.Points); i++ {
+			length := path.Points[i].Sub(path.Points[i-1]).Size().ToMillimeter()
+			if path.Speed[i] > 0 {
+				featureStats.PrintTime += float64(length) / float64(path.Speed[i])
+			}
+			featureStats.Filament += path.Extrusion[i]
+			featureStats.Length += length
+		}
+		stats[path.Feature] = featureStats
+	}
+	return stats
+}
+
+// ToolPathWriter writes the tool paths layer by layer as json, so that machines which do not understand GCode,
+// e.g. robot arms or custom motion controllers, can use the output of GoSlice directly.
+//
+// The document has the following format:
+//
+//	{
+//	  "version": 1,
+//	  "layers": [
+//	    {
+//	      "layer": 0,
+//	      "z": 0.2,
+//	      "paths": [
+//	        {
+//	          "feature": "outer-wall",
+//	          "points": [
+//	            {"x": 100.5, "y": 80.2, "z": 0.2, "e": 0, "speed": 0, "time": 12.3},
+//	            {"x": 110.5, "y": 80.2, "z": 0.2, "e": 0.33, "speed": 30, "time": 12.63}
+//	          ]
+//	        }
+//	      ]
+//	    }
+//	  ]
+//	}
+//
+// All lengths are in mm. The feature is one of the Features or "travel" for moves without extrusion.
+// Each path starts at the position of the nozzle before its first move.
+// "e" is the filament extruded by the move to the point, "speed" the speed of the move in mm/s
+// and "time" the estimated time in seconds since the start of the print at which the nozzle reaches the point.
+// Like the Stats, the time is based on the speeds only. The retractions are not included.
+type ToolPathWriter struct {
+	w      io.Writer
+	layers int
+	time   float64
+	err    error
+}
+
+// toolPathHeader starts the document written by the ToolPathWriter including the version of the format.
+const toolPathHeader = `{"version":1,"layers":[`
+
+type toolPathLayerJSON struct {
+	Layer int            `json:"layer"`
+	Z     Millimeter     `json:"z"`
+	Paths []toolPathJSON `json:"paths"`
+}
+
+type toolPathJSON struct {
+	Feature Feature             `json:"feature"`
+	Points  []toolPathPointJSON `json:"points"`
+}
+
+type toolPathPointJSON struct {
+	X     Millimeter `json:"x"`
+	Y     Millimeter `json:"y"`
+	Z     Millimeter `json:"z"`
+	E     Millimeter `json:"e"`
+	Speed int        `json:"speed"`
+	Time  float64    `json:"time"`
+}
+
+// NewToolPathWriter returns a ToolPathWriter which writes to w.
+// Close has to be called after the last layer to finish the document.
+func NewToolPathWriter(w io.Writer) *ToolPathWriter {
+	return &ToolPathWriter{w: w}
+}
+
+// WriteLayer writes the tool paths of one layer.
+// If writing fails, all following layers are ignored and the error is returned again by Close.
+func (t *ToolPathWriter) WriteLayer(layerNr int, z Micrometer, paths []ToolPath) error {
+	if t.err != nil {
+		return t.err
+	}
+
+	layer := toolPathLayerJSON{
+		Layer: layerNr,
+		Z:     z.ToMillimeter(),
+		Paths: make([]toolPathJSON, 0, len(paths)),
+	}
+	for _, path := range paths {
+		points := make([]toolPathPointJSON, len(path.Points))
+		for i, p := range path.Points {
+			if i > 0 && path.Speed[i] > 0 {
+				t.time += float64(p.Sub(path.Points[i-1]).Size().ToMillimeter()) / float64(path.Speed[i])
+			}
+			points[i] = toolPathPointJSON{
+				X:     p.X().ToMillimeter(),
+				Y:     p.Y().ToMillimeter(),
+				Z:     p.Z().ToMillimeter(),
+				E:     path.Extrusion[i],
+				Speed: path.Speed[i],
+				Time:  t.time,
+			}
+		}
+		layer.Paths = append(layer.Paths, toolPathJSON{
+			Feature: path.Feature,
+			Points:  points,
+		})
+	}
+
+	encoded, err := json.Marshal(layer)
+	if err != nil {
+		t.err = err
+		return err
+	}
+
+	prefix := ",\n"
+	if t.layers == 0 {
+		prefix = toolPathHeader + "\n"
+	}
+	t.layers++
+
+	_, t.err = io.WriteString(t.w, prefix+string(encoded))
+	return t.err
+}
+
+// Close finishes the document. It returns the first error which occurred while writing.
+func (t *ToolPathWriter) Close() error {
+	if t.err != nil {
+		return t.err
+	}
+
+	end := "\n]}\n"
+	if t.layers == 0 {
+		end = toolPathHeader + "]}\n"
+	}
+	_, t.err = io.WriteString(t.w, end)
+	return t.err
+}
@@ -0,0 +1,93 @@
+package data_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestToolPathWriter(t *testing.T) {
+	var tests = map[string]struct {
+		layers   [][]data.ToolPath
+		expected string
+	}{
+		"no layers": {
+			expected: `{"version":1,"layers":[]}` + "\n",
+		},
+		"two layers": {
+			layers: [][]data.ToolPath{
+				{
+					{
+						Feature:   data.FeatureTravel,
+						Points:    []data.MicroVec3{data.NewMicroVec3(0, 0, 200), data.NewMicroVec3(10000, 0, 200)},
+						Extrusion: []data.Millimeter{0, 0},
+						Speed:     []int{0, 100},
+					},
+				},
+				{
+					{
+						Feature:   data.FeatureOuterWall,
+						Points:    []data.MicroVec3{data.NewMicroVec3(10000, 0, 400), data.NewMicroVec3(10000, 20000, 400)},
+						Extrusion: []data.Millimeter{0, 0.5},
+						Speed:     []int{0, 40},
+					},
+				},
+			},
+			expected: `{"version":1,"layers":[` + "\n" +
+				`{"layer":0,"z":0.2,"paths":[{"feature":"travel","points":[{"x":0,"y":0,"z":0.2,"e":0,"speed":0,"time":0},{"x":10,"y":0,"z":0.2,"e":0,"speed":100,"time":0.1}]}]},` + "\n" +
+				`{"layer":1,"z":0.4,"paths":[{"feature":"outer-wall","points":[{"x":10,"y":0,"z":0.4,"e":0,"speed":0,"time":0.1},{"x":10,"y":20,"z":0.4,"e":0.5,"speed":40,"time":0.6}]}]}` + "\n" +
+				"]}\n",
+		},
+	}
+
+	for testName, testCase := range tests {
+		t.Log("testCase:", testName)
+		var buf bytes.Buffer
+		w := data.NewToolPathWriter(&buf)
+		for layerNr, paths := range testCase.layers {
+			test.Ok(t, w.WriteLayer(layerNr, data.Micrometer(layerNr+1)*200, paths))
+		}
+		test.Ok(t, w.Close())
+
+		test.Equals(t, testCase.expected, buf.String())
+		test.Assert(t, json.Valid(buf.Bytes()), "the written tool paths should be valid json")
+	}
+}
+
+func TestToolPathStats(t *testing.T) {
+	paths := []data.ToolPath{
+		{
+			Feature:   data.FeatureTravel,
+			Points:    []data.MicroVec3{data.NewMicroVec3(0, 0, 200), data.NewMicroVec3(10000, 0, 200)},
+			Extrusion: []data.Millimeter{0, 0},
+			Speed:     []int{0, 100},
+		},
+		{
+			Feature:   data.FeatureOuterWall,
+			Points:    []data.MicroVec3{data.NewMicroVec3(10000, 0, 200), data.NewMicroVec3(10000, 20000, 200), data.NewMicroVec3(0, 20000, 200)},
+			Extrusion: []data.Millimeter{0, 1, 0.5},
+			Speed:     []int{0, 40, 20},
+		},
+		{
+			Feature:   data.FeatureOuterWall,
+			Points:    []data.MicroVec3{data.NewMicroVec3(0, 20000, 200), data.NewMicroVec3(0, 30000, 200)},
+			Extrusion: []data.Millimeter{0, 0.5},
+			Speed:     []int{0, 10},
+		},
+		{
+			Feature:   data.FeatureInfill,
+			Points:    []data.MicroVec3{data.NewMicroVec3(0, 30000, 200), data.NewMicroVec3(5000, 30000, 200)},
+			Extrusion: []data.Millimeter{0, 0.25},
+			Speed:     []int{0, 50},
+		},
+	}
+
+	test.Equals(t, map[data.Feature]data.FeatureStats{
+		data.FeatureOuterWall: {PrintTime: 2, Filament: 2, Length: 40},
+		data.FeatureInfill:    {PrintTime: 0.1, Filament: 0.25, Length: 5},
+	}, data.ToolPathStats(paths))
+
+	test.Equals(t, map[data.Feature]data.FeatureStats{}, data.ToolPathStats(nil))
+}
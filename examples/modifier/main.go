@@ -0,0 +1,76 @@
+// This example shows how to add a custom modifier to GoSlice.
+//
+// The modifier makes the sparse infill of every tenth layer solid, which stiffens tall prints.
+// It moves the infill parts into the attribute "bottom", so that the built in renderer of the bottom skin fills them.
+//
+// It accepts the same flags as the goslice command, e.g.
+//
+//	go run ./examples/modifier model.stl -o model.gcode
+package main
+
+import (
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"github.com/aligator/goslice/modifier"
+	"os"
+)
+
+// solidEvery is the distance in layers between the solid infill layers.
+const solidEvery = 10
+
+// solidInfillModifier moves the sparse infill of every n-th layer into the solid bottom skin.
+type solidInfillModifier struct {
+	handler.Named
+	every int
+}
+
+func (m *solidInfillModifier) Init(_ data.OptimizedModel) {}
+
+// LayerContext tells GoSlice that the modifier only needs the layer it modifies,
+// so it also works if the layers are processed in windows.
+func (m *solidInfillModifier) LayerContext() int {
+	return 0
+}
+
+func (m *solidInfillModifier) Modify(layers []data.PartitionedLayer) error {
+	for layerNr := m.every; layerNr < len(layers); layerNr += m.every {
+		infill, err := modifier.PartsAttribute(layers[layerNr], "infill")
+		if err != nil {
+			return err
+		}
+		if len(infill) == 0 {
+			continue
+		}
+
+		bottom, err := modifier.PartsAttribute(layers[layerNr], "bottom")
+		if err != nil {
+			return err
+		}
+
+		layer := modifier.SetAttribute(layers[layerNr], "bottom", append(bottom, infill...))
+		layers[layerNr] = modifier.SetAttribute(layer, "infill", []data.LayerPart(nil))
+	}
+
+	return nil
+}
+
+func main() {
+	options := data.ParseFlags()
+	if options.GoSlice.InputFilePath == "" {
+		fmt.Println("the model file has to be specified")
+		os.Exit(1)
+	}
+
+	s := goslice.NewGoSlice(options, goslice.WithModifier(func(options *data.Options) handler.LayerModifier {
+		return &solidInfillModifier{
+			Named: handler.Named{Name: "SolidInfill"},
+			every: solidEvery,
+		}
+	}))
+	if err := s.Process(); err != nil {
+		fmt.Println("error while processing file:", err)
+		os.Exit(2)
+	}
+}
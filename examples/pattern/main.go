@@ -0,0 +1,48 @@
+// This example shows how to add a custom infill pattern to GoSlice.
+//
+// The pattern "rotating" prints lines which turn by 60° on each layer,
+// so that three layers together form a triangular grid.
+// Registered patterns can be selected by the flag --infill-pattern, e.g.
+//
+//	go run ./examples/pattern model.stl --infill-pattern rotating -o model.gcode
+package main
+
+import (
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"os"
+)
+
+// rotatingPattern fills each layer with the lines of one of its patterns.
+type rotatingPattern struct {
+	patterns []clip.Pattern
+}
+
+func (p rotatingPattern) Fill(layerNr int, part data.LayerPart) (data.Paths, error) {
+	return p.patterns[layerNr%len(p.patterns)].Fill(layerNr, part)
+}
+
+func init() {
+	clip.RegisterPattern("rotating", func(o clip.PatternOptions) clip.Pattern {
+		var pattern rotatingPattern
+		for _, degree := range []int{0, 60, 120} {
+			pattern.patterns = append(pattern.patterns, clip.NewLinearPattern(o.LineWidth, o.LineDistance, o.Min, o.Max, o.Degree+degree, true, o.ZigZag))
+		}
+		return pattern
+	})
+}
+
+func main() {
+	options := data.ParseFlags()
+	if options.GoSlice.InputFilePath == "" {
+		fmt.Println("the model file has to be specified")
+		os.Exit(1)
+	}
+
+	if err := goslice.NewGoSlice(options).Process(); err != nil {
+		fmt.Println("error while processing file:", err)
+		os.Exit(2)
+	}
+}
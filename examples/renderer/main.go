@@ -0,0 +1,65 @@
+// This example shows how to add a custom renderer to GoSlice.
+//
+// The renderer adds a filament change (M600) before the first layer above a given height,
+// e.g. to print the upper part of the model in another color.
+//
+// It accepts the same flags as the goslice command, e.g.
+//
+//	go run ./examples/renderer model.stl -o model.gcode
+package main
+
+import (
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"os"
+)
+
+// changeHeight is the height at which the filament is changed.
+const changeHeight data.Millimeter = 5
+
+// filamentChange is a renderer which adds a filament change at the first layer above the height.
+type filamentChange struct {
+	height data.Micrometer
+	done   bool
+}
+
+func (r *filamentChange) Init(_ data.OptimizedModel) {
+	r.done = false
+}
+
+// RenderOncePerLayer marks the renderer to be rendered only once per layer (see gcode.LayerRenderer),
+// so that the filament is changed only once even if several instances of the model are printed.
+func (r *filamentChange) RenderOncePerLayer() {}
+
+func (r *filamentChange) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	if r.done || z < r.height {
+		return nil
+	}
+	r.done = true
+
+	// The renderer runs after the current layer is printed, so the change happens before the next layer.
+	if layerNr == maxLayer {
+		return nil
+	}
+	b.AddComment("FILAMENT CHANGE at layer %v", layerNr+1)
+	b.AddCommand("M600")
+	return nil
+}
+
+func main() {
+	options := data.ParseFlags()
+	if options.GoSlice.InputFilePath == "" {
+		fmt.Println("the model file has to be specified")
+		os.Exit(1)
+	}
+
+	s := goslice.NewGoSlice(options, goslice.WithRenderer(func(options *data.Options) gcode.Renderer {
+		return &filamentChange{height: changeHeight.ToMicrometer()}
+	}))
+	if err := s.Process(); err != nil {
+		fmt.Println("error while processing file:", err)
+		os.Exit(2)
+	}
+}
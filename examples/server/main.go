@@ -0,0 +1,120 @@
+// This example shows how to embed GoSlice in a minimal slicing server.
+//
+// The server slices models which are posted to /slice and responds with the gcode.
+// The quality is chosen by the built in presets (see data.PresetNames), e.g.
+//
+//	go run ./examples/server
+//	curl --data-binary @model.stl "localhost:8080/slice?preset=fine&format=stl" > model.gcode
+//
+// A GoSlice is created once for each preset and each request is processed by a new run of it (see GoSlice.NewRun),
+// so that several requests can be sliced concurrently.
+package main
+
+import (
+	"fmt"
+	"github.com/aligator/goslice"
+	"github.com/aligator/goslice/data"
+	"io"
+	"io/ioutil"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+)
+
+// listen is the address the server listens on.
+const listen = "localhost:8080"
+
+// formats are the accepted model formats, which are used as file extension for the reader.
+var formats = map[string]bool{"stl": true, "obj": true, "ply": true, "3mf": true}
+
+type server struct {
+	slicers map[string]*goslice.GoSlice
+	logger  *log.Logger
+}
+
+func (s *server) slice(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "the model has to be posted", http.StatusMethodNotAllowed)
+		return
+	}
+
+	preset := r.URL.Query().Get("preset")
+	if preset == "" {
+		preset = "normal"
+	}
+	slicer, ok := s.slicers[preset]
+	if !ok {
+		http.Error(w, fmt.Sprintf("the preset %q is unknown", preset), http.StatusBadRequest)
+		return
+	}
+
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "stl"
+	}
+	if !formats[format] {
+		http.Error(w, fmt.Sprintf("the format %q is unknown", format), http.StatusBadRequest)
+		return
+	}
+
+	dir, err := ioutil.TempDir("", "goslice-server")
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	modelPath := filepath.Join(dir, "model."+format)
+	if err := writeFile(modelPath, r.Body); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+
+	run := slicer.NewRun()
+	run.Options.InputFilePath = modelPath
+	run.Options.OutputFilePath = filepath.Join(dir, "model.gcode")
+	run.Options.Logger = s.logger
+	if err := run.Process(); err != nil {
+		http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	http.ServeFile(w, r, run.Options.OutputFilePath)
+}
+
+// writeFile writes everything read from r to a new file at the path.
+func writeFile(path string, r io.Reader) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+
+	_, err = io.Copy(file, r)
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
+
+func main() {
+	logger := log.New(os.Stderr, "", log.LstdFlags)
+
+	s := &server{
+		slicers: map[string]*goslice.GoSlice{},
+		logger:  logger,
+	}
+	for _, name := range data.PresetNames() {
+		options, err := data.PresetOptions(name)
+		if err != nil {
+			logger.Fatal(err)
+		}
+		options.GoSlice.Logger = logger
+		s.slicers[name] = goslice.NewGoSlice(options)
+	}
+
+	http.HandleFunc("/slice", s.slice)
+	logger.Printf("Slicing models posted to http://%s/slice\n", listen)
+	logger.Fatal(http.ListenAndServe(listen, nil))
+}
@@ -0,0 +1,132 @@
+// This file provides the fitting of arcs to paths, so that they can be printed using G2 / G3.
+
+package gcode
+
+import (
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// minArcSegments is the minimum amount of segments which are replaced by an arc.
+const minArcSegments = 3
+
+// maxArcRadius is the max radius in µm of the fitted arcs.
+// Nearly straight segments would otherwise result in huge arcs which some firmwares do not handle well.
+const maxArcRadius = 1000000.0
+
+// arcMove is one move of a path fitted by fitArcs.
+// It is either a straight line to end or, if isArc is set, an arc around center.
+type arcMove struct {
+	end       data.MicroPoint
+	isArc     bool
+	center    data.MicroPoint
+	clockwise bool
+}
+
+// fitArcs replaces the sequences of at least minArcSegments segments of the path, which lie on a circular arc
+// within the tolerance, by arcs. The segments are extended greedily, so each arc is as long as possible.
+// The first point of the path is the start and not part of the result.
+func fitArcs(path data.Path, tolerance data.Micrometer) []arcMove {
+	var moves []arcMove
+	for start := 0; start < len(path)-1; {
+		var best arcMove
+		bestEnd := -1
+		for end := start + minArcSegments; end < len(path); end++ {
+			arc, ok := fitArc(path[start:end+1], float64(tolerance))
+			if !ok {
+				break
+			}
+			best, bestEnd = arc, end
+		}
+
+		if bestEnd == -1 {
+			moves = append(moves, arcMove{end: path[start+1]})
+			start++
+			continue
+		}
+
+		moves = append(moves, best)
+		start = bestEnd
+	}
+	return moves
+}
+
+// fitArc returns the arc from the first to the last point of the path through its middle point,
+// if all points and the centers of all segments lie on it within the tolerance
+// and the path runs around the center in one direction by less than a full circle.
+func fitArc(path data.Path, tolerance float64) (arcMove, bool) {
+	first, middle, last := path[0], path[len(path)/2], path[len(path)-1]
+	cx, cy, ok := circleCenter(first, middle, last)
+	if !ok {
+		return arcMove{}, false
+	}
+
+	radius := math.Hypot(float64(first.X())-cx, float64(first.Y())-cy)
+	if radius > maxArcRadius {
+		return arcMove{}, false
+	}
+
+	var direction, angle float64
+	for i := 1; i < len(path); i++ {
+		ax, ay := float64(path[i-1].X())-cx, float64(path[i-1].Y())-cy
+		bx, by := float64(path[i].X())-cx, float64(path[i].Y())-cy
+
+		if math.Abs(math.Hypot(bx, by)-radius) > tolerance {
+			return arcMove{}, false
+		}
+		// the center of the segment lies inside of the arc
+		if radius-math.Hypot((ax+bx)/2, (ay+by)/2) > tolerance {
+			return arcMove{}, false
+		}
+
+		// all segments have to run around the center in the same direction
+		cross := ax*by - ay*bx
+		if cross == 0 || (direction != 0 && (cross > 0) != (direction > 0)) {
+			return arcMove{}, false
+		}
+		direction = cross
+		angle += math.Atan2(math.Abs(cross), ax*bx+ay*by)
+	}
+	if angle >= 2*math.Pi-0.01 {
+		return arcMove{}, false
+	}
+
+	return arcMove{
+		end:       last,
+		isArc:     true,
+		center:    data.NewMicroPoint(data.Micrometer(math.Round(cx)), data.Micrometer(math.Round(cy))),
+		clockwise: direction < 0,
+	}, true
+}
+
+// circleCenter returns the center of the circle through the three points.
+// It returns false if the points lie on a line.
+func circleCenter(a, b, c data.MicroPoint) (float64, float64, bool) {
+	ax, ay := float64(a.X()), float64(a.Y())
+	bx, by := float64(b.X())-ax, float64(b.Y())-ay
+	cx, cy := float64(c.X())-ax, float64(c.Y())-ay
+
+	d := 2 * (bx*cy - by*cx)
+	if d == 0 {
+		return 0, 0, false
+	}
+
+	b2 := bx*bx + by*by
+	c2 := cx*cx + cy*cy
+	return ax + (cy*b2-by*c2)/d, ay + (bx*c2-cx*b2)/d, true
+}
+
+// arcLength returns the length of the arc from start to end around center.
+func arcLength(start, end, center data.MicroPoint, clockwise bool) data.Micrometer {
+	ax, ay := float64(start.X()-center.X()), float64(start.Y()-center.Y())
+	bx, by := float64(end.X()-center.X()), float64(end.Y()-center.Y())
+
+	angle := math.Atan2(ax*by-ay*bx, ax*bx+ay*by)
+	if clockwise {
+		angle = -angle
+	}
+	if angle < 0 {
+		angle += 2 * math.Pi
+	}
+	return data.Micrometer(math.Round(angle * math.Hypot(ax, ay)))
+}
@@ -6,11 +6,43 @@ import (
 	"fmt"
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
+	"log"
 	"math"
+	"sort"
 )
 
+// ExtrusionCalculator calculates the amount of filament needed to extrude lines.
+// It can be replaced to experiment with other extrusion models.
+type ExtrusionCalculator interface {
+	// ExtrusionPerMM returns the length of filament in mm which is needed
+	// for one mm of a line with the given width and height.
+	ExtrusionPerMM(lineWidth, layerThickness data.Micrometer) data.Millimeter
+}
+
+// VolumetricExtrusion is the default ExtrusionCalculator.
+// It calculates the extrusion so that the volume of the line (width * height * length)
+// matches the volume of the filament used.
+type VolumetricExtrusion struct {
+	// FilamentDiameter is the diameter of the filament.
+	FilamentDiameter data.Micrometer
+
+	// Multiplier is the multiplier in % applied to the calculated extrusion.
+	Multiplier int
+}
+
+func (v VolumetricExtrusion) ExtrusionPerMM(lineWidth, layerThickness data.Micrometer) data.Millimeter {
+	filamentArea := math.Pi * (v.FilamentDiameter.ToMillimeter() / 2.0) * (v.FilamentDiameter.ToMillimeter() / 2.0)
+	return (layerThickness.ToMillimeter() * lineWidth.ToMillimeter() / filamentArea) * (data.Millimeter(v.Multiplier) / 100)
+}
+
 // Builder creates GCode by combining several commands.
 // Use  NewGCodeBuilder to create a new builder.
+//
+// It keeps track of the current position, the speeds and the extrusion.
+// The extrusion is based on the line width and layer thickness set by SetExtrusion
+// and can be changed temporarily using the override methods.
+// The actual amount of filament is calculated by an ExtrusionCalculator
+// which can be replaced by SetExtrusionCalculator.
 type Builder struct {
 	buf *bytes.Buffer
 
@@ -23,72 +55,499 @@ type Builder struct {
 	retractionSpeed  int
 	retractionAmount data.Millimeter
 
-	filamentDiameter    data.Micrometer
-	extrusionMultiplier int
+	combing bool
+	comb    *combPlanner
+	// logger is used by the clippers of the comb planners
+	logger *log.Logger
+
+	// clipper checks if the travel moves cross perimeters
+	clipper clip.Clipper
+
+	acceleration, junctionDeviation float64
+	lastDirection                   data.MicroPoint
+
+	maxExtrusionPerMM   data.Millimeter
+	excessiveExtrusions []string
+
+	arcTolerance data.Micrometer
+
+	transform        func(p data.MicroVec3) data.MicroVec3
+	maxSegmentLength data.Micrometer
+	writtenPosition  data.MicroVec3
+	offset           data.MicroPoint
+	zOffset          data.Millimeter
+
+	feature                   data.Feature
+	featureHooks              []FeatureHook
+	featureFanSpeed           map[data.Feature]int
+	fanSpeed, currentFanSpeed int
+
+	featureTemperatureOffset        map[data.Feature]int
+	temperatureHysteresis           int
+	temperature, currentTemperature int
+
+	// extruder is the active extruder. extruderTemperatures contains the temperature set last
+	// for each inactive extruder which was heated already.
+	extruder             int
+	extruderTemperatures map[int]int
+	toolChangeTime       float64
+
+	calculator                            ExtrusionCalculator
+	layerThickness, lineWidth             data.Micrometer
+	layerThicknessOverride, widthOverride data.Micrometer
+	flowOverride                          int
+
+	stats data.Stats
+
+	recordToolPaths bool
+	toolPaths       []data.ToolPath
 }
 
+// NewGCodeBuilder returns a new Builder which uses the VolumetricExtrusion
+// based on the filament options.
 func NewGCodeBuilder(options *data.Options) *Builder {
 	g := &Builder{
-		currentPosition:     data.NewMicroVec3(0, 0, 0),
-		filamentDiameter:    options.Filament.FilamentDiameter,
-		extrusionMultiplier: options.Filament.ExtrusionMultiplier,
+		currentPosition: data.NewMicroVec3(0, 0, 0),
+		writtenPosition: data.NewMicroVec3(0, 0, 0),
+		offset:          data.NewMicroPoint(0, 0),
+		lastDirection:   data.NewMicroPoint(0, 0),
+		calculator: VolumetricExtrusion{
+			FilamentDiameter: options.Filament.FilamentDiameter,
+			Multiplier:       options.Filament.ExtrusionMultiplier,
+		},
+		featureFanSpeed:          options.Filament.FeatureFanSpeed.FeatureToSpeedLUT,
+		featureTemperatureOffset: options.Filament.FeatureTemperatureOffset.FeatureToOffsetLUT,
+		temperatureHysteresis:    options.Filament.TemperatureHysteresis,
+		extruderTemperatures:     map[int]int{},
+		toolChangeTime:           options.Printer.ToolChangeTime,
+		stats:                    data.NewStats(),
+		maxExtrusionPerMM:        options.Printer.MaxExtrusionPerMM,
+		combing:                  options.Print.Combing,
+		logger:                   options.GoSlice.Logger,
+		clipper:                  clip.NewClipper(clip.WithLogger(options.GoSlice.Logger)),
+	}
+	if options.Printer.Acceleration > 0 {
+		g.acceleration = options.Printer.Acceleration
+		// the junction deviation which results in the square corner velocity at 90° corners
+		g.junctionDeviation = options.Printer.SquareCornerVelocity * options.Printer.SquareCornerVelocity * (math.Sqrt2 - 1) / g.acceleration
+	}
+	if options.Printer.ArcFitting {
+		g.arcTolerance = options.Printer.ArcTolerance.ToMicrometer()
+	}
+	// the bridge and overhang fan speeds are used unless the feature fan speed is set for these features
+	if options.Print.Bridge.Enabled {
+		g.setDefaultFeatureFanSpeed(data.FeatureBridge, options.Print.Bridge.FanSpeed)
+	}
+	if options.Print.OverhangPerimeter.Enabled {
+		g.setDefaultFeatureFanSpeed(data.FeatureOverhangWall, options.Print.OverhangPerimeter.FanSpeed)
+	}
+	// on belt printers Z moves the belt, so the offset would move the print on the belt
+	if options.Printer.Kinematics != "belt" {
+		g.zOffset = options.Print.ZOffset
 	}
 	g.buf = bytes.NewBuffer([]byte{})
 	return g
 }
 
+// String returns the whole GCode generated so far.
 func (g *Builder) String() string {
 	return g.buf.String()
 }
 
+// Flush returns the GCode generated so far and removes it from the Builder.
+// All other state, such as the current position and the statistics, is kept.
+func (g *Builder) Flush() string {
+	gcode := g.buf.String()
+	g.buf.Reset()
+	return gcode
+}
+
+// Stats returns the statistics of the moves and retractions added so far.
+// Only moves added by the move methods (not by AddCommand) are included.
+func (g *Builder) Stats() data.Stats {
+	stats := g.stats
+	stats.Features = make(map[data.Feature]data.FeatureStats, len(g.stats.Features))
+	for feature, featureStats := range g.stats.Features {
+		stats.Features[feature] = featureStats
+	}
+	return stats
+}
+
+// CurrentPosition returns the position the last move ended at.
+func (g *Builder) CurrentPosition() data.MicroVec3 {
+	return g.currentPosition.Copy()
+}
+
+// SetPositionTransform sets a transformation which is applied to all positions before they are written.
+// This can be used if the layers are not planar (e.g. conical).
+// Moves are split into segments of at most maxSegmentLength so that non-linear transformations are followed.
+// A maxSegmentLength of 0 disables the splitting.
+// Note that CurrentPosition still returns the untransformed position.
+func (g *Builder) SetPositionTransform(transform func(p data.MicroVec3) data.MicroVec3, maxSegmentLength data.Micrometer) {
+	g.transform = transform
+	g.maxSegmentLength = maxSegmentLength
+}
+
+// SetOffset moves all positions which are added afterwards by the offset.
+// This is used to print the same layer at several positions (see data.InstancedModel).
+// The current position is moved in the opposite direction, so that it still describes the same position of the nozzle.
+func (g *Builder) SetOffset(offset data.MicroPoint) {
+	shift := g.offset.Sub(offset)
+	g.currentPosition = data.NewMicroVec3(g.currentPosition.X()+shift.X(), g.currentPosition.Y()+shift.Y(), g.currentPosition.Z())
+	g.offset = offset.Copy()
+}
+
+// SetTravelBoundary sets the parts of the layer which is printed next.
+// If combing is enabled, the travel moves between two points inside of the same part are routed inside of it
+// instead of crossing its perimeters. This only affects the moves to the start of paths added with a layer.
+func (g *Builder) SetTravelBoundary(parts []data.LayerPart) {
+	if !g.combing {
+		return
+	}
+	g.comb = newCombPlanner(parts, g.logger)
+}
+
+// SetExtrusionCalculator replaces the calculator used to calculate the extrusion amounts.
+// The current extrusion is recalculated using the new calculator.
+func (g *Builder) SetExtrusionCalculator(calculator ExtrusionCalculator) {
+	g.calculator = calculator
+	g.updateExtrusion()
+}
+
+// SetExtrusion sets the layer thickness and line width used to calculate the extrusion.
 func (g *Builder) SetExtrusion(layerThickness, lineWidth data.Micrometer) {
-	filamentArea := math.Pi * (g.filamentDiameter.ToMillimeter() / 2.0) * (g.filamentDiameter.ToMillimeter() / 2.0)
-	g.extrusionPerMM = (layerThickness.ToMillimeter() * lineWidth.ToMillimeter() / filamentArea) * (data.Millimeter(g.extrusionMultiplier) / 100)
+	g.layerThickness = layerThickness
+	g.lineWidth = lineWidth
+	g.updateExtrusion()
+}
+
+// SetExtrusionOverride overrides the layer thickness and the line width set by SetExtrusion
+// until DisableExtrusionOverride is called.
+// A value of 0 keeps the value set by SetExtrusion.
+func (g *Builder) SetExtrusionOverride(layerThickness, lineWidth data.Micrometer) {
+	g.layerThicknessOverride = layerThickness
+	g.widthOverride = lineWidth
+	g.updateExtrusion()
 }
 
+// DisableExtrusionOverride resets the overrides set by SetExtrusionOverride.
+func (g *Builder) DisableExtrusionOverride() {
+	g.SetExtrusionOverride(0, 0)
+}
+
+// SetFlowOverride sets a flow in % which is applied to all extrusions
+// until DisableFlowOverride is called.
+func (g *Builder) SetFlowOverride(percent int) {
+	g.flowOverride = percent
+	g.updateExtrusion()
+}
+
+// DisableFlowOverride resets the flow set by SetFlowOverride.
+func (g *Builder) DisableFlowOverride() {
+	g.SetFlowOverride(0)
+}
+
+// FlowOverride returns the flow in % set by SetFlowOverride or 0 if it is disabled.
+func (g *Builder) FlowOverride() int {
+	return g.flowOverride
+}
+
+// updateExtrusion recalculates the extrusion per mm based on the current settings.
+func (g *Builder) updateExtrusion() {
+	layerThickness := g.layerThickness
+	if g.layerThicknessOverride > 0 {
+		layerThickness = g.layerThicknessOverride
+	}
+
+	lineWidth := g.lineWidth
+	if g.widthOverride > 0 {
+		lineWidth = g.widthOverride
+	}
+
+	g.extrusionPerMM = g.calculator.ExtrusionPerMM(lineWidth, layerThickness)
+	if g.flowOverride > 0 {
+		g.extrusionPerMM = g.extrusionPerMM * data.Millimeter(g.flowOverride) / 100
+	}
+}
+
+// setDefaultFeatureFanSpeed sets the fan speed for the feature if no fan speed is set for it yet.
+// The fan speeds are copied first, so that the lookup table of the options is not changed.
+func (g *Builder) setDefaultFeatureFanSpeed(feature data.Feature, speed int) {
+	if _, ok := g.featureFanSpeed[feature]; ok {
+		return
+	}
+
+	featureFanSpeed := make(map[data.Feature]int, len(g.featureFanSpeed)+1)
+	for f, s := range g.featureFanSpeed {
+		featureFanSpeed[f] = s
+	}
+	featureFanSpeed[feature] = speed
+	g.featureFanSpeed = featureFanSpeed
+}
+
+// FeatureHook is called by the Builder when the printed feature changes.
+type FeatureHook func(b *Builder, previous, next data.Feature)
+
+// OnFeatureChange registers a hook which is called each time the printed feature changes.
+// The hooks are called after the built in fan and temperature settings are applied.
+func (g *Builder) OnFeatureChange(hook FeatureHook) {
+	g.featureHooks = append(g.featureHooks, hook)
+}
+
+// SetFeature sets the feature which is printed next.
+// If the settings for the new feature differ from the current ones
+// (e.g. a different fan speed), the needed commands are added.
+func (g *Builder) SetFeature(feature data.Feature) {
+	previous := g.feature
+	g.feature = feature
+	g.updateFan()
+	g.updateTemperature(false)
+
+	if previous == feature {
+		return
+	}
+	for _, hook := range g.featureHooks {
+		hook(g, previous, feature)
+	}
+}
+
+// Feature returns the feature which is currently printed.
+func (g *Builder) Feature() data.Feature {
+	return g.feature
+}
+
+// SetFanSpeed sets the fan speed (0-255) used for all features without a fan speed override.
+// The fan is expected to be disabled at the beginning.
+func (g *Builder) SetFanSpeed(speed int) {
+	g.fanSpeed = speed
+	g.updateFan()
+}
+
+// updateFan adds a fan command if the fan speed needed for the current feature changed.
+func (g *Builder) updateFan() {
+	speed := g.fanSpeed
+	if featureSpeed, ok := g.featureFanSpeed[g.feature]; ok {
+		speed = featureSpeed
+	}
+
+	if speed == g.currentFanSpeed {
+		return
+	}
+	g.currentFanSpeed = speed
+
+	if speed == 0 {
+		g.AddCommand("M107 ; disable fan")
+	} else {
+		g.AddCommand("M106 S%d; change fan speed", speed)
+	}
+}
+
+// SetTemperature sets the hot end temperature used for all features without a temperature offset.
+// The temperature is changed without waiting.
+func (g *Builder) SetTemperature(temperature int) {
+	g.temperature = temperature
+	g.updateTemperature(true)
+}
+
+// ChangeTool changes the active extruder, which is 0 at the beginning.
+// If the hot ends are heated already, the filament is retracted before the change and pushed back afterwards.
+// All extruders use the same temperature: An extruder which is used the first time is heated and waited for,
+// the temperature of the others is only updated if it changed while they were inactive.
+// The tool change time of the printer is added to the stats for each change after heating.
+func (g *Builder) ChangeTool(extruder int) {
+	if extruder == g.extruder {
+		return
+	}
+
+	// before the start gcode heats the hot end the extruder is only selected
+	heated := g.temperature > 0
+	if heated {
+		g.Retract()
+		g.extruderTemperatures[g.extruder] = g.currentTemperature
+	}
+
+	g.AddCommand("T%d", extruder)
+	g.extruder = extruder
+	if !heated {
+		return
+	}
+	g.stats.ToolChanges++
+	g.stats.PrintTime += g.toolChangeTime
+	g.stats.ToolChangeTime += g.toolChangeTime
+
+	if temperature, ok := g.extruderTemperatures[extruder]; ok {
+		g.currentTemperature = temperature
+		g.updateTemperature(true)
+	} else {
+		g.WaitForTemperature(g.temperature)
+	}
+	g.Unretract()
+}
+
+// Extruder returns the active extruder.
+func (g *Builder) Extruder() int {
+	return g.extruder
+}
+
+// WaitForTemperature sets the hot end temperature and waits until it is reached.
+// Temperature offsets of the current feature are not applied.
+func (g *Builder) WaitForTemperature(temperature int) {
+	g.temperature = temperature
+	g.currentTemperature = temperature
+	g.AddCommand("M109 S%d ; wait for hot end temperature", temperature)
+}
+
+// updateTemperature adds a temperature command if the temperature needed for the current feature changed.
+// Changes caused only by the feature offsets are skipped if they are smaller than the hysteresis.
+// This avoids a lot of M104 commands for small offsets.
+func (g *Builder) updateTemperature(baseChanged bool) {
+	temperature := g.temperature
+	if offset, ok := g.featureTemperatureOffset[g.feature]; ok {
+		temperature += offset
+	}
+
+	difference := temperature - g.currentTemperature
+	if difference == 0 || !baseChanged && difference < g.temperatureHysteresis && -difference < g.temperatureHysteresis {
+		return
+	}
+
+	g.currentTemperature = temperature
+	g.AddCommand("M104 S%d", temperature)
+}
+
+// SetMoveSpeed sets the speed in mm/s used for all non extruding moves.
 func (g *Builder) SetMoveSpeed(moveSpeed data.Millimeter) {
 	g.moveSpeed = int(moveSpeed)
 }
 
+// SetExtrudeSpeed sets the speed in mm/s used for all extruding moves.
 func (g *Builder) SetExtrudeSpeed(extrudeSpeed data.Millimeter) {
 	g.extrudeSpeed = int(extrudeSpeed)
 }
 
+// ExtrudeSpeed returns the speed in mm/s set by SetExtrudeSpeed.
+func (g *Builder) ExtrudeSpeed() data.Millimeter {
+	return data.Millimeter(g.extrudeSpeed)
+}
+
+// SetExtrudeSpeedOverride sets a speed in mm/s which is used instead of the extrude speed
+// until DisableExtrudeSpeedOverride is called.
 func (g *Builder) SetExtrudeSpeedOverride(extrudeSpeed data.Millimeter) {
 	g.extrudeSpeedOverride = int(extrudeSpeed)
 }
 
+// DisableExtrudeSpeedOverride resets the speed set by SetExtrudeSpeedOverride.
 func (g *Builder) DisableExtrudeSpeedOverride() {
 	g.extrudeSpeedOverride = 0
 }
 
+// SetRetractionSpeed sets the speed in mm/s used for retractions.
 func (g *Builder) SetRetractionSpeed(retractionSpeed data.Millimeter) {
 	g.retractionSpeed = int(retractionSpeed)
 }
 
+// SetRetractionAmount sets the length of filament retracted by Retract.
 func (g *Builder) SetRetractionAmount(retractionAmount data.Millimeter) {
 	g.retractionAmount = retractionAmount
 }
 
+// AddCommand adds the given command. It can contain formatting verbs which are filled by the args.
 func (g *Builder) AddCommand(command string, args ...interface{}) {
 	command = command + "\n"
 	command = fmt.Sprintf(command, args...)
 	g.buf.WriteString(command)
 }
 
+// AddComment adds the given comment. It can contain formatting verbs which are filled by the args.
 func (g *Builder) AddComment(comment string, args ...interface{}) {
 	comment = ";" + comment + "\n"
 	comment = fmt.Sprintf(comment, args...)
 	g.buf.WriteString(comment)
 }
 
+// Retract retracts the filament by the retraction amount.
+func (g *Builder) Retract() {
+	// the print head stops for the retraction
+	g.lastDirection = data.NewMicroPoint(0, 0)
+	g.AddCommand("G1 F%v E%0.4f", g.retractionSpeed*60, g.extrusionAmount-g.retractionAmount)
+	g.stats.Retractions++
+	if g.feature != "" {
+		featureStats := g.stats.Features[g.feature]
+		featureStats.Retractions++
+		g.stats.Features[g.feature] = featureStats
+	}
+	g.addRetractionTime()
+}
+
+// Unretract pushes the filament back after a Retract.
+func (g *Builder) Unretract() {
+	g.AddCommand("G1 F%v E%0.4f", g.retractionSpeed*60, g.extrusionAmount)
+	g.addRetractionTime()
+}
+
+func (g *Builder) addRetractionTime() {
+	if g.retractionSpeed > 0 {
+		duration := float64(g.retractionAmount) / float64(g.retractionSpeed)
+		g.stats.PrintTime += duration
+		g.stats.RetractionTime += duration
+	}
+}
+
+// Move adds a non extruding move to the given point.
+func (g *Builder) Move(p data.MicroVec3) {
+	g.AddMove(p, 0)
+}
+
+// Extrude adds an extruding move to the given point.
+// The extrusion amount is calculated based on the length of the move.
+func (g *Builder) Extrude(p data.MicroVec3) {
+	g.AddMove(p, g.Extrusion(p.PointXY().Sub(g.currentPosition.PointXY()).SizeMM()))
+}
+
+// Extrusion returns the filament length needed for a line with the given length
+// based on the current extrusion settings.
+func (g *Builder) Extrusion(length data.Millimeter) data.Millimeter {
+	return length * g.extrusionPerMM
+}
+
+// AddMove adds a move to the given point which extrudes the given amount of filament.
+// Moves with zero length and no extrusion are ignored.
 func (g *Builder) AddMove(p data.MicroVec3, extrusion data.Millimeter) {
 	// Ignore moves which are of zero length.
 	if g.notFirstMove && g.currentPosition.X() == p.X() && g.currentPosition.Y() == p.Y() && g.currentPosition.Z() == p.Z() && extrusion == 0 {
 		return
 	}
+
+	// split the move if a transformation is used
+	segments := data.Micrometer(1)
+	if g.transform != nil && g.notFirstMove && g.maxSegmentLength > 0 {
+		segments = p.Sub(g.currentPosition).Size()/g.maxSegmentLength + 1
+	}
 	g.notFirstMove = true
 
+	start := g.currentPosition
+	for i := data.Micrometer(1); i <= segments; i++ {
+		point := p
+		if i < segments {
+			point = start.Add(p.Sub(start).Mul(i).Div(segments))
+		}
+		if g.offset.X() != 0 || g.offset.Y() != 0 {
+			point = data.NewMicroVec3(point.X()+g.offset.X(), point.Y()+g.offset.Y(), point.Z())
+		}
+		if g.transform != nil {
+			point = g.transform(point)
+		}
+		g.writeMove(point, extrusion/data.Millimeter(segments))
+	}
+	g.currentPosition = p
+}
+
+// writeMove writes the command for a move to the given (already transformed) point.
+func (g *Builder) writeMove(p data.MicroVec3, extrusion data.Millimeter) {
+	if g.maxExtrusionPerMM > 0 && extrusion > 0 {
+		extrusion = g.capExtrusion(p, extrusion, p.Sub(g.writtenPosition).Size().ToMillimeter())
+	}
+
 	var speed int
 	if extrusion != 0 {
 		g.buf.WriteString("G1")
@@ -98,14 +557,19 @@ func (g *Builder) AddMove(p data.MicroVec3, extrusion data.Millimeter) {
 		} else {
 			speed = g.extrudeSpeedOverride
 		}
+		speed = g.plannedSpeed(p, speed)
 	} else {
 		g.buf.WriteString("G0")
 		speed = g.moveSpeed
 	}
+	if direction := p.PointXY().Sub(g.writtenPosition.PointXY()); direction.X() != 0 || direction.Y() != 0 {
+		g.lastDirection = direction
+	}
 
 	g.buf.WriteString(fmt.Sprintf(" X%0.2f Y%0.2f", p.X().ToMillimeter(), p.Y().ToMillimeter()))
-	if p.Z() != g.currentPosition.Z() {
-		g.buf.WriteString(fmt.Sprintf(" Z%0.2f", p.Z().ToMillimeter()))
+	if p.Z() != g.writtenPosition.Z() {
+		// the z offset is only applied to the written value, so it does not affect the positions and the statistics
+		g.buf.WriteString(fmt.Sprintf(" Z%0.2f", p.Z().ToMillimeter()+g.zOffset))
 	}
 
 	if g.currentSpeed != speed {
@@ -119,9 +583,221 @@ func (g *Builder) AddMove(p data.MicroVec3, extrusion data.Millimeter) {
 	}
 	g.buf.WriteString("\n")
 
+	g.addMoveStats(p.Sub(g.writtenPosition).Size().ToMillimeter(), extrusion, speed)
+	if g.recordToolPaths {
+		g.addToolPathMove(p, extrusion, speed)
+	}
+	g.writtenPosition = p
+}
+
+// extrudeArc adds an extruding arc move around the center to the given point.
+// The extrusion amount is calculated based on the length of the arc.
+// The arc has to lie at the z of the current position and it must not be used if a position transform is set.
+func (g *Builder) extrudeArc(p data.MicroVec3, center data.MicroPoint, clockwise bool) {
+	length := arcLength(g.currentPosition.PointXY(), p.PointXY(), center, clockwise).ToMillimeter()
+	extrusion := g.Extrusion(length)
+	g.notFirstMove = true
+
+	point := data.NewMicroVec3(p.X()+g.offset.X(), p.Y()+g.offset.Y(), p.Z())
+	g.writeArc(point, center.Add(g.offset), clockwise, extrusion, length)
 	g.currentPosition = p
 }
 
+// writeArc writes the command for an arc move to the given point around the center (both already offset).
+// A clockwise arc is written as G2, a counter clockwise arc as G3.
+func (g *Builder) writeArc(p data.MicroVec3, center data.MicroPoint, clockwise bool, extrusion, length data.Millimeter) {
+	if g.maxExtrusionPerMM > 0 && extrusion > 0 {
+		extrusion = g.capExtrusion(p, extrusion, length)
+	}
+
+	speed := g.extrudeSpeed
+	if g.extrudeSpeedOverride > 0 {
+		speed = g.extrudeSpeedOverride
+	}
+	speed = g.plannedSpeed(p, speed)
+
+	if clockwise {
+		g.buf.WriteString("G2")
+	} else {
+		g.buf.WriteString("G3")
+	}
+
+	start := g.writtenPosition.PointXY()
+	offset := center.Sub(start)
+	g.buf.WriteString(fmt.Sprintf(" X%0.2f Y%0.2f I%0.3f J%0.3f", p.X().ToMillimeter(), p.Y().ToMillimeter(), offset.X().ToMillimeter(), offset.Y().ToMillimeter()))
+
+	if g.currentSpeed != speed {
+		g.buf.WriteString(fmt.Sprintf(" F%v", speed*60))
+		g.currentSpeed = speed
+	}
+
+	g.extrusionAmount += extrusion
+	g.buf.WriteString(fmt.Sprintf(" E%0.4f", g.extrusionAmount))
+	g.buf.WriteString("\n")
+
+	// the direction at the end of the arc is its tangent
+	radius := p.PointXY().Sub(center)
+	if clockwise {
+		g.lastDirection = data.NewMicroPoint(radius.Y(), -radius.X())
+	} else {
+		g.lastDirection = data.NewMicroPoint(-radius.Y(), radius.X())
+	}
+
+	g.addMoveStats(length, extrusion, speed)
+	if g.recordToolPaths {
+		// the tool paths consist of straight moves, so the arc is split into segments of at most 1 mm
+		segments := int(math.Ceil(float64(length)))
+		startAngle := math.Atan2(float64(start.Y()-center.Y()), float64(start.X()-center.X()))
+		sweep := float64(length.ToMicrometer()) / float64(start.Sub(center).Size())
+		if clockwise {
+			sweep = -sweep
+		}
+		for i := 1; i < segments; i++ {
+			angle := startAngle + sweep*float64(i)/float64(segments)
+			r := float64(start.Sub(center).Size())
+			point := data.NewMicroVec3(center.X()+data.Micrometer(math.Round(r*math.Cos(angle))), center.Y()+data.Micrometer(math.Round(r*math.Sin(angle))), p.Z())
+			g.addToolPathMove(point, extrusion/data.Millimeter(segments), speed)
+			g.writtenPosition = point
+		}
+		g.addToolPathMove(p, extrusion/data.Millimeter(segments), speed)
+	}
+	g.writtenPosition = p
+}
+
+// plannedSpeed returns the speed in mm/s which the printer can actually reach on a move
+// from the written position to p, if it is commanded with the given speed.
+// The printer starts the move with the speed allowed at the junction to the previous move
+// and accelerates with the printer acceleration. As the next move is unknown, it assumes that it has
+// to slow down to the same speed at the end of the move.
+// Commanding the reachable speed keeps the extrusion in sync with the actual movement.
+// If no acceleration is set, the speed is not changed.
+func (g *Builder) plannedSpeed(p data.MicroVec3, speed int) int {
+	if g.acceleration <= 0 {
+		return speed
+	}
+
+	direction := p.PointXY().Sub(g.writtenPosition.PointXY())
+	length := float64(direction.SizeMM())
+	if length == 0 {
+		return speed
+	}
+
+	// the speed at the junction is based on the junction deviation model used by the firmwares
+	var junctionSpeed2 float64
+	if lastLength := float64(g.lastDirection.SizeMM()); lastLength > 0 {
+		cos := (float64(direction.X())*float64(g.lastDirection.X()) + float64(direction.Y())*float64(g.lastDirection.Y())) / 1000000 / (length * lastLength)
+		sinHalf := math.Sqrt(math.Max(0, 0.5*(1+cos)))
+		if sinHalf >= 1 {
+			// no change of the direction
+			return speed
+		}
+		junctionSpeed2 = g.acceleration * g.junctionDeviation * sinHalf / (1 - sinHalf)
+	}
+
+	// accelerate over the first half and slow down over the second half of the move
+	reachable := math.Sqrt(junctionSpeed2 + g.acceleration*length)
+	if reachable >= float64(speed) {
+		return speed
+	}
+	return int(math.Max(1, reachable))
+}
+
+// capExtrusion returns the extrusion of the move with the given length to the given (already transformed) point
+// limited to the max extrusion per mm of the move.
+// Each capped move is recorded with its feature and position, see TakeExcessiveExtrusions.
+func (g *Builder) capExtrusion(p data.MicroVec3, extrusion data.Millimeter, length data.Millimeter) data.Millimeter {
+	max := length * g.maxExtrusionPerMM
+	if extrusion <= max {
+		return extrusion
+	}
+
+	g.excessiveExtrusions = append(g.excessiveExtrusions, fmt.Sprintf(
+		"the %q move from X%0.2f Y%0.2f to X%0.2f Y%0.2f extrudes %.4fmm of filament over %.3fmm, but only %.4fmm are allowed",
+		g.feature, g.writtenPosition.X().ToMillimeter(), g.writtenPosition.Y().ToMillimeter(), p.X().ToMillimeter(), p.Y().ToMillimeter(), extrusion, length, max,
+	))
+	return max
+}
+
+// TakeExcessiveExtrusions returns a description of each move whose extrusion was capped
+// since the last call and removes them from the Builder.
+func (g *Builder) TakeExcessiveExtrusions() []string {
+	excessive := g.excessiveExtrusions
+	g.excessiveExtrusions = nil
+	return excessive
+}
+
+// RecordToolPaths enables or disables the recording of the written moves as tool paths (see TakeToolPaths).
+func (g *Builder) RecordToolPaths(enabled bool) {
+	g.recordToolPaths = enabled
+}
+
+// TakeToolPaths returns the tool paths recorded since the last call and removes them from the Builder.
+func (g *Builder) TakeToolPaths() []data.ToolPath {
+	toolPaths := g.toolPaths
+	g.toolPaths = nil
+	return toolPaths
+}
+
+// addToolPathMove adds a written move to the recorded tool paths.
+// A new tool path is started if the role changes or the move does not start at the end of the last path.
+func (g *Builder) addToolPathMove(p data.MicroVec3, extrusion data.Millimeter, speed int) {
+	feature := data.FeatureTravel
+	if extrusion != 0 {
+		feature = g.feature
+	}
+
+	last := len(g.toolPaths) - 1
+	if last < 0 || g.toolPaths[last].Feature != feature || !isSamePosition(g.toolPaths[last].Points[len(g.toolPaths[last].Points)-1], g.writtenPosition) {
+		g.toolPaths = append(g.toolPaths, data.ToolPath{
+			Feature:   feature,
+			Points:    []data.MicroVec3{g.writtenPosition},
+			Extrusion: []data.Millimeter{0},
+			Speed:     []int{0},
+		})
+		last++
+	}
+
+	toolPath := &g.toolPaths[last]
+	toolPath.Points = append(toolPath.Points, p)
+	toolPath.Extrusion = append(toolPath.Extrusion, extrusion)
+	toolPath.Speed = append(toolPath.Speed, speed)
+}
+
+// isSamePosition returns true if both points are at the same position.
+func isSamePosition(a, b data.MicroVec3) bool {
+	return a.X() == b.X() && a.Y() == b.Y() && a.Z() == b.Z()
+}
+
+// addMoveStats adds a move with the given length in mm and speed in mm/s to the stats.
+func (g *Builder) addMoveStats(length data.Millimeter, extrusion data.Millimeter, speed int) {
+	var duration float64
+	if speed > 0 {
+		duration = float64(length) / float64(speed)
+	}
+	g.stats.PrintTime += duration
+
+	if extrusion == 0 {
+		g.stats.TravelDistance += length
+		g.stats.TravelTime += duration
+		return
+	}
+
+	g.stats.Filament += extrusion
+	if g.feature == "" {
+		return
+	}
+
+	featureStats := g.stats.Features[g.feature]
+	featureStats.PrintTime += duration
+	featureStats.Filament += extrusion
+	featureStats.Length += length
+	g.stats.Features[g.feature] = featureStats
+}
+
+// AddPolygon adds the moves needed to print the given polygon at the given z.
+// If open is false, the polygon gets closed by a move back to the first point.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
 func (g *Builder) AddPolygon(currentLayer data.PartitionedLayer, polygon data.Path, z data.Micrometer, open bool) error {
 	if len(polygon) == 0 {
 		return nil
@@ -130,65 +806,463 @@ func (g *Builder) AddPolygon(currentLayer data.PartitionedLayer, polygon data.Pa
 	// smooth the polygon
 	polygon = data.DouglasPeucker(polygon, -1)
 
-	for i, p := range polygon {
-		if i == 0 {
-			// for the move to the polygon: detect move through perimeters and add retraction if needed
-			// TODO: this is very ineffective, as it has to clip for every first move of every polygon with the whole layer...
-			move := data.Path{
-				g.currentPosition.PointXY(),
-				polygon[0],
+	err := g.travel(currentLayer, data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z))
+	if err != nil {
+		return err
+	}
+
+	// replace curved sections by arcs if the firmware supports it
+	if g.arcTolerance > 0 && g.transform == nil {
+		points := polygon
+		if !open {
+			points = append(append(data.Path{}, polygon...), polygon[0])
+		}
+
+		for _, move := range fitArcs(points, g.arcTolerance) {
+			end := data.NewMicroVec3(move.end.X(), move.end.Y(), z)
+			if move.isArc {
+				g.extrudeArc(end, move.center, move.clockwise)
+			} else {
+				g.Extrude(end)
 			}
+		}
+		return nil
+	}
+
+	for _, p := range polygon[1:] {
+		g.Extrude(data.NewMicroVec3(p.X(), p.Y(), z))
+	}
+
+	// add the move from the last point to the first point only if the path is closed
+	if open {
+		return nil
+	}
+
+	g.Extrude(data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z))
+
+	return nil
+}
+
+// AddPolygonWithFlow adds the moves needed to print the given closed polygon at the given z
+// with a flow in % for each segment. The segment i starts at point i and the last segment closes the polygon.
+// The flow is applied on top of the flow set by SetFlowOverride.
+// In contrast to AddPolygon the polygon is not smoothed.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddPolygonWithFlow(currentLayer data.PartitionedLayer, polygon data.Path, z data.Micrometer, flows []int) error {
+	if len(polygon) == 0 {
+		return nil
+	}
+	if len(flows) != len(polygon) {
+		return fmt.Errorf("the polygon has %v points but %v flows", len(polygon), len(flows))
+	}
+
+	err := g.travel(currentLayer, data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z))
+	if err != nil {
+		return err
+	}
+
+	previousFlow := g.flowOverride
+	baseFlow := previousFlow
+	if baseFlow <= 0 {
+		baseFlow = 100
+	}
+
+	for i := range polygon {
+		if flow := baseFlow * flows[i] / 100; flow != g.flowOverride {
+			g.SetFlowOverride(flow)
+		}
+
+		p := polygon[(i+1)%len(polygon)]
+		g.Extrude(data.NewMicroVec3(p.X(), p.Y(), z))
+	}
+
+	if g.flowOverride != previousFlow {
+		g.SetFlowOverride(previousFlow)
+	}
 
-			isCrossing := false
-			if currentLayer != nil && g.retractionSpeed != 0 && g.retractionAmount != 0 {
-				c := clip.NewClipper()
-				var ok bool
-				isCrossing, ok = c.IsCrossingPerimeter(currentLayer.LayerParts(), move)
+	return nil
+}
 
-				if !ok {
-					return errors.New("could not calculate the difference between the current layer and the non-extrusion-move")
-				}
+// AddPolygonWithOverhangs adds the moves needed to print the given closed polygon at the given z.
+// The segments marked in overhangs are printed as data.FeatureOverhangWall with the given speed in mm/s,
+// all other segments as the current feature with the current speed. A speed of 0 keeps the current speed.
+// If flows are given, they are applied in the same way as in AddPolygonWithFlow.
+// If widths are given, they are applied in the same way as in AddPathWithWidths.
+// The segment i starts at point i and the last segment closes the polygon.
+// In contrast to AddPolygon the polygon is not smoothed.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddPolygonWithOverhangs(currentLayer data.PartitionedLayer, polygon data.Path, z data.Micrometer, flows []int, widths []data.Micrometer, overhangs []bool, speed data.Millimeter) error {
+	if len(polygon) == 0 {
+		return nil
+	}
+	if len(overhangs) != len(polygon) {
+		return fmt.Errorf("the polygon has %v points but %v overhangs", len(polygon), len(overhangs))
+	}
+	if flows != nil && len(flows) != len(polygon) {
+		return fmt.Errorf("the polygon has %v points but %v flows", len(polygon), len(flows))
+	}
+	if widths != nil && len(widths) != len(polygon) {
+		return fmt.Errorf("the polygon has %v points but %v widths", len(polygon), len(widths))
+	}
+
+	err := g.travel(currentLayer, data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z))
+	if err != nil {
+		return err
+	}
+
+	previousFeature := g.feature
+	previousSpeed := g.extrudeSpeed
+	previousFlow := g.flowOverride
+	previousWidth := g.widthOverride
+	baseFlow := previousFlow
+	if baseFlow <= 0 {
+		baseFlow = 100
+	}
+
+	for i := range polygon {
+		if overhangs[i] {
+			g.SetFeature(data.FeatureOverhangWall)
+			if speed > 0 {
+				g.extrudeSpeed = int(speed)
 			}
+		} else {
+			g.SetFeature(previousFeature)
+			g.extrudeSpeed = previousSpeed
+		}
 
-			if isCrossing {
-				g.AddCommand("G1 F%v E%0.4f", g.retractionSpeed*60, g.extrusionAmount-g.retractionAmount)
+		if flows != nil {
+			if flow := baseFlow * flows[i] / 100; flow != g.flowOverride {
+				g.SetFlowOverride(flow)
 			}
+		}
+
+		if widths != nil && widths[i] != g.widthOverride {
+			g.SetExtrusionOverride(g.layerThicknessOverride, widths[i])
+		}
 
-			g.AddMove(data.NewMicroVec3(
-				polygon[i].X(),
-				polygon[i].Y(),
-				z), 0.0)
+		p := polygon[(i+1)%len(polygon)]
+		g.Extrude(data.NewMicroVec3(p.X(), p.Y(), z))
+	}
 
-			if isCrossing {
-				g.AddCommand("G1 F%v E%0.4f", g.retractionSpeed*60, g.extrusionAmount)
-			}
-			continue
+	g.SetFeature(previousFeature)
+	g.extrudeSpeed = previousSpeed
+	if g.flowOverride != previousFlow {
+		g.SetFlowOverride(previousFlow)
+	}
+	if g.widthOverride != previousWidth {
+		g.SetExtrusionOverride(g.layerThicknessOverride, previousWidth)
+	}
+
+	return nil
+}
+
+// AddPathWithWidths adds the moves needed to print the given open path at the given z
+// with a line width for each segment. The segment i starts at point i.
+// The widths override the line width set by SetExtrusion (see SetExtrusionOverride).
+// In contrast to AddPolygon the path is not smoothed.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddPathWithWidths(currentLayer data.PartitionedLayer, path data.Path, z data.Micrometer, widths []data.Micrometer) error {
+	if len(path) == 0 {
+		return nil
+	}
+	if len(widths) != len(path)-1 {
+		return fmt.Errorf("the path has %v segments but %v widths", len(path)-1, len(widths))
+	}
+
+	err := g.travel(currentLayer, data.NewMicroVec3(path[0].X(), path[0].Y(), z))
+	if err != nil {
+		return err
+	}
+
+	previousWidth := g.widthOverride
+	for i, p := range path[1:] {
+		if widths[i] != g.widthOverride {
+			g.SetExtrusionOverride(g.layerThicknessOverride, widths[i])
+		}
+		g.Extrude(data.NewMicroVec3(p.X(), p.Y(), z))
+	}
+
+	if g.widthOverride != previousWidth {
+		g.SetExtrusionOverride(g.layerThicknessOverride, previousWidth)
+	}
+
+	return nil
+}
+
+// AddScarfPolygon adds the moves needed to print the given closed polygon at the given z with a scarf seam.
+// The first scarfLength of the polygon is printed rising from z - layerThickness to z while the flow rises
+// from 0 to 100 %. After the rest of the polygon, the first scarfLength is printed again at z while the flow
+// falls from 100 % to 0, so that both ramps together fill the seam and no visible start or end remains.
+// Each ramp is split into the given number of steps with constant flow.
+// The flow is applied on top of the flow set by SetFlowOverride.
+// If flows are given, the flow of each segment is additionally scaled in the same way as in AddPolygonWithFlow.
+// As the flows belong to the segments of the polygon, it is only smoothed if no flows are given.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddScarfPolygon(currentLayer data.PartitionedLayer, polygon data.Path, z, layerThickness, scarfLength data.Micrometer, steps int, flows []int) error {
+	if len(polygon) == 0 {
+		return nil
+	}
+	if flows != nil && len(flows) != len(polygon) {
+		return fmt.Errorf("the polygon has %v points but %v flows", len(polygon), len(flows))
+	}
+	if steps < 1 {
+		steps = 1
+	}
+
+	if flows == nil {
+		// smooth the polygon
+		polygon = data.DouglasPeucker(polygon, -1)
+	}
+	// close the polygon
+	polygon = append(polygon, polygon[0])
+
+	// segmentEnds contains the distance along the polygon at which each segment ends
+	segmentEnds := make([]data.Micrometer, len(polygon)-1)
+	var polygonLength data.Micrometer
+	for i := 1; i < len(polygon); i++ {
+		polygonLength += polygon[i].Sub(polygon[i-1]).Size()
+		segmentEnds[i-1] = polygonLength
+	}
+	if scarfLength > polygonLength {
+		scarfLength = polygonLength
+	}
+	if scarfLength <= 0 {
+		if flows != nil {
+			return g.AddPolygonWithFlow(currentLayer, polygon[:len(polygon)-1], z, flows)
 		}
+		return g.AddPolygon(currentLayer, polygon[:len(polygon)-1], z, false)
+	}
 
-		point := data.NewMicroPoint(p.X(), p.Y())
+	// segmentFlow returns the flow of the segment at the given distance along the polygon
+	segmentFlow := func(distance data.Micrometer) int {
+		if flows == nil {
+			return 100
+		}
+		segment := sort.Search(len(segmentEnds), func(i int) bool {
+			return segmentEnds[i] > distance
+		})
+		if segment >= len(flows) {
+			segment = len(flows) - 1
+		}
+		return flows[segment]
+	}
 
-		prevPoint := data.NewMicroPoint(polygon[i-1].X(), polygon[i-1].Y())
+	ramp, rest := splitPath(polygon, scarfLength, steps)
 
-		g.AddMove(
-			data.NewMicroVec3(p.X(), p.Y(), z),
-			point.Sub(prevPoint).SizeMM()*g.extrusionPerMM,
-		)
+	err := g.travel(currentLayer, data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z-layerThickness))
+	if err != nil {
+		return err
 	}
 
-	// add the move from the last point to the first point only if the path is closed
-	if open {
+	previousFlow := g.flowOverride
+	baseFlow := previousFlow
+	if baseFlow <= 0 {
+		baseFlow = 100
+	}
+	setFlow := func(percent int) {
+		flow := baseFlow * percent / 100
+		if flow < 1 {
+			// a flow override of 0 would disable the override
+			flow = 1
+		}
+		if flow != g.flowOverride {
+			g.SetFlowOverride(flow)
+		}
+	}
+
+	// rising ramp
+	var distance data.Micrometer
+	for i := 1; i < len(ramp); i++ {
+		length := ramp[i].Sub(ramp[i-1]).Size()
+		setFlow(scarfFlow(distance+length/2, scarfLength, steps) * segmentFlow(distance+length/2) / 100)
+		distance += length
+		g.Extrude(data.NewMicroVec3(ramp[i].X(), ramp[i].Y(), z-layerThickness+layerThickness*distance/scarfLength))
+	}
+
+	// the rest of the polygon
+	for i := 1; i < len(rest); i++ {
+		length := rest[i].Sub(rest[i-1]).Size()
+		setFlow(segmentFlow(distance + length/2))
+		distance += length
+		g.Extrude(data.NewMicroVec3(rest[i].X(), rest[i].Y(), z))
+	}
+
+	// falling ramp
+	distance = 0
+	for i := 1; i < len(ramp); i++ {
+		length := ramp[i].Sub(ramp[i-1]).Size()
+		setFlow((100 - scarfFlow(distance+length/2, scarfLength, steps)) * segmentFlow(distance+length/2) / 100)
+		distance += length
+		g.Extrude(data.NewMicroVec3(ramp[i].X(), ramp[i].Y(), z))
+	}
+
+	if g.flowOverride != previousFlow {
+		g.SetFlowOverride(previousFlow)
+	}
+
+	return nil
+}
+
+// AddSpiralPolygon adds the moves needed to print the given closed polygon as one line which rises
+// from z - layerThickness at its start to z at its end, e.g. for spiralized layers.
+// The polygon starts at the point nearest to the current position, so that the polygons of consecutive layers
+// form one continuous spiral without a seam.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddSpiralPolygon(currentLayer data.PartitionedLayer, polygon data.Path, z, layerThickness data.Micrometer) error {
+	if len(polygon) == 0 {
+		return nil
+	}
+
+	polygon = data.DouglasPeucker(polygon, -1)
+
+	current := g.currentPosition.PointXY()
+	start := 0
+	for i, p := range polygon {
+		if p.Sub(current).Size2() < polygon[start].Sub(current).Size2() {
+			start = i
+		}
+	}
+	polygon = append(append(data.Path{}, polygon[start:]...), polygon[:start+1]...)
+
+	var polygonLength data.Micrometer
+	for i := 1; i < len(polygon); i++ {
+		polygonLength += polygon[i].Sub(polygon[i-1]).Size()
+	}
+	if polygonLength <= 0 {
 		return nil
 	}
 
-	point0 := data.NewMicroPoint(polygon[0].X(), polygon[0].Y())
+	err := g.travel(currentLayer, data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z-layerThickness))
+	if err != nil {
+		return err
+	}
+
+	var distance data.Micrometer
+	for i := 1; i < len(polygon); i++ {
+		distance += polygon[i].Sub(polygon[i-1]).Size()
+		g.Extrude(data.NewMicroVec3(polygon[i].X(), polygon[i].Y(), z-layerThickness+layerThickness*distance/polygonLength))
+	}
+
+	return nil
+}
+
+// scarfFlow returns the flow in % of the rising ramp of a scarf seam at the given distance from its start.
+// The flow is constant within each step and is the flow in the middle of the step.
+func scarfFlow(distance, scarfLength data.Micrometer, steps int) int {
+	step := int(distance * data.Micrometer(steps) / scarfLength)
+	if step >= steps {
+		step = steps - 1
+	}
+	return int(math.Round((float64(step) + 0.5) * 100 / float64(steps)))
+}
+
+// splitPath splits the path at the given length measured along it.
+// The first returned path contains additional points at the borders of the given number of equal steps.
+// The second path starts where the first one ends.
+func splitPath(path data.Path, length data.Micrometer, steps int) (data.Path, data.Path) {
+	first := data.Path{path[0]}
+	var distance data.Micrometer
+	nextStep := 1
+
+	for i := 1; i < len(path); i++ {
+		start, end := path[i-1], path[i]
+		segmentLength := end.Sub(start).Size()
+
+		// add the step borders within this segment
+		for nextStep <= steps {
+			border := length * data.Micrometer(nextStep) / data.Micrometer(steps)
+			if border > distance+segmentLength || segmentLength == 0 {
+				break
+			}
+
+			point := start.Add(end.Sub(start).Mul(border - distance).Div(segmentLength))
+			if first[len(first)-1].Sub(point).Size2() != 0 {
+				first = append(first, point)
+			}
+			nextStep++
+		}
+
+		if nextStep > steps {
+			second := append(data.Path{first[len(first)-1]}, path[i:]...)
+			return first, second
+		}
+
+		if first[len(first)-1].Sub(end).Size2() != 0 {
+			first = append(first, end)
+		}
+		distance += segmentLength
+	}
+
+	return first, data.Path{first[len(first)-1]}
+}
 
-	last := len(polygon) - 1
-	pointLast := data.NewMicroPoint(polygon[last].X(), polygon[last].Y())
+// AddPath adds the moves needed to print the given open path.
+// In contrast to AddPolygon each point can have its own z.
+// The path is not smoothed.
+// If currentLayer is not nil, it is used to detect if the move to the first point
+// crosses any perimeter. In this case a retraction is added.
+func (g *Builder) AddPath(currentLayer data.PartitionedLayer, path []data.MicroVec3) error {
+	if len(path) == 0 {
+		return nil
+	}
+
+	err := g.travel(currentLayer, path[0])
+	if err != nil {
+		return err
+	}
+
+	for _, p := range path[1:] {
+		g.Extrude(p)
+	}
 
-	g.AddMove(
-		data.NewMicroVec3(polygon[0].X(), polygon[0].Y(), z),
-		point0.Sub(pointLast).SizeMM()*g.extrusionPerMM,
-	)
+	return nil
+}
+
+// travel moves to the given point.
+// If currentLayer is not nil and the move crosses any perimeter of it, a retraction is added.
+// With combing, moves inside of one part of the travel boundary are routed inside of it without a retraction instead.
+func (g *Builder) travel(currentLayer data.PartitionedLayer, p data.MicroVec3) error {
+	// route the move inside of the part if possible, then no retraction is needed
+	if currentLayer != nil && g.comb != nil {
+		if route, ok := g.comb.route(g.currentPosition.PointXY(), p.PointXY(), g.lineWidth); ok {
+			for _, point := range route {
+				g.Move(data.NewMicroVec3(point.X(), point.Y(), p.Z()))
+			}
+			return nil
+		}
+	}
+
+	// detect move through perimeters and add retraction if needed
+	// TODO: this is very ineffective, as it has to clip for every first move of every polygon with the whole layer...
+	move := data.Path{
+		g.currentPosition.PointXY(),
+		p.PointXY(),
+	}
+
+	isCrossing := false
+	if currentLayer != nil && g.retractionSpeed != 0 && g.retractionAmount != 0 {
+		var ok bool
+		isCrossing, ok = g.clipper.IsCrossingPerimeter(currentLayer.LayerParts(), move)
+
+		if !ok {
+			return errors.New("could not calculate the difference between the current layer and the non-extrusion-move")
+		}
+	}
+
+	if isCrossing {
+		g.Retract()
+	}
+
+	g.Move(p)
+
+	if isCrossing {
+		g.Unretract()
+	}
 
 	return nil
 }
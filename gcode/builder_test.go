@@ -4,16 +4,63 @@ import (
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/gcode"
 	"github.com/aligator/goslice/util/test"
+	"math"
 	"testing"
 )
 
+// constantExtrusion is an ExtrusionCalculator which always uses the same extrusion per mm.
+type constantExtrusion data.Millimeter
+
+func (c constantExtrusion) ExtrusionPerMM(_, _ data.Micrometer) data.Millimeter {
+	return data.Millimeter(c)
+}
+
 func TestGCodeBuilder(t *testing.T) {
+	featureFanOptions := data.DefaultOptions()
+	featureFanOptions.Filament.FeatureFanSpeed.FeatureToSpeedLUT[data.FeatureBridge] = 0
+
+	featureTemperatureOptions := data.DefaultOptions()
+	featureTemperatureOptions.Filament.FeatureTemperatureOffset.FeatureToOffsetLUT[data.FeatureBridge] = -10
+	featureTemperatureOptions.Filament.FeatureTemperatureOffset.FeatureToOffsetLUT[data.FeatureTopSkin] = 2
+	featureTemperatureOptions.Filament.TemperatureHysteresis = 3
+
 	overExtrusionOptions := data.DefaultOptions()
 	overExtrusionOptions.Filament.ExtrusionMultiplier = 150
 
 	underExtrusionOptions := data.DefaultOptions()
 	underExtrusionOptions.Filament.ExtrusionMultiplier = 50
 
+	zOffsetOptions := data.DefaultOptions()
+	zOffsetOptions.Print.ZOffset = -0.05
+
+	beltZOffsetOptions := data.DefaultOptions()
+	beltZOffsetOptions.Print.ZOffset = -0.05
+	beltZOffsetOptions.Printer.Kinematics = "belt"
+
+	accelerationOptions := data.DefaultOptions()
+	accelerationOptions.Printer.Acceleration = 1000
+
+	arcOptions := data.DefaultOptions()
+	arcOptions.Printer.ArcFitting = true
+
+	combingOptions := data.DefaultOptions()
+	combingOptions.Print.Combing = true
+
+	// a square of 20mm with a hole of 10mm in its center
+	ringLayer := data.NewPartitionedLayer([]data.LayerPart{
+		data.NewBasicLayerPart(data.Path{
+			data.NewMicroPoint(0, 0),
+			data.NewMicroPoint(20000, 0),
+			data.NewMicroPoint(20000, 20000),
+			data.NewMicroPoint(0, 20000),
+		}, data.Paths{{
+			data.NewMicroPoint(5000, 5000),
+			data.NewMicroPoint(5000, 15000),
+			data.NewMicroPoint(15000, 15000),
+			data.NewMicroPoint(15000, 5000),
+		}}),
+	})
+
 	var tests = map[string]struct {
 		exec     func(*gcode.Builder)
 		expected string
@@ -80,6 +127,82 @@ func TestGCodeBuilder(t *testing.T) {
 				"G0 X0.00 Y0.05\n" +
 				"G0 X0.00 Y0.00\n",
 		},
+		"set offset": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				b.SetOffset(data.NewMicroPoint(20000, 0))
+				b.Move(data.NewMicroVec3(0, 0, 200))
+				b.Extrude(data.NewMicroVec3(10000, 0, 200))
+
+				// the current position still describes the same position of the nozzle
+				b.SetOffset(data.NewMicroPoint(0, 0))
+				test.Equals(t, data.Micrometer(30000), b.CurrentPosition().X())
+				b.Extrude(data.NewMicroVec3(30000, 10000, 200))
+			},
+			expected: "G0 X20.00 Y0.00 Z0.20\n" +
+				"G1 X30.00 Y0.00 E10.0000\n" +
+				"G1 X30.00 Y10.00 E20.0000\n",
+		},
+		"add scarf polygon": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				err := b.AddScarfPolygon(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+				}, 400, 200, 10000, 2, nil)
+				test.Ok(t, err)
+			},
+			// the first 10mm are printed twice, rising with 25% and 75% flow and then falling with 75% and 25% flow
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X5.00 Y0.00 Z0.30 E1.2500\n" +
+				"G1 X10.00 Y0.00 Z0.40 E5.0000\n" +
+				"G1 X10.00 Y10.00 E15.0000\n" +
+				"G1 X0.00 Y10.00 E25.0000\n" +
+				"G1 X0.00 Y0.00 E35.0000\n" +
+				"G1 X5.00 Y0.00 E38.7500\n" +
+				"G1 X10.00 Y0.00 E40.0000\n",
+		},
+		"add scarf polygon with flows": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				err := b.AddScarfPolygon(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+				}, 400, 200, 10000, 2, []int{50, 100, 100, 100})
+				test.Ok(t, err)
+			},
+			// the flow of the first segment is halved in both ramps, resulting in 12% and 37% flow
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X5.00 Y0.00 Z0.30 E0.6000\n" +
+				"G1 X10.00 Y0.00 Z0.40 E2.4500\n" +
+				"G1 X10.00 Y10.00 E12.4500\n" +
+				"G1 X0.00 Y10.00 E22.4500\n" +
+				"G1 X0.00 Y0.00 E32.4500\n" +
+				"G1 X5.00 Y0.00 E34.3000\n" +
+				"G1 X10.00 Y0.00 E34.9000\n",
+		},
+		"add spiral polygon": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				err := b.AddSpiralPolygon(nil, data.Path{
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+					data.NewMicroPoint(0, 0),
+				}, 400, 200)
+				test.Ok(t, err)
+			},
+			// starts at the point nearest to the current position and rises by one layer thickness
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X10.00 Y0.00 Z0.25 E10.0000\n" +
+				"G1 X10.00 Y10.00 Z0.30 E20.0000\n" +
+				"G1 X0.00 Y10.00 Z0.35 E30.0000\n" +
+				"G1 X0.00 Y0.00 Z0.40 E40.0000\n",
+		},
 		"some moves": {
 			exec: func(b *gcode.Builder) {
 				b.AddMove(data.NewMicroVec3(0, 0, 0), 0)
@@ -103,6 +226,25 @@ func TestGCodeBuilder(t *testing.T) {
 				"G1 X0.00 Y0.02 E14.0000\n" +
 				"G1 X0.00 Y0.00 Z0.03 E19.0000\n",
 		},
+		"moves with z offset": {
+			options: &zOffsetOptions,
+			exec: func(b *gcode.Builder) {
+				b.AddMove(data.NewMicroVec3(0, 0, 200), 0)
+				b.AddMove(data.NewMicroVec3(10, 0, 200), 5)
+				b.AddMove(data.NewMicroVec3(10, 0, 400), 0)
+				test.Equals(t, data.Micrometer(400), b.CurrentPosition().Z())
+			},
+			expected: "G0 X0.00 Y0.00 Z0.15\n" +
+				"G1 X0.01 Y0.00 E5.0000\n" +
+				"G0 X0.01 Y0.00 Z0.35\n",
+		},
+		"z offset is ignored on belt printers": {
+			options: &beltZOffsetOptions,
+			exec: func(b *gcode.Builder) {
+				b.AddMove(data.NewMicroVec3(0, 0, 200), 0)
+			},
+			expected: "G0 X0.00 Y0.00 Z0.20\n",
+		},
 		"moves with zero length and no extrusion get ignored": {
 			exec: func(b *gcode.Builder) {
 				b.AddMove(data.NewMicroVec3(0, 0, 0), 0)
@@ -225,6 +367,293 @@ func TestGCodeBuilder(t *testing.T) {
 			expected: "G0 X0.00 Y0.00\n" +
 				"G1 X0.00 Y10.00 E0.1663\n",
 		},
+
+		"flow override": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetFlowOverride(150)
+				b.Extrude(data.NewMicroVec3(0, 10000, 0))
+				b.DisableFlowOverride()
+				b.Extrude(data.NewMicroVec3(0, 20000, 0))
+			},
+			expected: "G1 X0.00 Y10.00 E0.4989\n" +
+				"G1 X0.00 Y20.00 E0.8315\n",
+		},
+
+		"polygon with flow": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				err := b.AddPolygonWithFlow(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+				}, 0, []int{100, 50, 100, 100})
+				test.Ok(t, err)
+				b.Extrude(data.NewMicroVec3(0, 20000, 0))
+			},
+			expected: "G0 X0.00 Y0.00\n" +
+				"G1 X10.00 Y0.00 E0.3326\n" +
+				"G1 X10.00 Y10.00 E0.4989\n" +
+				"G1 X0.00 Y10.00 E0.8315\n" +
+				"G1 X0.00 Y0.00 E1.1641\n" +
+				"G1 X0.00 Y20.00 E1.8293\n",
+		},
+
+		"polygon with overhangs": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetExtrudeSpeed(60)
+				err := b.AddPolygonWithOverhangs(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+				}, 0, nil, nil, []bool{false, true, true, false}, 20)
+				test.Ok(t, err)
+				b.Extrude(data.NewMicroVec3(0, 20000, 0))
+			},
+			expected: "G0 X0.00 Y0.00\n" +
+				"G1 X10.00 Y0.00 F3600 E0.3326\n" +
+				"G1 X10.00 Y10.00 F1200 E0.6652\n" +
+				"G1 X0.00 Y10.00 E0.9978\n" +
+				"G1 X0.00 Y0.00 F3600 E1.3304\n" +
+				"G1 X0.00 Y20.00 E1.9956\n",
+		},
+
+		"polygon with overhangs and widths": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetExtrudeSpeed(60)
+				err := b.AddPolygonWithOverhangs(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(0, 10000),
+				}, 0, nil, []data.Micrometer{800, 800, 400, 400}, []bool{false, true, false, false}, 20)
+				test.Ok(t, err)
+				b.Extrude(data.NewMicroVec3(0, 20000, 0))
+			},
+			expected: "G0 X0.00 Y0.00\n" +
+				"G1 X10.00 Y0.00 F3600 E0.6652\n" +
+				"G1 X10.00 Y10.00 F1200 E1.3304\n" +
+				"G1 X0.00 Y10.00 F3600 E1.6630\n" +
+				"G1 X0.00 Y0.00 E1.9956\n" +
+				"G1 X0.00 Y20.00 E2.6608\n",
+		},
+
+		"path with widths": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				err := b.AddPathWithWidths(nil, data.Path{
+					data.NewMicroPoint(0, 0),
+					data.NewMicroPoint(10000, 0),
+					data.NewMicroPoint(10000, 10000),
+				}, 0, []data.Micrometer{800, 400})
+				test.Ok(t, err)
+				b.Extrude(data.NewMicroVec3(0, 10000, 0))
+			},
+			expected: "G0 X0.00 Y0.00\n" +
+				"G1 X10.00 Y0.00 E0.6652\n" +
+				"G1 X10.00 Y10.00 E0.9978\n" +
+				"G1 X0.00 Y10.00 E1.3304\n",
+		},
+
+		"extrusion override": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetExtrusionOverride(0, 800)
+				b.Extrude(data.NewMicroVec3(0, 10000, 0))
+				b.DisableExtrusionOverride()
+				b.Extrude(data.NewMicroVec3(0, 20000, 0))
+			},
+			expected: "G1 X0.00 Y10.00 E0.6652\n" +
+				"G1 X0.00 Y20.00 E0.9978\n",
+		},
+
+		"custom extrusion calculator": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(2))
+				b.SetExtrusion(200, 400)
+				b.Move(data.NewMicroVec3(0, 0, 0))
+				b.Extrude(data.NewMicroVec3(0, 10000, 0))
+			},
+			expected: "G0 X0.00 Y0.00\n" +
+				"G1 X0.00 Y10.00 E20.0000\n",
+		},
+
+		"add path with varying z": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				err := b.AddPath(nil, []data.MicroVec3{
+					data.NewMicroVec3(0, 0, 200),
+					data.NewMicroVec3(0, 10000, 300),
+					data.NewMicroVec3(0, 20000, 200),
+				})
+				test.Ok(t, err)
+			},
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X0.00 Y10.00 Z0.30 E0.3326\n" +
+				"G1 X0.00 Y20.00 Z0.20 E0.6652\n",
+		},
+
+		"position transform": {
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetPositionTransform(func(p data.MicroVec3) data.MicroVec3 {
+					return data.NewMicroVec3(p.X(), p.Y(), p.Z()+p.Y()/10)
+				}, 5000)
+				b.Move(data.NewMicroVec3(0, 0, 200))
+				b.Extrude(data.NewMicroVec3(0, 10000, 200))
+				test.Equals(t, data.Micrometer(10000), b.CurrentPosition().Y())
+			},
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X0.00 Y3.33 Z0.53 E0.1109\n" +
+				"G1 X0.00 Y6.67 Z0.87 E0.2217\n" +
+				"G1 X0.00 Y10.00 Z1.20 E0.3326\n",
+		},
+
+		"feature fan speed": {
+			options: &featureFanOptions,
+			exec: func(b *gcode.Builder) {
+				b.SetFanSpeed(255)
+				b.SetFeature(data.FeatureOuterWall)
+				b.SetFeature(data.FeatureBridge)
+				b.SetFeature(data.FeatureInnerWall)
+				b.SetFanSpeed(100)
+				b.SetFeature(data.FeatureBridge)
+				b.SetFanSpeed(50)
+			},
+			expected: "M106 S255; change fan speed\n" +
+				"M107 ; disable fan\n" +
+				"M106 S255; change fan speed\n" +
+				"M106 S100; change fan speed\n" +
+				"M107 ; disable fan\n",
+		},
+
+		"feature temperature offset": {
+			options: &featureTemperatureOptions,
+			exec: func(b *gcode.Builder) {
+				b.WaitForTemperature(210)
+				b.SetFeature(data.FeatureBridge)
+				b.SetFeature(data.FeatureTopSkin)
+				b.SetFeature(data.FeatureInnerWall)
+				b.SetTemperature(200)
+				b.SetFeature(data.FeatureBridge)
+			},
+			expected: "M109 S210 ; wait for hot end temperature\n" +
+				"M104 S200\n" +
+				"M104 S212\n" +
+				"M104 S200\n" +
+				"M104 S190\n",
+		},
+
+		"feature temperature hysteresis": {
+			options: &featureTemperatureOptions,
+			exec: func(b *gcode.Builder) {
+				b.WaitForTemperature(210)
+				b.SetFeature(data.FeatureTopSkin)
+				b.SetFeature(data.FeatureInnerWall)
+				b.SetTemperature(211)
+				b.SetFeature(data.FeatureTopSkin)
+			},
+			expected: "M109 S210 ; wait for hot end temperature\n" +
+				"M104 S211\n",
+		},
+
+		"feature hook": {
+			exec: func(b *gcode.Builder) {
+				b.OnFeatureChange(func(b *gcode.Builder, previous, next data.Feature) {
+					b.AddComment("%s -> %s", previous, next)
+				})
+				b.SetFeature(data.FeatureOuterWall)
+				b.SetFeature(data.FeatureOuterWall)
+				b.SetFeature(data.FeatureInfill)
+			},
+			expected: "; -> outer-wall\n" +
+				";outer-wall -> infill\n",
+		},
+
+		"acceleration": {
+			options: &accelerationOptions,
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				b.SetExtrudeSpeed(50)
+				b.Move(data.NewMicroVec3(0, 0, 200))
+				// a short move after a stop cannot reach the speed: sqrt(1000mm/s² * 1mm) = 31mm/s
+				b.Extrude(data.NewMicroVec3(1000, 0, 200))
+				// long moves reach the speed
+				b.Extrude(data.NewMicroVec3(11000, 0, 200))
+				// a short move in the same direction keeps the speed
+				b.Extrude(data.NewMicroVec3(12000, 0, 200))
+				// after a sharp corner the speed is reduced again
+				b.Extrude(data.NewMicroVec3(11000, 1000, 200))
+			},
+			expected: "G0 X0.00 Y0.00 Z0.20\n" +
+				"G1 X1.00 Y0.00 F1860 E1.0000\n" +
+				"G1 X11.00 Y0.00 F3000 E11.0000\n" +
+				"G1 X12.00 Y0.00 E12.0000\n" +
+				"G1 X11.00 Y1.00 F2220 E13.4142\n",
+		},
+
+		"arc fitting": {
+			options: &arcOptions,
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				b.SetExtrudeSpeed(50)
+				// a quarter circle with a radius of 2mm around 0, 0 followed by a straight line
+				err := b.AddPolygon(nil, data.Path{
+					data.NewMicroPoint(2000, 0),
+					data.NewMicroPoint(1932, 518),
+					data.NewMicroPoint(1732, 1000),
+					data.NewMicroPoint(1414, 1414),
+					data.NewMicroPoint(1000, 1732),
+					data.NewMicroPoint(518, 1932),
+					data.NewMicroPoint(0, 2000),
+					data.NewMicroPoint(0, 5000),
+				}, 200, true)
+				test.Ok(t, err)
+			},
+			expected: "G0 X2.00 Y0.00 Z0.20\n" +
+				"G3 X0.00 Y2.00 I-2.001 J-0.001 F3000 E3.1410\n" +
+				"G1 X0.00 Y5.00 E6.1410\n",
+		},
+
+		"combing around a hole": {
+			options: &combingOptions,
+			exec: func(b *gcode.Builder) {
+				b.SetExtrusion(200, 400)
+				b.SetTravelBoundary(ringLayer.LayerParts())
+				b.SetExtrusionCalculator(constantExtrusion(1))
+				b.Move(data.NewMicroVec3(2500, 10000, 0))
+				err := b.AddPolygon(ringLayer, data.Path{
+					data.NewMicroPoint(17500, 10000),
+					data.NewMicroPoint(17500, 12000),
+				}, 0, true)
+				test.Ok(t, err)
+			},
+			// the move goes around the hole without a retraction
+			expected: "G0 X2.50 Y10.00\n" +
+				"G0 X4.60 Y15.17\n" +
+				"G0 X4.83 Y15.40\n" +
+				"G0 X15.17 Y15.40\n" +
+				"G0 X15.40 Y15.17\n" +
+				"G0 X17.50 Y10.00\n" +
+				"G1 X17.50 Y12.00 E2.0000\n",
+		},
+
+		"retract": {
+			exec: func(b *gcode.Builder) {
+				b.SetRetractionSpeed(30)
+				b.SetRetractionAmount(2)
+				b.AddMove(data.NewMicroVec3(0, 10000, 0), 5)
+				b.Retract()
+				b.Unretract()
+			},
+			expected: "G1 X0.00 Y10.00 E5.0000\n" +
+				"G1 F1800 E3.0000\n" +
+				"G1 F1800 E5.0000\n",
+		},
 	}
 
 	for desc, testCase := range tests {
@@ -239,3 +668,127 @@ func TestGCodeBuilder(t *testing.T) {
 		test.Equals(t, testCase.expected, builder.String())
 	}
 }
+
+func TestBuilderStats(t *testing.T) {
+	options := data.DefaultOptions()
+	b := gcode.NewGCodeBuilder(&options)
+	b.SetMoveSpeed(100)
+	b.SetExtrudeSpeed(10)
+	b.SetRetractionSpeed(20)
+	b.SetRetractionAmount(2)
+
+	b.Move(data.NewMicroVec3(0, 10000, 0))
+	b.Retract()
+	b.Unretract()
+	b.SetFeature(data.FeatureOuterWall)
+	b.AddMove(data.NewMicroVec3(0, 30000, 0), 4)
+	b.SetFeature(data.FeatureInfill)
+	b.Retract()
+	b.Unretract()
+	b.AddMove(data.NewMicroVec3(10000, 30000, 0), 1)
+
+	stats := b.Stats()
+	test.Equals(t, 2, stats.Retractions)
+	test.Assert(t, stats.RetractionTime > 0.3999 && stats.RetractionTime < 0.4001, "unexpected retraction time %v", stats.RetractionTime)
+	test.Equals(t, data.Millimeter(10), stats.TravelDistance)
+	test.Equals(t, 0.1, stats.TravelTime)
+	test.Equals(t, data.Millimeter(5), stats.Filament)
+	// 0.1s travel + 4 * 0.1s retraction + 3s extrusion
+	test.Assert(t, stats.PrintTime > 3.4999 && stats.PrintTime < 3.5001, "unexpected print time %v", stats.PrintTime)
+	test.Equals(t, map[data.Feature]data.FeatureStats{
+		data.FeatureOuterWall: {PrintTime: 2, Filament: 4, Length: 20},
+		data.FeatureInfill:    {PrintTime: 1, Filament: 1, Length: 10, Retractions: 1},
+	}, stats.Features)
+}
+
+func TestBuilderToolPaths(t *testing.T) {
+	options := data.DefaultOptions()
+	b := gcode.NewGCodeBuilder(&options)
+	b.SetMoveSpeed(100)
+	b.SetExtrudeSpeed(10)
+	b.RecordToolPaths(true)
+
+	b.Move(data.NewMicroVec3(0, 10000, 0))
+	b.SetFeature(data.FeatureOuterWall)
+	b.AddMove(data.NewMicroVec3(0, 30000, 0), 4)
+	b.AddMove(data.NewMicroVec3(10000, 30000, 0), 1)
+	b.SetFeature(data.FeatureInfill)
+	b.AddMove(data.NewMicroVec3(10000, 40000, 0), 1)
+
+	paths := b.TakeToolPaths()
+	test.Equals(t, 3, len(paths))
+
+	test.Equals(t, data.FeatureTravel, paths[0].Feature)
+	test.Equals(t, []int{0, 100}, paths[0].Speed)
+	test.Equals(t, data.Millimeter(10), paths[0].Length())
+
+	test.Equals(t, data.FeatureOuterWall, paths[1].Feature)
+	test.Equals(t, 3, len(paths[1].Points))
+	test.Equals(t, data.Millimeter(30), paths[1].Length())
+	test.Equals(t, data.Millimeter(5), paths[1].Filament())
+
+	test.Equals(t, data.FeatureInfill, paths[2].Feature)
+	test.Equals(t, int64(10000), int64(paths[2].Points[0].X()))
+	test.Equals(t, data.Millimeter(1), paths[2].Filament())
+
+	test.Equals(t, 0, len(b.TakeToolPaths()))
+}
+
+func TestBuilderExcessiveExtrusions(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Printer.MaxExtrusionPerMM = 0.1
+	b := gcode.NewGCodeBuilder(&options)
+
+	b.Move(data.NewMicroVec3(0, 0, 0))
+	b.SetFeature(data.FeatureInfill)
+	b.AddMove(data.NewMicroVec3(10000, 0, 0), 0.5)
+	// a zero length move cannot extrude anything
+	b.AddMove(data.NewMicroVec3(10000, 0, 0), 2)
+	b.AddMove(data.NewMicroVec3(20000, 0, 0), 4)
+
+	test.Equals(t, "G0 X0.00 Y0.00\n"+
+		"G1 X10.00 Y0.00 E0.5000\n"+
+		"G0 X10.00 Y0.00\n"+
+		"G1 X20.00 Y0.00 E1.5000\n", b.String())
+	test.Equals(t, []string{
+		"the \"infill\" move from X10.00 Y0.00 to X10.00 Y0.00 extrudes 2.0000mm of filament over 0.000mm, but only 0.0000mm are allowed",
+		"the \"infill\" move from X10.00 Y0.00 to X20.00 Y0.00 extrudes 4.0000mm of filament over 10.000mm, but only 1.0000mm are allowed",
+	}, b.TakeExcessiveExtrusions())
+	test.Equals(t, 0, len(b.TakeExcessiveExtrusions()))
+}
+
+func TestBuilderChangeTool(t *testing.T) {
+	options := data.DefaultOptions()
+	b := gcode.NewGCodeBuilder(&options)
+	b.SetRetractionSpeed(30)
+	b.SetRetractionAmount(2)
+
+	// before the hot end is heated the extruder is only selected
+	b.ChangeTool(1)
+	b.ChangeTool(0)
+	test.Equals(t, "T1\nT0\n", b.Flush())
+	test.Equals(t, 0, b.Stats().ToolChanges)
+
+	// a new extruder is heated to the current temperature, the filament is retracted meanwhile
+	b.WaitForTemperature(200)
+	b.Flush()
+	b.ChangeTool(1)
+	b.ChangeTool(1)
+	test.Equals(t, 1, b.Extruder())
+	test.Equals(t, "G1 F1800 E-2.0000\n"+
+		"T1\n"+
+		"M109 S200 ; wait for hot end temperature\n"+
+		"G1 F1800 E0.0000\n", b.Flush())
+
+	// the temperature of a known extruder is not set again if it did not change
+	b.ChangeTool(0)
+	test.Equals(t, "G1 F1800 E-2.0000\n"+
+		"T0\n"+
+		"G1 F1800 E0.0000\n", b.Flush())
+
+	// the tool change time is added to the print time for each change after heating
+	stats := b.Stats()
+	test.Equals(t, 2, stats.ToolChanges)
+	test.Equals(t, 2*options.Printer.ToolChangeTime, stats.ToolChangeTime)
+	test.Assert(t, math.Abs(stats.RetractionTime+stats.ToolChangeTime-stats.PrintTime) < 1e-9, "the print time should contain the tool change time, got %v", stats.PrintTime)
+}
@@ -0,0 +1,223 @@
+// This file provides the routing of travel moves inside of the parts, so that they do not cross the perimeters (combing).
+
+package gcode
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"log"
+	"math"
+)
+
+// combNudge is the distance by which points moved onto the comb boundary are moved further inside,
+// so that they do not lie exactly on it.
+const combNudge data.Micrometer = 10
+
+// combPlanner routes the travel moves between two points of the same part of a layer inside of the part.
+// The moves run inside of the boundary, which are the parts inset by a distance, so that they stay away from the
+// outer perimeters. The boundary is calculated when it is needed for the first time.
+type combPlanner struct {
+	parts    []data.LayerPart
+	boundary []data.LayerPart
+	ready    bool
+	logger   *log.Logger
+}
+
+// newCombPlanner returns a combPlanner for the parts of a layer.
+// The logger is used for the warnings of the clipper which calculates the boundary.
+func newCombPlanner(parts []data.LayerPart, logger *log.Logger) *combPlanner {
+	return &combPlanner{parts: parts, logger: logger}
+}
+
+// route returns the points of a travel move from start to end which stays inside of one part,
+// without the start but with the end. The boundary is the part inset by distance.
+// Points between the boundary and the outline of the part, e.g. on the outer perimeter,
+// are connected to the nearest point of the boundary first.
+// It returns false if start and end do not lie inside of the same part.
+func (c *combPlanner) route(start, end data.MicroPoint, distance data.Micrometer) (data.Path, bool) {
+	if !c.ready {
+		c.ready = true
+		cl := clip.NewClipper(clip.WithLogger(c.logger))
+		for _, part := range c.parts {
+			for _, inset := range cl.Inset(part, 0, 1, -distance)[0] {
+				// fewer points make the routing faster, the boundary moves by far less than the distance
+				var holes data.Paths
+				for _, hole := range inset.Holes() {
+					holes = append(holes, data.DouglasPeucker(append(data.Path{}, hole...), distance/4))
+				}
+				c.boundary = append(c.boundary, data.NewBasicLayerPart(data.DouglasPeucker(append(data.Path{}, inset.Outline()...), distance/4), holes))
+			}
+		}
+	}
+
+	startPart, startPoint, ok := c.enter(start, distance)
+	if !ok {
+		return nil, false
+	}
+	endPart, endPoint, ok := c.enter(end, distance)
+	if !ok || startPart != endPart {
+		return nil, false
+	}
+
+	part := c.boundary[startPart]
+	contours := append(data.Paths{part.Outline()}, part.Holes()...)
+	around, ok := combAround(contours, startPoint, endPoint, len(contours))
+	if !ok {
+		return nil, false
+	}
+	path := shortcutPath(contours, part, append(data.Path{startPoint}, around...))
+
+	var result data.Path
+	if startPoint.Sub(start).Size2() > 0 {
+		result = append(result, startPoint)
+	}
+	result = append(result, path[1:]...)
+	if endPoint.Sub(end).Size2() > 0 {
+		result = append(result, end)
+	}
+	return result, true
+}
+
+// enter returns the index of the boundary part which contains the point together with the point itself.
+// If the point lies inside of a part but not inside of its boundary, the nearest point of the boundary
+// within the distance is returned instead. It returns false if there is no such point.
+func (c *combPlanner) enter(p data.MicroPoint, distance data.Micrometer) (int, data.MicroPoint, bool) {
+	for i, part := range c.boundary {
+		if data.PartContains(part, p) {
+			return i, p, true
+		}
+	}
+
+	inside := false
+	for _, part := range c.parts {
+		if data.PartContains(part, p) {
+			inside = true
+			break
+		}
+	}
+	if !inside {
+		return 0, nil, false
+	}
+
+	bestPart := -1
+	var best data.MicroPoint
+	var bestDistance data.Micrometer
+	for i, part := range c.boundary {
+		for _, contour := range append(data.Paths{part.Outline()}, part.Holes()...) {
+			for k := range contour {
+				point := data.ProjectOnSegment(p, contour[k], contour[(k+1)%len(contour)])
+				if d := point.Sub(p).Size(); d <= distance && (bestPart == -1 || d < bestDistance) {
+					bestPart, best, bestDistance = i, point, d
+				}
+			}
+		}
+	}
+	if bestPart == -1 {
+		return 0, nil, false
+	}
+
+	// move the point a bit further inside, so that it does not lie on the boundary
+	if bestDistance > 0 {
+		nudged := best.Add(best.Sub(p).Mul(combNudge).Div(bestDistance))
+		if data.PartContains(c.boundary[bestPart], nudged) {
+			best = nudged
+		}
+	}
+	return bestPart, best, true
+}
+
+// combAround returns the points of a path from a to b (without a) which goes around all contours the straight line
+// crosses. The line runs straight up to the first contour it crosses and then along the contour up to the point where
+// the line crosses the contour for the last time. From there the rest of the line is routed the same way.
+// At most depth contours are passed, otherwise false is returned.
+func combAround(contours data.Paths, a, b data.MicroPoint, depth int) (data.Path, bool) {
+	hitContour, firstEdge, lastEdge := -1, 0, 0
+	firstT, lastT := 0.0, 0.0
+	for contourNr, contour := range contours {
+		for i := range contour {
+			t, ok := segmentIntersection(a, b, contour[i], contour[(i+1)%len(contour)])
+			if !ok {
+				continue
+			}
+			if hitContour == -1 || t < firstT {
+				hitContour, firstEdge, firstT = contourNr, i, t
+			}
+		}
+	}
+	if hitContour == -1 {
+		return data.Path{b}, true
+	}
+	if depth == 0 {
+		return nil, false
+	}
+
+	contour := contours[hitContour]
+	for i := range contour {
+		if t, ok := segmentIntersection(a, b, contour[i], contour[(i+1)%len(contour)]); ok && t >= lastT {
+			lastEdge, lastT = i, t
+		}
+	}
+
+	entry := pointAlong(a, b, firstT)
+	exit := pointAlong(a, b, lastT)
+	rest, ok := combAround(contours, exit, b, depth-1)
+	if !ok {
+		return nil, false
+	}
+	return append(data.ShortestContourWalk(contour, firstEdge, entry, lastEdge, exit), rest...), true
+}
+
+// shortcutPath removes the points of the path which can be skipped by a straight line
+// that stays inside of the part whose contours are given.
+func shortcutPath(contours data.Paths, part data.LayerPart, path data.Path) data.Path {
+	result := data.Path{path[0]}
+	for i := 0; i < len(path)-1; {
+		j := i + 1
+		for j+1 < len(path) && isInsideLine(contours, part, path[i], path[j+1]) {
+			j++
+		}
+		result = append(result, path[j])
+		i = j
+	}
+	return result
+}
+
+// isInsideLine returns true if the line from a to b crosses none of the contours and lies inside of the part.
+func isInsideLine(contours data.Paths, part data.LayerPart, a, b data.MicroPoint) bool {
+	for _, contour := range contours {
+		for i := range contour {
+			if _, ok := segmentIntersection(a, b, contour[i], contour[(i+1)%len(contour)]); ok {
+				return false
+			}
+		}
+	}
+	return data.PartContains(part, a.Add(b).Div(2))
+}
+
+// segmentIntersection returns the position t (0 to 1) along the segment from a to b where it crosses
+// the segment from c to d. Crossings at the very ends of the segment from a to b and parallel segments are ignored.
+func segmentIntersection(a, b, c, d data.MicroPoint) (float64, bool) {
+	rx, ry := float64(b.X()-a.X()), float64(b.Y()-a.Y())
+	sx, sy := float64(d.X()-c.X()), float64(d.Y()-c.Y())
+	denominator := rx*sy - ry*sx
+	if denominator == 0 {
+		return 0, false
+	}
+
+	qx, qy := float64(c.X()-a.X()), float64(c.Y()-a.Y())
+	t := (qx*sy - qy*sx) / denominator
+	u := (qx*ry - qy*rx) / denominator
+	const epsilon = 1e-9
+	if t <= epsilon || t >= 1-epsilon || u < 0 || u > 1 {
+		return 0, false
+	}
+	return t, true
+}
+
+// pointAlong returns the point at the position t (0 to 1) along the segment from a to b.
+func pointAlong(a, b data.MicroPoint, t float64) data.MicroPoint {
+	return data.NewMicroPoint(
+		a.X()+data.Micrometer(math.Round(t*float64(b.X()-a.X()))),
+		a.Y()+data.Micrometer(math.Round(t*float64(b.Y()-a.Y()))),
+	)
+}
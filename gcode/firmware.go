@@ -0,0 +1,49 @@
+// This file provides the commands which differ between the firmwares of the printers.
+
+package gcode
+
+import (
+	"fmt"
+)
+
+// Firmware creates the gcode commands which differ between the firmwares of the printers.
+type Firmware interface {
+	// Dwell returns the command which pauses for the given time in seconds.
+	Dwell(seconds int) string
+
+	// LoadBedMesh returns the command which loads and enables the bed mesh with the given profile name.
+	LoadBedMesh(profile string) string
+}
+
+// NewFirmware returns the Firmware with the given name as set by the option Printer.Firmware.
+// Unknown firmwares are handled as marlin.
+func NewFirmware(name string) Firmware {
+	switch name {
+	case "klipper":
+		return klipper{}
+	default:
+		return marlin{}
+	}
+}
+
+type marlin struct{}
+
+func (marlin) Dwell(seconds int) string {
+	return fmt.Sprintf("G4 S%d", seconds)
+}
+
+// LoadBedMesh loads the mesh from the EEPROM slot given as profile.
+func (marlin) LoadBedMesh(profile string) string {
+	return fmt.Sprintf("M420 S1 L%s", profile)
+}
+
+type klipper struct{}
+
+// Dwell uses milliseconds as klipper does not support seconds for G4.
+func (klipper) Dwell(seconds int) string {
+	return fmt.Sprintf("G4 P%d", seconds*1000)
+}
+
+func (klipper) LoadBedMesh(profile string) string {
+	return fmt.Sprintf("BED_MESH_PROFILE LOAD=%s", profile)
+}
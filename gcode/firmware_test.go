@@ -0,0 +1,38 @@
+package gcode_test
+
+import (
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestFirmware(t *testing.T) {
+	var testCases = map[string]struct {
+		firmware        string
+		expectedDwell   string
+		expectedBedMesh string
+	}{
+		"marlin": {
+			firmware:        "marlin",
+			expectedDwell:   "G4 S90",
+			expectedBedMesh: "M420 S1 L2",
+		},
+		"klipper": {
+			firmware:        "klipper",
+			expectedDwell:   "G4 P90000",
+			expectedBedMesh: "BED_MESH_PROFILE LOAD=2",
+		},
+		"unknown is marlin": {
+			firmware:        "",
+			expectedDwell:   "G4 S90",
+			expectedBedMesh: "M420 S1 L2",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		firmware := gcode.NewFirmware(testCase.firmware)
+		test.Equals(t, testCase.expectedDwell, firmware.Dwell(90))
+		test.Equals(t, testCase.expectedBedMesh, firmware.LoadBedMesh("2"))
+	}
+}
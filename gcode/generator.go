@@ -2,8 +2,10 @@
 package gcode
 
 import (
+	"fmt"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
+	"strings"
 )
 
 // Renderer can be used to add GCodes based on the current layer and layer data.
@@ -17,31 +19,116 @@ type Renderer interface {
 	Render(b *Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error
 }
 
+// LayerRenderer is a Renderer which is rendered only once per layer even if the model is printed several times
+// (see data.InstancedModel) or its bodies are printed by different extruders (see data.MaterialModel),
+// e.g. because it adds commands for the whole layer.
+// All other renderers are rendered once for each instance with the Builder moved by the offset of the instance (see Builder.SetOffset).
+type LayerRenderer interface {
+	Renderer
+
+	// RenderOncePerLayer only marks the renderer and does nothing.
+	RenderOncePerLayer()
+}
+
+// SequenceRenderer is a Renderer which has to know which object it renders if several objects are printed
+// one after the other (see data.SequentialModel), e.g. because it adds the starting gcode only for the first object.
+type SequenceRenderer interface {
+	Renderer
+
+	// StartObject is called before the layers of an object are rendered with the number of the object
+	// and the count of all objects. If only one model is printed, it is called with (0, 1).
+	StartObject(objectNr, objectCount int)
+}
+
+// sequenceLift is the distance by which the nozzle is lifted above the finished objects
+// before it moves to the next object.
+const sequenceLift = data.Micrometer(5000)
+
 type generator struct {
-	options *data.Options
-	gcode   string
-	builder *Builder
+	options    *data.Options
+	gcode      string
+	builder    *Builder
+	layerCount int
+	layerStats []data.LayerStats
+
+	renderers    []Renderer
+	calculator   ExtrusionCalculator
+	featureHooks []FeatureHook
+	pathHooks    []ToolPathHook
+
+	transform        func(p data.MicroVec3) data.MicroVec3
+	maxSegmentLength data.Micrometer
+
+	// instances contains the offsets of all instances of the model.
+	instances []data.MicroPoint
 
-	renderers []Renderer
+	// materials contains the layers of the bodies of each extruder while a model whose bodies are printed by
+	// different extruders is generated. The layers of unused extruders are nil.
+	materials [][]data.PartitionedLayer
+
+	// overriddenOptions caches the options of layers with overridden settings by their args.
+	overriddenOptions map[string]*data.Options
 }
 
 func (g *generator) Init(model data.OptimizedModel) {
+	g.instances = nil
+	if instanced, ok := model.(data.InstancedModel); ok {
+		g.instances = instanced.Instances()
+	}
+
 	for _, renderer := range g.renderers {
 		renderer.Init(model)
 	}
 }
 
-type option func(s *generator)
+// Option customizes the generator created by NewGenerator, e.g. by adding a renderer.
+type Option func(s *generator)
 
 // WithRenderer adds a renderer to the generator.
-func WithRenderer(r Renderer) option {
+func WithRenderer(r Renderer) Option {
 	return func(s *generator) {
 		s.renderers = append(s.renderers, r)
 	}
 }
 
+// WithExtrusionCalculator replaces the default extrusion calculation of the Builder.
+func WithExtrusionCalculator(c ExtrusionCalculator) Option {
+	return func(s *generator) {
+		s.calculator = c
+	}
+}
+
+// WithPositionTransform sets a transformation which is applied to all positions (see Builder.SetPositionTransform).
+func WithPositionTransform(transform func(p data.MicroVec3) data.MicroVec3, maxSegmentLength data.Micrometer) Option {
+	return func(s *generator) {
+		s.transform = transform
+		s.maxSegmentLength = maxSegmentLength
+	}
+}
+
+// WithFeatureHook adds a hook which is called each time the printed feature changes (see Builder.OnFeatureChange).
+func WithFeatureHook(hook FeatureHook) Option {
+	return func(s *generator) {
+		s.featureHooks = append(s.featureHooks, hook)
+	}
+}
+
+// ToolPathHook is called by the generator with the tool paths of each layer after the layer is rendered.
+// The tool paths contain the role of each path (see data.ToolPath).
+type ToolPathHook func(layerNr int, z data.Micrometer, paths []data.ToolPath)
+
+// WithToolPathHook adds a hook which receives the tool paths of each rendered layer.
+// The tool paths are only recorded if at least one hook is added.
+func WithToolPathHook(hook ToolPathHook) Option {
+	return func(s *generator) {
+		s.pathHooks = append(s.pathHooks, hook)
+	}
+}
+
 // NewGenerator returns a new Builder generator which can be customized by adding several renderers using WithRenderer().
-func NewGenerator(options *data.Options, generatorOptions ...option) handler.GCodeGenerator {
+// The returned generator also implements handler.GCodeStatsProvider, handler.GCodeRangeGenerator, handler.GCodeSequenceGenerator
+// and handler.GCodeMaterialGenerator.
+func NewGenerator(options *data.Options, generatorOptions ...Option) handler.GCodeGenerator {
 	g := &generator{
 		options: options,
 	}
@@ -55,25 +142,395 @@ func NewGenerator(options *data.Options, generatorOptions ...option) handler.GCo
 
 func (g *generator) init() {
 	g.builder = NewGCodeBuilder(g.options)
+	if g.calculator != nil {
+		g.builder.SetExtrusionCalculator(g.calculator)
+	}
+	if g.transform != nil {
+		g.builder.SetPositionTransform(g.transform, g.maxSegmentLength)
+	}
+	for _, hook := range g.featureHooks {
+		g.builder.OnFeatureChange(hook)
+	}
+	// the tool paths are always needed for the layer stats
+	g.builder.RecordToolPaths(true)
+	g.startObject(0, 1)
+}
+
+// startObject tells all SequenceRenderers which object is rendered next.
+func (g *generator) startObject(objectNr, objectCount int) {
+	for _, renderer := range g.renderers {
+		if sequenceRenderer, ok := renderer.(SequenceRenderer); ok {
+			sequenceRenderer.StartObject(objectNr, objectCount)
+		}
+	}
 }
 
 // Generate generates the GCode by using the renderers added to the generator.
 // The final GCode is just returned as string.
 func (g *generator) Generate(layers []data.PartitionedLayer) (string, error) {
+	return g.GenerateRange(layers, 0, len(layers))
+}
+
+// GenerateRange generates the GCode of the layers from the layer number from (inclusive) to the layer number to (exclusive).
+// Generating from layer 0 starts a new GCode, each further range continues it.
+// Only the GCode of the range is returned.
+func (g *generator) GenerateRange(layers []data.PartitionedLayer, from, to int) (string, error) {
+	if from == 0 || g.builder == nil {
+		g.init()
+		g.layerStats = make([]data.LayerStats, 0, len(layers))
+	}
+
+	err := g.renderLayers(layers, from, to)
+	if err != nil {
+		return "", err
+	}
+
+	g.layerCount = to
+	return g.builder.Flush(), nil
+}
+
+// GenerateSequence generates the GCode of several objects which are printed one after the other in the given order.
+// The layers contain the modified layers of each object.
+// After each object the nozzle is lifted above all finished objects and moved above the next object.
+func (g *generator) GenerateSequence(objects []data.OptimizedModel, layers [][]data.PartitionedLayer) (string, error) {
+	if len(objects) != len(layers) {
+		return "", fmt.Errorf("there are %v objects but the layers of %v objects", len(objects), len(layers))
+	}
+
+	g.init()
+	g.layerStats = nil
+	g.layerCount = 0
+
+	heights := g.options.Print.LayerHeights()
+	var finishedHeight data.Micrometer
+	for objectNr, object := range objects {
+		g.Init(object)
+		g.startObject(objectNr, len(objects))
+
+		if objectNr > 0 {
+			g.moveToObject(object, finishedHeight+sequenceLift)
+		}
+
+		err := g.renderLayers(layers[objectNr], 0, len(layers[objectNr]))
+		if err != nil {
+			return "", err
+		}
+
+		g.layerCount += len(layers[objectNr])
+		if len(layers[objectNr]) > 0 {
+			finishedHeight = data.Max(finishedHeight, heights.Z(len(layers[objectNr])-1))
+		}
+	}
+
+	return g.builder.Flush(), nil
+}
+
+// GenerateMaterials generates the GCode of a model whose bodies are printed by different extruders.
+// The layers of the whole model contain the structures around the bodies, e.g. the support, and are printed
+// by the extruder which is active at the beginning of the layer.
+// The layers of the bodies contain the bodies of each extruder, the layers of unused extruders are nil.
+// The print starts with the first used extruder.
+func (g *generator) GenerateMaterials(layers []data.PartitionedLayer, materials [][]data.PartitionedLayer) (string, error) {
+	for extruder, material := range materials {
+		if material != nil && len(material) != len(layers) {
+			return "", fmt.Errorf("the model has %v layers but the bodies of extruder %v have %v layers", len(layers), extruder, len(material))
+		}
+	}
+
 	g.init()
+	g.layerStats = make([]data.LayerStats, 0, len(layers))
+	g.materials = materials
+	defer func() {
+		g.materials = nil
+	}()
+
+	for extruder, material := range materials {
+		if material != nil {
+			g.builder.ChangeTool(extruder)
+			break
+		}
+	}
+
+	err := g.renderLayers(layers, 0, len(layers))
+	if err != nil {
+		return "", err
+	}
+
+	g.layerCount = len(layers)
+	return g.builder.Flush(), nil
+}
 
-	maxLayer := len(layers) - 1
+// moveToObject lifts the nozzle to the given height and moves it above the center of the object,
+// so that it cannot touch the finished objects on the way to the next object.
+func (g *generator) moveToObject(object data.OptimizedModel, z data.Micrometer) {
+	g.builder.AddComment("NEXT_OBJECT")
+	g.builder.Retract()
 
-	for layerNr := range layers {
+	current := g.builder.CurrentPosition()
+	g.builder.Move(data.NewMicroVec3(current.X(), current.Y(), z))
+
+	min, max := object.Min(), object.Max()
+	g.builder.Move(data.NewMicroVec3((min.X()+max.X())/2, (min.Y()+max.Y())/2, z))
+	g.builder.Unretract()
+}
+
+// renderLayers renders the layers from the layer number from (inclusive) to the layer number to (exclusive).
+// Only the layers within the slice range of the options are rendered (see data.Options.PrintedLayers),
+// so the last printed layer is passed to the renderers as max layer.
+func (g *generator) renderLayers(layers []data.PartitionedLayer, from, to int) error {
+	first, maxLayer := g.options.PrintedLayers(len(layers))
+	if len(layers) > 0 && maxLayer < first {
+		return fmt.Errorf("no layer lies between %.3fmm and %.3fmm", g.options.Slicing.SliceFrom, g.options.Slicing.SliceTo)
+	}
+	if from < first {
+		from = first
+	}
+	if to > maxLayer+1 {
+		to = maxLayer + 1
+	}
+	heights := g.options.Print.LayerHeights()
+
+	for layerNr := from; layerNr < to; layerNr++ {
 		g.options.GoSlice.Logger.Printf("Render layer %d/%d\n", layerNr, maxLayer)
-		for _, renderer := range g.renderers {
-			z := g.options.Print.InitialLayerThickness + data.Micrometer(layerNr)*g.options.Print.LayerThickness
-			err := renderer.Render(g.builder, layerNr, maxLayer, layers[layerNr], z, g.options)
+		z := heights.Z(layerNr)
+
+		if g.options.Slicing.EmptyLayers == "abort" && data.IsEmptyLayer(layers[layerNr]) {
+			return fmt.Errorf("layer %v at %vmm contains nothing to print, use another empty layer handling to print the model anyway", layerNr, z.ToMillimeter())
+		}
+
+		// The first and the last layer are never skipped as they contain the starting and the ending gcode.
+		if g.options.Slicing.EmptyLayers == "skip" && layerNr > first && layerNr < maxLayer && data.IsEmptyLayer(layers[layerNr]) {
+			// the layer stats are still added, so that they stay at the index of their layer
+			g.layerStats = append(g.layerStats, data.LayerStats{Z: z.ToMillimeter(), Features: map[data.Feature]data.FeatureStats{}})
+			continue
+		}
+
+		options, err := g.layerOptions(layers[layerNr])
+		if err != nil {
+			return fmt.Errorf("layer %d: %w", layerNr, err)
+		}
+
+		before := g.builder.Stats()
+		// the moves written before the layer, e.g. by the start gcode, are not part of the layer stats
+		leading := g.builder.TakeToolPaths()
+		err = g.render(layerNr, maxLayer, layers[layerNr], z, options)
+		if err != nil {
+			return err
+		}
+		for _, excessive := range g.builder.TakeExcessiveExtrusions() {
+			if g.options.Printer.ExcessiveExtrusion == "error" {
+				return fmt.Errorf("layer %d: %s", layerNr, excessive)
+			}
+			g.options.GoSlice.Logger.Printf("Warning: layer %d: %s, the extrusion is clamped\n", layerNr, excessive)
+		}
+		paths := g.builder.TakeToolPaths()
+		g.layerStats = append(g.layerStats, layerStats(before, g.builder.Stats(), paths, layers[layerNr], z))
+
+		if len(g.pathHooks) > 0 {
+			paths = append(leading, paths...)
+			for _, hook := range g.pathHooks {
+				hook(layerNr, z, paths)
+			}
+		}
+	}
+
+	return nil
+}
+
+// layerOptions returns the options used to render the layer.
+// If the layer has overridden settings (see data.OverriddenSettings), they are applied to a copy of the options.
+// The copies are cached, so that the renderers get the same options for all layers with the same settings.
+// Problems added by the overridden settings (see data.Options.Validate) are logged once when the copy is created.
+func (g *generator) layerOptions(layer data.PartitionedLayer) (*data.Options, error) {
+	if layer == nil {
+		return g.options, nil
+	}
+
+	args := data.OverriddenSettings(layer)
+	if len(args) == 0 {
+		return g.options, nil
+	}
+
+	key := strings.Join(args, "\x00")
+	if options, ok := g.overriddenOptions[key]; ok {
+		return options, nil
+	}
+
+	options, err := g.options.Override(args)
+	if err != nil {
+		return nil, err
+	}
+
+	// the problems of the options are already reported, so only the ones added by the override are reported
+	known := map[string]bool{}
+	for _, warning := range g.options.Validate() {
+		known[warning] = true
+	}
+	for _, warning := range options.Validate() {
+		if !known[warning] {
+			g.options.GoSlice.Logger.Printf("Warning: layer override %v: %s\n", strings.Join(args, " "), warning)
+		}
+	}
+
+	if g.overriddenOptions == nil {
+		g.overriddenOptions = map[string]*data.Options{}
+	}
+	g.overriddenOptions[key] = &options
+	return &options, nil
+}
+
+// render renders one layer using all renderers.
+// If the model has several instances, the renderers between two LayerRenderers are rendered for one instance after the other.
+// The instances are printed in reverse order on every second layer, so that each layer starts at the instance the last one ended.
+func (g *generator) render(layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	if g.materials != nil {
+		return g.renderMaterials(layerNr, maxLayer, layer, z, options)
+	}
+
+	if layer != nil {
+		g.builder.SetTravelBoundary(layer.LayerParts())
+	}
+
+	for i := 0; i < len(g.renderers); {
+		if _, ok := g.renderers[i].(LayerRenderer); ok || len(g.instances) <= 1 {
+			err := g.renderers[i].Render(g.builder, layerNr, maxLayer, layer, z, options)
 			if err != nil {
-				return "", err
+				return err
+			}
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(g.renderers) {
+			if _, ok := g.renderers[end].(LayerRenderer); ok {
+				break
 			}
+			end++
 		}
+
+		for n := range g.instances {
+			instance := g.instances[n]
+			if layerNr%2 == 1 {
+				instance = g.instances[len(g.instances)-1-n]
+			}
+
+			g.builder.SetOffset(instance)
+			for _, renderer := range g.renderers[i:end] {
+				err := renderer.Render(g.builder, layerNr, maxLayer, layer, z, options)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		g.builder.SetOffset(data.NewMicroPoint(0, 0))
+		i = end
+	}
+
+	return nil
+}
+
+// renderMaterials renders one layer of a model whose bodies are printed by different extruders.
+// The LayerRenderers are rendered once with the layer of the whole model.
+// The renderers between two LayerRenderers render the layer of the whole model with the active extruder
+// and then the layer of the bodies of each extruder after changing to it.
+// The active extruder is used first, so that a tool change is only needed for the other extruders.
+func (g *generator) renderMaterials(layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	for i := 0; i < len(g.renderers); {
+		if _, ok := g.renderers[i].(LayerRenderer); ok {
+			err := g.renderers[i].Render(g.builder, layerNr, maxLayer, layer, z, options)
+			if err != nil {
+				return err
+			}
+			i++
+			continue
+		}
+
+		end := i + 1
+		for end < len(g.renderers) {
+			if _, ok := g.renderers[end].(LayerRenderer); ok {
+				break
+			}
+			end++
+		}
+
+		g.builder.SetTravelBoundary(layer.LayerParts())
+		for _, renderer := range g.renderers[i:end] {
+			err := renderer.Render(g.builder, layerNr, maxLayer, layer, z, options)
+			if err != nil {
+				return err
+			}
+		}
+
+		active := g.builder.Extruder()
+		extruders := []int{active}
+		for extruder := range g.materials {
+			if extruder != active {
+				extruders = append(extruders, extruder)
+			}
+		}
+
+		for _, extruder := range extruders {
+			if extruder >= len(g.materials) || g.materials[extruder] == nil || data.IsEmptyLayer(g.materials[extruder][layerNr]) {
+				continue
+			}
+			bodies := g.materials[extruder][layerNr]
+
+			bodyOptions, err := g.layerOptions(bodies)
+			if err != nil {
+				return fmt.Errorf("layer %d: %w", layerNr, err)
+			}
+
+			g.builder.ChangeTool(extruder)
+			g.builder.SetTravelBoundary(bodies.LayerParts())
+			for _, renderer := range g.renderers[i:end] {
+				err := renderer.Render(g.builder, layerNr, maxLayer, bodies, z, bodyOptions)
+				if err != nil {
+					return err
+				}
+			}
+		}
+		i = end
+	}
+
+	return nil
+}
+
+// Stats returns the statistics of the GCode generated last.
+func (g *generator) Stats() data.Stats {
+	if g.builder == nil {
+		return data.NewStats()
+	}
+
+	stats := g.builder.Stats()
+	stats.Layers = g.layerCount
+	stats.LayerStats = g.layerStats
+	return stats
+}
+
+// layerStats returns the statistics of a layer.
+// The features are analyzed using the tool paths of the layer,
+// only the retractions are taken by comparing the statistics before and after the layer was rendered.
+func layerStats(before, after data.Stats, paths []data.ToolPath, layer data.PartitionedLayer, z data.Micrometer) data.LayerStats {
+	stats := data.LayerStats{
+		Z:         z.ToMillimeter(),
+		PrintTime: after.PrintTime - before.PrintTime,
+		Features:  data.ToolPathStats(paths),
+	}
+	if layer != nil {
+		stats.Parts = len(layer.LayerParts())
+	}
+
+	for feature, featureStats := range after.Features {
+		retractions := featureStats.Retractions - before.Features[feature].Retractions
+		if retractions == 0 {
+			continue
+		}
+
+		layerFeatureStats := stats.Features[feature]
+		layerFeatureStats.Retractions = retractions
+		stats.Features[feature] = layerFeatureStats
 	}
 
-	return g.builder.String(), nil
+	return stats
 }
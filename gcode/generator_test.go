@@ -3,9 +3,13 @@ package gcode_test
 import (
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/gcode/renderer"
+	"github.com/aligator/goslice/handler"
 	"github.com/aligator/goslice/util/test"
+	"io/ioutil"
 	"log"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -56,3 +60,214 @@ func TestGCodeGenerator(t *testing.T) {
 		"number 1\n"+
 		"number 2\n", result)
 }
+
+func TestGCodeGeneratorEmptyLayers(t *testing.T) {
+	square := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(1000, 0),
+		data.NewMicroPoint(1000, 1000),
+		data.NewMicroPoint(0, 1000),
+	}, nil)
+	layers := []data.PartitionedLayer{
+		data.NewPartitionedLayer([]data.LayerPart{square}),
+		data.NewPartitionedLayer(nil),
+		data.NewPartitionedLayer([]data.LayerPart{square}),
+	}
+
+	var testCases = map[string]struct {
+		mode          string
+		expectedError bool
+		contains      []string
+		notContains   []string
+	}{
+		"travel": {
+			mode:     "travel",
+			contains: []string{";LAYER:1\n", ";EMPTY_LAYER\nG0 X0.00 Y0.00 Z0.40", "number 1\n"},
+		},
+		"skip": {
+			mode: "skip",
+			// no renderer is called for the skipped layer
+			notContains: []string{";LAYER:1\n", ";EMPTY_LAYER", "number 1\n"},
+		},
+		"abort": {
+			mode:          "abort",
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.GoSlice.Logger = log.New(ioutil.Discard, "", 0)
+		options.Slicing.EmptyLayers = testCase.mode
+
+		generator := gcode.NewGenerator(&options, gcode.WithRenderer(&renderer.PreLayer{}), gcode.WithRenderer(&fakeRenderer{t: t, c: newCounter()}))
+		generator.Init(nil)
+		result, err := generator.Generate(layers)
+
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "error expected")
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Assert(t, strings.Contains(result, ";LAYER:2\n"), "the last layer should be rendered")
+		for _, expected := range testCase.contains {
+			test.Assert(t, strings.Contains(result, expected), "the gcode should contain %q", expected)
+		}
+		for _, unexpected := range testCase.notContains {
+			test.Assert(t, !strings.Contains(result, unexpected), "the gcode should not contain %q", unexpected)
+		}
+	}
+}
+
+// boundsModel is an OptimizedModel which only provides its bounds.
+type boundsModel struct {
+	data.OptimizedModel
+	min, max data.MicroVec3
+}
+
+func (b boundsModel) Min() data.MicroVec3 {
+	return b.min
+}
+
+func (b boundsModel) Max() data.MicroVec3 {
+	return b.max
+}
+
+func TestGCodeGeneratorSequence(t *testing.T) {
+	options := data.DefaultOptions()
+	options.GoSlice.Logger = log.New(ioutil.Discard, "", 0)
+
+	objects := []data.OptimizedModel{
+		boundsModel{min: data.NewMicroVec3(0, 0, 0), max: data.NewMicroVec3(10000, 10000, 400)},
+		boundsModel{min: data.NewMicroVec3(30000, 0, 0), max: data.NewMicroVec3(40000, 10000, 400)},
+	}
+	layers := [][]data.PartitionedLayer{
+		{data.NewPartitionedLayer(nil), data.NewPartitionedLayer(nil)},
+		{data.NewPartitionedLayer(nil), data.NewPartitionedLayer(nil)},
+	}
+
+	generator := gcode.NewGenerator(&options, gcode.WithRenderer(&renderer.PreLayer{}), gcode.WithRenderer(&renderer.PostLayer{}))
+	result, err := generator.(handler.GCodeSequenceGenerator).GenerateSequence(objects, layers)
+	test.Ok(t, err)
+
+	test.Equals(t, 1, strings.Count(result, ";START_GCODE"))
+	test.Equals(t, 1, strings.Count(result, ";END_GCODE"))
+	test.Equals(t, 2, strings.Count(result, ";LAYER:0\n"))
+
+	// the nozzle is lifted 5mm above the first object before it moves to the second one
+	next := strings.Index(result, ";NEXT_OBJECT")
+	test.Assert(t, next > strings.Index(result, ";LAYER:1\n") && next < strings.Index(result, ";END_GCODE"), "the next object should start after the first one")
+	test.Assert(t, strings.Contains(result, "G0 X0.00 Y0.00 Z5.40\nG0 X35.00 Y5.00\n"), "the nozzle should move above the second object")
+	test.Equals(t, 4, generator.(handler.GCodeStatsProvider).Stats().Layers)
+}
+
+func TestGCodeGeneratorMaterials(t *testing.T) {
+	options := data.DefaultOptions()
+	options.GoSlice.Logger = log.New(ioutil.Discard, "", 0)
+
+	square := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(1000, 0),
+		data.NewMicroPoint(1000, 1000),
+		data.NewMicroPoint(0, 1000),
+	}, nil)
+	layers := []data.PartitionedLayer{
+		data.NewPartitionedLayer([]data.LayerPart{square}),
+		data.NewPartitionedLayer([]data.LayerPart{square}),
+	}
+	// the third extruder prints only the first layer, the second extruder is not used
+	materials := [][]data.PartitionedLayer{
+		{data.NewPartitionedLayer([]data.LayerPart{square}), data.NewPartitionedLayer([]data.LayerPart{square})},
+		nil,
+		{data.NewPartitionedLayer([]data.LayerPart{square}), data.NewPartitionedLayer(nil)},
+	}
+
+	rendererCounter := newCounter()
+	generator := gcode.NewGenerator(&options, gcode.WithRenderer(&renderer.PreLayer{}), gcode.WithRenderer(&fakeRenderer{t: t, c: rendererCounter}))
+	generator.Init(nil)
+	result, err := generator.(handler.GCodeMaterialGenerator).GenerateMaterials(layers, materials)
+	test.Ok(t, err)
+
+	// the whole layer is rendered once and the bodies once for each extruder
+	test.Equals(t, 3, strings.Count(result, "number 0\n"))
+	test.Equals(t, 2, strings.Count(result, "number 1\n"))
+	test.Assert(t, !strings.Contains(result, "T1\n"), "the unused extruder should not be selected")
+
+	// the active extruder continues in the next layer
+	test.Equals(t, 1, strings.Count(result, "T2\n"))
+	test.Equals(t, 1, strings.Count(result, "T0\n"))
+	test.Assert(t, strings.Index(result, "T2\n") < strings.Index(result, ";LAYER:1\n"), "the third extruder should print in the first layer")
+	test.Assert(t, strings.Index(result, "T0\n") > strings.Index(result, ";LAYER:1\n"), "the first extruder should be selected again in the second layer")
+
+	_, err = generator.(handler.GCodeMaterialGenerator).GenerateMaterials(layers, [][]data.PartitionedLayer{layers[:1]})
+	test.Assert(t, err != nil, "the bodies should have as many layers as the model")
+}
+
+func TestGCodeGeneratorSliceRange(t *testing.T) {
+	options := data.DefaultOptions()
+	options.GoSlice.Logger = log.New(ioutil.Discard, "", 0)
+	options.Slicing.SliceFrom = 0.6
+	options.Slicing.SliceTo = 0.8
+
+	layers := make([]data.PartitionedLayer, 6)
+	for i := range layers {
+		layers[i] = data.NewPartitionedLayer(nil)
+	}
+
+	generator := gcode.NewGenerator(&options, gcode.WithRenderer(&renderer.PreLayer{}), gcode.WithRenderer(&renderer.PostLayer{}))
+	generator.Init(nil)
+	result, err := generator.Generate(layers)
+	test.Ok(t, err)
+
+	test.Assert(t, !strings.Contains(result, ";LAYER:1\n") && !strings.Contains(result, ";LAYER:4\n"), "only the layers within the range should be rendered")
+	test.Assert(t, strings.Index(result, ";LAYER:2\n") < strings.Index(result, ";START_GCODE"), "the starting gcode should be added to the first layer of the range")
+	test.Assert(t, strings.Contains(result, "G1 Z5.60 F5000"), "the nozzle should be lifted above the print")
+	test.Assert(t, strings.Index(result, ";LAYER:3\n") < strings.Index(result, ";END_GCODE"), "the ending gcode should be added to the last layer of the range")
+
+	options.Slicing.SliceFrom = 5
+	options.Slicing.SliceTo = 0
+	_, err = generator.Generate(layers)
+	test.Assert(t, err != nil, "an error is expected if no layer lies within the range")
+}
+
+// overriddenLayer is an empty layer with overridden settings.
+type overriddenLayer struct {
+	data.PartitionedLayer
+	settings data.LayerSettings
+}
+
+func (l overriddenLayer) Attributes() map[string]interface{} {
+	return map[string]interface{}{"layerOverride": l.settings}
+}
+
+// layerSpeedRenderer adds the layer speed of the options it gets.
+type layerSpeedRenderer struct{}
+
+func (layerSpeedRenderer) Init(model data.OptimizedModel) {}
+
+func (layerSpeedRenderer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	b.AddCommand("speed %v", options.Print.LayerSpeed)
+	return nil
+}
+
+func TestGCodeGeneratorLayerOverrides(t *testing.T) {
+	options := data.DefaultOptions()
+	options.GoSlice.Logger = log.New(ioutil.Discard, "", 0)
+	options.Print.LayerSpeed = 60
+
+	layers := []data.PartitionedLayer{
+		data.NewPartitionedLayer(nil),
+		overriddenLayer{PartitionedLayer: data.NewPartitionedLayer(nil), settings: data.LayerSettings{"--layer-speed=30"}},
+		data.NewPartitionedLayer(nil),
+	}
+
+	generator := gcode.NewGenerator(&options, gcode.WithRenderer(layerSpeedRenderer{}))
+	generator.Init(nil)
+	result, err := generator.Generate(layers)
+	test.Ok(t, err)
+
+	test.Equals(t, "speed 60.000\nspeed 30.000\nspeed 60.000\n", result)
+	test.Equals(t, data.Millimeter(60), options.Print.LayerSpeed)
+}
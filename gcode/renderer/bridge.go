@@ -0,0 +1,88 @@
+// This file provides a renderer for bridges.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// Bridge is a renderer which generates the gcode for the attribute "bridges".
+// Each bridge is filled with parallel lines in its direction using the bridge speed and flow.
+// The bridge flow is applied on top of the flow set before, e.g. by a layer override.
+// The bridge fan speed is applied by the builder for the feature data.FeatureBridge.
+type Bridge struct {
+	min, max data.MicroPoint
+}
+
+func (r *Bridge) Init(model data.OptimizedModel) {
+	r.min = model.Min().PointXY()
+	r.max = model.Max().PointXY()
+}
+
+func (r *Bridge) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	bridges, err := modifier.Bridges(layer)
+	if err != nil {
+		return err
+	}
+	if len(bridges) == 0 {
+		return nil
+	}
+
+	if options.Print.Bridge.Speed > 0 {
+		previousSpeed := b.ExtrudeSpeed()
+		b.SetExtrudeSpeed(options.Print.Bridge.Speed)
+		defer b.SetExtrudeSpeed(previousSpeed)
+	}
+
+	// the bridge flow is applied on top of the current flow
+	previousFlow := b.FlowOverride()
+	baseFlow := previousFlow
+	if baseFlow <= 0 {
+		baseFlow = 100
+	}
+	b.SetFlowOverride(baseFlow * options.Print.Bridge.Flow / 100)
+	defer b.SetFlowOverride(previousFlow)
+
+	bridgeOutlines := make(data.Paths, len(bridges))
+	for i, bridge := range bridges {
+		bridgeOutlines[i] = bridge.Part.Outline()
+	}
+
+	// Continue with the bridge nearest to the current position.
+	width := options.Printer.LayerExtrusionWidth(layerNr)
+	isUsed := make([]bool, len(bridges))
+	for range bridges {
+		bridge := bridges[nextNearest(bridgeOutlines, isUsed, b.CurrentPosition().PointXY())]
+		b.AddComment("TYPE:FILL")
+		b.AddComment("BRIDGE-FILL")
+		b.SetFeature(data.FeatureBridge)
+
+		lines, err := clip.NewLinearPattern(width, width, r.min, r.max, bridge.Angle, false, true).Fill(layerNr, bridge.Part)
+		if err != nil {
+			return err
+		}
+
+		// Start at the end of the lines which is nearer to the current position.
+		if len(lines) > 0 {
+			current := b.CurrentPosition().PointXY()
+			first := lines[0][0]
+			lastPath := lines[len(lines)-1]
+			last := lastPath[len(lastPath)-1]
+			if current.Sub(last).Size2() < current.Sub(first).Size2() {
+				lines = lines.Reversed()
+			}
+		}
+
+		for _, line := range lines {
+			err := b.AddPolygon(layer, line, z, true)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+// This file provides a renderer for the lines planned by the first layer modifier, e.g. the skirt and the brim.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// FirstLayer draws the lines planned by the first layer modifier in the planned order.
+// The lines are already planned for all instances of the model, so they are rendered only once per layer.
+type FirstLayer struct{}
+
+func (FirstLayer) Init(model data.OptimizedModel) {}
+
+func (FirstLayer) RenderOncePerLayer() {}
+
+func (FirstLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	paths, err := modifier.FirstLayerPaths(layer)
+	if err != nil {
+		return err
+	}
+
+	var feature data.Feature
+	for _, path := range paths {
+		if path.Feature != feature {
+			// Use type SKIRT also for the brim as Cura also does it the same. This is for support of the gcode viewer in Cura.
+			b.AddComment("TYPE:SKIRT")
+			b.SetFeature(path.Feature)
+			feature = path.Feature
+		}
+
+		// As the lines surround the objects there shouldn't be any collision with the model -> currentLayer is nil
+		err := b.AddPolygon(nil, path.Path, z, path.Open)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,50 @@
+// This file provides a renderer for the lines filling the gaps between the perimeters.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// GapFill is a renderer which generates the gcode for the attribute "gapFill".
+// Each line is printed with the width of the gap it fills.
+// It has to be added after the Perimeter renderer.
+type GapFill struct{}
+
+func (g GapFill) Init(model data.OptimizedModel) {}
+
+func (g GapFill) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	lines, err := modifier.GapFill(layer)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	b.AddComment("TYPE:FILL")
+	b.AddComment("GAP-FILL")
+	b.SetFeature(data.FeatureGapFill)
+	b.SetExtrudeSpeed(options.Print.LayerSpeed)
+
+	paths := make(data.Paths, len(lines))
+	for i, line := range lines {
+		paths[i] = line.Path
+	}
+
+	// Continue with the line nearest to the current position.
+	isUsed := make([]bool, len(lines))
+	for range lines {
+		line := lines[nextNearest(paths, isUsed, b.CurrentPosition().PointXY())]
+		b.SetExtrusionOverride(0, line.Width)
+		err := b.AddPolygon(layer, line.Path, z, !line.Closed)
+		b.DisableExtrusionOverride()
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
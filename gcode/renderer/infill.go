@@ -3,6 +3,7 @@
 package renderer
 
 import (
+	"errors"
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/gcode"
@@ -12,25 +13,73 @@ import (
 // Infill is a renderer which can fill parts which are defined by a layer part attribute of a specific name.
 // The attribute has to be of type []data.LayerPart.
 type Infill struct {
-	// PatternSetup is called once on init and sets a specific pattern this infill renderer should use.
+	// PatternSetup sets a specific pattern this infill renderer should use.
+	// It is called once for each distinct options passed to Render, e.g. if settings are overridden for some layers.
 	// Min and max define the dimension of the model (in X and Y direction)
-	PatternSetup func(min data.MicroPoint, max data.MicroPoint) clip.Pattern
+	PatternSetup func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern
+
+	// PartPatternSetup is optional and replaces the pattern of PatternSetup if it is set
+	// and the infill is aligned to the parts (see data.PrintOptions.InfillAlignToPart).
+	// It is called for each part with the principal axis of the part outline in degrees (see data.Path.PrincipalAxis)
+	// so that the pattern can be aligned with the part.
+	PartPatternSetup func(options *data.Options, min data.MicroPoint, max data.MicroPoint, axis float64) clip.Pattern
 
 	// AttrName is the name of the attribute containing the []data.LayerPart's to fill.
 	AttrName string
 
+	// SurfacesAttrName is optional and is the name of the attribute containing the top surfaces above the layer
+	// which are passed to patterns implementing clip.LayerPattern (see clip.LayerInfo.Surfaces).
+	SurfacesAttrName string
+
 	// Comments is a list of comments to be added before each infill.
 	Comments []string
 
-	pattern clip.Pattern
+	// Feature is the type of the infill, e.g. data.FeatureTopSkin.
+	Feature data.Feature
+
+	// TrimToPerimeters trims the infill lines at the center lines of the most inner perimeters.
+	TrimToPerimeters bool
+
+	// Speed is the speed in mm per second used for the infill.
+	// If it is 0, the current extrude speed is used.
+	Speed data.Millimeter
+
+	// CombinedLayers is the amount of layers whose infill is printed at once (see data.PrintOptions.InfillCombineLayers).
+	// The infill is printed with multiplied flow and the pattern is filled with the number of the group of layers
+	// instead of the layer number, so that e.g. the direction of the lines still alternates between the groups.
+	// If it is 0, the infill belongs to a single layer.
+	CombinedLayers int
+
+	// NonPlanar raises the infill lines to the surface of the model.
+	// It only has an effect if data.NonPlanarTopOptions are enabled and
+	// should only be used for the top infill.
+	NonPlanar bool
+
+	patterns map[*data.Options]clip.Pattern
+	min, max data.MicroPoint
+	model    data.OptimizedModel
 }
 
 func (i *Infill) Init(model data.OptimizedModel) {
-	i.pattern = i.PatternSetup(model.Min().PointXY(), model.Max().PointXY())
+	i.min = model.Min().PointXY()
+	i.max = model.Max().PointXY()
+	i.patterns = map[*data.Options]clip.Pattern{}
+	i.model = model
+}
+
+// pattern returns the pattern for the options and creates it on first use.
+func (i *Infill) pattern(options *data.Options) clip.Pattern {
+	pattern, ok := i.patterns[options]
+	if !ok {
+		pattern = i.PatternSetup(options, i.min, i.max)
+		i.patterns[options] = pattern
+	}
+	return pattern
 }
 
 func (i *Infill) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
-	if i.pattern == nil {
+	alignToPart := i.PartPatternSetup != nil && options.Print.InfillAlignToPart
+	if !alignToPart && i.pattern(options) == nil {
 		return nil
 	}
 
@@ -42,17 +91,91 @@ func (i *Infill) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.
 		return nil
 	}
 
-	for _, part := range infillParts {
+	if i.Speed > 0 {
+		previousSpeed := b.ExtrudeSpeed()
+		b.SetExtrudeSpeed(i.Speed)
+		defer b.SetExtrudeSpeed(previousSpeed)
+	}
+
+	layerInfo := clip.LayerInfo{
+		Nr: layerNr,
+		Z:  z,
+	}
+
+	if i.CombinedLayers > 1 {
+		previousFlow := b.FlowOverride()
+		flow := previousFlow
+		if flow <= 0 {
+			flow = 100
+		}
+		b.SetFlowOverride(flow * i.CombinedLayers)
+		defer b.SetFlowOverride(previousFlow)
+
+		layerInfo.Nr = layerNr / i.CombinedLayers
+	}
+	if i.SurfacesAttrName != "" {
+		layerInfo.Surfaces, err = modifier.PartsAttribute(layer, i.SurfacesAttrName)
+		if err != nil {
+			return err
+		}
+	}
+
+	var centerLines []data.LayerPart
+	if i.TrimToPerimeters {
+		centerLines, err = modifier.InnermostPerimeters(layer)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Continue with the part nearest to the current position.
+	partOutlines := outlines(infillParts)
+	isUsed := make([]bool, len(infillParts))
+	for range infillParts {
+		part := infillParts[nextNearest(partOutlines, isUsed, b.CurrentPosition().PointXY())]
+		pattern := i.pattern(options)
+		if alignToPart {
+			pattern = i.PartPatternSetup(options, i.min, i.max, part.Outline().PrincipalAxis())
+			if pattern == nil {
+				continue
+			}
+		}
+
 		for _, c := range i.Comments {
 			b.AddComment(c)
 		}
+		b.SetFeature(i.Feature)
 
-		infill, err := i.pattern.Fill(layerNr, part)
+		infill, err := clip.FillLayer(pattern, layerInfo, part)
 		if err != nil {
 			return err
 		}
+
+		if centerLines != nil {
+			var ok bool
+			infill, ok = clip.NewClipper(clip.WithLogger(options.GoSlice.Logger)).IntersectLines(infill, centerLines)
+			if !ok {
+				return errors.New("could not trim the infill lines at the perimeters")
+			}
+		}
+
+		// Start at the end of the infill which is nearer to the current position.
+		if len(infill) > 0 {
+			current := b.CurrentPosition().PointXY()
+			first := infill[0][0]
+			lastPath := infill[len(infill)-1]
+			last := lastPath[len(lastPath)-1]
+			if current.Sub(last).Size2() < current.Sub(first).Size2() {
+				infill = infill.Reversed()
+			}
+		}
 		for _, path := range infill {
-			err := b.AddPolygon(layer, path, z, true)
+			var err error
+			if i.NonPlanar && options.Print.NonPlanarTop.Enabled {
+				err = b.AddPath(layer, nonPlanarPath(i.model, path, z, options.Print.NonPlanarTop.MaxHeight, options.Printer.ExtrusionWidth))
+			} else {
+				err = b.AddPolygon(layer, path, z, true)
+			}
 			if err != nil {
 				return err
 			}
@@ -61,3 +184,33 @@ func (i *Infill) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.
 
 	return nil
 }
+
+// nonPlanarPath splits the path into segments of at most segmentLength and
+// raises each point to the surface of the model.
+// The points are never lowered below z and never raised more than maxHeight.
+func nonPlanarPath(model data.OptimizedModel, path data.Path, z data.Micrometer, maxHeight data.Micrometer, segmentLength data.Micrometer) []data.MicroVec3 {
+	if len(path) == 0 {
+		return nil
+	}
+
+	raise := func(p data.MicroPoint) data.MicroVec3 {
+		pointZ := z
+		surfaceZ, ok := model.RaycastZ(p, z+maxHeight)
+		if ok && surfaceZ > z {
+			pointZ = surfaceZ
+		}
+		return data.NewMicroVec3(p.X(), p.Y(), pointZ)
+	}
+
+	result := []data.MicroVec3{raise(path[0])}
+	for j := 1; j < len(path); j++ {
+		segment := path[j].Sub(path[j-1])
+		count := segment.Size()/segmentLength + 1
+
+		for k := data.Micrometer(1); k <= count; k++ {
+			result = append(result, raise(path[j-1].Add(segment.Mul(k).Div(count))))
+		}
+	}
+
+	return result
+}
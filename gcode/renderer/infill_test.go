@@ -0,0 +1,150 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// rampModel is an OptimizedModel with a surface which rises from 0 at x = 0 to 1000 at x = 10000.
+type rampModel struct {
+	data.OptimizedModel
+}
+
+func (r rampModel) RaycastZ(p data.MicroPoint, maxZ data.Micrometer) (data.Micrometer, bool) {
+	z := p.X() / 10
+	if p.X() < 0 || p.X() > 10000 || z > maxZ {
+		return 0, false
+	}
+	return z, true
+}
+
+func TestNonPlanarPath(t *testing.T) {
+	var testCases = map[string]struct {
+		path data.Path
+		// expected contains the x and z of each point of the result
+		expected [][2]data.Micrometer
+	}{
+		"empty path": {
+			path: nil,
+		},
+		"raised to the surface": {
+			path: data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(2000, 0)},
+			expected: [][2]data.Micrometer{
+				{0, 100}, {333, 100}, {666, 100}, {1000, 100}, {1333, 133}, {1666, 166}, {2000, 200},
+			},
+		},
+		"limited by the max height": {
+			path: data.Path{data.NewMicroPoint(5000, 0), data.NewMicroPoint(7000, 0)},
+			expected: [][2]data.Micrometer{
+				{5000, 500}, {5333, 533}, {5666, 566}, {6000, 600}, {6333, 100}, {6666, 100}, {7000, 100},
+			},
+		},
+		"beside the model": {
+			path: data.Path{data.NewMicroPoint(-1000, 0), data.NewMicroPoint(-600, 0)},
+			expected: [][2]data.Micrometer{
+				{-1000, 100}, {-800, 100}, {-600, 100},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		result := nonPlanarPath(rampModel{}, testCase.path, 100, 500, 400)
+
+		test.Equals(t, len(testCase.expected), len(result))
+		for i, point := range result {
+			test.Equals(t, testCase.expected[i], [2]data.Micrometer{point.X(), point.Z()})
+			test.Equals(t, data.Micrometer(0), point.Y())
+		}
+	}
+}
+
+// boundsModel is an OptimizedModel which only provides its bounds.
+type boundsModel struct {
+	data.OptimizedModel
+	min, max data.MicroVec3
+}
+
+func (m boundsModel) Min() data.MicroVec3 {
+	return m.min
+}
+
+func (m boundsModel) Max() data.MicroVec3 {
+	return m.max
+}
+
+func TestInfillTrimToPerimeters(t *testing.T) {
+	// with two insets of 0.4mm the center line of the innermost perimeter is 0.6mm inside of the square
+	var testCases = map[string]struct {
+		trim bool
+		// expectedEnd is the distance of the ends of the infill lines from the outline of the square
+		expectedEnd data.Micrometer
+	}{
+		"trimmed at the center line": {
+			trim:        true,
+			expectedEnd: 600,
+		},
+		"not trimmed": {
+			// the infill overlap of 50% keeps the infill 0.1mm inside of the center line
+			trim:        false,
+			expectedEnd: 700,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.InfillTrimToPerimeter = testCase.trim
+
+		layers := []data.PartitionedLayer{data.NewPartitionedLayer([]data.LayerPart{
+			data.NewBasicLayerPart(counterClockwiseSquare(), nil),
+		})}
+		test.Ok(t, modifier.NewPerimeterModifier(&options).Modify(layers))
+
+		// the infill modifier uses the overlapping perimeters as infill area of a layer inside of the model,
+		// if the infill is trimmed, they reach half a line beyond the center line
+		overlapPerimeters, ok := layers[0].Attributes()["overlapPerimeters"].([][]data.LayerPart)
+		test.Assert(t, ok, "the overlapping perimeters should be set")
+		attributes := map[string]interface{}{}
+		for name, attribute := range layers[0].Attributes() {
+			attributes[name] = attribute
+		}
+		attributes["infill"] = overlapPerimeters[0]
+		layer := attributedLayer{PartitionedLayer: layers[0], attributes: attributes}
+
+		b := gcode.NewGCodeBuilder(&options)
+		b.SetExtrusion(200, 400)
+		b.RecordToolPaths(true)
+
+		infill := &Infill{
+			PatternSetup: func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+				return clip.NewLinearPattern(options.Printer.ExtrusionWidth, 2000, min, max, 45, false, false)
+			},
+			AttrName:         "infill",
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+		}
+		infill.Init(boundsModel{
+			min: data.NewMicroVec3(0, 0, 0),
+			max: data.NewMicroVec3(10000, 10000, 200),
+		})
+		test.Ok(t, infill.Render(b, 0, 1, layer, 200, &options))
+
+		lines := 0
+		for _, path := range b.TakeToolPaths() {
+			if path.Feature != data.FeatureInfill {
+				continue
+			}
+			lines++
+			for _, end := range []data.MicroVec3{path.Points[0], path.Points[len(path.Points)-1]} {
+				distance := data.Min(data.Min(end.X(), 10000-end.X()), data.Min(end.Y(), 10000-end.Y()))
+				test.Assert(t, distance >= testCase.expectedEnd-1 && distance <= testCase.expectedEnd+1, "the end %v of the infill line should be %v away from the outline but is %v", end.PointXY(), testCase.expectedEnd, distance)
+			}
+		}
+		test.Assert(t, lines > 0, "the infill should contain lines")
+	}
+}
@@ -8,30 +8,38 @@ import (
 )
 
 // PreLayer adds starting gcode, resets the extrude speeds on each layer and enables the fan above a specific layer.
-type PreLayer struct{}
+// The starting gcode optionally waits for the bed to heat soak and loads a bed mesh using the commands of the configured firmware.
+// It also moves to the height of empty layers if the option Slicing.EmptyLayers is "travel".
+// Empty layers skipped by the generator are not rendered at all.
+// If the layer at which the normal temperature is set is skipped, it is set at the next rendered layer.
+// If several objects are printed one after the other, the starting gcode is only added to the first object.
+// If only a z range is printed (see data.SlicingOptions.SliceFrom), the starting gcode is added to the first layer of the range.
+type PreLayer struct {
+	objectNr int
+
+	// previousLayer is the number of the layer rendered last.
+	previousLayer int
+}
 
-func (PreLayer) Init(model data.OptimizedModel) {}
+func (*PreLayer) Init(model data.OptimizedModel) {}
 
-func (PreLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
-	b.AddComment("LAYER:%v", layerNr)
-	if layerNr == 0 {
-		b.AddComment("Generated with GoSlice")
-		b.AddComment("______________________")
-
-		b.AddCommand("M107 ; disable fan")
+func (*PreLayer) RenderOncePerLayer() {}
 
-		// set and wait for the initial temperature
-		b.AddComment("SET_INITIAL_TEMP")
-		b.AddCommand("M104 S%d ; start heating hot end", options.Filament.InitialHotEndTemperature)
-		b.AddCommand("M190 S%d ; heat and wait for bed", options.Filament.InitialBedTemperature)
-		b.AddCommand("M109 S%d ; wait for hot end temperature", options.Filament.InitialHotEndTemperature)
+func (p *PreLayer) StartObject(objectNr, objectCount int) {
+	p.objectNr = objectNr
+}
 
-		// starting gcode
-		b.AddComment("START_GCODE")
-		b.AddCommand("G1 Z5 F5000 ; lift nozzle")
-		b.AddCommand("G92 E0 ; reset extrusion distance")
+func (p *PreLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	// The print starts at the first layer within the slice range, which is usually layer 0.
+	first, _ := options.PrintedLayers(maxLayer + 1)
 
-		b.SetExtrusion(options.Print.InitialLayerThickness, options.Printer.ExtrusionWidth)
+	empty := layerNr > first && data.IsEmptyLayer(layer)
+	b.AddComment("LAYER:%v", layerNr)
+	if layerNr == first {
+		p.previousLayer = layerNr - 1
+		if p.objectNr == 0 {
+			p.startGCode(b, first, z, options)
+		}
 
 		// set speeds
 		b.SetExtrudeSpeed(options.Print.LayerSpeed)
@@ -40,11 +48,16 @@ func (PreLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.P
 		// set retraction
 		b.SetRetractionSpeed(options.Filament.RetractionSpeed)
 		b.SetRetractionAmount(options.Filament.RetractionLength)
+	}
+
+	if layerNr == 0 {
+		b.SetExtrusion(options.Print.InitialLayerThickness, options.Printer.LayerExtrusionWidth(0))
 
 		// force the InitialLayerSpeed for first layer
 		b.SetExtrudeSpeedOverride(options.Print.IntialLayerSpeed)
-	} else if layerNr == 1 {
-		b.SetExtrusion(options.Print.LayerThickness, options.Printer.ExtrusionWidth)
+	} else {
+		// the thickness may change with each layer if layer thickness ranges are used
+		b.SetExtrusion(options.Print.LayerHeights().Thickness(layerNr), options.Printer.ExtrusionWidth)
 	}
 
 	if layerNr > 0 {
@@ -52,35 +65,101 @@ func (PreLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.P
 		b.SetExtrudeSpeed(options.Print.LayerSpeed)
 	}
 
-	if fanSpeed, ok := options.Filament.FanSpeed.LayerToSpeedLUT[layerNr]; ok {
-		if fanSpeed == 0 {
-			b.AddCommand("M107 ; disable fan")
-		} else {
-			b.AddCommand("M106 S%d; change fan speed", fanSpeed)
-		}
+	// the speed set last is applied on every layer, as the fan speed may be overridden for some layers
+	if fanSpeed, ok := options.Filament.FanSpeed.SpeedAt(layerNr); ok {
+		b.SetFanSpeed(fanSpeed)
 	}
 
-	if layerNr == options.Filament.InitialTemperatureLayerCount {
+	// the layer at which the temperature changes may have been skipped
+	if layerNr >= options.Filament.InitialTemperatureLayerCount && p.previousLayer < options.Filament.InitialTemperatureLayerCount {
 		// set the normal temperature
 		// this is done without waiting
 		b.AddComment("SET_TEMP")
 		b.AddCommand("M140 S%d", options.Filament.BedTemperature)
-		b.AddCommand("M104 S%d", options.Filament.HotEndTemperature)
+		b.SetTemperature(options.Filament.HotEndTemperature)
 	}
 
+	if empty && options.Slicing.EmptyLayers == "travel" {
+		// move to the layer height so that the next layer starts at the expected height
+		b.AddComment("EMPTY_LAYER")
+		current := b.CurrentPosition()
+		b.Move(data.NewMicroVec3(current.X(), current.Y(), z))
+	}
+
+	p.previousLayer = layerNr
 	return nil
 }
 
+// startGCode adds the starting gcode before the first printed layer.
+// If the print starts above the first layer, the nozzle is lifted above the print and the temperatures
+// and the fan speed are set as they would be at that layer.
+func (p *PreLayer) startGCode(b *gcode.Builder, first int, z data.Micrometer, options *data.Options) {
+	hotEndTemperature := options.Filament.InitialHotEndTemperature
+	bedTemperature := options.Filament.InitialBedTemperature
+	if first > 0 && first >= options.Filament.InitialTemperatureLayerCount {
+		hotEndTemperature = options.Filament.HotEndTemperature
+		bedTemperature = options.Filament.BedTemperature
+	}
+
+	b.AddComment("Generated with GoSlice")
+	b.AddComment("______________________")
+
+	b.AddCommand("M107 ; disable fan")
+
+	// set and wait for the initial temperature
+	b.AddComment("SET_INITIAL_TEMP")
+	b.AddCommand("M104 S%d ; start heating hot end", hotEndTemperature)
+	b.AddCommand("M190 S%d ; heat and wait for bed", bedTemperature)
+
+	firmware := gcode.NewFirmware(options.Printer.Firmware)
+	if options.Filament.HeatSoakTime > 0 {
+		b.AddComment("HEAT_SOAK")
+		b.AddCommand("%s ; wait until the bed is heated evenly", firmware.Dwell(options.Filament.HeatSoakTime))
+	}
+	b.WaitForTemperature(hotEndTemperature)
+
+	// starting gcode
+	b.AddComment("START_GCODE")
+	if options.Printer.BedMeshProfile != "" {
+		b.AddCommand("%s ; load bed mesh", firmware.LoadBedMesh(options.Printer.BedMeshProfile))
+	}
+	if options.Printer.Kinematics == "belt" {
+		// on belt printers Z moves the belt
+		b.AddCommand("G1 Y5 F5000 ; lift nozzle")
+	} else if first > 0 {
+		b.AddCommand("G1 Z%0.2f F5000 ; lift nozzle above the print", (z + 5000).ToMillimeter())
+	} else {
+		b.AddCommand("G1 Z5 F5000 ; lift nozzle")
+	}
+	b.AddCommand("G92 E0 ; reset extrusion distance")
+
+	if first > 0 {
+		// use the fan speed which was set last below the first layer
+		if fanSpeed, ok := options.Filament.FanSpeed.SpeedAt(first - 1); ok {
+			b.SetFanSpeed(fanSpeed)
+		}
+	}
+}
+
 // PostLayer adds GCode at the last layer.
-type PostLayer struct{}
+// If several objects are printed one after the other, the ending gcode is only added to the last object.
+type PostLayer struct {
+	lastObject bool
+}
+
+func (*PostLayer) Init(model data.OptimizedModel) {}
 
-func (PostLayer) Init(model data.OptimizedModel) {}
+func (*PostLayer) RenderOncePerLayer() {}
 
-func (PostLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+func (p *PostLayer) StartObject(objectNr, objectCount int) {
+	p.lastObject = objectNr == objectCount-1
+}
+
+func (p *PostLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
 	// ending gcode
-	if layerNr == maxLayer {
+	if layerNr == maxLayer && p.lastObject {
 		b.AddComment("END_GCODE")
-		b.SetExtrusion(options.Print.LayerThickness, options.Printer.ExtrusionWidth)
+		b.SetExtrusion(options.Print.LayerHeights().Thickness(layerNr), options.Printer.ExtrusionWidth)
 		b.AddCommand("M107 ; disable fan")
 
 		// disable heaters
@@ -88,6 +167,12 @@ func (PostLayer) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.
 		b.AddCommand("M140 S0 ; Set bed to 0C (off)")
 
 		b.AddCommand("G28 X0  ; home X axis to get head out of the way")
+
+		if options.Printer.Kinematics == "belt" {
+			b.AddCommand("G91 ; relative positioning")
+			b.AddCommand("G1 Z%0.2f F%v ; advance the belt to eject the print", options.Printer.Belt.EjectDistance, int(options.Print.MoveSpeed)*60)
+			b.AddCommand("G90 ; absolute positioning")
+		}
 		b.AddCommand("M84 ;steppers off")
 
 	}
@@ -0,0 +1,68 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// extrusionRecorder is an ExtrusionCalculator which records the line width and the layer thickness it is called with.
+type extrusionRecorder struct {
+	lineWidth, layerThickness data.Micrometer
+}
+
+func (r *extrusionRecorder) ExtrusionPerMM(lineWidth, layerThickness data.Micrometer) data.Millimeter {
+	r.lineWidth = lineWidth
+	r.layerThickness = layerThickness
+	return 1
+}
+
+func TestPreLayerExtrusion(t *testing.T) {
+	var testCases = map[string]struct {
+		firstLayerWidth   data.Micrometer
+		layerNr           int
+		expectedWidth     data.Micrometer
+		expectedThickness data.Micrometer
+	}{
+		"first layer": {
+			firstLayerWidth:   600,
+			layerNr:           0,
+			expectedWidth:     600,
+			expectedThickness: 300,
+		},
+		"second layer": {
+			firstLayerWidth:   600,
+			layerNr:           1,
+			expectedWidth:     400,
+			expectedThickness: 200,
+		},
+		"first layer without own width": {
+			layerNr:           0,
+			expectedWidth:     400,
+			expectedThickness: 300,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Printer.ExtrusionWidth = 400
+		options.Printer.FirstLayerExtrusionWidth = testCase.firstLayerWidth
+		options.Print.InitialLayerThickness = 300
+		options.Print.LayerThickness = 200
+
+		recorder := &extrusionRecorder{}
+		b := gcode.NewGCodeBuilder(&options)
+		b.SetExtrusionCalculator(recorder)
+
+		preLayer := &PreLayer{}
+		for layerNr := 0; layerNr <= testCase.layerNr; layerNr++ {
+			err := preLayer.Render(b, layerNr, 1, data.NewPartitionedLayer(nil), 0, &options)
+			test.Ok(t, err)
+		}
+
+		test.Equals(t, testCase.expectedWidth, recorder.lineWidth)
+		test.Equals(t, testCase.expectedThickness, recorder.layerThickness)
+	}
+}
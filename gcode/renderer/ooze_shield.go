@@ -0,0 +1,39 @@
+// This file provides a renderer for the ooze shield.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// OozeShield prints the ooze shield generated by the ooze shield modifier as a single wall.
+// It has to be added before the renderers of the model, so that the nozzle is wiped at the shield first.
+type OozeShield struct{}
+
+func (OozeShield) Init(model data.OptimizedModel) {}
+
+func (OozeShield) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	shield, err := modifier.OozeShield(layer)
+	if err != nil {
+		return err
+	}
+	if len(shield) == 0 {
+		return nil
+	}
+
+	// Use type SKIRT as Cura also does it for the ooze shield. This is for support of the gcode viewer in Cura.
+	b.AddComment("TYPE:SKIRT")
+	b.SetFeature(data.FeatureOozeShield)
+
+	for _, part := range shield {
+		// the shield surrounds the model, so the travel to it is checked for crossing perimeters
+		err := b.AddPolygon(layer, part.Outline(), z, false)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,46 @@
+// This file provides the ordering of features by proximity.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// nextNearest returns the index of the path which is not used yet and has the point nearest to the given position
+// and marks it as used. Paths without points are taken last.
+// If all paths are used, -1 is returned.
+func nextNearest(paths data.Paths, isUsed []bool, position data.MicroPoint) int {
+	bestIndex := -1
+	var bestDistance data.Micrometer
+	for i, path := range paths {
+		if isUsed[i] {
+			continue
+		}
+
+		for _, point := range path {
+			distance := position.Sub(point).Size2()
+			if bestIndex == -1 || len(paths[bestIndex]) == 0 || distance < bestDistance {
+				bestIndex = i
+				bestDistance = distance
+			}
+		}
+
+		if bestIndex == -1 {
+			bestIndex = i
+		}
+	}
+
+	if bestIndex != -1 {
+		isUsed[bestIndex] = true
+	}
+	return bestIndex
+}
+
+// outlines returns the outlines of the parts.
+func outlines(parts []data.LayerPart) data.Paths {
+	result := make(data.Paths, len(parts))
+	for i, part := range parts {
+		result[i] = part.Outline()
+	}
+	return result
+}
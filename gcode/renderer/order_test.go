@@ -0,0 +1,82 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// square returns a counter clockwise square with the given side length starting at (x, y).
+func square(x, y, size data.Micrometer) data.Path {
+	return data.Path{
+		data.NewMicroPoint(x, y),
+		data.NewMicroPoint(x+size, y),
+		data.NewMicroPoint(x+size, y+size),
+		data.NewMicroPoint(x, y+size),
+	}
+}
+
+func TestNextNearest(t *testing.T) {
+	var testCases = map[string]struct {
+		paths    data.Paths
+		position data.MicroPoint
+		expected []int
+	}{
+		"nearest first": {
+			paths: data.Paths{
+				square(20000, 0, 1000),
+				square(0, 0, 1000),
+				square(10000, 0, 1000),
+			},
+			position: data.NewMicroPoint(0, 0),
+			expected: []int{1, 2, 0, -1},
+		},
+		"nearest point of the path": {
+			paths: data.Paths{
+				square(0, 0, 1000),
+				square(5000, 0, 10000),
+			},
+			position: data.NewMicroPoint(14000, 14000),
+			expected: []int{1, 0, -1},
+		},
+		"empty paths last": {
+			paths: data.Paths{
+				{},
+				square(10000, 0, 1000),
+				{},
+				square(0, 0, 1000),
+			},
+			position: data.NewMicroPoint(0, 0),
+			expected: []int{3, 1, 0, 2, -1},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		isUsed := make([]bool, len(testCase.paths))
+		var order []int
+		for range testCase.expected {
+			// the position stays the same, so that only the distance to it is relevant
+			order = append(order, nextNearest(testCase.paths, isUsed, testCase.position))
+		}
+		test.Equals(t, testCase.expected, order)
+	}
+}
+
+func TestProximityOrder(t *testing.T) {
+	island := func(x data.Micrometer) [][]data.LayerPart {
+		return [][]data.LayerPart{{data.NewBasicLayerPart(square(x, 0, 1000), nil)}}
+	}
+
+	perimeters := clip.OffsetResult{
+		island(25000),
+		nil,
+		island(0),
+		island(10000),
+	}
+
+	// each island continues at the start of the previous one and parts without perimeters are last
+	test.Equals(t, []int{3, 2, 0, 1}, proximityOrder(perimeters, data.NewMicroPoint(12000, 0)))
+	test.Equals(t, []int{0, 3, 2, 1}, proximityOrder(perimeters, data.NewMicroPoint(30000, 0)))
+}
@@ -3,17 +3,29 @@
 package renderer
 
 import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/gcode"
 	"github.com/aligator/goslice/modifier"
 )
 
 // Perimeter is a renderer which generates the gcode for the attribute "perimeters".
-type Perimeter struct{}
+// The perimeters of each part are printed in the order defined by the option Print.PerimeterOrder.
+// Each closed perimeter starts at the seam position defined by the option Print.SeamPosition.
+// The outlines are printed counter clockwise and the holes clockwise, or the other way round if Print.ClockwisePerimeters is set.
+// The segments which overhang the layer below by at least Print.OverhangPerimeter.Threshold (see modifier.PerimeterOverhangs)
+// are printed with the overhang speed as data.FeatureOverhangWall.
+type Perimeter struct {
+	seams seamPlanner
+}
 
-func (p Perimeter) Init(model data.OptimizedModel) {}
+func (p *Perimeter) Init(model data.OptimizedModel) {
+	p.seams.reset()
+	p.seams.center = model.Min().PointXY().Add(model.Max().PointXY()).Div(2)
+}
 
-func (p Perimeter) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+func (p *Perimeter) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
 	perimeters, err := modifier.Perimeters(layer)
 	if err != nil {
 		return err
@@ -22,32 +34,77 @@ func (p Perimeter) Render(b *gcode.Builder, layerNr int, maxLayer int, layer dat
 		return nil
 	}
 
-	for _, part := range perimeters {
-		for insetNr := range part {
-			// print the outer perimeter as last perimeter
-			if insetNr >= len(part)-1 {
-				insetNr = 0
-			} else {
-				insetNr++
-			}
+	flows, err := modifier.PerimeterFlows(layer)
+	if err != nil {
+		return err
+	}
+
+	widths, err := modifier.PerimeterWidths(layer)
+	if err != nil {
+		return err
+	}
+
+	overhangs, err := modifier.PerimeterOverhangs(layer)
+	if err != nil {
+		return err
+	}
+
+	p.seams.nextLayer()
 
-			for _, insetParts := range part[insetNr] {
+	// The scarf seam starts in the layer below, so it cannot be used on the first layer.
+	scarfSeam := options.Print.ScarfSeam.Enabled && layerNr > 0
+	layerThickness := options.Print.LayerHeights().Thickness(layerNr)
+
+	// Start with the island nearest to the position where the last layer (or island) ended.
+	for _, partNr := range proximityOrder(perimeters, b.CurrentPosition().PointXY()) {
+		part := perimeters[partNr]
+		for _, insetNr := range insetOrder(len(part), options.Print.PerimeterOrder) {
+			for insetPartNr, insetParts := range part[insetNr] {
 				if insetNr == 0 {
 					b.AddComment("TYPE:WALL-OUTER")
+					b.SetFeature(data.FeatureOuterWall)
 					b.SetExtrudeSpeed(options.Print.OuterPerimeterSpeed)
 				} else {
 					b.AddComment("TYPE:WALL-INNER")
+					b.SetFeature(data.FeatureInnerWall)
 					b.SetExtrudeSpeed(options.Print.LayerSpeed)
 				}
 
-				for _, hole := range insetParts.Holes() {
-					err := b.AddPolygon(layer, hole, z, false)
+				var flow modifier.PerimeterFlow
+				if flows != nil {
+					flow = flows[partNr][insetNr][insetPartNr]
+				}
+
+				var width modifier.PerimeterWidth
+				if widths != nil {
+					width = widths[partNr][insetNr][insetPartNr]
+				}
+
+				var overhang modifier.PerimeterOverhang
+				if overhangs != nil {
+					overhang = overhangs[partNr][insetNr][insetPartNr]
+				}
+
+				for holeNr, hole := range insetParts.Holes() {
+					var holeFlow []int
+					if flow.Holes != nil {
+						holeFlow = flow.Holes[holeNr]
+					}
+					var holeWidth []data.Micrometer
+					if width.Holes != nil {
+						holeWidth = width.Holes[holeNr]
+					}
+					var holeOverhang []int
+					if overhang.Holes != nil {
+						holeOverhang = overhang.Holes[holeNr]
+					}
+					err := p.addPerimeterPolygon(b, layer, hole, z, layerThickness, holeFlow, holeWidth, holeOverhang, options, scarfSeam && insetNr == 0, options.Print.ClockwisePerimeters)
 					if err != nil {
 						return err
 					}
 				}
 
-				err := b.AddPolygon(layer, insetParts.Outline(), z, false)
+				err := p.addPerimeterPolygon(b, layer, insetParts.Outline(), z, layerThickness, flow.Outline, width.Outline, overhang.Outline, options, scarfSeam && insetNr == 0, !options.Print.ClockwisePerimeters)
 				if err != nil {
 					return err
 				}
@@ -57,3 +114,205 @@ func (p Perimeter) Render(b *gcode.Builder, layerNr int, maxLayer int, layer dat
 
 	return nil
 }
+
+// addPerimeterPolygon adds a closed perimeter polygon in the given direction starting at its seam.
+// If widths are given, each segment of the polygon is printed with its width and the flows are ignored.
+// If flows are given, the flow of each segment of the smoothed polygon is adjusted.
+// If any segment overhangs by at least the overhang threshold, these segments are printed as overhang.
+// The overhangs belong to the segments of the polygon if widths are given and to the ones of the smoothed polygon otherwise.
+// Without widths and overhangs it is printed with a scarf seam if scarfSeam is set, which starts layerThickness below z.
+func (p *Perimeter) addPerimeterPolygon(b *gcode.Builder, layer data.PartitionedLayer, polygon data.Path, z, layerThickness data.Micrometer, flows []int, widths []data.Micrometer, overhangs []int, options *data.Options, scarfSeam bool, counterClockwise bool) error {
+	// the holes are printed in the opposite direction of the outlines
+	hole := counterClockwise == options.Print.ClockwisePerimeters
+	overhanging := isOverhanging(overhangs, options.Print.OverhangPerimeter.Threshold)
+
+	if widths != nil {
+		var err error
+		if overhanging {
+			if _, overhangs, err = orientedPolygon(polygon, overhangs, counterClockwise); err != nil {
+				return err
+			}
+		}
+		if polygon, widths, err = orientedWidthPolygon(polygon, widths, counterClockwise); err != nil {
+			return err
+		}
+		start := p.seams.start(polygon, b.CurrentPosition().PointXY(), options.Print.SeamPosition, options.Print.SeamAngle, hole)
+		if widths, err = startAtWidths(widths, len(polygon), start); err != nil {
+			return err
+		}
+		polygon = startAt(polygon, start)
+
+		if overhanging {
+			if overhangs, err = startAtInts(overhangs, len(polygon), start); err != nil {
+				return err
+			}
+			return b.AddPolygonWithOverhangs(layer, polygon, z, nil, widths, overhangSegments(overhangs, options.Print.OverhangPerimeter.Threshold), options.Print.OverhangPerimeter.Speed)
+		}
+		return b.AddPathWithWidths(layer, append(append(data.Path{}, polygon...), polygon[0]), z, widths)
+	}
+
+	if flows != nil || overhanging {
+		// the flows and overhangs belong to the segments of the smoothed polygon
+		polygon = data.DouglasPeucker(polygon, -1)
+	}
+	var err error
+	if overhanging {
+		if _, overhangs, err = orientedPolygon(polygon, overhangs, counterClockwise); err != nil {
+			return err
+		}
+	}
+	if polygon, flows, err = orientedPolygon(polygon, flows, counterClockwise); err != nil {
+		return err
+	}
+
+	start := p.seams.start(polygon, b.CurrentPosition().PointXY(), options.Print.SeamPosition, options.Print.SeamAngle, hole)
+	if flows, err = startAtInts(flows, len(polygon), start); err != nil {
+		return err
+	}
+	polygon = startAt(polygon, start)
+
+	if overhanging {
+		if overhangs, err = startAtInts(overhangs, len(polygon), start); err != nil {
+			return err
+		}
+		return b.AddPolygonWithOverhangs(layer, polygon, z, flows, nil, overhangSegments(overhangs, options.Print.OverhangPerimeter.Threshold), options.Print.OverhangPerimeter.Speed)
+	}
+
+	if scarfSeam {
+		return b.AddScarfPolygon(layer, polygon, z, layerThickness, options.Print.ScarfSeam.Length.ToMicrometer(), options.Print.ScarfSeam.Steps, flows)
+	}
+	if flows == nil {
+		return b.AddPolygon(layer, polygon, z, false)
+	}
+	return b.AddPolygonWithFlow(layer, polygon, z, flows)
+}
+
+// isOverhanging returns true if any of the overhangs in percent reaches the threshold.
+func isOverhanging(overhangs []int, threshold int) bool {
+	for _, overhang := range overhangs {
+		if overhang >= threshold {
+			return true
+		}
+	}
+	return false
+}
+
+// overhangSegments returns for each segment if its overhang in percent reaches the threshold.
+func overhangSegments(overhangs []int, threshold int) []bool {
+	segments := make([]bool, len(overhangs))
+	for i, overhang := range overhangs {
+		segments[i] = overhang >= threshold
+	}
+	return segments
+}
+
+// insetOrder returns the order in which the given amount of insets of a part are printed.
+// "outer-first" starts with the outer perimeter, otherwise the outer perimeter is printed last.
+func insetOrder(count int, order string) []int {
+	result := make([]int, count)
+	for i := range result {
+		if order == "outer-first" {
+			result[i] = i
+		} else {
+			result[i] = count - 1 - i
+		}
+	}
+	return result
+}
+
+// orientedPolygon returns the closed polygon in the given direction.
+// If it has to be reversed, the flows of its segments are reordered so that they still belong to the same segments.
+// There has to be one flow per segment of the polygon, otherwise an error is returned.
+func orientedPolygon(polygon data.Path, flows []int, counterClockwise bool) (data.Path, []int, error) {
+	if flows != nil && len(flows) != len(polygon) {
+		return nil, nil, fmt.Errorf("the polygon has %v segments but %v flows are given", len(polygon), len(flows))
+	}
+	if len(polygon) < 3 || (polygon.Area() > 0) == counterClockwise {
+		return polygon, flows, nil
+	}
+
+	if flows != nil {
+		// the segment i of the reversed polygon is the segment n-2-i of the polygon, the closing segment stays the last one
+		reversedFlows := make([]int, len(flows))
+		for i := range flows {
+			reversedFlows[i] = flows[(2*len(flows)-2-i)%len(flows)]
+		}
+		flows = reversedFlows
+	}
+	return polygon.Reversed(), flows, nil
+}
+
+// orientedWidthPolygon returns the closed polygon in the given direction.
+// If it has to be reversed, the widths of its segments are reordered so that they still belong to the same segments.
+// There has to be one width per segment of the polygon, otherwise an error is returned.
+func orientedWidthPolygon(polygon data.Path, widths []data.Micrometer, counterClockwise bool) (data.Path, []data.Micrometer, error) {
+	if widths != nil && len(widths) != len(polygon) {
+		return nil, nil, fmt.Errorf("the polygon has %v segments but %v widths are given", len(polygon), len(widths))
+	}
+	if len(polygon) < 3 || (polygon.Area() > 0) == counterClockwise {
+		return polygon, widths, nil
+	}
+
+	// the segment i of the reversed polygon is the segment n-2-i of the polygon, the closing segment stays the last one
+	reversedWidths := make([]data.Micrometer, len(widths))
+	for i := range widths {
+		reversedWidths[i] = widths[(2*len(widths)-2-i)%len(widths)]
+	}
+	return polygon.Reversed(), reversedWidths, nil
+}
+
+// islandStart returns the point where the printing of the outer perimeter of the part starts.
+// If the outer perimeter is printed last, this is also the point where the island ends.
+func islandStart(part [][]data.LayerPart) (data.MicroPoint, bool) {
+	if len(part) == 0 || len(part[0]) == 0 || len(part[0][0].Outline()) == 0 {
+		return nil, false
+	}
+
+	return part[0][0].Outline()[0], true
+}
+
+// proximityOrder returns the order of the islands (parts) so that each island is the nearest
+// to the end of the previous one. The first island is the one nearest to the start point.
+// Parts without any perimeter are moved to the end.
+func proximityOrder(perimeters clip.OffsetResult, start data.MicroPoint) []int {
+	order := make([]int, 0, len(perimeters))
+	isUsed := make([]bool, len(perimeters))
+
+	current := start
+	for {
+		bestIndex := -1
+		var bestDistance data.Micrometer
+		for i, part := range perimeters {
+			if isUsed[i] {
+				continue
+			}
+
+			point, ok := islandStart(part)
+			if !ok {
+				continue
+			}
+
+			distance := current.Sub(point).Size2()
+			if bestIndex == -1 || distance < bestDistance {
+				bestIndex = i
+				bestDistance = distance
+			}
+		}
+
+		if bestIndex == -1 {
+			break
+		}
+
+		isUsed[bestIndex] = true
+		order = append(order, bestIndex)
+		current, _ = islandStart(perimeters[bestIndex])
+	}
+
+	for i := range perimeters {
+		if !isUsed[i] {
+			order = append(order, i)
+		}
+	}
+
+	return order
+}
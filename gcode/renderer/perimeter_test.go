@@ -0,0 +1,187 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// microPointComparer returns a cmp.Comparer which can handle data.MicroPoint.
+func microPointComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 data.MicroPoint) bool {
+		return p1.X() == p2.X() && p1.Y() == p2.Y()
+	})
+}
+
+// counterClockwiseSquare returns a counter clockwise square with the side length 10000.
+func counterClockwiseSquare() data.Path {
+	return data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 10000),
+		data.NewMicroPoint(0, 10000),
+	}
+}
+
+func TestOrientedPolygon(t *testing.T) {
+	square := counterClockwiseSquare()
+
+	var testCases = map[string]struct {
+		flows            []int
+		counterClockwise bool
+		expectedPolygon  data.Path
+		expectedFlows    []int
+		expectedErr      bool
+	}{
+		"already in the direction": {
+			flows:            []int{1, 2, 3, 4},
+			counterClockwise: true,
+			expectedPolygon:  square,
+			expectedFlows:    []int{1, 2, 3, 4},
+		},
+		"reversed with the flows of the same segments": {
+			flows:            []int{1, 2, 3, 4},
+			counterClockwise: false,
+			expectedPolygon:  square.Reversed(),
+			expectedFlows:    []int{3, 2, 1, 4},
+		},
+		"reversed without flows": {
+			counterClockwise: false,
+			expectedPolygon:  square.Reversed(),
+		},
+		"less flows than segments": {
+			flows:            []int{1, 2, 3},
+			counterClockwise: false,
+			expectedErr:      true,
+		},
+		"more flows than segments": {
+			flows:            []int{1, 2, 3, 4, 5},
+			counterClockwise: true,
+			expectedErr:      true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		polygon, flows, err := orientedPolygon(square, testCase.flows, testCase.counterClockwise)
+		if testCase.expectedErr {
+			test.Assert(t, err != nil, "a mismatch of the flows should return an error")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedPolygon, polygon, microPointComparer())
+		test.Equals(t, testCase.expectedFlows, flows)
+	}
+}
+
+func TestOrientedWidthPolygon(t *testing.T) {
+	square := counterClockwiseSquare()
+
+	polygon, widths, err := orientedWidthPolygon(square, []data.Micrometer{100, 200, 300, 400}, false)
+	test.Ok(t, err)
+	test.Equals(t, square.Reversed(), polygon, microPointComparer())
+	test.Equals(t, []data.Micrometer{300, 200, 100, 400}, widths)
+
+	_, _, err = orientedWidthPolygon(square, []data.Micrometer{100, 200}, false)
+	test.Assert(t, err != nil, "a mismatch of the widths should return an error")
+}
+
+func TestStartAt(t *testing.T) {
+	square := counterClockwiseSquare()
+
+	var testCases = map[string]struct {
+		values      []int
+		start       int
+		expected    []int
+		expectedErr bool
+	}{
+		"at the first point": {
+			values:   []int{1, 2, 3, 4},
+			start:    0,
+			expected: []int{1, 2, 3, 4},
+		},
+		"rotated": {
+			values:   []int{1, 2, 3, 4},
+			start:    2,
+			expected: []int{3, 4, 1, 2},
+		},
+		"without values": {
+			start: 2,
+		},
+		"mismatch at the first point": {
+			values:      []int{1, 2, 3},
+			start:       0,
+			expectedErr: true,
+		},
+		"mismatch": {
+			values:      []int{1, 2, 3, 4, 5},
+			start:       2,
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		values, err := startAtInts(testCase.values, len(square), testCase.start)
+		if testCase.expectedErr {
+			test.Assert(t, err != nil, "a mismatch of the values should return an error")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, values)
+	}
+
+	test.Equals(t, data.Path{square[2], square[3], square[0], square[1]}, startAt(square, 2), microPointComparer())
+
+	widths, err := startAtWidths([]data.Micrometer{100, 200, 300, 400}, len(square), 1)
+	test.Ok(t, err)
+	test.Equals(t, []data.Micrometer{200, 300, 400, 100}, widths)
+
+	_, err = startAtWidths([]data.Micrometer{100}, len(square), 0)
+	test.Assert(t, err != nil, "a mismatch of the widths should return an error")
+}
+
+func TestInsetOrder(t *testing.T) {
+	var testCases = map[string]struct {
+		count    int
+		order    string
+		expected []int
+	}{
+		"one inset inner first": {
+			count:    1,
+			order:    "inner-first",
+			expected: []int{0},
+		},
+		"two insets inner first": {
+			count:    2,
+			order:    "inner-first",
+			expected: []int{1, 0},
+		},
+		"three insets inner first": {
+			count:    3,
+			order:    "inner-first",
+			expected: []int{2, 1, 0},
+		},
+		"one inset outer first": {
+			count:    1,
+			order:    "outer-first",
+			expected: []int{0},
+		},
+		"two insets outer first": {
+			count:    2,
+			order:    "outer-first",
+			expected: []int{0, 1},
+		},
+		"three insets outer first": {
+			count:    3,
+			order:    "outer-first",
+			expected: []int{0, 1, 2},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, insetOrder(testCase.count, testCase.order))
+	}
+}
@@ -0,0 +1,253 @@
+// This file provides the placement of the seams of closed perimeters.
+
+package renderer
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"math"
+	"math/rand"
+)
+
+// cornerDistance is the min distance of the points around a corner which are used to measure its angle,
+// so that a corner split into several short segments still counts as one corner.
+const cornerDistance data.Micrometer = 500
+
+// minCornerAngle is the min angle in degree by which the direction of a perimeter has to change at a point
+// so that it counts as a corner for the seam position "sharpest-corner".
+const minCornerAngle = 20
+
+// seamPlanner chooses the start point of closed perimeters based on the option Print.SeamPosition.
+// It has to be reset for each object and informed about each new layer, as aligned seams depend on the layer below.
+type seamPlanner struct {
+	previousSeams []data.MicroPoint
+	seams         []data.MicroPoint
+	random        *rand.Rand
+
+	// center is the center of the model used for seams at an angle.
+	center data.MicroPoint
+}
+
+// reset forgets all seams and restarts the random seams, so that the result is the same on each run.
+func (s *seamPlanner) reset() {
+	s.previousSeams = nil
+	s.seams = nil
+	s.random = rand.New(rand.NewSource(0))
+}
+
+// nextLayer has to be called before the perimeters of the next layer are started.
+func (s *seamPlanner) nextLayer() {
+	if len(s.seams) == 0 {
+		// keep the seams of the last layer which had any perimeters
+		return
+	}
+	s.previousSeams, s.seams = s.seams, nil
+}
+
+// start returns the index of the point of the closed polygon where the printing should start.
+// current is the current position of the nozzle and angle the compass angle used by the position "angle".
+// hole is true if the polygon is a hole, so that the part lies outside of it.
+func (s *seamPlanner) start(polygon data.Path, current data.MicroPoint, position string, angle int, hole bool) int {
+	if len(polygon) == 0 {
+		return 0
+	}
+
+	start := 0
+	switch position {
+	case "aligned":
+		if len(s.previousSeams) > 0 {
+			start = nearestPoint(polygon, func(p data.MicroPoint) data.Micrometer {
+				var best data.Micrometer = -1
+				for _, seam := range s.previousSeams {
+					if distance := p.Sub(seam).Size2(); best < 0 || distance < best {
+						best = distance
+					}
+				}
+				return best
+			})
+		}
+	case "rear":
+		for i, p := range polygon {
+			if p.Y() > polygon[start].Y() || (p.Y() == polygon[start].Y() && p.X() < polygon[start].X()) {
+				start = i
+			}
+		}
+	case "random":
+		if s.random == nil {
+			s.reset()
+		}
+		start = s.random.Intn(len(polygon))
+	case "nearest":
+		start = nearestPoint(polygon, func(p data.MicroPoint) data.Micrometer {
+			return p.Sub(current).Size2()
+		})
+	case "angle":
+		start = s.pointAtAngle(polygon, angle)
+	case "sharpest-corner":
+		start = s.sharpestCorner(polygon, hole)
+	}
+
+	s.seams = append(s.seams, polygon[start])
+	return start
+}
+
+// pointAtAngle returns the index of the point of the polygon whose direction seen from the center
+// is the nearest to the given compass angle, where 0° is the front (-Y) and 90° the right (+X).
+// If several points have the same direction, the one farthest away from the center is used.
+func (s *seamPlanner) pointAtAngle(polygon data.Path, angle int) int {
+	radians := float64(angle) * math.Pi / 180
+	dirX, dirY := math.Sin(radians), -math.Cos(radians)
+
+	best := 0
+	bestCos, bestDistance := math.Inf(-1), math.Inf(-1)
+	for i, p := range polygon {
+		v := p.Sub(s.center)
+		length := math.Hypot(float64(v.X()), float64(v.Y()))
+		if length == 0 {
+			continue
+		}
+
+		distance := float64(v.X())*dirX + float64(v.Y())*dirY
+		cos := distance / length
+		if cos > bestCos || (cos == bestCos && distance > bestDistance) {
+			best = i
+			bestCos, bestDistance = cos, distance
+		}
+	}
+	return best
+}
+
+// sharpestCorner returns the index of the sharpest concave corner of the polygon, seen from the part.
+// If the polygon has no concave corner, the sharpest convex corner is used.
+// If several corners are equally sharp, e.g. on a regular shape, or if the polygon has no corner at all,
+// the point nearest to the seams of the layer below is used, so that the seams stay aligned.
+func (s *seamPlanner) sharpestCorner(polygon data.Path, hole bool) int {
+	// the part lies left of counter clockwise outlines and of clockwise holes
+	inside := 1.0
+	if (polygon.Area() > 0) == hole {
+		inside = -1.0
+	}
+
+	// the turn of the polygon at each point in degree, negative at concave corners
+	turns := make([]float64, len(polygon))
+	for i, p := range polygon {
+		before := p.Sub(cornerNeighbour(polygon, i, -1))
+		after := cornerNeighbour(polygon, i, 1).Sub(p)
+		cross := float64(before.X())*float64(after.Y()) - float64(before.Y())*float64(after.X())
+		dot := float64(before.X())*float64(after.X()) + float64(before.Y())*float64(after.Y())
+		turns[i] = inside * math.Atan2(cross, dot) * 180 / math.Pi
+	}
+
+	sharpest := 0.0
+	concave := false
+	for _, turn := range turns {
+		if math.Abs(turn) < minCornerAngle {
+			continue
+		}
+		if turn < 0 && !concave {
+			concave, sharpest = true, 0
+		}
+		if (turn < 0) == concave && math.Abs(turn) > sharpest {
+			sharpest = math.Abs(turn)
+		}
+	}
+
+	var candidates []int
+	for i, turn := range turns {
+		// corners differing by less than a degree count as equally sharp
+		if sharpest > 0 && (turn < 0) == concave && math.Abs(turn) > sharpest-1 {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 0 {
+		for i := range polygon {
+			candidates = append(candidates, i)
+		}
+	}
+	if len(candidates) == 1 || len(s.previousSeams) == 0 {
+		return candidates[0]
+	}
+
+	best := candidates[0]
+	var bestDistance data.Micrometer = -1
+	for _, i := range candidates {
+		for _, seam := range s.previousSeams {
+			if distance := polygon[i].Sub(seam).Size2(); bestDistance < 0 || distance < bestDistance {
+				best, bestDistance = i, distance
+			}
+		}
+	}
+	return best
+}
+
+// cornerNeighbour returns the first point of the closed polygon in the given direction (1 or -1) from the point i,
+// which is at least cornerDistance away from it. If there is no such point, the farthest point is returned.
+func cornerNeighbour(polygon data.Path, i, direction int) data.MicroPoint {
+	p := polygon[i]
+	best := polygon[(i+direction+len(polygon))%len(polygon)]
+	for k := 1; k < len(polygon); k++ {
+		neighbour := polygon[((i+direction*k)%len(polygon)+len(polygon))%len(polygon)]
+		if neighbour.Sub(p).Size2() > best.Sub(p).Size2() {
+			best = neighbour
+		}
+		if neighbour.Sub(p).Size() >= cornerDistance {
+			return neighbour
+		}
+	}
+	return best
+}
+
+// nearestPoint returns the index of the point of the path with the smallest distance.
+func nearestPoint(path data.Path, distance func(p data.MicroPoint) data.Micrometer) int {
+	best := 0
+	bestDistance := distance(path[0])
+	for i := 1; i < len(path); i++ {
+		if d := distance(path[i]); d < bestDistance {
+			best = i
+			bestDistance = d
+		}
+	}
+	return best
+}
+
+// startAt returns the closed polygon rotated so that it starts at the given index.
+// Per segment values, e.g. flows, can be rotated the same way using startAtInts.
+func startAt(polygon data.Path, start int) data.Path {
+	if start == 0 {
+		return polygon
+	}
+
+	rotated := make(data.Path, 0, len(polygon))
+	rotated = append(rotated, polygon[start:]...)
+	return append(rotated, polygon[:start]...)
+}
+
+// startAtWidths returns the widths rotated so that they start at the given index.
+// There has to be one width per segment of the closed polygon with the given amount of points.
+func startAtWidths(widths []data.Micrometer, segments int, start int) ([]data.Micrometer, error) {
+	if widths != nil && len(widths) != segments {
+		return nil, fmt.Errorf("the polygon has %v segments but %v widths are given", segments, len(widths))
+	}
+	if start == 0 || len(widths) == 0 {
+		return widths, nil
+	}
+
+	rotated := make([]data.Micrometer, 0, len(widths))
+	rotated = append(rotated, widths[start:]...)
+	return append(rotated, widths[:start]...), nil
+}
+
+// startAtInts returns the values rotated so that they start at the given index.
+// There has to be one value per segment of the closed polygon with the given amount of points.
+func startAtInts(values []int, segments int, start int) ([]int, error) {
+	if values != nil && len(values) != segments {
+		return nil, fmt.Errorf("the polygon has %v segments but %v values are given", segments, len(values))
+	}
+	if start == 0 || len(values) == 0 {
+		return values, nil
+	}
+
+	rotated := make([]int, 0, len(values))
+	rotated = append(rotated, values[start:]...)
+	return append(rotated, values[:start]...), nil
+}
@@ -0,0 +1,228 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"math"
+	"testing"
+)
+
+func TestSharpestCorner(t *testing.T) {
+	// lShape is a counter clockwise L shape with the only concave corner at index 3
+	lShape := data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(20000, 0),
+		data.NewMicroPoint(20000, 5000),
+		data.NewMicroPoint(5000, 5000),
+		data.NewMicroPoint(5000, 20000),
+		data.NewMicroPoint(0, 20000),
+	}
+	// circle turns by less than minCornerAngle at each point, so it has no corners
+	var circle data.Path
+	for i := 0; i < 64; i++ {
+		angle := float64(i) * 2 * math.Pi / 64
+		circle = append(circle, data.NewMicroPoint(data.Micrometer(10000*math.Cos(angle)), data.Micrometer(10000*math.Sin(angle))))
+	}
+
+	var testCases = map[string]struct {
+		polygon       data.Path
+		hole          bool
+		previousSeams []data.MicroPoint
+		expected      int
+	}{
+		"concave corner": {
+			polygon:  lShape,
+			expected: 3,
+		},
+		"concave corner of a clockwise outline": {
+			polygon:  lShape.Reversed(),
+			expected: 2,
+		},
+		"hole": {
+			// seen from the part around the hole, the convex corners of the L shape are concave
+			polygon:  lShape.Reversed(),
+			hole:     true,
+			expected: 0,
+		},
+		"sharpest convex corner": {
+			polygon: data.Path{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(20000, 0),
+				data.NewMicroPoint(0, 5000),
+			},
+			expected: 1,
+		},
+		"equal corners": {
+			polygon:  counterClockwiseSquare(),
+			expected: 0,
+		},
+		"equal corners aligned to the layer below": {
+			polygon:       counterClockwiseSquare(),
+			previousSeams: []data.MicroPoint{data.NewMicroPoint(9000, 11000)},
+			expected:      2,
+		},
+		"no corners aligned to the layer below": {
+			polygon:       circle,
+			previousSeams: []data.MicroPoint{data.NewMicroPoint(0, 11000)},
+			expected:      16,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		s := seamPlanner{previousSeams: testCase.previousSeams}
+		test.Equals(t, testCase.expected, s.sharpestCorner(testCase.polygon, testCase.hole))
+	}
+}
+
+func TestCornerNeighbour(t *testing.T) {
+	// the corner at (0, 0) is split into short segments
+	polygon := data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(100, 0),
+		data.NewMicroPoint(200, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 10000),
+		data.NewMicroPoint(0, 10000),
+		data.NewMicroPoint(0, 200),
+	}
+	small := data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(100, 0),
+		data.NewMicroPoint(300, 100),
+		data.NewMicroPoint(0, 100),
+	}
+
+	var testCases = map[string]struct {
+		polygon   data.Path
+		i         int
+		direction int
+		expected  data.MicroPoint
+	}{
+		"forwards": {
+			polygon:   polygon,
+			i:         0,
+			direction: 1,
+			expected:  data.NewMicroPoint(10000, 0),
+		},
+		"backwards": {
+			polygon:   polygon,
+			i:         0,
+			direction: -1,
+			expected:  data.NewMicroPoint(0, 10000),
+		},
+		"backwards across the start": {
+			polygon:   polygon,
+			i:         1,
+			direction: -1,
+			expected:  data.NewMicroPoint(0, 10000),
+		},
+		"farthest point of a small polygon": {
+			polygon:   small,
+			i:         0,
+			direction: 1,
+			expected:  data.NewMicroPoint(300, 100),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, cornerNeighbour(testCase.polygon, testCase.i, testCase.direction), microPointComparer())
+	}
+}
+
+func TestSeamStart(t *testing.T) {
+	square := counterClockwiseSquare()
+
+	var testCases = map[string]struct {
+		position string
+		polygon  data.Path
+		current  data.MicroPoint
+		// layerBelow is started before the polygon on the layer below if it is set
+		layerBelow data.Path
+		expected   int
+	}{
+		"aligned without a layer below": {
+			position: "aligned",
+			polygon:  square,
+			current:  data.NewMicroPoint(10000, 10000),
+			expected: 0,
+		},
+		"aligned to the layer below": {
+			position: "aligned",
+			// the same square starting at another point, the seam below is at (10000, 10000)
+			polygon:    startAt(square, 1),
+			current:    data.NewMicroPoint(0, 0),
+			layerBelow: square,
+			expected:   1,
+		},
+		"rear": {
+			position: "rear",
+			polygon: data.Path{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(10000, 0),
+				data.NewMicroPoint(5000, 12000),
+				data.NewMicroPoint(0, 10000),
+			},
+			current:  data.NewMicroPoint(0, 0),
+			expected: 2,
+		},
+		"rear with equal Y uses the left point": {
+			position: "rear",
+			polygon:  square,
+			current:  data.NewMicroPoint(10000, 10000),
+			expected: 3,
+		},
+		"nearest": {
+			position: "nearest",
+			polygon:  square,
+			current:  data.NewMicroPoint(9000, -500),
+			expected: 1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		s := seamPlanner{}
+		s.reset()
+		if testCase.layerBelow != nil {
+			// the seam of the layer below is at the point nearest to the current position
+			s.start(testCase.layerBelow, data.NewMicroPoint(9000, 11000), "nearest", 0, false)
+			s.nextLayer()
+		}
+
+		test.Equals(t, testCase.expected, s.start(testCase.polygon, testCase.current, testCase.position, 0, false))
+	}
+}
+
+func TestRandomSeamStart(t *testing.T) {
+	var circle data.Path
+	for i := 0; i < 100; i++ {
+		angle := float64(i) * 2 * math.Pi / 100
+		circle = append(circle, data.NewMicroPoint(data.Micrometer(10000*math.Cos(angle)), data.Micrometer(10000*math.Sin(angle))))
+	}
+
+	// starts returns the starts of several layers
+	starts := func(s *seamPlanner) []int {
+		var result []int
+		for i := 0; i < 10; i++ {
+			start := s.start(circle, data.NewMicroPoint(0, 0), "random", 0, false)
+			test.Assert(t, start >= 0 && start < len(circle), "the start %v is not a point of the polygon", start)
+			result = append(result, start)
+			s.nextLayer()
+		}
+		return result
+	}
+
+	s := seamPlanner{}
+	s.reset()
+	first := starts(&s)
+	s.reset()
+	test.Equals(t, first, starts(&s))
+
+	distinct := map[int]bool{}
+	for _, start := range first {
+		distinct[start] = true
+	}
+	test.Assert(t, len(distinct) > 1, "the random seams should not all be at the same point: %v", first)
+}
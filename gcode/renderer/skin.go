@@ -0,0 +1,53 @@
+// This file provides a renderer for the perimeter loops around the skins.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+)
+
+// SkinPerimeters prints the perimeter loops around the top or bottom skins (see data.PrintOptions.SkinPerimeters).
+// It has to be added before the Infill renderer of the same skin.
+type SkinPerimeters struct {
+	// Loops extracts the loops from the layer, e.g. modifier.TopSkinPerimeters.
+	Loops func(layer data.PartitionedLayer) ([]data.LayerPart, error)
+
+	// Comments is a list of comments to be added before the loops.
+	Comments []string
+
+	// Feature is the type of the skin, e.g. data.FeatureTopSkin.
+	Feature data.Feature
+}
+
+func (s SkinPerimeters) Init(model data.OptimizedModel) {}
+
+func (s SkinPerimeters) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	loops, err := s.Loops(layer)
+	if err != nil {
+		return err
+	}
+	if len(loops) == 0 {
+		return nil
+	}
+
+	for _, c := range s.Comments {
+		b.AddComment(c)
+	}
+	b.SetFeature(s.Feature)
+
+	// Continue with the loop nearest to the current position.
+	loopOutlines := outlines(loops)
+	isUsed := make([]bool, len(loops))
+	for range loops {
+		loop := loops[nextNearest(loopOutlines, isUsed, b.CurrentPosition().PointXY())]
+		for _, path := range append(data.Paths{loop.Outline()}, loop.Holes()...) {
+			err := b.AddPolygon(layer, path, z, false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
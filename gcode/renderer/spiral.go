@@ -0,0 +1,33 @@
+// This file provides a renderer for the spiralized layers of the vase mode.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// Spiral prints the attribute "spiral" which is set by the perimeter modifier if spiralize is enabled.
+// The path is printed as one line which rises from the height of the layer below to the height of the layer,
+// so that all spiralized layers together form one continuous line without a z seam.
+type Spiral struct{}
+
+func (Spiral) Init(model data.OptimizedModel) {}
+
+func (Spiral) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	spiral, err := modifier.SpiralPath(layer)
+	if err != nil {
+		return err
+	}
+	if len(spiral) < 3 {
+		return nil
+	}
+
+	b.AddComment("TYPE:WALL-OUTER")
+	b.SetFeature(data.FeatureOuterWall)
+	b.SetExtrudeSpeed(options.Print.OuterPerimeterSpeed)
+
+	// the spiral continues where the last layer ended, so no retraction is needed
+	return b.AddSpiralPolygon(nil, spiral, z, options.Print.LayerHeights().Thickness(layerNr))
+}
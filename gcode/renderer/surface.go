@@ -0,0 +1,54 @@
+// This file provides a renderer for the surfaces printed as single lines in the surface modes.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/slicer"
+)
+
+// Surface prints the attribute "surface" which is set by the slicer if a surface mode is enabled.
+// Each path is printed as a single line, starting at the end which is nearer to the current position.
+type Surface struct{}
+
+func (Surface) Init(model data.OptimizedModel) {}
+
+func (Surface) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	paths, err := slicer.SurfacePaths(layer)
+	if err != nil {
+		return err
+	}
+	if len(paths) == 0 {
+		return nil
+	}
+
+	b.AddComment("TYPE:WALL-OUTER")
+	b.SetFeature(data.FeatureOuterWall)
+	b.SetExtrudeSpeed(options.Print.OuterPerimeterSpeed)
+
+	// Continue with the path nearest to the current position.
+	isUsed := make([]bool, len(paths))
+	for range paths {
+		path := paths[nextNearest(paths, isUsed, b.CurrentPosition().PointXY())]
+		if len(path) < 2 {
+			continue
+		}
+
+		current := b.CurrentPosition().PointXY()
+		if current.Sub(path[len(path)-1]).Size2() < current.Sub(path[0]).Size2() {
+			reversed := make(data.Path, len(path))
+			for i, p := range path {
+				reversed[len(path)-1-i] = p
+			}
+			path = reversed
+		}
+
+		err := b.AddPolygon(layer, path, z, true)
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
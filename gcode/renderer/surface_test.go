@@ -0,0 +1,58 @@
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// attributedLayer is a layer without parts which only contains the given attributes.
+type attributedLayer struct {
+	data.PartitionedLayer
+	attributes map[string]interface{}
+}
+
+func (l attributedLayer) Attributes() map[string]interface{} {
+	return l.attributes
+}
+
+func TestSurface(t *testing.T) {
+	var testCases = map[string]struct {
+		surface  data.Paths
+		expected string
+	}{
+		"without surface": {
+			surface:  nil,
+			expected: "",
+		},
+		"nearest end first": {
+			surface: data.Paths{
+				{data.NewMicroPoint(30000, 0), data.NewMicroPoint(20000, 0)},
+				{data.NewMicroPoint(0, 0), data.NewMicroPoint(10000, 0)},
+			},
+			// the second path is printed reversed as its end is nearer to the end of the first one
+			expected: ";TYPE:WALL-OUTER\n" +
+				"G0 X0.00 Y0.00 Z0.20 F9000\n" +
+				"G1 X10.00 Y0.00 F2400 E0.3326\n" +
+				"G0 X20.00 Y0.00 F9000\n" +
+				"G1 X30.00 Y0.00 F2400 E0.6652\n",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		b := gcode.NewGCodeBuilder(&options)
+		b.SetExtrusion(200, 400)
+		b.SetMoveSpeed(150)
+
+		layer := attributedLayer{
+			PartitionedLayer: data.NewPartitionedLayer(nil),
+			attributes:       map[string]interface{}{"surface": testCase.surface},
+		}
+		err := Surface{}.Render(b, 0, 0, layer, 200, &options)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, b.String())
+	}
+}
@@ -0,0 +1,53 @@
+// This file provides a renderer for the walls which are too thin for the outer perimeter.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// ThinWall is a renderer which generates the gcode for the attribute "thinWalls".
+// Each line is printed as outer wall with a width following the width of the wall.
+// It has to be added after the Perimeter renderer.
+type ThinWall struct{}
+
+func (t ThinWall) Init(model data.OptimizedModel) {}
+
+func (t ThinWall) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	lines, err := modifier.ThinWalls(layer)
+	if err != nil {
+		return err
+	}
+	if len(lines) == 0 {
+		return nil
+	}
+
+	b.AddComment("TYPE:WALL-OUTER")
+	b.AddComment("THIN-WALL")
+	b.SetFeature(data.FeatureOuterWall)
+	b.SetExtrudeSpeed(options.Print.OuterPerimeterSpeed)
+
+	paths := make(data.Paths, len(lines))
+	for i, line := range lines {
+		paths[i] = line.Path
+	}
+
+	// Continue with the line nearest to the current position.
+	isUsed := make([]bool, len(lines))
+	for range lines {
+		line := lines[nextNearest(paths, isUsed, b.CurrentPosition().PointXY())]
+		path := line.Path
+		if line.Closed {
+			path = append(append(data.Path{}, path...), path[0])
+		}
+
+		err := b.AddPathWithWidths(layer, path, z, line.SegmentWidths())
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
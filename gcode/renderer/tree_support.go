@@ -0,0 +1,42 @@
+// This file provides a renderer for the tree support.
+
+package renderer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/gcode"
+	"github.com/aligator/goslice/modifier"
+)
+
+// TreeSupport prints the branches generated by the tree support modifier as single walls.
+type TreeSupport struct{}
+
+func (TreeSupport) Init(model data.OptimizedModel) {}
+
+func (TreeSupport) Render(b *gcode.Builder, layerNr int, maxLayer int, layer data.PartitionedLayer, z data.Micrometer, options *data.Options) error {
+	branches, err := modifier.TreeSupport(layer)
+	if err != nil {
+		return err
+	}
+	if len(branches) == 0 {
+		return nil
+	}
+
+	b.AddComment("TYPE:SUPPORT")
+	b.SetFeature(data.FeatureSupport)
+
+	// Continue with the branch nearest to the current position.
+	branchOutlines := outlines(branches)
+	isUsed := make([]bool, len(branches))
+	for range branches {
+		branch := branches[nextNearest(branchOutlines, isUsed, b.CurrentPosition().PointXY())]
+		for _, path := range append(data.Paths{branch.Outline()}, branch.Holes()...) {
+			err := b.AddPolygon(layer, path, z, false)
+			if err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
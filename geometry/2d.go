@@ -1,6 +1,6 @@
 // This file provides some basic helper functions for 2d vector calculations.
 
-package data
+package geometry
 
 import "math"
 
@@ -43,7 +43,10 @@ func douglasPeucker(points Path, ep Micrometer, depth int) Path {
 		// TODO: check if implementation without recursion would be possible and if it is more performant
 		left := douglasPeucker(points[:idx+1], ep, depth+1)
 		right := douglasPeucker(points[idx:], ep, depth+1)
-		return append(left[:len(left)-1], right...)
+		// left shares its points with the given path, so a new path is needed to not modify it
+		result := make(Path, 0, len(left)+len(right)-1)
+		result = append(result, left[:len(left)-1]...)
+		return append(result, right...)
 	}
 
 	// If the most distant point fails to pass the threshold test, then just return the two points
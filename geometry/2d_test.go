@@ -0,0 +1,69 @@
+package geometry_test
+
+import (
+	"github.com/aligator/goslice/geometry"
+	"github.com/aligator/goslice/util/test"
+	"math"
+	"testing"
+)
+
+// Test MicroVec3 implementation
+
+func TestDotProduct(t *testing.T) {
+	vec1 := geometry.NewMicroPoint(10, 20)
+	vec2 := geometry.NewMicroPoint(110, 120)
+
+	test.Equals(t, geometry.Micrometer(3500), geometry.DotProduct(vec1, vec2))
+}
+
+func TestXDistance2ToLine(t *testing.T) {
+	vec1 := geometry.NewMicroPoint(0, 20)
+	vec2 := geometry.NewMicroPoint(50, 20)
+
+	point := geometry.NewMicroPoint(0, 40)
+
+	test.Equals(t, geometry.Micrometer(400), geometry.PerpendicularDistance2(vec1, vec2, point))
+}
+
+func TestToRadians(t *testing.T) {
+	var testCases = []struct {
+		expected float64
+		degree   float64
+	}{
+		{
+			expected: 1,
+			degree:   57.29577951308232,
+		},
+		{
+			expected: math.Pi,
+			degree:   180,
+		},
+		{
+			expected: 0.5235987755982988, // math.Pi / 6
+			degree:   30,
+		},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		test.Equals(t, testCase.expected, geometry.ToRadians(testCase.degree))
+	}
+}
+
+func TestDouglasPeucker(t *testing.T) {
+	path := geometry.Path{
+		geometry.NewMicroPoint(0, 0),
+		geometry.NewMicroPoint(500, 5),
+		geometry.NewMicroPoint(1000, 0),
+		geometry.NewMicroPoint(1000, 1000),
+		geometry.NewMicroPoint(500, 1005),
+		geometry.NewMicroPoint(0, 1000),
+	}
+	original := append(geometry.Path{}, path...)
+
+	simplified := geometry.DouglasPeucker(path, -1)
+	test.Assert(t, len(simplified) < len(path), "the path should be simplified")
+
+	// the original path should not be modified
+	test.Equals(t, original, path, microPointComparer())
+}
@@ -0,0 +1,359 @@
+// This file provides boolean and offset operations on polygons.
+// The external clipper lib is only used inside of this file.
+
+package geometry
+
+import (
+	"log"
+
+	clipper "github.com/aligator/go.clipper"
+	goconvexhull2d "github.com/furstenheim/go-convex-hull-2d"
+)
+
+// clipperPoint converts the GoSlice point representation to the
+// representation which is used by the external clipper lib.
+func clipperPoint(p MicroPoint) *clipper.IntPoint {
+	return &clipper.IntPoint{
+		X: clipper.CInt(p.X()),
+		Y: clipper.CInt(p.Y()),
+	}
+}
+
+// clipperPaths converts the GoSlice Paths representation
+// to the representation which is used by the external clipper lib.
+func clipperPaths(p Paths) clipper.Paths {
+	result := make(clipper.Paths, len(p))
+	for i, path := range p {
+		result[i] = clipperPath(path)
+	}
+
+	return result
+}
+
+// clipperPath converts the GoSlice Path representation
+// to the representation which is used by the external clipper lib.
+func clipperPath(p Path) clipper.Path {
+	result := make(clipper.Path, len(p))
+	for i, point := range p {
+		result[i] = clipperPoint(point)
+	}
+
+	return result
+}
+
+// microPath converts the external clipper lib representation of a path
+// to the representation which is used by GoSlice.
+func microPath(p clipper.Path) Path {
+	result := make(Path, len(p))
+	for i, point := range p {
+		result[i] = NewMicroPoint(Micrometer(point.X), Micrometer(point.Y))
+	}
+
+	return result
+}
+
+// polyTreeToLayerParts creates layer parts out of a poly tree (which is the result of clipper's Execute2).
+func polyTreeToLayerParts(tree *clipper.PolyTree) []LayerPart {
+	var layerParts []LayerPart
+
+	var polysForNextRound []*clipper.PolyNode
+
+	for _, c := range tree.Childs() {
+		polysForNextRound = append(polysForNextRound, c)
+	}
+	for {
+		if polysForNextRound == nil {
+			break
+		}
+		thisRound := polysForNextRound
+		polysForNextRound = nil
+
+		for _, p := range thisRound {
+			var holes Paths
+
+			for _, child := range p.Childs() {
+				// TODO: simplify, yes / no ??
+				holes = append(holes, microPath(child.Contour()))
+				for _, c := range child.Childs() {
+					polysForNextRound = append(polysForNextRound, c)
+				}
+			}
+
+			// TODO: simplify, yes / no ??
+			layerParts = append(layerParts, NewBasicLayerPart(microPath(p.Contour()), holes))
+		}
+	}
+
+	return layerParts
+}
+
+// Partition combines the given closed polygons into polygons with holes.
+// Overlapping polygons are treated using the even-odd rule,
+// so a polygon inside of another one becomes a hole of it.
+func Partition(polygons Paths, logger *log.Logger) (parts []LayerPart, ok bool) {
+	if len(polygons) == 0 {
+		return []LayerPart{}, true
+	}
+
+	var tree *clipper.PolyTree
+	ok = withRetry(logger, "partition", func(clean func(clipper.Paths) clipper.Paths) bool {
+		cl := clipper.NewClipper(clipper.IoNone)
+		cl.AddPaths(clean(clipperPaths(polygons)), clipper.PtSubject, true)
+		tree, ok = cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+
+	return polyTreeToLayerParts(tree), true
+}
+
+// JoinType defines how the corners of a polygon are joined when it is offset.
+type JoinType int
+
+const (
+	// JoinSquare cuts off the corners at the offset distance.
+	JoinSquare JoinType = iota
+
+	// JoinRound rounds the corners with the offset distance as radius.
+	JoinRound
+
+	// JoinMiter keeps the corners sharp, unless they would be longer than the miter limit times the offset distance.
+	// Then they are cut off like JoinSquare.
+	JoinMiter
+)
+
+// joinArcTolerance is the max distance in micrometer between the rounded corners of JoinRound and a real arc.
+// The default of the clipper lib would generate a lot of points which are far too close for printing.
+const joinArcTolerance = 5
+
+// clipperJoinType converts the join type to the representation which is used by the external clipper lib.
+func clipperJoinType(joinType JoinType) clipper.JoinType {
+	switch joinType {
+	case JoinRound:
+		return clipper.JtRound
+	case JoinMiter:
+		return clipper.JtMiter
+	default:
+		return clipper.JtSquare
+	}
+}
+
+// Inset insets the given layer part insetCount times.
+// The result is built the following way: [insetNr][insetParts]LayerPart
+// as insetting one polygon may result in several polygons.
+//
+// If you need to ex-set a part, just provide a negative offset.
+// The initialOffset is used for the first inset, so that the first inset can be a bit more or less offset.
+// The corners are joined using JoinSquare.
+func Inset(part LayerPart, offset Micrometer, insetCount int, initialOffset Micrometer) [][]LayerPart {
+	return InsetWithJoin(part, offset, insetCount, initialOffset, JoinSquare, 2)
+}
+
+// InsetWithJoin works like Inset but joins the corners using the given join type.
+// The miterLimit is only used by JoinMiter and is the max distance of a corner
+// from the original corner as multiple of the offset.
+func InsetWithJoin(part LayerPart, offset Micrometer, insetCount int, initialOffset Micrometer, joinType JoinType, miterLimit float64) [][]LayerPart {
+	var insets [][]LayerPart
+
+	co := clipper.NewClipperOffset()
+	co.MiterLimit = miterLimit
+	co.ArcTolerance = joinArcTolerance
+
+	currentOffset := float64(initialOffset)
+
+	for insetNr := 0; insetNr < insetCount; insetNr++ {
+		// insets for the outline
+		co.Clear()
+		co.AddPaths(clipperPaths(Paths{part.Outline()}), clipperJoinType(joinType), clipper.EtClosedPolygon)
+		co.AddPaths(clipperPaths(part.Holes()), clipperJoinType(joinType), clipper.EtClosedPolygon)
+
+		allNewInsets := co.Execute2(currentOffset)
+		insets = append(insets, polyTreeToLayerParts(allNewInsets))
+
+		currentOffset += float64(-int(offset))
+	}
+
+	return insets
+}
+
+// partPaths returns the outline and the holes of the part in the representation of the external clipper lib.
+func partPaths(part LayerPart) clipper.Paths {
+	return append(clipper.Paths{clipperPath(part.Outline())}, clipperPaths(part.Holes())...)
+}
+
+// clipTypeName returns the name of the clip type used in warnings.
+func clipTypeName(clipType clipper.ClipType) string {
+	switch clipType {
+	case clipper.CtDifference:
+		return "difference"
+	case clipper.CtIntersection:
+		return "intersection"
+	case clipper.CtUnion:
+		return "union"
+	default:
+		return "operation"
+	}
+}
+
+// Difference calculates the difference between the parts and the toRemove parts.
+// It returns the result as a new slice of layer parts.
+func Difference(parts []LayerPart, toRemove []LayerPart, logger *log.Logger) (clippedParts []LayerPart, ok bool) {
+	return runClipper(clipper.CtDifference, parts, toRemove, logger)
+}
+
+// Intersection calculates the intersection between the parts and the toIntersect parts.
+// It returns the result as a new slice of layer parts.
+func Intersection(parts []LayerPart, toIntersect []LayerPart, logger *log.Logger) (clippedParts []LayerPart, ok bool) {
+	return runClipper(clipper.CtIntersection, parts, toIntersect, logger)
+}
+
+// Union calculates the union of the parts and the toMerge parts.
+// It returns the result as a new slice of layer parts.
+func Union(parts []LayerPart, toMerge []LayerPart, logger *log.Logger) (clippedParts []LayerPart, ok bool) {
+	return runClipper(clipper.CtUnion, parts, toMerge, logger)
+}
+
+func runClipper(clipType clipper.ClipType, parts []LayerPart, toClip []LayerPart, logger *log.Logger) (clippedParts []LayerPart, ok bool) {
+	if len(parts) == 0 && len(toClip) == 0 {
+		return nil, true
+	}
+
+	var tree *clipper.PolyTree
+	ok = withRetry(logger, clipTypeName(clipType), func(clean func(clipper.Paths) clipper.Paths) bool {
+		cl := clipper.NewClipper(clipper.IoNone)
+		for _, part := range parts {
+			cl.AddPaths(clean(partPaths(part)), clipper.PtSubject, true)
+		}
+
+		for _, intersect := range toClip {
+			cl.AddPaths(clean(partPaths(intersect)), clipper.PtClip, true)
+		}
+
+		tree, ok = cl.Execute2(clipType, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		return ok
+	})
+
+	if !ok {
+		return nil, ok
+	}
+	return polyTreeToLayerParts(tree), ok
+}
+
+// IsCrossingPerimeter checks if the given line crosses any perimeter of the given parts. If yes, the result is true.
+func IsCrossingPerimeter(parts []LayerPart, line Path, logger *log.Logger) (result, ok bool) {
+	if len(parts) == 0 {
+		// there is no perimeter which could be crossed
+		return false, true
+	}
+
+	// TODO: Is there a more performant way to detect this?
+	var tree *clipper.PolyTree
+	ok = withRetry(logger, "crossing check", func(clean func(clipper.Paths) clipper.Paths) bool {
+		cl := clipper.NewClipper(clipper.IoReverseSolution) // inverse solution so that it is basically LINE - PARTS
+
+		for _, part := range parts {
+			cl.AddPaths(clean(clipperPaths(part.Holes())), clipper.PtClip, true)
+			cl.AddPaths(clean(clipperPaths(Paths{part.Outline()})), clipper.PtClip, true)
+		}
+
+		cl.AddPath(clipperPath(line), clipper.PtSubject, false)
+
+		// calculate the difference of the parts and the line, then look if the (inverted) result contains any left path which would be a line not inside of the parts.
+		// If any part is left, the line crossed a perimeter.
+		tree, ok = cl.Execute2(clipper.CtDifference, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		return ok
+	})
+
+	if !ok {
+		return false, ok
+	}
+
+	return tree.Total() > 0, true
+}
+
+// IntersectLines clips the given open lines by the given parts.
+// Only the segments of the lines which are inside of the parts remain.
+func IntersectLines(lines Paths, parts []LayerPart, logger *log.Logger) (clippedLines Paths, ok bool) {
+	return clipLines(lines, parts, clipper.CtIntersection, logger)
+}
+
+// DifferenceLines clips the given open lines by the given parts.
+// Only the segments of the lines which are outside of the parts remain.
+// The parts should not overlap each other.
+func DifferenceLines(lines Paths, parts []LayerPart, logger *log.Logger) (clippedLines Paths, ok bool) {
+	return clipLines(lines, parts, clipper.CtDifference, logger)
+}
+
+// clipLines clips the given open lines by the given parts using the clip type.
+func clipLines(lines Paths, parts []LayerPart, clipType clipper.ClipType, logger *log.Logger) (clippedLines Paths, ok bool) {
+	if len(lines) == 0 {
+		return lines, true
+	}
+
+	var tree *clipper.PolyTree
+	ok = withRetry(logger, "line "+clipTypeName(clipType), func(clean func(clipper.Paths) clipper.Paths) bool {
+		cl := clipper.NewClipper(clipper.IoNone)
+
+		for _, part := range parts {
+			cl.AddPaths(clean(partPaths(part)), clipper.PtClip, true)
+		}
+
+		cl.AddPaths(clipperPaths(lines), clipper.PtSubject, false)
+
+		tree, ok = cl.Execute2(clipType, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+
+	for _, child := range tree.Childs() {
+		clippedLines = append(clippedLines, microPath(child.Contour()))
+	}
+
+	return clippedLines, true
+}
+
+// Hull generates the convex outline around all given parts.
+func Hull(parts []LayerPart) (hull Path, ok bool) {
+	var allPoints Path
+	for _, part := range parts {
+		allPoints = append(allPoints, part.Outline()...)
+	}
+
+	convexHull := goconvexhull2d.New(allPoints)
+
+	hullPath, ok := convexHull.(Path)
+	if !ok {
+		return nil, ok
+	}
+	return hullPath, true
+}
+
+// TopLevelPolygons only returns the outlines which are not inside of any other outline.
+// The holes of the parts are ignored.
+func TopLevelPolygons(parts []LayerPart, logger *log.Logger) (topLevel Paths, ok bool) {
+	var tree *clipper.PolyTree
+	ok = withRetry(logger, "search for the top level polygons", func(clean func(clipper.Paths) clipper.Paths) bool {
+		cl := clipper.NewClipper(clipper.IoNone)
+
+		for _, part := range parts {
+			cl.AddPaths(clean(clipperPaths(Paths{part.Outline()})), clipper.PtSubject, true)
+		}
+
+		// this is just a dummy-call to Execute2 as I found no other way to get a tree from clipper...
+		tree, ok = cl.Execute2(clipper.CtUnion, clipper.PftEvenOdd, clipper.PftEvenOdd)
+		return ok
+	})
+	if !ok {
+		return nil, false
+	}
+
+	for _, child := range tree.Childs() {
+		topLevel = append(topLevel, microPath(child.Contour()))
+	}
+
+	return topLevel, true
+}
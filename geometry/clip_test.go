@@ -0,0 +1,141 @@
+package geometry_test
+
+import (
+	"github.com/aligator/goslice/geometry"
+	"github.com/aligator/goslice/util/test"
+	"log"
+	"math"
+	"testing"
+)
+
+// square returns a square LayerPart with the given corner and size without holes.
+func square(x, y, size geometry.Micrometer) geometry.LayerPart {
+	return geometry.NewBasicLayerPart(geometry.Path{
+		geometry.NewMicroPoint(x, y),
+		geometry.NewMicroPoint(x+size, y),
+		geometry.NewMicroPoint(x+size, y+size),
+		geometry.NewMicroPoint(x, y+size),
+	}, nil)
+}
+
+// area calculates the area of the outline of the part minus the area of the holes.
+func area(part geometry.LayerPart) float64 {
+	result := pathArea(part.Outline())
+	for _, hole := range part.Holes() {
+		result -= pathArea(hole)
+	}
+	return result
+}
+
+func pathArea(p geometry.Path) float64 {
+	var result float64
+	for i, point := range p {
+		next := p[(i+1)%len(p)]
+		result += float64(point.X())*float64(next.Y()) - float64(next.X())*float64(point.Y())
+	}
+	if result < 0 {
+		result = -result
+	}
+	return result / 2
+}
+
+func TestPartition(t *testing.T) {
+	outer := square(0, 0, 100).Outline()
+	inner := square(25, 25, 50).Outline()
+
+	parts, ok := geometry.Partition(geometry.Paths{outer, inner}, nil)
+	test.Assert(t, ok, "partition should succeed")
+	test.Equals(t, 1, len(parts))
+	test.Equals(t, 1, len(parts[0].Holes()))
+	test.Equals(t, float64(100*100-50*50), area(parts[0]))
+
+	parts, ok = geometry.Partition(nil, nil)
+	test.Assert(t, ok, "partition should succeed")
+	test.Equals(t, 0, len(parts))
+}
+
+func TestBooleanOperations(t *testing.T) {
+	a := []geometry.LayerPart{square(0, 0, 100)}
+	b := []geometry.LayerPart{square(50, 0, 100)}
+
+	var testCases = map[string]struct {
+		operation    func(parts, other []geometry.LayerPart, logger *log.Logger) ([]geometry.LayerPart, bool)
+		expectedArea float64
+	}{
+		"Difference": {
+			operation:    geometry.Difference,
+			expectedArea: 50 * 100,
+		},
+		"Intersection": {
+			operation:    geometry.Intersection,
+			expectedArea: 50 * 100,
+		},
+		"Union": {
+			operation:    geometry.Union,
+			expectedArea: 150 * 100,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		result, ok := testCase.operation(a, b, nil)
+		test.Assert(t, ok, "operation should succeed")
+		test.Equals(t, 1, len(result))
+		test.Equals(t, testCase.expectedArea, area(result[0]))
+
+		// empty layers, e.g. above a lower body, result in no parts
+		result, ok = testCase.operation([]geometry.LayerPart{}, []geometry.LayerPart{}, nil)
+		test.Assert(t, ok, "operation on empty parts should succeed")
+		test.Equals(t, 0, len(result))
+	}
+}
+
+func TestInset(t *testing.T) {
+	insets := geometry.Inset(square(0, 0, 1000), 100, 2, -50)
+	test.Equals(t, 2, len(insets))
+	test.Equals(t, float64(900*900), area(insets[0][0]))
+	test.Equals(t, float64(700*700), area(insets[1][0]))
+}
+
+func TestInsetWithJoin(t *testing.T) {
+	grow := func(joinType geometry.JoinType) float64 {
+		insets := geometry.InsetWithJoin(square(0, 0, 1000), 0, 1, 100, joinType, 2)
+		test.Equals(t, 1, len(insets[0]))
+		return area(insets[0][0])
+	}
+
+	// miter joins keep the corners of a square grown by 100
+	miterArea := grow(geometry.JoinMiter)
+	test.Equals(t, float64(1200*1200), miterArea)
+
+	// round joins add a quarter circle at each corner, approximated by a few segments
+	roundArea := grow(geometry.JoinRound)
+	test.Assert(t, math.Abs(roundArea-(1000*1000+4*1000*100+math.Pi*100*100)) < 5000, "the round corners should be quarter circles, got the area %v", roundArea)
+
+	// square joins cut off the corners, but less than round joins
+	squareArea := grow(geometry.JoinSquare)
+	test.Assert(t, roundArea < squareArea && squareArea < miterArea, "the area %v with square corners should be between %v and %v", squareArea, roundArea, miterArea)
+}
+
+func TestIntersectLines(t *testing.T) {
+	line := geometry.Path{geometry.NewMicroPoint(-50, 50), geometry.NewMicroPoint(150, 50)}
+
+	clipped, ok := geometry.IntersectLines(geometry.Paths{line}, []geometry.LayerPart{square(0, 0, 100)}, nil)
+	test.Assert(t, ok, "intersection should succeed")
+	test.Equals(t, 1, len(clipped))
+	test.Equals(t, geometry.Micrometer(100), clipped[0][0].Sub(clipped[0][1]).Size())
+
+	outside, ok := geometry.DifferenceLines(geometry.Paths{line}, []geometry.LayerPart{square(0, 0, 100)}, nil)
+	test.Assert(t, ok, "difference should succeed")
+	test.Equals(t, 2, len(outside))
+	test.Equals(t, geometry.Micrometer(50), outside[0][0].Sub(outside[0][1]).Size())
+	test.Equals(t, geometry.Micrometer(50), outside[1][0].Sub(outside[1][1]).Size())
+
+	crossing, ok := geometry.IsCrossingPerimeter([]geometry.LayerPart{square(0, 0, 100)}, line, nil)
+	test.Assert(t, ok, "check should succeed")
+	test.Assert(t, crossing, "the line should cross the perimeter")
+
+	crossing, ok = geometry.IsCrossingPerimeter(nil, line, nil)
+	test.Assert(t, ok, "check without parts should succeed")
+	test.Assert(t, !crossing, "the line should not cross any perimeter if there are no parts")
+}
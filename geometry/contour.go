@@ -0,0 +1,108 @@
+// This file provides calculations on the points along paths and closed contours.
+
+package geometry
+
+import "math"
+
+// ProjectOnSegment returns the point of the segment from a to b which is nearest to p.
+func ProjectOnSegment(p, a, b MicroPoint) MicroPoint {
+	ab := b.Sub(a)
+	length2 := float64(ab.X())*float64(ab.X()) + float64(ab.Y())*float64(ab.Y())
+	if length2 == 0 {
+		return a
+	}
+
+	ap := p.Sub(a)
+	t := (float64(ap.X())*float64(ab.X()) + float64(ap.Y())*float64(ab.Y())) / length2
+	t = math.Max(0, math.Min(1, t))
+	return NewMicroPoint(a.X()+Micrometer(math.Round(t*float64(ab.X()))), a.Y()+Micrometer(math.Round(t*float64(ab.Y()))))
+}
+
+// Length returns the length of the Path as open line.
+func (p Path) Length() Micrometer {
+	var length Micrometer
+	for i := 1; i < len(p); i++ {
+		length += p[i].Sub(p[i-1]).Size()
+	}
+	return length
+}
+
+// Contains returns true if the point lies inside of the Path using the even odd rule.
+// The Path is assumed to be closed. Nothing lies inside of an empty Path.
+func (p Path) Contains(point MicroPoint) bool {
+	if len(p) == 0 {
+		return false
+	}
+
+	inside := false
+	previous := p[len(p)-1]
+	for _, current := range p {
+		if (current.Y() > point.Y()) != (previous.Y() > point.Y()) {
+			x := float64(previous.X()-current.X())*float64(point.Y()-current.Y())/float64(previous.Y()-current.Y()) + float64(current.X())
+			if float64(point.X()) < x {
+				inside = !inside
+			}
+		}
+		previous = current
+	}
+	return inside
+}
+
+// PartContains returns true if the point lies inside of the outline and not inside of a hole of the part.
+func PartContains(part LayerPart, point MicroPoint) bool {
+	if !part.Outline().Contains(point) {
+		return false
+	}
+	for _, hole := range part.Holes() {
+		if hole.Contains(point) {
+			return false
+		}
+	}
+	return true
+}
+
+// WalkContour returns the points along the closed contour from the point from on the segment fromSegment
+// to the point to on the segment toSegment (both included), either in the direction of the contour (forward)
+// or in the opposite direction. The segment i runs from the point i to the point i+1 of the contour.
+func WalkContour(contour Path, fromSegment int, from MicroPoint, toSegment int, to MicroPoint, forward bool) Path {
+	path := Path{from}
+
+	// both points lie on the same segment and the walk does not go around the whole contour
+	if fromSegment == toSegment {
+		start := contour[fromSegment]
+		fromDistance, toDistance := from.Sub(start).Size2(), to.Sub(start).Size2()
+		if forward && fromDistance <= toDistance || !forward && fromDistance >= toDistance {
+			return append(path, to)
+		}
+	}
+
+	if forward {
+		for i := (fromSegment + 1) % len(contour); ; i = (i + 1) % len(contour) {
+			path = append(path, contour[i])
+			if i == toSegment {
+				break
+			}
+		}
+	} else {
+		end := (toSegment + 1) % len(contour)
+		for i := fromSegment; ; i = (i - 1 + len(contour)) % len(contour) {
+			path = append(path, contour[i])
+			if i == end {
+				break
+			}
+		}
+	}
+
+	return append(path, to)
+}
+
+// ShortestContourWalk returns the shorter one of the two walks along the closed contour
+// from the point from on the segment fromSegment to the point to on the segment toSegment (see WalkContour).
+func ShortestContourWalk(contour Path, fromSegment int, from MicroPoint, toSegment int, to MicroPoint) Path {
+	forward := WalkContour(contour, fromSegment, from, toSegment, to, true)
+	backward := WalkContour(contour, fromSegment, from, toSegment, to, false)
+	if backward.Length() < forward.Length() {
+		return backward
+	}
+	return forward
+}
@@ -0,0 +1,277 @@
+package geometry_test
+
+import (
+	"github.com/aligator/goslice/geometry"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func squarePath() geometry.Path {
+	return geometry.Path{
+		geometry.NewMicroPoint(0, 0),
+		geometry.NewMicroPoint(1000, 0),
+		geometry.NewMicroPoint(1000, 1000),
+		geometry.NewMicroPoint(0, 1000),
+	}
+}
+
+func TestProjectOnSegment(t *testing.T) {
+	var testCases = map[string]struct {
+		p, a, b  geometry.MicroPoint
+		expected geometry.MicroPoint
+	}{
+		"in the middle": {
+			p:        geometry.NewMicroPoint(500, 300),
+			a:        geometry.NewMicroPoint(0, 0),
+			b:        geometry.NewMicroPoint(1000, 0),
+			expected: geometry.NewMicroPoint(500, 0),
+		},
+		"before the start": {
+			p:        geometry.NewMicroPoint(-500, 300),
+			a:        geometry.NewMicroPoint(0, 0),
+			b:        geometry.NewMicroPoint(1000, 0),
+			expected: geometry.NewMicroPoint(0, 0),
+		},
+		"after the end": {
+			p:        geometry.NewMicroPoint(1500, -300),
+			a:        geometry.NewMicroPoint(0, 0),
+			b:        geometry.NewMicroPoint(1000, 0),
+			expected: geometry.NewMicroPoint(1000, 0),
+		},
+		"diagonal": {
+			p:        geometry.NewMicroPoint(1000, 0),
+			a:        geometry.NewMicroPoint(0, 0),
+			b:        geometry.NewMicroPoint(1000, 1000),
+			expected: geometry.NewMicroPoint(500, 500),
+		},
+		"empty segment": {
+			p:        geometry.NewMicroPoint(500, 500),
+			a:        geometry.NewMicroPoint(100, 100),
+			b:        geometry.NewMicroPoint(100, 100),
+			expected: geometry.NewMicroPoint(100, 100),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, geometry.ProjectOnSegment(testCase.p, testCase.a, testCase.b), microPointComparer())
+	}
+}
+
+func TestPathLength(t *testing.T) {
+	var testCases = map[string]struct {
+		path     geometry.Path
+		expected geometry.Micrometer
+	}{
+		"open path": {
+			path:     squarePath(),
+			expected: 3000,
+		},
+		"single point": {
+			path:     geometry.Path{geometry.NewMicroPoint(100, 100)},
+			expected: 0,
+		},
+		"empty path": {
+			path:     geometry.Path{},
+			expected: 0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, testCase.path.Length())
+	}
+}
+
+func TestPathContains(t *testing.T) {
+	var testCases = map[string]struct {
+		path     geometry.Path
+		point    geometry.MicroPoint
+		expected bool
+	}{
+		"inside": {
+			path:     squarePath(),
+			point:    geometry.NewMicroPoint(500, 500),
+			expected: true,
+		},
+		"outside": {
+			path:     squarePath(),
+			point:    geometry.NewMicroPoint(1500, 500),
+			expected: false,
+		},
+		"inside of the notch of a concave path": {
+			path: geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(1000, 0),
+				geometry.NewMicroPoint(1000, 1000),
+				geometry.NewMicroPoint(500, 200),
+				geometry.NewMicroPoint(0, 1000),
+			},
+			point:    geometry.NewMicroPoint(500, 800),
+			expected: false,
+		},
+		"empty path": {
+			path:     geometry.Path{},
+			point:    geometry.NewMicroPoint(0, 0),
+			expected: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, testCase.path.Contains(testCase.point))
+	}
+}
+
+func TestPartContains(t *testing.T) {
+	part := geometry.NewBasicLayerPart(squarePath(), geometry.Paths{
+		{
+			geometry.NewMicroPoint(400, 400),
+			geometry.NewMicroPoint(400, 600),
+			geometry.NewMicroPoint(600, 600),
+			geometry.NewMicroPoint(600, 400),
+		},
+	})
+
+	var testCases = map[string]struct {
+		point    geometry.MicroPoint
+		expected bool
+	}{
+		"inside": {
+			point:    geometry.NewMicroPoint(200, 200),
+			expected: true,
+		},
+		"inside of the hole": {
+			point:    geometry.NewMicroPoint(500, 500),
+			expected: false,
+		},
+		"outside": {
+			point:    geometry.NewMicroPoint(-200, 200),
+			expected: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, geometry.PartContains(part, testCase.point))
+	}
+}
+
+func TestWalkContour(t *testing.T) {
+	var testCases = map[string]struct {
+		fromSegment int
+		from        geometry.MicroPoint
+		toSegment   int
+		to          geometry.MicroPoint
+		forward     bool
+		expected    geometry.Path
+	}{
+		"forward": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(200, 0),
+			toSegment:   3,
+			to:          geometry.NewMicroPoint(0, 500),
+			forward:     true,
+			expected: geometry.Path{
+				geometry.NewMicroPoint(200, 0),
+				geometry.NewMicroPoint(1000, 0),
+				geometry.NewMicroPoint(1000, 1000),
+				geometry.NewMicroPoint(0, 1000),
+				geometry.NewMicroPoint(0, 500),
+			},
+		},
+		"backward": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(200, 0),
+			toSegment:   3,
+			to:          geometry.NewMicroPoint(0, 500),
+			forward:     false,
+			expected: geometry.Path{
+				geometry.NewMicroPoint(200, 0),
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(0, 500),
+			},
+		},
+		"same segment in the direction of the walk": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(200, 0),
+			toSegment:   0,
+			to:          geometry.NewMicroPoint(800, 0),
+			forward:     true,
+			expected: geometry.Path{
+				geometry.NewMicroPoint(200, 0),
+				geometry.NewMicroPoint(800, 0),
+			},
+		},
+		"same segment against the direction of the walk": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(800, 0),
+			toSegment:   0,
+			to:          geometry.NewMicroPoint(200, 0),
+			forward:     true,
+			expected: geometry.Path{
+				geometry.NewMicroPoint(800, 0),
+				geometry.NewMicroPoint(1000, 0),
+				geometry.NewMicroPoint(1000, 1000),
+				geometry.NewMicroPoint(0, 1000),
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(200, 0),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		path := geometry.WalkContour(squarePath(), testCase.fromSegment, testCase.from, testCase.toSegment, testCase.to, testCase.forward)
+		test.Equals(t, testCase.expected, path, microPointComparer())
+	}
+}
+
+func TestShortestContourWalk(t *testing.T) {
+	var testCases = map[string]struct {
+		fromSegment int
+		from        geometry.MicroPoint
+		toSegment   int
+		to          geometry.MicroPoint
+		expected    geometry.Path
+	}{
+		"backward is shorter": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(200, 0),
+			toSegment:   3,
+			to:          geometry.NewMicroPoint(0, 500),
+			expected: geometry.Path{
+				geometry.NewMicroPoint(200, 0),
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(0, 500),
+			},
+		},
+		"forward is shorter": {
+			fromSegment: 0,
+			from:        geometry.NewMicroPoint(800, 0),
+			toSegment:   1,
+			to:          geometry.NewMicroPoint(1000, 500),
+			expected: geometry.Path{
+				geometry.NewMicroPoint(800, 0),
+				geometry.NewMicroPoint(1000, 0),
+				geometry.NewMicroPoint(1000, 500),
+			},
+		},
+		"same segment": {
+			fromSegment: 2,
+			from:        geometry.NewMicroPoint(300, 1000),
+			toSegment:   2,
+			to:          geometry.NewMicroPoint(700, 1000),
+			expected: geometry.Path{
+				geometry.NewMicroPoint(300, 1000),
+				geometry.NewMicroPoint(700, 1000),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		path := geometry.ShortestContourWalk(squarePath(), testCase.fromSegment, testCase.from, testCase.toSegment, testCase.to)
+		test.Equals(t, testCase.expected, path, microPointComparer())
+	}
+}
@@ -0,0 +1,18 @@
+// Package geometry provides the geometric primitives of GoSlice and the operations on them.
+//
+// All coordinates are integer Micrometer values which makes the calculations exact and
+// allows converting them to Millimeter only for the output.
+// The package contains
+//   - MicroPoint and MicroVec3 for points and vectors in 2d and 3d space,
+//   - Path and Paths for lines and polygons,
+//   - LayerPart for polygons with holes,
+//   - polygon operations like Partition, Inset, Difference, Intersection and Union.
+//
+// The polygon operations are retried with simplified polygons if they fail.
+// Each successful retry is logged as warning to the logger passed to the operation, which may be nil.
+//
+// The package does not depend on any other package of GoSlice, so it can be used
+// by other tools independently of the slicer.
+//
+// The package data provides aliases of all types, so existing code using them does not need to be changed.
+package geometry
@@ -1,7 +1,7 @@
 // This file provides some types which consist of several Micrometer values.
 // These types can represent points or vectors.
 
-package data
+package geometry
 
 import (
 	"math"
@@ -63,12 +63,8 @@ type MicroVec3 interface {
 	// Copy returns a completely new copy of the vector.
 	Copy() MicroVec3
 
-	// String implements the value interface needed for the options.
+	// String returns the coordinates separated by _, e.g. 10_20_30.
 	String() string
-	// Set implements the value interface needed for the options.
-	Set(s string) error
-	// Type implements the value interface needed for the options.
-	Type() string
 }
 
 // microVec implements MicroVec3
@@ -344,3 +340,7 @@ func (p *microPoint) Copy() MicroPoint {
 		p.x, p.y,
 	}
 }
+
+func (v microVec3) String() string {
+	return v.X().String() + "_" + v.Y().String() + "_" + v.Z().String()
+}
@@ -1,7 +1,7 @@
-package data_test
+package geometry_test
 
 import (
-	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/geometry"
 	"github.com/aligator/goslice/util/test"
 	"github.com/google/go-cmp/cmp"
 	"testing"
@@ -15,30 +15,30 @@ const (
 
 // some helper functions
 
-func setupMicroVec3() data.MicroVec3 {
-	return data.NewMicroVec3(x, y, z)
+func setupMicroVec3() geometry.MicroVec3 {
+	return geometry.NewMicroVec3(x, y, z)
 }
 
-func setupMicroPoint() data.MicroPoint {
-	return data.NewMicroPoint(x, y)
+func setupMicroPoint() geometry.MicroPoint {
+	return geometry.NewMicroPoint(x, y)
 }
 
 // microVec3Comparer returns a cmp.Comparer which can handle MicroVec3.
 func microVec3Comparer() cmp.Option {
-	return cmp.Comparer(func(vec1, vec2 data.MicroVec3) bool {
+	return cmp.Comparer(func(vec1, vec2 geometry.MicroVec3) bool {
 		return vec1.X() == vec2.X() && vec1.Y() == vec2.Y() && vec1.Z() == vec2.Z()
 	})
 }
 
 // microVec3Comparer returns a cmp.Comparer which can handle MicroPoint.
 func microPointComparer() cmp.Option {
-	return cmp.Comparer(func(vec1, vec2 data.MicroPoint) bool {
+	return cmp.Comparer(func(vec1, vec2 geometry.MicroPoint) bool {
 		return vec1.X() == vec2.X() && vec1.Y() == vec2.Y()
 	})
 }
 
 // assertMicroVec3 checks if the vector vec contains the given xyz values.
-func assertMicroVec3(t testing.TB, vec data.MicroVec3, xyz ...data.Micrometer) {
+func assertMicroVec3(t testing.TB, vec geometry.MicroVec3, xyz ...geometry.Micrometer) {
 	if len(xyz) != 3 {
 		// if it goes here, assertMicroVec3 is used wrong
 		t.FailNow()
@@ -50,7 +50,7 @@ func assertMicroVec3(t testing.TB, vec data.MicroVec3, xyz ...data.Micrometer) {
 }
 
 // assertMicroPoint checks if the vector vec contains the given xy values.
-func assertMicroPoint(t testing.TB, vec data.MicroPoint, xy ...data.Micrometer) {
+func assertMicroPoint(t testing.TB, vec geometry.MicroPoint, xy ...geometry.Micrometer) {
 	if len(xy) != 2 {
 		// if it goes here, assertMicroVec3 is used wrong
 		t.FailNow()
@@ -62,8 +62,8 @@ func assertMicroPoint(t testing.TB, vec data.MicroPoint, xy ...data.Micrometer)
 
 func TestMax(t *testing.T) {
 	var tests = []struct {
-		expected data.Micrometer
-		a, b     data.Micrometer
+		expected geometry.Micrometer
+		a, b     geometry.Micrometer
 	}{
 		{3, 1, 3},
 		{3, 3, 1},
@@ -72,7 +72,7 @@ func TestMax(t *testing.T) {
 	}
 
 	for _, testCase := range tests {
-		actual := data.Max(testCase.a, testCase.b)
+		actual := geometry.Max(testCase.a, testCase.b)
 		test.Equals(t, testCase.expected, actual)
 	}
 }
@@ -80,14 +80,14 @@ func TestMax(t *testing.T) {
 // Test MicroVec3 implementation
 
 func TestNewMicroVec3(t *testing.T) {
-	vec := data.NewMicroVec3(x, y, z)
+	vec := geometry.NewMicroVec3(x, y, z)
 	test.Assert(t, vec != nil, "vec should not be nil")
 
 	assertMicroVec3(t, vec, x, y, z)
 }
 
 func TestMicroVec3Add(t *testing.T) {
-	var expected = []data.Micrometer{20, 40, 60}
+	var expected = []geometry.Micrometer{20, 40, 60}
 
 	vec := setupMicroVec3()
 	vec2 := setupMicroVec3()
@@ -100,7 +100,7 @@ func TestMicroVec3Add(t *testing.T) {
 }
 
 func TestMicroVec3Sub(t *testing.T) {
-	var expected = []data.Micrometer{0, 0, 0}
+	var expected = []geometry.Micrometer{0, 0, 0}
 
 	vec := setupMicroVec3()
 	vec2 := setupMicroVec3()
@@ -113,7 +113,7 @@ func TestMicroVec3Sub(t *testing.T) {
 }
 
 func TestMicroVec3Mul(t *testing.T) {
-	var expected = []data.Micrometer{30, 60, 90}
+	var expected = []geometry.Micrometer{30, 60, 90}
 
 	vec := setupMicroVec3()
 	actual := vec.Mul(3)
@@ -125,7 +125,7 @@ func TestMicroVec3Mul(t *testing.T) {
 }
 
 func TestMicroVec3Div(t *testing.T) {
-	var expected = []data.Micrometer{5, 10, 15}
+	var expected = []geometry.Micrometer{5, 10, 15}
 
 	vec := setupMicroVec3()
 	actual := vec.Div(2)
@@ -138,16 +138,16 @@ func TestMicroVec3Div(t *testing.T) {
 
 func TestMicroVec3Max(t *testing.T) {
 	var tests = []struct {
-		expected data.Micrometer
-		vector   data.MicroVec3
+		expected geometry.Micrometer
+		vector   geometry.MicroVec3
 	}{
-		{3, data.NewMicroVec3(1, 2, 3)},
-		{3, data.NewMicroVec3(1, 3, 2)},
-		{3, data.NewMicroVec3(3, 2, 1)},
+		{3, geometry.NewMicroVec3(1, 2, 3)},
+		{3, geometry.NewMicroVec3(1, 3, 2)},
+		{3, geometry.NewMicroVec3(3, 2, 1)},
 
-		{1, data.NewMicroVec3(1, 1, 1)},
-		{1, data.NewMicroVec3(0, 0, 1)},
-		{-5, data.NewMicroVec3(-10, -5, -10)},
+		{1, geometry.NewMicroVec3(1, 1, 1)},
+		{1, geometry.NewMicroVec3(0, 0, 1)},
+		{-5, geometry.NewMicroVec3(-10, -5, -10)},
 	}
 
 	for _, testCase := range tests {
@@ -168,7 +168,7 @@ func TestMicroVec3TestLength(t *testing.T) {
 
 	var tests = []struct {
 		expected bool
-		length   data.Micrometer
+		length   geometry.Micrometer
 	}{
 		{true, 100},
 		{true, 38},
@@ -184,13 +184,13 @@ func TestMicroVec3TestLength(t *testing.T) {
 }
 
 func TestMicroVec3TestSize2(t *testing.T) {
-	var expected = data.Micrometer(1400)
+	var expected = geometry.Micrometer(1400)
 	vec := setupMicroVec3()
 	test.Equals(t, expected, vec.Size2())
 }
 
 func TestMicroVec3TestSize(t *testing.T) {
-	var expected = data.Micrometer(37)
+	var expected = geometry.Micrometer(37)
 	vec := setupMicroVec3()
 	test.Equals(t, expected, vec.Size())
 }
@@ -210,25 +210,8 @@ func TestMicroVec3TestString(t *testing.T) {
 	test.Equals(t, expected, vec.String())
 }
 
-func TestMicroVec3TestSet(t *testing.T) {
-	var expected = []data.Micrometer{40, 60, 200}
-
-	actual := setupMicroVec3()
-	err := actual.Set("40_60_200")
-
-	test.Ok(t, err)
-	assertMicroVec3(t, actual, expected...)
-}
-
-func TestMicroVec3TestType(t *testing.T) {
-	var expected = "Micrometer"
-	vec := setupMicroVec3()
-
-	test.Equals(t, expected, vec.Type())
-}
-
 func TestMicroVec3TestSetXYZ(t *testing.T) {
-	var expected = []data.Micrometer{50, 90, 200}
+	var expected = []geometry.Micrometer{50, 90, 200}
 	actual := setupMicroVec3()
 
 	actual.SetX(expected[0])
@@ -241,14 +224,14 @@ func TestMicroVec3TestSetXYZ(t *testing.T) {
 // Test MicroPoint implementation
 
 func TestNewMicroPoint(t *testing.T) {
-	vec := data.NewMicroPoint(x, y)
+	vec := geometry.NewMicroPoint(x, y)
 	test.Assert(t, vec != nil, "vec should not be nil")
 
 	assertMicroPoint(t, vec, x, y)
 }
 
 func TestMicroPointAdd(t *testing.T) {
-	var expected = []data.Micrometer{20, 40}
+	var expected = []geometry.Micrometer{20, 40}
 
 	vec := setupMicroPoint()
 	vec2 := setupMicroPoint()
@@ -260,7 +243,7 @@ func TestMicroPointAdd(t *testing.T) {
 }
 
 func TestMicroPointSub(t *testing.T) {
-	var expected = []data.Micrometer{0, 0}
+	var expected = []geometry.Micrometer{0, 0}
 
 	vec := setupMicroPoint()
 	vec2 := setupMicroPoint()
@@ -272,7 +255,7 @@ func TestMicroPointSub(t *testing.T) {
 }
 
 func TestMicroPointMul(t *testing.T) {
-	var expected = []data.Micrometer{30, 60}
+	var expected = []geometry.Micrometer{30, 60}
 
 	vec := setupMicroPoint()
 	actual := vec.Mul(3)
@@ -283,7 +266,7 @@ func TestMicroPointMul(t *testing.T) {
 }
 
 func TestMicroPointDiv(t *testing.T) {
-	var expected = []data.Micrometer{5, 10}
+	var expected = []geometry.Micrometer{5, 10}
 
 	vec := setupMicroPoint()
 	actual := vec.Div(2)
@@ -294,21 +277,21 @@ func TestMicroPointDiv(t *testing.T) {
 }
 
 func TestMicroPointTestSize2(t *testing.T) {
-	var expected = data.Micrometer(500)
+	var expected = geometry.Micrometer(500)
 	vec := setupMicroPoint()
 
 	test.Equals(t, expected, vec.Size2())
 }
 
 func TestMicroPointTestSize(t *testing.T) {
-	var expected = data.Micrometer(22)
+	var expected = geometry.Micrometer(22)
 	vec := setupMicroPoint()
 
 	test.Equals(t, expected, vec.Size())
 }
 
 func TestMicroPointTestSizeMM(t *testing.T) {
-	var expected = data.Millimeter(0.022360679)
+	var expected = geometry.Millimeter(0.022360679)
 	vec := setupMicroPoint()
 
 	test.Equals(t, expected, vec.SizeMM())
@@ -324,7 +307,7 @@ func TestMicroPointTestCopy(t *testing.T) {
 }
 
 func TestMicroPointTestSetXY(t *testing.T) {
-	var expected = []data.Micrometer{50, 90}
+	var expected = []geometry.Micrometer{50, 90}
 	actual := setupMicroPoint()
 
 	actual.SetX(expected[0])
@@ -335,19 +318,19 @@ func TestMicroPointTestSetXY(t *testing.T) {
 
 func TestMicroPointTestRotate(t *testing.T) {
 	var testCases = []struct {
-		point    data.MicroPoint
+		point    geometry.MicroPoint
 		degree   float64
-		expected data.MicroPoint
+		expected geometry.MicroPoint
 	}{
 		{
 			point:    setupMicroPoint(),
 			degree:   90,
-			expected: data.NewMicroPoint(-20, 10),
+			expected: geometry.NewMicroPoint(-20, 10),
 		},
 		{
 			point:    setupMicroPoint(),
 			degree:   -90,
-			expected: data.NewMicroPoint(20, -10),
+			expected: geometry.NewMicroPoint(20, -10),
 		},
 		{
 			point:    setupMicroPoint(),
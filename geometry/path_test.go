@@ -0,0 +1,410 @@
+package geometry_test
+
+import (
+	"github.com/aligator/goslice/geometry"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"math"
+	"testing"
+)
+
+// pathComparer returns a cmp.Comparer which can handle Path.
+func pathComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 geometry.Path) bool {
+		for i, path := range p1 {
+			if !cmp.Equal(path, p2[i], microPointComparer()) {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// pathsComparer returns a cmp.Comparer which can handle Paths.
+func pathsComparer(forceOrder bool) cmp.Option {
+	// implementation which forces the order to be the same
+	if forceOrder {
+		return cmp.Comparer(func(p1, p2 geometry.Paths) bool {
+			if len(p1) != len(p2) {
+				return false
+			}
+
+			for i, path := range p1 {
+				if !cmp.Equal(path, p2[i], pathComparer()) {
+					return false
+				}
+			}
+
+			return true
+		})
+	}
+
+	// implementation which also accepts a different order
+	return cmp.Comparer(func(p1, p2 geometry.Paths) bool {
+		if len(p1) != len(p2) {
+			return false
+		}
+
+		found := make([]bool, len(p1))
+
+		for i, path := range p1 {
+			if !found[i] && cmp.Equal(path, p2[i], pathComparer()) {
+				found[i] = true
+			}
+		}
+
+		// if any of the paths was not found, return false
+		for _, f := range found {
+			if !f {
+				return false
+			}
+		}
+
+		return true
+	})
+}
+
+// layerPartComparer returns a cmp.Comparer which can handle LayerPart.
+func layerPartComparer(forceOrder bool) cmp.Option {
+	return cmp.Comparer(func(p1, p2 geometry.LayerPart) bool {
+		if !cmp.Equal(p1.Outline(), p2.Outline(), pathComparer()) {
+			return false
+		}
+		if !cmp.Equal(p1.Holes(), p2.Holes(), pathsComparer(true)) {
+			return false
+		}
+
+		return true
+	})
+}
+
+// Test Path
+
+func TestPathIsAlmostFinished(t *testing.T) {
+	var testCases = []struct {
+		toTest   geometry.Path
+		distance geometry.Micrometer
+		expected bool
+	}{
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, distance: 30, expected: true},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, -31),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, distance: 30, expected: false},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, -30),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, distance: 30, expected: true},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(-30, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, distance: 30, expected: true},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(-31, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, distance: 30, expected: false},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		test.Equals(t, testCase.expected, testCase.toTest.IsAlmostFinished(testCase.distance))
+	}
+}
+
+func TestPathSimplify(t *testing.T) {
+	// TODO
+}
+
+func TestPathArea(t *testing.T) {
+	var testCases = []struct {
+		toTest   geometry.Path
+		expected geometry.Micrometer
+	}{
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+		}, expected: 10000},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(100, 0),
+		}, expected: -10000},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(0, 100),
+		}, expected: 5000},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+		}, expected: 0},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		test.Equals(t, testCase.expected, testCase.toTest.Area())
+	}
+}
+
+func TestPathPrincipalAxis(t *testing.T) {
+	// rotated returns a rectangle of the given size which is rotated around the origin.
+	rotated := func(width, height, degree float64) geometry.Path {
+		rad := degree * math.Pi / 180
+		var path geometry.Path
+		for _, corner := range [][2]float64{{0, 0}, {width, 0}, {width, height}, {0, height}} {
+			x := corner[0]*math.Cos(rad) - corner[1]*math.Sin(rad)
+			y := corner[0]*math.Sin(rad) + corner[1]*math.Cos(rad)
+			path = append(path, geometry.NewMicroPoint(geometry.Micrometer(math.Round(x)), geometry.Micrometer(math.Round(y))))
+		}
+		return path
+	}
+
+	var testCases = map[string]struct {
+		toTest   geometry.Path
+		expected float64
+	}{
+		"long in x direction": {
+			toTest:   rotated(10000, 2000, 0),
+			expected: 0,
+		},
+		"long in y direction": {
+			toTest:   rotated(2000, 10000, 0),
+			expected: 90,
+		},
+		"rotated by 30 degree": {
+			toTest:   rotated(10000, 2000, 30),
+			expected: 30,
+		},
+		"rotated by 150 degree": {
+			toTest:   rotated(10000, 2000, 150),
+			expected: 150,
+		},
+		"clockwise": {
+			toTest:   rotated(10000, 2000, 30).Reversed(),
+			expected: 30,
+		},
+		"square": {
+			toTest:   rotated(5000, 5000, 0),
+			expected: 0,
+		},
+		"line": {
+			toTest: geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(100, 0),
+			},
+			expected: 0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		axis := testCase.toTest.PrincipalAxis()
+		test.Assert(t, math.Abs(axis-testCase.expected) < 0.1, "expected the axis %v but got %v", testCase.expected, axis)
+	}
+}
+
+func TestPathsReversed(t *testing.T) {
+	paths := geometry.Paths{
+		geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+		},
+		geometry.Path{
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 200),
+		},
+	}
+
+	expected := geometry.Paths{
+		geometry.Path{
+			geometry.NewMicroPoint(0, 200),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(100, 100),
+		},
+		geometry.Path{
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(0, 0),
+		},
+	}
+
+	test.Equals(t, expected, paths.Reversed(), pathsComparer(true))
+	// the original should not be modified
+	test.Equals(t, geometry.NewMicroPoint(0, 0), paths[0][0], microPointComparer())
+}
+
+func TestPathsTranslated(t *testing.T) {
+	paths := geometry.Paths{
+		geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+		},
+	}
+
+	expected := geometry.Paths{
+		geometry.Path{
+			geometry.NewMicroPoint(50, -20),
+			geometry.NewMicroPoint(150, -20),
+		},
+	}
+
+	test.Equals(t, expected, paths.Translated(geometry.NewMicroPoint(50, -20)), pathsComparer(true))
+	// the original should not be modified
+	test.Equals(t, geometry.NewMicroPoint(0, 0), paths[0][0], microPointComparer())
+}
+
+func TestPathBounds(t *testing.T) {
+	var testCases = []struct {
+		toTest      geometry.Path
+		expectedMin geometry.MicroPoint
+		expectedMax geometry.MicroPoint
+	}{
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+			geometry.NewMicroPoint(100, 0),
+			geometry.NewMicroPoint(100, 100),
+			geometry.NewMicroPoint(0, 100),
+			geometry.NewMicroPoint(0, 0),
+		}, expectedMin: geometry.NewMicroPoint(0, 0), expectedMax: geometry.NewMicroPoint(100, 100)},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 50),
+			geometry.NewMicroPoint(50, 100),
+			geometry.NewMicroPoint(100, 50),
+			geometry.NewMicroPoint(50, 0),
+			geometry.NewMicroPoint(0, 50),
+		}, expectedMin: geometry.NewMicroPoint(0, 0), expectedMax: geometry.NewMicroPoint(100, 100)},
+		{toTest: geometry.Path{
+			geometry.NewMicroPoint(0, 0),
+		}, expectedMin: geometry.NewMicroPoint(0, 0), expectedMax: geometry.NewMicroPoint(0, 0)},
+		{toTest: geometry.Path{}, expectedMin: geometry.NewMicroPoint(0, 0), expectedMax: geometry.NewMicroPoint(0, 0)},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		min, max := testCase.toTest.Bounds()
+		test.Equals(t, testCase.expectedMin, min, microPointComparer())
+		test.Equals(t, testCase.expectedMax, max, microPointComparer())
+	}
+}
+
+func TestPathsBounds(t *testing.T) {
+	var testCases = []struct {
+		toTest      geometry.Paths
+		expectedMin geometry.MicroPoint
+		expectedMax geometry.MicroPoint
+	}{
+		{toTest: geometry.Paths{
+			geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(100, 0),
+				geometry.NewMicroPoint(100, 100),
+				geometry.NewMicroPoint(0, 100),
+				geometry.NewMicroPoint(0, 0),
+			},
+			geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(200, 0),
+				geometry.NewMicroPoint(200, 50),
+				geometry.NewMicroPoint(0, 50),
+				geometry.NewMicroPoint(0, 0),
+			},
+		}, expectedMin: geometry.NewMicroPoint(0, 0), expectedMax: geometry.NewMicroPoint(200, 100)},
+		{toTest: geometry.Paths{
+			geometry.Path{
+				geometry.NewMicroPoint(0, 50),
+				geometry.NewMicroPoint(50, 100),
+				geometry.NewMicroPoint(100, 50),
+				geometry.NewMicroPoint(50, 0),
+				geometry.NewMicroPoint(0, 50),
+			},
+			geometry.Path{
+				geometry.NewMicroPoint(-50, 0),
+				geometry.NewMicroPoint(0, 50),
+				geometry.NewMicroPoint(50, 0),
+				geometry.NewMicroPoint(0, -50),
+				geometry.NewMicroPoint(-50, 0),
+			},
+		}, expectedMin: geometry.NewMicroPoint(-50, -50), expectedMax: geometry.NewMicroPoint(100, 100)},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		min, max := testCase.toTest.Bounds()
+		test.Equals(t, testCase.expectedMin, min, microPointComparer())
+		test.Equals(t, testCase.expectedMax, max, microPointComparer())
+	}
+}
+
+func TestNewBasicLayerPart(t *testing.T) {
+	var testCases = []struct {
+		outline geometry.Path
+		holes   geometry.Paths
+	}{
+		{
+			outline: geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(100, 0),
+				geometry.NewMicroPoint(100, 0),
+				geometry.NewMicroPoint(0, 100),
+				geometry.NewMicroPoint(0, 0),
+			},
+			holes: geometry.Paths{
+				geometry.Path{
+					geometry.NewMicroPoint(50, 50),
+					geometry.NewMicroPoint(75, 50),
+					geometry.NewMicroPoint(75, 75),
+					geometry.NewMicroPoint(50, 75),
+					geometry.NewMicroPoint(50, 50),
+				},
+			},
+		},
+		{
+			outline: geometry.Path{
+				geometry.NewMicroPoint(0, 0),
+				geometry.NewMicroPoint(100, 0),
+				geometry.NewMicroPoint(100, 0),
+				geometry.NewMicroPoint(0, 100),
+				geometry.NewMicroPoint(0, 0),
+			},
+			holes: geometry.Paths{
+				geometry.Path{
+					geometry.NewMicroPoint(50, 50),
+					geometry.NewMicroPoint(75, 50),
+					geometry.NewMicroPoint(75, 75),
+					geometry.NewMicroPoint(50, 75),
+					geometry.NewMicroPoint(50, 50),
+				},
+			},
+		},
+	}
+
+	for i, testCase := range testCases {
+		t.Log("testCase", i)
+		part := geometry.NewBasicLayerPart(testCase.outline, testCase.holes)
+		test.Equals(t, testCase.outline, part.Outline(), pathComparer())
+		test.Equals(t, testCase.holes, part.Holes(), pathsComparer(true))
+		test.Equals(t, map[string]interface{}(nil), part.Attributes())
+	}
+}
@@ -0,0 +1,40 @@
+// This file provides the retry of failed polygon operations with simplified polygons.
+
+package geometry
+
+import (
+	"log"
+
+	clipper "github.com/aligator/go.clipper"
+)
+
+// cleanDistances are the distances in micrometer used to clean the input polygons of a failed operation
+// before it is retried. Vertices which are nearer to each other or to the line through their neighbours
+// than the distance are removed, so each retry simplifies the polygons a bit more.
+var cleanDistances = []float64{2, 10, 50}
+
+// withRetry runs the operation. If it fails, it is retried with more and more simplified polygons,
+// as the clipper lib fails on some degenerated polygons, e.g. of messy scanned meshes.
+// The operation has to pass all closed input paths through the given clean function.
+// Each successful retry is logged as warning to the logger, if it is not nil.
+// Only if the last retry also fails, false is returned.
+func withRetry(logger *log.Logger, operation string, run func(clean func(clipper.Paths) clipper.Paths) bool) bool {
+	if run(func(paths clipper.Paths) clipper.Paths { return paths }) {
+		return true
+	}
+
+	for _, distance := range cleanDistances {
+		distance := distance
+		ok := run(func(paths clipper.Paths) clipper.Paths {
+			return clipper.NewClipper(clipper.IoNone).CleanPolygons(paths, distance)
+		})
+		if ok {
+			if logger != nil {
+				logger.Printf("Warning: the %s of polygons failed and only succeeded after removing details smaller than %vµm\n", operation, distance)
+			}
+			return true
+		}
+	}
+
+	return false
+}
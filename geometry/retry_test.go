@@ -0,0 +1,71 @@
+package geometry
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	clipper "github.com/aligator/go.clipper"
+	"github.com/aligator/goslice/util/test"
+)
+
+func TestWithRetry(t *testing.T) {
+	// a square with an additional vertex 1µm next to its first corner
+	square := clipper.Paths{clipperPath(Path{
+		NewMicroPoint(0, 0),
+		NewMicroPoint(1, 0),
+		NewMicroPoint(1000, 0),
+		NewMicroPoint(1000, 1000),
+		NewMicroPoint(0, 1000),
+	})}
+
+	var testCases = map[string]struct {
+		failures int
+		ok       bool
+		warning  bool
+		length   int
+	}{
+		"NoFailure": {
+			failures: 0,
+			ok:       true,
+			length:   5,
+		},
+		"FirstRetry": {
+			failures: 1,
+			ok:       true,
+			warning:  true,
+			length:   4,
+		},
+		"AllRetriesFail": {
+			failures: len(cleanDistances) + 1,
+			ok:       false,
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Log("testCase:", name)
+
+		var output bytes.Buffer
+		runs := 0
+		var length int
+		ok := withRetry(log.New(&output, "", 0), "test", func(clean func(clipper.Paths) clipper.Paths) bool {
+			runs++
+			length = len(clean(square)[0])
+			return runs > testCase.failures
+		})
+
+		test.Equals(t, testCase.ok, ok)
+		test.Equals(t, testCase.warning, strings.Contains(output.String(), "Warning: the test of polygons failed"))
+		if testCase.ok {
+			test.Equals(t, testCase.length, length)
+		}
+	}
+
+	// without a logger the warning is dropped
+	runs := 0
+	test.Assert(t, withRetry(nil, "test", func(clean func(clipper.Paths) clipper.Paths) bool {
+		runs++
+		return runs > 1
+	}), "the retry should succeed without a logger")
+}
@@ -0,0 +1,61 @@
+// This file provides the units used for all values.
+
+package geometry
+
+import (
+	"math"
+	"strconv"
+)
+
+// Millimeter represents a value in mm
+// It should not be used for calculations, convert to micrometer.
+// using ToMicrometer() before calculating to prevent rounding
+// errors because of the float-type.
+type Millimeter float32
+
+func (m Millimeter) ToMicrometer() Micrometer {
+	return Micrometer(math.RoundToEven(float64(m * 1000)))
+}
+
+// Micrometer represents a value in 0.001 mm
+type Micrometer int64
+
+// MaxMicrometer is the biggest possible Micrometer value, e.g. as start value when searching the minimum.
+const MaxMicrometer = Micrometer(math.MaxInt64)
+
+// MinMicrometer is the smallest possible Micrometer value, e.g. as start value when searching the maximum.
+const MinMicrometer = Micrometer(math.MinInt64)
+
+func (m Micrometer) ToMillimeter() Millimeter {
+	return Millimeter(float64(m) / 1000)
+}
+
+// implement the pflag Value interface so that the units can be used directly as options
+
+func (m Micrometer) String() string {
+	return strconv.FormatInt(int64(m), 10)
+}
+
+func (m *Micrometer) Set(s string) error {
+	v, err := strconv.ParseInt(s, 0, 64)
+	*m = Micrometer(v)
+	return err
+}
+
+func (m Micrometer) Type() string {
+	return "Micrometer"
+}
+
+func (m Millimeter) String() string {
+	return strconv.FormatFloat(float64(m), 'f', 3, 32)
+}
+
+func (m *Millimeter) Set(s string) error {
+	v, err := strconv.ParseFloat(s, 32)
+	*m = Millimeter(v)
+	return err
+}
+
+func (m Millimeter) Type() string {
+	return "Millimeter"
+}
@@ -1,15 +1,15 @@
-package data_test
+package geometry_test
 
 import (
-	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/geometry"
 	"github.com/aligator/goslice/util/test"
 	"testing"
 )
 
 func TestMillimeter(t *testing.T) {
 	var tests = []struct {
-		val      data.Millimeter
-		expected data.Micrometer
+		val      geometry.Millimeter
+		expected geometry.Micrometer
 	}{
 		{val: 10, expected: 10000},
 		{val: 1.123, expected: 1123},
@@ -26,8 +26,8 @@ func TestMillimeter(t *testing.T) {
 
 func TestMicrometer(t *testing.T) {
 	var tests = []struct {
-		val      data.Micrometer
-		expected data.Millimeter
+		val      geometry.Micrometer
+		expected geometry.Millimeter
 	}{
 		{val: 10, expected: 0.01},
 		{val: 1123, expected: 1.123},
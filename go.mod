@@ -10,4 +10,5 @@ require (
 	github.com/hschendel/stl v1.0.4
 	github.com/spf13/pflag v1.0.5
 	github.com/stretchr/testify v1.6.1 // indirect
+	gopkg.in/yaml.v2 v2.4.0
 )
@@ -1,6 +1,8 @@
 package goslice
 
 import (
+	"errors"
+	"fmt"
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/gcode"
@@ -9,155 +11,583 @@ import (
 	"github.com/aligator/goslice/modifier"
 	"github.com/aligator/goslice/optimizer"
 	"github.com/aligator/goslice/reader"
+	"github.com/aligator/goslice/repair"
 	"github.com/aligator/goslice/slicer"
 	"github.com/aligator/goslice/writer"
+	"io"
+	"math"
+	"os"
+	"sync"
 	"time"
 )
 
 // GoSlice combines all logic  needed to slice
 // a model and generate a GCode file.
+//
+// The handlers keep the state of the current run, so a GoSlice processes only one model at a time.
+// Concurrent calls of Process wait for each other.
+// To process several models concurrently, create a GoSlice for each run using NewRun.
 type GoSlice struct {
 	Options   data.GoSliceOptions
 	Reader    handler.ModelReader
+	Repairer  handler.ModelRepairer
 	Optimizer handler.ModelOptimizer
 	Slicer    handler.ModelSlicer
 	Modifiers []handler.LayerModifier
 	Generator handler.GCodeGenerator
 	Writer    handler.GCodeWriter
+
+	// MaterialModifiers modify the layers of the bodies printed by different extruders together (see data.MaterialModel).
+	MaterialModifiers []handler.MaterialModifier
+
+	// options are the options the handlers were created with.
+	options data.Options
+	// hooks are the options which add custom handlers, so that NewRun can create them again.
+	hooks []Option
+	mutex sync.Mutex
+
+	// toolPaths writes the tool paths while the gcode is generated if a tool path file is set.
+	toolPaths *data.ToolPathWriter
+
+	// modelReport contains the errors of the mesh found by the optimizer in the last run.
+	modelReport data.ModelReport
 }
 
-// NewGoSlice provides a GoSlice with all built in implementations.
-func NewGoSlice(options data.Options) *GoSlice {
+// Option adds custom handlers to a GoSlice created by NewGoSlice.
+type Option func(h *hooks)
+
+// hooks contain the factories of the custom handlers.
+// Factories are used instead of instances, as the handlers keep the state of a run (see NewRun).
+type hooks struct {
+	modifiers []func(options *data.Options) handler.LayerModifier
+	renderers []func(options *data.Options) gcode.Renderer
+}
+
+// WithModifier adds a modifier which is run after all built in modifiers.
+// The factory is called for each GoSlice created by NewGoSlice or NewRun.
+func WithModifier(factory func(options *data.Options) handler.LayerModifier) Option {
+	return func(h *hooks) {
+		h.modifiers = append(h.modifiers, factory)
+	}
+}
+
+// WithRenderer adds a renderer which is rendered after all built in renderers
+// but before the layer is finished (see renderer.PostLayer).
+// The factory is called for each GoSlice created by NewGoSlice or NewRun.
+func WithRenderer(factory func(options *data.Options) gcode.Renderer) Option {
+	return func(h *hooks) {
+		h.renderers = append(h.renderers, factory)
+	}
+}
+
+// NewGoSlice provides a GoSlice with all built in implementations
+// and the custom handlers added by the given options.
+func NewGoSlice(options data.Options, opts ...Option) *GoSlice {
+	for _, warning := range options.Validate() {
+		options.GoSlice.Logger.Printf("Warning: %s\n", warning)
+	}
+
+	return newGoSlice(options, opts)
+}
+
+// NewRun returns a new GoSlice with the same options and new instances of all built in handlers
+// and of the handlers added by the options passed to NewGoSlice.
+// It can process a model concurrently to s, e.g. to reuse a configured pipeline for several jobs of a server.
+// The GoSlice options, such as the input and output paths, are copied from s.Options and may be changed for the new run.
+// Handlers which were replaced after creating s are not copied as they may keep the state of a run,
+// so they have to be set again.
+func (s *GoSlice) NewRun() *GoSlice {
+	s.mutex.Lock()
+	options := s.options
+	options.GoSlice = s.Options
+	s.mutex.Unlock()
+
+	return newGoSlice(options, s.hooks)
+}
+
+func newGoSlice(options data.Options, opts []Option) *GoSlice {
 	s := &GoSlice{
 		Options: options.GoSlice,
+		options: options,
+		hooks:   opts,
+	}
+
+	var custom hooks
+	for _, opt := range opts {
+		opt(&custom)
 	}
 
 	// create handlers
-	topBottomPatternFactory := func(min data.MicroPoint, max data.MicroPoint) clip.Pattern {
-		return clip.NewLinearPattern(options.Printer.ExtrusionWidth, options.Printer.ExtrusionWidth, min, max, options.Print.InfillRotationDegree, true, false)
+
+	supportedBottomPatternFactory := func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		if options.Print.Support.SupportedBottomDensity <= 0 {
+			return nil
+		}
+		lineWidth := options.Printer.ExtrusionWidth * 100 / data.Micrometer(options.Print.Support.SupportedBottomDensity)
+		return clip.NewLinearPattern(options.Printer.ExtrusionWidth, lineWidth, min, max, degree, true, options.Print.SkinZigZag)
+	}
+	// infillPercentPatternFactory creates the infill pattern for the given infill percent.
+	infillPercentPatternFactory := func(options *data.Options, percent int, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		// TODO: the calculation of the percentage is currently very basic and may not be correct.
+
+		if percent != 0 {
+			mm10 := data.Millimeter(10).ToMicrometer()
+			linesPer10mmFor100Percent := mm10 / options.Printer.ExtrusionWidth
+			linesPer10mmForInfillPercent := float64(linesPer10mmFor100Percent) * float64(percent) / 100.0
+
+			lineWidth := data.Micrometer(float64(mm10) / linesPer10mmForInfillPercent)
+
+			patternOptions := clip.PatternOptions{
+				LineWidth:        options.Printer.ExtrusionWidth,
+				LineDistance:     lineWidth,
+				Min:              min,
+				Max:              max,
+				Degree:           degree,
+				ZigZag:           options.Print.InfillZigZag,
+				AdaptiveDistance: options.Print.InfillAdaptiveDistance.ToMicrometer(),
+			}
+			pattern, err := clip.NewPattern(options.Print.InfillPattern, patternOptions)
+			if err != nil {
+				// the unknown pattern is reported once by data.Options.Validate
+				pattern, _ = clip.NewPattern("linear", patternOptions)
+			}
+			return pattern
+		}
+
+		return nil
+	}
+	infillPatternFactory := func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		return infillPercentPatternFactory(options, options.Print.InfillPercent, min, max, degree)
+	}
+	// gradualInfillPatternFactory creates the pattern of a step of the gradual infill, which doubles the density on each step.
+	gradualInfillPatternFactory := func(step int) func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		return func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+			return infillPercentPatternFactory(options, gradualInfillPercent(options.Print.InfillPercent, step, options.Print.GradualInfillSteps), min, max, degree)
+		}
+	}
+	skinSupportPatternFactory := func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		lineWidth := data.Max(options.Print.MaxSkinSpan.ToMicrometer(), options.Printer.ExtrusionWidth)
+		return clip.NewLinearPattern(options.Printer.ExtrusionWidth, lineWidth, min, max, degree, true, options.Print.InfillZigZag)
+	}
+	solidInfillPatternFactory := func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		return clip.NewLinearPattern(options.Printer.ExtrusionWidth, options.Printer.ExtrusionWidth, min, max, degree, true, options.Print.InfillZigZag)
+	}
+
+	// supportPatternFactory creates the support pattern selected by the option Print.Support.Pattern.
+	supportPatternFactory := func(options *data.Options, width data.Micrometer, lineDistance data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		switch options.Print.Support.Pattern {
+		case "lines":
+			return clip.NewLinearPattern(width, lineDistance, min, max, degree, false, false)
+		case "grid":
+			return clip.NewGridPattern(width, lineDistance, min, max, degree)
+		case "concentric":
+			return clip.NewConcentricPattern(width, lineDistance)
+		default:
+			return clip.NewLinearPattern(width, lineDistance, min, max, degree, false, true)
+		}
+	}
+
+	// rotated uses the pattern factory with the global infill rotation.
+	rotated := func(factory func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern) func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+		return func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+			return factory(options, min, max, options.Print.InfillRotationDegree)
+		}
+	}
+	// aligned uses the pattern factory aligned with the principal axis of each part.
+	aligned := func(factory func(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern) func(options *data.Options, min data.MicroPoint, max data.MicroPoint, axis float64) clip.Pattern {
+		return func(options *data.Options, min data.MicroPoint, max data.MicroPoint, axis float64) clip.Pattern {
+			// The lines of the linear pattern are rotated clockwise starting at the y-axis.
+			return factory(options, min, max, 90-int(math.Round(axis)))
+		}
 	}
 
 	s.Reader = reader.Reader(&options)
+	s.Repairer = repair.NewRepairer(&options)
 	s.Optimizer = optimizer.NewOptimizer(&options)
-	s.Slicer = slicer.NewSlicer(&options)
+	plane, err := slicer.NewSlicingPlane(&options)
+	if err != nil {
+		s.Options.Logger.Printf("Warning: %s, planar slicing is used\n", err)
+	}
+	var positionTransform func(p data.MicroVec3) data.MicroVec3
+	if plane != nil {
+		positionTransform = plane.Inverse
+	}
+	if options.Printer.Kinematics == "belt" {
+		beltPosition := optimizer.BeltMachinePosition(options.Printer.Belt.Angle)
+		if positionTransform == nil {
+			positionTransform = beltPosition
+		} else {
+			planePosition := positionTransform
+			positionTransform = func(p data.MicroVec3) data.MicroVec3 {
+				return beltPosition(planePosition(p))
+			}
+		}
+	}
+
+	s.Slicer = slicer.NewSlicer(&options, slicer.WithSlicingPlane(plane))
 	s.Modifiers = []handler.LayerModifier{
+		modifier.NewPrintableOverhangModifier(&options),
+		modifier.NewHoleCompensationModifier(&options),
+		modifier.NewHollowModifier(&options),
 		modifier.NewPerimeterModifier(&options),
+		modifier.NewPerimeterOverhangModifier(&options),
 		modifier.NewInfillModifier(&options),
 		modifier.NewInternalInfillModifier(&options),
+		modifier.NewSkinSupportModifier(&options),
+		modifier.NewGradualInfillModifier(&options),
+		modifier.NewInfillSurfacesModifier(&options),
+		modifier.NewCombinedInfillModifier(&options),
 		modifier.NewBrimModifier(&options),
 		modifier.NewSupportDetectorModifier(&options),
 		modifier.NewSupportGeneratorModifier(&options),
+		modifier.NewTreeSupportModifier(&options),
+		modifier.NewSupportedBottomModifier(&options),
+		modifier.NewBridgeModifier(&options),
+		modifier.NewOozeShieldModifier(&options),
+		modifier.NewFirstLayerModifier(&options),
+		modifier.NewLayerOverrideModifier(&options),
+	}
+	for _, factory := range custom.modifiers {
+		s.Modifiers = append(s.Modifiers, factory(&options))
+	}
+	s.MaterialModifiers = []handler.MaterialModifier{
+		modifier.NewMaterialOverlapModifier(&options),
+		modifier.NewInterlockingModifier(&options),
 	}
 
-	patternSpacing := options.Print.Support.PatternSpacing.ToMicrometer()
+	var customRenderers []gcode.Option
+	for _, factory := range custom.renderers {
+		customRenderers = append(customRenderers, gcode.WithRenderer(factory(&options)))
+	}
 
-	s.Generator = gcode.NewGenerator(
-		&options,
-		gcode.WithRenderer(renderer.PreLayer{}),
-		gcode.WithRenderer(renderer.Skirt{}),
-		gcode.WithRenderer(renderer.Brim{}),
-		gcode.WithRenderer(renderer.Perimeter{}),
+	generatorOptions := []gcode.Option{
+		gcode.WithPositionTransform(positionTransform, options.Printer.ExtrusionWidth*2),
+		gcode.WithToolPathHook(func(layerNr int, z data.Micrometer, paths []data.ToolPath) {
+			if s.toolPaths != nil {
+				// errors are returned when the tool path file is closed
+				_ = s.toolPaths.WriteLayer(layerNr, z, paths)
+			}
+		}),
+		gcode.WithRenderer(&renderer.PreLayer{}),
+		gcode.WithRenderer(renderer.FirstLayer{}),
+		gcode.WithRenderer(renderer.OozeShield{}),
+		gcode.WithRenderer(&renderer.Perimeter{}),
+		gcode.WithRenderer(renderer.ThinWall{}),
+		gcode.WithRenderer(renderer.GapFill{}),
+		gcode.WithRenderer(renderer.Surface{}),
+		gcode.WithRenderer(renderer.Spiral{}),
 
 		// Add infill for support generation.
 		gcode.WithRenderer(&renderer.Infill{
-			PatternSetup: func(min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+			PatternSetup: func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+				patternSpacing := options.Print.Support.PatternSpacing.ToMicrometer()
 				// make bounding box bigger to allow generation of support which has always at least two lines
 				min.SetX(min.X() - patternSpacing)
 				min.SetY(min.Y() - patternSpacing)
 				max.SetX(max.X() + patternSpacing)
 				max.SetY(max.Y() + patternSpacing)
-				return clip.NewLinearPattern(options.Printer.ExtrusionWidth, patternSpacing, min, max, 90, false, true)
+				return withFirstLayer(options, func(width data.Micrometer) clip.Pattern {
+					return supportPatternFactory(options, width, patternSpacing, min, max, options.Print.Support.PatternAngle)
+				})
 			},
 			AttrName: "support",
 			Comments: []string{"TYPE:SUPPORT"},
+			Feature:  data.FeatureSupport,
 		}),
-		// Interface pattern for support generation is generated by rotating 90° to the support and no spaces between the lines.
+		// Interface pattern for support generation is generated by rotating 90° to the support lines and no spaces between the lines.
 		gcode.WithRenderer(&renderer.Infill{
-			PatternSetup: func(min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+			PatternSetup: func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+				patternSpacing := options.Print.Support.PatternSpacing.ToMicrometer()
 				// make bounding box bigger to allow generation of support which has always at least two lines
 				min.SetX(min.X() - patternSpacing)
 				min.SetY(min.Y() - patternSpacing)
 				max.SetX(max.X() + patternSpacing)
 				max.SetY(max.Y() + patternSpacing)
-				return clip.NewLinearPattern(options.Printer.ExtrusionWidth, options.Printer.ExtrusionWidth, min, max, 0, false, true)
+				return withFirstLayer(options, func(width data.Micrometer) clip.Pattern {
+					return clip.NewLinearPattern(width, width, min, max, options.Print.Support.PatternAngle-90, false, true)
+				})
 			},
 			AttrName: "supportInterface",
 			Comments: []string{"TYPE:SUPPORT"},
+			Feature:  data.FeatureSupportInterface,
+		}),
+		// The floor of the support on the model uses dense lines in the direction of the support lines.
+		gcode.WithRenderer(&renderer.Infill{
+			PatternSetup: func(options *data.Options, min data.MicroPoint, max data.MicroPoint) clip.Pattern {
+				return clip.NewLinearPattern(options.Printer.ExtrusionWidth, options.Printer.ExtrusionWidth, min, max, options.Print.Support.PatternAngle, false, true)
+			},
+			AttrName: "supportFloor",
+			Comments: []string{"TYPE:SUPPORT"},
+			Feature:  data.FeatureSupportInterface,
 		}),
+		gcode.WithRenderer(renderer.TreeSupport{}),
 
+		gcode.WithRenderer(renderer.SkinPerimeters{
+			Loops:    modifier.BottomSkinPerimeters,
+			Comments: []string{"TYPE:FILL", "BOTTOM-FILL"},
+			Feature:  data.FeatureBottomSkin,
+		}),
 		gcode.WithRenderer(&renderer.Infill{
-			PatternSetup: topBottomPatternFactory,
-			AttrName:     "bottom",
-			Comments:     []string{"TYPE:FILL", "BOTTOM-FILL"},
+			PatternSetup:     rotated(topBottomPatternFactory),
+			PartPatternSetup: aligned(topBottomPatternFactory),
+			AttrName:         "bottom",
+			Comments:         []string{"TYPE:FILL", "BOTTOM-FILL"},
+			Feature:          data.FeatureBottomSkin,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
 		}),
 		gcode.WithRenderer(&renderer.Infill{
-			PatternSetup: topBottomPatternFactory,
-			AttrName:     "top",
-			Comments:     []string{"TYPE:FILL", "TOP-FILL"},
+			PatternSetup:     rotated(supportedBottomPatternFactory),
+			PartPatternSetup: aligned(supportedBottomPatternFactory),
+			AttrName:         "supportedBottom",
+			Comments:         []string{"TYPE:FILL", "SUPPORTED-BOTTOM-FILL"},
+			Feature:          data.FeatureSupportedBottom,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+			Speed:            options.Print.Support.SupportedBottomSpeed,
+		}),
+		gcode.WithRenderer(&renderer.Bridge{}),
+		gcode.WithRenderer(renderer.SkinPerimeters{
+			Loops:    modifier.TopSkinPerimeters,
+			Comments: []string{"TYPE:FILL", "TOP-FILL"},
+			Feature:  data.FeatureTopSkin,
 		}),
 		gcode.WithRenderer(&renderer.Infill{
-			PatternSetup: func(min data.MicroPoint, max data.MicroPoint) clip.Pattern {
-				// TODO: the calculation of the percentage is currently very basic and may not be correct.
-
-				if options.Print.InfillPercent != 0 {
-					mm10 := data.Millimeter(10).ToMicrometer()
-					linesPer10mmFor100Percent := mm10 / options.Printer.ExtrusionWidth
-					linesPer10mmForInfillPercent := float64(linesPer10mmFor100Percent) * float64(options.Print.InfillPercent) / 100.0
-
-					lineWidth := data.Micrometer(float64(mm10) / linesPer10mmForInfillPercent)
-
-					return clip.NewLinearPattern(options.Printer.ExtrusionWidth, lineWidth, min, max, options.Print.InfillRotationDegree, true, options.Print.InfillZigZag)
-				}
-
-				return nil
-			},
-			AttrName: "infill",
-			Comments: []string{"TYPE:FILL", "INTERNAL-FILL"},
+			PatternSetup:     rotated(topBottomPatternFactory),
+			PartPatternSetup: aligned(topBottomPatternFactory),
+			AttrName:         "top",
+			Comments:         []string{"TYPE:FILL", "TOP-FILL"},
+			Feature:          data.FeatureTopSkin,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+			NonPlanar:        true,
+		}),
+		gcode.WithRenderer(&renderer.Infill{
+			PatternSetup:     rotated(infillPatternFactory),
+			PartPatternSetup: aligned(infillPatternFactory),
+			AttrName:         "infill",
+			SurfacesAttrName: "infillSurfaces",
+			Comments:         []string{"TYPE:FILL", "INTERNAL-FILL"},
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+		}),
+		// The infill of several layers printed at once.
+		gcode.WithRenderer(&renderer.Infill{
+			PatternSetup:     rotated(infillPatternFactory),
+			PartPatternSetup: aligned(infillPatternFactory),
+			AttrName:         "combinedInfill",
+			Comments:         []string{"TYPE:FILL", "INTERNAL-FILL"},
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+			CombinedLayers:   options.Print.InfillCombineLayers,
+		}),
+		// Solid infill of every n-th layer.
+		gcode.WithRenderer(&renderer.Infill{
+			PatternSetup:     rotated(solidInfillPatternFactory),
+			PartPatternSetup: aligned(solidInfillPatternFactory),
+			AttrName:         "solidInfill",
+			Comments:         []string{"TYPE:FILL", "SOLID-FILL"},
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+		}),
+		// Denser infill below top skins which would otherwise span too far.
+		gcode.WithRenderer(&renderer.Infill{
+			PatternSetup:     rotated(skinSupportPatternFactory),
+			PartPatternSetup: aligned(skinSupportPatternFactory),
+			AttrName:         "skinSupport",
+			Comments:         []string{"TYPE:FILL", "SKIN-SUPPORT-FILL"},
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
 		}),
-		gcode.WithRenderer(renderer.PostLayer{}),
-	)
-	s.Writer = writer.Writer()
+	}
+	// The steps of the gradual infill get denser towards the top skins.
+	for step := 1; step <= options.Print.GradualInfillSteps; step++ {
+		generatorOptions = append(generatorOptions, gcode.WithRenderer(&renderer.Infill{
+			PatternSetup:     rotated(gradualInfillPatternFactory(step)),
+			PartPatternSetup: aligned(gradualInfillPatternFactory(step)),
+			AttrName:         modifier.GradualInfillAttribute(step),
+			Comments:         []string{"TYPE:FILL", "INTERNAL-FILL"},
+			Feature:          data.FeatureInfill,
+			TrimToPerimeters: options.Print.InfillTrimToPerimeter,
+		}))
+	}
+	generatorOptions = append(generatorOptions, customRenderers...)
+	generatorOptions = append(generatorOptions, gcode.WithRenderer(&renderer.PostLayer{}))
+
+	s.Generator = gcode.NewGenerator(&options, generatorOptions...)
+	s.Writer = writer.Writer(writer.WithOverwrite(options.GoSlice.Force))
 
 	return s
 }
 
 func (s *GoSlice) Process() error {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
 	startTime := time.Now()
 
+	outputPath := s.Options.OutputFilePath
+	if outputPath == "" {
+		if s.Options.InputFilePath == "-" {
+			return errors.New("an output file path is needed if the model is read from stdin")
+		}
+		if reader.IsURL(s.Options.InputFilePath) {
+			return errors.New("an output file path is needed if the model is downloaded from a URL")
+		}
+		outputPath = s.Options.InputFilePath + ".gcode"
+	}
+
+	err := s.checkOutputPath(outputPath)
+	if err != nil {
+		return err
+	}
+
 	// 1. Load model
-	s.Options.Logger.Printf("Load model %v\n", s.Options.InputFilePath)
-	models, err := s.Reader.Read(s.Options.InputFilePath)
+	models, err := s.readModels()
+	if err != nil {
+		return err
+	}
+	defer s.closeModels(models)
+
+	// 2. Repair model
+	models, err = s.Repairer.Repair(models)
 	if err != nil {
 		return err
 	}
-	s.Options.Logger.Printf("Model loaded.\nFace count: %v\nSize: min: %v max %v\n", models.FaceCount(), models.Min(), models.Max())
 
-	// 2. Optimize model
+	// 3. Optimize model
 	var optimizedModel data.OptimizedModel
 	optimizedModel, err = s.Optimizer.Optimize(models)
 	if err != nil {
 		return err
 	}
+	defer s.closeModels(optimizedModel)
 	s.Options.Logger.Printf("Model optimized\n")
+	s.modelReport = data.ModelReport{}
+	if reported, ok := optimizedModel.(data.ReportedModel); ok {
+		s.modelReport = reported.Report()
+	}
 
 	//err = optimizedModel.SaveDebugSTL("test.stl")
 	//if err != nil {
 	//	return err
 	//}
 
-	// 3. Slice model into layers
+	if s.Options.ToolPathFilePath != "" {
+		file, err := os.Create(s.Options.ToolPathFilePath)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+
+		s.toolPaths = data.NewToolPathWriter(file)
+		defer func() {
+			s.toolPaths = nil
+		}()
+	}
+
+	if sequential, ok := optimizedModel.(data.SequentialModel); ok && len(sequential.Objects()) > 1 {
+		// 4. to 6. slice, modify and generate each object separately
+		err = s.processSequential(sequential.Objects(), outputPath)
+		if err != nil {
+			return err
+		}
+	} else if material, ok := optimizedModel.(data.MaterialModel); ok && material.Materials() != nil {
+		// 4. to 6. slice and modify the whole model and the bodies of each extruder and generate them together
+		err = s.processMaterials(optimizedModel, material.Materials(), outputPath)
+		if err != nil {
+			return err
+		}
+	} else if margin, ok := s.windowMargin(); ok {
+		// 4. to 6. slice, modify and generate the layers window by window
+		err = s.processInWindows(optimizedModel, outputPath, margin)
+		if err != nil {
+			return err
+		}
+	} else {
+		// 4. and 5. Slice and modify the layers or load layers saved before
+		var layers []data.PartitionedLayer
+		if s.Options.LoadLayersFilePath != "" {
+			layers, err = s.loadLayers()
+		} else {
+			layers, err = s.sliceAndModify(optimizedModel)
+		}
+		if err != nil {
+			return err
+		}
+
+		if s.Options.SaveLayersFilePath != "" {
+			err = s.saveLayers(layers)
+			if err != nil {
+				return err
+			}
+		}
+
+		// 6. generate gcode from the layers
+		s.Generator.Init(optimizedModel)
+		finalGcode, err := s.Generator.Generate(layers)
+		if err != nil {
+			return err
+		}
+
+		err = s.Writer.Write(finalGcode, outputPath)
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.toolPaths != nil {
+		err = s.toolPaths.Close()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Options.StatsFilePath != "" {
+		err = s.writeStats()
+		if err != nil {
+			return err
+		}
+	}
+
+	if s.Options.Summary {
+		stats, err := s.stats()
+		if err != nil {
+			return err
+		}
+
+		err = data.WriteSummary(s.Options.Logger.Writer(), stats)
+		if err != nil {
+			return err
+		}
+	}
+
+	s.Options.Logger.Println("full processing time:", time.Now().Sub(startTime))
+
+	return nil
+}
+
+// sliceAndModify slices the model into layers and applies all modifiers to them.
+func (s *GoSlice) sliceAndModify(optimizedModel data.OptimizedModel) ([]data.PartitionedLayer, error) {
+	// 4. Slice model into layers
 	layers, err := s.Slicer.Slice(optimizedModel)
 	if err != nil {
-		return err
+		return nil, err
 	}
 	s.Options.Logger.Printf("Model sliced to %v layers\n", len(layers))
 
-	// 4. Modify the layers
-	// e.g. generate perimeter paths,
-	// generate the parts which should be filled in, ...
+	// 5. Modify the layers
+	err = s.modify(optimizedModel, layers)
+	if err != nil {
+		return nil, err
+	}
+
+	return layers, nil
+}
+
+// modify applies all modifiers to the layers of the model,
+// e.g. they generate the perimeter paths and the parts which should be filled in.
+func (s *GoSlice) modify(optimizedModel data.OptimizedModel, layers []data.PartitionedLayer) error {
 	for _, m := range s.Modifiers {
 		m.Init(optimizedModel)
-		err = m.Modify(layers)
+		err := m.Modify(layers)
 		if err != nil {
 			return err
 		}
@@ -165,20 +595,405 @@ func (s *GoSlice) Process() error {
 	}
 	s.Options.Logger.Printf("Layers modified %v\n", len(layers))
 
-	// 5. generate gcode from the layers
+	return nil
+}
+
+// processSequential slices and modifies the objects one by one and generates the gcode
+// which prints each object completely before the next one starts.
+func (s *GoSlice) processSequential(objects []data.OptimizedModel, outputPath string) error {
+	sequenceGenerator, ok := s.Generator.(handler.GCodeSequenceGenerator)
+	if !ok {
+		return errors.New("the generator does not support printing the objects one after the other")
+	}
+	if s.Options.SaveLayersFilePath != "" || s.Options.LoadLayersFilePath != "" {
+		return errors.New("the layers cannot be saved or loaded if the objects are printed one after the other")
+	}
+
+	layers := make([][]data.PartitionedLayer, len(objects))
+	for i, object := range objects {
+		s.Options.Logger.Printf("Processing object %v/%v\n", i+1, len(objects))
+
+		var err error
+		layers[i], err = s.sliceAndModify(object)
+		if err != nil {
+			return err
+		}
+	}
+
+	finalGcode, err := sequenceGenerator.GenerateSequence(objects, layers)
+	if err != nil {
+		return err
+	}
+
+	return s.Writer.Write(finalGcode, outputPath)
+}
+
+// processMaterials slices and modifies the whole model and the bodies of each extruder and generates the gcode
+// which prints the bodies of each layer with their extruders.
+// The structures around the bodies, such as the skirt and the support, are generated for the whole model.
+// The bodies are modified by the material modifiers before the modifiers are applied to the bodies of each extruder.
+func (s *GoSlice) processMaterials(optimizedModel data.OptimizedModel, materials []data.OptimizedModel, outputPath string) error {
+	materialGenerator, ok := s.Generator.(handler.GCodeMaterialGenerator)
+	if !ok {
+		return errors.New("the generator does not support printing the bodies with different extruders")
+	}
+	if s.Options.SaveLayersFilePath != "" || s.Options.LoadLayersFilePath != "" {
+		return errors.New("the layers cannot be saved or loaded if the bodies are printed by different extruders")
+	}
+
+	layers, err := s.sliceAndModify(optimizedModel)
+	if err != nil {
+		return err
+	}
+	for layerNr, layer := range layers {
+		layers[layerNr] = modifier.SharedLayer(layer)
+	}
+
+	bodies := make([][]data.PartitionedLayer, len(materials))
+	for extruder, material := range materials {
+		if material == nil {
+			continue
+		}
+
+		bodies[extruder], err = s.Slicer.Slice(material)
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, m := range s.MaterialModifiers {
+		err = m.Modify(bodies)
+		if err != nil {
+			return err
+		}
+		s.Options.Logger.Printf("Material modifier %s applied\n", m.GetName())
+	}
+
+	for extruder, material := range materials {
+		if material == nil {
+			continue
+		}
+
+		s.Options.Logger.Printf("Processing the bodies of extruder %v\n", extruder)
+		err = s.modify(material, bodies[extruder])
+		if err != nil {
+			return err
+		}
+		for layerNr, layer := range bodies[extruder] {
+			bodies[extruder][layerNr] = modifier.BodyLayer(layer)
+		}
+	}
+
 	s.Generator.Init(optimizedModel)
-	finalGcode, err := s.Generator.Generate(layers)
+	finalGcode, err := materialGenerator.GenerateMaterials(layers, bodies)
 	if err != nil {
 		return err
 	}
 
-	outputPath := s.Options.OutputFilePath
-	if outputPath == "" {
-		outputPath = s.Options.InputFilePath + ".gcode"
+	return s.Writer.Write(finalGcode, outputPath)
+}
+
+// windowMargin returns the number of layers all modifiers together need below and above a window of layers.
+// It returns false if the layers have to be processed at once, because no layer window is set
+// or a handler does not support processing the layers in windows.
+func (s *GoSlice) windowMargin() (int, bool) {
+	if s.Options.LayerWindow <= 0 {
+		return 0, false
 	}
 
-	err = s.Writer.Write(finalGcode, outputPath)
-	s.Options.Logger.Println("full processing time:", time.Now().Sub(startTime))
+	if s.Options.SaveLayersFilePath != "" || s.Options.LoadLayersFilePath != "" {
+		s.Options.Logger.Printf("The layers are saved or loaded, so all layers are processed at once\n")
+		return 0, false
+	}
+
+	_, slicerOk := s.Slicer.(handler.ModelRangeSlicer)
+	_, generatorOk := s.Generator.(handler.GCodeRangeGenerator)
+	_, writerOk := s.Writer.(handler.GCodeStreamWriter)
+	if !slicerOk || !generatorOk || !writerOk {
+		s.Options.Logger.Printf("The slicer, generator or writer does not support layer windows, so all layers are processed at once\n")
+		return 0, false
+	}
+
+	margin := 0
+	for _, m := range s.Modifiers {
+		contextModifier, ok := m.(handler.LayerContextModifier)
+		if !ok || contextModifier.LayerContext() < 0 {
+			s.Options.Logger.Printf("Modifier %s needs all layers, so all layers are processed at once\n", m.GetName())
+			return 0, false
+		}
+		margin += contextModifier.LayerContext()
+	}
+
+	return margin, true
+}
+
+// processInWindows slices, modifies and generates the layers in windows of the configured size and
+// writes the gcode of each window directly to the output, so that only the layers of one window are kept in memory.
+// Each window is sliced and modified together with margin layers below and above it.
+// The layers are passed to the modifiers and the generator with their absolute layer numbers,
+// all layers outside of the sliced range are empty.
+func (s *GoSlice) processInWindows(optimizedModel data.OptimizedModel, outputPath string, margin int) error {
+	rangeSlicer := s.Slicer.(handler.ModelRangeSlicer)
+	rangeGenerator := s.Generator.(handler.GCodeRangeGenerator)
+
+	output, err := s.Writer.(handler.GCodeStreamWriter).Open(outputPath)
+	if err != nil {
+		return err
+	}
+	defer output.Close()
+
+	layerCount := rangeSlicer.LayerCount(optimizedModel)
+	s.Options.Logger.Printf("Processing %v layers in windows of %v layers\n", layerCount, s.Options.LayerWindow)
+
+	for _, m := range s.Modifiers {
+		m.Init(optimizedModel)
+	}
+	s.Generator.Init(optimizedModel)
+
+	empty := data.NewPartitionedLayer(nil)
+	for start := 0; start < layerCount; start += s.Options.LayerWindow {
+		end := start + s.Options.LayerWindow
+		if end > layerCount {
+			end = layerCount
+		}
+		from := start - margin
+		if from < 0 {
+			from = 0
+		}
+		to := end + margin
+		if to > layerCount {
+			to = layerCount
+		}
+
+		sliced, err := rangeSlicer.SliceRange(optimizedModel, from, to)
+		if err != nil {
+			return err
+		}
+
+		layers := make([]data.PartitionedLayer, layerCount)
+		for layerNr := range layers {
+			if layerNr >= from && layerNr < to {
+				layers[layerNr] = sliced[layerNr-from]
+			} else {
+				layers[layerNr] = empty
+			}
+		}
+
+		for _, m := range s.Modifiers {
+			err = m.Modify(layers)
+			if err != nil {
+				return err
+			}
+		}
+		s.Options.Logger.Printf("Layers %v to %v modified\n", start, end-1)
+
+		gcode, err := rangeGenerator.GenerateRange(layers, start, end)
+		if err != nil {
+			return err
+		}
+
+		_, err = io.WriteString(output, gcode)
+		if err != nil {
+			return err
+		}
+	}
+
+	return output.Close()
+}
+
+// saveLayers writes the modified layers to a file, so that they can be loaded instead of slicing the model again.
+func (s *GoSlice) saveLayers(layers []data.PartitionedLayer) error {
+	file, err := os.Create(s.Options.SaveLayersFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return modifier.WriteLayers(file, layers)
+}
+
+// loadLayers reads the layers saved by saveLayers.
+func (s *GoSlice) loadLayers() ([]data.PartitionedLayer, error) {
+	file, err := os.Open(s.Options.LoadLayersFilePath)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	layers, err := modifier.ReadLayers(file)
+	if err != nil {
+		return nil, err
+	}
+	s.Options.Logger.Printf("Loaded %v modified layers from %v\n", len(layers), s.Options.LoadLayersFilePath)
+
+	return layers, nil
+}
+
+// inputPaths returns the paths of all input models.
+func (s *GoSlice) inputPaths() []string {
+	if len(s.Options.InputFilePaths) == 0 {
+		return []string{s.Options.InputFilePath}
+	}
+	return s.Options.InputFilePaths
+}
+
+// checkOutputPath checks before the slicing if the gcode can be written to the output path.
+// An input model must never be overwritten and an existing output file only if the force option is set.
+func (s *GoSlice) checkOutputPath(outputPath string) error {
+	output, err := os.Stat(outputPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	for _, path := range s.inputPaths() {
+		if path == "-" || reader.IsURL(path) {
+			continue
+		}
+
+		input, err := os.Stat(path)
+		if err == nil && os.SameFile(input, output) {
+			return fmt.Errorf("the output file %v is the input model %v and cannot be overwritten", outputPath, path)
+		}
+	}
+
+	if !s.Options.Force && output.Mode().IsRegular() {
+		return fmt.Errorf("the output file %v already exists, use --force to overwrite it", outputPath)
+	}
+
+	return nil
+}
+
+// readModels reads all input models.
+// If several models are given, they are combined to a data.ModelGroup.
+// Each file is read only once, even if it is given several times.
+// If all models are the same file, they are combined to data.ModelCopies.
+// A model without faces returns an error, so that the bounds of the models and their groups always exist.
+func (s *GoSlice) readModels() (data.Model, error) {
+	paths := s.inputPaths()
+	models := make([]data.Model, len(paths))
+	loaded := map[string]data.Model{}
+	for i, path := range paths {
+		if model, ok := loaded[path]; ok && path != "-" {
+			models[i] = model
+			continue
+		}
+
+		s.Options.Logger.Printf("Load model %v\n", path)
+		model, err := s.Reader.Read(path)
+		if err != nil {
+			return nil, err
+		}
+		if model.FaceCount() == 0 {
+			return nil, fmt.Errorf("the model %v does not contain any faces", path)
+		}
+		s.Options.Logger.Printf("Model loaded.\nFace count: %v\nSize: min: %v max %v\n", model.FaceCount(), model.Min(), model.Max())
+		models[i] = model
+		loaded[path] = model
+	}
+
+	if len(models) == 1 {
+		return models[0], nil
+	}
+	if len(loaded) == 1 && paths[0] != "-" {
+		return data.NewModelCopies(models[0], len(models)), nil
+	}
+	return data.NewModelGroup(models...), nil
+}
+
+// closeModels closes the models which keep their faces in temporary files, e.g. big models processed in layer windows.
+func (s *GoSlice) closeModels(models ...data.Model) {
+	for _, m := range models {
+		if group, ok := m.(data.ModelGroup); ok {
+			s.closeModels(group.Models()...)
+		}
+
+		if closer, ok := m.(io.Closer); ok {
+			err := closer.Close()
+			if err != nil {
+				s.Options.Logger.Printf("Could not remove the temporary files of the model: %v\n", err)
+			}
+		}
+	}
+}
+
+// Stats returns the statistics of the GCode generated last.
+// It fails if the generator does not provide statistics.
+func (s *GoSlice) Stats() (data.Stats, error) {
+	s.mutex.Lock()
+	defer s.mutex.Unlock()
+
+	return s.stats()
+}
+
+func (s *GoSlice) stats() (data.Stats, error) {
+	provider, ok := s.Generator.(handler.GCodeStatsProvider)
+	if !ok {
+		return data.Stats{}, errors.New("the generator does not provide statistics")
+	}
+
+	stats := provider.Stats()
+	stats.Model = s.modelReport
+	return stats, nil
+}
 
-	return err
+func (s *GoSlice) writeStats() error {
+	stats, err := s.stats()
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(s.Options.StatsFilePath)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	return data.WriteStats(file, stats)
+}
+
+// gradualInfillPercent returns the infill density of the given step of the gradual infill.
+// The density doubles with each step towards the top skins (step 1) up to at most 100%.
+func gradualInfillPercent(percent int, step int, steps int) int {
+	for i := step; i <= steps && percent < 100; i++ {
+		percent *= 2
+	}
+	if percent > 100 {
+		percent = 100
+	}
+	return percent
+}
+
+// withFirstLayer creates the pattern for the given line width.
+// If the lines of the first layer have another width, a separate pattern is used for it.
+func withFirstLayer(options *data.Options, pattern func(width data.Micrometer) clip.Pattern) clip.Pattern {
+	firstLayerWidth := options.Printer.LayerExtrusionWidth(0)
+	if firstLayerWidth == options.Printer.ExtrusionWidth {
+		return pattern(options.Printer.ExtrusionWidth)
+	}
+	return clip.NewFirstLayerPattern(pattern(firstLayerWidth), pattern(options.Printer.ExtrusionWidth))
+}
+
+// skinPatternFactories contains a factory for each of the data.SkinPatterns.
+var skinPatternFactories = map[string]func(options *data.Options, width data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern{
+	data.SkinPatternLines: func(options *data.Options, width data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		return clip.NewLinearPattern(width, width, min, max, degree, true, options.Print.SkinZigZag)
+	},
+	data.SkinPatternConcentric: func(options *data.Options, width data.Micrometer, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+		return clip.NewConcentricPattern(width, width)
+	},
+}
+
+// topBottomPatternFactory creates the skin pattern selected by the option Print.SkinPattern.
+// Unknown patterns, which are reported by data.Options.Validate, fall back to lines.
+func topBottomPatternFactory(options *data.Options, min data.MicroPoint, max data.MicroPoint, degree int) clip.Pattern {
+	factory, ok := skinPatternFactories[options.Print.SkinPattern]
+	if !ok {
+		factory = skinPatternFactories[data.SkinPatternLines]
+	}
+	return withFirstLayer(options, func(width data.Micrometer) clip.Pattern {
+		return factory(options, width, min, max, degree)
+	})
 }
@@ -1,8 +1,14 @@
 package goslice
 
 import (
+	"fmt"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/util/test"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 	"testing"
 )
 
@@ -29,6 +35,8 @@ func TestWholeSlicer(t *testing.T) {
 	// enable support so that it is tested also
 	o.Print.Support.Enabled = true
 	o.Print.BrimSkirt.BrimCount = 3
+	// the gcode files next to the models are overwritten on each run
+	o.GoSlice.Force = true
 	s := NewGoSlice(o)
 
 	var tests = []struct {
@@ -49,3 +57,486 @@ func TestWholeSlicer(t *testing.T) {
 		test.Ok(t, err)
 	}
 }
+
+// TestConcurrentRuns slices several models at the same time using runs of the same GoSlice.
+// It should also be run with -race to find data shared between the runs.
+func TestConcurrentRuns(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	o := data.DefaultOptions()
+	o.Print.Support.Enabled = true
+	o.Print.BrimSkirt.BrimCount = 3
+	s := NewGoSlice(o)
+
+	// the gopher is sliced twice, so that the results of concurrent runs can be compared
+	models := []string{benchy, gopher, gopher}
+	errs := make([]error, len(models))
+	var wg sync.WaitGroup
+	for i, model := range models {
+		run := s.NewRun()
+		run.Options.InputFilePath = folder + model
+		run.Options.OutputFilePath = filepath.Join(dir, fmt.Sprintf("%v.gcode", i))
+
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			errs[i] = run.Process()
+		}(i)
+	}
+	wg.Wait()
+
+	for _, err := range errs {
+		test.Ok(t, err)
+	}
+
+	first, err := ioutil.ReadFile(filepath.Join(dir, "1.gcode"))
+	test.Ok(t, err)
+	second, err := ioutil.ReadFile(filepath.Join(dir, "2.gcode"))
+	test.Ok(t, err)
+	test.Assert(t, string(first) == string(second), "concurrent runs of the same model should generate the same gcode")
+}
+
+func TestLayerWindow(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	o := data.DefaultOptions()
+	o.GoSlice.InputFilePath = folder + gopher
+	o.GoSlice.OutputFilePath = filepath.Join(dir, "all.gcode")
+	test.Ok(t, NewGoSlice(o).Process())
+
+	o.GoSlice.OutputFilePath = filepath.Join(dir, "window.gcode")
+	o.GoSlice.LayerWindow = 10
+	test.Ok(t, NewGoSlice(o).Process())
+
+	expected, err := ioutil.ReadFile(filepath.Join(dir, "all.gcode"))
+	test.Ok(t, err)
+	actual, err := ioutil.ReadFile(filepath.Join(dir, "window.gcode"))
+	test.Ok(t, err)
+	test.Assert(t, string(expected) == string(actual), "the gcode generated in layer windows should be the same as the gcode generated at once")
+}
+
+// cuboidSTL returns an ascii STL of the cuboid from min to max in millimeters.
+func cuboidSTL(x0, y0, z0, x1, y1, z1 float64) string {
+	quads := [][4][3]float64{
+		{{x0, y0, z0}, {x0, y1, z0}, {x1, y1, z0}, {x1, y0, z0}},
+		{{x0, y0, z1}, {x1, y0, z1}, {x1, y1, z1}, {x0, y1, z1}},
+		{{x0, y0, z0}, {x1, y0, z0}, {x1, y0, z1}, {x0, y0, z1}},
+		{{x0, y1, z0}, {x0, y1, z1}, {x1, y1, z1}, {x1, y1, z0}},
+		{{x0, y0, z0}, {x0, y0, z1}, {x0, y1, z1}, {x0, y1, z0}},
+		{{x1, y0, z0}, {x1, y1, z0}, {x1, y1, z1}, {x1, y0, z1}},
+	}
+
+	stl := "solid cuboid\n"
+	for _, q := range quads {
+		for _, face := range [][3][3]float64{{q[0], q[1], q[2]}, {q[0], q[2], q[3]}} {
+			stl += "facet normal 0 0 0\nouter loop\n"
+			for _, p := range face {
+				stl += fmt.Sprintf("vertex %v %v %v\n", p[0], p[1], p[2])
+			}
+			stl += "endloop\nendfacet\n"
+		}
+	}
+	return stl + "endsolid cuboid\n"
+}
+
+func TestMaterials(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	// the second body stands on the right half of the first one
+	lower := filepath.Join(dir, "lower.stl")
+	upper := filepath.Join(dir, "upper.stl")
+	test.Ok(t, ioutil.WriteFile(lower, []byte(cuboidSTL(0, 0, 0, 20, 20, 5)), 0644))
+	test.Ok(t, ioutil.WriteFile(upper, []byte(cuboidSTL(10, 0, 5, 20, 20, 10)), 0644))
+
+	o := data.DefaultOptions()
+	o.GoSlice.InputFilePaths = []string{lower, upper}
+	o.GoSlice.OutputFilePath = filepath.Join(dir, "materials.gcode")
+	o.Print.ModelExtruders = []int{0, 1}
+	o.Print.Interlocking.Enabled = true
+	o.Print.Support.Enabled = true
+	test.Ok(t, NewGoSlice(o).Process())
+
+	gcode, err := ioutil.ReadFile(filepath.Join(dir, "materials.gcode"))
+	test.Ok(t, err)
+	result := string(gcode)
+
+	// the models are sliced together
+	test.Equals(t, 1, strings.Count(result, ";LAYER:0\n"))
+
+	// both extruders print in the interlocking layers around the boundary, only the second one above
+	boundary := strings.Index(result, ";LAYER:24\n")
+	test.Assert(t, strings.Contains(result[:boundary], "\nT1\n"), "the second extruder should print the beams below the boundary")
+	top := strings.Index(result, ";LAYER:40\n")
+	test.Assert(t, !strings.Contains(result[top:], "\nT0\n"), "only the second extruder should print above the interlocking layers")
+}
+
+func TestSkinZigZag(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	// a flat plate which consists only of top and bottom skin
+	plate := filepath.Join(dir, "plate.stl")
+	test.Ok(t, ioutil.WriteFile(plate, []byte(cuboidSTL(0, 0, 0, 20, 20, 1)), 0644))
+
+	// a plate on a thin pillar, so that the bottom of the plate rests on support
+	table := filepath.Join(dir, "table.stl")
+	test.Ok(t, ioutil.WriteFile(table, []byte(
+		strings.TrimSuffix(cuboidSTL(8, 8, 0, 12, 12, 3), "endsolid cuboid\n")+
+			strings.TrimPrefix(cuboidSTL(0, 0, 3, 20, 20, 4), "solid cuboid\n"),
+	), 0644))
+
+	var testCases = map[string]struct {
+		model   string
+		support bool
+		// skin is the comment which starts the checked skin, skinLayers the amount of layers which contain it
+		skin       string
+		skinLayers int
+		zigZag     bool
+		// maxTravels and minTravels limit the travel moves between the skin lines of each layer
+		maxTravels int
+		minTravels int
+	}{
+		"separate lines": {
+			model:      plate,
+			skin:       ";TYPE:FILL\n",
+			skinLayers: 5,
+			zigZag:     false,
+			minTravels: 20,
+			maxTravels: 100,
+		},
+		"lines chained along the border": {
+			model:      plate,
+			skin:       ";TYPE:FILL\n",
+			skinLayers: 5,
+			zigZag:     true,
+			minTravels: 0,
+			maxTravels: 2,
+		},
+		"separate lines of the supported bottom": {
+			model:      table,
+			support:    true,
+			skin:       ";SUPPORTED-BOTTOM-FILL\n",
+			skinLayers: 1,
+			zigZag:     false,
+			minTravels: 20,
+			maxTravels: 100,
+		},
+		"supported bottom lines chained along the border": {
+			model:      table,
+			support:    true,
+			skin:       ";SUPPORTED-BOTTOM-FILL\n",
+			skinLayers: 1,
+			zigZag:     true,
+			minTravels: 0,
+			// the pillar splits the border of the supported bottom
+			maxTravels: 4,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		o := data.DefaultOptions()
+		o.GoSlice.InputFilePath = testCase.model
+		o.GoSlice.OutputFilePath = filepath.Join(dir, "skin.gcode")
+		o.GoSlice.Force = true
+		o.Print.Support.Enabled = testCase.support
+		o.Print.SkinZigZag = testCase.zigZag
+		test.Ok(t, NewGoSlice(o).Process())
+
+		gcode, err := ioutil.ReadFile(filepath.Join(dir, "skin.gcode"))
+		test.Ok(t, err)
+
+		skinLayers := 0
+		for layerNr, layer := range strings.Split(string(gcode), ";LAYER:")[1:] {
+			sections := strings.Split(layer, testCase.skin)
+			if len(sections) == 1 {
+				continue
+			}
+			test.Equals(t, 2, len(sections))
+			skinLayers++
+			skin := strings.Split(sections[1], ";TYPE:")[0]
+
+			travels := strings.Count(skin, "\nG0 ")
+			test.Assert(t, travels >= testCase.minTravels && travels <= testCase.maxTravels, "layer %v: %v travel moves between the skin lines are not between %v and %v", layerNr, travels, testCase.minTravels, testCase.maxTravels)
+		}
+		test.Equals(t, testCase.skinLayers, skinLayers)
+	}
+}
+
+// modelReader is a handler.ModelReader which returns the model of each path.
+type modelReader map[string]data.Model
+
+func (r modelReader) Read(filename string) (data.Model, error) {
+	return r[filename], nil
+}
+
+func TestReadModels(t *testing.T) {
+	reader := modelReader{
+		"empty.stl": data.NewModelGroup(),
+		"other.stl": data.NewModelGroup(),
+	}
+
+	var testCases = map[string]struct {
+		paths         []string
+		expectedError string
+	}{
+		"single empty model": {
+			paths:         []string{"empty.stl"},
+			expectedError: "the model empty.stl does not contain any faces",
+		},
+		"group of empty models": {
+			paths:         []string{"other.stl", "empty.stl"},
+			expectedError: "the model other.stl does not contain any faces",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		o := data.DefaultOptions()
+		o.GoSlice.InputFilePaths = testCase.paths
+		s := NewGoSlice(o)
+		s.Reader = reader
+
+		_, err := s.readModels()
+		test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected, got %v", err)
+	}
+}
+
+func TestCheckOutputPath(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	existing := filepath.Join(dir, "existing.gcode")
+	test.Ok(t, ioutil.WriteFile(existing, []byte("G28"), 0644))
+
+	var testCases = map[string]struct {
+		output        string
+		force         bool
+		expectedError string
+	}{
+		"new file": {
+			output: filepath.Join(dir, "new.gcode"),
+		},
+		"existing file": {
+			output:        existing,
+			expectedError: "already exists",
+		},
+		"existing file with force": {
+			output: existing,
+			force:  true,
+		},
+		"input model": {
+			output:        folder + gopher,
+			force:         true,
+			expectedError: "is the input model",
+		},
+		"input model by another path": {
+			output:        "./test_stl/../test_stl/" + gopher,
+			force:         true,
+			expectedError: "is the input model",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		o := data.DefaultOptions()
+		o.GoSlice.InputFilePath = folder + gopher
+		o.GoSlice.Force = testCase.force
+
+		err := NewGoSlice(o).checkOutputPath(testCase.output)
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected, got %v", err)
+		} else {
+			test.Ok(t, err)
+		}
+	}
+}
+
+func TestSaveAndLoadLayers(t *testing.T) {
+	dir, err := ioutil.TempDir("", "goslice")
+	test.Ok(t, err)
+	defer os.RemoveAll(dir)
+
+	overrides := filepath.Join(dir, "overrides.yaml")
+	test.Ok(t, ioutil.WriteFile(overrides, []byte("- layers: 10-20\n  settings:\n    layer-speed: 30\n"), 0644))
+
+	// the features are split, as some of them exclude each other
+	var testCases = map[string]func(o *data.Options){
+		"perimeters and infill": func(o *data.Options) {
+			o.Print.ThinWalls = true
+			o.Print.GapFill = true
+			o.Print.VariableWidthPerimeters = true
+			o.Print.OverhangPerimeter.Enabled = true
+			o.Print.Bridge.Enabled = true
+			o.Print.SkinSupportLayers = 2
+			o.Print.GradualInfillSteps = 2
+			o.Print.SolidInfillEvery = 10
+			o.Print.InfillPattern = "cubic"
+			o.Print.Support.Enabled = true
+			o.Print.Support.FloorLayers = 2
+			o.Print.Support.SupportedBottomDensity = 50
+			o.Print.BrimSkirt.BrimCount = 2
+			o.Print.BrimSkirt.BrimType = "both"
+			o.Print.BrimSkirt.BrimEars = true
+			o.Print.OozeShield.Enabled = true
+			o.GoSlice.LayerOverridesFilePath = overrides
+		},
+		"overlapping perimeters and tree support": func(o *data.Options) {
+			o.Print.PerimeterOverlapCompensation = true
+			o.Print.InfillCombineLayers = 2
+			o.Print.Support.Enabled = true
+			o.Print.Support.Type = "tree"
+		},
+		"spiralize": func(o *data.Options) {
+			o.Print.Spiralize = true
+		},
+		"surface mode": func(o *data.Options) {
+			o.Slicing.SurfaceMode = "surface"
+		},
+	}
+
+	for testName, setOptions := range testCases {
+		t.Log("testCase:", testName)
+		o := data.DefaultOptions()
+		setOptions(&o)
+		o.GoSlice.InputFilePath = folder + gopher
+		o.GoSlice.OutputFilePath = filepath.Join(dir, "sliced.gcode")
+		o.GoSlice.SaveLayersFilePath = filepath.Join(dir, "layers")
+		o.GoSlice.Force = true
+		test.Ok(t, NewGoSlice(o).Process())
+
+		o.GoSlice.OutputFilePath = filepath.Join(dir, "loaded.gcode")
+		o.GoSlice.SaveLayersFilePath = ""
+		o.GoSlice.LoadLayersFilePath = filepath.Join(dir, "layers")
+		test.Ok(t, NewGoSlice(o).Process())
+
+		expected, err := ioutil.ReadFile(filepath.Join(dir, "sliced.gcode"))
+		test.Ok(t, err)
+		actual, err := ioutil.ReadFile(filepath.Join(dir, "loaded.gcode"))
+		test.Ok(t, err)
+		test.Assert(t, string(expected) == string(actual), "the gcode generated from the loaded layers should be the same as the gcode generated from the sliced layers")
+	}
+}
+
+func TestGradualInfillPercent(t *testing.T) {
+	var testCases = map[string]struct {
+		percent  int
+		step     int
+		steps    int
+		expected int
+	}{
+		"nearest step to the top skins": {
+			percent:  10,
+			step:     1,
+			steps:    3,
+			expected: 80,
+		},
+		"middle step": {
+			percent:  10,
+			step:     2,
+			steps:    3,
+			expected: 40,
+		},
+		"lowest step": {
+			percent:  10,
+			step:     3,
+			steps:    3,
+			expected: 20,
+		},
+		"limited to 100%": {
+			percent:  30,
+			step:     1,
+			steps:    3,
+			expected: 100,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, gradualInfillPercent(testCase.percent, testCase.step, testCase.steps))
+	}
+}
+
+func TestTopBottomPatternFactory(t *testing.T) {
+	square := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 10000),
+		data.NewMicroPoint(0, 10000),
+	}, nil)
+
+	var testCases = map[string]struct {
+		pattern         string
+		firstLayerWidth data.Micrometer
+		layerNr         int
+		concentric      bool
+		// expectedMinX is the min x of the first loop of a concentric pattern
+		expectedMinX data.Micrometer
+	}{
+		"lines": {
+			pattern: data.SkinPatternLines,
+		},
+		"concentric": {
+			pattern:      data.SkinPatternConcentric,
+			layerNr:      1,
+			concentric:   true,
+			expectedMinX: 200,
+		},
+		"concentric on the first layer": {
+			pattern:         data.SkinPatternConcentric,
+			firstLayerWidth: 600,
+			layerNr:         0,
+			concentric:      true,
+			expectedMinX:    300,
+		},
+		"concentric above the first layer": {
+			pattern:         data.SkinPatternConcentric,
+			firstLayerWidth: 600,
+			layerNr:         1,
+			concentric:      true,
+			expectedMinX:    200,
+		},
+		"unknown pattern uses lines": {
+			pattern: "grid",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Printer.ExtrusionWidth = 400
+		options.Printer.FirstLayerExtrusionWidth = testCase.firstLayerWidth
+		options.Print.SkinPattern = testCase.pattern
+
+		min, max := square.Outline().Bounds()
+		paths, err := topBottomPatternFactory(&options, min, max, 45).Fill(testCase.layerNr, square)
+		test.Ok(t, err)
+		test.Assert(t, len(paths) > 0, "the square should be filled")
+
+		for _, path := range paths {
+			// only the loops of the concentric pattern end at their start point
+			test.Equals(t, testCase.concentric, path[0] == path[len(path)-1])
+		}
+		if testCase.concentric {
+			loopMin, _ := paths[0].Bounds()
+			test.Equals(t, testCase.expectedMinX, loopMin.X())
+		}
+	}
+}
+
+func TestSkinPatternFactories(t *testing.T) {
+	// every skin pattern accepted by the options has to be created by the factory
+	test.Equals(t, len(data.SkinPatterns), len(skinPatternFactories))
+	for _, name := range data.SkinPatterns {
+		_, ok := skinPatternFactories[name]
+		test.Assert(t, ok, "the skin pattern %v has no factory", name)
+	}
+}
@@ -2,7 +2,10 @@
 
 package handler
 
-import "github.com/aligator/goslice/data"
+import (
+	"github.com/aligator/goslice/data"
+	"io"
+)
 
 type Namer interface {
 	GetName() string
@@ -21,6 +24,16 @@ type ModelReader interface {
 	Read(filename string) (data.Model, error)
 }
 
+// ModelStreamReader reads a model in the given format from an io.Reader.
+type ModelStreamReader interface {
+	ReadStream(r io.Reader, format string) (data.Model, error)
+}
+
+// ModelRepairer can repair errors of a model, such as holes or inverted faces, before it is optimized.
+type ModelRepairer interface {
+	Repair(m data.Model) (data.Model, error)
+}
+
 // ModelOptimizer can optimize a model and generates an optimized model out of it.
 type ModelOptimizer interface {
 	Optimize(m data.Model) (data.OptimizedModel, error)
@@ -31,6 +44,16 @@ type ModelSlicer interface {
 	Slice(m data.OptimizedModel) ([]data.PartitionedLayer, error)
 }
 
+// ModelRangeSlicer can slice only some of the layers of a model.
+// It can be implemented by a ModelSlicer to allow processing the layers in windows.
+type ModelRangeSlicer interface {
+	// LayerCount returns the amount of layers of the model.
+	LayerCount(m data.OptimizedModel) int
+
+	// SliceRange returns the layers from the layer number from (inclusive) to the layer number to (exclusive).
+	SliceRange(m data.OptimizedModel, from, to int) ([]data.PartitionedLayer, error)
+}
+
 // LayerModifier can add new attributes to the layers or even alter the layer directly.
 type LayerModifier interface {
 	Namer
@@ -38,6 +61,25 @@ type LayerModifier interface {
 	Modify(layers []data.PartitionedLayer) error
 }
 
+// LayerContextModifier tells how many layers a LayerModifier needs to modify a layer.
+// It can be implemented by a LayerModifier to allow processing the layers in windows.
+// Modifiers which do not implement it are expected to need all layers.
+type LayerContextModifier interface {
+	// LayerContext returns the amount of layers below and above a layer which are needed to modify it.
+	// -1 means that all layers are needed.
+	LayerContext() int
+}
+
+// MaterialModifier modifies the layers of the bodies printed by different extruders together (see data.MaterialModel),
+// e.g. at the boundaries between them. It runs before the LayerModifiers modify the layers of each extruder.
+type MaterialModifier interface {
+	Namer
+
+	// Modify modifies the sliced layers of the bodies of each extruder.
+	// The index is the number of the extruder, the layers of unused extruders are nil.
+	Modify(materials [][]data.PartitionedLayer) error
+}
+
 // GCodeGenerator generates the GCode out of the given layers.
 // The layers are already modified by the layer modifiers.
 // So the attributes added by them can be used.
@@ -46,7 +88,44 @@ type GCodeGenerator interface {
 	Generate(layer []data.PartitionedLayer) (string, error)
 }
 
+// GCodeRangeGenerator generates the GCode of some of the layers.
+// It can be implemented by a GCodeGenerator to allow processing the layers in windows.
+type GCodeRangeGenerator interface {
+	// GenerateRange generates the GCode of the layers from the layer number from (inclusive) to the layer number to (exclusive).
+	// It has to be called for consecutive ranges starting at layer 0. The layers outside of the range may be empty.
+	GenerateRange(layers []data.PartitionedLayer, from, to int) (string, error)
+}
+
+// GCodeSequenceGenerator generates the GCode of several objects which are printed one after the other.
+// It can be implemented by a GCodeGenerator to allow sequential printing (see data.SequentialModel).
+type GCodeSequenceGenerator interface {
+	// GenerateSequence generates the GCode of the objects in the given order.
+	// The layers contain the modified layers of each object.
+	GenerateSequence(objects []data.OptimizedModel, layers [][]data.PartitionedLayer) (string, error)
+}
+
+// GCodeMaterialGenerator generates the GCode of a model whose bodies are printed by different extruders.
+// It can be implemented by a GCodeGenerator to allow multi material prints (see data.MaterialModel).
+type GCodeMaterialGenerator interface {
+	// GenerateMaterials generates the GCode of the given layers of the whole model, which contain the structures
+	// around the bodies, and of the layers of the bodies of each extruder.
+	// The index of the bodies is the number of the extruder, the layers of unused extruders are nil.
+	GenerateMaterials(layers []data.PartitionedLayer, materials [][]data.PartitionedLayer) (string, error)
+}
+
+// GCodeStatsProvider provides statistics about the GCode generated last.
+// It can be implemented by a GCodeGenerator.
+type GCodeStatsProvider interface {
+	Stats() data.Stats
+}
+
 // GCodeWriter writes the given GCode into the given destination.
 type GCodeWriter interface {
 	Write(gcode string, destination string) error
 }
+
+// GCodeStreamWriter opens a destination to write the GCode in several parts.
+// It can be implemented by a GCodeWriter to allow processing the layers in windows.
+type GCodeStreamWriter interface {
+	Open(destination string) (io.WriteCloser, error)
+}
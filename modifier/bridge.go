@@ -0,0 +1,49 @@
+// This file provides the detection of overhangs which can be bridged.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// bridgeAnchors returns the areas of the layer below on which the overhang part rests.
+// The part is grown by the anchor distance before, as it usually does not touch the layer below exactly.
+func bridgeAnchors(c clip.Clipper, part data.LayerPart, below []data.LayerPart, anchorDistance data.Micrometer) ([]data.LayerPart, error) {
+	grown := c.Inset(part, 0, 1, anchorDistance)
+	if len(grown) == 0 {
+		return nil, nil
+	}
+
+	anchors, ok := c.Intersection(grown[0], below)
+	if !ok {
+		return nil, errors.New("could not calculate the anchors of the overhang")
+	}
+	return anchors, nil
+}
+
+// isAnchoredOnBothEnds returns true if the anchors hold the overhang on at least two sides,
+// which is the case for several separate anchors or one anchor surrounding the overhang.
+func isAnchoredOnBothEnds(anchors []data.LayerPart) bool {
+	return len(anchors) >= 2 || (len(anchors) == 1 && len(anchors[0].Holes()) > 0)
+}
+
+// isShortBridge returns true if the overhang part can be bridged: it has to be anchored on both ends
+// and no point of it may be further away from the layer below than half of the max bridge length.
+// reach has to contain the layer below grown by half of the max bridge length.
+func isShortBridge(c clip.Clipper, part data.LayerPart, below []data.LayerPart, reach []data.LayerPart, anchorDistance data.Micrometer) (bool, error) {
+	rest, ok := c.Difference([]data.LayerPart{part}, reach)
+	if !ok {
+		return false, errors.New("could not calculate the span of the overhang")
+	}
+	if len(rest) > 0 {
+		return false, nil
+	}
+
+	anchors, err := bridgeAnchors(c, part, below, anchorDistance)
+	if err != nil {
+		return false, err
+	}
+	return isAnchoredOnBothEnds(anchors), nil
+}
@@ -0,0 +1,196 @@
+// This file provides the detection of bridges and of the direction they are printed in.
+
+package modifier
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// bridgeAngleStep is the step in degree in which the directions of the bridges are tried.
+const bridgeAngleStep = 10
+
+// Bridge is a part of the bottom skin which does not rest on the layer below.
+type Bridge struct {
+	// Part is the area of the bridge including its anchors on the layer below.
+	Part data.LayerPart
+
+	// Angle is the direction of the bridge lines in degree in the same way as the degree of clip.NewLinearPattern.
+	Angle int
+}
+
+// Bridges extracts the attribute "bridges" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func Bridges(layer data.PartitionedLayer) ([]Bridge, error) {
+	if attr, ok := layer.Attributes()["bridges"]; ok {
+		bridges, ok := attr.([]Bridge)
+		if !ok {
+			return nil, errors.New("the attribute bridges has the wrong datatype")
+		}
+
+		return bridges, nil
+	}
+
+	return nil, nil
+}
+
+type bridgeModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m bridgeModifier) Init(model data.OptimizedModel) {}
+
+func (m bridgeModifier) LayerContext() int {
+	return 1
+}
+
+// NewBridgeModifier moves the parts of the "bottom" attribute which do not rest on the layer below
+// to the attribute "bridges" (see Bridges), so that they can be printed with the bridge settings.
+// Each bridge is extended onto the layer below by the extrusion width, so that its lines are anchored there,
+// and gets the direction in which most of its lines are anchored on both ends.
+// It has to run after the supported bottom modifier, so that bottoms resting on support are not bridged.
+func NewBridgeModifier(options *data.Options) handler.LayerModifier {
+	return &bridgeModifier{
+		Named: handler.Named{
+			Name: "Bridge",
+		},
+		options: options,
+	}
+}
+
+func (m bridgeModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.Bridge.Enabled {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+
+	// each layer only reads the layer below, so the layers are processed in parallel
+	newLayers := make([]data.PartitionedLayer, len(layers))
+	err := data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
+		if layerNr == 0 {
+			return nil
+		}
+
+		bottom, err := BottomInfill(layers[layerNr])
+		if err != nil {
+			return err
+		}
+		if len(bottom) == 0 {
+			return nil
+		}
+
+		below := layers[layerNr-1].LayerParts()
+		unsupported, ok := c.Difference(bottom, below)
+		if !ok {
+			return fmt.Errorf("could not calculate the unsupported bottom of layer %d", layerNr)
+		}
+
+		width := m.options.Printer.LayerExtrusionWidth(layerNr)
+		var bridges []Bridge
+		var bridgeParts []data.LayerPart
+		for _, part := range unsupported {
+			// areas which are too narrow for a line are left in the bottom skin
+			if len(c.Inset(part, 0, 1, -width/2)[0]) == 0 {
+				continue
+			}
+
+			// extend the bridge onto the layer below, but only within the bottom skin
+			anchored, ok := c.Intersection(c.Inset(part, 0, 1, width)[0], bottom)
+			if !ok {
+				return fmt.Errorf("could not anchor the bridge of layer %d", layerNr)
+			}
+
+			for _, bridge := range anchored {
+				angle, err := bridgeAngle(c, bridge, below, width)
+				if err != nil {
+					return err
+				}
+				bridges = append(bridges, Bridge{
+					Part:  bridge,
+					Angle: angle,
+				})
+				bridgeParts = append(bridgeParts, bridge)
+			}
+		}
+		if len(bridges) == 0 {
+			return nil
+		}
+
+		remaining, ok := c.Difference(bottom, bridgeParts)
+		if !ok {
+			return fmt.Errorf("could not subtract the bridges from the bottom of layer %d", layerNr)
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["bottom"] = remaining
+		newLayer.attributes["bridges"] = bridges
+		newLayers[layerNr] = newLayer
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for layerNr, newLayer := range newLayers {
+		if newLayer != nil {
+			layers[layerNr] = newLayer
+		}
+	}
+
+	return nil
+}
+
+// bridgeAngle returns the direction of the lines which bridge the part best.
+// The directions are tried in steps of bridgeAngleStep. The length of each line which rests on the anchors
+// of the part (see bridgeAnchors) on both ends counts positive and the length of all other lines negative,
+// the direction with the best score wins.
+func bridgeAngle(c clip.Clipper, part data.LayerPart, below []data.LayerPart, width data.Micrometer) (int, error) {
+	anchors, err := bridgeAnchors(c, part, below, width)
+	if err != nil {
+		return 0, err
+	}
+
+	min, max := part.Outline().Bounds()
+
+	bestAngle := 0
+	var bestScore data.Micrometer
+	for angle := 0; angle < 180; angle += bridgeAngleStep {
+		// the lines are only used for the score, so every second line is enough
+		lines, err := clip.NewLinearPattern(width, 2*width, min, max, angle, false, false).Fill(0, part)
+		if err != nil {
+			continue
+		}
+
+		var score data.Micrometer
+		for _, line := range lines {
+			if len(line) < 2 {
+				continue
+			}
+			start, end := line[0], line[len(line)-1]
+			length := end.Sub(start).Size()
+			if length == 0 {
+				continue
+			}
+
+			// look half a line width beyond the ends, as the ends lie within the anchors
+			direction := end.Sub(start).Mul(width).Div(length)
+			if insideParts(anchors, start.Sub(direction.Div(2))) && insideParts(anchors, end.Add(direction.Div(2))) {
+				score += length
+			} else {
+				score -= length
+			}
+		}
+
+		if angle == 0 || score > bestScore {
+			bestAngle, bestScore = angle, score
+		}
+	}
+
+	return bestAngle, nil
+}
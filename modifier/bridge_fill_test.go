@@ -0,0 +1,80 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestBridgeAngle(t *testing.T) {
+	var testCases = map[string]struct {
+		part     data.LayerPart
+		below    []data.LayerPart
+		expected int
+	}{
+		"anchored left and right": {
+			part: rectanglePart(0, 0, 10000, 3000),
+			below: []data.LayerPart{
+				rectanglePart(-5000, -1000, 1000, 4000),
+				rectanglePart(9000, -1000, 15000, 4000),
+			},
+			// the lines of the angle 0 run along the y axis
+			expected: 90,
+		},
+		"anchored at the bottom and the top": {
+			part: rectanglePart(0, 0, 3000, 10000),
+			below: []data.LayerPart{
+				rectanglePart(-1000, -5000, 4000, 1000),
+				rectanglePart(-1000, 9000, 4000, 15000),
+			},
+			expected: 0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		angle, err := bridgeAngle(clip.NewClipper(), testCase.part, testCase.below, 400)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, angle)
+	}
+}
+
+func TestBridgeModifier(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.Bridge.Enabled = true
+	width := options.Printer.LayerExtrusionWidth(1)
+
+	// a slab resting on two pillars
+	slab := rectanglePart(0, 0, 20000, 3000)
+	testLayers := layers(
+		[]data.LayerPart{rectanglePart(0, 0, 5000, 3000), rectanglePart(15000, 0, 20000, 3000)},
+		[]data.LayerPart{slab},
+	)
+	layer := newExtendedLayer(testLayers[1])
+	layer.attributes["bottom"] = []data.LayerPart{slab}
+	testLayers[1] = layer
+
+	err := NewBridgeModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+
+	bridges, err := Bridges(testLayers[1])
+	test.Ok(t, err)
+	test.Equals(t, 1, len(bridges))
+	test.Equals(t, 90, bridges[0].Angle)
+
+	// the bridge spans the gap and is anchored on both pillars
+	min, max := partsBounds([]data.LayerPart{bridges[0].Part})
+	test.Equals(t, data.NewMicroPoint(5000-width, 0), min, microPointComparer())
+	test.Equals(t, data.NewMicroPoint(15000+width, 3000), max, microPointComparer())
+
+	// the rest of the bottom stays on the pillars
+	bottom, err := BottomInfill(testLayers[1])
+	test.Ok(t, err)
+	test.Equals(t, 2, len(bottom))
+
+	// the first layer cannot be a bridge
+	bridges, err = Bridges(testLayers[0])
+	test.Ok(t, err)
+	test.Equals(t, 0, len(bridges))
+}
@@ -0,0 +1,65 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestIsShortBridge(t *testing.T) {
+	pillars := []data.LayerPart{
+		rectanglePart(0, 0, 5000, 3000),
+		rectanglePart(15000, 0, 20000, 3000),
+	}
+	// the gap between the pillars is 10 mm long
+	gap := rectanglePart(5000, 0, 15000, 3000)
+
+	var testCases = map[string]struct {
+		part            data.LayerPart
+		below           []data.LayerPart
+		maxBridgeLength data.Micrometer
+		expected        bool
+	}{
+		"anchored on both ends": {
+			part:            gap,
+			below:           pillars,
+			maxBridgeLength: 12000,
+			expected:        true,
+		},
+		"longer than the max bridge length": {
+			part:            gap,
+			below:           pillars,
+			maxBridgeLength: 8000,
+			expected:        false,
+		},
+		"anchored on one end": {
+			part:            gap,
+			below:           pillars[:1],
+			maxBridgeLength: 40000,
+			expected:        false,
+		},
+		"surrounded by the anchor": {
+			part:            rectanglePart(5000, 5000, 10000, 10000),
+			below:           []data.LayerPart{data.NewBasicLayerPart(rectangle(0, 0, 15000, 15000), data.Paths{rectangle(5000, 5000, 10000, 10000).Reversed()})},
+			maxBridgeLength: 12000,
+			expected:        true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		c := clip.NewClipper()
+
+		var reach []data.LayerPart
+		for _, part := range testCase.below {
+			var ok bool
+			reach, ok = c.Union(reach, c.Inset(part, 0, 1, testCase.maxBridgeLength/2)[0])
+			test.Assert(t, ok, "the reach should be merged")
+		}
+
+		isBridge, err := isShortBridge(c, testCase.part, testCase.below, reach, 400)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, isBridge)
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
+	"math"
 )
 
 type brimModifier struct {
@@ -14,6 +15,10 @@ type brimModifier struct {
 
 func (m brimModifier) Init(model data.OptimizedModel) {}
 
+func (m brimModifier) LayerContext() int {
+	return 0
+}
+
 // NewBrimModifier generates the brim lines.
 // The brim is basically a surrounding of the objects on the first layer
 // by several lines which directly contact the object
@@ -24,6 +29,12 @@ func (m brimModifier) Init(model data.OptimizedModel) {}
 // Additionally the attribute "outerBrim" is generated to make it more easy
 // for other modifiers and renderers to clip with the brim to avoid overlapping.
 // "outerBrim" just contains the outline of the brim (taking into account the line width also).
+//
+// Depending on the brim type, the brim is generated around the objects, inside their holes or both.
+// The brim inside the holes is set as the attribute "innerBrim" and is also contained in "outerBrim".
+// If brim ears are enabled, the brim is only printed at the sharp outer corners of the objects.
+// For this the attribute "brimEars" contains a disc with twice the brim width as radius at each of these corners
+// and the lines of the brim have to be clipped by them. "outerBrim" then only contains the ears.
 func NewBrimModifier(options *data.Options) handler.LayerModifier {
 	return &brimModifier{
 		Named: handler.Named{
@@ -70,6 +81,30 @@ func BrimOuterDimension(layer data.PartitionedLayer) ([]data.LayerPart, error) {
 	return nil, nil
 }
 
+// InnerBrim extracts the attribute "innerBrim" from the layer.
+// It contains one part for each hole with the brim lines starting at the hole.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func InnerBrim(layer data.PartitionedLayer) (clip.OffsetResult, error) {
+	if attr, ok := layer.Attributes()["innerBrim"]; ok {
+		parts, ok := attr.(clip.OffsetResult)
+		if !ok {
+			return nil, fmt.Errorf("the attribute 'innerBrim' has the wrong datatype")
+		}
+
+		return parts, nil
+	}
+
+	return nil, nil
+}
+
+// BrimEars extracts the attribute "brimEars" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func BrimEars(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "brimEars")
+}
+
 func (m brimModifier) Modify(layers []data.PartitionedLayer) error {
 	if m.options.Print.BrimSkirt.BrimCount == 0 {
 		return nil
@@ -98,7 +133,19 @@ func (m brimModifier) Modify(layers []data.PartitionedLayer) error {
 		}
 	}
 
-	cl := clip.NewClipper()
+	cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	width := m.options.Printer.LayerExtrusionWidth(0)
+	brimType := m.options.Print.BrimSkirt.BrimType
+
+	var innerBrim clip.OffsetResult
+	var innerBrimArea []data.LayerPart
+	if brimType == "inner" || brimType == "both" {
+		var ok bool
+		innerBrim, innerBrimArea, ok = m.innerBrim(cl, perimeters, width)
+		if !ok {
+			return fmt.Errorf("could not generate the brim inside the holes")
+		}
+	}
 
 	// Get the top level polys e.g. the polygons which are not inside another.
 	topLevelPerimeters, _ := cl.TopLevelPolygons(allOuterPerimeters)
@@ -112,28 +159,61 @@ func (m brimModifier) Modify(layers []data.PartitionedLayer) error {
 		return nil
 	}
 
-	// Generate the brim.
-	brim := cl.InsetLayer(allOuterPerimeters, -m.options.Printer.ExtrusionWidth, m.options.Print.BrimSkirt.BrimCount, m.options.Printer.ExtrusionWidth)
+	var brim clip.OffsetResult
+	var outerBrim []data.LayerPart
+	var ears []data.LayerPart
+	if brimType != "inner" {
+		// Generate the brim.
+		brim = cl.InsetLayer(allOuterPerimeters, -width, m.options.Print.BrimSkirt.BrimCount, width)
 
-	// Now we need to generate the outer bounds of the brim (e.g. outer brim line + half line width)
-	// That is needed for the support, to remove the support at the places where the brim is.
-	var outerBrimLines []data.LayerPart
-	// For this we first get only the most outer brim lines.
-	for _, part := range brim {
-		if len(part) == 0 {
-			continue
+		// Now we need to generate the outer bounds of the brim (e.g. outer brim line + half line width)
+		// That is needed for the support, to remove the support at the places where the brim is.
+		var outerBrimLines []data.LayerPart
+		// For this we first get only the most outer brim lines.
+		for _, part := range brim {
+			if len(part) == 0 {
+				continue
+			}
+			for _, insetPart := range part[len(part)-1] {
+				outerBrimLines = append(outerBrimLines, insetPart)
+			}
 		}
-		for _, insetPart := range part[len(part)-1] {
-			outerBrimLines = append(outerBrimLines, insetPart)
+
+		// Then the outer brim lines are exset so that the result matches the exact dimension taking into account the extrusion width.
+		outerBrim = cl.InsetLayer(outerBrimLines, -width, 1, width/2).ToOneDimension()
+
+		if m.options.Print.BrimSkirt.BrimEars {
+			var ok bool
+			ears, ok = m.ears(cl, allOuterPerimeters, width)
+			if !ok {
+				return fmt.Errorf("could not generate the brim ears")
+			}
+			outerBrim, ok = cl.Intersection(outerBrim, ears)
+			if !ok {
+				return fmt.Errorf("could not clip the brim by the brim ears")
+			}
 		}
 	}
 
-	// Then the outer brim lines are exset so that the result matches the exact dimension taking into account the extrusion width.
-	outerBrim := cl.InsetLayer(outerBrimLines, -m.options.Printer.ExtrusionWidth, 1, m.options.Printer.ExtrusionWidth/2).ToOneDimension()
+	for _, area := range innerBrimArea {
+		var ok bool
+		outerBrim, ok = cl.Union(outerBrim, []data.LayerPart{area})
+		if !ok {
+			return fmt.Errorf("could not merge the brim inside the holes into the brim")
+		}
+	}
 
 	newLayer := newExtendedLayer(layers[0])
 	if len(brim) > 0 {
-		newLayer.attributes["brim"] = append(brim)
+		newLayer.attributes["brim"] = brim
+	}
+
+	if len(innerBrim) > 0 {
+		newLayer.attributes["innerBrim"] = innerBrim
+	}
+
+	if len(ears) > 0 {
+		newLayer.attributes["brimEars"] = ears
 	}
 
 	if len(outerBrim) > 0 {
@@ -144,3 +224,91 @@ func (m brimModifier) Modify(layers []data.PartitionedLayer) error {
 
 	return nil
 }
+
+// innerBrim generates the brim lines inside the holes of the outermost perimeters.
+// It returns the lines of each hole starting at the hole and the areas covered by them.
+func (m brimModifier) innerBrim(cl clip.Clipper, perimeters clip.OffsetResult, width data.Micrometer) (clip.OffsetResult, []data.LayerPart, bool) {
+	var brim clip.OffsetResult
+	var areas []data.LayerPart
+	for _, part := range perimeters {
+		if len(part) == 0 {
+			continue
+		}
+		for _, perimeter := range part[0] {
+			for _, hole := range perimeter.Holes() {
+				// use the hole as outline and shrink it so that the lines are inside of it
+				if hole.Area() < 0 {
+					hole = hole.Reversed()
+				}
+				holePart := data.NewBasicLayerPart(hole, nil)
+				lines := cl.Inset(holePart, width, m.options.Print.BrimSkirt.BrimCount, -width)
+				// small holes may not have space for all lines
+				for len(lines) > 0 && len(lines[len(lines)-1]) == 0 {
+					lines = lines[:len(lines)-1]
+				}
+				if len(lines) == 0 {
+					continue
+				}
+				brim = append(brim, lines)
+
+				// the area is the hole without the part inside of the innermost line
+				inside := cl.InsetLayer(lines[len(lines)-1], width, 1, -width/2).ToOneDimension()
+				area, ok := cl.Difference([]data.LayerPart{holePart}, inside)
+				if !ok {
+					return nil, nil, false
+				}
+				areas = append(areas, area...)
+			}
+		}
+	}
+
+	return brim, areas, true
+}
+
+// ears returns a disc at each sharp convex corner of the given outlines.
+// A corner is sharp if its angle is smaller than the max angle of the brim ears.
+func (m brimModifier) ears(cl clip.Clipper, outlines []data.LayerPart, width data.Micrometer) ([]data.LayerPart, bool) {
+	radius := 2 * width * data.Micrometer(m.options.Print.BrimSkirt.BrimCount)
+	maxAngle := data.ToRadians(float64(m.options.Print.BrimSkirt.BrimEarsMaxAngle))
+
+	var ears []data.LayerPart
+	for _, part := range outlines {
+		outline := part.Outline()
+		if outline.Area() < 0 {
+			outline = outline.Reversed()
+		}
+		// small segments of curves should not count as corners
+		outline = outline.Simplify(width*width, width*width/4)
+
+		for i, corner := range outline {
+			previous := outline[(i+len(outline)-1)%len(outline)]
+			next := outline[(i+1)%len(outline)]
+			in := corner.Sub(previous)
+			out := next.Sub(corner)
+
+			// only convex corners of the counter clockwise outline turn left
+			cross := float64(in.X())*float64(out.Y()) - float64(in.Y())*float64(out.X())
+			if cross <= 0 {
+				continue
+			}
+
+			toPrevious := previous.Sub(corner)
+			if toPrevious.Size() == 0 || out.Size() == 0 {
+				continue
+			}
+			dot := float64(toPrevious.X())*float64(out.X()) + float64(toPrevious.Y())*float64(out.Y())
+			angle := math.Acos(math.Max(-1, math.Min(1, dot/(float64(toPrevious.Size())*float64(out.Size())))))
+			if angle >= maxAngle {
+				continue
+			}
+
+			var ok bool
+			ears, ok = cl.Union(ears, []data.LayerPart{data.NewBasicLayerPart(polyhole(corner, radius, false), nil)})
+			if !ok {
+				return nil, false
+			}
+		}
+	}
+
+	return ears, true
+}
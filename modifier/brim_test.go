@@ -0,0 +1,156 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestBrimEars(t *testing.T) {
+	// the triangle has a corner of 90° at (0, 0), of 76° at (0, 5000) and of 14° at (20000, 0)
+	triangle := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(20000, 0),
+		data.NewMicroPoint(0, 5000),
+	}, nil)
+
+	var testCases = map[string]struct {
+		outlines []data.LayerPart
+		maxAngle int
+		// expected contains the corners which get an ear
+		expected []data.MicroPoint
+	}{
+		"square with small max angle": {
+			outlines: []data.LayerPart{rectanglePart(0, 0, 20000, 20000)},
+			maxAngle: 80,
+		},
+		"square with large max angle": {
+			outlines: []data.LayerPart{rectanglePart(0, 0, 20000, 20000)},
+			maxAngle: 100,
+			expected: []data.MicroPoint{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(20000, 0),
+				data.NewMicroPoint(20000, 20000),
+				data.NewMicroPoint(0, 20000),
+			},
+		},
+		"only the sharp corner": {
+			outlines: []data.LayerPart{triangle},
+			maxAngle: 45,
+			expected: []data.MicroPoint{data.NewMicroPoint(20000, 0)},
+		},
+		"clockwise outline": {
+			outlines: []data.LayerPart{data.NewBasicLayerPart(triangle.Outline().Reversed(), nil)},
+			maxAngle: 45,
+			expected: []data.MicroPoint{data.NewMicroPoint(20000, 0)},
+		},
+		"concave corners are ignored": {
+			// an L shape with the concave corner at (5000, 5000)
+			outlines: []data.LayerPart{data.NewBasicLayerPart(data.Path{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(20000, 0),
+				data.NewMicroPoint(20000, 5000),
+				data.NewMicroPoint(5000, 5000),
+				data.NewMicroPoint(5000, 20000),
+				data.NewMicroPoint(0, 20000),
+			}, nil)},
+			maxAngle: 100,
+			expected: []data.MicroPoint{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(20000, 0),
+				data.NewMicroPoint(20000, 5000),
+				data.NewMicroPoint(5000, 20000),
+				data.NewMicroPoint(0, 20000),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.BrimSkirt.BrimCount = 2
+		options.Print.BrimSkirt.BrimEarsMaxAngle = testCase.maxAngle
+
+		ears, ok := brimModifier{options: &options}.ears(clip.NewClipper(), testCase.outlines, 400)
+		test.Assert(t, ok, "the ears should be generated")
+		test.Equals(t, len(testCase.expected), len(ears))
+
+		// each ear is a disc with twice the brim width as radius around its corner
+		for _, corner := range testCase.expected {
+			found := false
+			for _, ear := range ears {
+				min, max := ear.Outline().Bounds()
+				center := min.Add(max).Div(2)
+				if center.Sub(corner).Size() <= 100 && max.Y()-min.Y() == 3200 {
+					found = true
+				}
+			}
+			test.Assert(t, found, "there should be an ear at %v", corner)
+		}
+	}
+}
+
+func TestBrimModifierType(t *testing.T) {
+	// a square with a hole in the center
+	part := data.NewBasicLayerPart(rectangle(0, 0, 20000, 20000), data.Paths{rectangle(5000, 5000, 15000, 15000).Reversed()})
+
+	var testCases = map[string]struct {
+		brimType      string
+		expectedOuter bool
+		expectedInner bool
+		// expectedBounds are the bounds (min x, max x) of the outer dimension of the brim
+		expectedBounds [2]data.Micrometer
+	}{
+		"outer": {
+			brimType:       "outer",
+			expectedOuter:  true,
+			expectedBounds: [2]data.Micrometer{-800, 20800},
+		},
+		"inner": {
+			brimType:      "inner",
+			expectedInner: true,
+			// the area inside the hole starts at the center line of the perimeter
+			expectedBounds: [2]data.Micrometer{4800, 15200},
+		},
+		"both": {
+			brimType:       "both",
+			expectedOuter:  true,
+			expectedInner:  true,
+			expectedBounds: [2]data.Micrometer{-800, 20800},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Printer.ExtrusionWidth = 400
+		options.Print.BrimSkirt.BrimCount = 2
+		options.Print.BrimSkirt.BrimType = testCase.brimType
+
+		// the outer perimeter is centered at half the extrusion width inside the part
+		perimeters := clip.NewClipper().InsetLayer([]data.LayerPart{part}, 400, 1, -200)
+		testLayers := layers([]data.LayerPart{part})
+		testLayers[0] = SetAttribute(testLayers[0], "perimeters", perimeters)
+
+		err := NewBrimModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		brim, err := Brim(testLayers[0])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedOuter, len(brim) > 0)
+
+		innerBrim, err := InnerBrim(testLayers[0])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedInner, len(innerBrim) > 0)
+		if testCase.expectedInner {
+			// the two lines inside the hole
+			test.Equals(t, 1, len(innerBrim))
+			test.Equals(t, 2, len(innerBrim[0]))
+		}
+
+		outerBrim, err := BrimOuterDimension(testLayers[0])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedBounds, xBounds(outerBrim))
+	}
+}
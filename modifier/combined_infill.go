@@ -0,0 +1,95 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type combinedInfillModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m combinedInfillModifier) Init(model data.OptimizedModel) {}
+
+// LayerContext returns the layers of one group as the infill of a layer depends on all layers of its group.
+func (m combinedInfillModifier) LayerContext() int {
+	if m.options.Print.InfillCombineLayers <= 1 {
+		return 0
+	}
+	return m.options.Print.InfillCombineLayers - 1
+}
+
+// NewCombinedInfillModifier creates a modifier which prints the sparse infill of several layers at once
+// (see data.PrintOptions.InfillCombineLayers), which saves a lot of time for sparse infill.
+// The layers are split into groups of the configured amount of layers.
+// The area which has sparse infill in all layers of a group is moved from the attribute "infill" of these layers
+// to the attribute "combinedInfill" of the top layer of the group, which is printed with multiplied flow.
+// It has to run after all modifiers which change the attribute "infill".
+func NewCombinedInfillModifier(options *data.Options) handler.LayerModifier {
+	return &combinedInfillModifier{
+		Named: handler.Named{
+			Name: "CombinedInfill",
+		},
+		options: options,
+	}
+}
+
+func (m combinedInfillModifier) Modify(layers []data.PartitionedLayer) error {
+	count := m.options.Print.InfillCombineLayers
+	if count <= 1 {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	for top := count - 1; top < len(layers); top += count {
+		combined, err := PartsAttribute(layers[top], "infill")
+		if err != nil {
+			return err
+		}
+
+		// only the area with infill in all layers of the group can be combined
+		for layerNr := top - count + 1; layerNr < top && len(combined) > 0; layerNr++ {
+			infill, err := PartsAttribute(layers[layerNr], "infill")
+			if err != nil {
+				return err
+			}
+
+			var ok bool
+			combined, ok = c.Intersection(combined, infill)
+			if !ok {
+				return fmt.Errorf("could not intersect the infill of layer %d with the layers above", layerNr)
+			}
+		}
+		if len(combined) == 0 {
+			continue
+		}
+
+		for layerNr := top - count + 1; layerNr <= top; layerNr++ {
+			infill, err := PartsAttribute(layers[layerNr], "infill")
+			if err != nil {
+				return err
+			}
+
+			remaining, ok := c.Difference(infill, combined)
+			if !ok {
+				return fmt.Errorf("could not subtract the combined infill from the infill of layer %d", layerNr)
+			}
+
+			// drop the slivers along the border of the combined infill which are too narrow for a line
+			width := m.options.Printer.LayerExtrusionWidth(layerNr)
+			var opened []data.LayerPart
+			for _, part := range remaining {
+				for _, shrunk := range c.Inset(part, 0, 1, -width/2)[0] {
+					opened = append(opened, c.Inset(shrunk, 0, 1, width/2)[0]...)
+				}
+			}
+			layers[layerNr] = SetAttribute(layers[layerNr], "infill", opened)
+		}
+		layers[top] = SetAttribute(layers[top], "combinedInfill", combined)
+	}
+
+	return nil
+}
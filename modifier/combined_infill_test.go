@@ -0,0 +1,98 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestCombinedInfillModifier(t *testing.T) {
+	whole := rectanglePart(0, 0, 20000, 10000)
+	infills := [][]data.LayerPart{
+		// the same infill in both layers
+		{whole},
+		{whole},
+		// the infill of the top layer is only a bit smaller, which leaves a sliver in the layer below
+		{whole},
+		{rectanglePart(0, 0, 19800, 10000)},
+		// the infill of the top layer is the left half
+		{whole},
+		{rectanglePart(0, 0, 10000, 10000)},
+		// the last layer does not belong to a complete group
+		{whole},
+	}
+
+	options := data.DefaultOptions()
+	options.Print.InfillCombineLayers = 2
+	options.Printer.ExtrusionWidth = 400
+
+	testLayers := layers(make([][]data.LayerPart, len(infills))...)
+	for layerNr, infill := range infills {
+		testLayers[layerNr] = SetAttribute(testLayers[layerNr], "infill", infill)
+	}
+
+	err := NewCombinedInfillModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+
+	var testCases = map[string]struct {
+		layerNr int
+		// expectedInfillMinX and expectedInfillMaxX are the x bounds of the infill left in the layer,
+		// expectedCombinedMaxX is the max x of the combined infill of the layer, both are 0 if there is none
+		expectedInfillMinX   data.Micrometer
+		expectedInfillMaxX   data.Micrometer
+		expectedCombinedMaxX data.Micrometer
+	}{
+		"same infill, lower layer": {
+			layerNr: 0,
+		},
+		"same infill, top layer": {
+			layerNr:              1,
+			expectedCombinedMaxX: 20000,
+		},
+		"sliver is dropped": {
+			layerNr: 2,
+		},
+		"smaller top layer": {
+			layerNr:              3,
+			expectedCombinedMaxX: 19800,
+		},
+		"rest of the infill stays": {
+			layerNr:            4,
+			expectedInfillMinX: 10000,
+			expectedInfillMaxX: 20000,
+		},
+		"half infill, top layer": {
+			layerNr:              5,
+			expectedCombinedMaxX: 10000,
+		},
+		"incomplete group": {
+			layerNr:            6,
+			expectedInfillMaxX: 20000,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		layer := testLayers[testCase.layerNr]
+
+		infill, err := PartsAttribute(layer, "infill")
+		test.Ok(t, err)
+		if testCase.expectedInfillMaxX == 0 {
+			test.Equals(t, 0, len(infill))
+		} else {
+			min, max := partsBounds(infill)
+			test.Equals(t, testCase.expectedInfillMinX, min.X())
+			test.Equals(t, testCase.expectedInfillMaxX, max.X())
+		}
+
+		combined, err := PartsAttribute(layer, "combinedInfill")
+		test.Ok(t, err)
+		if testCase.expectedCombinedMaxX == 0 {
+			test.Equals(t, 0, len(combined))
+		} else {
+			min, max := partsBounds(combined)
+			test.Equals(t, data.Micrometer(0), min.X())
+			test.Equals(t, testCase.expectedCombinedMaxX, max.X())
+		}
+	}
+}
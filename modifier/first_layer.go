@@ -0,0 +1,621 @@
+package modifier
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"sort"
+)
+
+type firstLayerModifier struct {
+	handler.Named
+	options *data.Options
+
+	// instances contains the offsets of all instances of the model.
+	instances []data.MicroPoint
+}
+
+func (m *firstLayerModifier) Init(model data.OptimizedModel) {
+	m.instances = nil
+	if instanced, ok := model.(data.InstancedModel); ok {
+		m.instances = instanced.Instances()
+	}
+}
+
+// LayerContext returns the layers above the first layer on which the skirt is repeated,
+// as they need the skirt planned on the first layer.
+func (m *firstLayerModifier) LayerContext() int {
+	if m.options.Print.BrimSkirt.SkirtHeight > 1 {
+		return m.options.Print.BrimSkirt.SkirtHeight - 1
+	}
+	return 0
+}
+
+// NewFirstLayerModifier plans all lines of the first layer which do not belong to the objects themselves,
+// which currently are the skirt and the brims generated by the brim modifier.
+// The planned lines are set as the attribute "firstLayer" in the order in which they have to be printed.
+//
+// As the planner knows all instances of the model (see data.InstancedModel),
+// the lines are planned for all instances at once and are already moved by the offset of their instance.
+//
+// The planner resolves the overlaps between the lines depending on the brim overlap precedence option:
+//   - "none": the brims are kept as they are.
+//   - "brim": the brims are clipped by all objects and by the brims of larger objects,
+//     so that the brim of the larger object is kept where two brims overlap.
+//   - "support": the brims are clipped in the same way and additionally by the support.
+//
+// The support below the brims is already removed by the support generator unless the support has precedence.
+// The skirt surrounds the hull of all objects, brims, support and the ooze shield, so it never overlaps them.
+//
+// The skirt consists of at least the configured count of lines, more lines are added until their length
+// reaches the skirt min length. It is repeated on the following layers up to the skirt height,
+// which get the attribute "firstLayer" containing only the skirt lines.
+//
+// Support columns whose footprint is smaller than the support brim max area get a brim of the support brim count,
+// which is clipped by all objects and their brims.
+//
+// The skirt is printed first as it primes the nozzle. After it, the brim of the object nearest
+// to the current position follows, from its outer line to its inner line, so that the brim ends next to the object.
+// Each closed line starts at its point nearest to the end of the previous line and open lines are reversed if
+// their end is nearer.
+func NewFirstLayerModifier(options *data.Options) handler.LayerModifier {
+	return &firstLayerModifier{
+		Named: handler.Named{
+			Name: "FirstLayer",
+		},
+		options: options,
+	}
+}
+
+// FirstLayerPath is one line planned by the first layer modifier.
+type FirstLayerPath struct {
+	// Feature is the feature of the line, e.g. data.FeatureSkirt.
+	Feature data.Feature
+
+	Path data.Path
+
+	// Open is true if the line is not closed after its last point.
+	Open bool
+}
+
+// FirstLayerPaths extracts the attribute "firstLayer" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func FirstLayerPaths(layer data.PartitionedLayer) ([]FirstLayerPath, error) {
+	if attr, ok := layer.Attributes()["firstLayer"]; ok {
+		paths, ok := attr.([]FirstLayerPath)
+		if !ok {
+			return nil, errors.New("the attribute firstLayer has the wrong datatype")
+		}
+
+		return paths, nil
+	}
+
+	return nil, nil
+}
+
+// objectBrim contains the brim of one object.
+type objectBrim struct {
+	// paths contains the brim lines from the outer to the inner one.
+	paths []FirstLayerPath
+	// area is the area covered by the object and its brim.
+	area []data.LayerPart
+	size data.Micrometer
+	// inner is true for the brim inside a hole of the object.
+	// It lies inside the area of the object, so it is only clipped by the objects and the support.
+	inner bool
+}
+
+func (m *firstLayerModifier) Modify(layers []data.PartitionedLayer) error {
+	if len(layers) == 0 {
+		return nil
+	}
+
+	layer := layers[0]
+	instances := m.instances
+	if len(instances) <= 1 {
+		instances = []data.MicroPoint{data.NewMicroPoint(0, 0)}
+	}
+
+	skirt, err := m.skirt(layer, instances)
+	if err != nil {
+		return err
+	}
+
+	brims, err := m.brims(layer, instances)
+	if err != nil {
+		return err
+	}
+
+	supportBrims, err := m.supportBrims(layer, instances, brims)
+	if err != nil {
+		return err
+	}
+	brims = append(brims, supportBrims...)
+
+	if len(skirt) == 0 && len(brims) == 0 {
+		return nil
+	}
+
+	// Without a skirt the first brim is the one nearest to the origin.
+	current := data.NewMicroPoint(0, 0)
+	if len(skirt) > 0 {
+		current = skirt[len(skirt)-1].Path[0]
+	}
+
+	newLayer := newExtendedLayer(layer)
+	newLayer.attributes["firstLayer"] = append(skirt, orderBrims(brims, current)...)
+	layers[0] = newLayer
+
+	if len(skirt) > 0 {
+		for layerNr := 1; layerNr < m.options.Print.BrimSkirt.SkirtHeight && layerNr < len(layers); layerNr++ {
+			newLayer := newExtendedLayer(layers[layerNr])
+			newLayer.attributes["firstLayer"] = skirt
+			layers[layerNr] = newLayer
+		}
+	}
+
+	return nil
+}
+
+// skirt generates the skirt lines around the hull of all instances.
+// The skirt is generated by exsetting the hull around everything (including support, brim and ooze shield) by the configured distance.
+// A 2d hull is basically one line surrounding everything.
+// (htps://spolearninglab.com/curriculum/lessonPlans/hacking/resources/software/3d/openscad/openscad_hull.html)
+func (m *firstLayerModifier) skirt(layer data.PartitionedLayer, instances []data.MicroPoint) ([]FirstLayerPath, error) {
+	if m.options.Print.BrimSkirt.SkirtCount == 0 {
+		return nil, nil
+	}
+
+	// On belt printers the first layer is a small strip on the belt,
+	// so most of the skirt would be below the belt.
+	if m.options.Printer.Kinematics == "belt" {
+		return nil, nil
+	}
+
+	// Get the perimeters and support to base the hull (line around everything) on them.
+	perimeters, err := Perimeters(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	support, err := FullSupport(layer)
+	if err != nil {
+		return nil, err
+	}
+	if support == nil && perimeters == nil {
+		return nil, nil
+	}
+
+	shield, err := OozeShield(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	width := m.options.Printer.LayerExtrusionWidth(0)
+
+	// Skirt distance + (1/2 extrusion with of the model side + 1/2 extrusion width of the most inner brim line) + the brim width
+	// is the distance between the perimeter (or brim) and skirt.
+	brimCount := m.options.Print.BrimSkirt.BrimCount
+	if m.options.Print.Support.BrimCount > brimCount {
+		brimCount = m.options.Print.Support.BrimCount
+	}
+	distance := m.options.Print.BrimSkirt.SkirtDistance.ToMicrometer() + (width * data.Micrometer(brimCount)) + width
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	// Generate the hull around everything.
+	parts := append(support, perimeters.ToOneDimension()...)
+	parts = append(parts, shield...)
+	if len(instances) > 1 {
+		// only the outlines are needed for the hull
+		var instanceParts []data.LayerPart
+		for _, instance := range instances {
+			for _, part := range parts {
+				instanceParts = append(instanceParts, data.NewBasicLayerPart(part.Outline().Translated(instance), nil))
+			}
+		}
+		parts = instanceParts
+	}
+	hull, ok := c.Hull(parts)
+	if !ok {
+		return nil, errors.New("could not generate hull around all perimeters to create the skirt")
+	}
+
+	// Generate all skirt lines by exsetting the hull.
+	// If they are too short, more lines are generated based on the length of the outer line
+	// and all lines beyond the min length are removed again.
+	minLength := m.options.Print.BrimSkirt.SkirtMinLength.ToMicrometer()
+	count := m.options.Print.BrimSkirt.SkirtCount
+	for {
+		var skirt []FirstLayerPath
+		var length, outerLength data.Micrometer
+		for wallNr, wall := range c.Inset(data.NewBasicLayerPart(hull, nil), -width, count, distance) {
+			if wallNr >= m.options.Print.BrimSkirt.SkirtCount && length >= minLength {
+				return skirt, nil
+			}
+
+			outerLength = 0
+			for _, loopPart := range wall {
+				if len(loopPart.Outline()) == 0 {
+					continue
+				}
+				skirt = append(skirt, FirstLayerPath{
+					Feature: data.FeatureSkirt,
+					Path:    loopPart.Outline(),
+				})
+				outerLength += loopLength(loopPart.Outline())
+			}
+			length += outerLength
+		}
+
+		if length >= minLength || outerLength <= 0 {
+			return skirt, nil
+		}
+		count += int((minLength-length)/outerLength) + 1
+	}
+}
+
+// loopLength returns the length of the closed path.
+func loopLength(path data.Path) data.Micrometer {
+	var length data.Micrometer
+	for i, point := range path {
+		length += point.Sub(path[(i+1)%len(path)]).Size()
+	}
+	return length
+}
+
+// brims returns the brims of all objects of all instances
+// and clips them depending on the overlap precedence.
+// The brims inside the holes are returned as separate brims.
+// If brim ears are generated, the brim lines are clipped by the ears.
+func (m *firstLayerModifier) brims(layer data.PartitionedLayer, instances []data.MicroPoint) ([]objectBrim, error) {
+	brim, err := Brim(layer)
+	if err != nil {
+		return nil, err
+	}
+	innerBrim, err := InnerBrim(layer)
+	if err != nil {
+		return nil, err
+	}
+	ears, err := BrimEars(layer)
+	if err != nil {
+		return nil, err
+	}
+	if brim == nil && innerBrim == nil {
+		return nil, nil
+	}
+
+	precedence := m.options.Print.BrimSkirt.OverlapPrecedence
+	clipped := precedence == "brim" || precedence == "support"
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	width := m.options.Printer.LayerExtrusionWidth(0)
+
+	all := make(clip.OffsetResult, 0, len(brim)+len(innerBrim))
+	all = append(append(all, brim...), innerBrim...)
+
+	var brims []objectBrim
+	for i, part := range all {
+		if len(part) == 0 {
+			continue
+		}
+		inner := i >= len(brim)
+
+		// the insets of the brim start at the object, so the outer line is the last one
+		var paths []FirstLayerPath
+		for insetNr := len(part) - 1; insetNr >= 0; insetNr-- {
+			for _, insetPart := range part[insetNr] {
+				paths = append(paths, FirstLayerPath{Feature: data.FeatureBrim, Path: insetPart.Outline()})
+				if clipped {
+					for _, hole := range insetPart.Holes() {
+						paths = append(paths, FirstLayerPath{Feature: data.FeatureBrim, Path: hole})
+					}
+				}
+			}
+		}
+
+		// the area is needed to clip the other brims and the support brims, even if the brims of the objects overlap
+		var area []data.LayerPart
+		var size data.Micrometer
+		if !inner {
+			area = c.InsetLayer(part[len(part)-1], -width, 1, width/2).ToOneDimension()
+			if ears != nil {
+				var ok bool
+				area, ok = c.Intersection(area, ears)
+				if !ok {
+					return nil, errors.New("could not clip the brim area by the brim ears")
+				}
+			}
+			for _, areaPart := range area {
+				size += absArea(areaPart.Outline())
+			}
+		}
+
+		if ears != nil && !inner {
+			var earPaths []FirstLayerPath
+			for _, path := range paths {
+				lines, ok := c.IntersectLines(ringLines(path.Path), ears)
+				if !ok {
+					return nil, errors.New("could not clip the brim lines by the brim ears")
+				}
+				for _, line := range lines {
+					earPaths = append(earPaths, FirstLayerPath{Feature: data.FeatureBrim, Path: line, Open: true})
+				}
+			}
+			paths = earPaths
+		}
+
+		for _, instance := range instances {
+			b := objectBrim{size: size, inner: inner}
+			for _, path := range paths {
+				path.Path = path.Path.Translated(instance)
+				b.paths = append(b.paths, path)
+			}
+			for _, areaPart := range area {
+				b.area = append(b.area, translatedPart(areaPart, instance))
+			}
+			brims = append(brims, b)
+		}
+	}
+
+	if !clipped {
+		return brims, nil
+	}
+
+	// the brim of the larger object has precedence
+	sort.SliceStable(brims, func(i, j int) bool {
+		return brims[i].size > brims[j].size
+	})
+
+	// blocked contains the areas in which no brim may be printed
+	var blocked []data.LayerPart
+	attributes := []string{"parts"}
+	if precedence == "support" {
+		attributes = append(attributes, "support", "supportInterface", "supportFloor", "treeSupport")
+	}
+	for _, instance := range instances {
+		for _, attribute := range attributes {
+			parts := layer.LayerParts()
+			if attribute != "parts" {
+				parts, err = PartsAttribute(layer, attribute)
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			var translated []data.LayerPart
+			for _, part := range parts {
+				translated = append(translated, translatedPart(part, instance))
+			}
+
+			var ok bool
+			blocked, ok = c.Union(blocked, translated)
+			if !ok {
+				return nil, fmt.Errorf("could not merge the %s into the areas blocked for the brim", attribute)
+			}
+		}
+	}
+	objectsBlocked := blocked
+
+	for i, b := range brims {
+		toRemove := blocked
+		if b.inner {
+			toRemove = objectsBlocked
+		}
+
+		var paths []FirstLayerPath
+		for _, path := range b.paths {
+			toClip := data.Paths{path.Path}
+			if !path.Open {
+				toClip = ringLines(path.Path)
+			}
+			lines, ok := c.DifferenceLines(toClip, toRemove)
+			if !ok {
+				return nil, errors.New("could not clip the brim lines by the other objects")
+			}
+			for _, line := range lines {
+				paths = append(paths, FirstLayerPath{
+					Feature: data.FeatureBrim,
+					Path:    line,
+					Open:    true,
+				})
+			}
+		}
+		brims[i].paths = paths
+
+		var ok bool
+		blocked, ok = c.Union(blocked, b.area)
+		if !ok {
+			return nil, errors.New("could not merge the brim into the areas blocked for the brim")
+		}
+	}
+
+	return brims, nil
+}
+
+// supportBrims returns the brims around the support columns of all instances whose footprint is smaller
+// than the support brim max area. They are clipped by all objects, by the areas of the given brims of the objects
+// and by each other.
+func (m *firstLayerModifier) supportBrims(layer data.PartitionedLayer, instances []data.MicroPoint, objectBrims []objectBrim) ([]objectBrim, error) {
+	count := m.options.Print.Support.BrimCount
+	if count <= 0 {
+		return nil, nil
+	}
+
+	support, err := FullSupport(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	width := m.options.Printer.LayerExtrusionWidth(0)
+	maxArea := data.Micrometer(m.options.Print.Support.BrimMaxArea * 1000 * 1000)
+
+	// the lines around each column from the inner to the outer one and the area covered by them
+	var columns []data.Paths
+	var areas [][]data.LayerPart
+	for _, part := range support {
+		if partArea(part) >= maxArea {
+			continue
+		}
+
+		var lines data.Paths
+		for _, inset := range c.Inset(part, -width, count, width/2) {
+			for _, insetPart := range inset {
+				lines = append(lines, insetPart.Outline())
+			}
+		}
+		columns = append(columns, lines)
+		areas = append(areas, c.Inset(part, -width, 1, data.Micrometer(count)*width)[0])
+	}
+	if len(columns) == 0 {
+		return nil, nil
+	}
+
+	var toBlock []data.LayerPart
+	for _, instance := range instances {
+		for _, part := range layer.LayerParts() {
+			toBlock = append(toBlock, translatedPart(part, instance))
+		}
+	}
+	for _, b := range objectBrims {
+		toBlock = append(toBlock, b.area...)
+	}
+	var blocked []data.LayerPart
+	for _, part := range toBlock {
+		var ok bool
+		blocked, ok = c.Union(blocked, []data.LayerPart{part})
+		if !ok {
+			return nil, errors.New("could not merge the areas blocked for the support brim")
+		}
+	}
+
+	var brims []objectBrim
+	for _, instance := range instances {
+		for columnNr, lines := range columns {
+			var b objectBrim
+			for i := len(lines) - 1; i >= 0; i-- {
+				clipped, ok := c.DifferenceLines(ringLines(lines[i].Translated(instance)), blocked)
+				if !ok {
+					return nil, errors.New("could not clip the support brim by the objects")
+				}
+				for _, line := range clipped {
+					b.paths = append(b.paths, FirstLayerPath{Feature: data.FeatureBrim, Path: line, Open: true})
+				}
+			}
+			brims = append(brims, b)
+
+			// the support brims must not overlap each other
+			for _, part := range areas[columnNr] {
+				var ok bool
+				blocked, ok = c.Union(blocked, []data.LayerPart{translatedPart(part, instance)})
+				if !ok {
+					return nil, errors.New("could not merge the support brim into the areas blocked for the support brim")
+				}
+			}
+		}
+	}
+
+	return brims, nil
+}
+
+// orderBrims returns the lines of all brims so that each brim starts next to the end of the previous one.
+// The first brim is the one nearest to the start point.
+func orderBrims(brims []objectBrim, start data.MicroPoint) []FirstLayerPath {
+	var ordered []FirstLayerPath
+	isUsed := make([]bool, len(brims))
+
+	current := start
+	for {
+		bestIndex := -1
+		var bestDistance data.Micrometer
+		for i, b := range brims {
+			if isUsed[i] || len(b.paths) == 0 {
+				continue
+			}
+
+			_, distance := nearestStart(b.paths[0], current)
+			if bestIndex == -1 || distance < bestDistance {
+				bestIndex = i
+				bestDistance = distance
+			}
+		}
+
+		if bestIndex == -1 {
+			break
+		}
+
+		isUsed[bestIndex] = true
+		for _, path := range brims[bestIndex].paths {
+			path = startNearest(path, current)
+			ordered = append(ordered, path)
+
+			current = path.Path[0]
+			if path.Open {
+				current = path.Path[len(path.Path)-1]
+			}
+		}
+	}
+
+	return ordered
+}
+
+// nearestStart returns the index of the point of the path at which it should start to be nearest to p
+// and the squared distance of this point to p.
+// Closed paths can start at any point, open paths only at one of their ends.
+func nearestStart(path FirstLayerPath, p data.MicroPoint) (int, data.Micrometer) {
+	best := 0
+	bestDistance := path.Path[0].Sub(p).Size2()
+	for i, point := range path.Path {
+		if path.Open && i != len(path.Path)-1 {
+			continue
+		}
+
+		if distance := point.Sub(p).Size2(); distance < bestDistance {
+			best = i
+			bestDistance = distance
+		}
+	}
+
+	return best, bestDistance
+}
+
+// startNearest returns the path rotated (closed paths) or reversed (open paths) so that it starts at its point nearest to p.
+func startNearest(path FirstLayerPath, p data.MicroPoint) FirstLayerPath {
+	start, _ := nearestStart(path, p)
+	if start == 0 {
+		return path
+	}
+
+	if path.Open {
+		path.Path = path.Path.Reversed()
+		return path
+	}
+
+	rotated := make(data.Path, 0, len(path.Path))
+	rotated = append(rotated, path.Path[start:]...)
+	path.Path = append(rotated, path.Path[:start]...)
+	return path
+}
+
+// translatedPart returns the part moved by the given offset.
+func translatedPart(part data.LayerPart, offset data.MicroPoint) data.LayerPart {
+	return data.NewBasicLayerPart(part.Outline().Translated(offset), part.Holes().Translated(offset))
+}
+
+// ringLines returns the closed polygon as two open lines which together go once around it.
+// A single open line which ends at its first point can make the clipper loop forever.
+func ringLines(polygon data.Path) data.Paths {
+	if len(polygon) < 2 {
+		return nil
+	}
+
+	half := len(polygon) / 2
+	second := make(data.Path, 0, len(polygon)-half+1)
+	second = append(second, polygon[half:]...)
+	second = append(second, polygon[0])
+
+	return data.Paths{polygon[:half+1], second}
+}
@@ -0,0 +1,287 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// segmentMidpoints returns the midpoint of each segment of the paths.
+func segmentMidpoints(paths []FirstLayerPath) []data.MicroPoint {
+	var points []data.MicroPoint
+	for _, path := range paths {
+		for i := 1; i < len(path.Path); i++ {
+			points = append(points, path.Path[i-1].Add(path.Path[i]).Div(2))
+		}
+	}
+	return points
+}
+
+func TestSupportBrims(t *testing.T) {
+	var testCases = map[string]struct {
+		precedence string
+	}{
+		"brims of the objects overlap": {
+			precedence: "none",
+		},
+		"brims of the objects are clipped": {
+			precedence: "brim",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.BrimSkirt.OverlapPrecedence = testCase.precedence
+		options.Print.Support.BrimCount = 3
+		width := options.Printer.LayerExtrusionWidth(0)
+
+		object := rectanglePart(0, 0, 10000, 10000)
+		// two small support columns next to each other and next to the object
+		columns := []data.LayerPart{
+			rectanglePart(10500, 0, 11500, 1000),
+			rectanglePart(12000, 0, 13000, 1000),
+		}
+
+		layer := newExtendedLayer(data.NewPartitionedLayer([]data.LayerPart{object}))
+		layer.attributes["brim"] = clip.OffsetResult{{{rectanglePart(-width/2, -width/2, 10000+width/2, 10000+width/2)}}}
+		layer.attributes["fullSupport"] = columns
+
+		m := &firstLayerModifier{options: &options}
+		instances := []data.MicroPoint{data.NewMicroPoint(0, 0)}
+		objectBrims, err := m.brims(layer, instances)
+		test.Ok(t, err)
+		test.Equals(t, 1, len(objectBrims))
+		test.Assert(t, len(objectBrims[0].area) > 0, "the area of the object brim is needed for the support brims")
+
+		supportBrims, err := m.supportBrims(layer, instances, objectBrims)
+		test.Ok(t, err)
+		test.Equals(t, len(columns), len(supportBrims))
+
+		// the support brims neither overlap the object, its brim nor each other
+		blocked := append([]data.LayerPart{object}, objectBrims[0].area...)
+		c := clip.NewClipper()
+		for i, b := range supportBrims {
+			test.Assert(t, len(b.paths) > 0, "support brim %v should not be removed completely", i)
+			for _, p := range segmentMidpoints(b.paths) {
+				test.Assert(t, !insideParts(blocked, p), "the support brim %v overlaps at %v", i, p)
+			}
+			blocked = append(blocked, c.Inset(columns[i], -width, 1, data.Micrometer(options.Print.Support.BrimCount)*width)[0]...)
+		}
+	}
+}
+
+func TestNearestStart(t *testing.T) {
+	square := rectangle(0, 0, 1000, 1000)
+	line := data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(1000, 0), data.NewMicroPoint(2000, 0)}
+
+	var testCases = map[string]struct {
+		path             FirstLayerPath
+		point            data.MicroPoint
+		expectedIndex    int
+		expectedDistance data.Micrometer
+	}{
+		"closed path starts at any point": {
+			path:             FirstLayerPath{Path: square},
+			point:            data.NewMicroPoint(1100, 1000),
+			expectedIndex:    2,
+			expectedDistance: 100 * 100,
+		},
+		"open path starts at its end": {
+			path:             FirstLayerPath{Path: line, Open: true},
+			point:            data.NewMicroPoint(1900, 0),
+			expectedIndex:    2,
+			expectedDistance: 100 * 100,
+		},
+		"open path does not start in the middle": {
+			path:             FirstLayerPath{Path: line, Open: true},
+			point:            data.NewMicroPoint(900, 0),
+			expectedIndex:    0,
+			expectedDistance: 900 * 900,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		index, distance := nearestStart(testCase.path, testCase.point)
+		test.Equals(t, testCase.expectedIndex, index)
+		test.Equals(t, testCase.expectedDistance, distance)
+	}
+}
+
+func TestStartNearest(t *testing.T) {
+	square := rectangle(0, 0, 1000, 1000)
+	line := data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(1000, 0), data.NewMicroPoint(2000, 0)}
+
+	var testCases = map[string]struct {
+		path     FirstLayerPath
+		point    data.MicroPoint
+		expected data.Path
+	}{
+		"closed path is rotated": {
+			path:     FirstLayerPath{Path: square},
+			point:    data.NewMicroPoint(1100, 1000),
+			expected: data.Path{square[2], square[3], square[0], square[1]},
+		},
+		"open path is reversed": {
+			path:     FirstLayerPath{Path: line, Open: true},
+			point:    data.NewMicroPoint(2100, 0),
+			expected: data.Path{line[2], line[1], line[0]},
+		},
+		"path already starts at the nearest point": {
+			path:     FirstLayerPath{Path: line, Open: true},
+			point:    data.NewMicroPoint(-100, 0),
+			expected: line,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		path := startNearest(testCase.path, testCase.point)
+		test.Equals(t, testCase.expected, path.Path, microPointComparer())
+		test.Equals(t, testCase.path.Open, path.Open)
+	}
+}
+
+func TestRingLines(t *testing.T) {
+	square := rectangle(0, 0, 1000, 1000)
+	triangle := square[:3]
+
+	var testCases = map[string]struct {
+		polygon  data.Path
+		expected data.Paths
+	}{
+		"square": {
+			polygon:  square,
+			expected: data.Paths{{square[0], square[1], square[2]}, {square[2], square[3], square[0]}},
+		},
+		"triangle": {
+			polygon:  triangle,
+			expected: data.Paths{{triangle[0], triangle[1]}, {triangle[1], triangle[2], triangle[0]}},
+		},
+		"single point": {
+			polygon: square[:1],
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, ringLines(testCase.polygon), microPointComparer())
+	}
+}
+
+func TestOrderBrims(t *testing.T) {
+	// brim returns a brim with two closed lines around the square from (x, 0) to (x+1000, 1000).
+	brim := func(x data.Micrometer) objectBrim {
+		return objectBrim{paths: []FirstLayerPath{
+			{Feature: data.FeatureBrim, Path: rectangle(x-800, -800, x+1800, 1800)},
+			{Feature: data.FeatureBrim, Path: rectangle(x-400, -400, x+1400, 1400)},
+		}}
+	}
+
+	brims := []objectBrim{brim(20000), {}, brim(0), brim(10000)}
+	ordered := orderBrims(brims, data.NewMicroPoint(0, 0))
+
+	// the empty brim is skipped and the brims are ordered from left to right
+	test.Equals(t, 6, len(ordered))
+	var starts []data.MicroPoint
+	for _, path := range ordered {
+		starts = append(starts, path.Path[0])
+	}
+	test.Equals(t, []data.MicroPoint{
+		data.NewMicroPoint(-800, -800),
+		data.NewMicroPoint(-400, -400),
+		// the next brim starts at its point nearest to the end of the previous one
+		data.NewMicroPoint(10000-800, -800),
+		data.NewMicroPoint(10000-400, -400),
+		data.NewMicroPoint(20000-800, -800),
+		data.NewMicroPoint(20000-400, -400),
+	}, starts, microPointComparer())
+}
+
+func TestSkirt(t *testing.T) {
+	var testCases = map[string]struct {
+		count     int
+		minLength data.Millimeter
+		height    int
+		// expectedLoops is the amount of skirt lines
+		expectedLoops int
+		// expectedLayers is the amount of layers which get the skirt
+		expectedLayers int
+	}{
+		"min length forces extra loops": {
+			// the loops around the small object are about 44mm long and get longer by about 2.6mm each
+			count:          1,
+			minLength:      200,
+			height:         1,
+			expectedLoops:  5,
+			expectedLayers: 1,
+		},
+		"min length reached by the count": {
+			count:          3,
+			minLength:      50,
+			height:         1,
+			expectedLoops:  3,
+			expectedLayers: 1,
+		},
+		"no skirt despite the min length": {
+			count:          0,
+			minLength:      200,
+			height:         3,
+			expectedLoops:  0,
+			expectedLayers: 0,
+		},
+		"repeated up to the height": {
+			count:          2,
+			height:         3,
+			expectedLoops:  2,
+			expectedLayers: 3,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.BrimSkirt.BrimCount = 0
+		options.Print.BrimSkirt.SkirtCount = testCase.count
+		options.Print.BrimSkirt.SkirtMinLength = testCase.minLength
+		options.Print.BrimSkirt.SkirtHeight = testCase.height
+
+		object := []data.LayerPart{rectanglePart(0, 0, 2000, 2000)}
+		testLayers := layers(object, object, object, object, object)
+		first := newExtendedLayer(testLayers[0])
+		first.attributes["perimeters"] = clip.OffsetResult{{object}}
+		testLayers[0] = first
+
+		test.Ok(t, NewFirstLayerModifier(&options).Modify(testLayers))
+
+		skirt, err := FirstLayerPaths(testLayers[0])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedLoops, len(skirt))
+
+		var length data.Micrometer
+		for i, path := range skirt {
+			test.Equals(t, data.FeatureSkirt, path.Feature)
+			length += loopLength(path.Path)
+			// the lines are added from the inside to the outside until the min length is reached
+			if i >= testCase.count {
+				test.Assert(t, length-loopLength(path.Path) < testCase.minLength.ToMicrometer(), "the skirt line %v is not needed to reach the min length", i)
+			}
+		}
+		if testCase.count > 0 {
+			test.Assert(t, length >= testCase.minLength.ToMicrometer(), "the skirt is %v long but should be at least %v", length, testCase.minLength.ToMicrometer())
+		}
+
+		for layerNr := 1; layerNr < len(testLayers); layerNr++ {
+			paths, err := FirstLayerPaths(testLayers[layerNr])
+			test.Ok(t, err)
+			if layerNr < testCase.expectedLayers {
+				// the following layers contain only the skirt
+				test.Equals(t, skirt, paths, microPointComparer())
+			} else {
+				test.Assert(t, paths == nil, "the layer %v should not get a skirt", layerNr)
+			}
+		}
+	}
+}
@@ -0,0 +1,127 @@
+// This file provides the detection of gaps which are too narrow for the perimeters.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// GapFillLine is a single line which fills a gap too narrow for another perimeter.
+type GapFillLine struct {
+	// Path is the center line of the gap.
+	Path data.Path
+
+	// Closed is true if the line goes around a hole, so that its last point is connected to its first point.
+	Closed bool
+
+	// Width is the width of the line which fills the gap.
+	Width data.Micrometer
+}
+
+// GapFill extracts the attribute "gapFill" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func GapFill(layer data.PartitionedLayer) ([]GapFillLine, error) {
+	if attr, ok := layer.Attributes()["gapFill"]; ok {
+		lines, ok := attr.([]GapFillLine)
+		if !ok {
+			return nil, errors.New("the attribute gapFill has the wrong datatype")
+		}
+
+		return lines, nil
+	}
+
+	return nil, nil
+}
+
+// gapFill returns the lines which fill the gaps of the part which are too narrow for its perimeters.
+// The perimeters are the center lines of the perimeters of the part ([insetNr][insetParts]) which were
+// generated using the initial offset and the width.
+// Only the gaps of the perimeters from firstInset up to lastInset (exclusive) are filled,
+// e.g. as the gaps of the outer perimeters are printed as thin walls.
+// Each gap is filled by a single line along its center with the average width of the gap.
+func gapFill(c clip.Clipper, part data.LayerPart, perimeters [][]data.LayerPart, width, initialOffset, minWidth data.Micrometer, firstInset, lastInset int) ([]GapFillLine, error) {
+	var lines []GapFillLine
+
+	for insetNr := firstInset; insetNr < lastInset && insetNr < len(perimeters); insetNr++ {
+		gaps, err := perimeterGaps(c, part, perimeters[insetNr], width, initialOffset-data.Micrometer(insetNr)*width, minWidth)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, gap := range gaps {
+			for _, line := range medialAxis(gap, width) {
+				var widthSum data.Micrometer
+				for _, w := range line.Widths {
+					widthSum += w
+				}
+
+				lines = append(lines, GapFillLine{
+					Path:   line.Path,
+					Closed: line.Closed,
+					Width:  clampWidth(widthSum/data.Micrometer(len(line.Widths)), minWidth, width),
+				})
+			}
+		}
+	}
+
+	return lines, nil
+}
+
+// perimeterGaps returns the gaps of the part which are too narrow for the perimeter with the given center lines,
+// which lie offset away from the contours of the part.
+//
+// The area left for the perimeter is shrunk by half of the width and expanded again,
+// which removes all areas too narrow for the perimeter. These areas are the gaps.
+// Gaps narrower than minWidth are ignored.
+func perimeterGaps(c clip.Clipper, part data.LayerPart, perimeter []data.LayerPart, width, offset, minWidth data.Micrometer) ([]data.LayerPart, error) {
+	available := c.Inset(part, 0, 1, offset+width/2)[0]
+	if len(available) == 0 {
+		return nil, nil
+	}
+
+	// the area covered by the perimeter lines, they overlap each other so they are merged one by one
+	var covered []data.LayerPart
+	for _, line := range perimeter {
+		for _, grown := range c.Inset(line, 0, 1, width/2)[0] {
+			var ok bool
+			covered, ok = c.Union(covered, []data.LayerPart{grown})
+			if !ok {
+				return nil, errors.New("could not merge the area covered by the perimeters")
+			}
+		}
+	}
+
+	gaps, ok := c.Difference(available, covered)
+	if !ok {
+		return nil, errors.New("could not calculate the gaps between the perimeters")
+	}
+
+	var result []data.LayerPart
+	for _, gap := range gaps {
+		// a line shorter than the width would not be printed anyway
+		if partArea(gap) < width*minWidth {
+			continue
+		}
+
+		// remove slivers narrower than the min width
+		for _, shrunk := range c.Inset(gap, 0, 1, -minWidth/2)[0] {
+			result = append(result, c.Inset(shrunk, 0, 1, minWidth/2)[0]...)
+		}
+	}
+
+	return result, nil
+}
+
+// clampWidth returns the width limited to the range from min to max.
+func clampWidth(width, min, max data.Micrometer) data.Micrometer {
+	if width > max {
+		return max
+	}
+	if width < min {
+		return min
+	}
+	return width
+}
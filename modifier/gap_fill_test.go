@@ -0,0 +1,93 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestGapFill(t *testing.T) {
+	var width data.Micrometer = 400
+	initialOffset := -width / 2
+
+	var testCases = map[string]struct {
+		// partWidth is the width of a rectangle which is 20 mm long
+		partWidth data.Micrometer
+		minWidth  data.Micrometer
+		lastInset int
+		// expectedWidth is the width of the single gap fill line along the center of the rectangle, 0 if there is none
+		expectedWidth data.Micrometer
+	}{
+		"wide enough for both perimeters": {
+			partWidth: 1600,
+			minWidth:  100,
+			lastInset: 2,
+		},
+		"too narrow for the inner perimeter": {
+			partWidth:     1200,
+			minWidth:      100,
+			lastInset:     2,
+			expectedWidth: 400,
+		},
+		"narrow gap": {
+			partWidth:     1000,
+			minWidth:      100,
+			lastInset:     2,
+			expectedWidth: 200,
+		},
+		"gap narrower than the min width": {
+			partWidth: 900,
+			minWidth:  150,
+			lastInset: 2,
+		},
+		"gap of the inner perimeter is skipped": {
+			partWidth: 1200,
+			minWidth:  100,
+			lastInset: 1,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		c := clip.NewClipper()
+		part := rectanglePart(0, 0, 20000, testCase.partWidth)
+		perimeters := c.Inset(part, width, 2, initialOffset)
+
+		lines, err := gapFill(c, part, perimeters, width, initialOffset, testCase.minWidth, 0, testCase.lastInset)
+		test.Ok(t, err)
+		if testCase.expectedWidth == 0 {
+			test.Equals(t, 0, len(lines))
+			continue
+		}
+
+		test.Equals(t, 1, len(lines))
+		test.Assert(t, !lines[0].Closed, "the gap fill line should be open")
+		test.Assert(t, lines[0].Width >= testCase.expectedWidth-5 && lines[0].Width <= testCase.expectedWidth+5, "expected the width %v but got %v", testCase.expectedWidth, lines[0].Width)
+
+		// the line runs along the center of the gap
+		min, max := lines[0].Path.Bounds()
+		center := testCase.partWidth / 2
+		test.Assert(t, min.Y() >= center-50 && max.Y() <= center+50, "the line should be near %v but is between %v and %v", center, min.Y(), max.Y())
+		test.Assert(t, max.X()-min.X() > 18000, "the line should fill the whole length of the gap")
+	}
+}
+
+func TestPerimeterGaps(t *testing.T) {
+	c := clip.NewClipper()
+	part := rectanglePart(0, 0, 20000, 1200)
+	perimeters := c.Inset(part, 400, 2, -200)
+
+	// the outer perimeter covers the part up to 400 from its border, the inner perimeter does not fit into the rest
+	gaps, err := perimeterGaps(c, part, perimeters[1], 400, -600, 100)
+	test.Ok(t, err)
+	test.Equals(t, 1, len(gaps))
+	min, max := partsBounds(gaps)
+	test.Equals(t, data.NewMicroPoint(400, 400), min, microPointComparer())
+	test.Equals(t, data.NewMicroPoint(19600, 800), max, microPointComparer())
+
+	// the outer perimeter has no gaps
+	gaps, err = perimeterGaps(c, part, perimeters[0], 400, -200, 100)
+	test.Ok(t, err)
+	test.Equals(t, 0, len(gaps))
+}
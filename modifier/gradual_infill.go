@@ -0,0 +1,118 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// GradualInfillAttribute returns the name of the attribute which contains the infill of the given step
+// of the gradual infill (see NewGradualInfillModifier). Step 1 is the nearest step below the top skins.
+func GradualInfillAttribute(step int) string {
+	return fmt.Sprintf("gradualInfill%d", step)
+}
+
+type gradualInfillModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m gradualInfillModifier) Init(model data.OptimizedModel) {}
+
+func (m gradualInfillModifier) LayerContext() int {
+	height := m.options.Print.GradualInfillStepHeight.ToMicrometer()
+	return m.options.Print.LayerHeights().MaxLayers(data.Micrometer(m.options.Print.GradualInfillSteps) * height)
+}
+
+// NewGradualInfillModifier creates a modifier which increases the infill density step by step below the top skins,
+// so that a sparse infill still supports the top skins well.
+// The infill which lies below the top skins of the layers within the height of a step
+// is moved from the attribute "infill" to the attribute of the step (see GradualInfillAttribute).
+// The density doubles with each step towards the top skins, e.g. 10%, 20%, 40%, 80% for three steps.
+// The top skins are grown by the distance of the sparse infill lines,
+// so that also the lines near the border of the skins are printed denser.
+// It has to run after the skin support modifier.
+func NewGradualInfillModifier(options *data.Options) handler.LayerModifier {
+	return &gradualInfillModifier{
+		Named: handler.Named{
+			Name: "GradualInfill",
+		},
+		options: options,
+	}
+}
+
+func (m gradualInfillModifier) Modify(layers []data.PartitionedLayer) error {
+	steps := m.options.Print.GradualInfillSteps
+	if steps <= 0 || m.options.Print.GradualInfillStepHeight <= 0 || m.options.Print.InfillPercent <= 0 {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	heights := m.options.Print.LayerHeights()
+	stepHeight := m.options.Print.GradualInfillStepHeight.ToMicrometer()
+	// the distance between the lines of the sparse infill
+	infillSpacing := m.options.Printer.ExtrusionWidth * 100 / data.Micrometer(m.options.Print.InfillPercent)
+
+	for layerNr := range layers {
+		infill, err := PartsAttribute(layers[layerNr], "infill")
+		if err != nil {
+			return err
+		}
+		if len(infill) == 0 {
+			continue
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+
+		// the top skins of all layers up to the current step, they overlap each other so they are merged one by one
+		var tops []data.LayerPart
+		above := layerNr + 1
+		// the last layer of the current step, each step contains at least one layer
+		stepTop := layerNr
+		for step := 1; step <= steps && len(infill) > 0; step++ {
+			stepLayers := heights.LayersAbove(stepTop, stepHeight)
+			if stepLayers < 1 {
+				stepLayers = 1
+			}
+			stepTop += stepLayers
+			for ; above < len(layers) && above <= stepTop; above++ {
+				top, err := TopInfill(layers[above])
+				if err != nil {
+					return err
+				}
+				for _, part := range top {
+					for _, grown := range c.Inset(part, 0, 1, infillSpacing)[0] {
+						var ok bool
+						tops, ok = c.Union(tops, []data.LayerPart{grown})
+						if !ok {
+							return fmt.Errorf("could not merge the top skins above layer %d", layerNr)
+						}
+					}
+				}
+			}
+			if len(tops) == 0 {
+				continue
+			}
+
+			stepInfill, ok := c.Intersection(infill, tops)
+			if !ok {
+				return fmt.Errorf("could not intersect the infill of layer %d with the top skins above", layerNr)
+			}
+			if len(stepInfill) == 0 {
+				continue
+			}
+
+			infill, ok = c.Difference(infill, stepInfill)
+			if !ok {
+				return fmt.Errorf("could not subtract the gradual infill from the infill of layer %d", layerNr)
+			}
+			newLayer.attributes[GradualInfillAttribute(step)] = stepInfill
+		}
+
+		newLayer.attributes["infill"] = infill
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
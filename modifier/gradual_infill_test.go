@@ -0,0 +1,88 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestGradualInfillModifier(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.InfillPercent = 20
+	options.Print.GradualInfillSteps = 2
+	// each step contains two layers
+	options.Print.GradualInfillStepHeight = 0.4
+	options.Printer.ExtrusionWidth = 400
+	// the top skins are grown by the distance of the sparse infill lines
+	spacing := data.Micrometer(2000)
+
+	testLayers := layers(nil, nil, nil, nil, nil, nil)
+	tops := map[int]data.LayerPart{
+		// step 1
+		2: rectanglePart(0, 0, 4000, 4000),
+		// step 2
+		4: rectanglePart(14000, 14000, 20000, 20000),
+		// above all steps
+		5: rectanglePart(0, 14000, 4000, 20000),
+	}
+	for layerNr := range testLayers {
+		layer := newExtendedLayer(testLayers[layerNr])
+		if layerNr == 0 {
+			layer.attributes["infill"] = []data.LayerPart{rectanglePart(0, 0, 20000, 20000)}
+		}
+		if top, ok := tops[layerNr]; ok {
+			layer.attributes["top"] = []data.LayerPart{top}
+		}
+		testLayers[layerNr] = layer
+	}
+
+	err := NewGradualInfillModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+
+	var testCases = map[string]struct {
+		attribute   string
+		expectedMin data.MicroPoint
+		expectedMax data.MicroPoint
+	}{
+		"step 1": {
+			attribute:   GradualInfillAttribute(1),
+			expectedMin: data.NewMicroPoint(0, 0),
+			expectedMax: data.NewMicroPoint(4000+spacing, 4000+spacing),
+		},
+		"step 2": {
+			attribute:   GradualInfillAttribute(2),
+			expectedMin: data.NewMicroPoint(14000-spacing, 14000-spacing),
+			expectedMax: data.NewMicroPoint(20000, 20000),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		parts, err := PartsAttribute(testLayers[0], testCase.attribute)
+		test.Ok(t, err)
+		test.Equals(t, 1, len(parts))
+		min, max := partsBounds(parts)
+		test.Equals(t, testCase.expectedMin, min, microPointComparer())
+		test.Equals(t, testCase.expectedMax, max, microPointComparer())
+	}
+
+	// the gradual infill is removed from the sparse infill, the top skin above all steps is ignored
+	infill, err := PartsAttribute(testLayers[0], "infill")
+	test.Ok(t, err)
+	test.Equals(t, 1, len(infill))
+	test.Assert(t, !data.PartContains(infill[0], data.NewMicroPoint(1000, 1000)), "the infill of step 1 should be removed")
+	test.Assert(t, !data.PartContains(infill[0], data.NewMicroPoint(19000, 19000)), "the infill of step 2 should be removed")
+	test.Assert(t, data.PartContains(infill[0], data.NewMicroPoint(1000, 19000)), "the infill below the top skin above all steps should stay")
+
+	// without steps the infill stays as it is
+	options.Print.GradualInfillSteps = 0
+	testLayers = layers([]data.LayerPart{rectanglePart(0, 0, 20000, 20000)})
+	unchanged := newExtendedLayer(testLayers[0])
+	unchanged.attributes["infill"] = []data.LayerPart{rectanglePart(0, 0, 20000, 20000)}
+	testLayers[0] = unchanged
+	err = NewGradualInfillModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+	parts, err := PartsAttribute(testLayers[0], GradualInfillAttribute(1))
+	test.Ok(t, err)
+	test.Equals(t, 0, len(parts))
+}
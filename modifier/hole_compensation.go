@@ -0,0 +1,86 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type holeCompensationModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+// NewHoleCompensationModifier creates a modifier which moves the contours of all holes outwards
+// by the hole compensation, as holes are always printed a bit too small.
+// The outer contours of the parts are not changed. Holes which grow into each other or into the outline are merged.
+//
+// It has to run before the hollow modifier so that the cavities are not changed.
+func NewHoleCompensationModifier(options *data.Options) handler.LayerModifier {
+	return &holeCompensationModifier{
+		Named: handler.Named{
+			Name: "HoleCompensation",
+		},
+		options: options,
+	}
+}
+
+func (m holeCompensationModifier) Init(_ data.OptimizedModel) {}
+
+func (m holeCompensationModifier) LayerContext() int {
+	return 0
+}
+
+func (m holeCompensationModifier) Modify(layers []data.PartitionedLayer) error {
+	compensation := m.options.Print.HoleCompensation.ToMicrometer()
+	if compensation <= 0 {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	return data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
+		var parts []data.LayerPart
+		for _, part := range layers[layerNr].LayerParts() {
+			compensated, ok := compensateHoles(c, part, compensation)
+			if !ok {
+				return fmt.Errorf("could not compensate the holes of layer %d", layerNr)
+			}
+			parts = append(parts, compensated...)
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.PartitionedLayer = data.NewPartitionedLayer(parts)
+		layers[layerNr] = newLayer
+		return nil
+	})
+}
+
+// compensateHoles returns the part with all holes grown by the given distance.
+// It may return several parts if a grown hole splits the part.
+func compensateHoles(c clip.Clipper, part data.LayerPart, distance data.Micrometer) ([]data.LayerPart, bool) {
+	if len(part.Holes()) == 0 {
+		return []data.LayerPart{part}, true
+	}
+
+	var grownHoles []data.LayerPart
+	for _, hole := range part.Holes() {
+		// offset the hole as counter clockwise outline so that it grows
+		if hole.Area() < 0 {
+			hole = hole.Reversed()
+		}
+		grown := c.Inset(data.NewBasicLayerPart(hole, nil), 0, 1, distance)
+		if len(grown) == 0 {
+			continue
+		}
+
+		// union the holes one by one as overlapping holes would cancel each other out
+		var ok bool
+		grownHoles, ok = c.Union(grownHoles, grown[0])
+		if !ok {
+			return nil, false
+		}
+	}
+
+	return c.Difference([]data.LayerPart{data.NewBasicLayerPart(part.Outline(), nil)}, grownHoles)
+}
@@ -0,0 +1,118 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp/cmpopts"
+	"testing"
+)
+
+func TestCompensateHoles(t *testing.T) {
+	// withHoles returns a part covering the square with the side length 10000 and the given holes.
+	withHoles := func(holes ...data.Path) data.LayerPart {
+		var reversed data.Paths
+		for _, hole := range holes {
+			reversed = append(reversed, hole.Reversed())
+		}
+		return data.NewBasicLayerPart(rectangle(0, 0, 10000, 10000), reversed)
+	}
+
+	// bounds contains min x, min y, max x and max y
+	type bounds [4]data.Micrometer
+
+	var testCases = map[string]struct {
+		part     data.LayerPart
+		distance data.Micrometer
+		// expectedParts contains the bounds of the outline of each resulting part
+		// and expectedHoles the bounds of each resulting hole
+		expectedParts []bounds
+		expectedHoles []bounds
+	}{
+		"without holes": {
+			part:          withHoles(),
+			distance:      200,
+			expectedParts: []bounds{{0, 0, 10000, 10000}},
+		},
+		"one hole": {
+			part:          withHoles(rectangle(4000, 4000, 6000, 6000)),
+			distance:      200,
+			expectedParts: []bounds{{0, 0, 10000, 10000}},
+			expectedHoles: []bounds{{3800, 3800, 6200, 6200}},
+		},
+		"holes grow into each other": {
+			part:          withHoles(rectangle(2000, 4000, 4900, 6000), rectangle(5100, 4000, 8000, 6000)),
+			distance:      200,
+			expectedParts: []bounds{{0, 0, 10000, 10000}},
+			expectedHoles: []bounds{{1800, 3800, 8200, 6200}},
+		},
+		"hole grows into the outline": {
+			part:     withHoles(rectangle(4000, 100, 6000, 9900)),
+			distance: 200,
+			// the grown hole has round corners, so the parts are a bit wider at the outline
+			expectedParts: []bounds{
+				{0, 0, 3817, 10000},
+				{6183, 0, 10000, 10000},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		parts, ok := compensateHoles(clip.NewClipper(), testCase.part, testCase.distance)
+		test.Assert(t, ok, "the holes should be compensated")
+
+		var partBounds, holeBounds []bounds
+		for _, part := range parts {
+			min, max := part.Outline().Bounds()
+			partBounds = append(partBounds, bounds{min.X(), min.Y(), max.X(), max.Y()})
+			for _, hole := range part.Holes() {
+				min, max := hole.Bounds()
+				holeBounds = append(holeBounds, bounds{min.X(), min.Y(), max.X(), max.Y()})
+			}
+		}
+
+		test.Equals(t, testCase.expectedParts, partBounds, cmpopts.SortSlices(func(b1, b2 bounds) bool {
+			return b1[0] < b2[0]
+		}))
+		test.Equals(t, testCase.expectedHoles, holeBounds)
+	}
+}
+
+func TestHoleCompensationModifier(t *testing.T) {
+	part := data.NewBasicLayerPart(rectangle(0, 0, 10000, 10000), data.Paths{rectangle(4000, 4000, 6000, 6000).Reversed()})
+
+	var testCases = map[string]struct {
+		compensation data.Millimeter
+		expectedMin  data.MicroPoint
+		expectedMax  data.MicroPoint
+	}{
+		"disabled": {
+			compensation: 0,
+			expectedMin:  data.NewMicroPoint(4000, 4000),
+			expectedMax:  data.NewMicroPoint(6000, 6000),
+		},
+		"enabled": {
+			compensation: 0.1,
+			expectedMin:  data.NewMicroPoint(3900, 3900),
+			expectedMax:  data.NewMicroPoint(6100, 6100),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.HoleCompensation = testCase.compensation
+
+		testLayers := layers([]data.LayerPart{part})
+		err := NewHoleCompensationModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		parts := testLayers[0].LayerParts()
+		test.Equals(t, 1, len(parts))
+		test.Equals(t, 1, len(parts[0].Holes()))
+		min, max := parts[0].Holes()[0].Bounds()
+		test.Equals(t, testCase.expectedMin, min, microPointComparer())
+		test.Equals(t, testCase.expectedMax, max, microPointComparer())
+	}
+}
@@ -0,0 +1,181 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"math"
+	"sort"
+)
+
+type hollowModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m hollowModifier) Init(_ data.OptimizedModel) {}
+
+// LayerContext returns -1 if drain holes are enabled as they may go through all layers below a cavity.
+func (m hollowModifier) LayerContext() int {
+	switch {
+	case !m.options.Print.Hollow.Enabled:
+		return 0
+	case m.options.Print.Hollow.DrainHoleDiameter > 0:
+		return -1
+	default:
+		return m.options.Print.LayerHeights().MaxLayers(m.options.Print.Hollow.WallThickness.ToMicrometer())
+	}
+}
+
+// NewHollowModifier hollows the model so that only a shell with the configured wall thickness remains.
+// The cavity of a layer is the area which is at least the wall thickness away from the outline
+// of the layer itself and of all layers within the wall thickness above and below it.
+// It is cut out of the layer parts, so that it becomes a hole which gets perimeters like any other hole.
+//
+// If a drain hole diameter is set, a hole is punched through the bottom shell below each cavity.
+//
+// It has to run before any other modifier except the printable overhang and the hole compensation modifier as it changes the layer parts directly.
+func NewHollowModifier(options *data.Options) handler.LayerModifier {
+	return &hollowModifier{
+		Named: handler.Named{
+			Name: "Hollow",
+		},
+		options: options,
+	}
+}
+
+func (m hollowModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.Hollow.Enabled {
+		return nil
+	}
+
+	thickness := m.options.Print.Hollow.WallThickness.ToMicrometer()
+	heights := m.options.Print.LayerHeights()
+
+	cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	insets := make([][]data.LayerPart, len(layers))
+	for layerNr, layer := range layers {
+		insets[layerNr] = cl.InsetLayer(layer.LayerParts(), thickness, 1, -thickness).ToOneDimension()
+	}
+
+	// calculate all cavities first as they depend on the unchanged layers
+	cavities := make([][]data.LayerPart, len(layers))
+	for layerNr := range layers {
+		// the layers of the shell below and above the layer
+		below, above := heights.LayersBelow(layerNr, thickness), heights.LayersAbove(layerNr, thickness)
+		if layerNr-below < 0 || layerNr+above >= len(layers) {
+			continue
+		}
+
+		cavity := insets[layerNr]
+		for otherNr := layerNr - below; otherNr <= layerNr+above && len(cavity) > 0; otherNr++ {
+			if otherNr == layerNr {
+				continue
+			}
+
+			var ok bool
+			cavity, ok = cl.Intersection(cavity, insets[otherNr])
+			if !ok {
+				return fmt.Errorf("could not calculate the cavity of layer %d", layerNr)
+			}
+		}
+		cavities[layerNr] = cavity
+	}
+
+	parts := make([][]data.LayerPart, len(layers))
+	for layerNr, layer := range layers {
+		var ok bool
+		parts[layerNr], ok = cl.Difference(layer.LayerParts(), cavities[layerNr])
+		if !ok {
+			return fmt.Errorf("could not hollow layer %d", layerNr)
+		}
+	}
+
+	if m.options.Print.Hollow.DrainHoleDiameter > 0 {
+		err := drainHoles(cl, parts, cavities, m.options.Print.Hollow.DrainHoleDiameter.ToMicrometer()/2)
+		if err != nil {
+			return err
+		}
+	}
+
+	for layerNr := range layers {
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.PartitionedLayer = data.NewPartitionedLayer(parts[layerNr])
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
+
+// drainHoles punches a hole with the given radius below each cavity which does not continue a cavity of the layer below.
+// The hole is cut out of the parts of all layers below until it reaches a layer which it does not touch.
+func drainHoles(cl clip.Clipper, parts [][]data.LayerPart, cavities [][]data.LayerPart, radius data.Micrometer) error {
+	for layerNr := 1; layerNr < len(cavities); layerNr++ {
+		for _, cavity := range cavities[layerNr] {
+			below, ok := cl.Intersection([]data.LayerPart{cavity}, cavities[layerNr-1])
+			if !ok {
+				return fmt.Errorf("could not find the bottom of the cavities of layer %d", layerNr)
+			}
+			if len(below) > 0 {
+				continue
+			}
+
+			// Place the hole so that it touches the wall of the cavity if it fits into it.
+			var center data.MicroPoint
+			if fitting := cl.Inset(cavity, radius, 1, -radius); len(fitting[0]) > 0 {
+				center = fitting[0][0].Outline()[0]
+			} else {
+				center = innerPoint(cavity)
+			}
+			hole := []data.LayerPart{data.NewBasicLayerPart(polyhole(center, radius, false), nil)}
+
+			for belowNr := layerNr - 1; belowNr >= 0; belowNr-- {
+				touched, ok := cl.Intersection(parts[belowNr], hole)
+				if !ok {
+					return fmt.Errorf("could not punch the drain hole into layer %d", belowNr)
+				}
+				if len(touched) == 0 {
+					break
+				}
+
+				parts[belowNr], ok = cl.Difference(parts[belowNr], hole)
+				if !ok {
+					return fmt.Errorf("could not punch the drain hole into layer %d", belowNr)
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+// innerPoint returns a point inside of the part, also if the part is concave.
+// It is the middle of the widest section of the horizontal line through the center of the bounds of the part.
+func innerPoint(part data.LayerPart) data.MicroPoint {
+	min, max := part.Outline().Bounds()
+	y := (min.Y() + max.Y()) / 2
+
+	var crossings []float64
+	for _, polygon := range append([]data.Path{part.Outline()}, part.Holes()...) {
+		for i, a := range polygon {
+			b := polygon[(i+1)%len(polygon)]
+			if (a.Y() > y) == (b.Y() > y) {
+				continue
+			}
+			crossings = append(crossings, float64(a.X())+float64(y-a.Y())*float64(b.X()-a.X())/float64(b.Y()-a.Y()))
+		}
+	}
+	sort.Float64s(crossings)
+
+	// the sections between the crossings 0 and 1, 2 and 3, ... are inside of the part
+	center := data.NewMicroPoint((min.X()+max.X())/2, y)
+	widest := 0.0
+	for i := 0; i+1 < len(crossings); i += 2 {
+		if width := crossings[i+1] - crossings[i]; width > widest {
+			widest = width
+			center = data.NewMicroPoint(data.Micrometer(math.Round((crossings[i]+crossings[i+1])/2)), y)
+		}
+	}
+	return center
+}
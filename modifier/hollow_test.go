@@ -0,0 +1,70 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// cShape returns a concave part around the center of its bounds (5000, 5000) which is open to the right.
+func cShape() data.LayerPart {
+	return data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 2000),
+		data.NewMicroPoint(2000, 2000),
+		data.NewMicroPoint(2000, 8000),
+		data.NewMicroPoint(10000, 8000),
+		data.NewMicroPoint(10000, 10000),
+		data.NewMicroPoint(0, 10000),
+	}, nil)
+}
+
+func TestInnerPoint(t *testing.T) {
+	var testCases = map[string]struct {
+		part     data.LayerPart
+		expected data.MicroPoint
+	}{
+		"convex": {
+			part:     rectanglePart(0, 0, 10000, 4000),
+			expected: data.NewMicroPoint(5000, 2000),
+		},
+		"concave": {
+			part:     cShape(),
+			expected: data.NewMicroPoint(1000, 5000),
+		},
+		"with a hole": {
+			part:     data.NewBasicLayerPart(rectangle(0, 0, 10000, 10000), data.Paths{rectangle(2000, 2000, 8000, 8000).Reversed()}),
+			expected: data.NewMicroPoint(1000, 5000),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		point := innerPoint(testCase.part)
+		test.Equals(t, testCase.expected, point, microPointComparer())
+		test.Assert(t, data.PartContains(testCase.part, point), "the point should be inside of the part")
+	}
+}
+
+func TestDrainHoles(t *testing.T) {
+	parts := [][]data.LayerPart{
+		{rectanglePart(-2000, -2000, 12000, 12000)},
+		{rectanglePart(-2000, -2000, 12000, 12000)},
+	}
+	// the cavity is too narrow for the hole and the center of its bounds is outside of it
+	cavities := [][]data.LayerPart{
+		nil,
+		{cShape()},
+	}
+
+	err := drainHoles(clip.NewClipper(), parts, cavities, 1500)
+	test.Ok(t, err)
+
+	test.Equals(t, 1, len(parts[0]))
+	test.Assert(t, !data.PartContains(parts[0][0], data.NewMicroPoint(1000, 5000)), "the hole should be below the cavity")
+	test.Assert(t, data.PartContains(parts[0][0], data.NewMicroPoint(5000, 5000)), "there should be no hole outside of the cavity")
+	test.Equals(t, 1, len(parts[1]))
+	test.Equals(t, 0, len(parts[1][0].Holes()))
+}
@@ -14,6 +14,14 @@ type infillModifier struct {
 
 func (m infillModifier) Init(model data.OptimizedModel) {}
 
+// LayerContext returns the number of top or bottom layers as the layers below and above define the top and bottom skins.
+func (m infillModifier) LayerContext() int {
+	if m.options.Print.NumberTopLayers > m.options.Print.NumberBottomLayers {
+		return m.options.Print.NumberTopLayers
+	}
+	return m.options.Print.NumberBottomLayers
+}
+
 // NewInfillModifier calculates the areas which need infill and passes them as "bottom" attribute to the layer.
 func NewInfillModifier(options *data.Options) handler.LayerModifier {
 	return &infillModifier{
@@ -32,6 +40,14 @@ func BottomInfill(layer data.PartitionedLayer) ([]data.LayerPart, error) {
 	return PartsAttribute(layer, "bottom")
 }
 
+// SupportedBottomInfill extracts the attribute "supportedBottom" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the infill is returned.
+func SupportedBottomInfill(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "supportedBottom")
+}
+
 // TopInfill extracts the attribute "top" from the layer.
 // If it has the wrong type, a error is returned.
 // If it doesn't exist, (nil, nil) is returned.
@@ -40,22 +56,49 @@ func TopInfill(layer data.PartitionedLayer) ([]data.LayerPart, error) {
 	return PartsAttribute(layer, "top")
 }
 
+// BottomSkinPerimeters extracts the attribute "bottomSkinPerimeters" from the layer.
+// It contains the perimeter loops around the bottom skins (see data.PrintOptions.SkinPerimeters).
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func BottomSkinPerimeters(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "bottomSkinPerimeters")
+}
+
+// TopSkinPerimeters extracts the attribute "topSkinPerimeters" from the layer.
+// It contains the perimeter loops around the top skins (see data.PrintOptions.SkinPerimeters).
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func TopSkinPerimeters(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "topSkinPerimeters")
+}
+
 func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
-	for layerNr := range layers {
+	// The layers are processed in parallel. As each layer also reads the layers below and above it,
+	// the new layers are collected first and replace the old ones after all layers are done.
+	newLayers := make([]data.PartitionedLayer, len(layers))
+	err := data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
 		overlappingPerimeters, err := OverlapPerimeters(layers[layerNr])
-		if err != nil || overlappingPerimeters == nil {
+		if err != nil {
 			return err
 		}
+		if overlappingPerimeters == nil {
+			// nothing to fill in an empty layer
+			return nil
+		}
 
 		perimeters, err := Perimeters(layers[layerNr])
-		if err != nil || perimeters == nil {
+		if err != nil {
 			return err
 		}
+		if perimeters == nil {
+			// nothing to fill in an empty layer
+			return nil
+		}
 
 		var bottomInfill []data.LayerPart
 		var topInfill []data.LayerPart
 
-		c := clip.NewClipper()
+		c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
 
 		// Calculate the bottom/top parts for each inner perimeter part.
 		// It also takes into account the configured number of top/bottom layers.
@@ -77,7 +120,7 @@ func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
 						break
 					} else {
 						// else calculate the difference and use it
-						parts, err = partDifference(insetPart, layers[layerNr-1-i])
+						parts, err = partDifference(c, insetPart, layers[layerNr-1-i])
 						if err != nil {
 							return err
 						}
@@ -106,7 +149,7 @@ func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
 						break
 					} else {
 						// else calculate the difference and use it
-						parts, err = partDifference(insetPart, layers[layerNr+1+i])
+						parts, err = partDifference(c, insetPart, layers[layerNr+1+i])
 						if err != nil {
 							return err
 						}
@@ -125,10 +168,10 @@ func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
 				}
 
 				// 2. Exset the area which needs infill to generate the internal overlap of top and bottom layer.
-				fullOverlapPercentage := m.options.Print.InfillOverlapPercent + m.options.Print.AdditionalInternalInfillOverlapPercent
+				fullOverlap := perimeterOverlap(m.options.Print.InfillOverlap, m.options.Print.InfillOverlapPercent, m.options.Print.AdditionalInternalInfillOverlapPercent, m.options.Printer.LayerExtrusionWidth(layerNr))
 				var internalOverlappingBottomParts, internalOverlappingTopParts []data.LayerPart
 				for _, bottomPart := range bottomInfillParts {
-					overlappingParts, err := calculateOverlapPerimeter(bottomPart, fullOverlapPercentage, m.options.Printer.ExtrusionWidth)
+					overlappingParts, err := calculateOverlapPerimeter(bottomPart, fullOverlap, m.options.GoSlice.Logger)
 					if err != nil {
 						return err
 					}
@@ -137,7 +180,7 @@ func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
 				}
 
 				for _, topPart := range topInfillParts {
-					overlappingParts, err := calculateOverlapPerimeter(topPart, fullOverlapPercentage, m.options.Printer.ExtrusionWidth)
+					overlappingParts, err := calculateOverlapPerimeter(topPart, fullOverlap, m.options.GoSlice.Logger)
 					if err != nil {
 						return err
 					}
@@ -189,9 +232,41 @@ func (m infillModifier) Modify(layers []data.PartitionedLayer) error {
 			newLayer.attributes["top"] = topInfill
 		}
 
-		layers[layerNr] = newLayer
+		newLayers[layerNr] = newLayer
+		return nil
+	})
+	if err != nil {
+		return err
+	}
 
+	for layerNr, newLayer := range newLayers {
+		if newLayer != nil {
+			layers[layerNr] = newLayer
+		}
 	}
 
 	return nil
 }
+
+// insetSkin pulls the skin parts inside by the skin inset and surrounds them by the configured amount of skin perimeters.
+// It returns the remaining area to fill and the center lines of the skin perimeters.
+func insetSkin(options *data.Options, skin []data.LayerPart, extrusionWidth data.Micrometer) (fill []data.LayerPart, perimeters []data.LayerPart) {
+	inset := options.Print.SkinInset.ToMicrometer()
+	count := options.Print.SkinPerimeters
+	if inset <= 0 && count <= 0 {
+		return skin, nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(options.GoSlice.Logger))
+	for _, part := range skin {
+		if count > 0 {
+			for _, loops := range c.Inset(part, extrusionWidth, count, -inset-extrusionWidth/2) {
+				perimeters = append(perimeters, loops...)
+			}
+		}
+
+		fill = append(fill, c.Inset(part, extrusionWidth, 1, -inset-data.Micrometer(count)*extrusionWidth)[0]...)
+	}
+
+	return fill, perimeters
+}
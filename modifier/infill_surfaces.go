@@ -0,0 +1,87 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type infillSurfacesModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m infillSurfacesModifier) Init(model data.OptimizedModel) {}
+
+func (m infillSurfacesModifier) LayerContext() int {
+	if !m.enabled() {
+		return 0
+	}
+	return m.options.Print.LayerHeights().MaxLayers(m.options.Print.InfillAdaptiveDistance.ToMicrometer())
+}
+
+// NewInfillSurfacesModifier creates a modifier which saves the top surfaces of the layers above, which are not further
+// away than the infill adaptive distance, as the attribute "infillSurfaces" to each layer with sparse infill.
+// They are passed to the infill pattern, so that the "adaptive-cubic" pattern can fill denser below them
+// (see clip.LayerInfo.Surfaces). The modifier only runs if that pattern is selected.
+// It has to run after the internal infill modifier.
+func NewInfillSurfacesModifier(options *data.Options) handler.LayerModifier {
+	return &infillSurfacesModifier{
+		Named: handler.Named{
+			Name: "InfillSurfaces",
+		},
+		options: options,
+	}
+}
+
+// enabled returns true if the selected infill pattern uses the surfaces.
+func (m infillSurfacesModifier) enabled() bool {
+	return m.options.Print.InfillPattern == "adaptive-cubic" && m.options.Print.InfillAdaptiveDistance > 0
+}
+
+func (m infillSurfacesModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.enabled() {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	heights := m.options.Print.LayerHeights()
+	distance := m.options.Print.InfillAdaptiveDistance.ToMicrometer()
+
+	for layerNr := range layers {
+		infill, err := PartsAttribute(layers[layerNr], "infill")
+		if err != nil {
+			return err
+		}
+		if len(infill) == 0 {
+			continue
+		}
+
+		// the top surfaces overlap each other, so they have to be merged one by one
+		var surfaces []data.LayerPart
+		layerCount := heights.LayersAbove(layerNr, distance)
+		for above := layerNr + 1; above < len(layers) && above <= layerNr+layerCount; above++ {
+			top, err := TopInfill(layers[above])
+			if err != nil {
+				return err
+			}
+			for _, part := range top {
+				var ok bool
+				surfaces, ok = c.Union(surfaces, []data.LayerPart{part})
+				if !ok {
+					return fmt.Errorf("could not merge the top surfaces above layer %d", layerNr)
+				}
+			}
+		}
+		if len(surfaces) == 0 {
+			continue
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["infillSurfaces"] = surfaces
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
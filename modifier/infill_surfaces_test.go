@@ -0,0 +1,80 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestInfillSurfacesModifier(t *testing.T) {
+	// infillSurfacesLayers returns five layers with infill in the first one and a top surface in each layer above.
+	infillSurfacesLayers := func() []data.PartitionedLayer {
+		testLayers := layers(nil, nil, nil, nil, nil)
+		for layerNr := range testLayers {
+			layer := newExtendedLayer(testLayers[layerNr])
+			if layerNr == 0 {
+				layer.attributes["infill"] = []data.LayerPart{rectanglePart(0, 0, 20000, 20000)}
+			} else {
+				x := data.Micrometer(layerNr-1) * 5000
+				layer.attributes["top"] = []data.LayerPart{rectanglePart(x, 0, x+5000, 5000)}
+			}
+			testLayers[layerNr] = layer
+		}
+		return testLayers
+	}
+
+	var testCases = map[string]struct {
+		pattern  string
+		distance data.Millimeter
+		// expectedMax is the max of the bounds of the surfaces of the first layer, nil if there are none
+		expectedMax data.MicroPoint
+	}{
+		"surfaces within the distance": {
+			pattern:     "adaptive-cubic",
+			distance:    0.4,
+			expectedMax: data.NewMicroPoint(10000, 5000),
+		},
+		"larger distance": {
+			pattern:     "adaptive-cubic",
+			distance:    0.8,
+			expectedMax: data.NewMicroPoint(20000, 5000),
+		},
+		"other pattern": {
+			pattern:  "cubic",
+			distance: 0.4,
+		},
+		"without distance": {
+			pattern:  "adaptive-cubic",
+			distance: 0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.InfillPattern = testCase.pattern
+		options.Print.InfillAdaptiveDistance = testCase.distance
+
+		testLayers := infillSurfacesLayers()
+		err := NewInfillSurfacesModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		surfaces, err := PartsAttribute(testLayers[0], "infillSurfaces")
+		test.Ok(t, err)
+		if testCase.expectedMax == nil {
+			test.Equals(t, 0, len(surfaces))
+			continue
+		}
+
+		// the overlapping surfaces are merged into one part
+		test.Equals(t, 1, len(surfaces))
+		min, max := partsBounds(surfaces)
+		test.Equals(t, data.NewMicroPoint(0, 0), min, microPointComparer())
+		test.Equals(t, testCase.expectedMax, max, microPointComparer())
+
+		// only the layers with infill get the surfaces
+		surfaces, err = PartsAttribute(testLayers[1], "infillSurfaces")
+		test.Ok(t, err)
+		test.Equals(t, 0, len(surfaces))
+	}
+}
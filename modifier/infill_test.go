@@ -0,0 +1,87 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestInsetSkin(t *testing.T) {
+	skin := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+
+	var testCases = map[string]struct {
+		inset              data.Millimeter
+		perimeters         int
+		expectedFillMin    data.MicroPoint
+		expectedFillMax    data.MicroPoint
+		expectedPerimeters []data.MicroPoint
+	}{
+		"unchanged": {
+			expectedFillMin: data.NewMicroPoint(0, 0),
+			expectedFillMax: data.NewMicroPoint(10000, 10000),
+		},
+		"inset": {
+			inset:           1,
+			expectedFillMin: data.NewMicroPoint(1000, 1000),
+			expectedFillMax: data.NewMicroPoint(9000, 9000),
+		},
+		"perimeters": {
+			perimeters:      2,
+			expectedFillMin: data.NewMicroPoint(800, 800),
+			expectedFillMax: data.NewMicroPoint(9200, 9200),
+			// the center lines of both loops
+			expectedPerimeters: []data.MicroPoint{data.NewMicroPoint(200, 200), data.NewMicroPoint(600, 600)},
+		},
+		"inset and perimeters": {
+			inset:              1,
+			perimeters:         1,
+			expectedFillMin:    data.NewMicroPoint(1400, 1400),
+			expectedFillMax:    data.NewMicroPoint(8600, 8600),
+			expectedPerimeters: []data.MicroPoint{data.NewMicroPoint(1200, 1200)},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.SkinInset = testCase.inset
+		options.Print.SkinPerimeters = testCase.perimeters
+
+		fill, perimeters := insetSkin(&options, skin, 400)
+
+		min, max := partsBounds(fill)
+		test.Equals(t, testCase.expectedFillMin, min, microPointComparer())
+		test.Equals(t, testCase.expectedFillMax, max, microPointComparer())
+
+		test.Equals(t, len(testCase.expectedPerimeters), len(perimeters))
+		for i, expectedMin := range testCase.expectedPerimeters {
+			min, _ := partsBounds(perimeters[i : i+1])
+			test.Equals(t, expectedMin, min, microPointComparer())
+		}
+	}
+}
+
+func TestSkinPerimeters(t *testing.T) {
+	bottom := []data.LayerPart{rectanglePart(0, 0, 1000, 1000)}
+	top := []data.LayerPart{rectanglePart(2000, 2000, 3000, 3000)}
+
+	layer := newExtendedLayer(data.NewPartitionedLayer(nil))
+	layer.attributes["bottomSkinPerimeters"] = bottom
+	layer.attributes["topSkinPerimeters"] = top
+
+	loops, err := BottomSkinPerimeters(layer)
+	test.Ok(t, err)
+	test.Equals(t, bottom, loops, layerPartComparer())
+
+	loops, err = TopSkinPerimeters(layer)
+	test.Ok(t, err)
+	test.Equals(t, top, loops, layerPartComparer())
+
+	loops, err = TopSkinPerimeters(data.NewPartitionedLayer(nil))
+	test.Ok(t, err)
+	test.Equals(t, 0, len(loops))
+
+	layer.attributes["topSkinPerimeters"] = "wrong type"
+	_, err = TopSkinPerimeters(layer)
+	test.Assert(t, err != nil, "an attribute with the wrong type should return an error")
+}
@@ -0,0 +1,201 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type interlockingModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+// NewInterlockingModifier interlocks the bodies printed by different extruders at their boundaries,
+// as two materials often stick only weakly to each other.
+//
+// For each pair of extruders it determines the zone near the boundary between their bodies:
+// the parts of each body which lie within the interlocking depth of the other body,
+// also of the bodies in the layers within the depth above and below.
+// The zone is divided into beams of the beam width, which are assigned alternately to both extruders.
+// So the beams of each extruder reach into the other body, also across horizontal boundaries.
+// The beams are aligned to the origin and run along the X axis or the Y axis,
+// the direction changes after each beam layer count, so that the crossing beams hold each other.
+//
+// It expects that the bodies do not overlap anymore (see NewMaterialOverlapModifier).
+func NewInterlockingModifier(options *data.Options) handler.MaterialModifier {
+	return &interlockingModifier{
+		Named: handler.Named{
+			Name: "Interlocking",
+		},
+		options: options,
+	}
+}
+
+func (m interlockingModifier) Modify(materials [][]data.PartitionedLayer) error {
+	interlocking := m.options.Print.Interlocking
+	if !interlocking.Enabled {
+		return nil
+	}
+	if interlocking.BeamWidth <= 0 || interlocking.BeamLayers < 1 || interlocking.Depth <= 0 {
+		return fmt.Errorf("the interlocking beam width %vmm, beam layers %v and depth %vmm have to be bigger than 0", interlocking.BeamWidth, interlocking.BeamLayers, interlocking.Depth)
+	}
+
+	for a := range materials {
+		for b := a + 1; b < len(materials); b++ {
+			if materials[a] == nil || materials[b] == nil {
+				continue
+			}
+
+			err := m.interlock(materials[a], materials[b])
+			if err != nil {
+				return fmt.Errorf("could not interlock the bodies of the extruders %d and %d: %w", a, b, err)
+			}
+		}
+	}
+
+	return nil
+}
+
+// interlock replaces the zone near the boundary between the bodies of two extruders by alternating beams of both.
+func (m interlockingModifier) interlock(a, b []data.PartitionedLayer) error {
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	depth := m.options.Print.Interlocking.Depth.ToMicrometer()
+	width := m.options.Print.Interlocking.BeamWidth.ToMicrometer()
+	heights := m.options.Print.LayerHeights()
+
+	// the bodies grown by the depth
+	grownA := make([][]data.LayerPart, len(a))
+	grownB := make([][]data.LayerPart, len(b))
+	for layerNr := range a {
+		grownA[layerNr] = c.InsetLayer(a[layerNr].LayerParts(), -depth, 1, depth).ToOneDimension()
+		grownB[layerNr] = c.InsetLayer(b[layerNr].LayerParts(), -depth, 1, depth).ToOneDimension()
+	}
+
+	partsA := make([][]data.LayerPart, len(a))
+	partsB := make([][]data.LayerPart, len(b))
+	// lowest is the lowest layer within the depth below the current layer.
+	// The layers get higher with their number, so the window of layers within the depth only moves up.
+	lowest := 0
+	for layerNr := range a {
+		z := heights.Z(layerNr)
+		for heights.Z(lowest) < z-depth {
+			lowest++
+		}
+
+		partsA[layerNr] = a[layerNr].LayerParts()
+		partsB[layerNr] = b[layerNr].LayerParts()
+		if len(partsA[layerNr]) == 0 && len(partsB[layerNr]) == 0 {
+			continue
+		}
+
+		// the bodies within the depth, also in the layers above and below
+		var nearA, nearB []data.LayerPart
+		for other := lowest; other < len(a) && heights.Z(other) <= z+depth; other++ {
+			var ok bool
+			nearA, ok = c.Union(nearA, grownA[other])
+			if !ok {
+				return fmt.Errorf("could not merge the grown bodies of layer %d", other)
+			}
+			nearB, ok = c.Union(nearB, grownB[other])
+			if !ok {
+				return fmt.Errorf("could not merge the grown bodies of layer %d", other)
+			}
+		}
+
+		zoneA, ok := c.Intersection(partsA[layerNr], nearB)
+		if !ok {
+			return fmt.Errorf("could not find the boundary in layer %d", layerNr)
+		}
+		zoneB, ok := c.Intersection(partsB[layerNr], nearA)
+		if !ok {
+			return fmt.Errorf("could not find the boundary in layer %d", layerNr)
+		}
+		zone, ok := c.Union(zoneA, zoneB)
+		if !ok {
+			return fmt.Errorf("could not find the boundary in layer %d", layerNr)
+		}
+		if len(zone) == 0 {
+			continue
+		}
+
+		beamsA, beamsB := interlockingBeams(zone, width, (layerNr/m.options.Print.Interlocking.BeamLayers)%2 == 1)
+
+		partsA[layerNr], ok = replaceZone(c, partsA[layerNr], zone, beamsA)
+		if !ok {
+			return fmt.Errorf("could not add the beams in layer %d", layerNr)
+		}
+		partsB[layerNr], ok = replaceZone(c, partsB[layerNr], zone, beamsB)
+		if !ok {
+			return fmt.Errorf("could not add the beams in layer %d", layerNr)
+		}
+	}
+
+	// the layers are replaced at the end, as the bodies of the layers above and below have to stay unchanged until then
+	for layerNr := range a {
+		newLayer := newExtendedLayer(a[layerNr])
+		newLayer.PartitionedLayer = data.NewPartitionedLayer(partsA[layerNr])
+		a[layerNr] = newLayer
+
+		newLayer = newExtendedLayer(b[layerNr])
+		newLayer.PartitionedLayer = data.NewPartitionedLayer(partsB[layerNr])
+		b[layerNr] = newLayer
+	}
+
+	return nil
+}
+
+// interlockingBeams returns the even and the odd beams of the given width which cover the parts.
+// The beams run along the X axis or, if alongY is set, along the Y axis.
+// They are aligned to the origin, so that the beams of all layers lie on top of each other.
+func interlockingBeams(parts []data.LayerPart, width data.Micrometer, alongY bool) (even, odd []data.LayerPart) {
+	min, max := data.NewPartitionedLayer(parts).Bounds()
+	from, to := min.Y(), max.Y()
+	if alongY {
+		from, to = min.X(), max.X()
+	}
+
+	// start at the even beam which contains from
+	start := from - from%(2*width)
+	if from%(2*width) < 0 {
+		start -= 2 * width
+	}
+
+	for position := start; position < to; position += 2 * width {
+		if alongY {
+			even = append(even, data.NewBasicLayerPart(rectanglePath(position, min.Y(), position+width, max.Y()), nil))
+			odd = append(odd, data.NewBasicLayerPart(rectanglePath(position+width, min.Y(), position+2*width, max.Y()), nil))
+		} else {
+			even = append(even, data.NewBasicLayerPart(rectanglePath(min.X(), position, max.X(), position+width), nil))
+			odd = append(odd, data.NewBasicLayerPart(rectanglePath(min.X(), position+width, max.X(), position+2*width), nil))
+		}
+	}
+
+	return even, odd
+}
+
+// rectanglePath returns a counter clockwise path around the rectangle from min to max.
+func rectanglePath(minX, minY, maxX, maxY data.Micrometer) data.Path {
+	return data.Path{
+		data.NewMicroPoint(minX, minY),
+		data.NewMicroPoint(maxX, minY),
+		data.NewMicroPoint(maxX, maxY),
+		data.NewMicroPoint(minX, maxY),
+	}
+}
+
+// replaceZone removes the zone from the parts and adds the beams within the zone instead.
+func replaceZone(c clip.Clipper, parts []data.LayerPart, zone []data.LayerPart, beams []data.LayerPart) ([]data.LayerPart, bool) {
+	rest, ok := c.Difference(parts, zone)
+	if !ok {
+		return nil, false
+	}
+
+	zoneBeams, ok := c.Intersection(zone, beams)
+	if !ok {
+		return nil, false
+	}
+
+	return c.Union(rest, zoneBeams)
+}
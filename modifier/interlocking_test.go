@@ -0,0 +1,124 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestInterlockingModifier(t *testing.T) {
+	var testCases = map[string]struct {
+		enabled    bool
+		beamWidth  data.Millimeter
+		beamLayers int
+		depth      data.Millimeter
+		// expectedError is set if the options are invalid
+		expectedError bool
+		// expectedA and expectedB contain the min and max x of the bodies of both extruders in each layer
+		expectedA [][2]data.Micrometer
+		expectedB [][2]data.Micrometer
+		// expectedPartCount contains the part count of the bodies of both extruders in each layer
+		expectedPartCount []int
+	}{
+		"disabled": {
+			beamWidth:         1,
+			beamLayers:        2,
+			depth:             2,
+			expectedA:         [][2]data.Micrometer{{0, 10000}, {0, 10000}, {0, 10000}, {0, 10000}},
+			expectedB:         [][2]data.Micrometer{{10000, 20000}, {10000, 20000}, {10000, 20000}, {10000, 20000}},
+			expectedPartCount: []int{1, 1, 1, 1},
+		},
+		"enabled": {
+			enabled:    true,
+			beamWidth:  1,
+			beamLayers: 2,
+			depth:      2,
+			// the beams reach 2mm into the other body
+			expectedA: [][2]data.Micrometer{{0, 12000}, {0, 12000}, {0, 11000}, {0, 11000}},
+			expectedB: [][2]data.Micrometer{{8000, 20000}, {8000, 20000}, {9000, 20000}, {9000, 20000}},
+			// the beams along the x axis are connected to the bodies like a comb,
+			// the beams along the y axis are separate parts after two layers
+			expectedPartCount: []int{1, 1, 2, 2},
+		},
+		"no beam width": {
+			enabled:       true,
+			beamLayers:    2,
+			depth:         2,
+			expectedError: true,
+		},
+		"no beam layers": {
+			enabled:       true,
+			beamWidth:     1,
+			depth:         2,
+			expectedError: true,
+		},
+		"no depth": {
+			enabled:       true,
+			beamWidth:     1,
+			beamLayers:    2,
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.InitialLayerThickness = 200
+		options.Print.LayerThickness = 200
+		options.Print.Interlocking.Enabled = testCase.enabled
+		options.Print.Interlocking.BeamWidth = testCase.beamWidth
+		options.Print.Interlocking.BeamLayers = testCase.beamLayers
+		options.Print.Interlocking.Depth = testCase.depth
+
+		a := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+		b := []data.LayerPart{rectanglePart(10000, 0, 20000, 10000)}
+		materials := [][]data.PartitionedLayer{
+			layers(a, a, a, a),
+			nil,
+			layers(b, b, b, b),
+		}
+
+		err := NewInterlockingModifier(&options).Modify(materials)
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "error expected")
+			continue
+		}
+		test.Ok(t, err)
+		test.Assert(t, materials[1] == nil, "the unused extruder should stay unused")
+
+		for layerNr := range materials[0] {
+			test.Equals(t, testCase.expectedA[layerNr], xBounds(materials[0][layerNr].LayerParts()))
+			test.Equals(t, testCase.expectedB[layerNr], xBounds(materials[2][layerNr].LayerParts()))
+			test.Equals(t, testCase.expectedPartCount[layerNr], len(materials[0][layerNr].LayerParts()))
+			test.Equals(t, testCase.expectedPartCount[layerNr], len(materials[2][layerNr].LayerParts()))
+		}
+	}
+}
+
+func TestInterlockingModifierDepth(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.InitialLayerThickness = 200
+	options.Print.LayerThickness = 200
+	options.Print.Interlocking.Enabled = true
+	options.Print.Interlocking.BeamWidth = 1
+	options.Print.Interlocking.BeamLayers = 2
+	options.Print.Interlocking.Depth = 0.3
+
+	// the body of the second extruder stands on the body of the first one
+	body := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+	materials := [][]data.PartitionedLayer{
+		layers(body, body, body, body, body, nil, nil, nil, nil, nil),
+		layers(nil, nil, nil, nil, nil, body, body, body, body, body),
+	}
+
+	err := NewInterlockingModifier(&options).Modify(materials)
+	test.Ok(t, err)
+
+	// only the layers within the depth of the boundary contain beams of both extruders
+	expectedA := []bool{true, true, true, true, true, true, false, false, false, false}
+	expectedB := []bool{false, false, false, false, true, true, true, true, true, true}
+	for layerNr := range materials[0] {
+		test.Equals(t, expectedA[layerNr], len(materials[0][layerNr].LayerParts()) > 0)
+		test.Equals(t, expectedB[layerNr], len(materials[1][layerNr].LayerParts()) > 0)
+	}
+}
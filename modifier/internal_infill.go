@@ -14,7 +14,13 @@ type internalInfillModifier struct {
 
 func (m internalInfillModifier) Init(model data.OptimizedModel) {}
 
-// NewInternalInfillModifier calculates the areas which need infill and passes them as "bottom" attribute to the layer.
+func (m internalInfillModifier) LayerContext() int {
+	return 0
+}
+
+// NewInternalInfillModifier calculates the areas which need infill and passes them as "infill" attribute to the layer.
+// On every n-th layer (see data.PrintOptions.SolidInfillEvery) they are passed as "solidInfill" attribute instead,
+// so that they are filled solid.
 func NewInternalInfillModifier(options *data.Options) handler.LayerModifier {
 	return &internalInfillModifier{
 		Named: handler.Named{
@@ -25,11 +31,16 @@ func NewInternalInfillModifier(options *data.Options) handler.LayerModifier {
 }
 
 func (m internalInfillModifier) Modify(layers []data.PartitionedLayer) error {
-	for layerNr := range layers {
+	// each layer only needs its own attributes, so the layers are processed in parallel
+	return data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
 		overlappingPerimeters, err := OverlapPerimeters(layers[layerNr])
-		if err != nil || overlappingPerimeters == nil {
+		if err != nil {
 			return err
 		}
+		if overlappingPerimeters == nil {
+			// nothing to fill in an empty layer
+			return nil
+		}
 
 		bottomInfill, err := BottomInfill(layers[layerNr])
 		if err != nil {
@@ -43,7 +54,7 @@ func (m internalInfillModifier) Modify(layers []data.PartitionedLayer) error {
 
 		var internalInfill []data.LayerPart
 
-		c := clip.NewClipper()
+		c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
 
 		// calculate the bottom parts for each inner perimeter part
 		for _, overlappingPart := range overlappingPerimeters {
@@ -70,22 +81,48 @@ func (m internalInfillModifier) Modify(layers []data.PartitionedLayer) error {
 
 		newLayer := newExtendedLayer(layers[layerNr])
 		if len(internalInfill) > 0 {
-			newLayer.attributes["infill"] = internalInfill
+			if every := m.options.Print.SolidInfillEvery; every > 0 && (layerNr+1)%every == 0 {
+				newLayer.attributes["solidInfill"] = internalInfill
+			} else {
+				newLayer.attributes["infill"] = internalInfill
+			}
 		}
-	}
 
-	return nil
+		// The skins are inset only after the internal infill is calculated from the whole skin areas,
+		// so that the internal infill does not grow into the space left by the inset.
+		extrusionWidth := m.options.Printer.LayerExtrusionWidth(layerNr)
+		for _, skin := range []struct {
+			parts            []data.LayerPart
+			attr, perimeters string
+		}{
+			{bottomInfill, "bottom", "bottomSkinPerimeters"},
+			{topInfill, "top", "topSkinPerimeters"},
+		} {
+			if len(skin.parts) == 0 {
+				continue
+			}
+
+			fill, perimeters := insetSkin(m.options, skin.parts, extrusionWidth)
+			if len(fill) > 0 {
+				newLayer.attributes[skin.attr] = fill
+			} else {
+				delete(newLayer.attributes, skin.attr)
+			}
+			if len(perimeters) > 0 {
+				newLayer.attributes[skin.perimeters] = perimeters
+			}
+		}
+		return nil
+	})
 }
 
-func partDifference(part data.LayerPart, layerToRemove data.PartitionedLayer) ([]data.LayerPart, error) {
+func partDifference(c clip.Clipper, part data.LayerPart, layerToRemove data.PartitionedLayer) ([]data.LayerPart, error) {
 	var toClip []data.LayerPart
 
 	for _, otherPart := range layerToRemove.LayerParts() {
 		toClip = append(toClip, otherPart)
 	}
 
-	c := clip.NewClipper()
-
 	diff, ok := c.Difference([]data.LayerPart{part}, toClip)
 	if !ok {
 		return nil, errors.New("error while calculating difference of a part and a layer")
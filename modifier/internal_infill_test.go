@@ -0,0 +1,92 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestInternalInfillModifier(t *testing.T) {
+	// infillLayers returns layers which can be filled completely, except for a top skin in the right half of the last layer.
+	infillLayers := func(count int) []data.PartitionedLayer {
+		testLayers := make([]data.PartitionedLayer, count)
+		for layerNr := range testLayers {
+			layer := newExtendedLayer(data.NewPartitionedLayer([]data.LayerPart{rectanglePart(0, 0, 20000, 20000)}))
+			layer.attributes["overlapPerimeters"] = [][]data.LayerPart{{rectanglePart(0, 0, 20000, 20000)}}
+			if layerNr == count-1 {
+				layer.attributes["top"] = []data.LayerPart{rectanglePart(10000, 0, 20000, 20000)}
+			}
+			testLayers[layerNr] = layer
+		}
+		return testLayers
+	}
+
+	var testCases = map[string]struct {
+		solidInfillEvery int
+		infillPercent    int
+		// expectedSolid contains for each layer if its infill is solid
+		expectedSolid []bool
+		noInfill      bool
+	}{
+		"sparse infill": {
+			infillPercent: 20,
+			expectedSolid: []bool{false, false, false, false, false, false},
+		},
+		"solid infill every third layer": {
+			solidInfillEvery: 3,
+			infillPercent:    20,
+			expectedSolid:    []bool{false, false, true, false, false, true},
+		},
+		"solid infill on each layer": {
+			solidInfillEvery: 1,
+			infillPercent:    20,
+			expectedSolid:    []bool{true, true, true, true, true, true},
+		},
+		"without infill": {
+			solidInfillEvery: 3,
+			infillPercent:    0,
+			noInfill:         true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.SolidInfillEvery = testCase.solidInfillEvery
+		options.Print.InfillPercent = testCase.infillPercent
+
+		testLayers := infillLayers(6)
+		err := NewInternalInfillModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testLayers {
+			infill, err := PartsAttribute(layer, "infill")
+			test.Ok(t, err)
+			solidInfill, err := PartsAttribute(layer, "solidInfill")
+			test.Ok(t, err)
+
+			if testCase.noInfill {
+				test.Equals(t, 0, len(infill)+len(solidInfill))
+				continue
+			}
+
+			filled := infill
+			if testCase.expectedSolid[layerNr] {
+				test.Equals(t, 0, len(infill))
+				filled = solidInfill
+			} else {
+				test.Equals(t, 0, len(solidInfill))
+			}
+
+			// the top skin is not filled by the internal infill
+			expectedMaxX := data.Micrometer(20000)
+			if layerNr == len(testLayers)-1 {
+				expectedMaxX = 10000
+			}
+			test.Equals(t, 1, len(filled))
+			min, max := partsBounds(filled)
+			test.Equals(t, data.NewMicroPoint(0, 0), min, microPointComparer())
+			test.Equals(t, data.NewMicroPoint(expectedMaxX, 20000), max, microPointComparer())
+		}
+	}
+}
@@ -0,0 +1,62 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type layerOverrideModifier struct {
+	handler.Named
+	options *data.Options
+
+	overrides []data.LayerOverride
+	err       error
+}
+
+// NewLayerOverrideModifier reads the layer overrides file of the options (see data.ReadLayerOverrides)
+// and sets the settings of all overrides which contain a layer as the attribute "layerOverride" of the type
+// data.LayerSettings to the layer. The generator applies them to the options used to render the layer
+// (see data.OverriddenSettings).
+func NewLayerOverrideModifier(options *data.Options) handler.LayerModifier {
+	return &layerOverrideModifier{
+		Named: handler.Named{
+			Name: "LayerOverride",
+		},
+		options: options,
+	}
+}
+
+func (m *layerOverrideModifier) Init(_ data.OptimizedModel) {
+	m.overrides, m.err = nil, nil
+	if m.options.GoSlice.LayerOverridesFilePath != "" {
+		m.overrides, m.err = data.LoadLayerOverrides(m.options.GoSlice.LayerOverridesFilePath)
+	}
+}
+
+func (m *layerOverrideModifier) LayerContext() int {
+	return 0
+}
+
+func (m *layerOverrideModifier) Modify(layers []data.PartitionedLayer) error {
+	if m.err != nil {
+		return m.err
+	}
+
+	for layerNr := range layers {
+		var args data.LayerSettings
+		for _, override := range m.overrides {
+			if override.Contains(layerNr) {
+				args = append(args, override.Args...)
+			}
+		}
+		if len(args) == 0 {
+			continue
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["layerOverride"] = args
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
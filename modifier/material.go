@@ -0,0 +1,99 @@
+// This file provides the handling of the bodies of a model which are printed by different extruders.
+
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// sharedAttributes are the attributes of the structures around the bodies, which are printed once for all bodies
+// if the bodies are printed by different extruders.
+var sharedAttributes = map[string]bool{
+	"firstLayer":       true,
+	"support":          true,
+	"supportInterface": true,
+	"supportFloor":     true,
+	"treeSupport":      true,
+	"oozeShield":       true,
+}
+
+// SharedLayer returns the layer of the whole model with only the structures around the bodies,
+// e.g. the skirt, the brims, the support and the ooze shield.
+// If the bodies are printed by different extruders, they are generated for the whole model and printed once,
+// while the bodies are printed using the layers of each extruder (see BodyLayer).
+// The overridden settings of the layer are kept.
+func SharedLayer(layer data.PartitionedLayer) data.PartitionedLayer {
+	shared := extendedLayer{PartitionedLayer: layer, attributes: map[string]interface{}{}}
+	for name, attribute := range layer.Attributes() {
+		if _, ok := attribute.(data.LayerSettings); ok || sharedAttributes[name] {
+			shared.attributes[name] = attribute
+		}
+	}
+	return shared
+}
+
+// BodyLayer returns the layer of the bodies of one extruder without the structures around them,
+// as these are printed with the layer of the whole model (see SharedLayer).
+func BodyLayer(layer data.PartitionedLayer) data.PartitionedLayer {
+	body := extendedLayer{PartitionedLayer: layer, attributes: map[string]interface{}{}}
+	for name, attribute := range layer.Attributes() {
+		if !sharedAttributes[name] {
+			body.attributes[name] = attribute
+		}
+	}
+	return body
+}
+
+type materialOverlapModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+// NewMaterialOverlapModifier removes the areas in which the bodies of several extruders overlap
+// from all but the extruder with the lowest number, so that no area is printed twice.
+func NewMaterialOverlapModifier(options *data.Options) handler.MaterialModifier {
+	return &materialOverlapModifier{
+		Named: handler.Named{
+			Name: "MaterialOverlap",
+		},
+		options: options,
+	}
+}
+
+func (m materialOverlapModifier) Modify(materials [][]data.PartitionedLayer) error {
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	for extruder, layers := range materials {
+		for layerNr, layer := range layers {
+			// the parts of all extruders with a lower number
+			var lower []data.LayerPart
+			for other := 0; other < extruder; other++ {
+				if materials[other] == nil {
+					continue
+				}
+
+				var ok bool
+				lower, ok = c.Union(lower, materials[other][layerNr].LayerParts())
+				if !ok {
+					return fmt.Errorf("could not merge the bodies of the extruders below %d in layer %d", extruder, layerNr)
+				}
+			}
+			if len(lower) == 0 || len(layer.LayerParts()) == 0 {
+				continue
+			}
+
+			parts, ok := c.Difference(layer.LayerParts(), lower)
+			if !ok {
+				return fmt.Errorf("could not remove the overlaps of the bodies of extruder %d in layer %d", extruder, layerNr)
+			}
+
+			newLayer := newExtendedLayer(layer)
+			newLayer.PartitionedLayer = data.NewPartitionedLayer(parts)
+			layers[layerNr] = newLayer
+		}
+	}
+
+	return nil
+}
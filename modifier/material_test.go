@@ -0,0 +1,51 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestMaterialOverlapModifier(t *testing.T) {
+	options := data.DefaultOptions()
+
+	// the body of the second extruder overlaps the body of the first one in the first layer
+	materials := [][]data.PartitionedLayer{
+		layers(
+			[]data.LayerPart{rectanglePart(0, 0, 12000, 10000)},
+			[]data.LayerPart{},
+		),
+		layers(
+			[]data.LayerPart{rectanglePart(10000, 0, 20000, 10000)},
+			[]data.LayerPart{rectanglePart(10000, 0, 20000, 10000)},
+		),
+	}
+
+	err := NewMaterialOverlapModifier(&options).Modify(materials)
+	test.Ok(t, err)
+
+	// only the body of the extruder with the lower number keeps the overlap
+	test.Equals(t, [2]data.Micrometer{0, 12000}, xBounds(materials[0][0].LayerParts()))
+	test.Equals(t, [2]data.Micrometer{12000, 20000}, xBounds(materials[1][0].LayerParts()))
+	test.Equals(t, noBounds(), xBounds(materials[0][1].LayerParts()))
+	test.Equals(t, [2]data.Micrometer{10000, 20000}, xBounds(materials[1][1].LayerParts()))
+}
+
+func TestSharedAndBodyLayer(t *testing.T) {
+	layer := layers([]data.LayerPart{rectanglePart(0, 0, 10000, 10000)})[0]
+	layer = SetAttribute(layer, "support", []data.LayerPart{rectanglePart(20000, 0, 30000, 10000)})
+	layer = SetAttribute(layer, "perimeters", [][][]data.LayerPart{})
+	layer = SetAttribute(layer, "layerOverride", data.LayerSettings{"--infill-percent=80"})
+
+	shared := SharedLayer(layer)
+	test.Assert(t, shared.Attributes()["support"] != nil, "the support should be printed with the whole model")
+	test.Assert(t, shared.Attributes()["perimeters"] == nil, "the perimeters should be printed with the bodies")
+	test.Assert(t, shared.Attributes()["layerOverride"] != nil, "the settings of the layer should be kept")
+
+	body := BodyLayer(layer)
+	test.Assert(t, body.Attributes()["support"] == nil, "the support should be printed with the whole model")
+	test.Assert(t, body.Attributes()["perimeters"] != nil, "the perimeters should be printed with the bodies")
+
+	// the attributes of the layer itself stay unchanged
+	test.Assert(t, layer.Attributes()["support"] != nil, "the support of the layer should be kept")
+}
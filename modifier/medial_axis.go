@@ -0,0 +1,232 @@
+// This file provides an approximation of the medial axis of narrow areas, used for the lines filling them.
+
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"math"
+	"sort"
+)
+
+// VariableWidthLine is a line along the medial axis of a narrow area.
+type VariableWidthLine struct {
+	// Path is the center line of the area.
+	Path data.Path
+
+	// Widths contains the width of the area at each point of the path.
+	Widths []data.Micrometer
+
+	// Closed is true if the line goes around a hole, so that its last point is connected to its first point.
+	Closed bool
+}
+
+// SegmentWidths returns the width of each segment of the line, which is the average width of its two points.
+// If the line is closed, the last segment connects the last point with the first point.
+func (l VariableWidthLine) SegmentWidths() []data.Micrometer {
+	var widths []data.Micrometer
+	for i := 1; i < len(l.Widths); i++ {
+		widths = append(widths, (l.Widths[i-1]+l.Widths[i])/2)
+	}
+	if l.Closed && len(l.Widths) > 0 {
+		widths = append(widths, (l.Widths[len(l.Widths)-1]+l.Widths[0])/2)
+	}
+	return widths
+}
+
+// contourSample is a point on a contour together with the direction of the contour at this point.
+type contourSample struct {
+	point     data.MicroPoint
+	direction data.MicroPoint
+}
+
+// medialAxis returns the lines along the medial axis of the narrow part which is not wider than maxWidth.
+//
+// The medial axis is approximated by pairing points along all contours with the nearest point of the opposite
+// side of the part, which runs in the opposite direction. The centers of these pairs form the lines.
+// As each line is found from both of its sides, lines lying within a longer line already found are removed.
+// Lines shorter than maxWidth are dropped.
+func medialAxis(part data.LayerPart, maxWidth data.Micrometer) []VariableWidthLine {
+	contours := append(data.Paths{part.Outline()}, part.Holes()...)
+
+	var lines []VariableWidthLine
+	for contourNr, contour := range contours {
+		if len(contour) < 3 {
+			continue
+		}
+
+		// The inside of the part lies left of the outline if it is counter clockwise
+		// and right of the holes if they are counter clockwise.
+		inside := 1.0
+		if (contour.Area() < 0) == (contourNr == 0) {
+			inside = -1.0
+		}
+
+		var line VariableWidthLine
+		closed := true
+		// the index of the first line found along this contour
+		first := -1
+		for _, sample := range sampleContour(contour, maxWidth/2) {
+			other, width, ok := oppositePoint(sample, contours, inside, maxWidth*3/2)
+			if !ok {
+				closed = false
+				if len(line.Path) > 0 {
+					if first == -1 {
+						first = len(lines)
+					}
+					lines = append(lines, line)
+					line = VariableWidthLine{}
+				}
+				continue
+			}
+
+			line.Path = append(line.Path, sample.point.Add(other).Div(2))
+			line.Widths = append(line.Widths, width)
+		}
+
+		if closed {
+			line.Closed = true
+			lines = append(lines, line)
+		} else if len(line.Path) > 0 && first != -1 {
+			// the line continues at the start of the contour
+			lines[first].Path = append(line.Path, lines[first].Path...)
+			lines[first].Widths = append(line.Widths, lines[first].Widths...)
+		} else if len(line.Path) > 0 {
+			lines = append(lines, line)
+		}
+	}
+
+	return distinctLines(lines, maxWidth)
+}
+
+// distinctLines removes the parts of the lines which lie within the width of a longer line.
+// The remaining lines shorter than minLength are dropped.
+func distinctLines(lines []VariableWidthLine, minLength data.Micrometer) []VariableWidthLine {
+	sort.SliceStable(lines, func(i, j int) bool {
+		return len(lines[i].Path) > len(lines[j].Path)
+	})
+
+	var result []VariableWidthLine
+	for _, line := range lines {
+		var piece VariableWidthLine
+		covered := false
+		for i, point := range line.Path {
+			if isCovered(point, line.Widths[i]/2, result) {
+				covered = true
+				if len(piece.Path) > 0 {
+					result = appendLine(result, piece, minLength)
+					piece = VariableWidthLine{}
+				}
+				continue
+			}
+
+			piece.Path = append(piece.Path, point)
+			piece.Widths = append(piece.Widths, line.Widths[i])
+		}
+
+		if !covered {
+			piece.Closed = line.Closed
+		}
+		result = appendLine(result, piece, minLength)
+	}
+
+	return result
+}
+
+// appendLine appends the line if it is not shorter than minLength.
+func appendLine(lines []VariableWidthLine, line VariableWidthLine, minLength data.Micrometer) []VariableWidthLine {
+	if len(line.Path) < 2 {
+		return lines
+	}
+
+	length := line.Path.Length()
+	if line.Closed {
+		length += line.Path[len(line.Path)-1].Sub(line.Path[0]).Size()
+	}
+	if length < minLength {
+		return lines
+	}
+
+	return append(lines, line)
+}
+
+// isCovered returns true if the point is nearer than distance to any of the lines.
+func isCovered(point data.MicroPoint, distance data.Micrometer, lines []VariableWidthLine) bool {
+	for _, line := range lines {
+		count := len(line.Path)
+		if !line.Closed {
+			count--
+		}
+		for i := 0; i < count; i++ {
+			nearest := data.ProjectOnSegment(point, line.Path[i], line.Path[(i+1)%len(line.Path)])
+			if nearest.Sub(point).ShorterThanOrEqual(distance) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// sampleContour returns points along the closed contour which are at most step apart.
+func sampleContour(contour data.Path, step data.Micrometer) []contourSample {
+	var samples []contourSample
+	for i := range contour {
+		start := contour[i]
+		segment := contour[(i+1)%len(contour)].Sub(start)
+		length := segment.Size()
+		if length == 0 {
+			continue
+		}
+
+		count := length/step + 1
+		for k := data.Micrometer(0); k < count; k++ {
+			samples = append(samples, contourSample{
+				point:     start.Add(segment.Mul(k).Div(count)),
+				direction: segment,
+			})
+		}
+	}
+	return samples
+}
+
+// oppositePoint returns the nearest point of the contours on the inner side of the sample
+// which belongs to a segment running in the opposite direction, e.g. the other side of a narrow area,
+// together with its distance to the sample.
+// It returns false if there is no such point within maxDistance.
+func oppositePoint(sample contourSample, contours data.Paths, inside float64, maxDistance data.Micrometer) (data.MicroPoint, data.Micrometer, bool) {
+	dx, dy := float64(sample.direction.X()), float64(sample.direction.Y())
+	directionLength := math.Hypot(dx, dy)
+
+	var best data.MicroPoint
+	bestDistance := data.Micrometer(-1)
+	for _, contour := range contours {
+		for i := range contour {
+			a, b := contour[i], contour[(i+1)%len(contour)]
+			segment := b.Sub(a)
+			sx, sy := float64(segment.X()), float64(segment.Y())
+			segmentLength := math.Hypot(sx, sy)
+			if segmentLength == 0 {
+				continue
+			}
+
+			// the opposite side runs in the other direction
+			if (dx*sx+dy*sy)/(directionLength*segmentLength) > -0.5 {
+				continue
+			}
+
+			point := data.ProjectOnSegment(sample.point, a, b)
+			toPoint := point.Sub(sample.point)
+			// the point has to lie on the inner side of the sample
+			if inside*(dx*float64(toPoint.Y())-dy*float64(toPoint.X())) <= 0 {
+				continue
+			}
+
+			distance := toPoint.Size()
+			if distance <= maxDistance && (bestDistance == -1 || distance < bestDistance) {
+				best = point
+				bestDistance = distance
+			}
+		}
+	}
+
+	return best, bestDistance, bestDistance != -1
+}
@@ -0,0 +1,143 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestMedialAxis(t *testing.T) {
+	// a wall which gets wider from 400 to 800 along the x axis
+	tapered := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, -200),
+		data.NewMicroPoint(20000, -400),
+		data.NewMicroPoint(20000, 400),
+		data.NewMicroPoint(0, 200),
+	}, nil)
+
+	var testCases = map[string]struct {
+		part           data.LayerPart
+		expectedLines  int
+		expectedClosed bool
+		// expectedY is the y coordinate of the center line of the walls along the x axis
+		expectedY data.Micrometer
+		// expectedFirstWidth and expectedLastWidth are the widths at the start and the end of the line
+		expectedFirstWidth data.Micrometer
+		expectedLastWidth  data.Micrometer
+	}{
+		"narrow wall": {
+			part:               rectanglePart(0, 0, 20000, 600),
+			expectedLines:      1,
+			expectedY:          300,
+			expectedFirstWidth: 600,
+			expectedLastWidth:  600,
+		},
+		"tapered wall": {
+			part:               tapered,
+			expectedLines:      1,
+			expectedY:          0,
+			expectedFirstWidth: 400,
+			expectedLastWidth:  800,
+		},
+		"ring": {
+			part:           data.NewBasicLayerPart(rectangle(0, 0, 10000, 10000), data.Paths{rectangle(400, 400, 9600, 9600).Reversed()}),
+			expectedLines:  1,
+			expectedClosed: true,
+		},
+		"too wide": {
+			part:          rectanglePart(0, 0, 20000, 2000),
+			expectedLines: 0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		lines := medialAxis(testCase.part, 1000)
+		test.Equals(t, testCase.expectedLines, len(lines))
+		if testCase.expectedLines == 0 {
+			continue
+		}
+
+		line := lines[0]
+		test.Equals(t, testCase.expectedClosed, line.Closed)
+		test.Equals(t, len(line.Path), len(line.Widths))
+
+		if testCase.expectedClosed {
+			// the line runs around the center of the ring, which is wider in the corners
+			min, max := line.Path.Bounds()
+			test.Equals(t, data.NewMicroPoint(200, 200), min, microPointComparer())
+			test.Equals(t, data.NewMicroPoint(9800, 9800), max, microPointComparer())
+			for _, width := range line.Widths {
+				test.Assert(t, width >= 400 && width <= 600, "the width %v should be between 400 and 600", width)
+			}
+			continue
+		}
+
+		min, max := line.Path.Bounds()
+		test.Equals(t, testCase.expectedY, min.Y())
+		test.Equals(t, testCase.expectedY, max.Y())
+		test.Assert(t, max.X()-min.X() > 19000, "the line should run along the whole wall")
+
+		first, last := line.Widths[0], line.Widths[len(line.Widths)-1]
+		test.Assert(t, first >= testCase.expectedFirstWidth-20 && first <= testCase.expectedFirstWidth+20, "expected the first width %v but got %v", testCase.expectedFirstWidth, first)
+		test.Assert(t, last >= testCase.expectedLastWidth-20 && last <= testCase.expectedLastWidth+20, "expected the last width %v but got %v", testCase.expectedLastWidth, last)
+		for i := 1; i < len(line.Widths); i++ {
+			test.Assert(t, line.Widths[i] >= line.Widths[i-1], "the widths should not decrease along the wall")
+		}
+	}
+}
+
+func TestOppositePoint(t *testing.T) {
+	contours := data.Paths{rectangle(0, 0, 20000, 600)}
+	// a sample on the bottom side of the counter clockwise outline, the inside lies left of it
+	sample := contourSample{
+		point:     data.NewMicroPoint(5000, 0),
+		direction: data.NewMicroPoint(20000, 0),
+	}
+
+	var testCases = map[string]struct {
+		inside           float64
+		maxDistance      data.Micrometer
+		expectedPoint    data.MicroPoint
+		expectedDistance data.Micrometer
+		expectedOk       bool
+	}{
+		"other side": {
+			inside:           1,
+			maxDistance:      1000,
+			expectedPoint:    data.NewMicroPoint(5000, 600),
+			expectedDistance: 600,
+			expectedOk:       true,
+		},
+		"too far away": {
+			inside:      1,
+			maxDistance: 500,
+		},
+		"wrong side": {
+			inside:      -1,
+			maxDistance: 1000,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		point, distance, ok := oppositePoint(sample, contours, testCase.inside, testCase.maxDistance)
+		test.Equals(t, testCase.expectedOk, ok)
+		if !testCase.expectedOk {
+			continue
+		}
+		test.Equals(t, testCase.expectedPoint, point, microPointComparer())
+		test.Equals(t, testCase.expectedDistance, distance)
+	}
+}
+
+func TestSegmentWidths(t *testing.T) {
+	line := VariableWidthLine{
+		Path:   data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(1000, 0), data.NewMicroPoint(1000, 1000)},
+		Widths: []data.Micrometer{400, 600, 800},
+	}
+	test.Equals(t, []data.Micrometer{500, 700}, line.SegmentWidths())
+
+	line.Closed = true
+	test.Equals(t, []data.Micrometer{500, 700, 600}, line.SegmentWidths())
+}
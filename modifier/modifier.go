@@ -38,6 +38,15 @@ func (l extendedLayer) Attributes() map[string]interface{} {
 	return l.attributes
 }
 
+// SetAttribute returns the layer with the attribute of the given name set to the value.
+// It can be used by modifiers outside of this package to pass data to the renderers,
+// e.g. a []data.LayerPart which can be filled by a renderer.Infill.
+func SetAttribute(layer data.PartitionedLayer, name string, value interface{}) data.PartitionedLayer {
+	newLayer := newExtendedLayer(layer)
+	newLayer.attributes[name] = value
+	return newLayer
+}
+
 // PartsAttribute extracts the given attribute from the layer.
 // It supports only []data.LayerPart as type.
 // If it has the wrong type, a error is returned.
@@ -0,0 +1,56 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/google/go-cmp/cmp"
+	"github.com/google/go-cmp/cmp/cmpopts"
+)
+
+// microPointComparer returns a cmp.Comparer which can handle data.MicroPoint.
+func microPointComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 data.MicroPoint) bool {
+		return p1.X() == p2.X() && p1.Y() == p2.Y()
+	})
+}
+
+// layerPartComparer returns a cmp.Comparer which compares data.LayerPart by their paths and attributes.
+func layerPartComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 data.LayerPart) bool {
+		return cmp.Equal(p1.Outline(), p2.Outline(), microPointComparer()) &&
+			cmp.Equal(p1.Holes(), p2.Holes(), microPointComparer(), cmpopts.EquateEmpty()) &&
+			cmp.Equal(p1.Attributes(), p2.Attributes(), microPointComparer(), layerPartComparer(), cmpopts.EquateEmpty())
+	})
+}
+
+// rectangle returns a counter clockwise path around the rectangle from min to max.
+func rectangle(minX, minY, maxX, maxY data.Micrometer) data.Path {
+	return data.Path{
+		data.NewMicroPoint(minX, minY),
+		data.NewMicroPoint(maxX, minY),
+		data.NewMicroPoint(maxX, maxY),
+		data.NewMicroPoint(minX, maxY),
+	}
+}
+
+// rectanglePart returns a part without holes covering the rectangle from min to max.
+func rectanglePart(minX, minY, maxX, maxY data.Micrometer) data.LayerPart {
+	return data.NewBasicLayerPart(rectangle(minX, minY, maxX, maxY), nil)
+}
+
+// layers returns a partitioned layer for each given slice of parts.
+func layers(parts ...[]data.LayerPart) []data.PartitionedLayer {
+	result := make([]data.PartitionedLayer, len(parts))
+	for i, layerParts := range parts {
+		result[i] = data.NewPartitionedLayer(layerParts)
+	}
+	return result
+}
+
+// partsBounds returns the bounds of the outlines of all parts.
+func partsBounds(parts []data.LayerPart) (data.MicroPoint, data.MicroPoint) {
+	var points data.Path
+	for _, part := range parts {
+		points = append(points, part.Outline()...)
+	}
+	return points.Bounds()
+}
@@ -0,0 +1,142 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"math"
+)
+
+type oozeShieldModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m oozeShieldModifier) Init(_ data.OptimizedModel) {}
+
+// LayerContext returns -1 if enabled as the shield is propagated from the top to the bottom through all layers.
+func (m oozeShieldModifier) LayerContext() int {
+	if m.options.Print.OozeShield.Enabled {
+		return -1
+	}
+	return 0
+}
+
+// NewOozeShieldModifier generates the ooze shield, a single wall around the model on each layer.
+// The wall is printed before the model, so that the filament which oozed out of the nozzle,
+// e.g. during long travel moves, is wiped off at the shield and not at the model.
+//
+// The shield follows the outlines of the model, the support and the brim in the configured distance.
+// To keep it printable, it is propagated from the top to the bottom:
+// each layer is widened to the shield above inset by d = h * tan θ (see also NewPrintableOverhangModifier),
+// so that the shield never overhangs more than the configured angle.
+// Only the outermost outlines are used as the shield surrounds everything.
+//
+// The result is set as the attribute "oozeShield" to the layers.
+func NewOozeShieldModifier(options *data.Options) handler.LayerModifier {
+	return &oozeShieldModifier{
+		Named: handler.Named{
+			Name: "OozeShield",
+		},
+		options: options,
+	}
+}
+
+// OozeShield extracts the attribute "oozeShield" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func OozeShield(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "oozeShield")
+}
+
+func (m oozeShieldModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.OozeShield.Enabled {
+		return nil
+	}
+
+	distance := m.options.Print.OozeShield.Distance.ToMicrometer()
+	tan := math.Tan(data.ToRadians(float64(m.options.Print.OozeShield.Angle)))
+	heights := m.options.Print.LayerHeights()
+	width := m.options.Printer.ExtrusionWidth
+
+	cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	var above []data.LayerPart
+	for layerNr := len(layers) - 1; layerNr >= 0; layerNr-- {
+		outlines, err := m.outlines(layers[layerNr], layerNr)
+		if err != nil {
+			return err
+		}
+
+		var shield []data.LayerPart
+		for _, outline := range outlines {
+			for _, grown := range cl.Inset(outline, -distance, 1, distance) {
+				var ok bool
+				// union the parts one by one as overlapping parts would cancel each other out
+				shield, ok = cl.Union(shield, grown)
+				if !ok {
+					return fmt.Errorf("could not generate the ooze shield of layer %d", layerNr)
+				}
+			}
+		}
+
+		if len(above) > 0 && m.options.Print.OozeShield.Angle < 90 {
+			// the shield has to reach at least to the shield above minus the allowed overhang
+			overhang := data.Micrometer(math.Round(float64(heights.Thickness(layerNr+1)) * tan))
+			for _, supporting := range cl.InsetLayer(above, overhang, 1, -overhang).ToOneDimension() {
+				var ok bool
+				shield, ok = cl.Union(shield, []data.LayerPart{supporting})
+				if !ok {
+					return fmt.Errorf("could not widen the ooze shield of layer %d", layerNr)
+				}
+			}
+		}
+
+		// the shield surrounds everything, so only the outermost outlines are kept
+		topLevel, ok := cl.TopLevelPolygons(shield)
+		if !ok {
+			return fmt.Errorf("could not generate the ooze shield of layer %d", layerNr)
+		}
+		shield = nil
+		for _, outline := range topLevel {
+			// simplify the outline as the offsets would add more and more points through the layers
+			shield = append(shield, data.NewBasicLayerPart(outline.Simplify(width*width, 25*25), nil))
+		}
+		above = shield
+		if len(shield) == 0 {
+			continue
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["oozeShield"] = shield
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
+
+// outlines returns the outlines of everything the ooze shield has to surround on the given layer.
+func (m oozeShieldModifier) outlines(layer data.PartitionedLayer, layerNr int) ([]data.LayerPart, error) {
+	support, err := FullSupport(layer)
+	if err != nil {
+		return nil, err
+	}
+
+	var outlines []data.LayerPart
+	for _, part := range layer.LayerParts() {
+		outlines = append(outlines, data.NewBasicLayerPart(part.Outline(), nil))
+	}
+	for _, part := range support {
+		outlines = append(outlines, data.NewBasicLayerPart(part.Outline(), nil))
+	}
+
+	if layerNr == 0 {
+		brim, err := BrimOuterDimension(layer)
+		if err != nil {
+			return nil, err
+		}
+		outlines = append(outlines, brim...)
+	}
+
+	return outlines, nil
+}
@@ -0,0 +1,56 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestOozeShieldModifier(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.OozeShield.Enabled = true
+	options.Print.OozeShield.Distance = 2
+	options.Print.OozeShield.Angle = 45
+	options.Print.InitialLayerThickness = 200
+	options.Print.LayerThickness = 200
+
+	var testCases = map[string]struct {
+		layers      []data.PartitionedLayer
+		expectedMin []data.MicroPoint
+		expectedMax []data.MicroPoint
+	}{
+		"wide layer above a narrow one": {
+			layers: layers(
+				[]data.LayerPart{rectanglePart(8000, 8000, 12000, 12000)},
+				[]data.LayerPart{rectanglePart(0, 0, 20000, 20000)},
+			),
+			// the shield below may be 0.2mm (45°) smaller than the shield above
+			expectedMin: []data.MicroPoint{data.NewMicroPoint(-1800, -1800), data.NewMicroPoint(-2000, -2000)},
+			expectedMax: []data.MicroPoint{data.NewMicroPoint(21800, 21800), data.NewMicroPoint(22000, 22000)},
+		},
+		"narrow layer above a wide one": {
+			layers: layers(
+				[]data.LayerPart{rectanglePart(0, 0, 20000, 20000)},
+				[]data.LayerPart{rectanglePart(8000, 8000, 12000, 12000)},
+			),
+			expectedMin: []data.MicroPoint{data.NewMicroPoint(-2000, -2000), data.NewMicroPoint(6000, 6000)},
+			expectedMax: []data.MicroPoint{data.NewMicroPoint(22000, 22000), data.NewMicroPoint(14000, 14000)},
+		},
+	}
+
+	for name, testCase := range testCases {
+		t.Log(name)
+		err := NewOozeShieldModifier(&options).Modify(testCase.layers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testCase.layers {
+			shield, err := OozeShield(layer)
+			test.Ok(t, err)
+			test.Equals(t, 1, len(shield))
+
+			min, max := partsBounds(shield)
+			test.Equals(t, testCase.expectedMin[layerNr], min, microPointComparer())
+			test.Equals(t, testCase.expectedMax[layerNr], max, microPointComparer())
+		}
+	}
+}
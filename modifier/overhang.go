@@ -0,0 +1,71 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"math"
+)
+
+type printableOverhangModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m printableOverhangModifier) Init(_ data.OptimizedModel) {}
+
+// LayerContext returns -1 if enabled as the overhangs are propagated through all layers.
+func (m printableOverhangModifier) LayerContext() int {
+	if m.options.Print.PrintableOverhang.Enabled {
+		return -1
+	}
+	return 0
+}
+
+// NewPrintableOverhangModifier changes the geometry of the layers so that all overhangs are printable without support.
+// It limits how far each layer may extend beyond the previous one.
+// For this the previous layer is offset by d = h * tan θ (see also NewSupportDetectorModifier)
+// and the current layer is clipped by the result.
+// As the already clipped previous layer is used, this cuts away everything
+// which overhangs more than the configured max angle.
+//
+// It has to run before any other modifier as it changes the layer parts directly.
+func NewPrintableOverhangModifier(options *data.Options) handler.LayerModifier {
+	return &printableOverhangModifier{
+		Named: handler.Named{
+			Name: "PrintableOverhang",
+		},
+		options: options,
+	}
+}
+
+func (m printableOverhangModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.PrintableOverhang.Enabled {
+		return nil
+	}
+
+	tan := math.Tan(data.ToRadians(float64(m.options.Print.PrintableOverhang.MaxAngle)))
+	heights := m.options.Print.LayerHeights()
+
+	cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	for layerNr := 1; layerNr < len(layers); layerNr++ {
+		// calculate distance (d) by the thickness of the current layer:
+		distance := data.Micrometer(math.Round(float64(heights.Thickness(layerNr)) * tan))
+
+		// offset the previous layer by d
+		allowed := cl.InsetLayer(layers[layerNr-1].LayerParts(), -distance, 1, distance).ToOneDimension()
+
+		// and remove everything from the current layer which is outside of it
+		parts, ok := cl.Intersection(layers[layerNr].LayerParts(), allowed)
+		if !ok {
+			return fmt.Errorf("could not clip the overhangs of layer %d", layerNr)
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.PartitionedLayer = data.NewPartitionedLayer(parts)
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
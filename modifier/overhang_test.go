@@ -0,0 +1,82 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestPrintableOverhangModifier(t *testing.T) {
+	var testCases = map[string]struct {
+		enabled  bool
+		maxAngle int
+		parts    [][]data.LayerPart
+		// expected contains the bounds (min x, max x) of each layer after the modification
+		expected [][2]data.Micrometer
+	}{
+		"disabled": {
+			enabled:  false,
+			maxAngle: 45,
+			parts: [][]data.LayerPart{
+				{rectanglePart(0, 0, 10000, 10000)},
+				{rectanglePart(0, 0, 20000, 10000)},
+			},
+			expected: [][2]data.Micrometer{{0, 10000}, {0, 20000}},
+		},
+		"overhang of 45°": {
+			enabled:  true,
+			maxAngle: 45,
+			parts: [][]data.LayerPart{
+				{rectanglePart(0, 0, 10000, 10000)},
+				{rectanglePart(0, 0, 20000, 10000)},
+				{rectanglePart(0, 0, 20000, 10000)},
+			},
+			expected: [][2]data.Micrometer{{0, 10000}, {0, 10200}, {0, 10400}},
+		},
+		"overhang of 60°": {
+			enabled:  true,
+			maxAngle: 60,
+			parts: [][]data.LayerPart{
+				{rectanglePart(0, 0, 10000, 10000)},
+				{rectanglePart(0, 0, 20000, 10000)},
+			},
+			expected: [][2]data.Micrometer{{0, 10000}, {0, 10346}},
+		},
+		"overhang within the max angle": {
+			enabled:  true,
+			maxAngle: 45,
+			parts: [][]data.LayerPart{
+				{rectanglePart(0, 0, 10000, 10000)},
+				{rectanglePart(0, 0, 10100, 10000)},
+			},
+			expected: [][2]data.Micrometer{{0, 10000}, {0, 10100}},
+		},
+		"floating part": {
+			enabled:  true,
+			maxAngle: 45,
+			parts: [][]data.LayerPart{
+				{rectanglePart(0, 0, 10000, 10000)},
+				{rectanglePart(0, 0, 10000, 10000), rectanglePart(15000, 0, 20000, 10000)},
+				{rectanglePart(15000, 0, 20000, 10000)},
+			},
+			expected: [][2]data.Micrometer{{0, 10000}, {0, 10000}, noBounds()},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.InitialLayerThickness = 200
+		options.Print.LayerThickness = 200
+		options.Print.PrintableOverhang.Enabled = testCase.enabled
+		options.Print.PrintableOverhang.MaxAngle = testCase.maxAngle
+
+		testLayers := layers(testCase.parts...)
+		err := NewPrintableOverhangModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testLayers {
+			test.Equals(t, testCase.expected[layerNr], xBounds(layer.LayerParts()))
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 	"github.com/aligator/goslice/clip"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
+	"log"
 )
 
 type perimeterModifier struct {
@@ -15,6 +16,14 @@ type perimeterModifier struct {
 // NewPerimeterModifier creates a modifier which calculates all perimeters
 //
 // The perimeters are saved as attribute in the LayerPart.
+// If the perimeter overlap compensation is enabled, the reduced flow of overlapping perimeters
+// is saved as attribute "perimeterFlow".
+// If thin walls are enabled, the lines along the walls too thin for the outer perimeter are saved as attribute "thinWalls".
+// If variable width perimeters are enabled, the perimeters are widened to fill the gaps which are too narrow
+// for the next perimeter and the widths of their segments are saved as attribute "perimeterWidth".
+// If gap fill is enabled, the lines filling the gaps too narrow for the perimeters are saved as attribute "gapFill".
+// If spiralize is enabled, only the outer perimeter of the largest part is saved as attribute "spiral"
+// for all layers above the bottom layers. These layers get no perimeters, so no infill is generated for them.
 func NewPerimeterModifier(options *data.Options) handler.LayerModifier {
 	return &perimeterModifier{
 		Named: handler.Named{
@@ -58,19 +67,97 @@ func Perimeters(layer data.PartitionedLayer) (clip.OffsetResult, error) {
 	return nil, nil
 }
 
+// InnermostPerimeters extracts the most inner perimeters of all parts from the attribute "perimeters".
+// These are the center lines of the most inner perimeter lines.
+// If the attribute has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func InnermostPerimeters(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	perimeters, err := Perimeters(layer)
+	if err != nil || perimeters == nil {
+		return nil, err
+	}
+
+	var innermost []data.LayerPart
+	for _, part := range perimeters {
+		if len(part) == 0 {
+			continue
+		}
+		innermost = append(innermost, part[len(part)-1]...)
+	}
+
+	return innermost, nil
+}
+
+// SpiralPath extracts the attribute "spiral" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func SpiralPath(layer data.PartitionedLayer) (data.Path, error) {
+	if attr, ok := layer.Attributes()["spiral"]; ok {
+		spiral, ok := attr.(data.Path)
+		if !ok {
+			return nil, errors.New("the attribute spiral has the wrong datatype")
+		}
+
+		return spiral, nil
+	}
+
+	return nil, nil
+}
+
 func (m perimeterModifier) Init(_ data.OptimizedModel) {}
 
+func (m perimeterModifier) LayerContext() int {
+	return 0
+}
+
 func (m perimeterModifier) Modify(layers []data.PartitionedLayer) error {
-	for layerNr := range layers {
+	// each layer only needs its own parts, so the layers are processed in parallel
+	return data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
+		newLayer := newExtendedLayer(layers[layerNr])
+
+		// Replace small circular holes by polyholes if enabled.
+		// This changes the geometry of the layer itself so that all following modifiers use the polyholes.
+		if m.options.Print.Polyhole.Enabled {
+			newLayer.PartitionedLayer = data.NewPartitionedLayer(polyholeParts(
+				newLayer.LayerParts(),
+				m.options.Print.Polyhole.MaxDiameter.ToMicrometer(),
+				m.options.Slicing.MeldDistance,
+			))
+		}
+
 		// Generate the perimeters.
-		c := clip.NewClipper()
-		insetParts := c.InsetLayer(layers[layerNr].LayerParts(), m.options.Printer.ExtrusionWidth, m.options.Print.InsetCount, -m.options.Printer.ExtrusionWidth/2)
+		c := clip.NewClipperWithJoin(m.options.Print.InsetJoinType, m.options.Print.InsetMiterLimit, clip.WithLogger(m.options.GoSlice.Logger))
+		extrusionWidth := m.options.Printer.LayerExtrusionWidth(layerNr)
+
+		if m.options.Print.Spiralize && layerNr >= m.options.Print.NumberBottomLayers {
+			if spiral := spiralPath(c, newLayer.LayerParts(), extrusionWidth); spiral != nil {
+				newLayer.attributes["spiral"] = spiral
+			}
+			layers[layerNr] = newLayer
+			return nil
+		}
+
+		initialOffset := -extrusionWidth / 2
+		if layerNr == 0 {
+			// The first layer is squished onto the bed and gets wider, so its perimeters are moved inwards.
+			// All insets and the infill area are moved with the outer perimeter, so that they do not overlap it.
+			initialOffset -= m.options.Print.ElephantFootCompensation.ToMicrometer()
+		}
+		insetParts := c.InsetLayer(newLayer.LayerParts(), extrusionWidth, m.options.Print.InsetCount, initialOffset)
 
 		// Also generate the overlapping perimeter, which helps with calculating the infill.
-		// This is derived from the most inner perimeters and offset by the options.Print.InfillOverlapPercent option.
+		// This is derived from the most inner perimeters and offset by the options.Print.InfillOverlap
+		// or options.Print.InfillOverlapPercent option.
 
 		var overlapPerimeter [][]data.LayerPart
 
+		overlap := perimeterOverlap(m.options.Print.InfillOverlap, m.options.Print.InfillOverlapPercent, 0, extrusionWidth)
+		if m.options.Print.InfillTrimToPerimeter {
+			// Let the infill area grow by half a line beyond the center line of the most inner perimeter
+			// so that the infill renderers can trim the lines exactly at the center line.
+			overlap = perimeterOverlap(0, 200, 0, extrusionWidth)
+		}
+
 		for partNr, part := range insetParts {
 			if len(overlapPerimeter) >= partNr {
 				overlapPerimeter = append(overlapPerimeter, nil)
@@ -79,7 +166,7 @@ func (m perimeterModifier) Modify(layers []data.PartitionedLayer) error {
 			// Use only the most inner perimeter.
 			for _, insetPart := range part[len(part)-1] {
 
-				maxOverlapBorder, err := calculateOverlapPerimeter(insetPart, m.options.Print.InfillOverlapPercent, m.options.Printer.ExtrusionWidth)
+				maxOverlapBorder, err := calculateOverlapPerimeter(insetPart, overlap, m.options.GoSlice.Logger)
 				if err != nil {
 					return err
 				}
@@ -87,23 +174,130 @@ func (m perimeterModifier) Modify(layers []data.PartitionedLayer) error {
 			}
 		}
 
-		newLayer := newExtendedLayer(layers[layerNr])
-		newLayer.attributes["perimeters"] = insetParts
 		newLayer.attributes["overlapPerimeters"] = overlapPerimeter
+
+		// The gaps of the outer perimeters are the thin walls, so they are not filled again.
+		// The gaps of the other perimeters are filled by the adapted perimeters if they are enabled.
+		firstGapInset := 0
+		lastGapInset := m.options.Print.InsetCount
+		if m.options.Print.VariableWidthPerimeters {
+			lastGapInset = 1
+		}
+		if m.options.Print.ThinWalls {
+			var thinWallLines []VariableWidthLine
+			for partNr, part := range newLayer.LayerParts() {
+				lines, err := thinWalls(c, part, insetParts[partNr], extrusionWidth, initialOffset, m.options.Print.ThinWallMinWidth.ToMicrometer())
+				if err != nil {
+					return err
+				}
+				thinWallLines = append(thinWallLines, lines...)
+			}
+			newLayer.attributes["thinWalls"] = thinWallLines
+			firstGapInset = 1
+		}
+		if m.options.Print.GapFill {
+			var gapFillLines []GapFillLine
+			for partNr, part := range newLayer.LayerParts() {
+				lines, err := gapFill(c, part, insetParts[partNr], extrusionWidth, initialOffset, m.options.Print.GapFillMinWidth.ToMicrometer(), firstGapInset, lastGapInset)
+				if err != nil {
+					return err
+				}
+				gapFillLines = append(gapFillLines, lines...)
+			}
+			newLayer.attributes["gapFill"] = gapFillLines
+		}
+
+		if m.options.Print.VariableWidthPerimeters {
+			widths := make([][][]PerimeterWidth, len(insetParts))
+			for partNr, part := range newLayer.LayerParts() {
+				var err error
+				insetParts[partNr], widths[partNr], err = adaptPerimeterWidths(c, part, insetParts[partNr], extrusionWidth, initialOffset)
+				if err != nil {
+					return err
+				}
+			}
+			newLayer.attributes["perimeterWidth"] = widths
+		}
+
+		newLayer.attributes["perimeters"] = insetParts
+		if m.options.Print.PerimeterOverlapCompensation {
+			newLayer.attributes["perimeterFlow"] = calculatePerimeterFlows(insetParts, extrusionWidth)
+		}
 		layers[layerNr] = newLayer
+		return nil
+	})
+}
+
+// spiralPath returns the center line of the outer perimeter of the largest part without its holes.
+// If there is no part, nil is returned.
+func spiralPath(c clip.Clipper, parts []data.LayerPart, extrusionWidth data.Micrometer) data.Path {
+	var largest data.Path
+	for _, part := range parts {
+		if largest == nil || absArea(part.Outline()) > absArea(largest) {
+			largest = part.Outline()
+		}
+	}
+	if largest == nil {
+		return nil
+	}
+
+	insets := c.Inset(data.NewBasicLayerPart(largest, nil), extrusionWidth, 1, -extrusionWidth/2)
+	if len(insets) == 0 {
+		return nil
 	}
 
-	return nil
+	var spiral data.Path
+	for _, inset := range insets[0] {
+		if spiral == nil || absArea(inset.Outline()) > absArea(spiral) {
+			spiral = inset.Outline()
+		}
+	}
+	return spiral
 }
 
-// calculateOverlapPerimeter helper function for calculating the overlap-perimeter out of a layer part.
-func calculateOverlapPerimeter(part data.LayerPart, overlapPercent int, extrusionWidth data.Micrometer) ([]data.LayerPart, error) {
-	perimeterOverlap := data.Micrometer(float32(extrusionWidth) * (100.0 - float32(overlapPercent)) / 100.0)
+// absArea returns the area of the path regardless of its direction.
+func absArea(path data.Path) data.Micrometer {
+	area := path.Area()
+	if area < 0 {
+		return -area
+	}
+	return area
+}
+
+// partArea returns the area of the part without its holes.
+func partArea(part data.LayerPart) data.Micrometer {
+	area := absArea(part.Outline())
+	for _, hole := range part.Holes() {
+		area -= absArea(hole)
+	}
+	return area
+}
 
+// perimeterOverlap returns twice the distance by which the outline of the infill area lies inside
+// the center line of the most inner perimeter, so that the infill overlaps the perimeter as configured.
+// If the absolute overlap is greater than 0, it is used instead of the overlapPercent of the extrusion width.
+// The additionalPercent of the extrusion width is added to the overlap in both cases.
+func perimeterOverlap(overlap data.Micrometer, overlapPercent, additionalPercent int, extrusionWidth data.Micrometer) data.Micrometer {
+	if overlap > 0 {
+		return extrusionWidth - 2*overlap - extrusionWidth*data.Micrometer(additionalPercent)/100
+	}
+
+	return data.Micrometer(float32(extrusionWidth) * (100.0 - float32(overlapPercent+additionalPercent)) / 100.0)
+}
+
+// calculateOverlapPerimeter helper function for calculating the overlap-perimeter out of a layer part.
+// The perimeterOverlap is calculated by perimeterOverlap.
+// The logger is used for the warnings of the clipper.
+func calculateOverlapPerimeter(part data.LayerPart, perimeterOverlap data.Micrometer, logger *log.Logger) ([]data.LayerPart, error) {
 	if perimeterOverlap != 0 {
-		c := clip.NewClipper()
+		c := clip.NewClipper(clip.WithLogger(logger))
+		insets := c.Inset(part, perimeterOverlap, 1, -perimeterOverlap/2)
+		if len(insets) == 0 {
+			return nil, errors.New("could not calculate the overlap perimeter")
+		}
+
 		// As we use only one inset, just return index 0.
-		return c.Inset(part, perimeterOverlap, 1, -perimeterOverlap/2)[0], nil
+		return insets[0], nil
 	} else {
 		// If no overlap needed, just return the input part.
 		return []data.LayerPart{part}, nil
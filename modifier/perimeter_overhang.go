@@ -0,0 +1,277 @@
+// This file provides the classification of the perimeter segments by their overhang over the layer below.
+
+package modifier
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// overhangSamples is the amount of points across the width of a perimeter line which are checked for support.
+const overhangSamples = 10
+
+// PerimeterOverhang contains the percentage of the extrusion width by which each segment of the perimeter polygons
+// of one inset part overhangs the layer below.
+// Segment i starts at point i of the polygon smoothed by data.DouglasPeucker(polygon, -1)
+// and the last segment closes the polygon. If the polygon has widths (see PerimeterWidths),
+// the segments belong to the polygon itself instead, like the widths.
+// A nil slice means that the polygon does not overhang the layer below.
+type PerimeterOverhang struct {
+	Outline []int
+	Holes   [][]int
+}
+
+// PerimeterOverhangs extracts the attribute "perimeterOverhang" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the overhangs are returned in the same structure as the perimeters: [part][insetNr][insetParts].
+func PerimeterOverhangs(layer data.PartitionedLayer) ([][][]PerimeterOverhang, error) {
+	if attr, ok := layer.Attributes()["perimeterOverhang"]; ok {
+		overhangs, ok := attr.([][][]PerimeterOverhang)
+		if !ok {
+			return nil, errors.New("the attribute perimeterOverhang has the wrong datatype")
+		}
+
+		return overhangs, nil
+	}
+
+	return nil, nil
+}
+
+type perimeterOverhangModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m perimeterOverhangModifier) Init(model data.OptimizedModel) {}
+
+func (m perimeterOverhangModifier) LayerContext() int {
+	return 1
+}
+
+// NewPerimeterOverhangModifier creates a modifier which classifies the segments of the perimeters by the percentage
+// of their width which overhangs the layer below and saves it as attribute "perimeterOverhang" (see PerimeterOverhangs).
+// The renderer uses it to print the heavily overhanging segments slower and with more cooling.
+// It has to run after the perimeter modifier.
+func NewPerimeterOverhangModifier(options *data.Options) handler.LayerModifier {
+	return &perimeterOverhangModifier{
+		Named: handler.Named{
+			Name: "PerimeterOverhang",
+		},
+		options: options,
+	}
+}
+
+func (m perimeterOverhangModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.OverhangPerimeter.Enabled {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+
+	// each layer only reads the layer below, so the layers are processed in parallel
+	newLayers := make([]data.PartitionedLayer, len(layers))
+	err := data.ForEachLayer(m.options.GoSlice.WorkerCount(), len(layers), func(layerNr int) error {
+		if layerNr == 0 {
+			return nil
+		}
+
+		perimeters, err := Perimeters(layers[layerNr])
+		if err != nil {
+			return err
+		}
+		if len(perimeters) == 0 {
+			return nil
+		}
+
+		widths, err := PerimeterWidths(layers[layerNr])
+		if err != nil {
+			return err
+		}
+
+		below := layers[layerNr-1].LayerParts()
+		unsupported, ok := c.Difference(layers[layerNr].LayerParts(), below)
+		if !ok {
+			return fmt.Errorf("could not calculate the unsupported area of layer %d", layerNr)
+		}
+		if len(unsupported) == 0 {
+			return nil
+		}
+
+		// Only the lines near the unsupported area can overhang.
+		// Slivers narrower than the distance between the samples across a line are ignored,
+		// e.g. the ones of slightly sloped walls.
+		width := m.options.Printer.LayerExtrusionWidth(layerNr)
+		sliver := width / overhangSamples / 2
+		var near []data.LayerPart
+		for _, part := range unsupported {
+			for _, shrunk := range c.Inset(part, 0, 1, -sliver)[0] {
+				near = append(near, c.Inset(shrunk, 0, 1, sliver+width)[0]...)
+			}
+		}
+		if len(near) == 0 {
+			return nil
+		}
+
+		boundedBelow := newBoundedParts(below)
+		boundedNear := newBoundedParts(near)
+
+		overhangs := make([][][]PerimeterOverhang, len(perimeters))
+		overhanging := false
+		for partNr, part := range perimeters {
+			overhangs[partNr] = make([][]PerimeterOverhang, len(part))
+			for insetNr, inset := range part {
+				overhangs[partNr][insetNr] = make([]PerimeterOverhang, len(inset))
+				for insetPartNr, insetPart := range inset {
+					var partWidths PerimeterWidth
+					if widths != nil {
+						partWidths = widths[partNr][insetNr][insetPartNr]
+					}
+
+					overhang := &overhangs[partNr][insetNr][insetPartNr]
+					overhang.Outline = polygonOverhangs(insetPart.Outline(), boundedBelow, boundedNear, width, partWidths.Outline == nil)
+
+					for holeNr, hole := range insetPart.Holes() {
+						holeOverhangs := polygonOverhangs(hole, boundedBelow, boundedNear, width, partWidths.Holes == nil || partWidths.Holes[holeNr] == nil)
+						if holeOverhangs == nil {
+							continue
+						}
+						if overhang.Holes == nil {
+							overhang.Holes = make([][]int, len(insetPart.Holes()))
+						}
+						overhang.Holes[holeNr] = holeOverhangs
+					}
+
+					if overhang.Outline != nil || overhang.Holes != nil {
+						overhanging = true
+					}
+				}
+			}
+		}
+		if !overhanging {
+			return nil
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["perimeterOverhang"] = overhangs
+		newLayers[layerNr] = newLayer
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for layerNr, newLayer := range newLayers {
+		if newLayer != nil {
+			layers[layerNr] = newLayer
+		}
+	}
+
+	return nil
+}
+
+// polygonOverhangs returns the percentage of the width by which each segment of the closed perimeter polygon
+// overhangs the parts below. Each segment is sampled in steps of half the width and at each sample
+// overhangSamples points across the line are checked. Only the samples inside of near are checked at all.
+// If smooth is set, the segments of the polygon smoothed in the same way as by the renderer are used.
+// If no segment overhangs, nil is returned.
+func polygonOverhangs(polygon data.Path, below, near []boundedPart, width data.Micrometer, smooth bool) []int {
+	if smooth {
+		// DouglasPeucker may change the points of the given path, so a copy is smoothed
+		// to get the same result as the renderer which smoothes the original path.
+		polygon = data.DouglasPeucker(append(data.Path{}, polygon...), -1)
+	}
+
+	overhangs := make([]int, len(polygon))
+	overhanging := false
+	for i := range polygon {
+		a, b := polygon[i], polygon[(i+1)%len(polygon)]
+		segment := b.Sub(a)
+		length := segment.Size()
+		if length == 0 {
+			continue
+		}
+
+		// most segments are far away from any unsupported area
+		min, max := data.Path{a, b}.Bounds()
+		segmentNear := overlappingParts(near, min, max)
+		if len(segmentNear) == 0 {
+			continue
+		}
+		segmentBelow := overlappingParts(below, min.Sub(data.NewMicroPoint(width, width)), max.Add(data.NewMicroPoint(width, width)))
+
+		// the direction across the line with the length of the width
+		across := data.NewMicroPoint(-segment.Y(), segment.X()).Mul(width).Div(length)
+
+		samples := length/(width/2) + 1
+		sum := 0
+		for s := data.Micrometer(0); s < samples; s++ {
+			center := a.Add(segment.Mul(2*s + 1).Div(2 * samples))
+			if !insideParts(segmentNear, center) {
+				continue
+			}
+
+			sum += crossOverhang(segmentBelow, center, across)
+		}
+
+		overhangs[i] = sum / int(samples)
+		if overhangs[i] > 0 {
+			overhanging = true
+		}
+	}
+
+	if !overhanging {
+		return nil
+	}
+	return overhangs
+}
+
+// crossOverhang returns the percentage of the line across the center which does not lie on the parts below.
+// The line has the length and direction of across and is checked at overhangSamples points.
+// Most lines rest completely on the parts below, so their ends are checked first.
+func crossOverhang(below []data.LayerPart, center, across data.MicroPoint) int {
+	sample := func(k data.Micrometer) data.MicroPoint {
+		return center.Add(across.Mul(2*k + 1 - overhangSamples).Div(2 * overhangSamples))
+	}
+	if insideParts(below, sample(0)) && insideParts(below, sample(overhangSamples-1)) {
+		return 0
+	}
+
+	unsupported := 0
+	for k := data.Micrometer(0); k < overhangSamples; k++ {
+		if !insideParts(below, sample(k)) {
+			unsupported++
+		}
+	}
+	return unsupported * 100 / overhangSamples
+}
+
+// boundedPart is a part together with the bounds of its outline.
+type boundedPart struct {
+	part     data.LayerPart
+	min, max data.MicroPoint
+}
+
+// newBoundedParts calculates the bounds of the parts.
+func newBoundedParts(parts []data.LayerPart) []boundedPart {
+	result := make([]boundedPart, len(parts))
+	for i, part := range parts {
+		min, max := part.Outline().Bounds()
+		result[i] = boundedPart{part: part, min: min, max: max}
+	}
+	return result
+}
+
+// overlappingParts returns the parts whose bounds overlap the rectangle from min to max.
+func overlappingParts(parts []boundedPart, min, max data.MicroPoint) []data.LayerPart {
+	var result []data.LayerPart
+	for _, part := range parts {
+		if part.min.X() <= max.X() && part.max.X() >= min.X() && part.min.Y() <= max.Y() && part.max.Y() >= min.Y() {
+			result = append(result, part.part)
+		}
+	}
+	return result
+}
@@ -0,0 +1,69 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestPolygonOverhangs(t *testing.T) {
+	square := rectangle(0, 0, 10000, 10000)
+	// the same square with an additional point in the middle of its right side which is removed by the smoothing
+	splitSquare := data.Path{
+		data.NewMicroPoint(0, 10000),
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 5000),
+		data.NewMicroPoint(10000, 10000),
+	}
+	// the right side of the square is not supported
+	below := []data.LayerPart{rectanglePart(-1000, -1000, 9000, 11000)}
+	near := []data.LayerPart{rectanglePart(9500, -1000, 11000, 11000)}
+
+	var testCases = map[string]struct {
+		polygon  data.Path
+		below    []data.LayerPart
+		near     []data.LayerPart
+		smooth   bool
+		expected []int
+	}{
+		"supported": {
+			polygon: square,
+			below:   []data.LayerPart{rectanglePart(-1000, -1000, 11000, 11000)},
+			near:    near,
+			smooth:  true,
+		},
+		"not near the unsupported area": {
+			polygon: square,
+			below:   below,
+			smooth:  true,
+		},
+		"overhanging side": {
+			polygon:  square,
+			below:    below,
+			near:     near,
+			smooth:   true,
+			expected: []int{5, 100, 5, 0},
+		},
+		"segments of the smoothed polygon": {
+			polygon:  splitSquare,
+			below:    below,
+			near:     near,
+			smooth:   true,
+			expected: []int{0, 5, 100, 5},
+		},
+		"segments of the polygon itself": {
+			polygon:  splitSquare,
+			below:    below,
+			near:     near,
+			smooth:   false,
+			expected: []int{0, 5, 100, 100, 5},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		overhangs := polygonOverhangs(testCase.polygon, newBoundedParts(testCase.below), newBoundedParts(testCase.near), 400, testCase.smooth)
+		test.Equals(t, testCase.expected, overhangs)
+	}
+}
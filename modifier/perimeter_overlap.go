@@ -0,0 +1,239 @@
+// This file provides the detection of overlapping perimeters which is used to reduce the flow on them.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// PerimeterFlow contains the flow in percent for each segment of the perimeter polygons of one inset part.
+// Segment i starts at point i of the polygon smoothed by data.DouglasPeucker(polygon, -1)
+// and the last segment closes the polygon.
+// A nil slice means that the polygon does not overlap with any other perimeter.
+// The renderer returns an error if the amount of flows does not match the segments of the smoothed polygon.
+type PerimeterFlow struct {
+	Outline []int
+	Holes   [][]int
+}
+
+// PerimeterFlows extracts the attribute "perimeterFlow" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the flows are returned in the same structure as the perimeters: [part][insetNr][insetParts].
+func PerimeterFlows(layer data.PartitionedLayer) ([][][]PerimeterFlow, error) {
+	if attr, ok := layer.Attributes()["perimeterFlow"]; ok {
+		flows, ok := attr.([][][]PerimeterFlow)
+		if !ok {
+			return nil, errors.New("the attribute perimeterFlow has the wrong datatype")
+		}
+
+		return flows, nil
+	}
+
+	return nil, nil
+}
+
+// overlapSegment is a segment of a perimeter polygon.
+// start is the distance of the first point from the start of the polygon measured along the polygon.
+type overlapSegment struct {
+	polygon    int
+	a, b       [2]float64
+	start      float64
+	minX, maxX float64
+	minY, maxY float64
+}
+
+// overlapCell identifies a cell of the grid which is used to find the segments near a point.
+type overlapCell struct {
+	x, y int
+}
+
+// overlapCellOf returns the cell of the grid with the given cell size which contains the point.
+func overlapCellOf(p [2]float64, size float64) overlapCell {
+	return overlapCell{int(math.Floor(p[0] / size)), int(math.Floor(p[1] / size))}
+}
+
+// perimeterFlows calculates the flow of each segment of the perimeters of one part.
+// Where two perimeter lines are nearer than the extrusion width, they would be extruded twice.
+// To compensate this, both lines are reduced by half of the overlap, averaged over the segment.
+// Segments of the same polygon which are less than twice the extrusion width apart (measured along the polygon)
+// are not taken into account as they are just neighbours, e.g. at a corner.
+func perimeterFlows(part [][]data.LayerPart, extrusionWidth data.Micrometer) [][]PerimeterFlow {
+	width := float64(extrusionWidth)
+
+	var polygons []data.Path
+	var lengths []float64
+	var segments []overlapSegment
+	for _, inset := range part {
+		for _, insetPart := range inset {
+			for _, polygon := range append([]data.Path{insetPart.Outline()}, insetPart.Holes()...) {
+				// DouglasPeucker may change the points of the given path, so a copy is smoothed
+				// to get the same result as the renderer which smoothes the original path.
+				polygon = data.DouglasPeucker(append(data.Path{}, polygon...), -1)
+				index := len(polygons)
+				polygons = append(polygons, polygon)
+
+				length := 0.0
+				for i := range polygon {
+					a := polygon[i]
+					b := polygon[(i+1)%len(polygon)]
+					segment := overlapSegment{
+						polygon: index,
+						a:       [2]float64{float64(a.X()), float64(a.Y())},
+						b:       [2]float64{float64(b.X()), float64(b.Y())},
+						start:   length,
+					}
+					segment.minX, segment.maxX = math.Min(segment.a[0], segment.b[0]), math.Max(segment.a[0], segment.b[0])
+					segment.minY, segment.maxY = math.Min(segment.a[1], segment.b[1]), math.Max(segment.a[1], segment.b[1])
+					segments = append(segments, segment)
+					length += math.Hypot(segment.b[0]-segment.a[0], segment.b[1]-segment.a[1])
+				}
+				lengths = append(lengths, length)
+			}
+		}
+	}
+
+	// The segments are sorted into a grid with cells of the extrusion width, so that only the segments near a point are checked.
+	// Each segment is added to the cells of points sampled in steps of half the extrusion width,
+	// so each point of a segment is within a quarter of the width from a sampled point.
+	grid := map[overlapCell][]int{}
+	for i, segment := range segments {
+		length := math.Hypot(segment.b[0]-segment.a[0], segment.b[1]-segment.a[1])
+		steps := int(math.Ceil(length / (width / 2)))
+		var last overlapCell
+		for s := 0; s <= steps; s++ {
+			t := 0.0
+			if steps > 0 {
+				t = float64(s) / float64(steps)
+			}
+			cell := overlapCellOf([2]float64{segment.a[0] + (segment.b[0]-segment.a[0])*t, segment.a[1] + (segment.b[1]-segment.a[1])*t}, width)
+			if s > 0 && cell == last {
+				continue
+			}
+			grid[cell] = append(grid[cell], i)
+			last = cell
+		}
+	}
+
+	// checked contains the number of the last query which checked the segment,
+	// as a segment can be in several of the checked cells
+	checked := make([]int, len(segments))
+	query := 0
+	var near []int
+
+	// overlap returns the width by which a line at the given point of the polygon overlaps other lines.
+	overlap := func(p [2]float64, polygon int, position float64) float64 {
+		query++
+		minDistance := width
+
+		// a segment nearer than the width has a sampled point within 1.25 times the width,
+		// which is at most two cells away
+		center := overlapCellOf(p, width)
+		near = near[:0]
+		for x := center.x - 2; x <= center.x+2; x++ {
+			for y := center.y - 2; y <= center.y+2; y++ {
+				for _, i := range grid[overlapCell{x, y}] {
+					if checked[i] != query {
+						checked[i] = query
+						near = append(near, i)
+					}
+				}
+			}
+		}
+
+		for _, i := range near {
+			other := segments[i]
+			if p[0] < other.minX-width || p[0] > other.maxX+width || p[1] < other.minY-width || p[1] > other.maxY+width {
+				continue
+			}
+
+			distance, t := segmentDistance(p, other.a, other.b)
+			if distance >= minDistance {
+				continue
+			}
+
+			if other.polygon == polygon {
+				// ignore the neighbours on the same polygon
+				along := math.Abs(other.start + t - position)
+				along = math.Min(along, lengths[polygon]-along)
+				if along < 2*width {
+					continue
+				}
+			}
+			minDistance = distance
+		}
+		return width - minDistance
+	}
+
+	result := make([][]PerimeterFlow, len(part))
+	segmentNr := 0
+	polygonNr := 0
+	for insetNr, inset := range part {
+		result[insetNr] = make([]PerimeterFlow, len(inset))
+		for insetPartNr, insetPart := range inset {
+			for holeNr := -1; holeNr < len(insetPart.Holes()); holeNr++ {
+				flows := make([]int, len(polygons[polygonNr]))
+				compensated := false
+				for i := range flows {
+					segment := segments[segmentNr]
+					segmentNr++
+
+					// sample the segment in steps of half the extrusion width
+					length := math.Hypot(segment.b[0]-segment.a[0], segment.b[1]-segment.a[1])
+					samples := int(math.Ceil(length/(width/2))) + 1
+					sum := 0.0
+					for s := 0; s < samples; s++ {
+						t := (float64(s) + 0.5) / float64(samples)
+						p := [2]float64{segment.a[0] + (segment.b[0]-segment.a[0])*t, segment.a[1] + (segment.b[1]-segment.a[1])*t}
+						sum += overlap(p, polygonNr, segment.start+length*t)
+					}
+
+					flows[i] = int(math.Round(100 - sum/float64(samples)/2/width*100))
+					if flows[i] < 100 {
+						compensated = true
+					}
+				}
+
+				if compensated {
+					if holeNr < 0 {
+						result[insetNr][insetPartNr].Outline = flows
+					} else {
+						if result[insetNr][insetPartNr].Holes == nil {
+							result[insetNr][insetPartNr].Holes = make([][]int, len(insetPart.Holes()))
+						}
+						result[insetNr][insetPartNr].Holes[holeNr] = flows
+					}
+				}
+				polygonNr++
+			}
+		}
+	}
+
+	return result
+}
+
+// segmentDistance returns the distance of the point p to the segment from a to b
+// and the distance of the nearest point of the segment from a.
+func segmentDistance(p, a, b [2]float64) (float64, float64) {
+	dx, dy := b[0]-a[0], b[1]-a[1]
+	length2 := dx*dx + dy*dy
+	t := 0.0
+	if length2 > 0 {
+		t = math.Max(0, math.Min(1, ((p[0]-a[0])*dx+(p[1]-a[1])*dy)/length2))
+	}
+
+	x, y := a[0]+dx*t, a[1]+dy*t
+	return math.Hypot(p[0]-x, p[1]-y), t * math.Sqrt(length2)
+}
+
+// calculatePerimeterFlows calculates the PerimeterFlow of all perimeters of a layer.
+func calculatePerimeterFlows(perimeters clip.OffsetResult, extrusionWidth data.Micrometer) [][][]PerimeterFlow {
+	flows := make([][][]PerimeterFlow, len(perimeters))
+	for partNr, part := range perimeters {
+		flows[partNr] = perimeterFlows(part, extrusionWidth)
+	}
+	return flows
+}
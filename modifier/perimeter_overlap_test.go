@@ -0,0 +1,133 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"math"
+	"testing"
+)
+
+func TestSegmentDistance(t *testing.T) {
+	var testCases = map[string]struct {
+		p, a, b          [2]float64
+		expectedDistance float64
+		expectedAlong    float64
+	}{
+		"beside the segment": {
+			p:                [2]float64{500, 300},
+			a:                [2]float64{0, 0},
+			b:                [2]float64{1000, 0},
+			expectedDistance: 300,
+			expectedAlong:    500,
+		},
+		"before the start": {
+			p:                [2]float64{-300, 400},
+			a:                [2]float64{0, 0},
+			b:                [2]float64{1000, 0},
+			expectedDistance: 500,
+			expectedAlong:    0,
+		},
+		"after the end": {
+			p:                [2]float64{0, -300},
+			a:                [2]float64{0, 1000},
+			b:                [2]float64{0, 0},
+			expectedDistance: 300,
+			expectedAlong:    1000,
+		},
+		"point on the segment": {
+			p:                [2]float64{300, 300},
+			a:                [2]float64{0, 0},
+			b:                [2]float64{1000, 1000},
+			expectedDistance: 0,
+			expectedAlong:    math.Hypot(300, 300),
+		},
+		"empty segment": {
+			p:                [2]float64{300, 400},
+			a:                [2]float64{0, 0},
+			b:                [2]float64{0, 0},
+			expectedDistance: 500,
+			expectedAlong:    0,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		distance, along := segmentDistance(testCase.p, testCase.a, testCase.b)
+		test.Assert(t, math.Abs(distance-testCase.expectedDistance) < 1e-9, "the distance should be %v but is %v", testCase.expectedDistance, distance)
+		test.Assert(t, math.Abs(along-testCase.expectedAlong) < 1e-9, "the distance along the segment should be %v but is %v", testCase.expectedAlong, along)
+	}
+}
+
+func TestPerimeterFlows(t *testing.T) {
+	var testCases = map[string]struct {
+		part     [][]data.LayerPart
+		expected func(t *testing.T, flows [][]PerimeterFlow)
+	}{
+		"parallel perimeters closer than the extrusion width": {
+			// the perimeters are 300 apart, so they overlap by 100 which is 12.5% per line
+			part: [][]data.LayerPart{
+				{rectanglePart(0, 0, 20000, 20000)},
+				{rectanglePart(300, 300, 19700, 19700)},
+			},
+			expected: func(t *testing.T, flows [][]PerimeterFlow) {
+				test.Equals(t, []int{88, 88, 88, 88}, flows[0][0].Outline)
+				test.Equals(t, []int{88, 88, 88, 88}, flows[1][0].Outline)
+			},
+		},
+		"parallel perimeters with the extrusion width": {
+			part: [][]data.LayerPart{
+				{rectanglePart(0, 0, 20000, 20000)},
+				{rectanglePart(400, 400, 19600, 19600)},
+			},
+			expected: func(t *testing.T, flows [][]PerimeterFlow) {
+				test.Assert(t, flows[0][0].Outline == nil, "the outer perimeter should not be compensated but is %v", flows[0][0].Outline)
+				test.Assert(t, flows[1][0].Outline == nil, "the inner perimeter should not be compensated but is %v", flows[1][0].Outline)
+			},
+		},
+		"neighbours at the corners are ignored": {
+			part: [][]data.LayerPart{
+				{rectanglePart(0, 0, 20000, 20000)},
+			},
+			expected: func(t *testing.T, flows [][]PerimeterFlow) {
+				test.Assert(t, flows[0][0].Outline == nil, "the corners should not be compensated but the flows are %v", flows[0][0].Outline)
+			},
+		},
+		"opposite sides of a thin polygon overlap": {
+			part: [][]data.LayerPart{
+				{rectanglePart(0, 0, 20000, 300)},
+			},
+			expected: func(t *testing.T, flows [][]PerimeterFlow) {
+				test.Equals(t, 4, len(flows[0][0].Outline))
+				// the long sides overlap, the short sides only touch their neighbours
+				test.Assert(t, flows[0][0].Outline[0] < 100 && flows[0][0].Outline[2] < 100, "the long sides should be compensated but the flows are %v", flows[0][0].Outline)
+				test.Equals(t, 100, flows[0][0].Outline[1])
+				test.Equals(t, 100, flows[0][0].Outline[3])
+			},
+		},
+		"hole near the outline": {
+			part: [][]data.LayerPart{
+				{data.NewBasicLayerPart(rectangle(0, 0, 20000, 20000), data.Paths{{
+					data.NewMicroPoint(300, 300),
+					data.NewMicroPoint(300, 10000),
+					data.NewMicroPoint(10000, 10000),
+					data.NewMicroPoint(10000, 300),
+				}})},
+			},
+			expected: func(t *testing.T, flows [][]PerimeterFlow) {
+				test.Equals(t, 1, len(flows[0][0].Holes))
+				test.Equals(t, 4, len(flows[0][0].Holes[0]))
+				test.Equals(t, 4, len(flows[0][0].Outline))
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		flows := calculatePerimeterFlows(clip.OffsetResult{testCase.part}, 400)
+		test.Equals(t, 1, len(flows))
+		test.Equals(t, len(testCase.part), len(flows[0]))
+		test.Equals(t, flows[0], perimeterFlows(testCase.part, 400))
+		testCase.expected(t, flows[0])
+	}
+}
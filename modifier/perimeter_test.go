@@ -0,0 +1,190 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSpiralPath(t *testing.T) {
+	var testCases = map[string]struct {
+		parts []data.LayerPart
+		// expected contains the bounds of the spiral, nil if there is none
+		expected []data.MicroPoint
+	}{
+		"no parts": {
+			parts: nil,
+		},
+		"one part": {
+			parts:    []data.LayerPart{rectanglePart(0, 0, 10000, 10000)},
+			expected: []data.MicroPoint{data.NewMicroPoint(200, 200), data.NewMicroPoint(9800, 9800)},
+		},
+		"largest part": {
+			parts: []data.LayerPart{
+				rectanglePart(0, 0, 5000, 5000),
+				rectanglePart(10000, 0, 30000, 10000),
+			},
+			expected: []data.MicroPoint{data.NewMicroPoint(10200, 200), data.NewMicroPoint(29800, 9800)},
+		},
+		"holes are ignored": {
+			parts: []data.LayerPart{
+				data.NewBasicLayerPart(rectangle(0, 0, 10000, 10000), data.Paths{rectangle(1000, 1000, 9000, 9000).Reversed()}),
+			},
+			expected: []data.MicroPoint{data.NewMicroPoint(200, 200), data.NewMicroPoint(9800, 9800)},
+		},
+		"too small": {
+			parts: []data.LayerPart{rectanglePart(0, 0, 300, 300)},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		spiral := spiralPath(clip.NewClipper(), testCase.parts, 400)
+
+		if testCase.expected == nil {
+			test.Assert(t, spiral == nil, "no spiral should be returned")
+			continue
+		}
+
+		min, max := spiral.Bounds()
+		test.Equals(t, testCase.expected[0], min, microPointComparer())
+		test.Equals(t, testCase.expected[1], max, microPointComparer())
+	}
+}
+
+func TestPerimeterModifierSpiralize(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.Spiralize = true
+	options.Print.NumberBottomLayers = 2
+	options.Printer.ExtrusionWidth = 400
+
+	square := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+	testLayers := layers(square, square, square, square)
+
+	err := NewPerimeterModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+
+	for layerNr, layer := range testLayers {
+		perimeters, err := Perimeters(layer)
+		test.Ok(t, err)
+		spiral, err := SpiralPath(layer)
+		test.Ok(t, err)
+
+		if layerNr < options.Print.NumberBottomLayers {
+			// the bottom layers are printed normally
+			test.Assert(t, len(perimeters) > 0, "the bottom layer %v should have perimeters", layerNr)
+			test.Assert(t, spiral == nil, "the bottom layer %v should have no spiral", layerNr)
+		} else {
+			test.Equals(t, 0, len(perimeters))
+			test.Assert(t, len(spiral) > 0, "the layer %v should have a spiral", layerNr)
+		}
+	}
+}
+
+func TestElephantFootCompensation(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Print.ElephantFootCompensation = 0.3
+	options.Print.InsetCount = 2
+	options.Printer.ExtrusionWidth = 400
+
+	cube := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+	testLayers := layers(cube, cube)
+
+	err := NewPerimeterModifier(&options).Modify(testLayers)
+	test.Ok(t, err)
+
+	var testCases = map[string]struct {
+		layerNr int
+		// expectedInsets contains the distance of each inset from the outline of the cube
+		expectedInsets []data.Micrometer
+	}{
+		"first layer": {
+			layerNr:        0,
+			expectedInsets: []data.Micrometer{500, 900},
+		},
+		"second layer": {
+			layerNr:        1,
+			expectedInsets: []data.Micrometer{200, 600},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		perimeters, err := Perimeters(testLayers[testCase.layerNr])
+		test.Ok(t, err)
+		test.Equals(t, 1, len(perimeters))
+		test.Equals(t, len(testCase.expectedInsets), len(perimeters[0]))
+
+		for insetNr, inset := range perimeters[0] {
+			distance := testCase.expectedInsets[insetNr]
+			min, max := partsBounds(inset)
+			test.Equals(t, data.NewMicroPoint(distance, distance), min, microPointComparer())
+			test.Equals(t, data.NewMicroPoint(10000-distance, 10000-distance), max, microPointComparer())
+		}
+	}
+}
+
+func TestPerimeterOverlap(t *testing.T) {
+	// oldOverlap is the formula which was used before the absolute overlap existed
+	oldOverlap := func(overlapPercent int, extrusionWidth data.Micrometer) data.Micrometer {
+		return data.Micrometer(float32(extrusionWidth) * (100.0 - float32(overlapPercent)) / 100.0)
+	}
+
+	var testCases = map[string]struct {
+		overlap           data.Micrometer
+		overlapPercent    int
+		additionalPercent int
+		extrusionWidth    data.Micrometer
+		expected          data.Micrometer
+	}{
+		"percent": {
+			overlapPercent: 50,
+			extrusionWidth: 400,
+			expected:       oldOverlap(50, 400),
+		},
+		"rounded percent": {
+			overlapPercent: 33,
+			extrusionWidth: 450,
+			expected:       oldOverlap(33, 450),
+		},
+		"more than 100 percent": {
+			overlapPercent: 200,
+			extrusionWidth: 400,
+			expected:       oldOverlap(200, 400),
+		},
+		"absolute overlap instead of the percent": {
+			overlap:        100,
+			overlapPercent: 10,
+			extrusionWidth: 400,
+			expected:       200,
+		},
+		"additional percent": {
+			overlapPercent:    50,
+			additionalPercent: 25,
+			extrusionWidth:    400,
+			expected:          100,
+		},
+		"additional percent with absolute overlap": {
+			overlap:           100,
+			additionalPercent: 25,
+			extrusionWidth:    400,
+			expected:          100,
+		},
+		"absolute overlap of half the width": {
+			overlap:        200,
+			extrusionWidth: 400,
+			expected:       0,
+		},
+		"absolute overlap beyond half the width": {
+			overlap:        300,
+			extrusionWidth: 400,
+			expected:       -200,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, perimeterOverlap(testCase.overlap, testCase.overlapPercent, testCase.additionalPercent, testCase.extrusionWidth))
+	}
+}
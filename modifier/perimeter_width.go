@@ -0,0 +1,167 @@
+// This file provides the adaption of the perimeter width to the width of the walls.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// PerimeterWidth contains the line width for each segment of the perimeter polygons of one inset part.
+// Segment i starts at point i of the polygon and the last segment closes the polygon.
+// A nil slice means that the polygon is printed with the normal extrusion width.
+type PerimeterWidth struct {
+	Outline []data.Micrometer
+	Holes   [][]data.Micrometer
+}
+
+// PerimeterWidths extracts the attribute "perimeterWidth" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the widths are returned in the same structure as the perimeters: [part][insetNr][insetParts].
+func PerimeterWidths(layer data.PartitionedLayer) ([][][]PerimeterWidth, error) {
+	if attr, ok := layer.Attributes()["perimeterWidth"]; ok {
+		widths, ok := attr.([][][]PerimeterWidth)
+		if !ok {
+			return nil, errors.New("the attribute perimeterWidth has the wrong datatype")
+		}
+
+		return widths, nil
+	}
+
+	return nil, nil
+}
+
+// adaptPerimeterWidths widens the perimeters of one part so that they fill the gaps which are too narrow for the
+// next perimeter, e.g. where a wall gets thinner. This keeps the amount of lines the same instead of leaving a gap.
+// Both lines beside such a gap are widened by half of the gap and moved towards it by a quarter of the gap,
+// so that they meet in its center while their outer side stays the same.
+// The gaps inside the innermost perimeter are left for the infill.
+//
+// The perimeters are the center lines of the perimeters of the part ([insetNr][insetParts]) which were
+// generated using the initial offset and the width.
+// The adapted perimeters are returned together with the widths of their segments.
+func adaptPerimeterWidths(c clip.Clipper, part data.LayerPart, perimeters [][]data.LayerPart, width, initialOffset data.Micrometer) ([][]data.LayerPart, [][]PerimeterWidth, error) {
+	adapted := make([][]data.LayerPart, len(perimeters))
+	widths := make([][]PerimeterWidth, len(perimeters))
+	for insetNr, inset := range perimeters {
+		adapted[insetNr] = append([]data.LayerPart{}, inset...)
+		widths[insetNr] = make([]PerimeterWidth, len(inset))
+	}
+
+	for insetNr := 1; insetNr < len(perimeters); insetNr++ {
+		// gaps narrower than a tenth of the width are not worth it
+		gaps, err := perimeterGaps(c, part, perimeters[insetNr], width, initialOffset-data.Micrometer(insetNr)*width, width/10)
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var axes []VariableWidthLine
+		for _, gap := range gaps {
+			axes = append(axes, medialAxis(gap, width)...)
+		}
+		if len(axes) == 0 {
+			continue
+		}
+
+		for insetPartNr, insetPart := range perimeters[insetNr-1] {
+			outline, outlineWidths := adaptPolygon(insetPart.Outline(), axes, width)
+
+			var holes data.Paths
+			var holeWidths [][]data.Micrometer
+			for holeNr, hole := range insetPart.Holes() {
+				hole, widths := adaptPolygon(hole, axes, width)
+				holes = append(holes, hole)
+				if widths != nil {
+					if holeWidths == nil {
+						holeWidths = make([][]data.Micrometer, len(insetPart.Holes()))
+					}
+					holeWidths[holeNr] = widths
+				}
+			}
+
+			if outlineWidths == nil && holeWidths == nil {
+				continue
+			}
+			adapted[insetNr-1][insetPartNr] = data.NewBasicLayerPart(outline, holes)
+			widths[insetNr-1][insetPartNr] = PerimeterWidth{
+				Outline: outlineWidths,
+				Holes:   holeWidths,
+			}
+		}
+	}
+
+	return adapted, widths, nil
+}
+
+// adaptPolygon moves the points of the closed perimeter polygon which lie beside one of the gaps towards the gap
+// and returns the widths of the segments of the resulting polygon.
+// If no point lies beside a gap, the polygon is returned unchanged with nil widths.
+func adaptPolygon(polygon data.Path, gaps []VariableWidthLine, width data.Micrometer) (data.Path, []data.Micrometer) {
+	var result data.Path
+	var pointWidths []data.Micrometer
+	adapted := false
+	for _, sample := range sampleContour(polygon, width/2) {
+		point := sample.point
+		pointWidth := width
+
+		center, gapWidth, ok := nearestAxisPoint(point, gaps)
+		gapWidth = clampWidth(gapWidth, 0, width)
+		// the line lies beside the gap if its inner side is not further away than the side of the gap
+		if ok && center.Sub(point).ShorterThanOrEqual(width/2+gapWidth/2+width/4) {
+			toCenter := center.Sub(point)
+			if distance := toCenter.Size(); distance > gapWidth/4 {
+				point = point.Add(toCenter.Mul(gapWidth / 4).Div(distance))
+			}
+			pointWidth = width + gapWidth/2
+			adapted = true
+		}
+
+		result = append(result, point)
+		pointWidths = append(pointWidths, pointWidth)
+	}
+
+	if !adapted {
+		return polygon, nil
+	}
+
+	widths := make([]data.Micrometer, len(pointWidths))
+	for i := range pointWidths {
+		widths[i] = (pointWidths[i] + pointWidths[(i+1)%len(pointWidths)]) / 2
+	}
+	return result, widths
+}
+
+// nearestAxisPoint returns the point of the lines which is nearest to p together with the width at this point.
+// It returns false if there are no lines.
+func nearestAxisPoint(p data.MicroPoint, lines []VariableWidthLine) (data.MicroPoint, data.Micrometer, bool) {
+	var best data.MicroPoint
+	var bestWidth data.Micrometer
+	bestDistance := data.Micrometer(-1)
+	for _, line := range lines {
+		count := len(line.Path)
+		if !line.Closed {
+			count--
+		}
+		for i := 0; i < count; i++ {
+			next := (i + 1) % len(line.Path)
+			a, b := line.Path[i], line.Path[next]
+			point := data.ProjectOnSegment(p, a, b)
+			distance := point.Sub(p).Size()
+			if bestDistance != -1 && distance >= bestDistance {
+				continue
+			}
+
+			// interpolate the width along the segment
+			w := line.Widths[i]
+			if length := b.Sub(a).Size(); length > 0 {
+				w += (line.Widths[next] - line.Widths[i]) * point.Sub(a).Size() / length
+			}
+
+			best, bestWidth, bestDistance = point, w, distance
+		}
+	}
+
+	return best, bestWidth, bestDistance != -1
+}
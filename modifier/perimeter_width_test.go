@@ -0,0 +1,102 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestAdaptPerimeterWidths(t *testing.T) {
+	// a wall which gets wider from 800 at x = 0 to 1600 at x = 20000,
+	// so that the second perimeter does not fit into the narrower half
+	tapered := data.NewBasicLayerPart(data.Path{
+		data.NewMicroPoint(0, -400),
+		data.NewMicroPoint(20000, -800),
+		data.NewMicroPoint(20000, 800),
+		data.NewMicroPoint(0, 400),
+	}, nil)
+	// halfWidth returns the half of the width of the wall at x
+	halfWidth := func(x data.Micrometer) data.Micrometer {
+		return 400 + x/50
+	}
+
+	var width data.Micrometer = 400
+	c := clip.NewClipper()
+	perimeters := c.Inset(tapered, width, 2, -width/2)
+	test.Equals(t, 1, len(perimeters[0]))
+	test.Equals(t, 1, len(perimeters[1]))
+
+	adapted, widths, err := adaptPerimeterWidths(c, tapered, perimeters, width, -width/2)
+	test.Ok(t, err)
+
+	// the innermost perimeter is not adapted
+	test.Equals(t, perimeters[1], adapted[1], layerPartComparer())
+	test.Assert(t, widths[1][0].Outline == nil, "the innermost perimeter should keep the normal width")
+
+	outline := adapted[0][0].Outline()
+	outlineWidths := widths[0][0].Outline
+	test.Equals(t, len(outline), len(outlineWidths))
+
+	widened := 0
+	for i, point := range outline {
+		// skip the ends of the wall and the end of the gap, where the segment widths change
+		if point.X() < 1000 || point.X() > 19000 || (point.X() >= 9500 && point.X() <= 10500) {
+			continue
+		}
+
+		y := point.Y()
+		if y < 0 {
+			y = -y
+		}
+
+		segmentWidth := outlineWidths[i]
+		if point.X() > 10500 {
+			test.Equals(t, width, segmentWidth)
+		} else {
+			test.Assert(t, segmentWidth > width && segmentWidth <= width*3/2, "the width %v at %v should be widened by at most half of the width", segmentWidth, point)
+			widened++
+		}
+
+		// the outer side of the line stays at the side of the wall
+		outerSide := y + segmentWidth/2
+		test.Assert(t, outerSide >= halfWidth(point.X())-20 && outerSide <= halfWidth(point.X())+20, "the outer side %v at %v should be at the side of the wall", outerSide, point)
+	}
+	test.Assert(t, widened > 0, "the perimeter beside the gap should be widened")
+}
+
+func TestAdaptPolygon(t *testing.T) {
+	polygon := rectangle(0, 0, 20000, 400)
+
+	// without gaps the polygon stays as it is
+	unchanged, widths := adaptPolygon(polygon, nil, 400)
+	test.Equals(t, polygon, unchanged, microPointComparer())
+	test.Assert(t, widths == nil, "the polygon should keep the normal width")
+
+	// a gap of 200 along the top side of the polygon
+	gaps := []VariableWidthLine{{
+		Path:   data.Path{data.NewMicroPoint(0, 700), data.NewMicroPoint(20000, 700)},
+		Widths: []data.Micrometer{200, 200},
+	}}
+	adapted, widths := adaptPolygon(polygon, gaps, 400)
+	test.Equals(t, len(adapted), len(widths))
+
+	for i, point := range adapted {
+		if point.X() <= 0 || point.X() >= 20000 {
+			continue
+		}
+
+		if point.Y() > 200 {
+			// moved towards the gap by a quarter of the gap and widened by half of the gap
+			test.Equals(t, data.Micrometer(450), point.Y())
+			if i+1 < len(adapted) && adapted[i+1].Y() > 200 {
+				test.Equals(t, data.Micrometer(500), widths[i])
+			}
+		} else {
+			test.Equals(t, data.Micrometer(0), point.Y())
+			if i+1 < len(adapted) && adapted[i+1].Y() < 200 {
+				test.Equals(t, data.Micrometer(400), widths[i])
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+// This file provides the conversion of circular holes into polyholes.
+
+package modifier
+
+import (
+	"math"
+
+	"github.com/aligator/goslice/data"
+)
+
+// minCircleVertices is the minimal amount of vertices a hole needs to be detected as circle.
+const minCircleVertices = 6
+
+// detectCircle checks if the given closed path approximates a circle.
+// It returns the center and the radius of the circle if it is one.
+// A path counts as circle if the distance of all vertices to the center
+// differs at most by the given tolerance (or 5% of the radius if it is bigger) from the mean radius.
+func detectCircle(path data.Path, tolerance data.Micrometer) (center data.MicroPoint, radius data.Micrometer, ok bool) {
+	if len(path) < minCircleVertices {
+		return nil, 0, false
+	}
+
+	var sumX, sumY data.Micrometer
+	for _, point := range path {
+		sumX += point.X()
+		sumY += point.Y()
+	}
+	center = data.NewMicroPoint(sumX/data.Micrometer(len(path)), sumY/data.Micrometer(len(path)))
+
+	var sumRadius data.Micrometer
+	for _, point := range path {
+		sumRadius += point.Sub(center).Size()
+	}
+	radius = sumRadius / data.Micrometer(len(path))
+
+	maxDeviation := data.Max(tolerance, radius/20)
+	for _, point := range path {
+		deviation := point.Sub(center).Size() - radius
+		if deviation > maxDeviation || deviation < -maxDeviation {
+			return nil, 0, false
+		}
+	}
+
+	return center, radius, true
+}
+
+// polyhole generates a polyhole for a circle with the given center and radius.
+// The amount of sides depends on the diameter and the polygon is made
+// big enough so that the circle fits inside of it.
+// The result has the same orientation as indicated by clockwise.
+func polyhole(center data.MicroPoint, radius data.Micrometer, clockwise bool) data.Path {
+	sides := int(math.Max(math.Round(4*float64(radius.ToMillimeter())), 3))
+	polyRadius := float64(radius) / math.Cos(math.Pi/float64(sides))
+
+	direction := 1.0
+	if clockwise {
+		direction = -1.0
+	}
+
+	result := make(data.Path, sides)
+	for i := 0; i < sides; i++ {
+		angle := direction * 2 * math.Pi * float64(i) / float64(sides)
+		result[i] = data.NewMicroPoint(
+			center.X()+data.Micrometer(math.Round(polyRadius*math.Cos(angle))),
+			center.Y()+data.Micrometer(math.Round(polyRadius*math.Sin(angle))),
+		)
+	}
+
+	return result
+}
+
+// polyholeParts replaces all circular holes of the given parts
+// which are not bigger than maxDiameter by polyholes.
+// The parts keep their attributes.
+func polyholeParts(parts []data.LayerPart, maxDiameter data.Micrometer, tolerance data.Micrometer) []data.LayerPart {
+	result := make([]data.LayerPart, len(parts))
+	for i, part := range parts {
+		result[i] = part
+
+		changed := false
+		holes := make(data.Paths, len(part.Holes()))
+		for j, hole := range part.Holes() {
+			center, radius, ok := detectCircle(hole, tolerance)
+			if !ok || radius*2 > maxDiameter {
+				holes[j] = hole
+				continue
+			}
+
+			holes[j] = polyhole(center, radius, hole.Area() < 0)
+			changed = true
+		}
+		if !changed {
+			continue
+		}
+
+		result[i] = data.NewBasicLayerPart(part.Outline(), holes)
+		if attributes := part.Attributes(); attributes != nil {
+			result[i] = attributedPart{LayerPart: result[i], attributes: attributes}
+		}
+	}
+
+	return result
+}
@@ -0,0 +1,165 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"math"
+	"testing"
+)
+
+// circle returns a path with the given amount of vertices on the circle.
+func circle(center data.MicroPoint, radius data.Micrometer, vertices int, clockwise bool) data.Path {
+	direction := 1.0
+	if clockwise {
+		direction = -1.0
+	}
+
+	result := make(data.Path, vertices)
+	for i := range result {
+		angle := direction * 2 * math.Pi * float64(i) / float64(vertices)
+		result[i] = data.NewMicroPoint(
+			center.X()+data.Micrometer(math.Round(float64(radius)*math.Cos(angle))),
+			center.Y()+data.Micrometer(math.Round(float64(radius)*math.Sin(angle))),
+		)
+	}
+	return result
+}
+
+func TestDetectCircle(t *testing.T) {
+	var testCases = map[string]struct {
+		path           data.Path
+		expectedOk     bool
+		expectedCenter data.MicroPoint
+		expectedRadius data.Micrometer
+	}{
+		"circle": {
+			path:           circle(data.NewMicroPoint(10000, 20000), 5000, 32, true),
+			expectedOk:     true,
+			expectedCenter: data.NewMicroPoint(10000, 20000),
+			expectedRadius: 5000,
+		},
+		"too few vertices": {
+			path: circle(data.NewMicroPoint(0, 0), 5000, 5, true),
+		},
+		"rectangle": {
+			path: data.Path{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(5000, 0),
+				data.NewMicroPoint(10000, 0),
+				data.NewMicroPoint(10000, 2000),
+				data.NewMicroPoint(5000, 2000),
+				data.NewMicroPoint(0, 2000),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		center, radius, ok := detectCircle(testCase.path, 100)
+		test.Equals(t, testCase.expectedOk, ok)
+		if !testCase.expectedOk {
+			continue
+		}
+
+		test.Equals(t, testCase.expectedCenter, center, microPointComparer())
+		// the vertices are rounded, so the mean radius may be slightly smaller
+		test.Assert(t, radius <= testCase.expectedRadius && radius > testCase.expectedRadius-10, "the radius should be about %v but is %v", testCase.expectedRadius, radius)
+	}
+}
+
+func TestPolyhole(t *testing.T) {
+	var testCases = map[string]struct {
+		radius        data.Micrometer
+		clockwise     bool
+		expectedSides int
+	}{
+		"8 sides for 2mm": {
+			radius:        2000,
+			clockwise:     true,
+			expectedSides: 8,
+		},
+		"rounded side count": {
+			radius:        1600,
+			clockwise:     true,
+			expectedSides: 6,
+		},
+		"at least 3 sides": {
+			radius:        300,
+			clockwise:     true,
+			expectedSides: 3,
+		},
+		"counter clockwise": {
+			radius:        2500,
+			clockwise:     false,
+			expectedSides: 10,
+		},
+	}
+
+	center := data.NewMicroPoint(10000, -5000)
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		result := polyhole(center, testCase.radius, testCase.clockwise)
+		test.Equals(t, testCase.expectedSides, len(result))
+		test.Equals(t, testCase.clockwise, result.Area() < 0)
+
+		// the circle fits into the polygon, so the vertices lie on the radius r/cos(π/n)
+		polyRadius := float64(testCase.radius) / math.Cos(math.Pi/float64(testCase.expectedSides))
+		test.Equals(t, data.NewMicroPoint(center.X()+data.Micrometer(math.Round(polyRadius)), center.Y()), result[0], microPointComparer())
+		for _, p := range result {
+			distance := math.Hypot(float64(p.X()-center.X()), float64(p.Y()-center.Y()))
+			test.Assert(t, math.Abs(distance-polyRadius) <= 1, "the vertex %v should be %v away from the center but is %v", p, polyRadius, distance)
+		}
+	}
+}
+
+func TestPolyholeParts(t *testing.T) {
+	outline := rectangle(0, 0, 50000, 50000)
+	hole := circle(data.NewMicroPoint(10000, 10000), 2000, 32, true)
+	square := data.Path{
+		data.NewMicroPoint(30000, 30000),
+		data.NewMicroPoint(30000, 34000),
+		data.NewMicroPoint(34000, 34000),
+		data.NewMicroPoint(34000, 30000),
+	}
+	big := circle(data.NewMicroPoint(30000, 10000), 8000, 32, true)
+	attributes := map[string]interface{}{"type": "test"}
+
+	// the polyhole of the small circle
+	center, radius, _ := detectCircle(hole, 100)
+	replaced := polyhole(center, radius, true)
+
+	var testCases = map[string]struct {
+		part          data.LayerPart
+		expectedHoles data.Paths
+	}{
+		"circle": {
+			part:          data.NewBasicLayerPart(outline, data.Paths{hole}),
+			expectedHoles: data.Paths{replaced},
+		},
+		"no circle": {
+			part:          data.NewBasicLayerPart(outline, data.Paths{square}),
+			expectedHoles: data.Paths{square},
+		},
+		"bigger than the max diameter": {
+			part:          data.NewBasicLayerPart(outline, data.Paths{big}),
+			expectedHoles: data.Paths{big},
+		},
+		"only the circle is replaced": {
+			part:          data.NewBasicLayerPart(outline, data.Paths{square, hole, big}),
+			expectedHoles: data.Paths{square, replaced, big},
+		},
+		"attributes are kept": {
+			part:          attributedPart{LayerPart: data.NewBasicLayerPart(outline, data.Paths{hole}), attributes: attributes},
+			expectedHoles: data.Paths{replaced},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		result := polyholeParts([]data.LayerPart{testCase.part}, 10000, 100)
+		test.Equals(t, 1, len(result))
+		test.Equals(t, outline, result[0].Outline(), microPointComparer())
+		test.Equals(t, testCase.expectedHoles, result[0].Holes(), microPointComparer())
+		test.Equals(t, testCase.part.Attributes(), result[0].Attributes())
+	}
+}
@@ -0,0 +1,420 @@
+// This file provides the serialization of modified layers including their attributes.
+
+package modifier
+
+import (
+	"encoding/gob"
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"io"
+)
+
+// layersFileVersion is increased each time the format of the serialized layers changes.
+const layersFileVersion = 3
+
+// The types of the attributes which can be serialized.
+const (
+	attributeParts             = "parts"
+	attributePartsList         = "partsList"
+	attributeOffsetResult      = "offsetResult"
+	attributePerimeterFlow     = "perimeterFlow"
+	attributePerimeterWidth    = "perimeterWidth"
+	attributePerimeterOverhang = "perimeterOverhang"
+	attributePath              = "path"
+	attributePaths             = "paths"
+	attributeFirstLayer        = "firstLayer"
+	attributeVariableWidth     = "variableWidth"
+	attributeGapFill           = "gapFill"
+	attributeBridges           = "bridges"
+	attributeArgs              = "args"
+)
+
+type serializedPoint struct {
+	X, Y data.Micrometer
+}
+
+type serializedFirstLayerPath struct {
+	Feature data.Feature
+	Path    []serializedPoint
+	Open    bool
+}
+
+type serializedVariableWidthLine struct {
+	Path   []serializedPoint
+	Widths []data.Micrometer
+	Closed bool
+}
+
+type serializedGapFillLine struct {
+	Path   []serializedPoint
+	Closed bool
+	Width  data.Micrometer
+}
+
+type serializedBridge struct {
+	Part  serializedPart
+	Angle int
+}
+
+type serializedPart struct {
+	Outline    []serializedPoint
+	Holes      [][]serializedPoint
+	Attributes map[string]serializedAttribute
+}
+
+// serializedAttribute contains one attribute of a layer or a part.
+// Only the field matching the Type is set.
+type serializedAttribute struct {
+	Type              string
+	Parts             []serializedPart
+	PartsList         [][]serializedPart
+	OffsetResult      [][][]serializedPart
+	PerimeterFlow     [][][]PerimeterFlow
+	PerimeterWidth    [][][]PerimeterWidth
+	PerimeterOverhang [][][]PerimeterOverhang
+	Path              []serializedPoint
+	Paths             [][]serializedPoint
+	FirstLayer        []serializedFirstLayerPath
+	VariableWidth     []serializedVariableWidthLine
+	GapFill           []serializedGapFillLine
+	Bridges           []serializedBridge
+	Args              []string
+}
+
+type serializedLayer struct {
+	Parts      []serializedPart
+	Attributes map[string]serializedAttribute
+}
+
+type serializedLayers struct {
+	Version int
+	Layers  []serializedLayer
+}
+
+// attributedPart is a part with attributes, e.g. a part read by ReadLayers which had attributes when it was written
+// or a part whose holes were replaced by polyholes.
+type attributedPart struct {
+	data.LayerPart
+	attributes map[string]interface{}
+}
+
+func (p attributedPart) Attributes() map[string]interface{} {
+	return p.attributes
+}
+
+// WriteLayers writes the layers including all attributes set by the modifiers of GoSlice,
+// so that they can be read again using ReadLayers.
+// The attributes of the parts are written too.
+// It fails if a layer or part contains an attribute of an unknown type.
+func WriteLayers(w io.Writer, layers []data.PartitionedLayer) error {
+	result := serializedLayers{
+		Version: layersFileVersion,
+		Layers:  make([]serializedLayer, len(layers)),
+	}
+
+	for layerNr, layer := range layers {
+		var err error
+		result.Layers[layerNr].Parts, err = serializeParts(layer.LayerParts())
+		if err != nil {
+			return fmt.Errorf("layer %v: %w", layerNr, err)
+		}
+
+		result.Layers[layerNr].Attributes, err = serializeAttributes(layer.Attributes())
+		if err != nil {
+			return fmt.Errorf("layer %v: %w", layerNr, err)
+		}
+	}
+
+	return gob.NewEncoder(w).Encode(result)
+}
+
+// ReadLayers reads layers written by WriteLayers.
+func ReadLayers(r io.Reader) ([]data.PartitionedLayer, error) {
+	var serialized serializedLayers
+	err := gob.NewDecoder(r).Decode(&serialized)
+	if err != nil {
+		return nil, err
+	}
+
+	if serialized.Version != layersFileVersion {
+		return nil, fmt.Errorf("the layers were written in version %v of the format but only version %v is supported", serialized.Version, layersFileVersion)
+	}
+
+	layers := make([]data.PartitionedLayer, len(serialized.Layers))
+	for layerNr, serializedLayer := range serialized.Layers {
+		parts, err := deserializeParts(serializedLayer.Parts)
+		if err != nil {
+			return nil, fmt.Errorf("layer %v: %w", layerNr, err)
+		}
+
+		layer := newExtendedLayer(data.NewPartitionedLayer(parts))
+		for name, attribute := range serializedLayer.Attributes {
+			layer.attributes[name], err = deserializeAttribute(attribute)
+			if err != nil {
+				return nil, fmt.Errorf("the attribute %s of layer %v: %w", name, layerNr, err)
+			}
+		}
+
+		layers[layerNr] = layer
+	}
+
+	return layers, nil
+}
+
+// serializeAttributes converts all attributes.
+// It returns nil if there are no attributes.
+func serializeAttributes(attributes map[string]interface{}) (map[string]serializedAttribute, error) {
+	if len(attributes) == 0 {
+		return nil, nil
+	}
+
+	result := make(map[string]serializedAttribute, len(attributes))
+	for name, attribute := range attributes {
+		serialized, err := serializeAttribute(attribute)
+		if err != nil {
+			return nil, fmt.Errorf("the attribute %s: %w", name, err)
+		}
+		result[name] = serialized
+	}
+	return result, nil
+}
+
+// serializeAttribute converts one attribute.
+// It fails if the attribute has an unknown type.
+func serializeAttribute(attribute interface{}) (serializedAttribute, error) {
+	var serialized serializedAttribute
+	var err error
+	switch value := attribute.(type) {
+	case []data.LayerPart:
+		serialized.Type = attributeParts
+		if serialized.Parts, err = serializeParts(value); err != nil {
+			return serializedAttribute{}, err
+		}
+	case [][]data.LayerPart:
+		serialized.Type = attributePartsList
+		serialized.PartsList = make([][]serializedPart, len(value))
+		for i, parts := range value {
+			if serialized.PartsList[i], err = serializeParts(parts); err != nil {
+				return serializedAttribute{}, err
+			}
+		}
+	case clip.OffsetResult:
+		serialized.Type = attributeOffsetResult
+		serialized.OffsetResult = make([][][]serializedPart, len(value))
+		for i, part := range value {
+			serialized.OffsetResult[i] = make([][]serializedPart, len(part))
+			for j, parts := range part {
+				if serialized.OffsetResult[i][j], err = serializeParts(parts); err != nil {
+					return serializedAttribute{}, err
+				}
+			}
+		}
+	case [][][]PerimeterFlow:
+		serialized.Type = attributePerimeterFlow
+		serialized.PerimeterFlow = value
+	case [][][]PerimeterWidth:
+		serialized.Type = attributePerimeterWidth
+		serialized.PerimeterWidth = value
+	case [][][]PerimeterOverhang:
+		serialized.Type = attributePerimeterOverhang
+		serialized.PerimeterOverhang = value
+	case data.Path:
+		serialized.Type = attributePath
+		serialized.Path = serializePath(value)
+	case data.Paths:
+		serialized.Type = attributePaths
+		serialized.Paths = make([][]serializedPoint, len(value))
+		for i, path := range value {
+			serialized.Paths[i] = serializePath(path)
+		}
+	case []FirstLayerPath:
+		serialized.Type = attributeFirstLayer
+		for _, path := range value {
+			serialized.FirstLayer = append(serialized.FirstLayer, serializedFirstLayerPath{
+				Feature: path.Feature,
+				Path:    serializePath(path.Path),
+				Open:    path.Open,
+			})
+		}
+	case []VariableWidthLine:
+		serialized.Type = attributeVariableWidth
+		for _, line := range value {
+			serialized.VariableWidth = append(serialized.VariableWidth, serializedVariableWidthLine{
+				Path:   serializePath(line.Path),
+				Widths: line.Widths,
+				Closed: line.Closed,
+			})
+		}
+	case []GapFillLine:
+		serialized.Type = attributeGapFill
+		for _, line := range value {
+			serialized.GapFill = append(serialized.GapFill, serializedGapFillLine{
+				Path:   serializePath(line.Path),
+				Closed: line.Closed,
+				Width:  line.Width,
+			})
+		}
+	case []Bridge:
+		serialized.Type = attributeBridges
+		serialized.Bridges = make([]serializedBridge, len(value))
+		for i, bridge := range value {
+			parts, err := serializeParts([]data.LayerPart{bridge.Part})
+			if err != nil {
+				return serializedAttribute{}, err
+			}
+			serialized.Bridges[i] = serializedBridge{Part: parts[0], Angle: bridge.Angle}
+		}
+	case data.LayerSettings:
+		serialized.Type = attributeArgs
+		serialized.Args = value
+	default:
+		return serializedAttribute{}, fmt.Errorf("the type %T cannot be serialized", attribute)
+	}
+
+	return serialized, nil
+}
+
+// deserializeAttribute converts one attribute back to the type it had when it was written.
+func deserializeAttribute(attribute serializedAttribute) (interface{}, error) {
+	switch attribute.Type {
+	case attributeParts:
+		return deserializeParts(attribute.Parts)
+	case attributePartsList:
+		partsList := make([][]data.LayerPart, len(attribute.PartsList))
+		for i, parts := range attribute.PartsList {
+			var err error
+			if partsList[i], err = deserializeParts(parts); err != nil {
+				return nil, err
+			}
+		}
+		return partsList, nil
+	case attributeOffsetResult:
+		offsetResult := make(clip.OffsetResult, len(attribute.OffsetResult))
+		for i, part := range attribute.OffsetResult {
+			offsetResult[i] = make([][]data.LayerPart, len(part))
+			for j, parts := range part {
+				var err error
+				if offsetResult[i][j], err = deserializeParts(parts); err != nil {
+					return nil, err
+				}
+			}
+		}
+		return offsetResult, nil
+	case attributePerimeterFlow:
+		return attribute.PerimeterFlow, nil
+	case attributePerimeterWidth:
+		return attribute.PerimeterWidth, nil
+	case attributePerimeterOverhang:
+		return attribute.PerimeterOverhang, nil
+	case attributePath:
+		return deserializePath(attribute.Path), nil
+	case attributePaths:
+		paths := make(data.Paths, len(attribute.Paths))
+		for i, path := range attribute.Paths {
+			paths[i] = deserializePath(path)
+		}
+		return paths, nil
+	case attributeFirstLayer:
+		paths := make([]FirstLayerPath, len(attribute.FirstLayer))
+		for i, path := range attribute.FirstLayer {
+			paths[i] = FirstLayerPath{
+				Feature: path.Feature,
+				Path:    deserializePath(path.Path),
+				Open:    path.Open,
+			}
+		}
+		return paths, nil
+	case attributeVariableWidth:
+		lines := make([]VariableWidthLine, len(attribute.VariableWidth))
+		for i, line := range attribute.VariableWidth {
+			lines[i] = VariableWidthLine{
+				Path:   deserializePath(line.Path),
+				Widths: line.Widths,
+				Closed: line.Closed,
+			}
+		}
+		return lines, nil
+	case attributeGapFill:
+		lines := make([]GapFillLine, len(attribute.GapFill))
+		for i, line := range attribute.GapFill {
+			lines[i] = GapFillLine{
+				Path:   deserializePath(line.Path),
+				Closed: line.Closed,
+				Width:  line.Width,
+			}
+		}
+		return lines, nil
+	case attributeBridges:
+		bridges := make([]Bridge, len(attribute.Bridges))
+		for i, bridge := range attribute.Bridges {
+			parts, err := deserializeParts([]serializedPart{bridge.Part})
+			if err != nil {
+				return nil, err
+			}
+			bridges[i] = Bridge{Part: parts[0], Angle: bridge.Angle}
+		}
+		return bridges, nil
+	case attributeArgs:
+		return data.LayerSettings(attribute.Args), nil
+	default:
+		return nil, fmt.Errorf("unknown type %s", attribute.Type)
+	}
+}
+
+func serializePath(path data.Path) []serializedPoint {
+	result := make([]serializedPoint, len(path))
+	for i, p := range path {
+		result[i] = serializedPoint{X: p.X(), Y: p.Y()}
+	}
+	return result
+}
+
+func serializeParts(parts []data.LayerPart) ([]serializedPart, error) {
+	result := make([]serializedPart, len(parts))
+	for i, part := range parts {
+		result[i].Outline = serializePath(part.Outline())
+		for _, hole := range part.Holes() {
+			result[i].Holes = append(result[i].Holes, serializePath(hole))
+		}
+
+		var err error
+		result[i].Attributes, err = serializeAttributes(part.Attributes())
+		if err != nil {
+			return nil, fmt.Errorf("part %v: %w", i, err)
+		}
+	}
+	return result, nil
+}
+
+func deserializePath(path []serializedPoint) data.Path {
+	result := make(data.Path, len(path))
+	for i, p := range path {
+		result[i] = data.NewMicroPoint(p.X, p.Y)
+	}
+	return result
+}
+
+func deserializeParts(parts []serializedPart) ([]data.LayerPart, error) {
+	result := make([]data.LayerPart, len(parts))
+	for i, part := range parts {
+		var holes data.Paths
+		for _, hole := range part.Holes {
+			holes = append(holes, deserializePath(hole))
+		}
+		result[i] = data.NewBasicLayerPart(deserializePath(part.Outline), holes)
+
+		if len(part.Attributes) == 0 {
+			continue
+		}
+		attributes := make(map[string]interface{}, len(part.Attributes))
+		for name, attribute := range part.Attributes {
+			var err error
+			if attributes[name], err = deserializeAttribute(attribute); err != nil {
+				return nil, fmt.Errorf("the attribute %s of part %v: %w", name, i, err)
+			}
+		}
+		result[i] = attributedPart{LayerPart: result[i], attributes: attributes}
+	}
+	return result, nil
+}
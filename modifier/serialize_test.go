@@ -0,0 +1,68 @@
+package modifier
+
+import (
+	"bytes"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestWriteAndReadLayers(t *testing.T) {
+	part := attributedPart{
+		LayerPart: rectanglePart(0, 0, 1000, 1000),
+		attributes: map[string]interface{}{
+			"inner": []data.LayerPart{rectanglePart(100, 100, 900, 900)},
+		},
+	}
+
+	var testCases = map[string]struct {
+		attributes    map[string]interface{}
+		expectedError string
+	}{
+		"all known types": {
+			attributes: map[string]interface{}{
+				"spiral": data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(10, 0)},
+				"thinWalls": []VariableWidthLine{{
+					Path:   data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(10, 0)},
+					Widths: []data.Micrometer{200, 300},
+				}},
+				"gapFill": []GapFillLine{{
+					Path:   data.Path{data.NewMicroPoint(0, 0), data.NewMicroPoint(10, 0)},
+					Closed: true,
+					Width:  250,
+				}},
+				"perimeterWidth":    [][][]PerimeterWidth{{{{Outline: []data.Micrometer{400, 500}}}}},
+				"perimeterOverhang": [][][]PerimeterOverhang{{{{Holes: [][]int{{0, 60}}}}}},
+				"bridges":           []Bridge{{Part: rectanglePart(0, 0, 10, 10), Angle: 30}},
+				"layerOverride":     data.LayerSettings{"--layer-speed=30"},
+			},
+		},
+		"unknown type": {
+			attributes:    map[string]interface{}{"unknown": 42},
+			expectedError: "the attribute unknown: the type int cannot be serialized",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		layer := newExtendedLayer(data.NewPartitionedLayer([]data.LayerPart{part}))
+		for name, attribute := range testCase.attributes {
+			layer.attributes[name] = attribute
+		}
+
+		var buf bytes.Buffer
+		err := WriteLayers(&buf, []data.PartitionedLayer{layer})
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+			continue
+		}
+		test.Ok(t, err)
+
+		layers, err := ReadLayers(&buf)
+		test.Ok(t, err)
+		test.Equals(t, 1, len(layers))
+		test.Equals(t, layer.Attributes(), layers[0].Attributes(), microPointComparer(), layerPartComparer())
+		test.Equals(t, layer.LayerParts(), layers[0].LayerParts(), microPointComparer(), layerPartComparer())
+	}
+}
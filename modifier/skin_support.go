@@ -0,0 +1,107 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// SkinSupport extracts the attribute "skinSupport" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+// If it exists, the areas which need denser infill are returned.
+func SkinSupport(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "skinSupport")
+}
+
+type skinSupportModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m skinSupportModifier) Init(model data.OptimizedModel) {}
+
+func (m skinSupportModifier) LayerContext() int {
+	return m.options.Print.SkinSupportLayers
+}
+
+// NewSkinSupportModifier creates a modifier which supports the top skins by denser infill.
+// If the distance between the lines of the sparse infill is bigger than the max skin span,
+// the infill of the layers below a top skin is moved to the attribute "skinSupport",
+// so that it can be filled using a denser pattern.
+// It has to run after the internal infill modifier.
+func NewSkinSupportModifier(options *data.Options) handler.LayerModifier {
+	return &skinSupportModifier{
+		Named: handler.Named{
+			Name: "SkinSupport",
+		},
+		options: options,
+	}
+}
+
+func (m skinSupportModifier) Modify(layers []data.PartitionedLayer) error {
+	maxSpan := m.options.Print.MaxSkinSpan.ToMicrometer()
+	if maxSpan <= 0 || m.options.Print.InfillPercent <= 0 {
+		return nil
+	}
+
+	// the distance between the lines of the sparse infill
+	infillSpacing := m.options.Printer.ExtrusionWidth * 100 / data.Micrometer(m.options.Print.InfillPercent)
+	if infillSpacing <= maxSpan {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+
+	for layerNr := range layers {
+		top, err := TopInfill(layers[layerNr])
+		if err != nil {
+			return err
+		}
+		if len(top) == 0 {
+			continue
+		}
+
+		for below := layerNr - 1; below >= 0 && below >= layerNr-m.options.Print.SkinSupportLayers; below-- {
+			infill, err := PartsAttribute(layers[below], "infill")
+			if err != nil {
+				return err
+			}
+			if len(infill) == 0 {
+				continue
+			}
+
+			supporting, ok := c.Intersection(infill, top)
+			if !ok {
+				return fmt.Errorf("could not intersect the infill of layer %d with the top skin above", below)
+			}
+			if len(supporting) == 0 {
+				continue
+			}
+
+			remaining, ok := c.Difference(infill, supporting)
+			if !ok {
+				return fmt.Errorf("could not subtract the skin support from the infill of layer %d", below)
+			}
+
+			existing, err := SkinSupport(layers[below])
+			if err != nil {
+				return err
+			}
+			if len(existing) > 0 {
+				supporting, ok = c.Union(existing, supporting)
+				if !ok {
+					return fmt.Errorf("could not union the skin support of layer %d", below)
+				}
+			}
+
+			newLayer := newExtendedLayer(layers[below])
+			newLayer.attributes["infill"] = remaining
+			newLayer.attributes["skinSupport"] = supporting
+			layers[below] = newLayer
+		}
+	}
+
+	return nil
+}
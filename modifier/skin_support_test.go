@@ -0,0 +1,84 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSkinSupportModifier(t *testing.T) {
+	// skinLayers returns layers filled with infill, except for a top skin in the right half of the last layer.
+	skinLayers := func() []data.PartitionedLayer {
+		testLayers := layers(nil, nil, nil, nil)
+		for layerNr := range testLayers {
+			infill := []data.LayerPart{rectanglePart(0, 0, 20000, 10000)}
+			if layerNr == len(testLayers)-1 {
+				infill = []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+				testLayers[layerNr] = SetAttribute(testLayers[layerNr], "top", []data.LayerPart{rectanglePart(10000, 0, 20000, 10000)})
+			}
+			testLayers[layerNr] = SetAttribute(testLayers[layerNr], "infill", infill)
+		}
+		return testLayers
+	}
+
+	whole := [2]data.Micrometer{0, 20000}
+	left := [2]data.Micrometer{0, 10000}
+	right := [2]data.Micrometer{10000, 20000}
+
+	var testCases = map[string]struct {
+		maxSkinSpan       data.Millimeter
+		skinSupportLayers int
+		// expectedInfill and expectedSkinSupport contain the bounds (min x, max x) of the attributes of each layer
+		expectedInfill      [][2]data.Micrometer
+		expectedSkinSupport [][2]data.Micrometer
+	}{
+		"disabled": {
+			maxSkinSpan:         0,
+			skinSupportLayers:   2,
+			expectedInfill:      [][2]data.Micrometer{whole, whole, whole, left},
+			expectedSkinSupport: [][2]data.Micrometer{noBounds(), noBounds(), noBounds(), noBounds()},
+		},
+		"infill supports the skin": {
+			maxSkinSpan:         5,
+			skinSupportLayers:   2,
+			expectedInfill:      [][2]data.Micrometer{whole, whole, whole, left},
+			expectedSkinSupport: [][2]data.Micrometer{noBounds(), noBounds(), noBounds(), noBounds()},
+		},
+		"infill spans too far": {
+			maxSkinSpan:         2,
+			skinSupportLayers:   2,
+			expectedInfill:      [][2]data.Micrometer{whole, left, left, left},
+			expectedSkinSupport: [][2]data.Micrometer{noBounds(), right, right, noBounds()},
+		},
+		"one support layer": {
+			maxSkinSpan:         2,
+			skinSupportLayers:   1,
+			expectedInfill:      [][2]data.Micrometer{whole, whole, left, left},
+			expectedSkinSupport: [][2]data.Micrometer{noBounds(), noBounds(), right, noBounds()},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		// the lines of the sparse infill are 4mm apart
+		options.Print.InfillPercent = 10
+		options.Printer.ExtrusionWidth = 400
+		options.Print.MaxSkinSpan = testCase.maxSkinSpan
+		options.Print.SkinSupportLayers = testCase.skinSupportLayers
+
+		testLayers := skinLayers()
+		err := NewSkinSupportModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testLayers {
+			infill, err := PartsAttribute(layer, "infill")
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedInfill[layerNr], xBounds(infill))
+
+			skinSupport, err := SkinSupport(layer)
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedSkinSupport[layerNr], xBounds(skinSupport))
+		}
+	}
+}
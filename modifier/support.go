@@ -30,6 +30,12 @@ func FullSupport(layer data.PartitionedLayer) ([]data.LayerPart, error) {
 	return nil, nil
 }
 
+// SupportDistance returns the distance (d) a layer may overhang the layer below without support.
+// It is calculated by d = h * tan θ, see NewSupportDetectorModifier.
+func SupportDistance(layerThickness data.Micrometer, thresholdAngle int) float64 {
+	return float64(layerThickness) * math.Tan(data.ToRadians(float64(thresholdAngle)))
+}
+
 type supportDetectorModifier struct {
 	handler.Named
 	options *data.Options
@@ -37,6 +43,14 @@ type supportDetectorModifier struct {
 
 func (m supportDetectorModifier) Init(_ data.OptimizedModel) {}
 
+// LayerContext returns -1 if enabled as the support is propagated through all layers below an overhang.
+func (m supportDetectorModifier) LayerContext() int {
+	if m.options.Print.Support.Enabled {
+		return -1
+	}
+	return 0
+}
+
 // NewSupportDetectorModifier calculates the areas which need support.
 // It saves them as the attribute "support" as []data.LayerPart.
 // It is meant as a preprocessing modifier.
@@ -62,6 +76,13 @@ func (m supportDetectorModifier) Init(_ data.OptimizedModel) {}
 // "To get the actual areas where the support is later generated,
 //  the previous layer is offset by the calculated d and then subtracted from the current layer.
 //  All areas that remain have a higher angle than the threshold and need to be supported."
+//
+// If a max bridge length is set, the areas which are anchored on both ends and which are not further away
+// from the layer below than half of the max bridge length are bridged instead of supported.
+//
+// Overhangs smaller than the min area are not supported. If a tower diameter is set, overhangs not bigger than
+// the tower max area, e.g. the fingertips of a figurine, are supported by towers of that diameter instead,
+// as thin support columns may break off during the print.
 func NewSupportDetectorModifier(options *data.Options) handler.LayerModifier {
 	return &supportDetectorModifier{
 		Named: handler.Named{
@@ -72,6 +93,9 @@ func NewSupportDetectorModifier(options *data.Options) handler.LayerModifier {
 }
 
 func (m supportDetectorModifier) Modify(layers []data.PartitionedLayer) error {
+	stack := data.NewLayerStack(layers, clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger)))
+	heights := m.options.Print.LayerHeights()
+
 	for layerNr := range layers {
 		if !m.options.Print.Support.Enabled {
 			return nil
@@ -84,28 +108,67 @@ func (m supportDetectorModifier) Modify(layers []data.PartitionedLayer) error {
 			continue
 		}
 
-		// calculate distance (d):
-		distance := float64(m.options.Print.LayerThickness) * math.Tan(data.ToRadians(float64(m.options.Print.Support.ThresholdAngle)))
+		// calculate distance (d) by the thickness of the layer above:
+		distance := SupportDistance(heights.Thickness(layerNr+1), m.options.Print.Support.ThresholdAngle)
 
-		// offset layer by d
-		cl := clip.NewClipper()
-		offsetLayer := cl.InsetLayer(layers[layerNr].LayerParts(), data.Micrometer(-math.Round(distance)), 1, -data.Micrometer(-math.Round(distance))/2).ToOneDimension()
+		// offset layer by d and subtract the result from the next layer
+		offset := data.Micrometer(math.Round(distance)) / 2
+		support, err := stack.Difference(layerNr+1, layerNr, offset)
+		if err != nil {
+			return fmt.Errorf("could not calculate the support parts: %w", err)
+		}
 
-		// subtract result from the next layer
-		support, ok := cl.Difference(layers[layerNr+1].LayerParts(), offsetLayer)
-		if !ok {
-			return errors.New("could not calculate the support parts")
+		cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+
+		// overhangs which can be bridged need no support
+		if maxBridgeLength := m.options.Print.Support.MaxBridgeLength.ToMicrometer(); maxBridgeLength > 0 {
+			var toSupport []data.LayerPart
+			below := stack.Offset(layerNr, offset)
+			// the grown parts overlap each other, so they have to be merged
+			var reach []data.LayerPart
+			for _, part := range stack.Offset(layerNr, offset+maxBridgeLength/2) {
+				var ok bool
+				reach, ok = cl.Union(reach, []data.LayerPart{part})
+				if !ok {
+					return fmt.Errorf("could not merge the reach of the bridges of layer %d", layerNr+1)
+				}
+			}
+			for _, part := range support {
+				isBridge, err := isShortBridge(cl, part, below, reach, m.options.Printer.ExtrusionWidth)
+				if err != nil {
+					return fmt.Errorf("could not detect the bridges of layer %d: %w", layerNr+1, err)
+				}
+				if !isBridge {
+					toSupport = append(toSupport, part)
+				}
+			}
+			support = toSupport
 		}
 
+		// tiny overhangs are not supported or get a tower instead of a thin column which may break off
+		support, towers := supportIslands(
+			support,
+			data.Micrometer(m.options.Print.Support.MinArea*1000*1000),
+			data.Micrometer(m.options.Print.Support.TowerMaxArea*1000*1000),
+			m.options.Print.Support.TowerDiameter.ToMicrometer(),
+		)
+
 		// make the support a little bit bigger to provide at least two lines on most places
 		support = cl.InsetLayer(support, -m.options.Print.Support.PatternSpacing.ToMicrometer()*3, 1, m.options.Print.Support.PatternSpacing.ToMicrometer()*3/2).ToOneDimension()
 
+		if len(towers) > 0 {
+			support, err = addTowers(cl, support, towers)
+			if err != nil {
+				return fmt.Errorf("could not add the support towers of layer %d: %w", layerNr+1, err)
+			}
+		}
+
 		// Save the result at the current layer minus TopGapLayers to skip the amount of TopGapLayers
 		newLayer := newExtendedLayer(layers[layerNr-m.options.Print.Support.TopGapLayers])
 		if len(support) > 0 {
 			newLayer.attributes["support"] = support
 		}
-		layers[layerNr-m.options.Print.Support.TopGapLayers] = newLayer
+		stack.Set(layerNr-m.options.Print.Support.TopGapLayers, newLayer)
 	}
 
 	return nil
@@ -118,10 +181,21 @@ type supportGeneratorModifier struct {
 
 func (m supportGeneratorModifier) Init(_ data.OptimizedModel) {}
 
+// LayerContext returns -1 if enabled as the support is propagated through all layers below an overhang.
+func (m supportGeneratorModifier) LayerContext() int {
+	if m.options.Print.Support.Enabled && m.options.Print.Support.Type == "grid" {
+		return -1
+	}
+	return 0
+}
+
 // NewSupportGeneratorModifier generates the actual areas for the support out of the areas which need support.
 // It grows these areas down till the first layer or till it touches the model.
 // It also generates the interface parts (the most top support layers which are filled differently)
 // and removes them from the normal support areas.
+// If support floor layers are set, the most bottom support layers which rest on the model are saved
+// as the attribute "supportFloor" and also removed from the normal support areas.
+// It only generates the "grid" support type, see NewTreeSupportModifier for the "tree" type.
 func NewSupportGeneratorModifier(options *data.Options) handler.LayerModifier {
 	return &supportGeneratorModifier{
 		Named: handler.Named{
@@ -132,14 +206,14 @@ func NewSupportGeneratorModifier(options *data.Options) handler.LayerModifier {
 }
 
 func (m supportGeneratorModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.Support.Enabled || m.options.Print.Support.Type != "grid" {
+		return nil
+	}
+
 	var lastSupport []data.LayerPart = nil
 
 	// for each layer starting at the 2nd top layer (the top layer won't need support)
-	for layerNr := len(layers) - 2; layerNr >= 0; layerNr-- {
-		if !m.options.Print.Support.Enabled || layerNr == 0 {
-			return nil
-		}
-
+	for layerNr := len(layers) - 2; layerNr > 0; layerNr-- {
 		// use the result from the last round or if it doesn't exist, load the current layer-support
 		currentSupport := lastSupport
 		if currentSupport == nil {
@@ -160,7 +234,7 @@ func (m supportGeneratorModifier) Modify(layers []data.PartitionedLayer) error {
 			continue
 		}
 
-		cl := clip.NewClipper()
+		cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
 
 		// union them
 		result, ok := cl.Union(currentSupport, belowSupport)
@@ -189,7 +263,7 @@ func (m supportGeneratorModifier) Modify(layers []data.PartitionedLayer) error {
 				layerNrAboveInterface = len(layers) - 1
 			}
 
-			c := clip.NewClipper()
+			c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
 
 			supportAboveInterface, err := PartsAttribute(layers[layerNrAboveInterface], "fullSupport")
 			if err != nil {
@@ -207,11 +281,12 @@ func (m supportGeneratorModifier) Modify(layers []data.PartitionedLayer) error {
 			}
 
 			// If there is any brim in this layer, remove it from the support to avoid overlapping.
+			// If the support has precedence, the brim is clipped by the support instead (see NewFirstLayerModifier).
 			brimArea, err := BrimOuterDimension(layers[layerNr-1])
 			if err != nil {
 				return err
 			}
-			if brimArea != nil {
+			if brimArea != nil && m.options.Print.BrimSkirt.OverlapPrecedence != "support" {
 				interfaceParts, ok = c.Difference(interfaceParts, brimArea)
 				actualWithoutInterfaceParts, ok = c.Difference(actualWithoutInterfaceParts, brimArea)
 			}
@@ -238,5 +313,56 @@ func (m supportGeneratorModifier) Modify(layers []data.PartitionedLayer) error {
 		}
 		layers[layerNr-1] = newLayer
 	}
+
+	return m.floors(layers)
+}
+
+// floors moves the support which does not reach down the amount of support floor layers
+// from the attribute "support" to the attribute "supportFloor".
+// Support which reaches the first layer stands on the bed and needs no floor.
+func (m supportGeneratorModifier) floors(layers []data.PartitionedLayer) error {
+	floorLayers := m.options.Print.Support.FloorLayers
+	if floorLayers <= 0 {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	for layerNr := 1; layerNr < len(layers); layerNr++ {
+		support, err := PartsAttribute(layers[layerNr], "support")
+		if err != nil {
+			return err
+		}
+		if len(support) == 0 {
+			continue
+		}
+
+		layerNrBelowFloor := layerNr - floorLayers
+		if layerNrBelowFloor < 0 {
+			layerNrBelowFloor = 0
+		}
+		supportBelowFloor, err := PartsAttribute(layers[layerNrBelowFloor], "fullSupport")
+		if err != nil {
+			return err
+		}
+
+		floor, ok := c.Difference(support, supportBelowFloor)
+		if !ok {
+			return fmt.Errorf("could not calculate the support floor of layer %d", layerNr)
+		}
+		if len(floor) == 0 {
+			continue
+		}
+
+		support, ok = c.Difference(support, floor)
+		if !ok {
+			return fmt.Errorf("could not remove the support floor from the support of layer %d", layerNr)
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["supportFloor"] = floor
+		newLayer.attributes["support"] = support
+		layers[layerNr] = newLayer
+	}
+
 	return nil
 }
@@ -0,0 +1,89 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSupportFloors(t *testing.T) {
+	// supportLayers returns layers with support which widens at layer 1 from the left half to the whole area
+	// and ends at the layers 2 and 3.
+	supportLayers := func() []data.PartitionedLayer {
+		left := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+		whole := []data.LayerPart{rectanglePart(0, 0, 20000, 10000)}
+
+		testLayers := layers(nil, nil, nil, nil)
+		for layerNr, attributes := range []map[string][]data.LayerPart{
+			{"fullSupport": left, "support": left},
+			{"fullSupport": whole, "support": whole},
+			{"fullSupport": whole, "support": whole},
+			{"fullSupport": whole, "support": whole},
+		} {
+			layer := newExtendedLayer(testLayers[layerNr])
+			for name, parts := range attributes {
+				layer.attributes[name] = parts
+			}
+			testLayers[layerNr] = layer
+		}
+		return testLayers
+	}
+
+	var testCases = map[string]struct {
+		floorLayers int
+		// expectedFloors contains the bounds (min x, max x) of the floor of each layer, noBounds if it has no floor
+		expectedFloors [][2]data.Micrometer
+		// expectedSupport contains the bounds (min x, max x) of the support left in each layer
+		expectedSupport [][2]data.Micrometer
+	}{
+		"without floor layers": {
+			floorLayers:     0,
+			expectedFloors:  [][2]data.Micrometer{noBounds(), noBounds(), noBounds(), noBounds()},
+			expectedSupport: [][2]data.Micrometer{{0, 10000}, {0, 20000}, {0, 20000}, {0, 20000}},
+		},
+		"one floor layer": {
+			floorLayers:     1,
+			expectedFloors:  [][2]data.Micrometer{noBounds(), {10000, 20000}, noBounds(), noBounds()},
+			expectedSupport: [][2]data.Micrometer{{0, 10000}, {0, 10000}, {0, 20000}, {0, 20000}},
+		},
+		"two floor layers": {
+			floorLayers:     2,
+			expectedFloors:  [][2]data.Micrometer{noBounds(), {10000, 20000}, {10000, 20000}, noBounds()},
+			expectedSupport: [][2]data.Micrometer{{0, 10000}, {0, 10000}, {0, 10000}, {0, 20000}},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.Support.FloorLayers = testCase.floorLayers
+
+		testLayers := supportLayers()
+		err := supportGeneratorModifier{options: &options}.floors(testLayers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testLayers {
+			floor, err := PartsAttribute(layer, "supportFloor")
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedFloors[layerNr], xBounds(floor))
+
+			support, err := PartsAttribute(layer, "support")
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedSupport[layerNr], xBounds(support))
+		}
+	}
+}
+
+// noBounds returns the bounds of no parts.
+func noBounds() [2]data.Micrometer {
+	return [2]data.Micrometer{-1, -1}
+}
+
+// xBounds returns the min and max x of the parts or noBounds if there are none.
+func xBounds(parts []data.LayerPart) [2]data.Micrometer {
+	if len(parts) == 0 {
+		return noBounds()
+	}
+	min, max := partsBounds(parts)
+	return [2]data.Micrometer{min.X(), max.X()}
+}
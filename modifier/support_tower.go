@@ -0,0 +1,47 @@
+// This file provides the handling of tiny overhangs which are not supported or supported by towers.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// towerSides is the amount of sides of the polygons used for the cross section of the support towers.
+const towerSides = 16
+
+// supportIslands sorts out the overhangs which are too small for the normal support.
+// If the tower diameter is set, a tower around the center of each overhang not bigger than towerMaxArea is returned.
+// These overhangs are kept, all other overhangs smaller than minArea are removed.
+// The areas are given in µm².
+func supportIslands(parts []data.LayerPart, minArea, towerMaxArea, towerDiameter data.Micrometer) (support []data.LayerPart, towers []data.LayerPart) {
+	for _, part := range parts {
+		area := partArea(part)
+		if towerDiameter > 0 && area <= towerMaxArea {
+			min, max := part.Outline().Bounds()
+			center := min.Add(max).Div(2)
+			towers = append(towers, data.NewBasicLayerPart(circlePath(center, towerDiameter/2, towerSides), nil))
+		} else if area < minArea {
+			continue
+		}
+
+		support = append(support, part)
+	}
+
+	return support, towers
+}
+
+// addTowers merges the towers into the support.
+// The parts are merged one by one, as the support parts may overlap each other after they were grown.
+func addTowers(c clip.Clipper, support []data.LayerPart, towers []data.LayerPart) ([]data.LayerPart, error) {
+	var result []data.LayerPart
+	for _, part := range append(support, towers...) {
+		var ok bool
+		result, ok = c.Union(result, []data.LayerPart{part})
+		if !ok {
+			return nil, errors.New("could not merge the support towers")
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,114 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSupportIslands(t *testing.T) {
+	tiny := rectanglePart(20000, 20000, 21000, 21000)
+	big := rectanglePart(0, 0, 5000, 5000)
+
+	var testCases = map[string]struct {
+		parts         []data.LayerPart
+		towerDiameter data.Micrometer
+		// expectedSupport contains the x bounds of the kept overhangs
+		expectedSupport [][2]data.Micrometer
+		// expectedTowers contains the center of each tower
+		expectedTowers []data.MicroPoint
+	}{
+		"tiny overhang without towers is removed": {
+			parts:           []data.LayerPart{tiny, big},
+			expectedSupport: [][2]data.Micrometer{{0, 5000}},
+		},
+		"tiny overhang gets a tower": {
+			parts:           []data.LayerPart{tiny, big},
+			towerDiameter:   4000,
+			expectedSupport: [][2]data.Micrometer{{20000, 21000}, {0, 5000}},
+			expectedTowers:  []data.MicroPoint{data.NewMicroPoint(20500, 20500)},
+		},
+		"big overhang gets no tower": {
+			parts:           []data.LayerPart{big},
+			towerDiameter:   4000,
+			expectedSupport: [][2]data.Micrometer{{0, 5000}},
+		},
+		"no overhangs": {
+			towerDiameter: 4000,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		// the tiny overhang has 1mm², the big one 25mm²
+		support, towers := supportIslands(testCase.parts, 2*1000*1000, 9*1000*1000, testCase.towerDiameter)
+
+		test.Equals(t, len(testCase.expectedSupport), len(support))
+		for i, part := range support {
+			test.Equals(t, testCase.expectedSupport[i], xBounds([]data.LayerPart{part}))
+		}
+
+		test.Equals(t, len(testCase.expectedTowers), len(towers))
+		for i, tower := range towers {
+			test.Equals(t, towerSides, len(tower.Outline()))
+			min, max := tower.Outline().Bounds()
+			test.Equals(t, testCase.expectedTowers[i], min.Add(max).Div(2), microPointComparer())
+			test.Equals(t, testCase.towerDiameter, max.X()-min.X())
+		}
+	}
+}
+
+func TestSupportTowers(t *testing.T) {
+	base := rectanglePart(0, 0, 10000, 10000)
+	// the tiny island has 1mm² and starts at layer 2 without anything below it
+	tiny := rectanglePart(20000, 20000, 21000, 21000)
+	big := rectanglePart(20000, 20000, 25000, 25000)
+
+	var testCases = map[string]struct {
+		layers        []data.PartitionedLayer
+		towerDiameter data.Millimeter
+		// expectedSupport contains the x bounds of the support of each layer, noBounds if it has no support
+		expectedSupport [][2]data.Micrometer
+	}{
+		"no overhangs": {
+			layers:          layers([]data.LayerPart{base}, []data.LayerPart{base}, []data.LayerPart{base}, []data.LayerPart{base}),
+			towerDiameter:   4,
+			expectedSupport: [][2]data.Micrometer{noBounds(), noBounds(), noBounds(), noBounds()},
+		},
+		"tiny overhang without tower": {
+			layers:          layers([]data.LayerPart{base}, []data.LayerPart{base}, []data.LayerPart{base, tiny}, []data.LayerPart{base, tiny}),
+			expectedSupport: [][2]data.Micrometer{noBounds(), noBounds(), noBounds(), noBounds()},
+		},
+		"tower below the tiny overhang": {
+			layers:        layers([]data.LayerPart{base}, []data.LayerPart{base}, []data.LayerPart{base, tiny}, []data.LayerPart{base, tiny}),
+			towerDiameter: 4,
+			// the tower is centered below the overhang and only the layer below the overhang needs support
+			expectedSupport: [][2]data.Micrometer{noBounds(), {18500, 22500}, noBounds(), noBounds()},
+		},
+		"no tower below a big overhang": {
+			layers:        layers([]data.LayerPart{base}, []data.LayerPart{base}, []data.LayerPart{base, big}, []data.LayerPart{base, big}),
+			towerDiameter: 4,
+			// the overhang is grown by 1.5 times the pattern spacing
+			expectedSupport: [][2]data.Micrometer{noBounds(), {19850, 25150}, noBounds(), noBounds()},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.Support.Enabled = true
+		options.Print.Support.TopGapLayers = 0
+		options.Print.Support.PatternSpacing = 0.1
+		options.Print.Support.MinArea = 2
+		options.Print.Support.TowerDiameter = testCase.towerDiameter
+
+		err := NewSupportDetectorModifier(&options).Modify(testCase.layers)
+		test.Ok(t, err)
+
+		for layerNr, layer := range testCase.layers {
+			support, err := PartsAttribute(layer, "support")
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedSupport[layerNr], xBounds(support))
+		}
+	}
+}
@@ -0,0 +1,92 @@
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+type supportedBottomModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m supportedBottomModifier) Init(model data.OptimizedModel) {}
+
+func (m supportedBottomModifier) LayerContext() int {
+	if m.options.Print.Support.Enabled {
+		return m.options.Print.Support.TopGapLayers + 1
+	}
+	return 0
+}
+
+// NewSupportedBottomModifier moves the parts of the "bottom" attribute which rest on support
+// to the attribute "supportedBottom", so that they can be printed with different settings than
+// the bottom skin printed on the bed or on the model.
+// It has to run after the support generation.
+func NewSupportedBottomModifier(options *data.Options) handler.LayerModifier {
+	return &supportedBottomModifier{
+		Named: handler.Named{
+			Name: "SupportedBottom",
+		},
+		options: options,
+	}
+}
+
+func (m supportedBottomModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.Support.Enabled {
+		return nil
+	}
+
+	c := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+
+	for layerNr := range layers {
+		// The support ends TopGapLayers below the layer it supports.
+		supportLayerNr := layerNr - 1 - m.options.Print.Support.TopGapLayers
+		if supportLayerNr < 0 {
+			continue
+		}
+
+		bottom, err := BottomInfill(layers[layerNr])
+		if err != nil {
+			return err
+		}
+		if len(bottom) == 0 {
+			continue
+		}
+
+		support, err := FullSupport(layers[supportLayerNr])
+		if err != nil {
+			return err
+		}
+		if len(support) == 0 {
+			continue
+		}
+
+		// Only the bottom which is directly above the support and not above the model rests on the support.
+		supported, ok := c.Intersection(bottom, support)
+		if !ok {
+			return fmt.Errorf("could not intersect the bottom with the support of layer %d", layerNr)
+		}
+		supported, ok = c.Difference(supported, layers[layerNr-1].LayerParts())
+		if !ok {
+			return fmt.Errorf("could not subtract the model from the supported bottom of layer %d", layerNr)
+		}
+		if len(supported) == 0 {
+			continue
+		}
+
+		remaining, ok := c.Difference(bottom, supported)
+		if !ok {
+			return fmt.Errorf("could not subtract the supported bottom from the bottom of layer %d", layerNr)
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		newLayer.attributes["bottom"] = remaining
+		newLayer.attributes["supportedBottom"] = supported
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
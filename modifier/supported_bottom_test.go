@@ -0,0 +1,74 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestSupportedBottomModifier(t *testing.T) {
+	var testCases = map[string]struct {
+		supportEnabled bool
+		// support is the full support of layer 1 and model the parts of layer 2
+		support []data.LayerPart
+		model   []data.LayerPart
+		// expectedBottom and expectedSupported are the bounds (min x, max x) of the bottom and the supported bottom of layer 3
+		expectedBottom    [2]data.Micrometer
+		expectedSupported [2]data.Micrometer
+	}{
+		"support disabled": {
+			supportEnabled:    false,
+			support:           []data.LayerPart{rectanglePart(10000, 0, 30000, 10000)},
+			expectedBottom:    [2]data.Micrometer{0, 20000},
+			expectedSupported: noBounds(),
+		},
+		"without support": {
+			supportEnabled:    true,
+			expectedBottom:    [2]data.Micrometer{0, 20000},
+			expectedSupported: noBounds(),
+		},
+		"partly on the support": {
+			supportEnabled:    true,
+			support:           []data.LayerPart{rectanglePart(10000, 0, 30000, 10000)},
+			model:             []data.LayerPart{rectanglePart(0, 0, 5000, 10000)},
+			expectedBottom:    [2]data.Micrometer{0, 10000},
+			expectedSupported: [2]data.Micrometer{10000, 20000},
+		},
+		"on the support and the model": {
+			supportEnabled:    true,
+			support:           []data.LayerPart{rectanglePart(10000, 0, 30000, 10000)},
+			model:             []data.LayerPart{rectanglePart(0, 0, 15000, 10000)},
+			expectedBottom:    [2]data.Micrometer{0, 15000},
+			expectedSupported: [2]data.Micrometer{15000, 20000},
+		},
+		"only on the model": {
+			supportEnabled:    true,
+			support:           []data.LayerPart{rectanglePart(10000, 0, 30000, 10000)},
+			model:             []data.LayerPart{rectanglePart(0, 0, 20000, 10000)},
+			expectedBottom:    [2]data.Micrometer{0, 20000},
+			expectedSupported: noBounds(),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.Support.Enabled = testCase.supportEnabled
+		options.Print.Support.TopGapLayers = 1
+
+		testLayers := layers(nil, nil, testCase.model, nil)
+		testLayers[1] = SetAttribute(testLayers[1], "fullSupport", testCase.support)
+		testLayers[3] = SetAttribute(testLayers[3], "bottom", []data.LayerPart{rectanglePart(0, 0, 20000, 10000)})
+
+		err := NewSupportedBottomModifier(&options).Modify(testLayers)
+		test.Ok(t, err)
+
+		bottom, err := BottomInfill(testLayers[3])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedBottom, xBounds(bottom))
+
+		supported, err := SupportedBottomInfill(testLayers[3])
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedSupported, xBounds(supported))
+	}
+}
@@ -0,0 +1,53 @@
+// This file provides the detection of walls which are too thin for the outer perimeter.
+
+package modifier
+
+import (
+	"errors"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+)
+
+// ThinWalls extracts the attribute "thinWalls" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func ThinWalls(layer data.PartitionedLayer) ([]VariableWidthLine, error) {
+	if attr, ok := layer.Attributes()["thinWalls"]; ok {
+		lines, ok := attr.([]VariableWidthLine)
+		if !ok {
+			return nil, errors.New("the attribute thinWalls has the wrong datatype")
+		}
+
+		return lines, nil
+	}
+
+	return nil, nil
+}
+
+// thinWalls returns the lines along the medial axis of the areas of the part which are too thin for the outer perimeter.
+// The perimeters are the center lines of the perimeters of the part ([insetNr][insetParts]) which were
+// generated using the initial offset and the width.
+// The width of the lines follows the width of the walls, but is at least minWidth and at most the extrusion width.
+func thinWalls(c clip.Clipper, part data.LayerPart, perimeters [][]data.LayerPart, width, initialOffset, minWidth data.Micrometer) ([]VariableWidthLine, error) {
+	var outer []data.LayerPart
+	if len(perimeters) > 0 {
+		outer = perimeters[0]
+	}
+
+	walls, err := perimeterGaps(c, part, outer, width, initialOffset, minWidth)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []VariableWidthLine
+	for _, wall := range walls {
+		for _, line := range medialAxis(wall, width) {
+			for i, w := range line.Widths {
+				line.Widths[i] = clampWidth(w, minWidth, width)
+			}
+			lines = append(lines, line)
+		}
+	}
+
+	return lines, nil
+}
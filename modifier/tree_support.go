@@ -0,0 +1,330 @@
+// This file provides a modifier which generates tree support as alternative to the grid support.
+// It is meant to run after the supportDetectorModifier instead of the supportGeneratorModifier.
+
+package modifier
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/clip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"math"
+)
+
+// branchSides is the amount of sides of the polygons used for the cross section of the branches.
+const branchSides = 12
+
+// TreeSupport extracts the attribute "treeSupport" from the layer.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func TreeSupport(layer data.PartitionedLayer) ([]data.LayerPart, error) {
+	return PartsAttribute(layer, "treeSupport")
+}
+
+// treeNode is the center of a branch on one layer.
+type treeNode struct {
+	position data.MicroPoint
+	radius   data.Micrometer
+}
+
+type treeSupportModifier struct {
+	handler.Named
+	options *data.Options
+}
+
+func (m treeSupportModifier) Init(_ data.OptimizedModel) {}
+
+// LayerContext returns -1 if enabled as the branches grow from the overhangs through all layers below them.
+func (m treeSupportModifier) LayerContext() int {
+	if m.options.Print.Support.Enabled && m.options.Print.Support.Type == "tree" {
+		return -1
+	}
+	return 0
+}
+
+// NewTreeSupportModifier generates tree support out of the areas which need support.
+// It replaces the NewSupportGeneratorModifier if the support type is "tree".
+//
+// Tips are placed in a grid with the branch diameter as spacing on the overhangs found by the NewSupportDetectorModifier.
+// From there the branches grow down layer by layer:
+//   - The radius of a branch grows from the tip diameter to the branch diameter.
+//   - Each branch moves towards its nearest neighbour by up to d = h * tan θ with θ as the branch angle.
+//     Branches which are near enough to meet are merged into one.
+//   - Branches are pushed away from the model by up to d. If a branch reaches the model anyway, it ends there.
+//
+// The cross sections of the branches are set as the attributes "treeSupport" and "fullSupport" to the layers.
+// The attribute "support" is cleared, as the branches are printed as walls by their own renderer.
+func NewTreeSupportModifier(options *data.Options) handler.LayerModifier {
+	return &treeSupportModifier{
+		Named: handler.Named{
+			Name: "TreeSupport",
+		},
+		options: options,
+	}
+}
+
+func (m treeSupportModifier) Modify(layers []data.PartitionedLayer) error {
+	if !m.options.Print.Support.Enabled || m.options.Print.Support.Type != "tree" {
+		return nil
+	}
+
+	tree := m.options.Print.Support.Tree
+	heights := m.options.Print.LayerHeights()
+	tan := math.Tan(data.ToRadians(float64(tree.BranchAngle)))
+	tipRadius := tree.TipDiameter.ToMicrometer() / 2
+	branchRadius := tree.BranchDiameter.ToMicrometer() / 2
+	gap := m.options.Print.Support.Gap.ToMicrometer()
+
+	cl := clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger))
+	var nodes []treeNode
+	for layerNr := len(layers) - 1; layerNr >= 0; layerNr-- {
+		// make the layer a bit bigger to create a gap between the support and the model
+		avoid := cl.InsetLayer(layers[layerNr].LayerParts(), -gap, 1, gap/2).ToOneDimension()
+
+		if len(nodes) > 0 {
+			maxMove := data.Micrometer(math.Round(float64(heights.Thickness(layerNr+1)) * tan))
+			nodes = mergeBranches(nodes, maxMove)
+			nodes = avoidModel(nodes, avoid, maxMove)
+			for i := range nodes {
+				nodes[i].radius = data.Min(nodes[i].radius+maxMove/2, branchRadius)
+			}
+		}
+
+		tips, err := m.tips(layers, layerNr, nodes, avoid, tipRadius)
+		if err != nil {
+			return err
+		}
+		nodes = append(nodes, tips...)
+		if len(nodes) == 0 {
+			continue
+		}
+
+		var branches []data.LayerPart
+		for _, node := range nodes {
+			var ok bool
+			// union the branches one by one as overlapping branches would cancel each other out
+			branches, ok = cl.Union(branches, []data.LayerPart{data.NewBasicLayerPart(branchCircle(node), nil)})
+			if !ok {
+				return fmt.Errorf("could not union the branches of the tree support of layer %d", layerNr)
+			}
+		}
+
+		branches, ok := cl.Difference(branches, avoid)
+		if !ok {
+			return fmt.Errorf("could not subtract the model from the tree support of layer %d", layerNr)
+		}
+
+		// If there is any brim in this layer, remove it from the support to avoid overlapping.
+		// If the support has precedence, the brim is clipped by the support instead (see NewFirstLayerModifier).
+		brimArea, err := BrimOuterDimension(layers[layerNr])
+		if err != nil {
+			return err
+		}
+		if brimArea != nil && m.options.Print.BrimSkirt.OverlapPrecedence != "support" {
+			branches, ok = cl.Difference(branches, brimArea)
+			if !ok {
+				return fmt.Errorf("could not subtract the brim from the tree support of layer %d", layerNr)
+			}
+		}
+
+		newLayer := newExtendedLayer(layers[layerNr])
+		if len(branches) > 0 {
+			newLayer.attributes["treeSupport"] = branches
+			newLayer.attributes["fullSupport"] = branches
+		}
+		// remove the support from the detection modifier as it is only used for the tips
+		newLayer.attributes["support"] = []data.LayerPart{}
+		layers[layerNr] = newLayer
+	}
+
+	return nil
+}
+
+// tips returns new tips for the overhangs of the given layer which are not yet supported by the existing branches.
+// The overhangs are limited to the model which has to be supported, as the detector makes them bigger.
+func (m treeSupportModifier) tips(layers []data.PartitionedLayer, layerNr int, nodes []treeNode, avoid []data.LayerPart, tipRadius data.Micrometer) ([]treeNode, error) {
+	overhangs, err := PartsAttribute(layers[layerNr], "support")
+	if err != nil || len(overhangs) == 0 {
+		return nil, err
+	}
+
+	supportedLayerNr := layerNr + m.options.Print.Support.TopGapLayers + 1
+	if supportedLayerNr < len(layers) {
+		var ok bool
+		overhangs, ok = clip.NewClipper(clip.WithLogger(m.options.GoSlice.Logger)).Intersection(overhangs, layers[supportedLayerNr].LayerParts())
+		if !ok {
+			return nil, fmt.Errorf("could not calculate the overhangs of layer %d for the tree support", layerNr)
+		}
+	}
+
+	spacing := m.options.Print.Support.Tree.BranchDiameter.ToMicrometer()
+	var tips []treeNode
+	for _, overhang := range overhangs {
+		min, max := overhang.Outline().Bounds()
+		// align the grid to the origin so that the tips of several layers line up
+		for x := (min.X()/spacing - 1) * spacing; x <= max.X(); x += spacing {
+			for y := (min.Y()/spacing - 1) * spacing; y <= max.Y(); y += spacing {
+				p := data.NewMicroPoint(x, y)
+				if !data.PartContains(overhang, p) || insideParts(avoid, p) || isNearNode(nodes, p, spacing) || isNearNode(tips, p, spacing) {
+					continue
+				}
+				tips = append(tips, treeNode{position: p, radius: tipRadius})
+			}
+		}
+	}
+
+	return tips, nil
+}
+
+// mergeBranches moves each branch by up to maxMove towards its nearest neighbour.
+// Branches which can meet are merged into one branch in the middle between them.
+func mergeBranches(nodes []treeNode, maxMove data.Micrometer) []treeNode {
+	merged := make([]bool, len(nodes))
+	var result []treeNode
+	for i, node := range nodes {
+		if merged[i] {
+			continue
+		}
+
+		nearest := -1
+		var nearestDistance data.Micrometer
+		for j, other := range nodes {
+			if i == j || merged[j] {
+				continue
+			}
+			distance := other.position.Sub(node.position).Size()
+			if nearest == -1 || distance < nearestDistance {
+				nearest, nearestDistance = j, distance
+			}
+		}
+		if nearest == -1 {
+			result = append(result, node)
+			continue
+		}
+
+		other := nodes[nearest]
+		// branches before this one are already in the result, so only the following ones can be merged
+		if nearestDistance <= 2*maxMove && nearest > i {
+			merged[nearest] = true
+			result = append(result, treeNode{
+				position: node.position.Add(other.position).Div(2),
+				radius:   data.Max(node.radius, other.radius),
+			})
+			continue
+		}
+
+		// never move further than to the middle so that the branches do not cross
+		move := data.Min(maxMove, nearestDistance/2)
+		direction := other.position.Sub(node.position)
+		node.position = node.position.Add(direction.Mul(move).Div(nearestDistance))
+		result = append(result, node)
+	}
+
+	return result
+}
+
+// avoidModel pushes the branches out of the given areas by up to maxMove.
+// Branches which still end inside of the areas rest on the model and are removed.
+func avoidModel(nodes []treeNode, avoid []data.LayerPart, maxMove data.Micrometer) []treeNode {
+	var result []treeNode
+	for _, node := range nodes {
+		nearest, distance, ok := nearestEdgePoint(avoid, node.position)
+		if !ok {
+			result = append(result, node)
+			continue
+		}
+
+		inside := insideParts(avoid, node.position)
+		if !inside && distance >= node.radius {
+			result = append(result, node)
+			continue
+		}
+
+		// move away from the nearest edge or through it if the branch is inside of the model
+		var direction data.MicroPoint
+		var move data.Micrometer
+		if inside {
+			direction = nearest.Sub(node.position)
+			move = distance + node.radius
+		} else {
+			direction = node.position.Sub(nearest)
+			move = node.radius - distance
+		}
+		if move > maxMove {
+			if inside {
+				continue
+			}
+			move = maxMove
+		}
+		if distance > 0 {
+			node.position = node.position.Add(direction.Mul(move).Div(distance))
+		}
+		result = append(result, node)
+	}
+
+	return result
+}
+
+// branchCircle returns the cross section of the branch.
+func branchCircle(node treeNode) data.Path {
+	return circlePath(node.position, node.radius, branchSides)
+}
+
+// circlePath returns a polygon with the given amount of sides which approximates the circle.
+func circlePath(center data.MicroPoint, radius data.Micrometer, sides int) data.Path {
+	result := make(data.Path, sides)
+	for i := range result {
+		angle := 2 * math.Pi * float64(i) / float64(sides)
+		result[i] = data.NewMicroPoint(
+			center.X()+data.Micrometer(math.Round(float64(radius)*math.Cos(angle))),
+			center.Y()+data.Micrometer(math.Round(float64(radius)*math.Sin(angle))),
+		)
+	}
+	return result
+}
+
+// isNearNode returns true if any of the nodes is nearer to the point than the given distance.
+func isNearNode(nodes []treeNode, p data.MicroPoint, distance data.Micrometer) bool {
+	for _, node := range nodes {
+		if node.position.Sub(p).Size() < distance {
+			return true
+		}
+	}
+	return false
+}
+
+// insideParts returns true if the point lies inside of any of the parts.
+func insideParts(parts []data.LayerPart, p data.MicroPoint) bool {
+	for _, part := range parts {
+		if data.PartContains(part, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// nearestEdgePoint returns the point on the outlines and holes of the parts which is nearest to the given point
+// and its distance. If there are no parts, ok is false.
+func nearestEdgePoint(parts []data.LayerPart, p data.MicroPoint) (nearest data.MicroPoint, distance data.Micrometer, ok bool) {
+	best := math.Inf(1)
+	for _, part := range parts {
+		for _, path := range append(data.Paths{part.Outline()}, part.Holes()...) {
+			if len(path) == 0 {
+				continue
+			}
+
+			previous := path[len(path)-1]
+			for _, point := range path {
+				candidate := data.ProjectOnSegment(p, previous, point)
+				if d := float64(candidate.Sub(p).Size2()); d < best {
+					best, nearest = d, candidate
+				}
+				previous = point
+			}
+		}
+	}
+	if math.IsInf(best, 1) {
+		return nil, 0, false
+	}
+	return nearest, data.Micrometer(math.Round(math.Sqrt(best))), true
+}
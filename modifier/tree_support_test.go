@@ -0,0 +1,145 @@
+package modifier
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// treeNodeComparer returns a cmp.Comparer which can handle treeNode.
+func treeNodeComparer() cmp.Option {
+	return cmp.Comparer(func(n1, n2 treeNode) bool {
+		return n1.position.X() == n2.position.X() && n1.position.Y() == n2.position.Y() && n1.radius == n2.radius
+	})
+}
+
+func node(x, y, radius data.Micrometer) treeNode {
+	return treeNode{position: data.NewMicroPoint(x, y), radius: radius}
+}
+
+func TestMergeBranches(t *testing.T) {
+	var testCases = map[string]struct {
+		nodes    []treeNode
+		maxMove  data.Micrometer
+		expected []treeNode
+	}{
+		"single branch is not moved": {
+			nodes:    []treeNode{node(0, 0, 500)},
+			maxMove:  200,
+			expected: []treeNode{node(0, 0, 500)},
+		},
+		"near branches are merged in the middle": {
+			nodes:    []treeNode{node(0, 0, 500), node(400, 0, 800)},
+			maxMove:  200,
+			expected: []treeNode{node(200, 0, 800)},
+		},
+		"far branches move towards each other": {
+			nodes:    []treeNode{node(0, 0, 500), node(1000, 0, 500)},
+			maxMove:  200,
+			expected: []treeNode{node(200, 0, 500), node(800, 0, 500)},
+		},
+		"branches do not move further than to the middle": {
+			// the first branch is merged with the second one, so the third one can only move towards it
+			nodes:    []treeNode{node(0, 0, 500), node(0, 200, 500), node(300, 0, 500)},
+			maxMove:  200,
+			expected: []treeNode{node(0, 100, 500), node(150, 0, 500)},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, mergeBranches(testCase.nodes, testCase.maxMove), treeNodeComparer())
+	}
+}
+
+func TestAvoidModel(t *testing.T) {
+	model := []data.LayerPart{rectanglePart(0, 0, 10000, 10000)}
+
+	var testCases = map[string]struct {
+		nodes    []treeNode
+		avoid    []data.LayerPart
+		maxMove  data.Micrometer
+		expected []treeNode
+	}{
+		"nothing to avoid": {
+			nodes:    []treeNode{node(5000, 5000, 500)},
+			maxMove:  200,
+			expected: []treeNode{node(5000, 5000, 500)},
+		},
+		"far branch is not moved": {
+			nodes:    []treeNode{node(-1000, 5000, 500)},
+			avoid:    model,
+			maxMove:  200,
+			expected: []treeNode{node(-1000, 5000, 500)},
+		},
+		"touching branch is pushed away": {
+			nodes:    []treeNode{node(-400, 5000, 500)},
+			avoid:    model,
+			maxMove:  200,
+			expected: []treeNode{node(-500, 5000, 500)},
+		},
+		"pushing is limited": {
+			nodes:    []treeNode{node(-100, 5000, 500)},
+			avoid:    model,
+			maxMove:  200,
+			expected: []treeNode{node(-300, 5000, 500)},
+		},
+		"branch just inside moves through the edge": {
+			nodes:    []treeNode{node(100, 5000, 50)},
+			avoid:    model,
+			maxMove:  200,
+			expected: []treeNode{node(-50, 5000, 50)},
+		},
+		"branch deep inside rests on the model": {
+			nodes:   []treeNode{node(5000, 5000, 500)},
+			avoid:   model,
+			maxMove: 200,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, avoidModel(testCase.nodes, testCase.avoid, testCase.maxMove), treeNodeComparer())
+	}
+}
+
+func TestNearestEdgePoint(t *testing.T) {
+	var testCases = map[string]struct {
+		parts            []data.LayerPart
+		point            data.MicroPoint
+		expectedNearest  data.MicroPoint
+		expectedDistance data.Micrometer
+		expectedOk       bool
+	}{
+		"outline": {
+			parts:            []data.LayerPart{rectanglePart(0, 0, 1000, 1000)},
+			point:            data.NewMicroPoint(1300, 500),
+			expectedNearest:  data.NewMicroPoint(1000, 500),
+			expectedDistance: 300,
+			expectedOk:       true,
+		},
+		"hole": {
+			parts:            []data.LayerPart{data.NewBasicLayerPart(rectangle(0, 0, 1000, 1000), data.Paths{rectangle(400, 400, 600, 600)})},
+			point:            data.NewMicroPoint(500, 450),
+			expectedNearest:  data.NewMicroPoint(500, 400),
+			expectedDistance: 50,
+			expectedOk:       true,
+		},
+		"no parts": {
+			point: data.NewMicroPoint(0, 0),
+		},
+		"empty outline": {
+			parts: []data.LayerPart{data.NewBasicLayerPart(data.Path{}, nil)},
+			point: data.NewMicroPoint(0, 0),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		nearest, distance, ok := nearestEdgePoint(testCase.parts, testCase.point)
+		test.Equals(t, testCase.expectedOk, ok)
+		test.Equals(t, testCase.expectedNearest, nearest, microPointComparer())
+		test.Equals(t, testCase.expectedDistance, distance)
+	}
+}
@@ -0,0 +1,176 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"sort"
+)
+
+// edgeKey identifies an edge by the indices of its points, the smaller index first.
+type edgeKey [2]int
+
+func newEdgeKey(a, b int) edgeKey {
+	if a > b {
+		a, b = b, a
+	}
+	return edgeKey{a, b}
+}
+
+// adjacencyReport contains the number of mesh errors which are bridged by indirect touching faces.
+type adjacencyReport struct {
+	// TVertices is the number of points which lie on the edge of another face.
+	TVertices int
+	// SplitEdges is the number of open edges which are connected to faces at T-vertices.
+	SplitEdges int
+	// NonManifoldEdges is the number of edges which are used by more than two faces.
+	NonManifoldEdges int
+}
+
+// connectIndirectTouchingFaces finds the touching faces which do not share exactly one edge with a face.
+// These are needed to follow the outline of a layer across common errors of meshes, e.g. of boolean operations:
+//  1. Edges used by more than two faces:
+//     All faces of such an edge touch each other, not only the first one found.
+//  2. T-vertices:
+//     If a point lies on the open edge of another face (within the tolerance), the edge is split on the other side
+//     into several shorter edges. The faces of these shorter open edges touch the face with the long edge.
+func (o *optimizedModel) connectIndirectTouchingFaces(tolerance data.Micrometer) adjacencyReport {
+	var report adjacencyReport
+
+	edges := map[edgeKey][]int{}
+	for i, face := range o.faces {
+		for j := 0; j < 3; j++ {
+			key := newEdgeKey(face.indices[j], face.indices[(j+1)%3])
+			edges[key] = append(edges[key], i)
+		}
+	}
+
+	// the edges are sorted to get the same indirect touching faces on each run
+	keys := make([]edgeKey, 0, len(edges))
+	for key := range edges {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		return keys[i][0] < keys[j][0] || (keys[i][0] == keys[j][0] && keys[i][1] < keys[j][1])
+	})
+
+	var openEdges []edgeKey
+	openPoints := map[int]bool{}
+	for _, key := range keys {
+		faces := edges[key]
+		if len(faces) > 2 {
+			report.NonManifoldEdges++
+			for _, a := range faces {
+				for _, b := range faces {
+					o.addIndirectTouchingFace(a, b)
+				}
+			}
+		}
+
+		if len(faces) == 1 {
+			openEdges = append(openEdges, key)
+			openPoints[key[0]] = true
+			openPoints[key[1]] = true
+		}
+	}
+
+	// the points of open edges sorted by x to find the points near an edge quickly
+	sortedPoints := make([]int, 0, len(openPoints))
+	for p := range openPoints {
+		sortedPoints = append(sortedPoints, p)
+	}
+	sort.Slice(sortedPoints, func(i, j int) bool {
+		x0, x1 := o.points[sortedPoints[i]].pos.X(), o.points[sortedPoints[j]].pos.X()
+		return x0 < x1 || (x0 == x1 && sortedPoints[i] < sortedPoints[j])
+	})
+
+	tVertices := map[int]bool{}
+	splitEdges := map[edgeKey]bool{}
+	for _, edge := range openEdges {
+		a, b := o.points[edge[0]].pos, o.points[edge[1]].pos
+		minX, maxX := a.X(), b.X()
+		if minX > maxX {
+			minX, maxX = maxX, minX
+		}
+
+		onEdge := []int{edge[0], edge[1]}
+		start := sort.Search(len(sortedPoints), func(i int) bool {
+			return o.points[sortedPoints[i]].pos.X() >= minX-tolerance
+		})
+		for i := start; i < len(sortedPoints) && o.points[sortedPoints[i]].pos.X() <= maxX+tolerance; i++ {
+			p := sortedPoints[i]
+			if p != edge[0] && p != edge[1] && isOnEdge(o.points[p].pos, a, b, tolerance) {
+				onEdge = append(onEdge, p)
+			}
+		}
+		if len(onEdge) == 2 {
+			continue
+		}
+
+		// connect the face of the edge with the faces of all open edges between the points on it
+		face := edges[edge][0]
+		for i, p0 := range onEdge {
+			if i >= 2 {
+				tVertices[p0] = true
+			}
+			for _, p1 := range onEdge[i+1:] {
+				key := newEdgeKey(p0, p1)
+				if key == edge || len(edges[key]) != 1 {
+					continue
+				}
+
+				other := edges[key][0]
+				o.addIndirectTouchingFace(face, other)
+				o.addIndirectTouchingFace(other, face)
+				splitEdges[edge] = true
+				splitEdges[key] = true
+			}
+		}
+	}
+
+	report.TVertices = len(tVertices)
+	report.SplitEdges = len(splitEdges)
+	return report
+}
+
+// addIndirectTouchingFace adds the face other to the indirect touching faces of the face
+// if it is not the face itself and not already a touching face.
+func (o *optimizedModel) addIndirectTouchingFace(face, other int) {
+	if face == other {
+		return
+	}
+
+	f := &o.faces[face]
+	for _, touching := range f.touching {
+		if touching == other {
+			return
+		}
+	}
+	for _, touching := range f.indirect {
+		if touching == other {
+			return
+		}
+	}
+	f.indirect = append(f.indirect, other)
+}
+
+// isOnEdge returns true if p lies within the tolerance on the edge from a to b but not at one of its ends.
+func isOnEdge(p, a, b data.MicroVec3, tolerance data.Micrometer) bool {
+	if p.Sub(a).ShorterThanOrEqual(tolerance) || p.Sub(b).ShorterThanOrEqual(tolerance) {
+		return false
+	}
+
+	ab := b.Sub(a)
+	ap := p.Sub(a)
+	length2 := float64(ab.Size2())
+	if length2 == 0 {
+		return false
+	}
+
+	dot := float64(ap.X())*float64(ab.X()) + float64(ap.Y())*float64(ab.Y()) + float64(ap.Z())*float64(ab.Z())
+	if dot <= 0 || dot >= length2 {
+		return false
+	}
+
+	// the squared distance of p to the line through a and b
+	distance2 := float64(ap.Size2()) - dot*dot/length2
+	return distance2 <= float64(tolerance)*float64(tolerance)
+}
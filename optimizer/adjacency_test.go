@@ -0,0 +1,71 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestIndirectTouchingFaces(t *testing.T) {
+	a := data.NewMicroVec3(0, 0, 0)
+	b := data.NewMicroVec3(2000, 0, 0)
+	c := data.NewMicroVec3(1000, 1000, 0)
+	d := data.NewMicroVec3(1000, -1000, 0)
+
+	var testCases = map[string]struct {
+		faces          []data.Face
+		expected       [][]int
+		expectedReport data.ModelReport
+	}{
+		"t-vertex": {
+			faces: []data.Face{
+				testFace{a, b, c},
+				// the edge a-b is split at the T-vertex on this side
+				testFace{data.NewMicroVec3(1000, 10, 0), a, d},
+				testFace{b, data.NewMicroVec3(1000, 10, 0), d},
+			},
+			expected:       [][]int{{1, 2}, {0}, {0}},
+			expectedReport: data.ModelReport{OpenEdges: 4, TVertices: 1, SplitEdges: 3},
+		},
+		"edge used by three faces": {
+			faces: []data.Face{
+				testFace{a, b, c},
+				testFace{b, a, d},
+				testFace{b, a, data.NewMicroVec3(1000, 0, 1000)},
+			},
+			expected:       [][]int{{2}, {2}, {1}},
+			expectedReport: data.ModelReport{OpenEdges: 6, NonManifoldEdges: 1},
+		},
+		"closed edges": {
+			faces: []data.Face{
+				testFace{a, b, c},
+				testFace{b, a, d},
+			},
+			expected:       [][]int{nil, nil},
+			expectedReport: data.ModelReport{OpenEdges: 4},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		m, err := NewOptimizer(&options).Optimize(testModel(testCase.faces))
+		test.Ok(t, err)
+
+		for i, expected := range testCase.expected {
+			test.Equals(t, expected, m.OptimizedFace(i).IndirectTouchingFaceIndices())
+		}
+		test.Equals(t, testCase.expectedReport, m.(data.ReportedModel).Report())
+	}
+}
+
+func TestIsOnEdge(t *testing.T) {
+	a := data.NewMicroVec3(0, 0, 0)
+	b := data.NewMicroVec3(2000, 0, 0)
+
+	test.Assert(t, isOnEdge(data.NewMicroVec3(1000, 0, 0), a, b, 30), "the point in the middle should be on the edge")
+	test.Assert(t, isOnEdge(data.NewMicroVec3(1000, 20, 0), a, b, 30), "the point within the tolerance should be on the edge")
+	test.Assert(t, !isOnEdge(data.NewMicroVec3(1000, 40, 0), a, b, 30), "the point outside of the tolerance should not be on the edge")
+	test.Assert(t, !isOnEdge(data.NewMicroVec3(10, 0, 0), a, b, 30), "the point near the end should not be on the edge")
+	test.Assert(t, !isOnEdge(data.NewMicroVec3(3000, 0, 0), a, b, 30), "the point behind the end should not be on the edge")
+}
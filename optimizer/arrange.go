@@ -0,0 +1,117 @@
+// This file provides the placement of several models on the build plate.
+
+package optimizer
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"sort"
+)
+
+// arrange packs the models of the group on the bed so that there is at least the given spacing between them.
+// The models are sorted by their depth and placed in rows along the X axis.
+// A new row is started if the next model does not fit into the bed width anymore.
+// A bed size of 0 means that the size in this direction is unknown and not limited.
+// If the models do not fit on the bed, an error is returned.
+// The returned model contains the faces of all models at their new position.
+func arrange(group data.ModelGroup, spacing, bedWidth, bedDepth data.Micrometer) (data.Model, error) {
+	var indices []int
+	for i, m := range group.Models() {
+		if m.FaceCount() > 0 {
+			indices = append(indices, i)
+		}
+	}
+
+	models := group.Models()
+	size := func(i int) data.MicroVec3 {
+		return models[i].Max().Sub(models[i].Min())
+	}
+
+	// place the deepest models first so that the rows are filled evenly
+	sort.SliceStable(indices, func(a, b int) bool {
+		return size(indices[a]).Y() > size(indices[b]).Y()
+	})
+
+	var arranged []data.Model
+	var x, y, rowDepth, width data.Micrometer
+	for _, i := range indices {
+		modelSize := size(i)
+		if bedWidth > 0 && modelSize.X() > bedWidth || bedDepth > 0 && modelSize.Y() > bedDepth {
+			return nil, fmt.Errorf("model %v with the size %vmm x %vmm does not fit on the bed of %vmm x %vmm", i+1, modelSize.X().ToMillimeter(), modelSize.Y().ToMillimeter(), bedWidth.ToMillimeter(), bedDepth.ToMillimeter())
+		}
+
+		// start a new row if the model does not fit into the current one
+		if x > 0 && bedWidth > 0 && x+modelSize.X() > bedWidth {
+			y += rowDepth + spacing
+			x = 0
+			rowDepth = 0
+		}
+
+		min := models[i].Min()
+		offset := data.NewMicroVec3(x-min.X(), y-min.Y(), -min.Z())
+		arranged = append(arranged, translatedModel{model: models[i], offset: offset})
+
+		x += modelSize.X() + spacing
+		width = data.Max(width, x-spacing)
+		rowDepth = data.Max(rowDepth, modelSize.Y())
+	}
+
+	if bedDepth > 0 && y+rowDepth > bedDepth {
+		return nil, fmt.Errorf("the models need %vmm x %vmm with a spacing of %vmm but the bed has only %vmm x %vmm", width.ToMillimeter(), (y + rowDepth).ToMillimeter(), spacing.ToMillimeter(), bedWidth.ToMillimeter(), bedDepth.ToMillimeter())
+	}
+
+	return data.NewModelGroup(arranged...), nil
+}
+
+// place moves each model of the group so that the center of its bounds lies at its translation
+// and its lowest point at z = 0. Empty models are skipped.
+func place(group data.ModelGroup, translations []data.MicroPoint) data.Model {
+	var placed []data.Model
+	for i, m := range group.Models() {
+		if m.FaceCount() == 0 {
+			continue
+		}
+
+		min, max := m.Min(), m.Max()
+		offset := data.NewMicroVec3(translations[i].X()-(min.X()+max.X())/2, translations[i].Y()-(min.Y()+max.Y())/2, -min.Z())
+		placed = append(placed, translatedModel{model: m, offset: offset})
+	}
+
+	return data.NewModelGroup(placed...)
+}
+
+// translatedModel moves all faces of a model by the offset.
+type translatedModel struct {
+	model  data.Model
+	offset data.MicroVec3
+}
+
+func (t translatedModel) FaceCount() int {
+	return t.model.FaceCount()
+}
+
+func (t translatedModel) Face(index int) data.Face {
+	return translatedFace{face: t.model.Face(index), offset: t.offset}
+}
+
+func (t translatedModel) Min() data.MicroVec3 {
+	return t.model.Min().Add(t.offset)
+}
+
+func (t translatedModel) Max() data.MicroVec3 {
+	return t.model.Max().Add(t.offset)
+}
+
+// translatedFace moves the points of a face by the offset.
+type translatedFace struct {
+	face   data.Face
+	offset data.MicroVec3
+}
+
+func (t translatedFace) Points() [3]data.MicroVec3 {
+	points := t.face.Points()
+	for i, p := range points {
+		points[i] = p.Add(t.offset)
+	}
+	return points
+}
@@ -0,0 +1,99 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// boxModel is a model which only provides its bounds.
+type boxModel struct {
+	min, max data.MicroVec3
+}
+
+func (b boxModel) FaceCount() int {
+	return 1
+}
+
+func (b boxModel) Face(index int) data.Face {
+	return testFace{b.min, b.max, b.max}
+}
+
+func (b boxModel) Min() data.MicroVec3 {
+	return b.min.Copy()
+}
+
+func (b boxModel) Max() data.MicroVec3 {
+	return b.max.Copy()
+}
+
+func box(x, y data.Micrometer) data.Model {
+	return boxModel{min: data.NewMicroVec3(-x, 100, 5), max: data.NewMicroVec3(0, 100+y, 10)}
+}
+
+func TestArrange(t *testing.T) {
+	var testCases = map[string]struct {
+		models        []data.Model
+		bedWidth      data.Micrometer
+		bedDepth      data.Micrometer
+		expectedMin   []data.MicroVec3
+		expectedError bool
+	}{
+		"one row": {
+			models:   []data.Model{box(50, 20), box(30, 40)},
+			bedWidth: 200,
+			bedDepth: 200,
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(0, 0, 0),
+				data.NewMicroVec3(40, 0, 0),
+			},
+		},
+		"new row if the bed is too small": {
+			models:   []data.Model{box(50, 20), box(30, 40), box(60, 10)},
+			bedWidth: 100,
+			bedDepth: 100,
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(0, 0, 0),
+				data.NewMicroVec3(40, 0, 0),
+				data.NewMicroVec3(0, 50, 0),
+			},
+		},
+		"unknown bed size": {
+			models: []data.Model{box(50, 20), box(30, 40), box(60, 10)},
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(0, 0, 0),
+				data.NewMicroVec3(40, 0, 0),
+				data.NewMicroVec3(100, 0, 0),
+			},
+		},
+		"model too big": {
+			models:        []data.Model{box(250, 20)},
+			bedWidth:      200,
+			bedDepth:      200,
+			expectedError: true,
+		},
+		"models do not fit": {
+			models:        []data.Model{box(150, 80), box(150, 80), box(150, 80)},
+			bedWidth:      200,
+			bedDepth:      200,
+			expectedError: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		result, err := arrange(data.NewModelGroup(testCase.models...), 10, testCase.bedWidth, testCase.bedDepth)
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "error expected")
+			continue
+		}
+		test.Ok(t, err)
+
+		// the deepest model is placed first
+		arranged := result.(data.ModelGroup).Models()
+		test.Equals(t, len(testCase.expectedMin), len(arranged))
+		for i, expected := range testCase.expectedMin {
+			test.Assert(t, arranged[i].Min().Sub(expected).ShorterThanOrEqual(0), "model %v should start at %v but starts at %v", i, expected, arranged[i].Min())
+		}
+	}
+}
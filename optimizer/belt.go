@@ -0,0 +1,33 @@
+// This file provides the transformations needed for belt printers.
+
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// beltShear transforms a point of the model into the space of a belt printer.
+// In this space the layers, which are tilted by the gantry angle, are planar:
+// X stays the same, Y is the position along the gantry and Z is
+// the distance to the first layer (perpendicular to the layers).
+func beltShear(p data.MicroVec3, angle int) data.MicroVec3 {
+	sin, cos := math.Sincos(data.ToRadians(float64(angle)))
+
+	return data.NewMicroVec3(
+		p.X(),
+		data.Micrometer(float64(p.Z())/sin),
+		data.Micrometer(float64(p.Z())*cos-float64(p.Y())*sin),
+	)
+}
+
+// BeltMachinePosition returns a transformation from the sliced belt printer space
+// into the GCode coordinates of a belt printer with the given gantry angle.
+// The layer distance is converted into the distance the belt has to move.
+func BeltMachinePosition(angle int) func(p data.MicroVec3) data.MicroVec3 {
+	sin := math.Sin(data.ToRadians(float64(angle)))
+
+	return func(p data.MicroVec3) data.MicroVec3 {
+		return data.NewMicroVec3(p.X(), p.Y(), data.Micrometer(float64(p.Z())/sin))
+	}
+}
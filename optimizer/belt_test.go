@@ -0,0 +1,100 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// vec3 returns the coordinates of the vector, so that they can be compared.
+func vec3(v data.MicroVec3) [3]data.Micrometer {
+	return [3]data.Micrometer{v.X(), v.Y(), v.Z()}
+}
+
+func TestBeltShear(t *testing.T) {
+	var testCases = map[string]struct {
+		point    data.MicroVec3
+		angle    int
+		expected [3]data.Micrometer
+	}{
+		"origin": {
+			point:    data.NewMicroVec3(0, 0, 0),
+			angle:    45,
+			expected: [3]data.Micrometer{0, 0, 0},
+		},
+		"x is not changed": {
+			point:    data.NewMicroVec3(1000, 0, 0),
+			angle:    45,
+			expected: [3]data.Micrometer{1000, 0, 0},
+		},
+		"height": {
+			point:    data.NewMicroVec3(0, 0, 1000),
+			angle:    45,
+			expected: [3]data.Micrometer{0, 1414, 707},
+		},
+		"along the belt": {
+			point:    data.NewMicroVec3(0, 1000, 0),
+			angle:    45,
+			expected: [3]data.Micrometer{0, 0, -707},
+		},
+		"on the first layer": {
+			point:    data.NewMicroVec3(0, 1000, 1000),
+			angle:    45,
+			expected: [3]data.Micrometer{0, 1414, 0},
+		},
+		"flat gantry": {
+			point:    data.NewMicroVec3(0, 2000, 1000),
+			angle:    30,
+			expected: [3]data.Micrometer{0, 2000, -133},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, vec3(beltShear(testCase.point, testCase.angle)))
+	}
+}
+
+func TestBeltMachinePosition(t *testing.T) {
+	var testCases = map[string]struct {
+		point    data.MicroVec3
+		angle    int
+		expected [3]data.Micrometer
+	}{
+		"first layer": {
+			point:    data.NewMicroVec3(1000, 2000, 0),
+			angle:    45,
+			expected: [3]data.Micrometer{1000, 2000, 0},
+		},
+		"45°": {
+			point:    data.NewMicroVec3(1000, 2000, 1000),
+			angle:    45,
+			expected: [3]data.Micrometer{1000, 2000, 1414},
+		},
+		"30°": {
+			point:    data.NewMicroVec3(1000, 2000, 1000),
+			angle:    30,
+			expected: [3]data.Micrometer{1000, 2000, 2000},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, vec3(BeltMachinePosition(testCase.angle)(testCase.point)))
+	}
+}
+
+func TestOptimizeBelt(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Printer.Center = data.NewMicroVec3(0, 0, 0)
+	options.Printer.Kinematics = "belt"
+	options.Printer.Belt.Angle = 45
+
+	m, err := NewOptimizer(&options).Optimize(boundedModel{cuboid(0, 0, 0, 10000, 10000, 10000)})
+	test.Ok(t, err)
+
+	// the cube is sheared so that its layers are planar and its first layer is at 0,
+	// the max z is truncated as the sheared coordinates are
+	test.Equals(t, [3]data.Micrometer{-5000, 0, 0}, vec3(m.Min()))
+	test.Equals(t, [3]data.Micrometer{5000, 14142, 14141}, vec3(m.Max()))
+}
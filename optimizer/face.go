@@ -9,6 +9,9 @@ type optimizedFace struct {
 	indices  [3]int
 	touching [3]int
 	index    int
+
+	// indirect contains the touching faces which do not share exactly one edge with this face.
+	indirect []int
 }
 
 func (o optimizedFace) Points() [3]data.MicroVec3 {
@@ -23,6 +26,10 @@ func (o optimizedFace) TouchingFaceIndices() [3]int {
 	return o.touching
 }
 
+func (o optimizedFace) IndirectTouchingFaceIndices() []int {
+	return o.indirect
+}
+
 func (o optimizedFace) MinZ() data.Micrometer {
 	points := o.Points()
 	minZ := points[0].Z()
@@ -0,0 +1,62 @@
+// This file provides the preparation of the bodies of a model which are printed by different extruders.
+
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// modelExtruders returns the extruder of each model of m,
+// or nil if all models are printed by the first extruder.
+// The bodies are only printed by several extruders if they are printed together in planar layers.
+func (o optimizer) modelExtruders(m data.Model) []int {
+	if o.sequential() || (o.options.Slicing.Plane.Type != "" && o.options.Slicing.Plane.Type != "planar") {
+		return nil
+	}
+
+	count := 1
+	if group, ok := m.(data.ModelGroup); ok {
+		count = len(group.Models())
+	}
+
+	extruders := make([]int, count)
+	other := false
+	for i := range extruders {
+		if i < len(o.options.Print.ModelExtruders) && o.options.Print.ModelExtruders[i] > 0 {
+			extruders[i] = o.options.Print.ModelExtruders[i]
+			other = true
+		}
+	}
+	if !other {
+		return nil
+	}
+	return extruders
+}
+
+// addMaterials optimizes the bodies of each extruder separately, moves them by the same offset as the whole model
+// and adds them as materials to the optimized model at the index of their extruder.
+func (o optimizer) addMaterials(om *optimizedModel, models []data.Model, extruders []int, offset data.MicroVec3) {
+	bodies := map[int][]data.Model{}
+	last := 0
+	for i, model := range models {
+		bodies[extruders[i]] = append(bodies[extruders[i]], model)
+		if extruders[i] > last {
+			last = extruders[i]
+		}
+	}
+
+	om.materials = make([]*optimizedModel, last+1)
+	for extruder, models := range bodies {
+		material := &optimizedModel{}
+		o.addFaces(material, data.NewModelGroup(models...))
+		if len(material.faces) == 0 {
+			continue
+		}
+
+		for j, point := range material.points {
+			material.points[j].pos = point.pos.Sub(offset)
+		}
+		om.materials[extruder] = material
+	}
+	o.options.GoSlice.Logger.Printf("The bodies are printed by %v extruders\n", len(bodies))
+}
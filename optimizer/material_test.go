@@ -0,0 +1,68 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestOptimizeMaterials(t *testing.T) {
+	// two bodies side by side, the right one is lower
+	left := boundedModel{cuboid(0, 0, 0, 10000, 10000, 10000)}
+	right := boundedModel{cuboid(10000, 0, 0, 20000, 10000, 5000)}
+
+	var testCases = map[string]struct {
+		extruders  []int
+		sequential bool
+		// expectedMaterials contains the min and max x of the bodies of each extruder, nil for unused extruders
+		expectedMaterials [][]data.Micrometer
+	}{
+		"first and third extruder": {
+			extruders:         []int{0, 2},
+			expectedMaterials: [][]data.Micrometer{{-10000, 0}, nil, {0, 10000}},
+		},
+		"both bodies by the second extruder": {
+			extruders:         []int{1, 1},
+			expectedMaterials: [][]data.Micrometer{nil, {-10000, 10000}},
+		},
+		"all bodies by the first extruder": {
+			extruders: []int{0},
+		},
+		"printed one after the other": {
+			extruders:  []int{0, 1},
+			sequential: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Printer.Center = data.NewMicroVec3(0, 0, 0)
+		options.Print.ModelExtruders = testCase.extruders
+		options.Print.Sequential.Enabled = testCase.sequential
+		options.Print.Sequential.ExtruderClearanceRadius = 0
+
+		m, err := NewOptimizer(&options).Optimize(data.NewModelGroup(left, right))
+		test.Ok(t, err)
+		test.Equals(t, 24, m.FaceCount())
+
+		materials := m.(data.MaterialModel).Materials()
+		if testCase.expectedMaterials == nil {
+			test.Assert(t, materials == nil, "the bodies should not be printed by different extruders")
+			continue
+		}
+
+		test.Equals(t, len(testCase.expectedMaterials), len(materials))
+		for extruder, expected := range testCase.expectedMaterials {
+			if expected == nil {
+				test.Assert(t, materials[extruder] == nil, "extruder %v should not be used", extruder)
+				continue
+			}
+
+			// the bodies stay at their position in the whole model and have its height
+			min, max := materials[extruder].Min(), materials[extruder].Max()
+			test.Equals(t, expected, []data.Micrometer{min.X(), max.X()})
+			test.Equals(t, data.Micrometer(10000), materials[extruder].Size().Z())
+		}
+	}
+}
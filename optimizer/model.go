@@ -2,34 +2,101 @@ package optimizer
 
 import (
 	"github.com/aligator/goslice/data"
+	"sort"
+	"sync"
 
 	"github.com/hschendel/stl"
 )
 
+// raycastCellSize is the size of the cells of the grid used by RaycastZ.
+const raycastCellSize = data.Micrometer(2000)
+
+// zBucketSize is the height of the buckets used by FacesInZRange.
+const zBucketSize = data.Micrometer(2000)
+
+// raycastCell identifies a cell of the grid used by RaycastZ.
+type raycastCell struct {
+	x, y data.Micrometer
+}
+
 // optimizedModel implements the OptimizedModel interface.
 type optimizedModel struct {
 	points    []point
 	faces     []optimizedFace
 	modelSize data.MicroVec3
+
+	// instances contains the offsets of all copies if the model is printed several times.
+	instances []data.MicroPoint
+
+	// objects contains the separately optimized objects in the order in which they are printed,
+	// if the objects are printed one after the other.
+	objects []data.OptimizedModel
+
+	// materials contains the bodies of each extruder at the index of the extruder
+	// if the bodies are printed by different extruders. The entries of unused extruders are nil.
+	materials []*optimizedModel
+
+	// raycastGrid contains the indices of all faces which overlap a cell (in X and Y direction).
+	// It is built once on the first call to RaycastZ, which may be called by several layers at the same time.
+	raycastGrid     map[raycastCell][]int
+	raycastGridOnce sync.Once
+
+	// zBuckets contains the indices of all faces which overlap a bucket (in Z direction).
+	// The first bucket starts at zBucketsMin.
+	// It is built on the first call to FacesInZRange.
+	zBuckets    [][]int
+	zBucketsMin data.Micrometer
+
+	// report contains the errors of the mesh found while optimizing it.
+	report data.ModelReport
 }
 
-func (o optimizedModel) FaceCount() int {
+func (o *optimizedModel) Report() data.ModelReport {
+	return o.report
+}
+
+func (o *optimizedModel) FaceCount() int {
 	return len(o.faces)
 }
 
-func (o optimizedModel) Face(index int) data.Face {
+func (o *optimizedModel) Face(index int) data.Face {
 	return o.faces[index]
 }
 
-func (o optimizedModel) OptimizedFace(index int) data.OptimizedFace {
+func (o *optimizedModel) OptimizedFace(index int) data.OptimizedFace {
 	return o.faces[index]
 }
 
-func (o optimizedModel) Size() data.MicroVec3 {
+func (o *optimizedModel) Size() data.MicroVec3 {
 	return o.modelSize
 }
 
-func (o optimizedModel) Min() data.MicroVec3 {
+func (o *optimizedModel) Instances() []data.MicroPoint {
+	if len(o.instances) == 0 {
+		return []data.MicroPoint{data.NewMicroPoint(0, 0)}
+	}
+	return o.instances
+}
+
+func (o *optimizedModel) Objects() []data.OptimizedModel {
+	return o.objects
+}
+
+func (o *optimizedModel) Materials() []data.OptimizedModel {
+	if len(o.materials) == 0 {
+		return nil
+	}
+
+	materials := make([]data.OptimizedModel, len(o.materials))
+	for i, material := range o.materials {
+		if material != nil {
+			materials[i] = material
+		}
+	}
+	return materials
+}
+
+func (o *optimizedModel) Min() data.MicroVec3 {
 	ret := o.faces[0].Points()[0].Copy()
 
 	for _, face := range o.faces {
@@ -51,7 +118,7 @@ func (o optimizedModel) Min() data.MicroVec3 {
 	return ret
 }
 
-func (o optimizedModel) Max() data.MicroVec3 {
+func (o *optimizedModel) Max() data.MicroVec3 {
 	ret := o.faces[0].Points()[0].Copy()
 
 	for _, face := range o.faces {
@@ -73,7 +140,145 @@ func (o optimizedModel) Max() data.MicroVec3 {
 	return ret
 }
 
-func (o optimizedModel) getFaceIdxWithPoints(idx0, idx1, notFaceIdx int) int {
+// RaycastZ casts a vertical ray through the given point downwards, starting at maxZ,
+// and returns the Z of the highest face it hits at or below maxZ.
+// Only the faces of the grid cell containing the point are checked.
+// It is safe for concurrent use.
+func (o *optimizedModel) RaycastZ(p data.MicroPoint, maxZ data.Micrometer) (data.Micrometer, bool) {
+	o.raycastGridOnce.Do(o.buildRaycastGrid)
+
+	px, py := float64(p.X()), float64(p.Y())
+
+	hit := false
+	var hitZ data.Micrometer
+	for _, faceIndex := range o.raycastGrid[raycastCellOf(p.X(), p.Y())] {
+		face := o.faces[faceIndex]
+		if face.MinZ() > maxZ || (hit && face.MaxZ() <= hitZ) {
+			continue
+		}
+
+		points := face.Points()
+		ax, ay := float64(points[0].X()), float64(points[0].Y())
+		bx, by := float64(points[1].X()), float64(points[1].Y())
+		cx, cy := float64(points[2].X()), float64(points[2].Y())
+
+		// calculate the barycentric coordinates of the point in the projected triangle
+		denominator := (by-cy)*(ax-cx) + (cx-bx)*(ay-cy)
+		if denominator == 0 {
+			// vertical face
+			continue
+		}
+
+		u := ((by-cy)*(px-cx) + (cx-bx)*(py-cy)) / denominator
+		v := ((cy-ay)*(px-cx) + (ax-cx)*(py-cy)) / denominator
+		w := 1 - u - v
+		if u < 0 || v < 0 || w < 0 {
+			continue
+		}
+
+		z := data.Micrometer(u*float64(points[0].Z()) + v*float64(points[1].Z()) + w*float64(points[2].Z()))
+		if z <= maxZ && (!hit || z > hitZ) {
+			hit = true
+			hitZ = z
+		}
+	}
+
+	return hitZ, hit
+}
+
+// buildRaycastGrid sorts all faces into the cells they overlap.
+func (o *optimizedModel) buildRaycastGrid() {
+	o.raycastGrid = map[raycastCell][]int{}
+
+	for i, face := range o.faces {
+		min, max := data.Path{
+			face.Points()[0].PointXY(),
+			face.Points()[1].PointXY(),
+			face.Points()[2].PointXY(),
+		}.Bounds()
+
+		minCell := raycastCellOf(min.X(), min.Y())
+		maxCell := raycastCellOf(max.X(), max.Y())
+		for x := minCell.x; x <= maxCell.x; x++ {
+			for y := minCell.y; y <= maxCell.y; y++ {
+				cell := raycastCell{x, y}
+				o.raycastGrid[cell] = append(o.raycastGrid[cell], i)
+			}
+		}
+	}
+}
+
+// raycastCellOf returns the cell containing the given coordinates.
+func raycastCellOf(x, y data.Micrometer) raycastCell {
+	cell := raycastCell{x / raycastCellSize, y / raycastCellSize}
+	// round towards negative infinity
+	if x < 0 {
+		cell.x--
+	}
+	if y < 0 {
+		cell.y--
+	}
+	return cell
+}
+
+func (o *optimizedModel) FacesInZRange(minZ, maxZ data.Micrometer) []int {
+	if o.zBuckets == nil {
+		o.buildZBuckets()
+	}
+
+	first, last := o.zBucketOf(minZ), o.zBucketOf(maxZ)
+	if first < 0 {
+		first = 0
+	}
+	if last >= len(o.zBuckets) {
+		last = len(o.zBuckets) - 1
+	}
+
+	var result []int
+	for bucket := first; bucket <= last; bucket++ {
+		for _, faceIndex := range o.zBuckets[bucket] {
+			face := o.faces[faceIndex]
+			faceFirst := o.zBucketOf(face.MinZ())
+			// add faces spanning several buckets only once
+			if faceFirst != bucket && bucket != first {
+				continue
+			}
+			if face.MinZ() > maxZ || face.MaxZ() < minZ {
+				continue
+			}
+			result = append(result, faceIndex)
+		}
+	}
+
+	sort.Ints(result)
+	return result
+}
+
+// buildZBuckets sorts all faces into the buckets they overlap.
+func (o *optimizedModel) buildZBuckets() {
+	o.zBuckets = [][]int{}
+	if len(o.faces) == 0 {
+		return
+	}
+
+	o.zBucketsMin = o.Min().Z()
+	o.zBuckets = make([][]int, o.zBucketOf(o.Max().Z())+1)
+	for i, face := range o.faces {
+		for bucket := o.zBucketOf(face.MinZ()); bucket <= o.zBucketOf(face.MaxZ()); bucket++ {
+			o.zBuckets[bucket] = append(o.zBuckets[bucket], i)
+		}
+	}
+}
+
+// zBucketOf returns the bucket containing the given Z.
+func (o *optimizedModel) zBucketOf(z data.Micrometer) int {
+	if z < o.zBucketsMin {
+		return -1
+	}
+	return int((z - o.zBucketsMin) / zBucketSize)
+}
+
+func (o *optimizedModel) getFaceIdxWithPoints(idx0, idx1, notFaceIdx int) int {
 	for _, faceIndex0 := range o.points[idx0].faceIndices {
 		if faceIndex0 == notFaceIdx {
 			continue
@@ -90,7 +295,7 @@ func (o optimizedModel) getFaceIdxWithPoints(idx0, idx1, notFaceIdx int) int {
 	return -1
 }
 
-func (o optimizedModel) SaveDebugSTL(filename string) error {
+func (o *optimizedModel) SaveDebugSTL(filename string) error {
 	triangles := make([]stl.Triangle, 0)
 
 	for _, face := range o.faces {
@@ -0,0 +1,203 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"sync"
+	"testing"
+)
+
+func TestFacesInZRange(t *testing.T) {
+	options := data.DefaultOptions()
+	m, err := NewOptimizer(&options).Optimize(testModel(append(
+		cuboid(0, 0, 0, 10000, 10000, 1000),
+		cuboid(2000, 2000, 1000, 8000, 8000, 15000)...,
+	)))
+	test.Ok(t, err)
+
+	indexed, ok := m.(data.ZIndexedModel)
+	test.Assert(t, ok, "the optimized model should implement data.ZIndexedModel")
+
+	var testCases = map[string]struct {
+		minZ, maxZ data.Micrometer
+	}{
+		"all":              {minZ: -1000, maxZ: 20000},
+		"bottom":           {minZ: 0, maxZ: 0},
+		"within one face":  {minZ: 5000, maxZ: 6000},
+		"several buckets":  {minZ: 500, maxZ: 9000},
+		"top":              {minZ: 15000, maxZ: 15000},
+		"above":            {minZ: 15001, maxZ: 20000},
+		"below":            {minZ: -2000, maxZ: -1},
+		"at bucket border": {minZ: 4000, maxZ: 4000},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+
+		var expected []int
+		for i := 0; i < m.FaceCount(); i++ {
+			face := m.OptimizedFace(i)
+			if face.MinZ() <= testCase.maxZ && face.MaxZ() >= testCase.minZ {
+				expected = append(expected, i)
+			}
+		}
+
+		test.Equals(t, expected, indexed.FacesInZRange(testCase.minZ, testCase.maxZ))
+	}
+}
+
+func TestInstances(t *testing.T) {
+	var testCases = map[string]struct {
+		modify            func(o *data.Options)
+		expectedFaces     int
+		expectedInstances []data.MicroPoint
+	}{
+		"copies are instanced": {
+			modify:        func(o *data.Options) {},
+			expectedFaces: 12,
+			// the testModel has a width of 1mm
+			expectedInstances: []data.MicroPoint{
+				data.NewMicroPoint(0, 0),
+				data.NewMicroPoint(6000, 0),
+				data.NewMicroPoint(12000, 0),
+			},
+		},
+		"instancing disabled": {
+			modify: func(o *data.Options) {
+				o.Slicing.Instancing = false
+			},
+			expectedFaces:     36,
+			expectedInstances: []data.MicroPoint{data.NewMicroPoint(0, 0)},
+		},
+		"different transforms": {
+			modify: func(o *data.Options) {
+				o.Print.ModelTransforms = []data.TransformOptions{{Scale: 1}, {Scale: 1, RotateZ: 45}, {Scale: 1}}
+			},
+			expectedFaces:     36,
+			expectedInstances: []data.MicroPoint{data.NewMicroPoint(0, 0)},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.ModelSpacing = 5
+		testCase.modify(&options)
+
+		m, err := NewOptimizer(&options).Optimize(data.NewModelCopies(testModel(cuboid(0, 0, 0, 10000, 10000, 10000)), 3))
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedFaces, m.FaceCount())
+
+		instances := m.(data.InstancedModel).Instances()
+		test.Equals(t, len(testCase.expectedInstances), len(instances))
+		for i, expected := range testCase.expectedInstances {
+			test.Assert(t, expected.X() == instances[i].X() && expected.Y() == instances[i].Y(), "instance %v should be at %v but is at %v", i, expected, instances[i])
+		}
+	}
+}
+
+// ramp returns the faces of a closed ramp from min to max, its top rises from z0 at x0 to z1 at x1.
+func ramp(x0, y0, x1, y1, z0, z1 data.Micrometer) []data.Face {
+	quads := [][4]data.MicroVec3{
+		{data.NewMicroVec3(x0, y0, 0), data.NewMicroVec3(x0, y1, 0), data.NewMicroVec3(x1, y1, 0), data.NewMicroVec3(x1, y0, 0)},
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x0, y1, z0)},
+		{data.NewMicroVec3(x0, y0, 0), data.NewMicroVec3(x1, y0, 0), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x0, y0, z0)},
+		{data.NewMicroVec3(x0, y1, 0), data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y1, 0)},
+		{data.NewMicroVec3(x0, y0, 0), data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x0, y1, 0)},
+		{data.NewMicroVec3(x1, y0, 0), data.NewMicroVec3(x1, y1, 0), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y0, z1)},
+	}
+
+	var faces []data.Face
+	for _, q := range quads {
+		faces = append(faces, testFace{q[0], q[1], q[2]}, testFace{q[0], q[2], q[3]})
+	}
+	return faces
+}
+
+func TestRaycastZ(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Printer.Center = data.NewMicroVec3(0, 0, 0)
+	// the ramp is centered at 0, so it rises from 1mm at x = -5mm to 3mm at x = 5mm
+	m, err := NewOptimizer(&options).Optimize(boundedModel{ramp(0, 0, 10000, 10000, 1000, 3000)})
+	test.Ok(t, err)
+
+	var testCases = map[string]struct {
+		point       data.MicroPoint
+		maxZ        data.Micrometer
+		expectedHit bool
+		expectedZ   data.Micrometer
+	}{
+		"center": {
+			point:       data.NewMicroPoint(0, 0),
+			maxZ:        10000,
+			expectedHit: true,
+			expectedZ:   2000,
+		},
+		"negative coordinates": {
+			point:       data.NewMicroPoint(-2500, -2500),
+			maxZ:        10000,
+			expectedHit: true,
+			expectedZ:   1500,
+		},
+		"at a cell border": {
+			point:       data.NewMicroPoint(-2000, -2000),
+			maxZ:        10000,
+			expectedHit: true,
+			expectedZ:   1600,
+		},
+		"positive coordinates": {
+			point:       data.NewMicroPoint(2500, 2500),
+			maxZ:        10000,
+			expectedHit: true,
+			expectedZ:   2500,
+		},
+		"top above max z": {
+			point:       data.NewMicroPoint(0, 0),
+			maxZ:        1900,
+			expectedHit: true,
+			expectedZ:   0,
+		},
+		"below the model": {
+			point:       data.NewMicroPoint(0, 0),
+			maxZ:        -1,
+			expectedHit: false,
+		},
+		"beside the model": {
+			point:       data.NewMicroPoint(6000, 0),
+			maxZ:        10000,
+			expectedHit: false,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		z, hit := m.RaycastZ(testCase.point, testCase.maxZ)
+		test.Equals(t, testCase.expectedHit, hit)
+		if testCase.expectedHit {
+			test.Equals(t, testCase.expectedZ, z)
+		}
+	}
+}
+
+func TestConcurrentRaycastZ(t *testing.T) {
+	options := data.DefaultOptions()
+	options.Printer.Center = data.NewMicroVec3(0, 0, 0)
+	m, err := NewOptimizer(&options).Optimize(boundedModel{ramp(0, 0, 10000, 10000, 1000, 3000)})
+	test.Ok(t, err)
+
+	// the grid is built by the first ray, which is cast by several layers at the same time
+	results := make([]data.Micrometer, 8)
+	var wg sync.WaitGroup
+	for i := range results {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			results[i], _ = m.RaycastZ(data.NewMicroPoint(0, 0), 10000)
+		}(i)
+	}
+	wg.Wait()
+
+	for _, z := range results {
+		test.Equals(t, data.Micrometer(2000), z)
+	}
+}
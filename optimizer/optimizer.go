@@ -11,19 +11,36 @@
 //
 // Beside creating the data.OptimizedModel it also fixes some errors in the model which would prevent printing.
 // 1. Fixing small holes:
-//    For this it snaps very similar points together to fix small holes.
-//    It does this by calculating a hash value which is (in most cases) the same for near points.
+//    For this it joins equal points and then welds the points of open edges which are within the weld distance
+//    together to fix hairline cracks. Points of closed edges are not moved so that small details are kept.
+//    It finds the near points using a spatial hash which divides the space into cells of the size of the weld distance.
 // 2. Removing duplicates:
 //    This is simply done by running through all faces and check if any faces have the same points.
+// 3. Connecting faces at T-vertices and at edges used by more than two faces:
+//    These faces do not share exactly one edge, so they are added as indirect touching faces
+//    which the slicer can use if no directly touching face continues the outline.
 //
 // At the end the count of open faces is printed (faces which do not have a touching face on one side -> still existing error).
+// Before that each model is scaled, mirrored and rotated as defined by the transform options
+// and optionally rotated automatically to the orientation which needs the least support.
 // Also the whole model is moved to the final place on the built plate.
+// If several models are passed as data.ModelGroup, they are packed on the bed before,
+// except if a model transform moves its model. Then each model is placed at its own translation.
+// If the group contains copies of the same model (data.ModelCopies), only the first copy is optimized
+// and the positions of the others are provided as instances (data.InstancedModel).
+// If the objects are printed one after the other, each model is additionally optimized separately (data.SequentialModel).
+// If the models are printed by different extruders, they are the bodies of one object. They are transformed together,
+// keep their position relative to each other and the bodies of each extruder are additionally optimized separately (data.MaterialModel).
+// If the layers are processed in windows, a single model is not optimized at once. Instead its faces are sorted into buckets
+// by their height in a temporary file and only the faces of the window which is sliced are loaded and optimized (data.ZRangeModel).
+// Models which read their faces lazily may fail to read some of them, then the optimization fails (data.LazyModel).
 
 package optimizer
 
 import (
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
+	"io"
 )
 
 type optimizer struct {
@@ -37,59 +54,269 @@ func NewOptimizer(options *data.Options) handler.ModelOptimizer {
 	}
 }
 
-// pointHash is used as type for the hash calculation of similar points.
-type pointHash uint
+// transform applies the transform options to the model including the automatic orientation.
+func (o optimizer) transform(m data.Model, t data.TransformOptions) data.Model {
+	return o.transformTogether([]data.Model{m}, t)[0]
+}
+
+// transformTogether applies the transform options to the models as if they were one model,
+// so that they keep their position relative to each other.
+// The automatic orientation is also the same for all models.
+func (o optimizer) transformTogether(models []data.Model, t data.TransformOptions) []data.Model {
+	transformed := make([]data.Model, len(models))
+	for i, m := range models {
+		transformed[i] = transform(m, t)
+	}
+	if !t.AutoOrient {
+		return transformed
+	}
+
+	orientation := autoOrient(data.NewModelGroup(transformed...), o.options)
+	o.options.GoSlice.Logger.Printf("Auto orientation: rotated by %v° around X and %v° around Y\n", orientation.RotateX, orientation.RotateY)
+	for i, m := range transformed {
+		transformed[i] = transform(m, orientation)
+	}
+	return transformed
+}
+
+// modelTransform returns the transform options for the model with the given index.
+func (o optimizer) modelTransform(index int) data.TransformOptions {
+	if index < len(o.options.Print.ModelTransforms) {
+		return o.options.Print.ModelTransforms[index]
+	}
+	return o.options.Print.Transform
+}
+
+// movesModels returns true if one of the model transforms moves its model.
+func (o optimizer) movesModels() bool {
+	for _, t := range o.options.Print.ModelTransforms {
+		if t.TranslateX != 0 || t.TranslateY != 0 {
+			return true
+		}
+	}
+	return false
+}
 
+// Optimize optimizes the model.
+// It fails if the model reads its faces lazily and some of them could not be read (see data.LazyModel).
 func (o optimizer) Optimize(m data.Model) (data.OptimizedModel, error) {
+	optimized, err := o.optimize(m)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := data.ModelErr(m); err != nil {
+		if closer, ok := optimized.(io.Closer); ok {
+			closer.Close()
+		}
+		return nil, err
+	}
+	return optimized, nil
+}
+
+func (o optimizer) optimize(m data.Model) (data.OptimizedModel, error) {
+	if o.streamable(m) {
+		return o.optimizeInZRanges(m)
+	}
+
+	translation := o.options.Print.Transform
+	// instanced is true if the model is placed several times but is only optimized once
+	instanced := false
+	// placed is true if the models of a group were moved to their own translations instead of being arranged
+	placed := false
+	// bodies contains the models printed by the extruders, if the models are printed by different extruders
+	var bodies []data.Model
+	extruders := o.modelExtruders(m)
+	if extruders != nil {
+		// the bodies are parts of one object, so they are transformed together and are not arranged
+		bodies = []data.Model{m}
+		if group, ok := m.(data.ModelGroup); ok {
+			bodies = group.Models()
+		}
+		bodies = o.transformTogether(bodies, translation)
+		m = data.NewModelGroup(bodies...)
+	} else if group, ok := m.(data.ModelGroup); ok {
+		models := group.Models()
+		instanced = o.instanceable(group)
+		transformed := make([]data.Model, len(models))
+		for i, model := range models {
+			if instanced && i > 0 {
+				transformed[i] = transformed[0]
+				continue
+			}
+			transformed[i] = o.transform(model, o.modelTransform(i))
+		}
+		bedDepth := o.options.Printer.BedDepth.ToMicrometer()
+		if o.options.Printer.Kinematics == "belt" {
+			// the belt is endless
+			bedDepth = 0
+		}
+
+		spacing := o.options.Print.ModelSpacing.ToMicrometer()
+		if o.sequential() {
+			// the print head must not touch the finished objects
+			spacing = data.Max(spacing, o.options.Print.Sequential.ExtruderClearanceRadius.ToMicrometer())
+		}
+
+		if o.movesModels() {
+			placed = true
+			translations := make([]data.MicroPoint, len(models))
+			for i := range models {
+				t := o.modelTransform(i)
+				translations[i] = data.NewMicroPoint(t.TranslateX.ToMicrometer(), t.TranslateY.ToMicrometer())
+			}
+			m = place(data.NewModelGroup(transformed...), translations)
+			// the translations of the models already contain the translation of the group
+			translation = data.TransformOptions{}
+		} else {
+			var err error
+			m, err = arrange(data.NewModelGroup(transformed...), spacing, o.options.Printer.BedWidth.ToMicrometer(), bedDepth)
+			if err != nil {
+				return nil, err
+			}
+		}
+	} else {
+		translation = o.modelTransform(0)
+		m = o.transform(m, translation)
+	}
+
 	om := &optimizedModel{}
 
-	// map of same faces grouped by their calculated hash
-	indices := make(map[pointHash][]int, 0)
+	// the faces of instanced copies are only added once
+	faces := m
+	if instanced {
+		copies := m.(data.ModelGroup).Models()
+		faces = copies[0]
+		first := copies[0].(translatedModel).offset
+		for _, instance := range copies {
+			om.instances = append(om.instances, instance.(translatedModel).offset.Sub(first).PointXY())
+		}
+		o.options.GoSlice.Logger.Printf("The model is sliced once and printed %v times\n", len(copies))
+	}
 
-FacesLoop:
-	for i := 0; i < m.FaceCount(); i++ {
-		face := m.Face(i)
+	openFaces, adjacency := o.addFaces(om, faces)
+	om.report = data.ModelReport{
+		OpenEdges:        openFaces,
+		TVertices:        adjacency.TVertices,
+		SplitEdges:       adjacency.SplitEdges,
+		NonManifoldEdges: adjacency.NonManifoldEdges,
+	}
+	o.options.GoSlice.Logger.Printf("Number of open faces: %v\n", openFaces)
+	if adjacency.TVertices > 0 || adjacency.NonManifoldEdges > 0 {
+		o.options.GoSlice.Logger.Printf("Connected faces at %v T-vertices (%v split edges) and %v edges used by more than two faces\n", adjacency.TVertices, adjacency.SplitEdges, adjacency.NonManifoldEdges)
+	}
+
+	min := m.Min()
+	max := m.Max()
+	vectorOffset := o.vectorOffset(min, max, translation, placed)
+	for i, point := range om.points {
+		om.points[i].pos = point.pos.Sub(vectorOffset)
+	}
+
+	om.modelSize = max.Sub(min)
+
+	if group, ok := m.(data.ModelGroup); ok && !instanced && o.sequential() && len(group.Models()) > 1 {
+		err := o.addObjects(om, group.Models(), vectorOffset)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if extruders != nil {
+		o.addMaterials(om, bodies, extruders, vectorOffset)
+	}
 
+	if o.options.Printer.Kinematics == "belt" {
+		// shear the model so that the tilted layers of the belt printer are planar
+		models := append([]*optimizedModel{om}, om.materials...)
+		for _, model := range models {
+			if model == nil {
+				continue
+			}
+			for i, point := range model.points {
+				model.points[i].pos = beltShear(point.pos, o.options.Printer.Belt.Angle)
+			}
+		}
+
+		// move the first layer to 0
+		minZ := om.Min().Z()
+		for _, model := range models {
+			if model == nil {
+				continue
+			}
+			for i, point := range model.points {
+				model.points[i].pos = point.pos.Sub(data.NewMicroVec3(0, 0, minZ))
+			}
+		}
+
+		om.modelSize = om.Max().Sub(om.Min())
+	}
+
+	// the bodies are sliced into the same layers as the whole model
+	for _, material := range om.materials {
+		if material != nil {
+			material.modelSize = om.modelSize
+		}
+	}
+
+	return om, nil
+}
+
+// vectorOffset returns the offset which moves the model from min to max to its place on the bed.
+// The model is centered at the translation from the center of the bed and its bottom is moved to 0.
+// If the model is already placed relative to the center of the bed, only its bottom is moved.
+func (o optimizer) vectorOffset(min, max data.MicroVec3, translation data.TransformOptions, placed bool) data.MicroVec3 {
+	// move points according to the center value
+	vectorOffset := data.NewMicroVec3((min.X()+max.X())/2, (min.Y()+max.Y())/2, min.Z())
+	if placed {
+		// the models are already placed relative to the center of the bed
+		vectorOffset = data.NewMicroVec3(0, 0, min.Z())
+	}
+	vectorOffset = vectorOffset.Sub(o.options.Printer.Center)
+	return vectorOffset.Sub(data.NewMicroVec3(translation.TranslateX.ToMicrometer(), translation.TranslateY.ToMicrometer(), 0))
+}
+
+// addFaces adds the faces of the model to the optimized model.
+// It joins equal vertices, welds open edges, removes duplicate faces and connects the touching faces.
+// It returns the number of open faces and the report of the indirectly connected faces.
+func (o optimizer) addFaces(om *optimizedModel, faces data.Model) (int, adjacencyReport) {
+	// join equal vertices first and then the vertices of open edges which are within the weld distance
+	vertices := newWeldHash(0)
+	faceVertices := make([][3]int, faces.FaceCount())
+	for i := range faceVertices {
+		for j, p := range faces.Face(i).Points() {
+			vertex := vertices.find(p)
+			if vertex == -1 {
+				vertex = vertices.add(p)
+			}
+			faceVertices[i][j] = vertex
+		}
+	}
+	welded := weldOpenEdges(vertices.points, faceVertices, o.options.Slicing.WeldDistance)
+
+	// the index of each vertex in om.points
+	pointIndices := make([]int, len(vertices.points))
+	for i := range pointIndices {
+		pointIndices[i] = -1
+	}
+
+FacesLoop:
+	for _, face := range faceVertices {
 		optimizedFace := optimizedFace{
 			indices:  [3]int{},
 			touching: [3]int{},
 			model:    om,
 		}
-		for j := 0; j < 3; j++ {
-			currentPoint := face.Points()[j]
-			// create hash for the pos
-			// points which are within the meldDistance fall into the same category of the indices map
-			meldDistanceHash := pointHash(o.options.Slicing.MeldDistance)
-			hash := ((pointHash(currentPoint.X()) + meldDistanceHash/2) / meldDistanceHash) ^
-				(((pointHash(currentPoint.Y()) + meldDistanceHash/2) / meldDistanceHash) << 10) ^
-				(((pointHash(currentPoint.Z()) + meldDistanceHash/2) / meldDistanceHash) << 20)
-			var idx int
-			add := true
-
-			// for each pos-indices with this hash
-			// check if the difference between it and the currentPoint
-			// is smaller (or same) than the currently tested pos
-			for _, index := range indices[hash] {
-				differenceVec := om.points[index].pos.Sub(currentPoint)
-				if differenceVec.ShorterThanOrEqual(o.options.Slicing.MeldDistance) {
-					// if true for any of the points with the same hash,
-					// do not add the current pos to the indices map
-					// but save the indices of the already existing duplicate
-					idx = index
-					add = false
-					break
-				}
-			}
-			if add {
-				// add the new pos-indices to the indices
-				indices[hash] = append(indices[hash], len(om.points))
-				idx = len(om.points)
+		for j, vertex := range face {
+			vertex = welded[vertex]
+			if pointIndices[vertex] == -1 {
+				pointIndices[vertex] = len(om.points)
 				om.points = append(om.points, point{
-					pos: currentPoint,
+					pos: vertices.points[vertex],
 				})
 			}
 
-			optimizedFace.indices[j] = idx
+			optimizedFace.indices[j] = pointIndices[vertex]
 		}
 
 		// ignore duplicate search for faces
@@ -146,18 +373,45 @@ FacesLoop:
 		om.faces[i] = face
 	}
 
-	o.options.GoSlice.Logger.Printf("Number of open faces: %v\n", openFaces)
+	adjacency := om.connectIndirectTouchingFaces(o.options.Slicing.MeldDistance)
+	// the open edges split at T-vertices are connected now
+	openFaces -= adjacency.SplitEdges
 
-	min := m.Min()
-	max := m.Max()
-	// move points according to the center value
-	vectorOffset := data.NewMicroVec3((min.X()+max.X())/2, (min.Y()+max.Y())/2, min.Z())
-	vectorOffset = vectorOffset.Sub(o.options.Printer.Center)
-	for i, point := range om.points {
-		om.points[i].pos = point.pos.Sub(vectorOffset)
+	return openFaces, adjacency
+}
+
+// instanceable returns true if the group can be sliced only once and printed at the position of each copy.
+// This is the case for data.ModelCopies which are transformed all in the same way.
+func (o optimizer) instanceable(group data.ModelGroup) bool {
+	if _, ok := group.(data.ModelCopies); !ok || !o.options.Slicing.Instancing || group.FaceCount() == 0 {
+		return false
 	}
 
-	om.modelSize = max.Sub(min)
+	// the instances are printed layer by layer, so they cannot be printed one after the other
+	if o.sequential() {
+		return false
+	}
 
-	return om, nil
+	// the bodies printed by different extruders are parts of one object
+	if o.modelExtruders(group) != nil {
+		return false
+	}
+
+	// the layers of the copies differ on belt printers and for non-planar layers
+	if o.options.Printer.Kinematics == "belt" || (o.options.Slicing.Plane.Type != "" && o.options.Slicing.Plane.Type != "planar") {
+		return false
+	}
+
+	// the brims of the copies can only be clipped by each other if all copies are sliced
+	if o.options.Print.BrimSkirt.BrimCount > 0 && o.options.Print.BrimSkirt.OverlapPrecedence != "none" {
+		return false
+	}
+
+	first := o.modelTransform(0)
+	for i := 1; i < len(group.Models()); i++ {
+		if o.modelTransform(i) != first {
+			return false
+		}
+	}
+	return true
 }
@@ -0,0 +1,124 @@
+package optimizer
+
+import (
+	"errors"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// boundedModel is a testModel which calculates its bounds from its faces.
+type boundedModel struct {
+	testModel
+}
+
+func (m boundedModel) Min() data.MicroVec3 {
+	min, _ := bounds(m.testModel)
+	return min
+}
+
+func (m boundedModel) Max() data.MicroVec3 {
+	_, max := bounds(m.testModel)
+	return max
+}
+
+func bounds(faces []data.Face) (min, max data.MicroVec3) {
+	min, max = faces[0].Points()[0], faces[0].Points()[0]
+	for _, f := range faces {
+		for _, p := range f.Points() {
+			min = data.NewMicroVec3(data.Min(min.X(), p.X()), data.Min(min.Y(), p.Y()), data.Min(min.Z(), p.Z()))
+			max = data.NewMicroVec3(data.Max(max.X(), p.X()), data.Max(max.Y(), p.Y()), data.Max(max.Z(), p.Z()))
+		}
+	}
+	return min, max
+}
+
+func TestModelTransforms(t *testing.T) {
+	var testCases = map[string]struct {
+		transforms data.ModelTransformOptions
+		// expectedMin contains the min of both models relative to the center of the bed
+		expectedMin []data.MicroVec3
+	}{
+		"models are arranged without translations": {
+			// the deeper model is arranged first, so the bigger one comes first to keep the order of the faces
+			transforms: data.ModelTransformOptions{{Scale: 2}, {Scale: 1}},
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(-17500, 30000, 0),
+				data.NewMicroVec3(7500, 30000, 0),
+			},
+		},
+		"models are placed at their translations": {
+			transforms: data.ModelTransformOptions{{Scale: 1, TranslateX: -20}, {Scale: 2, TranslateX: 30, TranslateY: 10}},
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(-25000, -5000, 0),
+				data.NewMicroVec3(20000, 0, 0),
+			},
+		},
+		"models without own transform use the global translation": {
+			transforms: data.ModelTransformOptions{{Scale: 1, TranslateY: -20}},
+			expectedMin: []data.MicroVec3{
+				data.NewMicroVec3(-5000, -25000, 0),
+				data.NewMicroVec3(-5000, 35000, 0),
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.Print.ModelSpacing = 5
+		options.Print.Transform.TranslateY = 40
+		options.Print.ModelTransforms = testCase.transforms
+
+		cube := boundedModel{cuboid(0, 0, 0, 10000, 10000, 10000)}
+		m, err := NewOptimizer(&options).Optimize(data.NewModelGroup(cube, cube))
+		test.Ok(t, err)
+		test.Equals(t, 24, m.FaceCount())
+
+		for modelNr, expected := range testCase.expectedMin {
+			// each cube has 12 faces
+			var faces []data.Face
+			for i := modelNr * 12; i < (modelNr+1)*12; i++ {
+				faces = append(faces, m.Face(i))
+			}
+			min, _ := bounds(faces)
+			min = min.Sub(options.Printer.Center)
+
+			test.Assert(t, min.X() == expected.X() && min.Y() == expected.Y() && min.Z() == expected.Z(), "model %v should start at %v but starts at %v", modelNr, expected, min)
+		}
+	}
+}
+
+// lazyModel is a boundedModel which failed to read some of its faces.
+type lazyModel struct {
+	boundedModel
+	err error
+}
+
+func (m lazyModel) Err() error {
+	return m.err
+}
+
+func TestOptimizeLazyModel(t *testing.T) {
+	model := boundedModel{cuboid(0, 0, 0, 10000, 10000, 10000)}
+	readErr := errors.New("could not read the face")
+
+	for _, layerWindow := range []int{0, 10} {
+		t.Log("layer window:", layerWindow)
+		options := data.DefaultOptions()
+		options.GoSlice.LayerWindow = layerWindow
+
+		_, err := NewOptimizer(&options).Optimize(lazyModel{boundedModel: model, err: readErr})
+		test.Assert(t, err == readErr, "the read error should be returned but got %v", err)
+
+		optimized, err := NewOptimizer(&options).Optimize(lazyModel{boundedModel: model})
+		test.Ok(t, err)
+		if zm, ok := optimized.(*zRangeModel); ok {
+			test.Ok(t, zm.Close())
+		}
+
+		// a group fails if one of its models failed
+		_, err = NewOptimizer(&options).Optimize(data.NewModelGroup(model, lazyModel{boundedModel: model, err: readErr}))
+		test.Assert(t, err == readErr, "the read error should be returned but got %v", err)
+	}
+}
@@ -0,0 +1,114 @@
+// This file provides the automatic orientation of models to minimize the needed support.
+
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/modifier"
+	"math"
+)
+
+// orientationStep is the angle in degree between the sampled orientations.
+const orientationStep = 45
+
+// minBedContact is the min part of the largest possible bed contact area an orientation needs,
+// so that the model sticks to the bed.
+const minBedContact = 0.5
+
+// orientationScore estimates the support needed for the model if it is transformed by the matrix.
+// Each face which overhangs more than the support detection allows (d = h * tan θ, see modifier.SupportDistance)
+// adds the volume of the support below it.
+// The contact area is added as the volume of the interface layers as they are printed dense and are hard to remove.
+// It also returns the area of the overhanging faces which lie on the bed.
+func orientationScore(m data.Model, matrix matrix, options *data.Options) (score float64, bedContact float64) {
+	heights := options.Print.LayerHeights()
+
+	minZ := math.Inf(1)
+	faces := make([][3][3]float64, m.FaceCount())
+	for i := range faces {
+		for j, p := range m.Face(i).Points() {
+			x, y, z := float64(p.X()), float64(p.Y()), float64(p.Z())
+			faces[i][j] = [3]float64{
+				matrix[0]*x + matrix[1]*y + matrix[2]*z,
+				matrix[3]*x + matrix[4]*y + matrix[5]*z,
+				matrix[6]*x + matrix[7]*y + matrix[8]*z,
+			}
+			minZ = math.Min(minZ, faces[i][j][2])
+		}
+	}
+
+	for _, f := range faces {
+		// the normal with the length of twice the area of the face
+		ux, uy, uz := f[1][0]-f[0][0], f[1][1]-f[0][1], f[1][2]-f[0][2]
+		vx, vy, vz := f[2][0]-f[0][0], f[2][1]-f[0][1], f[2][2]-f[0][2]
+		nx, ny, nz := uy*vz-uz*vy, uz*vx-ux*vz, ux*vy-uy*vx
+		if nz >= 0 {
+			continue
+		}
+
+		// the layer in which the face lies
+		height := (f[0][2]+f[1][2]+f[2][2])/3 - minZ
+		layerNr := 0
+		if height >= float64(heights.Thickness(0)) {
+			layerNr = heights.Count(data.Micrometer(height))
+		}
+		layerThickness := heights.Thickness(layerNr)
+
+		// A face overhangs by tan(α) * h per layer where α is the angle between the face and the Z axis.
+		horizontal := math.Hypot(nx, ny)
+		if horizontal > 0 && -nz/horizontal*float64(layerThickness) <= modifier.SupportDistance(layerThickness, options.Print.Support.ThresholdAngle) {
+			continue
+		}
+
+		area := -nz / 2
+		if layerNr == 0 {
+			// the face lies on the bed
+			bedContact += area
+			continue
+		}
+
+		// the interface layers lie directly below the face
+		interfaceHeight := float64(heights.Z(layerNr-1)) - float64(heights.Z(layerNr-1-options.Print.Support.InterfaceLayers))
+		score += area * (height + interfaceHeight)
+	}
+
+	return score, bedContact
+}
+
+// autoOrient returns the rotation around X and Y which needs the least support.
+// Orientations in which the model rests on less than half of the largest possible bed contact area
+// are not used, as the model would not stick to the bed.
+// The current orientation is kept if no other orientation is better.
+func autoOrient(m data.Model, options *data.Options) data.TransformOptions {
+	if m.FaceCount() == 0 {
+		return data.TransformOptions{Scale: 1}
+	}
+
+	// the first candidate is the current orientation
+	candidates := []data.TransformOptions{{Scale: 1}}
+	for x := 0; x < 360; x += orientationStep {
+		for y := 0; y < 360; y += orientationStep {
+			candidates = append(candidates, data.TransformOptions{Scale: 1, RotateX: float64(x), RotateY: float64(y)})
+		}
+	}
+
+	scores := make([]float64, len(candidates))
+	bedContacts := make([]float64, len(candidates))
+	maxBedContact := 0.0
+	for i, candidate := range candidates {
+		scores[i], bedContacts[i] = orientationScore(m, transformMatrix(candidate), options)
+		maxBedContact = math.Max(maxBedContact, bedContacts[i])
+	}
+
+	best := -1
+	for i := range candidates {
+		if bedContacts[i] < maxBedContact*minBedContact {
+			continue
+		}
+		if best == -1 || scores[i] < scores[best] {
+			best = i
+		}
+	}
+
+	return candidates[best]
+}
@@ -0,0 +1,55 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// cuboid returns the faces of a cuboid with the normals pointing outside.
+func cuboid(x0, y0, z0, x1, y1, z1 data.Micrometer) []data.Face {
+	quads := [][4]data.MicroVec3{
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x1, y1, z0), data.NewMicroVec3(x1, y0, z0)},
+		{data.NewMicroVec3(x0, y0, z1), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x0, y1, z1)},
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x1, y0, z0), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x0, y0, z1)},
+		{data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x0, y1, z1), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y1, z0)},
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x0, y0, z1), data.NewMicroVec3(x0, y1, z1), data.NewMicroVec3(x0, y1, z0)},
+		{data.NewMicroVec3(x1, y0, z0), data.NewMicroVec3(x1, y1, z0), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y0, z1)},
+	}
+
+	var faces []data.Face
+	for _, q := range quads {
+		faces = append(faces, testFace{q[0], q[1], q[2]}, testFace{q[0], q[2], q[3]})
+	}
+	return faces
+}
+
+func TestAutoOrient(t *testing.T) {
+	options := data.DefaultOptions()
+
+	var testCases = map[string]struct {
+		model    testModel
+		expected data.TransformOptions
+	}{
+		"cube is not rotated": {
+			model:    cuboid(0, 0, 0, 10000, 10000, 10000),
+			expected: data.TransformOptions{Scale: 1},
+		},
+		"plate on a stem is flipped": {
+			model: append(
+				cuboid(9000, 9000, 0, 11000, 11000, 10000),
+				cuboid(0, 0, 10000, 20000, 20000, 12000)...,
+			),
+			expected: data.TransformOptions{Scale: 1, RotateY: 180},
+		},
+		"empty model": {
+			model:    testModel{},
+			expected: data.TransformOptions{Scale: 1},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, autoOrient(testCase.model, &options))
+	}
+}
@@ -0,0 +1,102 @@
+// This file provides the preparation of several objects which are printed one after the other.
+
+package optimizer
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"sort"
+)
+
+// sequential returns true if the objects are printed one after the other.
+// This is not possible on belt printers as the objects are printed on the endless belt in one go.
+func (o optimizer) sequential() bool {
+	return o.options.Print.Sequential.Enabled && o.options.Printer.Kinematics != "belt"
+}
+
+// addObjects optimizes each of the arranged models separately, moves it by the same offset as the whole model
+// and adds it as object to the optimized model in the order in which the objects are printed.
+func (o optimizer) addObjects(om *optimizedModel, models []data.Model, offset data.MicroVec3) error {
+	order, err := sequence(models, o.options.Print.Sequential.ExtruderClearanceRadius.ToMicrometer(), o.options.Print.Sequential.GantryHeight.ToMicrometer())
+	if err != nil {
+		return err
+	}
+
+	for _, i := range order {
+		object := &optimizedModel{}
+		o.addFaces(object, models[i])
+		for j, point := range object.points {
+			object.points[j].pos = point.pos.Sub(offset)
+		}
+		object.modelSize = models[i].Max().Sub(models[i].Min())
+
+		om.objects = append(om.objects, object)
+	}
+	o.options.GoSlice.Logger.Printf("The %v objects are printed one after the other\n", len(order))
+
+	return nil
+}
+
+// sequence returns the order in which the models are printed one after the other.
+// The models are printed from the lowest to the highest one, because the nozzle is lifted above
+// the highest finished model before it moves to the next one. Models of the same height are printed
+// from front to back and then from left to right.
+//
+// An error is returned if the print head may knock over a finished model:
+// All models except the last one have to be lower than the gantry height, as the gantry spans the whole bed.
+// The models have to be at least the extruder clearance radius apart, as the print head surrounds the nozzle.
+// A gantry height or clearance radius of 0 is not checked.
+func sequence(models []data.Model, clearanceRadius, gantryHeight data.Micrometer) ([]int, error) {
+	order := make([]int, len(models))
+	for i := range order {
+		order[i] = i
+	}
+
+	height := func(i int) data.Micrometer {
+		return models[i].Max().Z() - models[i].Min().Z()
+	}
+
+	sort.SliceStable(order, func(a, b int) bool {
+		minA, minB := models[order[a]].Min(), models[order[b]].Min()
+		switch {
+		case height(order[a]) != height(order[b]):
+			return height(order[a]) < height(order[b])
+		case minA.Y() != minB.Y():
+			return minA.Y() < minB.Y()
+		default:
+			return minA.X() < minB.X()
+		}
+	})
+
+	if gantryHeight > 0 {
+		for n, i := range order[:len(order)-1] {
+			if height(i) > gantryHeight {
+				return nil, fmt.Errorf("the %v. printed object is %vmm high, but only the last object may be higher than the gantry height of %vmm", n+1, height(i).ToMillimeter(), gantryHeight.ToMillimeter())
+			}
+		}
+	}
+
+	if clearanceRadius > 0 {
+		for a := range order {
+			for b := a + 1; b < len(order); b++ {
+				if distance := boundsDistance(models[order[a]], models[order[b]]); distance < clearanceRadius {
+					return nil, fmt.Errorf("the %v. and %v. printed objects are only %vmm apart, but the extruder clearance radius is %vmm", a+1, b+1, distance.ToMillimeter(), clearanceRadius.ToMillimeter())
+				}
+			}
+		}
+	}
+
+	return order, nil
+}
+
+// boundsDistance returns the distance between the bounding boxes of the models in X and Y direction.
+// It is 0 if they overlap.
+func boundsDistance(a, b data.Model) data.Micrometer {
+	minA, maxA := a.Min(), a.Max()
+	minB, maxB := b.Min(), b.Max()
+
+	dx := data.Max(0, data.Max(minA.X()-maxB.X(), minB.X()-maxA.X()))
+	dy := data.Max(0, data.Max(minA.Y()-maxB.Y(), minB.Y()-maxA.Y()))
+
+	return data.NewMicroPoint(dx, dy).Size()
+}
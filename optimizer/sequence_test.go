@@ -0,0 +1,55 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func placedBox(x, y, width, height data.Micrometer) data.Model {
+	return boxModel{min: data.NewMicroVec3(x, y, 0), max: data.NewMicroVec3(x+width, y+width, height)}
+}
+
+func TestSequence(t *testing.T) {
+	var testCases = map[string]struct {
+		models        []data.Model
+		expectedOrder []int
+		expectedError bool
+	}{
+		"lowest first": {
+			models:        []data.Model{placedBox(0, 0, 10, 30), placedBox(40, 0, 10, 5), placedBox(80, 0, 10, 15)},
+			expectedOrder: []int{1, 2, 0},
+		},
+		"same height from front to back and left to right": {
+			models:        []data.Model{placedBox(40, 40, 10, 5), placedBox(40, 0, 10, 5), placedBox(0, 40, 10, 5)},
+			expectedOrder: []int{1, 2, 0},
+		},
+		"only the last object may be higher than the gantry": {
+			models:        []data.Model{placedBox(0, 0, 10, 50), placedBox(40, 0, 10, 15)},
+			expectedOrder: []int{1, 0},
+		},
+		"two objects higher than the gantry": {
+			models:        []data.Model{placedBox(0, 0, 10, 50), placedBox(40, 0, 10, 25)},
+			expectedError: true,
+		},
+		"objects too close": {
+			models:        []data.Model{placedBox(0, 0, 10, 5), placedBox(25, 0, 10, 5)},
+			expectedError: true,
+		},
+		"objects diagonally apart": {
+			models:        []data.Model{placedBox(0, 0, 10, 5), placedBox(25, 25, 10, 5)},
+			expectedOrder: []int{0, 1},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		order, err := sequence(testCase.models, 20, 20)
+		if testCase.expectedError {
+			test.Assert(t, err != nil, "error expected")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedOrder, order)
+	}
+}
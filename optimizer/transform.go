@@ -0,0 +1,137 @@
+// This file provides the transformation of models by the transform options.
+
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"math"
+	"strings"
+)
+
+// matrix is a 3x3 matrix in row-major order.
+type matrix [9]float64
+
+func identityMatrix() matrix {
+	return matrix{1, 0, 0, 0, 1, 0, 0, 0, 1}
+}
+
+// multiply returns the matrix which first applies other and then m.
+func (m matrix) multiply(other matrix) matrix {
+	var result matrix
+	for row := 0; row < 3; row++ {
+		for col := 0; col < 3; col++ {
+			for k := 0; k < 3; k++ {
+				result[row*3+col] += m[row*3+k] * other[k*3+col]
+			}
+		}
+	}
+	return result
+}
+
+func (m matrix) determinant() float64 {
+	return m[0]*(m[4]*m[8]-m[5]*m[7]) - m[1]*(m[3]*m[8]-m[5]*m[6]) + m[2]*(m[3]*m[7]-m[4]*m[6])
+}
+
+func (m matrix) apply(p data.MicroVec3) data.MicroVec3 {
+	x, y, z := float64(p.X()), float64(p.Y()), float64(p.Z())
+	return data.NewMicroVec3(
+		data.Micrometer(math.Round(m[0]*x+m[1]*y+m[2]*z)),
+		data.Micrometer(math.Round(m[3]*x+m[4]*y+m[5]*z)),
+		data.Micrometer(math.Round(m[6]*x+m[7]*y+m[8]*z)),
+	)
+}
+
+// transformMatrix returns the matrix which scales, mirrors and rotates a model as defined by the options.
+func transformMatrix(t data.TransformOptions) matrix {
+	scale := t.Scale
+	if scale == 0 {
+		scale = 1
+	}
+	m := matrix{scale, 0, 0, 0, scale, 0, 0, 0, scale}
+
+	mirror := strings.ToLower(t.Mirror)
+	for i, axis := range "xyz" {
+		if strings.ContainsRune(mirror, axis) {
+			m[i*4] = -m[i*4]
+		}
+	}
+
+	sin, cos := math.Sincos(data.ToRadians(t.RotateX))
+	m = matrix{1, 0, 0, 0, cos, -sin, 0, sin, cos}.multiply(m)
+	sin, cos = math.Sincos(data.ToRadians(t.RotateY))
+	m = matrix{cos, 0, sin, 0, 1, 0, -sin, 0, cos}.multiply(m)
+	sin, cos = math.Sincos(data.ToRadians(t.RotateZ))
+	m = matrix{cos, -sin, 0, sin, cos, 0, 0, 0, 1}.multiply(m)
+
+	return m
+}
+
+// transform returns the model scaled, mirrored and rotated as defined by the options.
+// The translation is not applied as the model is placed on the bed afterwards.
+func transform(m data.Model, t data.TransformOptions) data.Model {
+	matrix := transformMatrix(t)
+	if matrix == identityMatrix() || m.FaceCount() == 0 {
+		return m
+	}
+
+	result := &transformedModel{
+		model:  m,
+		matrix: matrix,
+		// mirroring inverts the faces, so the order of the points has to be changed to keep the normals pointing outside
+		flip: matrix.determinant() < 0,
+	}
+
+	// the bounds of a rotated model cannot be derived from the original bounds
+	result.min = result.Face(0).Points()[0]
+	result.max = result.min
+	for i := 0; i < m.FaceCount(); i++ {
+		for _, p := range result.Face(i).Points() {
+			result.min = data.NewMicroVec3(data.Min(result.min.X(), p.X()), data.Min(result.min.Y(), p.Y()), data.Min(result.min.Z(), p.Z()))
+			result.max = data.NewMicroVec3(data.Max(result.max.X(), p.X()), data.Max(result.max.Y(), p.Y()), data.Max(result.max.Z(), p.Z()))
+		}
+	}
+
+	return result
+}
+
+// transformedModel applies a matrix to all faces of a model.
+type transformedModel struct {
+	model    data.Model
+	matrix   matrix
+	flip     bool
+	min, max data.MicroVec3
+}
+
+func (t transformedModel) FaceCount() int {
+	return t.model.FaceCount()
+}
+
+func (t transformedModel) Face(index int) data.Face {
+	return transformedFace{face: t.model.Face(index), matrix: t.matrix, flip: t.flip}
+}
+
+func (t transformedModel) Min() data.MicroVec3 {
+	return t.min.Copy()
+}
+
+func (t transformedModel) Max() data.MicroVec3 {
+	return t.max.Copy()
+}
+
+// transformedFace applies a matrix to the points of a face.
+type transformedFace struct {
+	face   data.Face
+	matrix matrix
+	flip   bool
+}
+
+func (t transformedFace) Points() [3]data.MicroVec3 {
+	points := t.face.Points()
+	for i, p := range points {
+		points[i] = t.matrix.apply(p)
+	}
+	if t.flip {
+		points[1], points[2] = points[2], points[1]
+	}
+	return points
+}
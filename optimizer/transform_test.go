@@ -0,0 +1,87 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+type testFace [3]data.MicroVec3
+
+func (f testFace) Points() [3]data.MicroVec3 {
+	return f
+}
+
+type testModel []data.Face
+
+func (m testModel) FaceCount() int {
+	return len(m)
+}
+
+func (m testModel) Face(index int) data.Face {
+	return m[index]
+}
+
+func (m testModel) Min() data.MicroVec3 {
+	return data.NewMicroVec3(0, 0, 0)
+}
+
+func (m testModel) Max() data.MicroVec3 {
+	return data.NewMicroVec3(1000, 2000, 0)
+}
+
+func TestTransform(t *testing.T) {
+	model := testModel{testFace{
+		data.NewMicroVec3(0, 0, 0),
+		data.NewMicroVec3(1000, 0, 0),
+		data.NewMicroVec3(0, 2000, 0),
+	}}
+
+	var testCases = map[string]struct {
+		transform data.TransformOptions
+		expected  [3]data.MicroVec3
+		min, max  data.MicroVec3
+	}{
+		"identity": {
+			transform: data.TransformOptions{Scale: 1},
+			expected:  [3]data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(1000, 0, 0), data.NewMicroVec3(0, 2000, 0)},
+			min:       data.NewMicroVec3(0, 0, 0),
+			max:       data.NewMicroVec3(1000, 2000, 0),
+		},
+		"scale": {
+			transform: data.TransformOptions{Scale: 2},
+			expected:  [3]data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(2000, 0, 0), data.NewMicroVec3(0, 4000, 0)},
+			min:       data.NewMicroVec3(0, 0, 0),
+			max:       data.NewMicroVec3(2000, 4000, 0),
+		},
+		"rotate z": {
+			transform: data.TransformOptions{Scale: 1, RotateZ: 90},
+			expected:  [3]data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(0, 1000, 0), data.NewMicroVec3(-2000, 0, 0)},
+			min:       data.NewMicroVec3(-2000, 0, 0),
+			max:       data.NewMicroVec3(0, 1000, 0),
+		},
+		"rotate x": {
+			transform: data.TransformOptions{Scale: 1, RotateX: 90},
+			expected:  [3]data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(1000, 0, 0), data.NewMicroVec3(0, 0, 2000)},
+			min:       data.NewMicroVec3(0, 0, 0),
+			max:       data.NewMicroVec3(1000, 0, 2000),
+		},
+		"mirror keeps the orientation of the face": {
+			transform: data.TransformOptions{Scale: 1, Mirror: "x"},
+			expected:  [3]data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(0, 2000, 0), data.NewMicroVec3(-1000, 0, 0)},
+			min:       data.NewMicroVec3(-1000, 0, 0),
+			max:       data.NewMicroVec3(0, 2000, 0),
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		transformed := transform(model, testCase.transform)
+		points := transformed.Face(0).Points()
+		for i, expected := range testCase.expected {
+			test.Assert(t, points[i].Sub(expected).ShorterThanOrEqual(0), "point %v should be %v but is %v", i, expected, points[i])
+		}
+		test.Assert(t, transformed.Min().Sub(testCase.min).ShorterThanOrEqual(0), "min should be %v but is %v", testCase.min, transformed.Min())
+		test.Assert(t, transformed.Max().Sub(testCase.max).ShorterThanOrEqual(0), "max should be %v but is %v", testCase.max, transformed.Max())
+	}
+}
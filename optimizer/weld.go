@@ -0,0 +1,138 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// weldCell is the position of a cell of the weldHash.
+type weldCell [3]data.Micrometer
+
+// weldHash is a spatial hash which finds the point within the weld distance of a point.
+// The space is divided into cubic cells with the weld distance as size,
+// so all points within the weld distance of a point lie in the cell of the point or in one of its 26 neighbours.
+// This also finds near points which lie on different sides of a cell border.
+type weldHash struct {
+	distance data.Micrometer
+	cells    map[weldCell][]int
+	points   []data.MicroVec3
+}
+
+func newWeldHash(distance data.Micrometer) *weldHash {
+	return &weldHash{
+		distance: distance,
+		cells:    map[weldCell][]int{},
+	}
+}
+
+// cell returns the cell of the point.
+// If the weld distance is 0, each position has its own cell so that only equal points are welded.
+func (h *weldHash) cell(p data.MicroVec3) weldCell {
+	if h.distance <= 0 {
+		return weldCell{p.X(), p.Y(), p.Z()}
+	}
+
+	return weldCell{floorDiv(p.X(), h.distance), floorDiv(p.Y(), h.distance), floorDiv(p.Z(), h.distance)}
+}
+
+// find returns the index of the nearest point added before which is within the weld distance of p.
+// If there is none, -1 is returned.
+func (h *weldHash) find(p data.MicroVec3) int {
+	c := h.cell(p)
+	if h.distance <= 0 {
+		if indices := h.cells[c]; len(indices) > 0 {
+			return indices[0]
+		}
+		return -1
+	}
+
+	found := -1
+	var foundDistance data.Micrometer
+	for x := c[0] - 1; x <= c[0]+1; x++ {
+		for y := c[1] - 1; y <= c[1]+1; y++ {
+			for z := c[2] - 1; z <= c[2]+1; z++ {
+				for _, index := range h.cells[weldCell{x, y, z}] {
+					difference := h.points[index].Sub(p)
+					if !difference.ShorterThanOrEqual(h.distance) {
+						continue
+					}
+
+					distance := difference.Size2()
+					if found == -1 || distance < foundDistance || (distance == foundDistance && index < found) {
+						found = index
+						foundDistance = distance
+					}
+				}
+			}
+		}
+	}
+
+	return found
+}
+
+// add adds the point and returns its index.
+func (h *weldHash) add(p data.MicroVec3) int {
+	index := len(h.points)
+	h.points = append(h.points, p)
+	c := h.cell(p)
+	h.cells[c] = append(h.cells[c], index)
+	return index
+}
+
+// floorDiv divides a by b and rounds down, also for negative values.
+func floorDiv(a, b data.Micrometer) data.Micrometer {
+	result := a / b
+	if (a%b != 0) && ((a < 0) != (b < 0)) {
+		result--
+	}
+	return result
+}
+
+// weldOpenEdges joins the vertices of open edges, which belong to only one face, if they are within the distance.
+// The vertices of closed edges are not moved, so that small details of an intact mesh are kept.
+// It returns the index of the vertex each vertex is joined to.
+func weldOpenEdges(vertices []data.MicroVec3, faces [][3]int, distance data.Micrometer) []int {
+	result := make([]int, len(vertices))
+	for i := range result {
+		result[i] = i
+	}
+	if distance <= 0 {
+		return result
+	}
+
+	edges := map[[2]int]int{}
+	for _, face := range faces {
+		for j := 0; j < 3; j++ {
+			a, b := face[j], face[(j+1)%3]
+			if a > b {
+				a, b = b, a
+			}
+			edges[[2]int{a, b}]++
+		}
+	}
+
+	open := make([]bool, len(vertices))
+	for edge, count := range edges {
+		if count == 1 {
+			open[edge[0]] = true
+			open[edge[1]] = true
+		}
+	}
+
+	hash := newWeldHash(distance)
+	// the vertex of each point of the hash
+	var welded []int
+	for vertex, p := range vertices {
+		if !open[vertex] {
+			continue
+		}
+
+		if index := hash.find(p); index != -1 {
+			result[vertex] = welded[index]
+			continue
+		}
+		hash.add(p)
+		welded = append(welded, vertex)
+	}
+
+	return result
+}
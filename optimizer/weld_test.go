@@ -0,0 +1,108 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+func TestWeldHash(t *testing.T) {
+	var testCases = map[string]struct {
+		distance data.Micrometer
+		points   []data.MicroVec3
+		expected []int
+	}{
+		"equal points": {
+			distance: 30,
+			points:   []data.MicroVec3{data.NewMicroVec3(10, 10, 10), data.NewMicroVec3(10, 10, 10)},
+			expected: []int{0, 0},
+		},
+		"near points across a cell border": {
+			distance: 30,
+			points:   []data.MicroVec3{data.NewMicroVec3(29, 0, 0), data.NewMicroVec3(31, 0, 0)},
+			expected: []int{0, 0},
+		},
+		"near negative points": {
+			distance: 30,
+			points:   []data.MicroVec3{data.NewMicroVec3(-1, -1, 0), data.NewMicroVec3(1, 1, 0)},
+			expected: []int{0, 0},
+		},
+		"far points": {
+			distance: 30,
+			points:   []data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(31, 0, 0)},
+			expected: []int{0, 1},
+		},
+		"nearest point is used": {
+			distance: 30,
+			points: []data.MicroVec3{
+				data.NewMicroVec3(0, 0, 0),
+				data.NewMicroVec3(50, 0, 0),
+				data.NewMicroVec3(30, 0, 0),
+			},
+			expected: []int{0, 1, 1},
+		},
+		"zero distance": {
+			distance: 0,
+			points:   []data.MicroVec3{data.NewMicroVec3(0, 0, 0), data.NewMicroVec3(1, 0, 0), data.NewMicroVec3(0, 0, 0)},
+			expected: []int{0, 1, 0},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		h := newWeldHash(testCase.distance)
+		var actual []int
+		for _, p := range testCase.points {
+			index := h.find(p)
+			if index == -1 {
+				index = h.add(p)
+			}
+			actual = append(actual, index)
+		}
+		test.Equals(t, testCase.expected, actual)
+	}
+}
+
+func TestWeldOpenEdges(t *testing.T) {
+	vertices := []data.MicroVec3{
+		// two triangles with a hairline crack between the edges 1-2 and 3-4
+		data.NewMicroVec3(0, 0, 0),
+		data.NewMicroVec3(1000, 0, 0),
+		data.NewMicroVec3(0, 1000, 0),
+		data.NewMicroVec3(1010, 0, 0),
+		data.NewMicroVec3(0, 1010, 0),
+		data.NewMicroVec3(1000, 1000, 0),
+		// a tiny closed tetrahedron whose vertices are within the weld distance
+		data.NewMicroVec3(5000, 5000, 0),
+		data.NewMicroVec3(5010, 5000, 0),
+		data.NewMicroVec3(5000, 5010, 0),
+		data.NewMicroVec3(5000, 5000, 10),
+	}
+	faces := [][3]int{
+		{0, 1, 2},
+		{3, 5, 4},
+		{6, 8, 7},
+		{6, 7, 9},
+		{7, 8, 9},
+		{8, 6, 9},
+	}
+
+	var testCases = map[string]struct {
+		distance data.Micrometer
+		expected []int
+	}{
+		"crack is welded": {
+			distance: 30,
+			expected: []int{0, 1, 2, 1, 2, 5, 6, 7, 8, 9},
+		},
+		"crack is wider than the distance": {
+			distance: 5,
+			expected: []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, weldOpenEdges(vertices, faces, testCase.distance))
+	}
+}
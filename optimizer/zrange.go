@@ -0,0 +1,413 @@
+// This file provides the optimization of big models in ranges of Z,
+// so that only the faces of the layers which are sliced at the moment are kept in memory.
+
+package optimizer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io/ioutil"
+	"math"
+	"os"
+	"sort"
+	"sync"
+)
+
+// zRangeFaceSize is the size of one face in the bucket file in bytes:
+// its index in the model and its nine coordinates, each as 32 bit integer.
+const zRangeFaceSize = 10 * 4
+
+// zRangeBufferSize is the size of the buffer of each bucket while the faces are written to the bucket file.
+const zRangeBufferSize = 64 * 1024
+
+// streamable returns true if the model is optimized in ranges of Z (see zRangeModel) instead of at once.
+// This is only done if the layers are processed in windows, as otherwise all faces are needed at once anyway.
+// Groups of models, bodies of several extruders, belt printers, non-planar layers and non-planar top surfaces
+// need all faces of the model, so they are always optimized at once.
+func (o optimizer) streamable(m data.Model) bool {
+	if o.options.GoSlice.LayerWindow <= 0 || m.FaceCount() == 0 {
+		return false
+	}
+	if _, ok := m.(data.ModelGroup); ok {
+		return false
+	}
+	if o.modelExtruders(m) != nil || o.options.Printer.Kinematics == "belt" || o.options.Print.NonPlanarTop.Enabled {
+		return false
+	}
+
+	return o.options.Slicing.Plane.Type == "" || o.options.Slicing.Plane.Type == "planar"
+}
+
+// optimizeInZRanges transforms the model and sorts its faces into buckets by Z instead of optimizing them at once.
+// The faces are read from the model twice, first to count the faces of each bucket and then to write them.
+// See zRangeModel.
+func (o optimizer) optimizeInZRanges(m data.Model) (data.OptimizedModel, error) {
+	translation := o.modelTransform(0)
+	source := m
+	m = o.transform(m, translation)
+
+	min := m.Min()
+	max := m.Max()
+	vectorOffset := o.vectorOffset(min, max, translation, false)
+	zm := &zRangeModel{
+		optimizer:    o,
+		source:       source,
+		model:        m,
+		vectorOffset: vectorOffset,
+		min:          min.Sub(vectorOffset),
+		max:          max.Sub(vectorOffset),
+		bucketsMin:   min.Z(),
+	}
+
+	// each bucket gets a continuous part of the file
+	counts := make([]int64, zm.bucketOf(max.Z())+1)
+	for i := 0; i < m.FaceCount(); i++ {
+		first, last := zm.faceBuckets(m.Face(i).Points())
+		for bucket := first; bucket <= last; bucket++ {
+			counts[bucket]++
+		}
+	}
+	zm.bucketStarts = make([]int64, len(counts)+1)
+	for bucket, count := range counts {
+		zm.bucketStarts[bucket+1] = zm.bucketStarts[bucket] + count
+	}
+
+	file, err := ioutil.TempFile("", "goslice-buckets-*")
+	if err != nil {
+		return nil, err
+	}
+	zm.file = file
+
+	err = zm.writeBuckets()
+	if err != nil {
+		zm.Close()
+		return nil, err
+	}
+
+	o.options.GoSlice.Logger.Printf("Sorted %v faces into %v buckets of %vmm, the faces are optimized for each layer window\n", m.FaceCount(), len(counts), zBucketSize.ToMillimeter())
+	return zm, nil
+}
+
+// zRangeModel is a data.ZRangeModel which keeps the faces sorted into buckets by Z in a temporary file.
+// ZRange loads the faces of the buckets overlapping a range and optimizes them like a whole model,
+// so that only the faces of the range are kept in memory.
+// The touching faces are only known within such a range, so the faces returned by OptimizedFace are not connected.
+// The optimized ranges contain no errors of the mesh, as they are not checked for the whole model.
+// Close removes the file.
+type zRangeModel struct {
+	optimizer optimizer
+
+	// source is the model before it is transformed, which may read its faces lazily (see Err).
+	source data.Model
+	// model provides the transformed faces before they are moved by vectorOffset.
+	model        data.Model
+	vectorOffset data.MicroVec3
+	min, max     data.MicroVec3
+
+	file *os.File
+
+	// bucketStarts contains the position of the first face of each bucket in the file
+	// and the amount of faces in the file as last entry.
+	// A face is written into each bucket it overlaps.
+	bucketStarts []int64
+
+	// bucketsMin is the Z of the bottom of the first bucket before the faces are moved.
+	bucketsMin data.Micrometer
+
+	// raycast contains the faces loaded by the last call to RaycastZ up to the bucket raycastLast.
+	mutex        sync.Mutex
+	raycast      *optimizedModel
+	raycastLast  int
+	raycastFirst int
+}
+
+// bucketOf returns the bucket containing the given Z before the faces are moved.
+func (zm *zRangeModel) bucketOf(z data.Micrometer) int {
+	if z < zm.bucketsMin {
+		return -1
+	}
+	return int((z - zm.bucketsMin) / zBucketSize)
+}
+
+// bucketZ returns the Z of the bottom of the bucket after the faces are moved.
+func (zm *zRangeModel) bucketZ(bucket int) data.Micrometer {
+	return zm.bucketsMin + data.Micrometer(bucket)*zBucketSize - zm.vectorOffset.Z()
+}
+
+// faceBuckets returns the first and the last bucket overlapped by the face before it is moved.
+func (zm *zRangeModel) faceBuckets(points [3]data.MicroVec3) (int, int) {
+	minZ := data.Min(points[0].Z(), data.Min(points[1].Z(), points[2].Z()))
+	maxZ := data.Max(points[0].Z(), data.Max(points[1].Z(), points[2].Z()))
+	return zm.bucketOf(minZ), zm.bucketOf(maxZ)
+}
+
+// writeBuckets writes each face together with its index into all buckets it overlaps.
+// The faces of each bucket are buffered, so that the file is written in big blocks.
+func (zm *zRangeModel) writeBuckets() error {
+	positions := make([]int64, len(zm.bucketStarts)-1)
+	copy(positions, zm.bucketStarts)
+	buffers := make([][]byte, len(positions))
+
+	flush := func(bucket int) error {
+		_, err := zm.file.WriteAt(buffers[bucket], positions[bucket]*zRangeFaceSize)
+		positions[bucket] += int64(len(buffers[bucket]) / zRangeFaceSize)
+		buffers[bucket] = buffers[bucket][:0]
+		return err
+	}
+
+	var record [zRangeFaceSize]byte
+	for i := 0; i < zm.model.FaceCount(); i++ {
+		points := zm.model.Face(i).Points()
+		binary.LittleEndian.PutUint32(record[0:], uint32(i))
+		for j, p := range points {
+			for k, coordinate := range [3]data.Micrometer{p.X(), p.Y(), p.Z()} {
+				if coordinate < math.MinInt32 || coordinate > math.MaxInt32 {
+					return fmt.Errorf("the coordinate %v is too big", coordinate.ToMillimeter())
+				}
+				binary.LittleEndian.PutUint32(record[4+(j*3+k)*4:], uint32(int32(coordinate)))
+			}
+		}
+
+		first, last := zm.faceBuckets(points)
+		for bucket := first; bucket <= last; bucket++ {
+			buffers[bucket] = append(buffers[bucket], record[:]...)
+			if len(buffers[bucket]) >= zRangeBufferSize {
+				if err := flush(bucket); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	for bucket := range buffers {
+		if err := flush(bucket); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ZRange loads and optimizes the faces of the buckets overlapping the range.
+// The buckets next to the range are loaded as well, so that the open edges at the border of the loaded faces
+// are too far away to change the welding of the faces within the range.
+func (zm *zRangeModel) ZRange(minZ, maxZ data.Micrometer) (data.OptimizedModel, error) {
+	first := zm.bucketOf(minZ+zm.vectorOffset.Z()) - 1
+	last := zm.bucketOf(maxZ+zm.vectorOffset.Z()) + 1
+	return zm.load(first, last)
+}
+
+// load loads and optimizes the faces of the buckets from first to last (both inclusive).
+// The faces keep their order in the whole model, so that they are sliced in the same way
+// as if the whole model was optimized at once.
+func (zm *zRangeModel) load(first, last int) (*optimizedModel, error) {
+	if first < 0 {
+		first = 0
+	}
+	if last > len(zm.bucketStarts)-2 {
+		last = len(zm.bucketStarts) - 2
+	}
+
+	var faces zRangeFaces
+	if first <= last {
+		start := zm.bucketStarts[first]
+		buffer := make([]byte, (zm.bucketStarts[last+1]-start)*zRangeFaceSize)
+		if _, err := zm.file.ReadAt(buffer, start*zRangeFaceSize); err != nil {
+			return nil, fmt.Errorf("could not load the faces from %v to %v: %w", zm.bucketZ(first), zm.bucketZ(last+1), err)
+		}
+
+		for bucket := first; bucket <= last; bucket++ {
+			for position := zm.bucketStarts[bucket]; position < zm.bucketStarts[bucket+1]; position++ {
+				record := buffer[(position-start)*zRangeFaceSize:]
+				face := zRangeFace{index: int(binary.LittleEndian.Uint32(record))}
+				for j := range face.points {
+					coordinate := func(k int) data.Micrometer {
+						return data.Micrometer(int32(binary.LittleEndian.Uint32(record[4+(j*3+k)*4:])))
+					}
+					face.points[j] = data.NewMicroVec3(coordinate(0), coordinate(1), coordinate(2))
+				}
+
+				// faces overlapping several buckets are in each of them, so they are only loaded from the first one
+				if faceFirst, _ := zm.faceBuckets(face.points); faceFirst != bucket && bucket != first {
+					continue
+				}
+				faces = append(faces, face)
+			}
+		}
+	}
+
+	sort.Slice(faces, func(i, j int) bool {
+		return faces[i].index < faces[j].index
+	})
+
+	om := &optimizedModel{}
+	zm.optimizer.addFaces(om, faces)
+	for i, point := range om.points {
+		om.points[i].pos = point.pos.Sub(zm.vectorOffset)
+	}
+	om.modelSize = zm.Size()
+
+	return om, nil
+}
+
+func (zm *zRangeModel) FaceCount() int {
+	return zm.model.FaceCount()
+}
+
+func (zm *zRangeModel) Face(index int) data.Face {
+	return zm.OptimizedFace(index)
+}
+
+// OptimizedFace returns the moved face of the model.
+// It is not connected to the touching faces, these are only known for the models returned by ZRange.
+func (zm *zRangeModel) OptimizedFace(index int) data.OptimizedFace {
+	face := zRangeFace{index: index}
+	for i, point := range zm.model.Face(index).Points() {
+		face.points[i] = point.Sub(zm.vectorOffset)
+	}
+	return face
+}
+
+// Err returns the error of the source model if some of its faces could not be read.
+// The faces returned by ZRange are loaded from the own file, so they are not affected.
+func (zm *zRangeModel) Err() error {
+	return data.ModelErr(zm.source)
+}
+
+func (zm *zRangeModel) Size() data.MicroVec3 {
+	return zm.max.Sub(zm.min)
+}
+
+func (zm *zRangeModel) Min() data.MicroVec3 {
+	return zm.min.Copy()
+}
+
+func (zm *zRangeModel) Max() data.MicroVec3 {
+	return zm.max.Copy()
+}
+
+// RaycastZ loads the buckets at maxZ and then the buckets below until the ray hits a face.
+// The buckets at maxZ are kept for the next ray, as the rays of one layer mostly start at the same height.
+func (zm *zRangeModel) RaycastZ(p data.MicroPoint, maxZ data.Micrometer) (data.Micrometer, bool) {
+	zm.mutex.Lock()
+	defer zm.mutex.Unlock()
+
+	last := zm.bucketOf(maxZ + zm.vectorOffset.Z())
+	if last > len(zm.bucketStarts)-2 {
+		last = len(zm.bucketStarts) - 2
+	}
+	if last < 0 {
+		return 0, false
+	}
+
+	if zm.raycast == nil || zm.raycastLast != last {
+		loaded, err := zm.load(last-1, last)
+		if err != nil {
+			zm.optimizer.options.GoSlice.Logger.Printf("Could not cast the ray: %v\n", err)
+			return 0, false
+		}
+		zm.raycast = loaded
+		zm.raycastLast = last
+		zm.raycastFirst = last - 1
+		if zm.raycastFirst < 0 {
+			zm.raycastFirst = 0
+		}
+	}
+
+	hitZ, hit := zm.raycast.RaycastZ(p, maxZ)
+	// the faces which are not loaded yet end below the lowest loaded bucket
+	for bucket := zm.raycastFirst - 1; bucket >= 0 && !(hit && hitZ >= zm.bucketZ(bucket+1)); bucket-- {
+		loaded, err := zm.load(bucket, bucket)
+		if err != nil {
+			zm.optimizer.options.GoSlice.Logger.Printf("Could not cast the ray: %v\n", err)
+			return hitZ, hit
+		}
+		if z, ok := loaded.RaycastZ(p, maxZ); ok && (!hit || z > hitZ) {
+			hitZ, hit = z, true
+		}
+	}
+
+	return hitZ, hit
+}
+
+// SaveDebugSTL loads all faces and saves them.
+func (zm *zRangeModel) SaveDebugSTL(filename string) error {
+	all, err := zm.load(0, len(zm.bucketStarts)-2)
+	if err != nil {
+		return err
+	}
+	return all.SaveDebugSTL(filename)
+}
+
+// Close removes the bucket file. It can be called several times.
+func (zm *zRangeModel) Close() error {
+	if zm.file == nil {
+		return nil
+	}
+
+	name := zm.file.Name()
+	err := zm.file.Close()
+	zm.file = nil
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// zRangeFace is a face of a zRangeModel together with its index in the model.
+// As data.OptimizedFace it has no touching faces.
+type zRangeFace struct {
+	index  int
+	points [3]data.MicroVec3
+}
+
+func (f zRangeFace) Points() [3]data.MicroVec3 {
+	return f.points
+}
+
+func (f zRangeFace) TouchingFaceIndices() [3]int {
+	return [3]int{-1, -1, -1}
+}
+
+func (f zRangeFace) IndirectTouchingFaceIndices() []int {
+	return nil
+}
+
+func (f zRangeFace) MinZ() data.Micrometer {
+	return data.Min(f.points[0].Z(), data.Min(f.points[1].Z(), f.points[2].Z()))
+}
+
+func (f zRangeFace) MaxZ() data.Micrometer {
+	return data.Max(f.points[0].Z(), data.Max(f.points[1].Z(), f.points[2].Z()))
+}
+
+// zRangeFaces is a data.Model of the faces loaded from the bucket file.
+type zRangeFaces []zRangeFace
+
+func (f zRangeFaces) FaceCount() int {
+	return len(f)
+}
+
+func (f zRangeFaces) Face(index int) data.Face {
+	return f[index]
+}
+
+func (f zRangeFaces) Min() data.MicroVec3 {
+	min := f[0].points[0].Copy()
+	for _, face := range f {
+		for _, p := range face.points {
+			min = data.NewMicroVec3(data.Min(min.X(), p.X()), data.Min(min.Y(), p.Y()), data.Min(min.Z(), p.Z()))
+		}
+	}
+	return min
+}
+
+func (f zRangeFaces) Max() data.MicroVec3 {
+	max := f[0].points[0].Copy()
+	for _, face := range f {
+		for _, p := range face.points {
+			max = data.NewMicroVec3(data.Max(max.X(), p.X()), data.Max(max.Y(), p.Y()), data.Max(max.Z(), p.Z()))
+		}
+	}
+	return max
+}
@@ -0,0 +1,111 @@
+package optimizer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"os"
+	"testing"
+)
+
+func TestZRange(t *testing.T) {
+	model := boundedModel{append(
+		cuboid(0, 0, 0, 10000, 10000, 1000),
+		cuboid(2000, 2000, 1000, 8000, 8000, 15000)...,
+	)}
+
+	options := data.DefaultOptions()
+	whole, err := NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+
+	options.GoSlice.LayerWindow = 10
+	m, err := NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+	ranged, ok := m.(data.ZRangeModel)
+	test.Assert(t, ok, "the model should be optimized in ranges if the layers are processed in windows")
+	defer m.(*zRangeModel).Close()
+
+	test.Equals(t, vec3(whole.Size()), vec3(m.Size()))
+	test.Equals(t, vec3(whole.Min()), vec3(m.Min()))
+	test.Equals(t, vec3(whole.Max()), vec3(m.Max()))
+	test.Equals(t, whole.FaceCount(), m.FaceCount())
+
+	var testCases = map[string]struct {
+		minZ, maxZ data.Micrometer
+	}{
+		"all":              {minZ: 0, maxZ: 15000},
+		"bottom":           {minZ: 0, maxZ: 0},
+		"within one face":  {minZ: 5000, maxZ: 6000},
+		"several buckets":  {minZ: 500, maxZ: 9000},
+		"top":              {minZ: 15000, maxZ: 15000},
+		"at bucket border": {minZ: 4000, maxZ: 4000},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		part, err := ranged.ZRange(testCase.minZ, testCase.maxZ)
+		test.Ok(t, err)
+		test.Equals(t, vec3(whole.Size()), vec3(part.Size()))
+
+		loaded := map[[3][3]data.Micrometer]bool{}
+		for i := 0; i < part.FaceCount(); i++ {
+			face := part.OptimizedFace(i)
+			points := face.Points()
+			loaded[[3][3]data.Micrometer{vec3(points[0]), vec3(points[1]), vec3(points[2])}] = true
+
+			// the edges within the range are connected to the touching faces, so that the slicer can follow them
+			for j, touching := range face.TouchingFaceIndices() {
+				a, b := points[j].Z(), points[(j+1)%3].Z()
+				if data.Min(a, b) <= testCase.maxZ && data.Max(a, b) >= testCase.minZ {
+					test.Assert(t, touching != -1, "the edge %v of the face %v within the range should be connected", j, i)
+				}
+			}
+		}
+
+		for i := 0; i < whole.FaceCount(); i++ {
+			face := whole.OptimizedFace(i)
+			if face.MinZ() > testCase.maxZ || face.MaxZ() < testCase.minZ {
+				continue
+			}
+			points := face.Points()
+			test.Assert(t, loaded[[3][3]data.Micrometer{vec3(points[0]), vec3(points[1]), vec3(points[2])}], "the face %v overlapping the range should be loaded", i)
+		}
+	}
+}
+
+func TestZRangeRaycastZ(t *testing.T) {
+	// the ramp rises over several buckets
+	model := boundedModel{ramp(0, 0, 10000, 10000, 1000, 9000)}
+
+	options := data.DefaultOptions()
+	whole, err := NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+
+	options.GoSlice.LayerWindow = 10
+	m, err := NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+	defer m.(*zRangeModel).Close()
+
+	center := options.Printer.Center.PointXY()
+	for _, x := range []data.Micrometer{-4000, -1000, 0, 3000, 6000} {
+		for _, maxZ := range []data.Micrometer{-1, 500, 3000, 8000, 20000} {
+			p := center.Add(data.NewMicroPoint(x, 0))
+			expectedZ, expectedHit := whole.RaycastZ(p, maxZ)
+			z, hit := m.RaycastZ(p, maxZ)
+			test.Assert(t, hit == expectedHit && z == expectedZ, "the ray at %v from %v should hit %v at %v but hit %v at %v", x, maxZ, expectedHit, expectedZ, hit, z)
+		}
+	}
+}
+
+func TestZRangeModelClose(t *testing.T) {
+	options := data.DefaultOptions()
+	options.GoSlice.LayerWindow = 10
+	m, err := NewOptimizer(&options).Optimize(boundedModel{cuboid(0, 0, 0, 10000, 10000, 10000)})
+	test.Ok(t, err)
+
+	zm := m.(*zRangeModel)
+	name := zm.file.Name()
+	test.Ok(t, zm.Close())
+	_, err = os.Stat(name)
+	test.Assert(t, os.IsNotExist(err), "the bucket file should be removed but got %v", err)
+	test.Ok(t, zm.Close())
+}
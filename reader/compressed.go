@@ -0,0 +1,87 @@
+// This file provides the transparent decompression of gzip and zip compressed models.
+
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"io/ioutil"
+	"path"
+	"strings"
+)
+
+// readGZIP decompresses a gzip stream and reads the contained model.
+// The format is the format of the decompressed model, e.g. "stl" for ".stl.gz" files.
+// If it is empty, the format is taken from the original file name stored in the gzip header.
+// The unit is passed to reader.readFormat.
+func (r reader) readGZIP(input io.Reader, format string, unit float64) (data.Model, error) {
+	gz, err := gzip.NewReader(input)
+	if err != nil {
+		return nil, fmt.Errorf("gzip: %w", err)
+	}
+	defer gz.Close()
+
+	if format == "" {
+		format = path.Ext(gz.Name)
+	}
+
+	return r.readFormat(gz, format, unit)
+}
+
+// readZIP reads the model contained in a zip archive.
+// The archive has to contain exactly one stl, obj, ply, 3mf or step file.
+// Other files such as readmes or pictures and directories are ignored.
+// If it contains none of them but is a 3mf package itself, it is read as 3mf.
+// The unit is passed to reader.readFormat.
+func (r reader) readZIP(input io.Reader, unit float64) (data.Model, error) {
+	// zip needs random access, so the whole archive is read into memory
+	content, err := ioutil.ReadAll(input)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+
+	var mesh *zip.File
+	for _, file := range archive.File {
+		if file.FileInfo().IsDir() || strings.HasPrefix(file.Name, "__MACOSX/") {
+			continue
+		}
+
+		switch strings.ToLower(path.Ext(file.Name)) {
+		case ".stl", ".obj", ".ply", ".3mf", ".step", ".stp":
+			if mesh != nil {
+				return nil, fmt.Errorf("zip: the archive contains several meshes (%s and %s)", mesh.Name, file.Name)
+			}
+			mesh = file
+		}
+	}
+
+	if mesh == nil {
+		// 3mf files are zip archives themselves
+		for _, file := range archive.File {
+			name := strings.TrimPrefix(file.Name, "/")
+			if name == threeMFDefaultModelPath || name == "_rels/.rels" {
+				return readThreeMF(bytes.NewReader(content), unit)
+			}
+		}
+
+		return nil, errors.New("zip: the archive does not contain a stl, obj, ply, 3mf or step file")
+	}
+
+	file, err := mesh.Open()
+	if err != nil {
+		return nil, fmt.Errorf("zip: %w", err)
+	}
+	defer file.Close()
+
+	return r.readFormat(file, path.Ext(mesh.Name), unit)
+}
@@ -0,0 +1,110 @@
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+const testASCIISTL = "solid facet normal 0 0 1 outer loop vertex 0 0 0 vertex 1 0 0 vertex 0 1 0 endloop endfacet endsolid"
+
+func gzipped(t *testing.T, name, content string) []byte {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	w.Name = name
+	_, err := w.Write([]byte(content))
+	test.Ok(t, err)
+	test.Ok(t, w.Close())
+	return buf.Bytes()
+}
+
+func zipped(t *testing.T, files map[string]string) []byte {
+	var buf bytes.Buffer
+	w := zip.NewWriter(&buf)
+	for name, content := range files {
+		f, err := w.Create(name)
+		test.Ok(t, err)
+		_, err = f.Write([]byte(content))
+		test.Ok(t, err)
+	}
+	test.Ok(t, w.Close())
+	return buf.Bytes()
+}
+
+func TestReadCompressed(t *testing.T) {
+	options := data.DefaultOptions()
+	r := reader{options: &options}
+
+	var testCases = map[string]struct {
+		format        string
+		input         []byte
+		expectedError string
+	}{
+		"stl.gz": {
+			format: ".stl.gz",
+			input:  gzipped(t, "", testASCIISTL),
+		},
+		"gz with name in header": {
+			format: "gz",
+			input:  gzipped(t, "model.obj", "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"),
+		},
+		"no gzip is read by its content": {
+			format: "stl.gz",
+			input:  []byte(testASCIISTL),
+		},
+		"broken gzip": {
+			format:        "stl.gz",
+			input:         []byte{0x1f, 0x8b, 0x08},
+			expectedError: "gzip:",
+		},
+		"gzip with other extension": {
+			format: "stl",
+			input:  gzipped(t, "model.stl", testASCIISTL),
+		},
+		"zip": {
+			format: ".ZIP",
+			input: zipped(t, map[string]string{
+				"readme.txt":           "a model",
+				"models/":              "",
+				"models/model.stl":     testASCIISTL,
+				"__MACOSX/._model.stl": "metadata",
+			}),
+		},
+		"zip with several meshes": {
+			format: "zip",
+			input: zipped(t, map[string]string{
+				"a.stl": testASCIISTL,
+				"b.stl": testASCIISTL,
+			}),
+			expectedError: "several meshes",
+		},
+		"3mf without extension": {
+			format: "stl",
+			input: zipped(t, map[string]string{
+				"3D/3dmodel.model": strings.Replace(testThreeMFModel, `<item objectid="2" transform="0 1 0 -1 0 0 0 0 1 5 0 0"/>`, "", 1),
+			}),
+		},
+		"zip without mesh": {
+			format: "zip",
+			input: zipped(t, map[string]string{
+				"readme.txt": "a model",
+			}),
+			expectedError: "does not contain",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := r.ReadStream(bytes.NewReader(testCase.input), testCase.format)
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected, got %v", err)
+		} else {
+			test.Ok(t, err)
+			test.Equals(t, 1, model.FaceCount())
+		}
+	}
+}
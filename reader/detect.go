@@ -0,0 +1,111 @@
+// This file provides the detection of the format of a model by its content.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// detectSize is the amount of bytes read from the beginning of a model to detect its format.
+const detectSize = 512
+
+// peekHead reads the beginning of the input.
+// It returns the read bytes and a reader which still starts at the beginning of the input.
+// Inputs which can seek, e.g. files, are moved back, so that their size can still be determined by the format readers.
+func peekHead(input io.Reader) ([]byte, io.Reader, error) {
+	head := make([]byte, detectSize)
+	n, err := io.ReadFull(input, head)
+	if err != nil && err != io.EOF && err != io.ErrUnexpectedEOF {
+		return nil, nil, err
+	}
+	head = head[:n]
+
+	if seeker, ok := input.(io.Seeker); ok {
+		if _, err := seeker.Seek(int64(-n), io.SeekCurrent); err == nil {
+			return head, input, nil
+		}
+	}
+
+	return head, io.MultiReader(bytes.NewReader(head), input), nil
+}
+
+// detectFormat detects the format of a model by the magic bytes or keywords at the beginning of its content.
+// It returns the name of the format as used by reader.readFormat, e.g. "obj",
+// or an empty string if the format cannot be detected.
+// Binary STL files have no magic bytes, they are detected by the null bytes they nearly always contain.
+// 3mf files are detected as "zip", as they are zip archives.
+func detectFormat(head []byte) string {
+	switch {
+	case bytes.HasPrefix(head, []byte{0x1f, 0x8b}):
+		return "gz"
+	case bytes.HasPrefix(head, []byte("PK\x03\x04")):
+		return "zip"
+	case bytes.HasPrefix(head, []byte("ply\n")) || bytes.HasPrefix(head, []byte("ply\r\n")):
+		return "ply"
+	}
+
+	// all other formats except binary STL are text formats
+	if bytes.IndexByte(head, 0) >= 0 {
+		return "stl"
+	}
+
+	text := bytes.TrimLeft(head, " \t\r\n\ufeff")
+	switch {
+	case bytes.HasPrefix(text, []byte("solid")):
+		return "stl"
+	case bytes.HasPrefix(text, []byte("ISO-10303-21")):
+		return "step"
+	case bytes.HasPrefix(text, []byte("<")) && bytes.Contains(head, []byte("<svg")):
+		return "svg"
+	}
+
+	var lines []string
+	scanner := bufio.NewScanner(bytes.NewReader(text))
+	for scanner.Scan() && len(lines) < 2 {
+		lines = append(lines, strings.TrimSpace(scanner.Text()))
+	}
+	if len(lines) == 0 {
+		return ""
+	}
+
+	// dxf files consist of pairs of a group code and a value and start with a section or a comment
+	if len(lines) == 2 && ((lines[0] == "0" && lines[1] == "SECTION") || lines[0] == "999") {
+		return "dxf"
+	}
+
+	fields := strings.Fields(lines[0])
+	if len(fields) == 0 {
+		return ""
+	}
+	if strings.HasPrefix(fields[0], "#") {
+		return "obj"
+	}
+	switch fields[0] {
+	case "v", "vn", "vt", "f", "o", "g", "s", "mtllib", "usemtl":
+		return "obj"
+	}
+
+	return ""
+}
+
+// contentFormat returns the format which is used to read a model with the given format (e.g. from the file extension)
+// and the given beginning of its content. The detected format is used if it differs from the given one.
+func contentFormat(format string, head []byte) string {
+	detected := detectFormat(head)
+	switch {
+	case detected == "":
+		return format
+	case detected == "gz" && (format == "gz" || strings.HasSuffix(format, ".gz")):
+		// keep the format of the compressed model
+		return format
+	case detected == "zip" && format == "3mf":
+		return format
+	case detected == "step" && format == "stp":
+		return format
+	}
+
+	return detected
+}
@@ -0,0 +1,159 @@
+package reader
+
+import (
+	"bytes"
+	"github.com/aligator/goslice/util/test"
+	"io"
+	"io/ioutil"
+	"strings"
+	"testing"
+)
+
+func TestDetectFormat(t *testing.T) {
+	binarySTL := append([]byte("solid exported binary"), make([]byte, 100)...)
+
+	var testCases = map[string]struct {
+		head     []byte
+		expected string
+	}{
+		"gzip": {
+			head:     []byte{0x1f, 0x8b, 0x08, 0x00},
+			expected: "gz",
+		},
+		"zip": {
+			head:     []byte("PK\x03\x04rest"),
+			expected: "zip",
+		},
+		"binary ply": {
+			head:     append([]byte("ply\r\nformat binary_little_endian 1.0\n"), 0, 0, 0),
+			expected: "ply",
+		},
+		"ascii stl": {
+			head:     []byte("  solid cube\nfacet normal 0 0 1"),
+			expected: "stl",
+		},
+		"binary stl starting with solid": {
+			head:     binarySTL,
+			expected: "stl",
+		},
+		"step": {
+			head:     []byte("ISO-10303-21;\nHEADER;"),
+			expected: "step",
+		},
+		"svg": {
+			head:     []byte("<?xml version=\"1.0\"?>\n<svg xmlns=\"http://www.w3.org/2000/svg\">"),
+			expected: "svg",
+		},
+		"other xml": {
+			head:     []byte("<?xml version=\"1.0\"?>\n<model>"),
+			expected: "",
+		},
+		"dxf": {
+			head:     []byte("  0\r\nSECTION\r\n  2\r\nHEADER\r\n"),
+			expected: "dxf",
+		},
+		"dxf with comment": {
+			head:     []byte("999\ncreated by hand\n  0\nSECTION\n"),
+			expected: "dxf",
+		},
+		"obj": {
+			head:     []byte("v 0 0 0\nv 1 0 0\n"),
+			expected: "obj",
+		},
+		"obj with comment": {
+			head:     []byte("# Blender v2.90\nmtllib model.mtl\n"),
+			expected: "obj",
+		},
+		"empty": {
+			head:     nil,
+			expected: "",
+		},
+		"unknown text": {
+			head:     []byte("hello world"),
+			expected: "",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, detectFormat(testCase.head))
+	}
+}
+
+func TestContentFormat(t *testing.T) {
+	var testCases = map[string]struct {
+		format   string
+		head     string
+		expected string
+	}{
+		"wrong extension": {
+			format:   "stl",
+			head:     "v 0 0 0\n",
+			expected: "obj",
+		},
+		"not detectable": {
+			format:   "ply",
+			head:     "",
+			expected: "ply",
+		},
+		"binary stl with wrong extension": {
+			format:   "obj",
+			head:     "Exported from Blender\x00\x00",
+			expected: "stl",
+		},
+		"compressed stl": {
+			format:   "stl.gz",
+			head:     "\x1f\x8b",
+			expected: "stl.gz",
+		},
+		"3mf is a zip": {
+			format:   "3mf",
+			head:     "PK\x03\x04",
+			expected: "3mf",
+		},
+		"stp": {
+			format:   "stp",
+			head:     "ISO-10303-21;",
+			expected: "stp",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		test.Equals(t, testCase.expected, contentFormat(testCase.format, []byte(testCase.head)))
+	}
+}
+
+func TestPeekHead(t *testing.T) {
+	content := strings.Repeat("0123456789", 100)
+
+	var testCases = map[string]struct {
+		input      func() io.Reader
+		expectSame bool
+	}{
+		"seeker": {
+			input: func() io.Reader {
+				return strings.NewReader(content)
+			},
+			expectSame: true,
+		},
+		"stream": {
+			input: func() io.Reader {
+				return ioutil.NopCloser(bytes.NewBufferString(content))
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		input := testCase.input()
+		head, r, err := peekHead(input)
+		test.Ok(t, err)
+		test.Equals(t, content[:detectSize], string(head))
+		test.Equals(t, testCase.expectSame, r == input)
+
+		all, err := ioutil.ReadAll(r)
+		test.Ok(t, err)
+		test.Equals(t, content, string(all))
+	}
+}
@@ -0,0 +1,305 @@
+// This file provides a reader for ASCII DXF files.
+//
+// Closed polylines, circles and ellipses are read as outlines. Lines, arcs and open polylines
+// are joined to outlines if their ends meet. All outlines are extruded to the configured height.
+// Blocks, splines and texts are ignored.
+
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// dxfJoinTolerance is the max distance in mm between the ends of two segments which are joined.
+const dxfJoinTolerance = 0.001
+
+// dxfUnits maps the $INSUNITS values of the DXF header to the factor to convert them to mm.
+var dxfUnits = map[int]float64{
+	1: 25.4,
+	2: 304.8,
+	4: 1,
+	5: 10,
+	6: 1000,
+}
+
+// dxfPair is one group code with its value.
+type dxfPair struct {
+	code  int
+	value string
+}
+
+// dxfEntity is an entity of the ENTITIES section with all its group codes.
+type dxfEntity struct {
+	name  string
+	pairs []dxfPair
+}
+
+func (e dxfEntity) float(code int) float64 {
+	for _, pair := range e.pairs {
+		if pair.code == code {
+			value, _ := strconv.ParseFloat(pair.value, 64)
+			return value
+		}
+	}
+	return 0
+}
+
+func (e dxfEntity) integer(code int) int {
+	return int(e.float(code))
+}
+
+// vertices returns the vertices of a LWPOLYLINE or VERTEX with their bulge.
+func (e dxfEntity) vertices() (points [][2]float64, bulges []float64) {
+	for _, pair := range e.pairs {
+		value, _ := strconv.ParseFloat(pair.value, 64)
+		switch pair.code {
+		case 10:
+			points = append(points, [2]float64{value, 0})
+			bulges = append(bulges, 0)
+		case 20:
+			if len(points) > 0 {
+				points[len(points)-1][1] = value
+			}
+		case 42:
+			if len(bulges) > 0 {
+				bulges[len(bulges)-1] = value
+			}
+		}
+	}
+	return points, bulges
+}
+
+// readDXF reads the outlines of a DXF file and extrudes them to the given height in mm.
+// If unit is bigger than 0, the coordinates are interpreted in this unit instead of the unit defined by the file.
+func readDXF(r io.Reader, height float64, unit float64) (data.Model, error) {
+	pairs, err := readDXFPairs(r)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := 1.0
+	var entities []dxfEntity
+	section := ""
+	for i := 0; i < len(pairs); i++ {
+		pair := pairs[i]
+		switch {
+		case pair.code == 0 && pair.value == "SECTION" && i+1 < len(pairs):
+			section = pairs[i+1].value
+			i++
+		case pair.code == 0 && pair.value == "ENDSEC":
+			section = ""
+		case pair.code == 9 && pair.value == "$INSUNITS" && i+1 < len(pairs):
+			insUnits, _ := strconv.Atoi(pairs[i+1].value)
+			if factor, ok := dxfUnits[insUnits]; ok {
+				scale = factor
+			}
+			i++
+		case section == "ENTITIES" && pair.code == 0:
+			entities = append(entities, dxfEntity{name: pair.value})
+		case section == "ENTITIES" && len(entities) > 0:
+			entities[len(entities)-1].pairs = append(entities[len(entities)-1].pairs, pair)
+		}
+	}
+	if unit > 0 {
+		scale = unit
+	}
+
+	var outlines []outline
+	var segments []outline
+	for i := 0; i < len(entities); i++ {
+		entity := entities[i]
+		switch entity.name {
+		case "LWPOLYLINE":
+			points, bulges := entity.vertices()
+			path, closed := dxfPolyline(points, bulges, entity.integer(70)&1 != 0, scale)
+			if closed {
+				outlines = append(outlines, path)
+			} else {
+				segments = append(segments, path)
+			}
+		case "POLYLINE":
+			var points [][2]float64
+			var bulges []float64
+			for i+1 < len(entities) && entities[i+1].name == "VERTEX" {
+				i++
+				vertex, bulge := entities[i].vertices()
+				points = append(points, vertex...)
+				bulges = append(bulges, bulge...)
+			}
+			path, closed := dxfPolyline(points, bulges, entity.integer(70)&1 != 0, scale)
+			if closed {
+				outlines = append(outlines, path)
+			} else {
+				segments = append(segments, path)
+			}
+		case "LINE":
+			segments = append(segments, outline{
+				{entity.float(10) * scale, entity.float(20) * scale},
+				{entity.float(11) * scale, entity.float(21) * scale},
+			})
+		case "ARC":
+			center := [2]float64{entity.float(10), entity.float(20)}
+			start, end := data.ToRadians(entity.float(50)), data.ToRadians(entity.float(51))
+			if end <= start {
+				end += 2 * math.Pi
+			}
+			segments = append(segments, dxfArc(center, entity.float(40), start, end, scale))
+		case "CIRCLE":
+			center := [2]float64{entity.float(10), entity.float(20)}
+			circle := dxfArc(center, entity.float(40), 0, 2*math.Pi, scale)
+			outlines = append(outlines, circle[:len(circle)-1])
+		case "ELLIPSE":
+			outlines = append(outlines, dxfEllipse(entity, scale))
+		}
+	}
+
+	outlines = append(outlines, joinSegments(segments)...)
+	return extrudeOutlines(outlines, height)
+}
+
+// readDXFPairs reads all group codes and values.
+func readDXFPairs(r io.Reader) ([]dxfPair, error) {
+	scanner := bufio.NewScanner(r)
+	var pairs []dxfPair
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		code, err := strconv.Atoi(strings.TrimSpace(scanner.Text()))
+		if err != nil {
+			return nil, fmt.Errorf("line %v: invalid group code %q, only ASCII DXF files are supported", lineNr, scanner.Text())
+		}
+		if !scanner.Scan() {
+			return nil, fmt.Errorf("line %v: the value of the group code %v is missing", lineNr, code)
+		}
+		lineNr++
+		pairs = append(pairs, dxfPair{code: code, value: strings.TrimSpace(scanner.Text())})
+	}
+	return pairs, scanner.Err()
+}
+
+// dxfPolyline returns the points of a polyline in mm including the arcs defined by the bulges.
+// A polyline is also closed if its last point equals the first one.
+func dxfPolyline(points [][2]float64, bulges []float64, closed bool, scale float64) (outline, bool) {
+	var result outline
+	for i, p := range points {
+		result = append(result, [2]float64{p[0] * scale, p[1] * scale})
+		if i+1 == len(points) && !closed {
+			break
+		}
+		if bulges[i] == 0 {
+			continue
+		}
+
+		// The bulge is the tangent of a quarter of the included angle of the arc to the next point.
+		next := points[(i+1)%len(points)]
+		angle := 4 * math.Atan(bulges[i])
+		chord := math.Hypot(next[0]-p[0], next[1]-p[1])
+		if chord == 0 {
+			continue
+		}
+		radius := chord / 2 / math.Sin(angle/2)
+		// the center lies on the perpendicular bisector of the chord
+		middle := [2]float64{(p[0] + next[0]) / 2, (p[1] + next[1]) / 2}
+		distance := radius * math.Cos(angle/2)
+		normal := [2]float64{-(next[1] - p[1]) / chord, (next[0] - p[0]) / chord}
+		center := [2]float64{middle[0] + normal[0]*distance, middle[1] + normal[1]*distance}
+		start := math.Atan2(p[1]-center[1], p[0]-center[0])
+
+		arc := dxfArc(center, math.Abs(radius), start, start+angle, scale)
+		result = append(result, arc[1:len(arc)-1]...)
+	}
+
+	if len(result) > 2 && result[0] == result[len(result)-1] {
+		closed = true
+	}
+	return result, closed
+}
+
+// dxfArc returns the points of an arc in mm from the start angle to the end angle in radians including both ends.
+func dxfArc(center [2]float64, radius float64, start, end float64, scale float64) outline {
+	segments := int(math.Max(math.Ceil(math.Abs(end-start)/segmentAngle(radius*scale)), 1))
+	result := make(outline, segments+1)
+	for s := range result {
+		sin, cos := math.Sincos(start + (end-start)*float64(s)/float64(segments))
+		result[s] = [2]float64{(center[0] + radius*cos) * scale, (center[1] + radius*sin) * scale}
+	}
+	return result
+}
+
+// dxfEllipse returns the points of a full ellipse in mm.
+func dxfEllipse(entity dxfEntity, scale float64) outline {
+	center := [2]float64{entity.float(10), entity.float(20)}
+	major := [2]float64{entity.float(11), entity.float(21)}
+	ratio := entity.float(40)
+	length := math.Hypot(major[0], major[1])
+	minor := [2]float64{-major[1] * ratio, major[0] * ratio}
+
+	segments := int(math.Ceil(2 * math.Pi / segmentAngle(length*scale)))
+	result := make(outline, segments)
+	for s := range result {
+		sin, cos := math.Sincos(2 * math.Pi * float64(s) / float64(segments))
+		result[s] = [2]float64{
+			(center[0] + major[0]*cos + minor[0]*sin) * scale,
+			(center[1] + major[1]*cos + minor[1]*sin) * scale,
+		}
+	}
+	return result
+}
+
+// joinSegments joins open segments whose ends meet to closed outlines.
+// Segments which cannot be closed are ignored.
+func joinSegments(segments []outline) []outline {
+	near := func(a, b [2]float64) bool {
+		return math.Hypot(a[0]-b[0], a[1]-b[1]) <= dxfJoinTolerance
+	}
+
+	used := make([]bool, len(segments))
+	var result []outline
+	for i, segment := range segments {
+		if used[i] || len(segment) < 2 {
+			continue
+		}
+		used[i] = true
+		path := append(outline{}, segment...)
+
+		for !near(path[0], path[len(path)-1]) {
+			found := false
+			for j, other := range segments {
+				if used[j] || len(other) < 2 {
+					continue
+				}
+
+				if near(path[len(path)-1], other[len(other)-1]) {
+					reversed := make(outline, len(other))
+					for k, p := range other {
+						reversed[len(other)-1-k] = p
+					}
+					other = reversed
+				} else if !near(path[len(path)-1], other[0]) {
+					continue
+				}
+
+				used[j] = true
+				path = append(path, other[1:]...)
+				found = true
+				break
+			}
+
+			if !found {
+				break
+			}
+		}
+
+		if len(path) > 2 && near(path[0], path[len(path)-1]) {
+			result = append(result, path[:len(path)-1])
+		}
+	}
+
+	return result
+}
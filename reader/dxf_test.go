@@ -0,0 +1,99 @@
+package reader
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+// dxf joins the group codes and values to the content of a DXF file.
+func dxf(pairs ...string) string {
+	return strings.Join(pairs, "\n") + "\n"
+}
+
+func TestReadDXF(t *testing.T) {
+	var testCases = map[string]struct {
+		dxf           string
+		unit          float64
+		expectedError string
+		expectedMin   data.MicroVec3
+		expectedMax   data.MicroVec3
+	}{
+		"closed polyline with circular hole": {
+			dxf: dxf(
+				"0", "SECTION", "2", "ENTITIES",
+				"0", "LWPOLYLINE", "90", "4", "70", "1",
+				"10", "0", "20", "0",
+				"10", "30", "20", "0",
+				"10", "30", "20", "20",
+				"10", "0", "20", "20",
+				"0", "CIRCLE", "10", "15", "20", "10", "40", "5",
+				"0", "ENDSEC", "0", "EOF",
+			),
+			expectedMin: data.NewMicroVec3(0, 0, 0),
+			expectedMax: data.NewMicroVec3(30000, 20000, 2000),
+		},
+		"lines and arc joined in inch": {
+			dxf: dxf(
+				"0", "SECTION", "2", "HEADER", "9", "$INSUNITS", "70", "1", "0", "ENDSEC",
+				"0", "SECTION", "2", "ENTITIES",
+				"0", "LINE", "10", "0", "20", "0", "11", "2", "21", "0",
+				"0", "LINE", "10", "0", "20", "0", "11", "0", "21", "1",
+				"0", "ARC", "10", "1", "20", "1", "40", "1", "50", "0", "51", "180",
+				"0", "LINE", "10", "2", "20", "1", "11", "2", "21", "0",
+				"0", "ENDSEC", "0", "EOF",
+			),
+			expectedMin: data.NewMicroVec3(0, 0, 0),
+			expectedMax: data.NewMicroVec3(50800, 50800, 2000),
+		},
+		"polyline with bulge": {
+			dxf: dxf(
+				"0", "SECTION", "2", "ENTITIES",
+				"0", "LWPOLYLINE", "90", "2", "70", "1",
+				"10", "0", "20", "0", "42", "1",
+				"10", "10", "20", "0", "42", "1",
+				"0", "ENDSEC", "0", "EOF",
+			),
+			expectedMin: data.NewMicroVec3(0, -5000, 0),
+			expectedMax: data.NewMicroVec3(10000, 5000, 2000),
+		},
+		"unit overrides the file": {
+			dxf: dxf(
+				"0", "SECTION", "2", "HEADER", "9", "$INSUNITS", "70", "1", "0", "ENDSEC",
+				"0", "SECTION", "2", "ENTITIES",
+				"0", "CIRCLE", "10", "0", "20", "0", "40", "1",
+				"0", "ENDSEC", "0", "EOF",
+			),
+			unit:        10,
+			expectedMin: data.NewMicroVec3(-10000, -10000, 0),
+			expectedMax: data.NewMicroVec3(10000, 10000, 2000),
+		},
+		"open lines only": {
+			dxf: dxf(
+				"0", "SECTION", "2", "ENTITIES",
+				"0", "LINE", "10", "0", "20", "0", "11", "2", "21", "0",
+				"0", "ENDSEC", "0", "EOF",
+			),
+			expectedError: "does not contain any closed outlines",
+		},
+		"binary file": {
+			dxf:           "AutoCAD Binary DXF\r\n",
+			expectedError: "only ASCII DXF files are supported",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := readDXF(strings.NewReader(testCase.dxf), 2, testCase.unit)
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Assert(t, model.Min().Sub(testCase.expectedMin).Size() <= 20, "min should be %v but is %v", testCase.expectedMin, model.Min())
+		test.Assert(t, model.Max().Sub(testCase.expectedMax).Size() <= 20, "max should be %v but is %v", testCase.expectedMax, model.Max())
+	}
+}
@@ -0,0 +1,106 @@
+// This file provides a reader for Wavefront OBJ files.
+
+package reader
+
+import (
+	"bufio"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// readOBJ reads a model in the Wavefront OBJ format.
+// Only the vertices ("v") and the faces ("f") are used.
+// Faces with more than three vertices are triangulated as a triangle fan.
+// Everything else (normals, texture coordinates, materials, groups, ...) is ignored.
+func readOBJ(r io.Reader) (data.Model, error) {
+	var vertices []data.MicroVec3
+	var faces []data.Face
+
+	scanner := bufio.NewScanner(r)
+	lineNr := 0
+	for scanner.Scan() {
+		lineNr++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "v":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj line %d: a vertex needs three coordinates", lineNr)
+			}
+
+			var coordinates [3]data.Micrometer
+			for i := range coordinates {
+				value, err := strconv.ParseFloat(fields[i+1], 32)
+				if err != nil {
+					return nil, fmt.Errorf("obj line %d: invalid vertex coordinate %q", lineNr, fields[i+1])
+				}
+				coordinates[i] = data.Millimeter(value).ToMicrometer()
+			}
+
+			vertices = append(vertices, data.NewMicroVec3(coordinates[0], coordinates[1], coordinates[2]))
+		case "f":
+			if len(fields) < 4 {
+				return nil, fmt.Errorf("obj line %d: a face needs at least three vertices", lineNr)
+			}
+
+			indices := make([]int, len(fields)-1)
+			for i, field := range fields[1:] {
+				index, err := objVertexIndex(field, len(vertices))
+				if err != nil {
+					return nil, fmt.Errorf("obj line %d: %w", lineNr, err)
+				}
+				indices[i] = index
+			}
+
+			// triangulate the polygon as a fan around the first vertex
+			for i := 1; i < len(indices)-1; i++ {
+				faces = append(faces, face{vectors: [3]data.MicroVec3{
+					vertices[indices[0]].Copy(),
+					vertices[indices[i]].Copy(),
+					vertices[indices[i+1]].Copy(),
+				}})
+			}
+		default:
+			// ignore everything else
+		}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	if len(faces) == 0 {
+		return nil, fmt.Errorf("the obj file does not contain any faces")
+	}
+
+	return newModel(faces), nil
+}
+
+// objVertexIndex parses the vertex index of a face element which may have the forms
+// "v", "v/vt", "v//vn" or "v/vt/vn".
+// OBJ indices start at 1 and negative indices are relative to the last read vertex.
+// It returns the zero based index.
+func objVertexIndex(element string, vertexCount int) (int, error) {
+	index, err := strconv.Atoi(strings.SplitN(element, "/", 2)[0])
+	if err != nil {
+		return 0, fmt.Errorf("invalid face element %q", element)
+	}
+
+	if index < 0 {
+		index = vertexCount + index
+	} else {
+		index--
+	}
+
+	if index < 0 || index >= vertexCount {
+		return 0, fmt.Errorf("the face element %q references a not existing vertex", element)
+	}
+
+	return index, nil
+}
@@ -0,0 +1,72 @@
+package reader
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestReadOBJ(t *testing.T) {
+	var testCases = map[string]struct {
+		obj           string
+		expectedFaces int
+		expectedError string
+		expectedMax   data.MicroVec3
+	}{
+		"triangle": {
+			obj: "# comment\n" +
+				"mtllib material.mtl\n" +
+				"v 0 0 0\n" +
+				"v 1 0 0\n" +
+				"v 0 1.5 2\n" +
+				"vn 0 0 1\n" +
+				"usemtl red\n" +
+				"f 1 2 3\n",
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+		},
+		"quad with texture and normal indices": {
+			obj: "v 0 0 0\n" +
+				"v 1 0 0\n" +
+				"v 1 1 0\n" +
+				"v 0 1 0\n" +
+				"f 1/1/1 2/2/1 3/3/1 4/4/1\n",
+			expectedFaces: 2,
+			expectedMax:   data.NewMicroVec3(1000, 1000, 0),
+		},
+		"negative indices": {
+			obj: "v 0 0 0\n" +
+				"v 1 0 0\n" +
+				"v 0 1 0\n" +
+				"f -3//1 -2//1 -1//1\n",
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1000, 0),
+		},
+		"invalid index": {
+			obj: "v 0 0 0\n" +
+				"v 1 0 0\n" +
+				"f 1 2 3\n",
+			expectedError: "line 3",
+		},
+		"no faces": {
+			obj:           "v 0 0 0\n",
+			expectedError: "does not contain any faces",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := readOBJ(strings.NewReader(testCase.obj))
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedFaces, model.FaceCount())
+		max := model.Max()
+		test.Assert(t, max.X() == testCase.expectedMax.X() && max.Y() == testCase.expectedMax.Y() && max.Z() == testCase.expectedMax.Z(), "max should be %v but is %v", testCase.expectedMax, max)
+	}
+}
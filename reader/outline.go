@@ -0,0 +1,144 @@
+// This file provides the extrusion of closed 2D outlines, e.g. read from SVG or DXF files, to a 3D model.
+
+package reader
+
+import (
+	"errors"
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// outline is a closed 2D path in mm. The last point is connected to the first one.
+type outline [][2]float64
+
+func (o outline) area() float64 {
+	area := 0.0
+	for i, p := range o {
+		q := o[(i+1)%len(o)]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area / 2
+}
+
+// contains returns true if the point lies inside of the outline.
+func (o outline) contains(p [2]float64) bool {
+	inside := false
+	for i, a := range o {
+		b := o[(i+1)%len(o)]
+		if (a[1] > p[1]) != (b[1] > p[1]) && p[0] < (b[0]-a[0])*(p[1]-a[1])/(b[1]-a[1])+a[0] {
+			inside = !inside
+		}
+	}
+	return inside
+}
+
+// removeDuplicates removes consecutive duplicate points including the closing point if it equals the first one.
+func (o outline) removeDuplicates() outline {
+	var result outline
+	for _, p := range o {
+		if len(result) == 0 || result[len(result)-1] != p {
+			result = append(result, p)
+		}
+	}
+	for len(result) > 1 && result[0] == result[len(result)-1] {
+		result = result[:len(result)-1]
+	}
+	return result
+}
+
+// extrudeOutlines extrudes the outlines from z = 0 to the given height in mm.
+// Outlines inside of an odd number of other outlines are holes, so the nesting of the outlines defines the solid area
+// independently of their orientation.
+func extrudeOutlines(outlines []outline, height float64) (data.Model, error) {
+	var loops []outline
+	for _, o := range outlines {
+		o = o.removeDuplicates()
+		if len(o) >= 3 && o.area() != 0 {
+			loops = append(loops, o)
+		}
+	}
+	if len(loops) == 0 {
+		return nil, errors.New("the file does not contain any closed outlines")
+	}
+	if height <= 0 {
+		return nil, errors.New("the extrude height has to be bigger than 0")
+	}
+
+	// find the parent of each loop, which is the smallest loop containing it
+	parents := make([]int, len(loops))
+	depths := make([]int, len(loops))
+	for i, loop := range loops {
+		parents[i] = -1
+		for j, other := range loops {
+			if i == j || !other.contains(loop[0]) {
+				continue
+			}
+			depths[i]++
+			if parents[i] == -1 || math.Abs(other.area()) < math.Abs(loops[parents[i]].area()) {
+				parents[i] = j
+			}
+		}
+	}
+
+	// outer loops are counter clockwise and holes clockwise
+	for i, loop := range loops {
+		if (loop.area() > 0) != (depths[i]%2 == 0) {
+			for a, b := 0, len(loop)-1; a < b; a, b = a+1, b-1 {
+				loop[a], loop[b] = loop[b], loop[a]
+			}
+		}
+	}
+
+	var faces []data.Face
+	vec := func(p [2]float64, z float64) data.MicroVec3 {
+		return data.NewMicroVec3(
+			data.Millimeter(p[0]).ToMicrometer(),
+			data.Millimeter(p[1]).ToMicrometer(),
+			data.Millimeter(z).ToMicrometer(),
+		)
+	}
+
+	for i, loop := range loops {
+		// the walls
+		for j, a := range loop {
+			b := loop[(j+1)%len(loop)]
+			faces = append(faces,
+				face{vectors: [3]data.MicroVec3{vec(a, 0), vec(b, 0), vec(b, height)}},
+				face{vectors: [3]data.MicroVec3{vec(a, 0), vec(b, height), vec(a, height)}},
+			)
+		}
+
+		if depths[i]%2 != 0 {
+			continue
+		}
+
+		// the top and bottom of each outer loop with its holes
+		var params [][2]float64
+		ring := func(loop outline) []int {
+			indices := make([]int, len(loop))
+			for k, p := range loop {
+				indices[k] = len(params)
+				params = append(params, p)
+			}
+			return indices
+		}
+
+		outer := ring(loop)
+		var holes [][]int
+		for j, hole := range loops {
+			if parents[j] == i && depths[j]%2 != 0 {
+				holes = append(holes, ring(hole))
+			}
+		}
+
+		for _, t := range earcut(params, outer, holes) {
+			a, b, c := params[t[0]], params[t[1]], params[t[2]]
+			faces = append(faces,
+				face{vectors: [3]data.MicroVec3{vec(a, height), vec(b, height), vec(c, height)}},
+				face{vectors: [3]data.MicroVec3{vec(a, 0), vec(c, 0), vec(b, 0)}},
+			)
+		}
+	}
+
+	return newModel(faces), nil
+}
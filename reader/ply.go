@@ -0,0 +1,292 @@
+// This file provides a reader for PLY (Polygon File Format) files.
+
+package reader
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// plyProperty is one property of a PLY element.
+// For list properties countType is the type of the list length and valueType the type of the items.
+type plyProperty struct {
+	name      string
+	valueType string
+	isList    bool
+	countType string
+}
+
+// plyElement is an element definition of the PLY header, e.g. "vertex" or "face".
+type plyElement struct {
+	name       string
+	count      int
+	properties []plyProperty
+}
+
+// plyValueReader reads single values of the PLY body.
+type plyValueReader interface {
+	read(valueType string) (float64, error)
+}
+
+// readPLY reads a model in the ASCII or binary PLY format.
+// Only the x, y and z properties of the vertices and the vertex indices of the faces are used.
+// Faces with more than three vertices are triangulated as a triangle fan.
+// All other elements and properties are ignored.
+func readPLY(r io.Reader) (data.Model, error) {
+	buffered := bufio.NewReader(r)
+
+	format, elements, err := readPLYHeader(buffered)
+	if err != nil {
+		return nil, err
+	}
+
+	var values plyValueReader
+	switch format {
+	case "ascii":
+		scanner := bufio.NewScanner(buffered)
+		scanner.Split(bufio.ScanWords)
+		values = &plyASCIIReader{scanner: scanner}
+	case "binary_little_endian":
+		values = &plyBinaryReader{reader: buffered, order: binary.LittleEndian}
+	case "binary_big_endian":
+		values = &plyBinaryReader{reader: buffered, order: binary.BigEndian}
+	default:
+		return nil, fmt.Errorf("ply: unsupported format %q", format)
+	}
+
+	var vertices []data.MicroVec3
+	var faces []data.Face
+
+	for _, element := range elements {
+		for i := 0; i < element.count; i++ {
+			var coordinates [3]data.Micrometer
+			var indices []int
+
+			for _, property := range element.properties {
+				if property.isList {
+					count, err := values.read(property.countType)
+					if err != nil {
+						return nil, fmt.Errorf("ply: could not read %s %d: %w", element.name, i, err)
+					}
+
+					if count < 0 || count != math.Trunc(count) {
+						return nil, fmt.Errorf("ply: invalid list length %v of %s %d", count, element.name, i)
+					}
+
+					// The length comes from the file and may be far larger than the remaining data.
+					// So the list only grows with the values which are actually read.
+					var list []int
+					for j := 0; j < int(count); j++ {
+						value, err := values.read(property.valueType)
+						if err != nil {
+							return nil, fmt.Errorf("ply: could not read %s %d: %w", element.name, i, err)
+						}
+						list = append(list, int(value))
+					}
+
+					if element.name == "face" && (property.name == "vertex_indices" || property.name == "vertex_index") {
+						indices = list
+					}
+					continue
+				}
+
+				value, err := values.read(property.valueType)
+				if err != nil {
+					return nil, fmt.Errorf("ply: could not read %s %d: %w", element.name, i, err)
+				}
+
+				if element.name == "vertex" {
+					switch property.name {
+					case "x":
+						coordinates[0] = data.Millimeter(value).ToMicrometer()
+					case "y":
+						coordinates[1] = data.Millimeter(value).ToMicrometer()
+					case "z":
+						coordinates[2] = data.Millimeter(value).ToMicrometer()
+					}
+				}
+			}
+
+			switch element.name {
+			case "vertex":
+				vertices = append(vertices, data.NewMicroVec3(coordinates[0], coordinates[1], coordinates[2]))
+			case "face":
+				for _, index := range indices {
+					if index < 0 || index >= len(vertices) {
+						return nil, fmt.Errorf("ply: face %d references the not existing vertex %d", i, index)
+					}
+				}
+
+				// triangulate the polygon as a fan around the first vertex
+				for j := 1; j < len(indices)-1; j++ {
+					faces = append(faces, face{vectors: [3]data.MicroVec3{
+						vertices[indices[0]].Copy(),
+						vertices[indices[j]].Copy(),
+						vertices[indices[j+1]].Copy(),
+					}})
+				}
+			}
+		}
+	}
+
+	if len(faces) == 0 {
+		return nil, errors.New("the ply file does not contain any faces")
+	}
+
+	return newModel(faces), nil
+}
+
+// readPLYHeader reads the header up to and including the "end_header" line.
+// It returns the format and the element definitions in the order of the file.
+func readPLYHeader(r *bufio.Reader) (string, []plyElement, error) {
+	var format string
+	var elements []plyElement
+
+	for lineNr := 1; ; lineNr++ {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			if err == io.EOF {
+				return "", nil, errors.New("ply: unexpected end of the header")
+			}
+			return "", nil, err
+		}
+
+		fields := strings.Fields(line)
+		if lineNr == 1 {
+			if len(fields) != 1 || fields[0] != "ply" {
+				return "", nil, errors.New("ply: the file does not start with \"ply\"")
+			}
+			continue
+		}
+
+		if len(fields) == 0 {
+			continue
+		}
+
+		switch fields[0] {
+		case "format":
+			if len(fields) < 2 {
+				return "", nil, fmt.Errorf("ply header line %d: missing format", lineNr)
+			}
+			format = fields[1]
+		case "element":
+			if len(fields) != 3 {
+				return "", nil, fmt.Errorf("ply header line %d: invalid element definition", lineNr)
+			}
+			count, err := strconv.Atoi(fields[2])
+			if err != nil || count < 0 {
+				return "", nil, fmt.Errorf("ply header line %d: invalid element count %q", lineNr, fields[2])
+			}
+			elements = append(elements, plyElement{name: fields[1], count: count})
+		case "property":
+			if len(elements) == 0 {
+				return "", nil, fmt.Errorf("ply header line %d: property without element", lineNr)
+			}
+
+			var property plyProperty
+			if len(fields) == 5 && fields[1] == "list" {
+				property = plyProperty{name: fields[4], valueType: fields[3], isList: true, countType: fields[2]}
+			} else if len(fields) == 3 {
+				property = plyProperty{name: fields[2], valueType: fields[1]}
+			} else {
+				return "", nil, fmt.Errorf("ply header line %d: invalid property definition", lineNr)
+			}
+
+			for _, valueType := range []string{property.valueType, property.countType} {
+				if valueType != "" && plyTypeSize(valueType) == 0 {
+					return "", nil, fmt.Errorf("ply header line %d: unknown type %q", lineNr, valueType)
+				}
+			}
+
+			last := &elements[len(elements)-1]
+			last.properties = append(last.properties, property)
+		case "end_header":
+			if format == "" {
+				return "", nil, errors.New("ply: the header does not define a format")
+			}
+			return format, elements, nil
+		default:
+			// ignore comments, obj_info and everything else
+		}
+	}
+}
+
+// plyTypeSize returns the size in bytes of the given PLY type.
+// For unknown types 0 is returned.
+func plyTypeSize(valueType string) int {
+	switch valueType {
+	case "char", "uchar", "int8", "uint8":
+		return 1
+	case "short", "ushort", "int16", "uint16":
+		return 2
+	case "int", "uint", "int32", "uint32", "float", "float32":
+		return 4
+	case "double", "float64":
+		return 8
+	default:
+		return 0
+	}
+}
+
+// plyASCIIReader reads the values of an ASCII PLY body.
+type plyASCIIReader struct {
+	scanner *bufio.Scanner
+}
+
+func (p *plyASCIIReader) read(_ string) (float64, error) {
+	if !p.scanner.Scan() {
+		if err := p.scanner.Err(); err != nil {
+			return 0, err
+		}
+		return 0, io.ErrUnexpectedEOF
+	}
+
+	value, err := strconv.ParseFloat(p.scanner.Text(), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid value %q", p.scanner.Text())
+	}
+	return value, nil
+}
+
+// plyBinaryReader reads the values of a binary PLY body.
+type plyBinaryReader struct {
+	reader io.Reader
+	order  binary.ByteOrder
+	buf    [8]byte
+}
+
+func (p *plyBinaryReader) read(valueType string) (float64, error) {
+	buf := p.buf[:plyTypeSize(valueType)]
+	if _, err := io.ReadFull(p.reader, buf); err != nil {
+		if err == io.EOF {
+			return 0, io.ErrUnexpectedEOF
+		}
+		return 0, err
+	}
+
+	switch valueType {
+	case "char", "int8":
+		return float64(int8(buf[0])), nil
+	case "uchar", "uint8":
+		return float64(buf[0]), nil
+	case "short", "int16":
+		return float64(int16(p.order.Uint16(buf))), nil
+	case "ushort", "uint16":
+		return float64(p.order.Uint16(buf)), nil
+	case "int", "int32":
+		return float64(int32(p.order.Uint32(buf))), nil
+	case "uint", "uint32":
+		return float64(p.order.Uint32(buf)), nil
+	case "float", "float32":
+		return float64(math.Float32frombits(p.order.Uint32(buf))), nil
+	default:
+		return math.Float64frombits(p.order.Uint64(buf)), nil
+	}
+}
@@ -0,0 +1,153 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+// binaryPLY returns a binary little endian PLY containing one quad.
+func binaryPLY() string {
+	buf := bytes.NewBufferString("ply\n" +
+		"format binary_little_endian 1.0\n" +
+		"element vertex 4\n" +
+		"property float x\n" +
+		"property float y\n" +
+		"property float z\n" +
+		"property uchar red\n" +
+		"element face 1\n" +
+		"property list uchar int vertex_indices\n" +
+		"end_header\n")
+
+	for _, vertex := range [][3]float32{{0, 0, 0}, {1, 0, 0}, {1, 2, 0}, {0, 2, 3}} {
+		_ = binary.Write(buf, binary.LittleEndian, vertex)
+		buf.WriteByte(255)
+	}
+	buf.WriteByte(4)
+	_ = binary.Write(buf, binary.LittleEndian, []int32{0, 1, 2, 3})
+
+	return buf.String()
+}
+
+func TestReadPLY(t *testing.T) {
+	var testCases = map[string]struct {
+		ply           string
+		expectedFaces int
+		expectedError string
+		expectedMax   data.MicroVec3
+	}{
+		"ascii": {
+			ply: "ply\n" +
+				"format ascii 1.0\n" +
+				"comment made by hand\n" +
+				"element vertex 3\n" +
+				"property float x\n" +
+				"property float y\n" +
+				"property float z\n" +
+				"property float nx\n" +
+				"element face 1\n" +
+				"property list uchar int vertex_indices\n" +
+				"element edge 1\n" +
+				"property int vertex1\n" +
+				"property int vertex2\n" +
+				"end_header\n" +
+				"0 0 0 1\n" +
+				"1 0 0 1\n" +
+				"0 1.5 2 1\n" +
+				"3 0 1 2\n" +
+				"0 1\n",
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+		},
+		"binary": {
+			ply:           binaryPLY(),
+			expectedFaces: 2,
+			expectedMax:   data.NewMicroVec3(1000, 2000, 3000),
+		},
+		"invalid index": {
+			ply: "ply\n" +
+				"format ascii 1.0\n" +
+				"element vertex 1\n" +
+				"property float x\n" +
+				"property float y\n" +
+				"property float z\n" +
+				"element face 1\n" +
+				"property list uchar int vertex_indices\n" +
+				"end_header\n" +
+				"0 0 0\n" +
+				"3 0 1 2\n",
+			expectedError: "not existing vertex",
+		},
+		"truncated": {
+			ply: "ply\n" +
+				"format ascii 1.0\n" +
+				"element vertex 2\n" +
+				"property float x\n" +
+				"end_header\n" +
+				"0\n",
+			expectedError: "could not read vertex 1",
+		},
+		"negative list length": {
+			ply: "ply\n" +
+				"format ascii 1.0\n" +
+				"element vertex 3\n" +
+				"property float x\n" +
+				"property float y\n" +
+				"property float z\n" +
+				"element face 1\n" +
+				"property list int int vertex_indices\n" +
+				"end_header\n" +
+				"0 0 0\n" +
+				"1 0 0\n" +
+				"0 1 0\n" +
+				"-1 0 1 2\n",
+			expectedError: "invalid list length -1 of face 0",
+		},
+		"list length larger than the data": {
+			ply:           binaryPLYWithListLength(0xffffffff),
+			expectedError: "could not read face 0: unexpected EOF",
+		},
+		"no ply": {
+			ply:           "solid test\n",
+			expectedError: "does not start with",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := readPLY(strings.NewReader(testCase.ply))
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedFaces, model.FaceCount())
+		max := model.Max()
+		test.Assert(t, max.X() == testCase.expectedMax.X() && max.Y() == testCase.expectedMax.Y() && max.Z() == testCase.expectedMax.Z(), "max should be %v but is %v", testCase.expectedMax, max)
+	}
+}
+
+// binaryPLYWithListLength returns a binary little endian PLY with one triangle
+// whose vertex list has the given length in the file.
+func binaryPLYWithListLength(length uint32) string {
+	buf := bytes.NewBufferString("ply\n" +
+		"format binary_little_endian 1.0\n" +
+		"element vertex 3\n" +
+		"property float x\n" +
+		"property float y\n" +
+		"property float z\n" +
+		"element face 1\n" +
+		"property list uint int vertex_indices\n" +
+		"end_header\n")
+
+	_ = binary.Write(buf, binary.LittleEndian, [][3]float32{{0, 0, 0}, {1, 0, 0}, {0, 1, 0}})
+	_ = binary.Write(buf, binary.LittleEndian, length)
+	_ = binary.Write(buf, binary.LittleEndian, []int32{0, 1, 2})
+
+	return buf.String()
+}
@@ -1,9 +1,19 @@
 package reader
 
 import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
 	"github.com/aligator/goslice/data"
 	"github.com/aligator/goslice/handler"
-	"github.com/hschendel/stl"
+	"io"
+	"io/ioutil"
+	"math"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
 )
 
 // face is a 3d triangle face defined by three 3d vectors.
@@ -19,30 +29,6 @@ type model struct {
 	faces []data.Face
 }
 
-func (m *model) SetName(name string) {
-	// not used yet
-	return
-}
-
-func (m *model) SetBinaryHeader(header []byte) {
-	// not used yet
-	return
-}
-
-func (m *model) SetASCII(isASCII bool) {
-	// not used yet
-	return
-}
-
-func (m *model) SetTriangleCount(n uint32) {
-	// not used yet
-	return
-}
-
-func (m *model) AppendTriangle(t stl.Triangle) {
-	m.faces = append(m.faces, stlTriangleToFace(t))
-}
-
 func newModel(faces []data.Face) data.Model {
 	return &model{
 		faces: faces,
@@ -101,34 +87,474 @@ func (m model) Max() data.MicroVec3 {
 	return ret
 }
 
-type reader struct{}
+// packedFaceSize is the size of one face of a fileModel in bytes: nine int32 coordinates.
+const packedFaceSize = 9 * 4
 
-// Reader returns a stl model reader.
-func Reader(options *data.Options) handler.ModelReader {
-	return &reader{}
+// fileModelBlockSize is the amount of faces a fileModel reads at once.
+const fileModelBlockSize = 1024
+
+// faceStore stores the faces of a model while it is read.
+type faceStore interface {
+	data.Model
+
+	// reserve prepares the store for the given amount of faces.
+	reserve(count int)
+
+	// add adds a face with the coordinates of its three vertices.
+	add(coordinates [9]data.Micrometer) error
+
+	// finish has to be called after the last face is added.
+	finish() error
 }
 
-func (r reader) Read(filename string) (data.Model, error) {
-	model := &model{}
-	stl.CopyFile(filename, model)
-	return model, nil
+// pack converts the coordinates of a face into int32 and extends the bounds by them.
+// If first is set, the bounds are reset to the face.
+// It returns an error if a coordinate does not fit into the packed storage, which is about ±2km.
+func pack(coordinates [9]data.Micrometer, first bool, min, max *[3]int32) ([9]int32, error) {
+	var packed [9]int32
+	for i, coordinate := range coordinates {
+		if coordinate < math.MinInt32 || coordinate > math.MaxInt32 {
+			return packed, fmt.Errorf("the coordinate %v is too big", coordinate.ToMillimeter())
+		}
+		packed[i] = int32(coordinate)
+	}
+
+	for i, value := range packed {
+		axis := i % 3
+		if (first && i < 3) || value < min[axis] {
+			min[axis] = value
+		}
+		if (first && i < 3) || value > max[axis] {
+			max[axis] = value
+		}
+	}
+	return packed, nil
 }
 
-// stlTriangleToFace converts a triangle from the stl package
-// into a face.
-func stlTriangleToFace(t stl.Triangle) face {
+// packedFace creates a face from its packed coordinates.
+func packedFace(c []int32) data.Face {
 	return face{vectors: [3]data.MicroVec3{
-		data.NewMicroVec3(
-			data.Millimeter(t.Vertices[0][0]).ToMicrometer(),
-			data.Millimeter(t.Vertices[0][1]).ToMicrometer(),
-			data.Millimeter(t.Vertices[0][2]).ToMicrometer()),
-		data.NewMicroVec3(
-			data.Millimeter(t.Vertices[1][0]).ToMicrometer(),
-			data.Millimeter(t.Vertices[1][1]).ToMicrometer(),
-			data.Millimeter(t.Vertices[1][2]).ToMicrometer()),
-		data.NewMicroVec3(
-			data.Millimeter(t.Vertices[2][0]).ToMicrometer(),
-			data.Millimeter(t.Vertices[2][1]).ToMicrometer(),
-			data.Millimeter(t.Vertices[2][2]).ToMicrometer()),
+		data.NewMicroVec3(data.Micrometer(c[0]), data.Micrometer(c[1]), data.Micrometer(c[2])),
+		data.NewMicroVec3(data.Micrometer(c[3]), data.Micrometer(c[4]), data.Micrometer(c[5])),
+		data.NewMicroVec3(data.Micrometer(c[6]), data.Micrometer(c[7]), data.Micrometer(c[8])),
 	}}
 }
+
+// packedModel stores the coordinates of all faces in one slice instead of one allocation per vertex,
+// so that it needs only a fraction of the memory of model for very large meshes.
+// The faces are created when they are requested.
+type packedModel struct {
+	// coordinates contains the nine coordinates of each face in micrometer.
+	coordinates []int32
+	min, max    [3]int32
+}
+
+func (m *packedModel) reserve(count int) {
+	m.coordinates = make([]int32, 0, count*9)
+}
+
+// add adds a face with the coordinates of its three vertices.
+// It returns an error if a coordinate does not fit into the packed storage, which is about ±2km.
+func (m *packedModel) add(coordinates [9]data.Micrometer) error {
+	packed, err := pack(coordinates, len(m.coordinates) == 0, &m.min, &m.max)
+	if err != nil {
+		return err
+	}
+	m.coordinates = append(m.coordinates, packed[:]...)
+	return nil
+}
+
+func (m *packedModel) finish() error {
+	return nil
+}
+
+func (m *packedModel) FaceCount() int {
+	return len(m.coordinates) / 9
+}
+
+func (m *packedModel) Face(index int) data.Face {
+	return packedFace(m.coordinates[index*9 : index*9+9])
+}
+
+func (m *packedModel) Min() data.MicroVec3 {
+	return data.NewMicroVec3(data.Micrometer(m.min[0]), data.Micrometer(m.min[1]), data.Micrometer(m.min[2]))
+}
+
+func (m *packedModel) Max() data.MicroVec3 {
+	return data.NewMicroVec3(data.Micrometer(m.max[0]), data.Micrometer(m.max[1]), data.Micrometer(m.max[2]))
+}
+
+// fileModel stores the packed coordinates of all faces in a temporary file instead of in memory,
+// so that the memory it needs does not grow with the face count.
+// It is used if the layers are processed in windows, as the optimizer then keeps only the faces
+// of the current window in memory (see data.ZRangeModel).
+// The faces are read from the file when they are requested. Close removes the file.
+type fileModel struct {
+	file     *os.File
+	writer   *bufio.Writer
+	count    int
+	min, max [3]int32
+
+	// block caches the faces read last starting at the face blockStart,
+	// as the faces are mostly read one after the other.
+	mutex      sync.Mutex
+	block      []int32
+	blockStart int
+
+	// err is the first error which occurred while reading a face (see data.LazyModel).
+	err error
+}
+
+func newFileModel() (*fileModel, error) {
+	file, err := ioutil.TempFile("", "goslice-faces-*")
+	if err != nil {
+		return nil, err
+	}
+
+	return &fileModel{
+		file:   file,
+		writer: bufio.NewWriterSize(file, 64*1024),
+	}, nil
+}
+
+func (m *fileModel) reserve(int) {}
+
+// add writes a face with the coordinates of its three vertices to the file.
+// It returns an error if a coordinate does not fit into the packed storage, which is about ±2km.
+func (m *fileModel) add(coordinates [9]data.Micrometer) error {
+	packed, err := pack(coordinates, m.count == 0, &m.min, &m.max)
+	if err != nil {
+		return err
+	}
+
+	var buffer [packedFaceSize]byte
+	for i, value := range packed {
+		binary.LittleEndian.PutUint32(buffer[i*4:], uint32(value))
+	}
+	if _, err := m.writer.Write(buffer[:]); err != nil {
+		return err
+	}
+	m.count++
+	return nil
+}
+
+func (m *fileModel) finish() error {
+	return m.writer.Flush()
+}
+
+func (m *fileModel) FaceCount() int {
+	return m.count
+}
+
+// Face reads the face from the file.
+// As a data.Model cannot return errors, an empty face is returned if the file cannot be read
+// and the error is kept for Err.
+func (m *fileModel) Face(index int) data.Face {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+
+	if index < m.blockStart || index >= m.blockStart+len(m.block)/9 {
+		count := fileModelBlockSize
+		if index+count > m.count {
+			count = m.count - index
+		}
+
+		buffer := make([]byte, count*packedFaceSize)
+		if _, err := m.file.ReadAt(buffer, int64(index)*packedFaceSize); err != nil {
+			if m.err == nil {
+				m.err = fmt.Errorf("could not read the face %v of the model: %w", index, err)
+			}
+			return packedFace(make([]int32, 9))
+		}
+
+		m.block = make([]int32, count*9)
+		for i := range m.block {
+			m.block[i] = int32(binary.LittleEndian.Uint32(buffer[i*4:]))
+		}
+		m.blockStart = index
+	}
+
+	offset := (index - m.blockStart) * 9
+	return packedFace(m.block[offset : offset+9])
+}
+
+// Err returns the first error which occurred while reading a face.
+func (m *fileModel) Err() error {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.err
+}
+
+func (m *fileModel) Min() data.MicroVec3 {
+	return data.NewMicroVec3(data.Micrometer(m.min[0]), data.Micrometer(m.min[1]), data.Micrometer(m.min[2]))
+}
+
+func (m *fileModel) Max() data.MicroVec3 {
+	return data.NewMicroVec3(data.Micrometer(m.max[0]), data.Micrometer(m.max[1]), data.Micrometer(m.max[2]))
+}
+
+// Close removes the file. It can be called several times.
+func (m *fileModel) Close() error {
+	if m.file == nil {
+		return nil
+	}
+
+	name := m.file.Name()
+	err := m.file.Close()
+	m.file = nil
+	if removeErr := os.Remove(name); err == nil {
+		err = removeErr
+	}
+	return err
+}
+
+// closeModel removes the temporary file of a model which keeps its faces in a file.
+// It is used if the model cannot be returned because of an error.
+func closeModel(m data.Model) {
+	if closer, ok := m.(io.Closer); ok {
+		closer.Close()
+	}
+}
+
+type reader struct {
+	options     *data.Options
+	tessellator StepTessellator
+	client      *http.Client
+}
+
+type option func(r *reader)
+
+// WithStepTessellator sets the StepTessellator which is used for STEP files GoSlice cannot tessellate itself.
+// By default an external command is used if it is set by the option GoSlice.StepTessellator.
+func WithStepTessellator(tessellator StepTessellator) option {
+	return func(r *reader) {
+		r.tessellator = tessellator
+	}
+}
+
+// WithHTTPClient sets the client which is used to download models from URLs.
+// By default a client with the timeout set by the option GoSlice.DownloadTimeout is used.
+func WithHTTPClient(client *http.Client) option {
+	return func(r *reader) {
+		r.client = client
+	}
+}
+
+// Reader returns a model reader.
+// It supports stl, obj, ply, 3mf and step files and detects the format by the content.
+// Only if the format cannot be detected, the file extension is used.
+// The closed outlines of 2D svg and dxf files are extruded to the height set by the option GoSlice.ExtrudeHeight.
+// Files with unknown file extension are read as stl.
+// Gzip compressed models (e.g. ".stl.gz") and zip archives containing a single model are decompressed transparently.
+// If the filename is "-", the model is read from stdin using the configured input format.
+// If the filename is a http or https URL, the model is read while it is downloaded, limited to the size and the time set by the options
+// GoSlice.MaxDownloadSize and GoSlice.DownloadTimeout. A sha256 checksum can be added to verify the download, e.g. "https://host/model.stl#sha256=<hex>".
+//
+// The returned reader also implements handler.ModelStreamReader to read models from any io.Reader.
+func Reader(options *data.Options, readerOptions ...option) handler.ModelReader {
+	r := &reader{
+		options: options,
+	}
+	if options.GoSlice.StepTessellator != "" {
+		r.tessellator = NewCommandTessellator(options.GoSlice.StepTessellator)
+	}
+
+	for _, option := range readerOptions {
+		option(r)
+	}
+
+	return r
+}
+
+func (r reader) Read(filename string) (data.Model, error) {
+	if filename == "-" {
+		return r.ReadStream(os.Stdin, r.options.GoSlice.InputFormat)
+	}
+	if IsURL(filename) {
+		return r.readURL(filename)
+	}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	format := filepath.Ext(filename)
+	if strings.ToLower(format) == ".gz" {
+		// keep the format of the compressed model, e.g. ".stl.gz"
+		format = filepath.Ext(strings.TrimSuffix(filename, format)) + format
+	}
+
+	return r.ReadStream(file, format)
+}
+
+// ReadStream reads a model in the given format from the io.Reader.
+// The format is the name or file extension of the format, e.g. "obj" or ".obj".
+// It is only used if the format cannot be detected by the content. Unknown formats are read as stl.
+// Compressed models are supported by the formats "zip" and "gz" optionally prefixed by the
+// format of the compressed model, e.g. "stl.gz".
+//
+// The coordinates are interpreted in the unit set by the option GoSlice.InputUnit and
+// are then scaled by GoSlice.InputScale. A scale of 0 leaves the model unscaled.
+func (r reader) ReadStream(input io.Reader, format string) (data.Model, error) {
+	unit, err := unitScale(r.options.GoSlice.InputUnit)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := r.options.GoSlice.InputScale
+	if scale < 0 {
+		return nil, fmt.Errorf("the input scale %v must not be negative", scale)
+	}
+
+	model, err := r.readFormat(input, format, unit)
+	if err != nil {
+		return nil, err
+	}
+
+	if scale != 0 && scale != 1 {
+		model = scaleModel(model, scale)
+	}
+	return model, nil
+}
+
+// readFormat reads a model in the given format.
+// The format is detected by the content if possible and the given format is only used if it cannot be detected.
+// If unit is bigger than 0, the coordinates are interpreted in this unit, which is the factor to convert them
+// into millimeter. Otherwise the unit defined in the file is used and millimeter for formats without units.
+func (r reader) readFormat(input io.Reader, format string, unit float64) (data.Model, error) {
+	head, input, err := peekHead(input)
+	if err != nil {
+		return nil, err
+	}
+
+	format = contentFormat(strings.TrimPrefix(strings.ToLower(format), "."), head)
+	if format == "gz" || strings.HasSuffix(format, ".gz") {
+		return r.readGZIP(input, strings.TrimSuffix(strings.TrimSuffix(format, "gz"), "."), unit)
+	}
+
+	var model data.Model
+	switch format {
+	case "zip":
+		return r.readZIP(input, unit)
+	case "3mf":
+		return readThreeMF(input, unit)
+	case "step", "stp":
+		return readSTEP(input, r.tessellator, unit)
+	case "svg":
+		return readSVG(input, float64(r.options.GoSlice.ExtrudeHeight), unit)
+	case "dxf":
+		return readDXF(input, float64(r.options.GoSlice.ExtrudeHeight), unit)
+	case "obj":
+		model, err = readOBJ(input)
+	case "ply":
+		model, err = readPLY(input)
+	default:
+		var store faceStore
+		store, err = r.newFaceStore()
+		if err != nil {
+			return nil, err
+		}
+		model, err = readSTL(input, store)
+	}
+	if err != nil || unit <= 0 || unit == 1 {
+		return model, err
+	}
+
+	// these formats have no units and are read as millimeter
+	return scaleModel(model, unit), nil
+}
+
+// unitScale returns the factor to convert the given unit into millimeter.
+// Possible units are "mm", "cm", "m" and "inch".
+// For "auto" (or an empty unit) 0 is returned, which means that the unit defined in the file should be used.
+func unitScale(unit string) (float64, error) {
+	switch unit {
+	case "", "auto":
+		return 0, nil
+	case "mm":
+		return 1, nil
+	case "cm":
+		return 10, nil
+	case "m":
+		return 1000, nil
+	case "inch":
+		return 25.4, nil
+	default:
+		return 0, fmt.Errorf("the input unit %q is unknown", unit)
+	}
+}
+
+// newFaceStore returns the store for the faces of a model which is read as a stream.
+// If the layers are processed in windows, the faces are kept in a temporary file instead of in memory,
+// as the optimizer then loads only the faces of the current window.
+func (r reader) newFaceStore() (faceStore, error) {
+	if r.options.GoSlice.LayerWindow <= 0 {
+		return &packedModel{}, nil
+	}
+
+	model, err := newFileModel()
+	if err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+// scaleModel returns the model with all coordinates multiplied by the positive factor.
+// The faces are scaled when they are requested, so that the faces of the model are not copied.
+func scaleModel(m data.Model, factor float64) data.Model {
+	return scaledModel{
+		model:  m,
+		factor: factor,
+	}
+}
+
+// scaledModel multiplies all coordinates of a model by a positive factor.
+type scaledModel struct {
+	model  data.Model
+	factor float64
+}
+
+func (s scaledModel) scale(p data.MicroVec3) data.MicroVec3 {
+	return data.NewMicroVec3(
+		data.Micrometer(math.Round(float64(p.X())*s.factor)),
+		data.Micrometer(math.Round(float64(p.Y())*s.factor)),
+		data.Micrometer(math.Round(float64(p.Z())*s.factor)),
+	)
+}
+
+func (s scaledModel) FaceCount() int {
+	return s.model.FaceCount()
+}
+
+func (s scaledModel) Face(index int) data.Face {
+	var scaled face
+	for i, point := range s.model.Face(index).Points() {
+		scaled.vectors[i] = s.scale(point)
+	}
+	return scaled
+}
+
+// Min returns the scaled minimum of the model, which is the minimum of the scaled model as the factor is positive.
+func (s scaledModel) Min() data.MicroVec3 {
+	return s.scale(s.model.Min())
+}
+
+// Max returns the scaled maximum of the model, which is the maximum of the scaled model as the factor is positive.
+func (s scaledModel) Max() data.MicroVec3 {
+	return s.scale(s.model.Max())
+}
+
+// Err returns the error of the scaled model if it reads its faces lazily.
+func (s scaledModel) Err() error {
+	return data.ModelErr(s.model)
+}
+
+// Close closes the scaled model if it keeps its faces in a file.
+func (s scaledModel) Close() error {
+	if closer, ok := s.model.(io.Closer); ok {
+		return closer.Close()
+	}
+	return nil
+}
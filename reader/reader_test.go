@@ -0,0 +1,96 @@
+package reader
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestReadStream(t *testing.T) {
+	options := data.DefaultOptions()
+	r, ok := Reader(&options).(handler.ModelStreamReader)
+	test.Assert(t, ok, "the reader should implement ModelStreamReader")
+
+	var testCases = map[string]struct {
+		format string
+		input  string
+	}{
+		"obj": {
+			format: "OBJ",
+			input:  "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n",
+		},
+		"ply extension": {
+			format: ".ply",
+			input:  "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uchar int vertex_index\nend_header\n0 0 0\n1 0 0\n0 1 0\n3 0 1 2\n",
+		},
+		"unknown is stl": {
+			format: "",
+			input:  "solid facet normal 0 0 1 outer loop vertex 0 0 0 vertex 1 0 0 vertex 0 1 0 endloop endfacet endsolid",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := r.ReadStream(strings.NewReader(testCase.input), testCase.format)
+		test.Ok(t, err)
+		test.Equals(t, 1, model.FaceCount())
+	}
+}
+
+func TestReadStreamUnits(t *testing.T) {
+	var testCases = map[string]struct {
+		unit     string
+		scale    float64
+		format   string
+		input    string
+		expected data.Micrometer
+		err      bool
+	}{
+		"auto is mm": {
+			unit:     "auto",
+			scale:    1,
+			format:   "obj",
+			input:    "v 0 0 0\nv 2 0 0\nv 0 1 0\nf 1 2 3\n",
+			expected: 2000,
+		},
+		"inch": {
+			unit:     "inch",
+			scale:    1,
+			format:   "obj",
+			input:    "v 0 0 0\nv 2 0 0\nv 0 1 0\nf 1 2 3\n",
+			expected: 50800,
+		},
+		"cm with scale": {
+			unit:     "cm",
+			scale:    1.5,
+			format:   "ply",
+			input:    "ply\nformat ascii 1.0\nelement vertex 3\nproperty float x\nproperty float y\nproperty float z\nelement face 1\nproperty list uchar int vertex_index\nend_header\n0 0 0\n2 0 0\n0 1 0\n3 0 1 2\n",
+			expected: 30000,
+		},
+		"unknown unit": {
+			unit:   "furlong",
+			scale:  1,
+			format: "obj",
+			input:  "v 0 0 0\nv 2 0 0\nv 0 1 0\nf 1 2 3\n",
+			err:    true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.GoSlice.InputUnit = testCase.unit
+		options.GoSlice.InputScale = testCase.scale
+		r := Reader(&options).(handler.ModelStreamReader)
+
+		model, err := r.ReadStream(strings.NewReader(testCase.input), testCase.format)
+		if testCase.err {
+			test.Assert(t, err != nil, "an error was expected")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, model.Max().X())
+	}
+}
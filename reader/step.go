@@ -0,0 +1,459 @@
+// This file provides a reader for STEP (ISO 10303-21) files.
+//
+// STEP files describe the exact boundary representation of a model and not a triangle mesh,
+// so the faces have to be tessellated.
+// GoSlice tessellates faces on planes, cylinders and cones which are bounded by lines, polylines,
+// circles and ellipses itself. Tessellated geometry of AP242 files is used as it is.
+// For all other surfaces, e.g. free form surfaces, a StepTessellator can be plugged in.
+// Placements of assembly components are not applied.
+
+package reader
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// StepTessellator tessellates STEP files which contain surfaces GoSlice cannot tessellate itself.
+type StepTessellator interface {
+	// Tessellate converts the content of a STEP file to a triangle mesh.
+	Tessellate(content []byte) (data.Model, error)
+}
+
+// commandTessellator uses an external program to tessellate STEP files.
+type commandTessellator struct {
+	command string
+}
+
+// NewCommandTessellator returns a StepTessellator which runs an external program to convert STEP files to STL.
+// The command is split at whitespace and the placeholders {input} and {output} are replaced by
+// the paths of the STEP file and of the STL file the program has to create, e.g.
+//
+//	gmsh {input} -2 -format stl -o {output}
+func NewCommandTessellator(command string) StepTessellator {
+	return &commandTessellator{command: command}
+}
+
+func (c commandTessellator) Tessellate(content []byte) (data.Model, error) {
+	dir, err := ioutil.TempDir("", "goslice-step")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+
+	input := filepath.Join(dir, "model.step")
+	output := filepath.Join(dir, "model.stl")
+	if err := ioutil.WriteFile(input, content, 0600); err != nil {
+		return nil, err
+	}
+
+	args := strings.Fields(c.command)
+	if len(args) == 0 {
+		return nil, errors.New("step: the tessellator command is empty")
+	}
+	for i, arg := range args {
+		args[i] = strings.ReplaceAll(strings.ReplaceAll(arg, "{input}", input), "{output}", output)
+	}
+
+	cmd := exec.Command(args[0], args[1:]...)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("step: the tessellator failed: %w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+
+	file, err := os.Open(output)
+	if err != nil {
+		return nil, fmt.Errorf("step: the tessellator did not create a model: %w", err)
+	}
+	defer file.Close()
+
+	return readSTL(file, &packedModel{})
+}
+
+// readSTEP reads a model in the STEP format.
+// If unit is bigger than 0, it replaces the length unit of the file and is the factor to convert the coordinates into millimeter.
+// If the model contains surfaces which cannot be tessellated by GoSlice,
+// the whole file is passed to the tessellator. If it is nil, an error is returned.
+// The model of the tessellator is scaled by unit as well.
+func readSTEP(r io.Reader, tessellator StepTessellator, unit float64) (data.Model, error) {
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	entities, err := parseStep(content)
+	if err != nil {
+		return nil, err
+	}
+
+	s := &stepModel{
+		entities:    entities,
+		edgeCache:   map[int][]stepVec{},
+		unsupported: map[string]bool{},
+	}
+
+	s.scale = unit
+	if s.scale <= 0 {
+		s.scale, err = s.lengthUnit()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	faces, err := s.tessellate()
+	if err != nil {
+		return nil, err
+	}
+
+	if len(s.unsupported) > 0 {
+		var names []string
+		for name := range s.unsupported {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+
+		if tessellator == nil {
+			return nil, fmt.Errorf("step: the model contains the unsupported geometry %s, use an external tessellator to read it", strings.Join(names, ", "))
+		}
+		model, err := tessellator.Tessellate(content)
+		if err != nil || unit <= 0 || unit == 1 {
+			return model, err
+		}
+		// the tessellated model has no unit and is read as millimeter like the other formats without units
+		return scaleModel(model, unit), nil
+	}
+
+	if len(faces) == 0 {
+		return nil, errors.New("the step file does not contain any faces")
+	}
+
+	return newModel(faces), nil
+}
+
+// stepRef is a reference to another entity, e.g. #12.
+type stepRef int
+
+// stepEnum is an enumeration value, e.g. .T.
+type stepEnum string
+
+// stepTyped is a typed parameter, e.g. LENGTH_MEASURE(1.0).
+type stepTyped struct {
+	name  string
+	value interface{}
+}
+
+// stepRecord is one record of an entity instance consisting of the entity name and its parameters.
+// The parameters are float64, string, stepRef, stepEnum, stepTyped, []interface{} or nil for unset values.
+type stepRecord struct {
+	name   string
+	params []interface{}
+}
+
+// stepEntity is an entity instance of the data section.
+// Simple instances contain one record, complex instances contain several records.
+type stepEntity []stepRecord
+
+// record returns the record with the given name.
+func (e stepEntity) record(name string) (stepRecord, bool) {
+	for _, r := range e {
+		if r.name == name {
+			return r, true
+		}
+	}
+	return stepRecord{}, false
+}
+
+// stepParser parses the clear text encoding of STEP files.
+type stepParser struct {
+	content []byte
+	offset  int
+}
+
+// parseStep parses all entity instances of the data sections.
+func parseStep(content []byte) (map[int]stepEntity, error) {
+	p := &stepParser{content: content}
+
+	p.skipSpace()
+	if !p.consume("ISO-10303-21;") {
+		return nil, errors.New("step: the file does not start with \"ISO-10303-21;\"")
+	}
+
+	entities := map[int]stepEntity{}
+	inData := false
+	for {
+		p.skipSpace()
+		if p.offset >= len(p.content) {
+			return nil, errors.New("step: unexpected end of the file")
+		}
+
+		switch {
+		case p.consume("END-ISO-10303-21;"):
+			return entities, nil
+		case p.consume("ENDSEC;"):
+			inData = false
+		case p.content[p.offset] == '#' && inData:
+			id, entity, err := p.instance()
+			if err != nil {
+				return nil, err
+			}
+			entities[id] = entity
+		default:
+			keyword := p.keyword()
+			if keyword == "" {
+				return nil, p.errorf("unexpected character %q", p.content[p.offset])
+			}
+
+			// The header entities are skipped.
+			// Only the data sections, which may have parameters, are read.
+			inData = keyword == "DATA"
+			p.skipSpace()
+			if p.peek() == '(' {
+				if _, err := p.list(); err != nil {
+					return nil, err
+				}
+				p.skipSpace()
+			}
+			if !p.consume(";") {
+				return nil, p.errorf("expected \";\"")
+			}
+		}
+	}
+}
+
+func (p *stepParser) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("step offset %d: %s", p.offset, fmt.Sprintf(format, args...))
+}
+
+// skipSpace skips whitespace and comments.
+func (p *stepParser) skipSpace() {
+	for p.offset < len(p.content) {
+		switch {
+		case p.content[p.offset] == ' ' || p.content[p.offset] == '\t' || p.content[p.offset] == '\r' || p.content[p.offset] == '\n':
+			p.offset++
+		case bytes.HasPrefix(p.content[p.offset:], []byte("/*")):
+			end := bytes.Index(p.content[p.offset+2:], []byte("*/"))
+			if end < 0 {
+				p.offset = len(p.content)
+				return
+			}
+			p.offset += end + 4
+		default:
+			return
+		}
+	}
+}
+
+func (p *stepParser) peek() byte {
+	if p.offset >= len(p.content) {
+		return 0
+	}
+	return p.content[p.offset]
+}
+
+// consume skips the given text if the content continues with it.
+func (p *stepParser) consume(text string) bool {
+	if bytes.HasPrefix(p.content[p.offset:], []byte(text)) {
+		p.offset += len(text)
+		return true
+	}
+	return false
+}
+
+// keyword reads an entity or section name.
+func (p *stepParser) keyword() string {
+	start := p.offset
+	for p.offset < len(p.content) {
+		c := p.content[p.offset]
+		if c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '_' || c == '-' && p.offset > start {
+			p.offset++
+			continue
+		}
+		break
+	}
+	return strings.ToUpper(string(p.content[start:p.offset]))
+}
+
+// number reads the digits of an entity id or a number.
+func (p *stepParser) number() string {
+	start := p.offset
+	for p.offset < len(p.content) && strings.IndexByte("0123456789+-.eE", p.content[p.offset]) >= 0 {
+		p.offset++
+	}
+	return string(p.content[start:p.offset])
+}
+
+// instance reads one entity instance like #1=NAME(...); or #1=(NAME1(...)NAME2(...));
+func (p *stepParser) instance() (int, stepEntity, error) {
+	p.offset++ // skip #
+	id, err := strconv.Atoi(p.number())
+	if err != nil {
+		return 0, nil, p.errorf("invalid entity id")
+	}
+
+	p.skipSpace()
+	if !p.consume("=") {
+		return 0, nil, p.errorf("expected \"=\" after #%d", id)
+	}
+	p.skipSpace()
+
+	var entity stepEntity
+	if p.consume("(") {
+		// complex entity instance
+		for {
+			p.skipSpace()
+			if p.consume(")") {
+				break
+			}
+			record, err := p.record()
+			if err != nil {
+				return 0, nil, err
+			}
+			entity = append(entity, record)
+		}
+	} else {
+		record, err := p.record()
+		if err != nil {
+			return 0, nil, err
+		}
+		entity = stepEntity{record}
+	}
+
+	p.skipSpace()
+	if !p.consume(";") {
+		return 0, nil, p.errorf("expected \";\" after #%d", id)
+	}
+	return id, entity, nil
+}
+
+// record reads an entity name followed by its parameter list.
+func (p *stepParser) record() (stepRecord, error) {
+	name := p.keyword()
+	if name == "" {
+		return stepRecord{}, p.errorf("expected an entity name")
+	}
+	p.skipSpace()
+	params, err := p.list()
+	if err != nil {
+		return stepRecord{}, err
+	}
+	return stepRecord{name: name, params: params}, nil
+}
+
+// list reads a parenthesized, comma separated list of parameters.
+func (p *stepParser) list() ([]interface{}, error) {
+	if !p.consume("(") {
+		return nil, p.errorf("expected \"(\"")
+	}
+
+	result := []interface{}{}
+	for {
+		p.skipSpace()
+		if p.consume(")") {
+			return result, nil
+		}
+
+		value, err := p.parameter()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, value)
+
+		p.skipSpace()
+		if p.consume(",") {
+			continue
+		}
+		if p.consume(")") {
+			return result, nil
+		}
+		return nil, p.errorf("expected \",\" or \")\"")
+	}
+}
+
+// parameter reads a single parameter.
+func (p *stepParser) parameter() (interface{}, error) {
+	switch c := p.peek(); {
+	case c == '$' || c == '*':
+		p.offset++
+		return nil, nil
+	case c == '#':
+		p.offset++
+		id, err := strconv.Atoi(p.number())
+		if err != nil {
+			return nil, p.errorf("invalid reference")
+		}
+		return stepRef(id), nil
+	case c == '\'':
+		return p.string()
+	case c == '"':
+		// binary values are not needed
+		end := bytes.IndexByte(p.content[p.offset+1:], '"')
+		if end < 0 {
+			return nil, p.errorf("unterminated binary value")
+		}
+		p.offset += end + 2
+		return nil, nil
+	case c == '.':
+		end := bytes.IndexByte(p.content[p.offset+1:], '.')
+		if end < 0 {
+			return nil, p.errorf("unterminated enumeration")
+		}
+		value := stepEnum(p.content[p.offset+1 : p.offset+1+end])
+		p.offset += end + 2
+		return value, nil
+	case c == '(':
+		return p.list()
+	case c >= '0' && c <= '9' || c == '-' || c == '+':
+		text := p.number()
+		value, err := strconv.ParseFloat(text, 64)
+		if err != nil {
+			return nil, p.errorf("invalid number %q", text)
+		}
+		return value, nil
+	case c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z':
+		name := p.keyword()
+		p.skipSpace()
+		params, err := p.list()
+		if err != nil {
+			return nil, err
+		}
+		var value interface{}
+		if len(params) == 1 {
+			value = params[0]
+		}
+		return stepTyped{name: name, value: value}, nil
+	default:
+		return nil, p.errorf("unexpected character %q", c)
+	}
+}
+
+// string reads a string in single quotes. Two single quotes are an escaped quote.
+func (p *stepParser) string() (string, error) {
+	p.offset++
+	var result strings.Builder
+	for p.offset < len(p.content) {
+		c := p.content[p.offset]
+		p.offset++
+		if c != '\'' {
+			result.WriteByte(c)
+			continue
+		}
+		if p.peek() == '\'' {
+			result.WriteByte('\'')
+			p.offset++
+			continue
+		}
+		return result.String(), nil
+	}
+	return "", p.errorf("unterminated string")
+}
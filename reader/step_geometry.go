@@ -0,0 +1,836 @@
+// This file provides the tessellation of the geometry of STEP files.
+
+package reader
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"math"
+	"sort"
+)
+
+const (
+	// stepChordTolerance is the max distance in mm between a discretized curve and the exact curve.
+	stepChordTolerance = 0.01
+
+	// stepMinCircleSegments is the min amount of segments used for a full circle.
+	stepMinCircleSegments = 16
+)
+
+// stepVec is a point or direction in the units of the STEP file.
+type stepVec [3]float64
+
+func (v stepVec) add(o stepVec) stepVec {
+	return stepVec{v[0] + o[0], v[1] + o[1], v[2] + o[2]}
+}
+
+func (v stepVec) sub(o stepVec) stepVec {
+	return stepVec{v[0] - o[0], v[1] - o[1], v[2] - o[2]}
+}
+
+func (v stepVec) mul(f float64) stepVec {
+	return stepVec{v[0] * f, v[1] * f, v[2] * f}
+}
+
+func (v stepVec) dot(o stepVec) float64 {
+	return v[0]*o[0] + v[1]*o[1] + v[2]*o[2]
+}
+
+func (v stepVec) length() float64 {
+	return math.Sqrt(v.dot(v))
+}
+
+func (v stepVec) cross(o stepVec) stepVec {
+	return stepVec{v[1]*o[2] - v[2]*o[1], v[2]*o[0] - v[0]*o[2], v[0]*o[1] - v[1]*o[0]}
+}
+
+func (v stepVec) normalized() stepVec {
+	l := v.length()
+	if l == 0 {
+		return v
+	}
+	return v.mul(1 / l)
+}
+
+// stepPlacement is a local coordinate system defined by an AXIS2_PLACEMENT_3D.
+type stepPlacement struct {
+	origin, x, y, z stepVec
+}
+
+// local returns the coordinates of p in the coordinate system.
+func (p stepPlacement) local(v stepVec) stepVec {
+	d := v.sub(p.origin)
+	return stepVec{d.dot(p.x), d.dot(p.y), d.dot(p.z)}
+}
+
+// global converts local coordinates of the coordinate system to global ones.
+func (p stepPlacement) global(v stepVec) stepVec {
+	return p.origin.add(p.x.mul(v[0])).add(p.y.mul(v[1])).add(p.z.mul(v[2]))
+}
+
+// stepSurface maps the points of a face to a 2d parameter space in which the face is triangulated.
+type stepSurface struct {
+	placement stepPlacement
+
+	// periodic is true if the first parameter is an angle which repeats after 2π.
+	periodic bool
+
+	// radius scales the angle so that both parameters are about the same unit.
+	radius float64
+
+	// maxAngle is the max angle between two points of a triangle on periodic surfaces.
+	maxAngle float64
+}
+
+// param returns the parameters of the point.
+// For periodic surfaces the first parameter is in the range (-π*radius, π*radius].
+func (s stepSurface) param(v stepVec) [2]float64 {
+	l := s.placement.local(v)
+	if !s.periodic {
+		return [2]float64{l[0], l[1]}
+	}
+	return [2]float64{math.Atan2(l[1], l[0]) * s.radius, l[2]}
+}
+
+// period returns the length of the period of the first parameter.
+func (s stepSurface) period() float64 {
+	return 2 * math.Pi * s.radius
+}
+
+// point returns the point of a periodic surface at the given parameters and distance to the axis.
+func (s stepSurface) point(u, h, distance float64) stepVec {
+	angle := u / s.radius
+	return s.placement.global(stepVec{distance * math.Cos(angle), distance * math.Sin(angle), h})
+}
+
+// distance returns the distance of the point to the axis of a periodic surface.
+func (s stepSurface) distance(v stepVec) float64 {
+	l := s.placement.local(v)
+	return math.Hypot(l[0], l[1])
+}
+
+// segmentAngle returns the max angle of a segment of a circle with the given radius in mm
+// so that the chord tolerance is not exceeded.
+func segmentAngle(radius float64) float64 {
+	step := 2 * math.Acos(math.Max(-1, 1-stepChordTolerance/radius))
+	if maxStep := 2 * math.Pi / stepMinCircleSegments; step > maxStep || step <= 0 || math.IsNaN(step) {
+		return maxStep
+	}
+	return step
+}
+
+// stepModel tessellates the entities of a STEP file.
+type stepModel struct {
+	entities map[int]stepEntity
+
+	// scale converts the length unit of the file to mm.
+	scale float64
+
+	// edgeCache contains the discretized points of each edge curve from its start to its end vertex.
+	edgeCache map[int][]stepVec
+
+	// unsupported contains the names of the entities which could not be tessellated.
+	unsupported map[string]bool
+}
+
+// entity returns the record of the referenced entity.
+// For complex entities the first record which has one of the given names is returned.
+// If no names are given, the first record is returned.
+func (s *stepModel) entity(value interface{}, names ...string) (stepRecord, error) {
+	ref, ok := value.(stepRef)
+	if !ok {
+		return stepRecord{}, fmt.Errorf("step: expected a reference but got %v", value)
+	}
+	entity, ok := s.entities[int(ref)]
+	if !ok || len(entity) == 0 {
+		return stepRecord{}, fmt.Errorf("step: the entity #%d does not exist", ref)
+	}
+
+	if len(names) == 0 {
+		return entity[0], nil
+	}
+	for _, name := range names {
+		if record, ok := entity.record(name); ok {
+			return record, nil
+		}
+	}
+	return stepRecord{}, fmt.Errorf("step: the entity #%d is no %v", ref, names)
+}
+
+// param returns the parameter with the given index or an error if it does not exist.
+func param(r stepRecord, index int) (interface{}, error) {
+	if index >= len(r.params) {
+		return nil, fmt.Errorf("step: %s has not enough parameters", r.name)
+	}
+	return r.params[index], nil
+}
+
+func paramList(r stepRecord, index int) ([]interface{}, error) {
+	value, err := param(r, index)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := value.([]interface{})
+	if !ok {
+		return nil, fmt.Errorf("step: parameter %d of %s is no list", index+1, r.name)
+	}
+	return list, nil
+}
+
+func paramNumber(r stepRecord, index int) (float64, error) {
+	value, err := param(r, index)
+	if err != nil {
+		return 0, err
+	}
+	if typed, ok := value.(stepTyped); ok {
+		value = typed.value
+	}
+	number, ok := value.(float64)
+	if !ok {
+		return 0, fmt.Errorf("step: parameter %d of %s is no number", index+1, r.name)
+	}
+	return number, nil
+}
+
+// paramBool returns the value of a logical parameter. Unknown values are true.
+func paramBool(r stepRecord, index int) (bool, error) {
+	value, err := param(r, index)
+	if err != nil {
+		return false, err
+	}
+	enum, ok := value.(stepEnum)
+	if !ok {
+		return false, fmt.Errorf("step: parameter %d of %s is no logical value", index+1, r.name)
+	}
+	return enum != "F", nil
+}
+
+// numbers converts a list of numbers.
+func numbers(list []interface{}) ([]float64, error) {
+	result := make([]float64, len(list))
+	for i, value := range list {
+		number, ok := value.(float64)
+		if !ok {
+			return nil, fmt.Errorf("step: expected a number but got %v", value)
+		}
+		result[i] = number
+	}
+	return result, nil
+}
+
+// vector reads a CARTESIAN_POINT or DIRECTION.
+func (s *stepModel) vector(value interface{}) (stepVec, error) {
+	record, err := s.entity(value, "CARTESIAN_POINT", "DIRECTION")
+	if err != nil {
+		return stepVec{}, err
+	}
+	list, err := paramList(record, 1)
+	if err != nil {
+		return stepVec{}, err
+	}
+	coordinates, err := numbers(list)
+	if err != nil {
+		return stepVec{}, err
+	}
+
+	var v stepVec
+	copy(v[:], coordinates)
+	return v, nil
+}
+
+// vertex reads the point of a VERTEX_POINT.
+func (s *stepModel) vertex(value interface{}) (stepVec, error) {
+	record, err := s.entity(value, "VERTEX_POINT")
+	if err != nil {
+		return stepVec{}, err
+	}
+	point, err := param(record, 1)
+	if err != nil {
+		return stepVec{}, err
+	}
+	return s.vector(point)
+}
+
+// placement reads an AXIS2_PLACEMENT_3D.
+func (s *stepModel) placement(value interface{}) (stepPlacement, error) {
+	record, err := s.entity(value, "AXIS2_PLACEMENT_3D")
+	if err != nil {
+		return stepPlacement{}, err
+	}
+
+	origin, err := param(record, 1)
+	if err != nil {
+		return stepPlacement{}, err
+	}
+	var p stepPlacement
+	if p.origin, err = s.vector(origin); err != nil {
+		return stepPlacement{}, err
+	}
+
+	// the axis and the ref direction are optional
+	p.z = stepVec{0, 0, 1}
+	if axis, _ := param(record, 2); axis != nil {
+		if p.z, err = s.vector(axis); err != nil {
+			return stepPlacement{}, err
+		}
+	}
+	p.z = p.z.normalized()
+
+	p.x = stepVec{1, 0, 0}
+	if refDirection, _ := param(record, 3); refDirection != nil {
+		if p.x, err = s.vector(refDirection); err != nil {
+			return stepPlacement{}, err
+		}
+	} else if math.Abs(p.z[0]) > 0.9 {
+		p.x = stepVec{0, 1, 0}
+	}
+
+	// make x perpendicular to z
+	p.x = p.x.sub(p.z.mul(p.x.dot(p.z))).normalized()
+	p.y = p.z.cross(p.x)
+	return p, nil
+}
+
+// surfacePlacement reads the placement of a surface or curve, which is its first parameter after the name.
+func (s *stepModel) surfacePlacement(record stepRecord) (stepPlacement, error) {
+	value, err := param(record, 1)
+	if err != nil {
+		return stepPlacement{}, err
+	}
+	return s.placement(value)
+}
+
+// lengthUnit returns the factor to convert the length unit of the file to mm.
+// If the file does not define a length unit, mm are used.
+func (s *stepModel) lengthUnit() (float64, error) {
+	var ids []int
+	for id, entity := range s.entities {
+		if _, ok := entity.record("LENGTH_UNIT"); ok {
+			ids = append(ids, id)
+		}
+	}
+	if len(ids) == 0 {
+		return 1, nil
+	}
+
+	sort.Ints(ids)
+	return s.unitScale(stepRef(ids[0]))
+}
+
+// unitScale returns the factor to convert the referenced length unit to mm.
+func (s *stepModel) unitScale(ref stepRef) (float64, error) {
+	entity := s.entities[int(ref)]
+
+	if si, ok := entity.record("SI_UNIT"); ok {
+		prefix, _ := param(si, 0)
+		switch prefix {
+		case nil:
+			return 1000, nil
+		case stepEnum("KILO"):
+			return 1000000, nil
+		case stepEnum("DECI"):
+			return 100, nil
+		case stepEnum("CENTI"):
+			return 10, nil
+		case stepEnum("MILLI"):
+			return 1, nil
+		case stepEnum("MICRO"):
+			return 0.001, nil
+		default:
+			return 0, fmt.Errorf("step: unsupported unit prefix %v", prefix)
+		}
+	}
+
+	if conversion, ok := entity.record("CONVERSION_BASED_UNIT"); ok {
+		factor, err := param(conversion, 1)
+		if err != nil {
+			return 0, err
+		}
+		measure, err := s.entity(factor, "LENGTH_MEASURE_WITH_UNIT", "MEASURE_WITH_UNIT")
+		if err != nil {
+			return 0, err
+		}
+		value, err := paramNumber(measure, 0)
+		if err != nil {
+			return 0, err
+		}
+		unitValue, err := param(measure, 1)
+		if err != nil {
+			return 0, err
+		}
+		unit, ok := unitValue.(stepRef)
+		if !ok {
+			return 0, fmt.Errorf("step: invalid unit of #%d", ref)
+		}
+		scale, err := s.unitScale(unit)
+		if err != nil {
+			return 0, err
+		}
+		return value * scale, nil
+	}
+
+	return 0, fmt.Errorf("step: unsupported length unit #%d", ref)
+}
+
+// sortedIDs returns the ids of all entities containing a record with one of the given names in ascending order.
+func (s *stepModel) sortedIDs(names ...string) []int {
+	var ids []int
+	for id, entity := range s.entities {
+		for _, name := range names {
+			if _, ok := entity.record(name); ok {
+				ids = append(ids, id)
+				break
+			}
+		}
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+// tessellate returns the triangles of the model.
+// If the file contains tessellated geometry it is used, otherwise the faces of the boundary representation are tessellated.
+func (s *stepModel) tessellate() ([]data.Face, error) {
+	var triangles [][3]stepVec
+
+	if ids := s.sortedIDs("TRIANGULATED_FACE", "TRIANGULATED_SURFACE_SET"); len(ids) > 0 {
+		for _, id := range ids {
+			t, err := s.triangulatedFace(id)
+			if err != nil {
+				return nil, err
+			}
+			triangles = append(triangles, t...)
+		}
+	} else {
+		for _, id := range s.sortedIDs("ADVANCED_FACE", "FACE_SURFACE") {
+			t, err := s.face(id)
+			if err != nil {
+				return nil, err
+			}
+			triangles = append(triangles, t...)
+		}
+	}
+
+	faces := make([]data.Face, 0, len(triangles))
+	for _, t := range triangles {
+		var vectors [3]data.MicroVec3
+		for i, v := range t {
+			vectors[i] = data.NewMicroVec3(
+				data.Millimeter(v[0]*s.scale).ToMicrometer(),
+				data.Millimeter(v[1]*s.scale).ToMicrometer(),
+				data.Millimeter(v[2]*s.scale).ToMicrometer(),
+			)
+		}
+		faces = append(faces, face{vectors: vectors})
+	}
+	return faces, nil
+}
+
+// triangulatedFace reads the triangles of a TRIANGULATED_FACE or TRIANGULATED_SURFACE_SET.
+func (s *stepModel) triangulatedFace(id int) ([][3]stepVec, error) {
+	record, ok := s.entities[id].record("TRIANGULATED_FACE")
+	pnindexParam, trianglesParam := 5, 6
+	if !ok {
+		record, ok = s.entities[id].record("TRIANGULATED_SURFACE_SET")
+		pnindexParam, trianglesParam = 4, 5
+	}
+	if !ok {
+		return nil, fmt.Errorf("step: the entity #%d is no TRIANGULATED_FACE or TRIANGULATED_SURFACE_SET", id)
+	}
+
+	coordinatesRef, err := param(record, 1)
+	if err != nil {
+		return nil, err
+	}
+	coordinatesList, err := s.entity(coordinatesRef, "COORDINATES_LIST")
+	if err != nil {
+		return nil, err
+	}
+	coordinateValues, err := paramList(coordinatesList, 2)
+	if err != nil {
+		return nil, err
+	}
+	coordinates := make([]stepVec, len(coordinateValues))
+	for i, value := range coordinateValues {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("step: invalid coordinates of #%d", id)
+		}
+		point, err := numbers(list)
+		if err != nil {
+			return nil, err
+		}
+		copy(coordinates[i][:], point)
+	}
+
+	pnindexValues, err := paramList(record, pnindexParam)
+	if err != nil {
+		return nil, err
+	}
+	pnindex, err := numbers(pnindexValues)
+	if err != nil {
+		return nil, err
+	}
+
+	// point returns the point of a 1 based index which refers to pnindex if it is not empty.
+	point := func(index float64) (stepVec, error) {
+		i := int(index) - 1
+		if len(pnindex) > 0 {
+			if i < 0 || i >= len(pnindex) {
+				return stepVec{}, fmt.Errorf("step: invalid point index %v in #%d", index, id)
+			}
+			i = int(pnindex[i]) - 1
+		}
+		if i < 0 || i >= len(coordinates) {
+			return stepVec{}, fmt.Errorf("step: invalid point index %v in #%d", index, id)
+		}
+		return coordinates[i], nil
+	}
+
+	triangleValues, err := paramList(record, trianglesParam)
+	if err != nil {
+		return nil, err
+	}
+
+	var result [][3]stepVec
+	for _, value := range triangleValues {
+		list, ok := value.([]interface{})
+		if !ok {
+			return nil, fmt.Errorf("step: invalid triangle in #%d", id)
+		}
+		indices, err := numbers(list)
+		if err != nil || len(indices) != 3 {
+			return nil, fmt.Errorf("step: invalid triangle in #%d", id)
+		}
+
+		var t [3]stepVec
+		for i, index := range indices {
+			if t[i], err = point(index); err != nil {
+				return nil, err
+			}
+		}
+		result = append(result, t)
+	}
+	return result, nil
+}
+
+// face tessellates an ADVANCED_FACE or FACE_SURFACE.
+// Unsupported surfaces and curves are added to stepModel.unsupported.
+func (s *stepModel) face(id int) ([][3]stepVec, error) {
+	record, ok := s.entities[id].record("ADVANCED_FACE")
+	if !ok {
+		record, ok = s.entities[id].record("FACE_SURFACE")
+	}
+	if !ok {
+		return nil, fmt.Errorf("step: the entity #%d is no ADVANCED_FACE or FACE_SURFACE", id)
+	}
+
+	bounds, err := paramList(record, 1)
+	if err != nil {
+		return nil, err
+	}
+	sameSense, err := paramBool(record, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	surfaceRef, err := param(record, 2)
+	if err != nil {
+		return nil, err
+	}
+	surfaceRecord, err := s.entity(surfaceRef)
+	if err != nil {
+		return nil, err
+	}
+	surface := stepSurface{radius: 1}
+	switch surfaceRecord.name {
+	case "PLANE":
+		surface.placement, err = s.surfacePlacement(surfaceRecord)
+	case "CYLINDRICAL_SURFACE", "CONICAL_SURFACE":
+		surface.placement, err = s.surfacePlacement(surfaceRecord)
+		if err == nil {
+			surface.periodic = true
+			surface.radius, err = paramNumber(surfaceRecord, 2)
+			if surface.radius <= 0 {
+				// cones may have a radius of 0 at the placement
+				surface.radius = 1
+			}
+			surface.maxAngle = segmentAngle(surface.radius * s.scale)
+		}
+	default:
+		s.unsupported[surfaceRecord.name] = true
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	var loops [][]stepVec
+	outer := -1
+	for _, bound := range bounds {
+		boundRecord, err := s.entity(bound, "FACE_OUTER_BOUND", "FACE_BOUND")
+		if err != nil {
+			return nil, err
+		}
+
+		loopRef, err := param(boundRecord, 1)
+		if err != nil {
+			return nil, err
+		}
+		loop, err := s.loop(loopRef)
+		if err != nil {
+			return nil, err
+		}
+		if loop == nil {
+			// vertex loops and unsupported curves
+			continue
+		}
+
+		orientation, err := paramBool(boundRecord, 2)
+		if err != nil {
+			return nil, err
+		}
+		if !orientation {
+			reverseVecs(loop)
+		}
+
+		if boundRecord.name == "FACE_OUTER_BOUND" {
+			outer = len(loops)
+		}
+		loops = append(loops, loop)
+	}
+
+	if len(loops) == 0 {
+		return nil, nil
+	}
+
+	triangles, ok := triangulateSurface(surface, loops, outer)
+	if !ok {
+		s.unsupported[surfaceRecord.name+" with this topology"] = true
+		return nil, nil
+	}
+
+	if !sameSense {
+		for i := range triangles {
+			triangles[i][1], triangles[i][2] = triangles[i][2], triangles[i][1]
+		}
+	}
+	return triangles, nil
+}
+
+// loop returns the discretized points of an EDGE_LOOP or POLY_LOOP.
+// It returns nil for vertex loops and for loops with unsupported curves.
+func (s *stepModel) loop(value interface{}) ([]stepVec, error) {
+	record, err := s.entity(value, "EDGE_LOOP", "POLY_LOOP", "VERTEX_LOOP")
+	if err != nil {
+		return nil, err
+	}
+
+	switch record.name {
+	case "VERTEX_LOOP":
+		return nil, nil
+	case "POLY_LOOP":
+		list, err := paramList(record, 1)
+		if err != nil {
+			return nil, err
+		}
+		var points []stepVec
+		for _, point := range list {
+			v, err := s.vector(point)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, v)
+		}
+		return points, nil
+	}
+
+	edges, err := paramList(record, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	var points []stepVec
+	for _, edge := range edges {
+		orientedEdge, err := s.entity(edge, "ORIENTED_EDGE")
+		if err != nil {
+			return nil, err
+		}
+		edgeCurveRef, err := param(orientedEdge, 3)
+		if err != nil {
+			return nil, err
+		}
+		edgeCurve, ok := edgeCurveRef.(stepRef)
+		if !ok {
+			return nil, fmt.Errorf("step: invalid edge in %v", value)
+		}
+
+		edgePoints, err := s.edge(int(edgeCurve))
+		if err != nil || edgePoints == nil {
+			return nil, err
+		}
+
+		orientation, err := paramBool(orientedEdge, 4)
+		if err != nil {
+			return nil, err
+		}
+
+		// the last point is the first point of the next edge
+		if orientation {
+			for i := 0; i < len(edgePoints)-1; i++ {
+				points = append(points, edgePoints[i])
+			}
+		} else {
+			for i := len(edgePoints) - 1; i > 0; i-- {
+				points = append(points, edgePoints[i])
+			}
+		}
+	}
+	return points, nil
+}
+
+// edge returns the discretized points of an EDGE_CURVE from its start to its end vertex.
+// It returns nil if the curve is not supported.
+func (s *stepModel) edge(id int) ([]stepVec, error) {
+	if points, ok := s.edgeCache[id]; ok {
+		return points, nil
+	}
+
+	record, ok := s.entities[id].record("EDGE_CURVE")
+	if !ok {
+		return nil, fmt.Errorf("step: the entity #%d is no EDGE_CURVE", id)
+	}
+
+	startRef, err := param(record, 1)
+	if err != nil {
+		return nil, err
+	}
+	endRef, err := param(record, 2)
+	if err != nil {
+		return nil, err
+	}
+	curveRef, err := param(record, 3)
+	if err != nil {
+		return nil, err
+	}
+
+	start, err := s.vertex(startRef)
+	if err != nil {
+		return nil, err
+	}
+	end, err := s.vertex(endRef)
+	if err != nil {
+		return nil, err
+	}
+	sameSense, err := paramBool(record, 4)
+	if err != nil {
+		return nil, err
+	}
+	closed := startRef == endRef
+
+	curve, err := s.entity(curveRef)
+	if err != nil {
+		return nil, err
+	}
+	// surface curves contain the 3d curve as first parameter
+	for curve.name == "SURFACE_CURVE" || curve.name == "SEAM_CURVE" {
+		if curveRef, err = param(curve, 1); err != nil {
+			return nil, err
+		}
+		if curve, err = s.entity(curveRef); err != nil {
+			return nil, err
+		}
+	}
+
+	var points []stepVec
+	switch curve.name {
+	case "LINE":
+		points = []stepVec{start, end}
+	case "POLYLINE":
+		list, err := paramList(curve, 1)
+		if err != nil {
+			return nil, err
+		}
+		for _, point := range list {
+			v, err := s.vector(point)
+			if err != nil {
+				return nil, err
+			}
+			points = append(points, v)
+		}
+		if len(points) > 0 && points[0].sub(start).length() > points[len(points)-1].sub(start).length() {
+			reverseVecs(points)
+		}
+		// use the exact vertices at both ends
+		if len(points) >= 2 {
+			points[0], points[len(points)-1] = start, end
+		}
+	case "CIRCLE", "ELLIPSE":
+		points, err = s.conic(curve, start, end, sameSense, closed)
+		if err != nil {
+			return nil, err
+		}
+	default:
+		s.unsupported[curve.name] = true
+		return nil, nil
+	}
+
+	s.edgeCache[id] = points
+	return points, nil
+}
+
+// conic discretizes the arc of a CIRCLE or ELLIPSE from start to end.
+// If sameSense is true, the arc runs counter clockwise around the axis of the curve, otherwise clockwise.
+func (s *stepModel) conic(curve stepRecord, start, end stepVec, sameSense, closed bool) ([]stepVec, error) {
+	placement, err := s.surfacePlacement(curve)
+	if err != nil {
+		return nil, err
+	}
+	a, err := paramNumber(curve, 2)
+	if err != nil {
+		return nil, err
+	}
+	b := a
+	if curve.name == "ELLIPSE" {
+		if b, err = paramNumber(curve, 3); err != nil {
+			return nil, err
+		}
+	}
+
+	angle := func(v stepVec) float64 {
+		l := placement.local(v)
+		return math.Atan2(l[1]/b, l[0]/a)
+	}
+
+	startAngle := angle(start)
+	delta := angle(end) - startAngle
+	if !sameSense {
+		delta = -delta
+	}
+	for delta <= 1e-9 {
+		delta += 2 * math.Pi
+	}
+	if closed {
+		delta = 2 * math.Pi
+	}
+
+	segments := int(math.Ceil(delta / segmentAngle(math.Max(a, b)*s.scale)))
+
+	if !sameSense {
+		delta = -delta
+	}
+
+	points := make([]stepVec, segments+1)
+	points[0] = start
+	for i := 1; i < segments; i++ {
+		t := startAngle + delta*float64(i)/float64(segments)
+		points[i] = placement.global(stepVec{a * math.Cos(t), b * math.Sin(t), 0})
+	}
+	points[segments] = end
+	return points, nil
+}
+
+func reverseVecs(v []stepVec) {
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+}
@@ -0,0 +1,163 @@
+package reader
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"io/ioutil"
+	"math"
+	"strings"
+	"testing"
+)
+
+// testTessellatedSTEP is a tetrahedron with 10 mm long edges as AP242 tessellated geometry in meter.
+const testTessellatedSTEP = `ISO-10303-21;
+HEADER;
+FILE_DESCRIPTION((''),'2;1');
+FILE_NAME('tetrahedron.step','',(''),(''),'','','');
+FILE_SCHEMA(('AP242_MANAGED_MODEL_BASED_3D_ENGINEERING_MIM_LF'));
+ENDSEC;
+DATA;
+/* the length unit is meter */
+#1=(LENGTH_UNIT()NAMED_UNIT(*)SI_UNIT($,.METRE.));
+#2=COORDINATES_LIST('',4,((0.,0.,0.),(0.01,0.,0.),(0.,0.01,0.),(0.,0.,0.01)));
+#3=TRIANGULATED_SURFACE_SET('it''s a tetrahedron',#2,4,(),(),((1,3,2),(1,2,4),(1,4,3),(2,3,4)));
+ENDSEC;
+END-ISO-10303-21;
+`
+
+// testTetrahedronRecord is the record of the triangles in testTessellatedSTEP which is replaced to test other geometry.
+const testTetrahedronRecord = "#3=TRIANGULATED_SURFACE_SET('it''s a tetrahedron',#2,4,(),(),((1,3,2),(1,2,4),(1,4,3),(2,3,4)));"
+
+// volume calculates the volume enclosed by the faces of the model in mm³.
+// It is only positive if all faces point outwards.
+func volume(m data.Model) float64 {
+	var result float64
+	for i := 0; i < m.FaceCount(); i++ {
+		points := m.Face(i).Points()
+		var v [3][3]float64
+		for j, p := range points {
+			v[j] = [3]float64{float64(p.X().ToMillimeter()), float64(p.Y().ToMillimeter()), float64(p.Z().ToMillimeter())}
+		}
+		result += (v[0][0]*(v[1][1]*v[2][2]-v[1][2]*v[2][1]) -
+			v[0][1]*(v[1][0]*v[2][2]-v[1][2]*v[2][0]) +
+			v[0][2]*(v[1][0]*v[2][1]-v[1][1]*v[2][0])) / 6
+	}
+	return result
+}
+
+func TestReadSTEP(t *testing.T) {
+	plateVolume := 20*20*5 - math.Pi*3*3*5
+
+	var testCases = map[string]struct {
+		file           string
+		content        string
+		expectedVolume float64
+		expectedError  string
+	}{
+		"PlanarFaces": {
+			file:           "testdata/cube.step",
+			expectedVolume: 1000,
+		},
+		"CylindricalHole": {
+			file:           "testdata/plate.step",
+			expectedVolume: plateVolume,
+		},
+		"CylindricalHoleWithSeam": {
+			file:           "testdata/plate_seam.step",
+			expectedVolume: plateVolume,
+		},
+		"TessellatedInMeter": {
+			content:        testTessellatedSTEP,
+			expectedVolume: 1000.0 / 6,
+		},
+		"UnsupportedSurface": {
+			content:       strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(),#4,.T.);\n#4=SPHERICAL_SURFACE('',#5,1.);", 1),
+			expectedError: "unsupported geometry SPHERICAL_SURFACE",
+		},
+		"TruncatedSurface": {
+			content:       strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(),#4,.T.);\n#4=PLANE('');", 1),
+			expectedError: "PLANE has not enough parameters",
+		},
+		"TruncatedFace": {
+			content:       strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',());", 1),
+			expectedError: "ADVANCED_FACE has not enough parameters",
+		},
+		"TruncatedPlacement": {
+			content:       strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(),#4,.T.);\n#4=PLANE('',#5);\n#5=AXIS2_PLACEMENT_3D('');", 1),
+			expectedError: "AXIS2_PLACEMENT_3D has not enough parameters",
+		},
+		"TruncatedEdge": {
+			content: strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(#6),#4,.T.);\n#4=PLANE('',#5);\n"+
+				"#5=AXIS2_PLACEMENT_3D('',#9,$,$);\n#6=FACE_OUTER_BOUND('',#7,.T.);\n#7=EDGE_LOOP('',(#8));\n"+
+				"#8=ORIENTED_EDGE('',*,*,#10,.T.);\n#9=CARTESIAN_POINT('',(0.,0.,0.));\n#10=EDGE_CURVE('',#11);", 1),
+			expectedError: "EDGE_CURVE has not enough parameters",
+		},
+		"TruncatedOrientedEdge": {
+			content: strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(#6),#4,.T.);\n#4=PLANE('',#5);\n"+
+				"#5=AXIS2_PLACEMENT_3D('',#9,$,$);\n#6=FACE_OUTER_BOUND('',#7,.T.);\n#7=EDGE_LOOP('',(#8));\n"+
+				"#8=ORIENTED_EDGE('',*);\n#9=CARTESIAN_POINT('',(0.,0.,0.));", 1),
+			expectedError: "ORIENTED_EDGE has not enough parameters",
+		},
+		"TruncatedTriangulatedFace": {
+			content:       strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=TRIANGULATED_FACE('');", 1),
+			expectedError: "TRIANGULATED_FACE has not enough parameters",
+		},
+		"NoSTEP": {
+			content:       testASCIISTL,
+			expectedError: "does not start with",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		content := []byte(testCase.content)
+		if testCase.file != "" {
+			var err error
+			content, err = ioutil.ReadFile(testCase.file)
+			test.Ok(t, err)
+		}
+
+		m, err := readSTEP(strings.NewReader(string(content)), nil, 0)
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error %v should contain %q", err, testCase.expectedError)
+			continue
+		}
+
+		test.Ok(t, err)
+		actual := volume(m)
+		test.Assert(t, math.Abs(actual-testCase.expectedVolume) < testCase.expectedVolume*0.001, "expected the volume %v but got %v", testCase.expectedVolume, actual)
+	}
+}
+
+// fakeTessellator returns a fixed model.
+type fakeTessellator struct {
+	called bool
+}
+
+func (f *fakeTessellator) Tessellate(content []byte) (data.Model, error) {
+	f.called = true
+	return readSTL(strings.NewReader(testASCIISTL), &packedModel{})
+}
+
+func TestReadSTEPWithTessellator(t *testing.T) {
+	options := data.DefaultOptions()
+	tessellator := &fakeTessellator{}
+	r := Reader(&options, WithStepTessellator(tessellator))
+
+	content := strings.Replace(testTessellatedSTEP, testTetrahedronRecord, "#3=ADVANCED_FACE('',(),#4,.T.);\n#4=B_SPLINE_SURFACE_WITH_KNOTS('');", 1)
+	m, err := r.(*reader).ReadStream(strings.NewReader(content), "stp")
+	test.Ok(t, err)
+	test.Assert(t, tessellator.called, "the tessellator should be used")
+	test.Equals(t, 1, m.FaceCount())
+
+	// the input unit is also applied to the model of the tessellator
+	options.GoSlice.InputUnit = "cm"
+	scaled, err := r.(*reader).ReadStream(strings.NewReader(content), "stp")
+	test.Ok(t, err)
+	for i, point := range scaled.Face(0).Points() {
+		expected := m.Face(0).Points()[i]
+		test.Equals(t, expected.X()*10, point.X())
+		test.Equals(t, expected.Y()*10, point.Y())
+		test.Equals(t, expected.Z()*10, point.Z())
+	}
+}
@@ -0,0 +1,340 @@
+// This file provides a reader for ASCII and binary STL files.
+
+package reader
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"math"
+	"os"
+	"strconv"
+)
+
+const (
+	// stlBinaryHeaderSize is the size of the header of binary STL files including the triangle count.
+	stlBinaryHeaderSize = 84
+
+	// stlBinaryTriangleSize is the size of one triangle in binary STL files.
+	stlBinaryTriangleSize = 50
+)
+
+// stlPeekSize is the amount of bytes which are used to detect the format if the size of the file is unknown.
+const stlPeekSize = 512
+
+// readSTL reads a model in the ASCII or binary STL format into the store.
+// The format is detected by the content and not only by the "solid" keyword
+// at the beginning, as several binary files also start with it.
+//
+// The file is streamed, so the whole file is never kept in memory.
+// The faces are kept as defined by the store, packed in memory or in a temporary file.
+// If the file cannot be read, the store is closed.
+func readSTL(r io.Reader, store faceStore) (data.Model, error) {
+	size := streamSize(r)
+	buffered := bufio.NewReaderSize(r, 64*1024)
+	// the error is ignored as an incomplete header is detected when reading
+	header, _ := buffered.Peek(stlPeekSize)
+
+	var err error
+	if isBinarySTL(header, size) {
+		err = readBinarySTL(buffered, size, store)
+	} else {
+		err = readASCIISTL(buffered, store)
+	}
+	if err == nil {
+		err = store.finish()
+	}
+	if err == nil && store.FaceCount() == 0 {
+		err = errors.New("the stl file does not contain any faces")
+	}
+	if err != nil {
+		closeModel(store)
+		return nil, err
+	}
+
+	return store, nil
+}
+
+// streamSize returns the amount of bytes left in the reader if it is known, e.g. for files.
+// Otherwise -1 is returned.
+func streamSize(r io.Reader) int64 {
+	switch s := r.(type) {
+	case interface{ Len() int }:
+		return int64(s.Len())
+	case *os.File:
+		info, err := s.Stat()
+		if err != nil || !info.Mode().IsRegular() {
+			return -1
+		}
+		offset, err := s.Seek(0, io.SeekCurrent)
+		if err != nil {
+			return -1
+		}
+		return info.Size() - offset
+	default:
+		return -1
+	}
+}
+
+// isBinarySTL detects if the content starting with the given header is a binary STL.
+// If the size is known and matches the triangle count in the header, it is binary.
+// Otherwise it is binary if it does not start with "solid".
+// If the size is unknown, e.g. for compressed files, a file starting with "solid" is also binary if the header contains
+// control characters, as the triangle count and the coordinates of binary files almost always contain some.
+func isBinarySTL(header []byte, size int64) bool {
+	if size >= 0 && len(header) >= stlBinaryHeaderSize {
+		count := binary.LittleEndian.Uint32(header[80:84])
+		if uint64(size) == stlBinaryHeaderSize+uint64(count)*stlBinaryTriangleSize {
+			return true
+		}
+	}
+
+	if !bytes.HasPrefix(bytes.TrimLeft(header, " \t\r\n"), []byte("solid")) {
+		return true
+	}
+	if size >= 0 {
+		return false
+	}
+
+	for _, b := range header {
+		if b < ' ' && (b == 0 || !isSTLWhitespace(b)) {
+			return true
+		}
+	}
+	return false
+}
+
+// readBinarySTL reads the faces of a binary STL into the model.
+// If the size is known it is used to detect incomplete files before reading them.
+// Additional bytes after the last triangle are ignored.
+func readBinarySTL(r io.Reader, size int64, model faceStore) error {
+	header := make([]byte, stlBinaryHeaderSize)
+	if n, err := io.ReadFull(r, header); err != nil {
+		if err == io.EOF || err == io.ErrUnexpectedEOF {
+			return fmt.Errorf("stl offset %d: the binary header is incomplete", n)
+		}
+		return err
+	}
+
+	count := binary.LittleEndian.Uint32(header[80:84])
+	expectedSize := stlBinaryHeaderSize + uint64(count)*stlBinaryTriangleSize
+	if size >= 0 && uint64(size) < expectedSize {
+		return fmt.Errorf("stl offset %d: the file contains only %d of %d triangles", size, (size-stlBinaryHeaderSize)/stlBinaryTriangleSize, count)
+	}
+
+	// the count of a file with unknown size may be wrong, so only a part is reserved
+	reserved := int(count)
+	if size < 0 && reserved > 1<<16 {
+		reserved = 1 << 16
+	}
+	model.reserve(reserved)
+
+	triangle := make([]byte, stlBinaryTriangleSize)
+	for i := uint32(0); i < count; i++ {
+		offset := stlBinaryHeaderSize + int64(i)*stlBinaryTriangleSize
+		if n, err := io.ReadFull(r, triangle); err != nil {
+			if err == io.EOF || err == io.ErrUnexpectedEOF {
+				return fmt.Errorf("stl offset %d: the file contains only %d of %d triangles", offset+int64(n), i, count)
+			}
+			return err
+		}
+
+		var coordinates [9]data.Micrometer
+		for j := range coordinates {
+			// skip the normal
+			coordinateOffset := 12 + j*4
+			bits := binary.LittleEndian.Uint32(triangle[coordinateOffset:])
+			value := float64(math.Float32frombits(bits))
+			if math.IsNaN(value) || math.IsInf(value, 0) {
+				return fmt.Errorf("stl offset %d: invalid coordinate %v", offset+int64(coordinateOffset), value)
+			}
+			coordinates[j] = data.Millimeter(value).ToMicrometer()
+		}
+
+		if err := model.add(coordinates); err != nil {
+			return fmt.Errorf("stl offset %d: %v", offset, err)
+		}
+	}
+
+	return nil
+}
+
+// stlToken is a whitespace separated word of an ASCII STL together with its byte offset.
+type stlToken struct {
+	value  string
+	offset int
+}
+
+// stlTokenizer splits an ASCII STL into stlTokens while reading it.
+// As any whitespace is used as separator, missing newlines and additional whitespace are no problem.
+type stlTokenizer struct {
+	r      io.ByteReader
+	offset int
+	buffer []byte
+
+	// err is the first read error other than io.EOF.
+	err error
+}
+
+// next returns the next token. At the end an empty token with the offset of the end is returned.
+func (t *stlTokenizer) next() stlToken {
+	b, ok := t.readByte()
+	for ok && isSTLWhitespace(b) {
+		b, ok = t.readByte()
+	}
+
+	start := t.offset
+	if ok {
+		start--
+	}
+
+	t.buffer = t.buffer[:0]
+	for ok && !isSTLWhitespace(b) {
+		t.buffer = append(t.buffer, b)
+		b, ok = t.readByte()
+	}
+
+	return stlToken{value: string(t.buffer), offset: start}
+}
+
+// readByte reads the next byte and returns false at the end or on errors.
+func (t *stlTokenizer) readByte() (byte, bool) {
+	if t.err != nil {
+		return 0, false
+	}
+
+	b, err := t.r.ReadByte()
+	if err != nil {
+		if err != io.EOF {
+			t.err = err
+		}
+		return 0, false
+	}
+	t.offset++
+	return b, true
+}
+
+// expect reads the next token and returns an error if it is not the expected keyword.
+func (t *stlTokenizer) expect(keyword string) error {
+	token := t.next()
+	if token.value != keyword {
+		return unexpectedSTLToken(token, keyword)
+	}
+	return nil
+}
+
+// number reads the next token as number.
+func (t *stlTokenizer) number() (float64, error) {
+	token := t.next()
+	value, err := strconv.ParseFloat(token.value, 32)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return 0, unexpectedSTLToken(token, "a number")
+	}
+	return value, nil
+}
+
+func isSTLWhitespace(b byte) bool {
+	return b == ' ' || b == '\t' || b == '\r' || b == '\n' || b == '\f' || b == '\v' || b == 0
+}
+
+func unexpectedSTLToken(token stlToken, expected string) error {
+	if token.value == "" {
+		return fmt.Errorf("stl offset %d: expected %s but the file ended", token.offset, expected)
+	}
+	return fmt.Errorf("stl offset %d: expected %s but got %q", token.offset, expected, token.value)
+}
+
+// readASCIISTL reads the faces of an ASCII STL into the model.
+// Several solids in one file are supported and the names of the solids are ignored.
+func readASCIISTL(r io.ByteReader, model faceStore) error {
+	tokenizer := &stlTokenizer{r: r}
+	if err := readASCIISTLSolids(tokenizer, model); err != nil {
+		return err
+	}
+	return tokenizer.err
+}
+
+// readASCIISTLSolids reads all solids using the tokenizer.
+func readASCIISTLSolids(tokenizer *stlTokenizer, model faceStore) error {
+	if err := tokenizer.expect("solid"); err != nil {
+		return err
+	}
+
+	for {
+		token := tokenizer.next()
+		switch token.value {
+		case "facet":
+			coordinates, err := readASCIISTLFacet(tokenizer)
+			if err != nil {
+				return err
+			}
+			if err := model.add(coordinates); err != nil {
+				return fmt.Errorf("stl offset %d: %v", token.offset, err)
+			}
+		case "endsolid":
+			// skip the name and search the next solid
+			for token.value != "" && token.value != "solid" {
+				token = tokenizer.next()
+			}
+			if token.value == "" {
+				return nil
+			}
+		case "":
+			// tolerate a missing endsolid
+			return nil
+		default:
+			// part of the name of the solid
+			if model.FaceCount() > 0 {
+				return unexpectedSTLToken(token, "\"facet\" or \"endsolid\"")
+			}
+		}
+	}
+}
+
+// readASCIISTLFacet reads the coordinates of the three vertices of one facet after the "facet" keyword.
+func readASCIISTLFacet(tokenizer *stlTokenizer) ([9]data.Micrometer, error) {
+	var coordinates [9]data.Micrometer
+
+	if err := tokenizer.expect("normal"); err != nil {
+		return coordinates, err
+	}
+	for i := 0; i < 3; i++ {
+		if _, err := tokenizer.number(); err != nil {
+			return coordinates, err
+		}
+	}
+
+	if err := tokenizer.expect("outer"); err != nil {
+		return coordinates, err
+	}
+	if err := tokenizer.expect("loop"); err != nil {
+		return coordinates, err
+	}
+
+	for i := 0; i < 3; i++ {
+		if err := tokenizer.expect("vertex"); err != nil {
+			return coordinates, err
+		}
+
+		for j := 0; j < 3; j++ {
+			value, err := tokenizer.number()
+			if err != nil {
+				return coordinates, err
+			}
+			coordinates[i*3+j] = data.Millimeter(value).ToMicrometer()
+		}
+	}
+
+	if err := tokenizer.expect("endloop"); err != nil {
+		return coordinates, err
+	}
+	if err := tokenizer.expect("endfacet"); err != nil {
+		return coordinates, err
+	}
+
+	return coordinates, nil
+}
@@ -0,0 +1,172 @@
+package reader
+
+import (
+	"bytes"
+	"encoding/binary"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"io"
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+)
+
+// binarySTL returns a binary STL with the given header containing one triangle.
+// The triangle count in the header is set to count.
+func binarySTL(header string, count uint32) string {
+	buf := &bytes.Buffer{}
+	headerBytes := make([]byte, 80)
+	copy(headerBytes, header)
+	buf.Write(headerBytes)
+	_ = binary.Write(buf, binary.LittleEndian, count)
+	_ = binary.Write(buf, binary.LittleEndian, [12]float32{0, 0, 1, 0, 0, 0, 1, 0, 0, 0, 1.5, 2})
+	_ = binary.Write(buf, binary.LittleEndian, uint16(0))
+	return buf.String()
+}
+
+func TestReadSTL(t *testing.T) {
+	var testCases = map[string]struct {
+		stl           string
+		expectedFaces int
+		expectedError string
+		expectedMax   data.MicroVec3
+		// unknownSize hides the size of the stream, as for compressed files
+		unknownSize bool
+	}{
+		"ascii": {
+			stl: "solid test\n" +
+				"  facet normal 0 0 1\n" +
+				"    outer loop\n" +
+				"      vertex 0 0 0\n" +
+				"      vertex 1 0 0\n" +
+				"      vertex 0 1.5 2\n" +
+				"    endloop\n" +
+				"  endfacet\n" +
+				"endsolid test\n",
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+		},
+		"ascii without newlines and with several solids": {
+			stl: "solid a facet normal 0 0 1 outer loop vertex 0 0 0 vertex 1 0 0 vertex 0 1.5 2 endloop endfacet endsolid a\r\n" +
+				"solid\tfacet normal 0 0 1 outer loop vertex 0 0 0 vertex 1 0 0 vertex 0 1 3e0 endloop endfacet",
+			expectedFaces: 2,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 3000),
+		},
+		"binary starting with solid": {
+			stl:           binarySTL("solid exported by some CAD", 1),
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+		},
+		"binary starting with solid with unknown size": {
+			stl:           binarySTL("solid exported by some CAD", 1),
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+			unknownSize:   true,
+		},
+		"ascii with unknown size": {
+			stl:           "solid test facet normal 0 0 1 outer loop vertex 0 0 0 vertex 1 0 0 vertex 0 1.5 2 endloop endfacet endsolid test",
+			expectedFaces: 1,
+			expectedMax:   data.NewMicroVec3(1000, 1500, 2000),
+			unknownSize:   true,
+		},
+		"truncated binary with unknown size": {
+			stl:           binarySTL("binary", 2),
+			expectedError: "stl offset 134: the file contains only 1 of 2 triangles",
+			unknownSize:   true,
+		},
+		"truncated binary": {
+			stl:           binarySTL("binary", 2),
+			expectedError: "stl offset 134: the file contains only 1 of 2 triangles",
+		},
+		"invalid number": {
+			stl:           "solid test\nfacet normal 0 0 1\nouter loop\nvertex 0 a 0\n",
+			expectedError: "stl offset 50: expected a number but got \"a\"",
+		},
+		"unexpected end": {
+			stl:           "solid test\nfacet normal 0 0 1\nouter loop\n",
+			expectedError: "expected vertex but the file ended",
+		},
+		"no faces": {
+			stl:           "solid test\nendsolid test\n",
+			expectedError: "does not contain any faces",
+		},
+	}
+
+	// the faces are stored in memory or in a temporary file if the layers are processed in windows
+	stores := map[string]func() faceStore{
+		"packed": func() faceStore {
+			return &packedModel{}
+		},
+		"file": func() faceStore {
+			store, err := newFileModel()
+			test.Ok(t, err)
+			return store
+		},
+	}
+
+	for testName, testCase := range testCases {
+		for storeName, newStore := range stores {
+			t.Log("testCase:", testName, storeName)
+			var input io.Reader = strings.NewReader(testCase.stl)
+			if testCase.unknownSize {
+				input = ioutil.NopCloser(input)
+			}
+			model, err := readSTL(input, newStore())
+
+			if testCase.expectedError != "" {
+				test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+				continue
+			}
+
+			test.Ok(t, err)
+			test.Equals(t, testCase.expectedFaces, model.FaceCount())
+			max := model.Max()
+			test.Assert(t, max.X() == testCase.expectedMax.X() && max.Y() == testCase.expectedMax.Y() && max.Z() == testCase.expectedMax.Z(), "max should be %v but is %v", testCase.expectedMax, max)
+			closeModel(model)
+		}
+	}
+}
+
+func TestFileModel(t *testing.T) {
+	model, err := newFileModel()
+	test.Ok(t, err)
+	name := model.file.Name()
+
+	// more faces than one block, so that the faces are read in several blocks
+	count := fileModelBlockSize + 10
+	for i := 0; i < count; i++ {
+		z := data.Micrometer(i)
+		test.Ok(t, model.add([9]data.Micrometer{0, 0, z, 1000, 0, z, 0, 1000, z + 500}))
+	}
+	test.Ok(t, model.finish())
+	test.Assert(t, model.add([9]data.Micrometer{0, 0, 1 << 40, 0, 0, 0, 0, 0, 0}) != nil, "a coordinate which does not fit into int32 should fail")
+
+	coordinates := func(v data.MicroVec3) [3]data.Micrometer {
+		return [3]data.Micrometer{v.X(), v.Y(), v.Z()}
+	}
+
+	test.Equals(t, count, model.FaceCount())
+	test.Equals(t, [3]data.Micrometer{0, 0, 0}, coordinates(model.Min()))
+	test.Equals(t, [3]data.Micrometer{1000, 1000, data.Micrometer(count - 1 + 500)}, coordinates(model.Max()))
+
+	// read the faces backwards and forwards across the blocks
+	for _, i := range []int{count - 1, 0, fileModelBlockSize, fileModelBlockSize - 1, 5} {
+		points := model.Face(i).Points()
+		test.Equals(t, [3]data.Micrometer{1000, 0, data.Micrometer(i)}, coordinates(points[1]))
+		test.Equals(t, [3]data.Micrometer{0, 1000, data.Micrometer(i + 500)}, coordinates(points[2]))
+	}
+
+	test.Ok(t, model.Err())
+
+	test.Ok(t, model.Close())
+	_, err = os.Stat(name)
+	test.Assert(t, os.IsNotExist(err), "the file should be removed but got %v", err)
+	test.Ok(t, model.Close())
+
+	// the face is not in the cached block and cannot be read anymore
+	points := model.Face(count - 1).Points()
+	test.Equals(t, [3]data.Micrometer{0, 0, 0}, coordinates(points[2]))
+	test.Assert(t, model.Err() != nil, "the read error should be kept")
+	test.Assert(t, data.ModelErr(scaleModel(model, 2)) != nil, "the read error should be returned by the scaled model")
+}
@@ -0,0 +1,579 @@
+// This file provides a reader for SVG files.
+//
+// All closed shapes (paths, polygons, rectangles, circles and ellipses) are read as outlines
+// and extruded to the configured height. Open paths, strokes, texts and styles are ignored.
+
+package reader
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// svgPixel is the size of one pixel (the default user unit) in mm.
+const svgPixel = 25.4 / 96
+
+// svgHiddenElements contain shapes which are not rendered directly.
+var svgHiddenElements = map[string]bool{
+	"defs":     true,
+	"clipPath": true,
+	"mask":     true,
+	"symbol":   true,
+	"pattern":  true,
+	"marker":   true,
+}
+
+// svgTransform is an affine transformation [a b c d e f] which maps (x, y) to (a*x + c*y + e, b*x + d*y + f).
+type svgTransform [6]float64
+
+var svgIdentity = svgTransform{1, 0, 0, 1, 0, 0}
+
+// multiply returns the transformation which first applies other and then t.
+func (t svgTransform) multiply(other svgTransform) svgTransform {
+	return svgTransform{
+		t[0]*other[0] + t[2]*other[1],
+		t[1]*other[0] + t[3]*other[1],
+		t[0]*other[2] + t[2]*other[3],
+		t[1]*other[2] + t[3]*other[3],
+		t[0]*other[4] + t[2]*other[5] + t[4],
+		t[1]*other[4] + t[3]*other[5] + t[5],
+	}
+}
+
+func (t svgTransform) apply(p [2]float64) [2]float64 {
+	return [2]float64{t[0]*p[0] + t[2]*p[1] + t[4], t[1]*p[0] + t[3]*p[1] + t[5]}
+}
+
+// scale returns the mean scale factor of the transformation.
+func (t svgTransform) scale() float64 {
+	return math.Sqrt(math.Abs(t[0]*t[3] - t[1]*t[2]))
+}
+
+// readSVG reads all closed shapes of an SVG file and extrudes them to the given height in mm.
+// If unit is bigger than 0, the user units are interpreted in this unit instead of the size defined by the file.
+func readSVG(r io.Reader, height float64, unit float64) (data.Model, error) {
+	decoder := xml.NewDecoder(r)
+
+	var outlines []outline
+	// the transformation of each open element, the svg element converts the user units to mm and flips the y axis
+	var stack []svgTransform
+	// hidden is the amount of open elements which are not rendered directly, e.g. defs
+	hidden := 0
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		switch element := token.(type) {
+		case xml.StartElement:
+			attributes := map[string]string{}
+			for _, attribute := range element.Attr {
+				attributes[attribute.Name.Local] = attribute.Value
+			}
+
+			parent := svgIdentity
+			if len(stack) > 0 {
+				parent = stack[len(stack)-1]
+			} else if element.Name.Local == "svg" {
+				scale := unit
+				if scale <= 0 {
+					scale = svgUserUnit(attributes)
+				}
+				parent = svgTransform{scale, 0, 0, -scale, 0, 0}
+			}
+
+			transform, err := parseSVGTransform(attributes["transform"])
+			if err != nil {
+				return nil, err
+			}
+			transform = parent.multiply(transform)
+			stack = append(stack, transform)
+
+			if svgHiddenElements[element.Name.Local] || hidden > 0 {
+				hidden++
+				continue
+			}
+
+			shapes, err := svgShapes(element.Name.Local, attributes, transform.scale())
+			if err != nil {
+				return nil, fmt.Errorf("invalid %s element: %v", element.Name.Local, err)
+			}
+			for _, shape := range shapes {
+				for i, p := range shape {
+					shape[i] = transform.apply(p)
+				}
+				outlines = append(outlines, shape)
+			}
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+			if hidden > 0 {
+				hidden--
+			}
+		}
+	}
+
+	return extrudeOutlines(outlines, height)
+}
+
+// svgUserUnit returns the size of one user unit in mm defined by the width and the viewBox of the svg element.
+func svgUserUnit(attributes map[string]string) float64 {
+	viewBox := strings.FieldsFunc(attributes["viewBox"], func(r rune) bool {
+		return r == ' ' || r == ','
+	})
+	if len(viewBox) != 4 {
+		return svgPixel
+	}
+
+	viewBoxWidth, err := strconv.ParseFloat(viewBox[2], 64)
+	if err != nil || viewBoxWidth <= 0 {
+		return svgPixel
+	}
+
+	width, ok := svgLength(attributes["width"])
+	if !ok {
+		return svgPixel
+	}
+
+	return width / viewBoxWidth
+}
+
+// svgLength converts a length with an optional unit to mm.
+func svgLength(value string) (float64, bool) {
+	value = strings.TrimSpace(value)
+	units := []struct {
+		suffix string
+		scale  float64
+	}{
+		{"mm", 1},
+		{"cm", 10},
+		{"in", 25.4},
+		{"pt", 25.4 / 72},
+		{"pc", 25.4 / 6},
+		{"px", svgPixel},
+		{"", svgPixel},
+	}
+
+	for _, unit := range units {
+		if !strings.HasSuffix(value, unit.suffix) {
+			continue
+		}
+
+		length, err := strconv.ParseFloat(strings.TrimSuffix(value, unit.suffix), 64)
+		if err != nil {
+			return 0, false
+		}
+		return length * unit.scale, true
+	}
+
+	return 0, false
+}
+
+// parseSVGTransform parses the transform attribute, e.g. "translate(10, 20) rotate(45)".
+func parseSVGTransform(value string) (svgTransform, error) {
+	result := svgIdentity
+	value = strings.TrimSpace(value)
+	for value != "" {
+		open := strings.Index(value, "(")
+		end := strings.Index(value, ")")
+		if open < 0 || end < open {
+			return result, fmt.Errorf("invalid transform %q", value)
+		}
+
+		name := strings.Trim(value[:open], " ,")
+		args, err := svgNumbers(value[open+1 : end])
+		if err != nil {
+			return result, err
+		}
+		value = strings.TrimLeft(value[end+1:], " ,")
+
+		var t svgTransform
+		switch {
+		case name == "matrix" && len(args) == 6:
+			copy(t[:], args)
+		case name == "translate" && len(args) == 1:
+			t = svgTransform{1, 0, 0, 1, args[0], 0}
+		case name == "translate" && len(args) == 2:
+			t = svgTransform{1, 0, 0, 1, args[0], args[1]}
+		case name == "scale" && len(args) == 1:
+			t = svgTransform{args[0], 0, 0, args[0], 0, 0}
+		case name == "scale" && len(args) == 2:
+			t = svgTransform{args[0], 0, 0, args[1], 0, 0}
+		case name == "rotate" && (len(args) == 1 || len(args) == 3):
+			sin, cos := math.Sincos(data.ToRadians(args[0]))
+			t = svgTransform{cos, sin, -sin, cos, 0, 0}
+			if len(args) == 3 {
+				t = svgTransform{1, 0, 0, 1, args[1], args[2]}.multiply(t).multiply(svgTransform{1, 0, 0, 1, -args[1], -args[2]})
+			}
+		case name == "skewX" && len(args) == 1:
+			t = svgTransform{1, 0, math.Tan(data.ToRadians(args[0])), 1, 0, 0}
+		case name == "skewY" && len(args) == 1:
+			t = svgTransform{1, math.Tan(data.ToRadians(args[0])), 0, 1, 0, 0}
+		default:
+			return result, fmt.Errorf("invalid transform %s with %v arguments", name, len(args))
+		}
+		result = result.multiply(t)
+	}
+
+	return result, nil
+}
+
+// svgNumbers parses a list of numbers separated by spaces or commas.
+func svgNumbers(value string) ([]float64, error) {
+	tokens := &svgTokenizer{data: value}
+	var result []float64
+	for {
+		tokens.skipSeparators()
+		if tokens.done() {
+			return result, nil
+		}
+
+		number, err := tokens.number()
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, number)
+	}
+}
+
+// svgShapes returns the closed outlines of the element in its user units.
+// scale is the size of one user unit in mm and is used to discretize curves.
+func svgShapes(name string, attributes map[string]string, scale float64) ([]outline, error) {
+	number := func(attribute string) float64 {
+		value, _ := strconv.ParseFloat(strings.TrimSpace(attributes[attribute]), 64)
+		return value
+	}
+
+	switch name {
+	case "path":
+		return parseSVGPath(attributes["d"], scale)
+	case "polygon":
+		points, err := svgNumbers(attributes["points"])
+		if err != nil {
+			return nil, err
+		}
+		var shape outline
+		for i := 0; i+1 < len(points); i += 2 {
+			shape = append(shape, [2]float64{points[i], points[i+1]})
+		}
+		return []outline{shape}, nil
+	case "rect":
+		x, y, width, height := number("x"), number("y"), number("width"), number("height")
+		return []outline{{{x, y}, {x + width, y}, {x + width, y + height}, {x, y + height}}}, nil
+	case "circle":
+		return []outline{svgEllipse(number("cx"), number("cy"), number("r"), number("r"), scale)}, nil
+	case "ellipse":
+		return []outline{svgEllipse(number("cx"), number("cy"), number("rx"), number("ry"), scale)}, nil
+	}
+
+	return nil, nil
+}
+
+func svgEllipse(cx, cy, rx, ry float64, scale float64) outline {
+	segments := int(math.Ceil(2 * math.Pi / segmentAngle(math.Max(rx, ry)*scale)))
+	shape := make(outline, segments)
+	for i := range shape {
+		sin, cos := math.Sincos(2 * math.Pi * float64(i) / float64(segments))
+		shape[i] = [2]float64{cx + rx*cos, cy + ry*sin}
+	}
+	return shape
+}
+
+// svgTokenizer splits path data and number lists into commands and numbers.
+type svgTokenizer struct {
+	data string
+	pos  int
+}
+
+func (t *svgTokenizer) done() bool {
+	return t.pos >= len(t.data)
+}
+
+func (t *svgTokenizer) skipSeparators() {
+	for !t.done() && strings.ContainsRune(" \t\r\n,", rune(t.data[t.pos])) {
+		t.pos++
+	}
+}
+
+// isCommand returns true if the next token is a command letter.
+func (t *svgTokenizer) isCommand() bool {
+	t.skipSeparators()
+	return !t.done() && strings.ContainsRune("MmLlHhVvCcSsQqTtAaZz", rune(t.data[t.pos]))
+}
+
+func (t *svgTokenizer) number() (float64, error) {
+	t.skipSeparators()
+	start := t.pos
+	if !t.done() && (t.data[t.pos] == '-' || t.data[t.pos] == '+') {
+		t.pos++
+	}
+	dot := false
+	for !t.done() {
+		c := t.data[t.pos]
+		if c >= '0' && c <= '9' {
+			t.pos++
+		} else if c == '.' && !dot {
+			dot = true
+			t.pos++
+		} else if (c == 'e' || c == 'E') && t.pos > start {
+			t.pos++
+			if !t.done() && (t.data[t.pos] == '-' || t.data[t.pos] == '+') {
+				t.pos++
+			}
+		} else {
+			break
+		}
+	}
+
+	value, err := strconv.ParseFloat(t.data[start:t.pos], 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number at position %v", start)
+	}
+	return value, nil
+}
+
+// flag reads an arc flag, which may be written without separator, e.g. "a1 1 0 00 1 1".
+func (t *svgTokenizer) flag() (bool, error) {
+	t.skipSeparators()
+	if t.done() || (t.data[t.pos] != '0' && t.data[t.pos] != '1') {
+		return false, fmt.Errorf("invalid flag at position %v", t.pos)
+	}
+	t.pos++
+	return t.data[t.pos-1] == '1', nil
+}
+
+// parseSVGPath returns the closed sub paths of the path data.
+// A sub path is closed if it ends with a close command or at its starting point.
+func parseSVGPath(d string, scale float64) ([]outline, error) {
+	tokens := &svgTokenizer{data: d}
+
+	var result []outline
+	var current outline
+	var position, start, lastControl [2]float64
+	var lastCommand byte
+
+	finish := func(closed bool) {
+		if len(current) > 2 && (closed || current[0] == current[len(current)-1]) {
+			result = append(result, current)
+		}
+		current = nil
+	}
+
+	numbers := func(count int) ([]float64, error) {
+		values := make([]float64, count)
+		for i := range values {
+			value, err := tokens.number()
+			if err != nil {
+				return nil, err
+			}
+			values[i] = value
+		}
+		return values, nil
+	}
+
+	var command byte
+	for {
+		if tokens.isCommand() {
+			command = tokens.data[tokens.pos]
+			tokens.pos++
+		} else if tokens.done() {
+			break
+		} else if command == 0 {
+			return nil, errors.New("the path data has to start with a command")
+		}
+
+		relative := command >= 'a'
+		offset := [2]float64{}
+		if relative {
+			offset = position
+		}
+
+		var err error
+		var values []float64
+		switch command | 0x20 {
+		case 'm':
+			if values, err = numbers(2); err != nil {
+				return nil, err
+			}
+			finish(false)
+			position = [2]float64{offset[0] + values[0], offset[1] + values[1]}
+			start = position
+			current = outline{position}
+			// following coordinate pairs are line commands
+			if relative {
+				command = 'l'
+			} else {
+				command = 'L'
+			}
+		case 'l':
+			if values, err = numbers(2); err != nil {
+				return nil, err
+			}
+			position = [2]float64{offset[0] + values[0], offset[1] + values[1]}
+			current = append(current, position)
+		case 'h':
+			if values, err = numbers(1); err != nil {
+				return nil, err
+			}
+			position[0] = offset[0] + values[0]
+			current = append(current, position)
+		case 'v':
+			if values, err = numbers(1); err != nil {
+				return nil, err
+			}
+			position[1] = offset[1] + values[0]
+			current = append(current, position)
+		case 'c', 's':
+			var control1 [2]float64
+			if command|0x20 == 'c' {
+				if values, err = numbers(6); err != nil {
+					return nil, err
+				}
+				control1 = [2]float64{offset[0] + values[0], offset[1] + values[1]}
+				values = values[2:]
+			} else {
+				if values, err = numbers(4); err != nil {
+					return nil, err
+				}
+				control1 = position
+				if lastCommand|0x20 == 'c' || lastCommand|0x20 == 's' {
+					control1 = [2]float64{2*position[0] - lastControl[0], 2*position[1] - lastControl[1]}
+				}
+			}
+			control2 := [2]float64{offset[0] + values[0], offset[1] + values[1]}
+			end := [2]float64{offset[0] + values[2], offset[1] + values[3]}
+			current = append(current, bezier([][2]float64{position, control1, control2, end}, scale)...)
+			position, lastControl = end, control2
+		case 'q', 't':
+			var control [2]float64
+			if command|0x20 == 'q' {
+				if values, err = numbers(4); err != nil {
+					return nil, err
+				}
+				control = [2]float64{offset[0] + values[0], offset[1] + values[1]}
+				values = values[2:]
+			} else {
+				if values, err = numbers(2); err != nil {
+					return nil, err
+				}
+				control = position
+				if lastCommand|0x20 == 'q' || lastCommand|0x20 == 't' {
+					control = [2]float64{2*position[0] - lastControl[0], 2*position[1] - lastControl[1]}
+				}
+			}
+			end := [2]float64{offset[0] + values[0], offset[1] + values[1]}
+			current = append(current, bezier([][2]float64{position, control, end}, scale)...)
+			position, lastControl = end, control
+		case 'a':
+			if values, err = numbers(3); err != nil {
+				return nil, err
+			}
+			largeArc, err := tokens.flag()
+			if err != nil {
+				return nil, err
+			}
+			sweep, err := tokens.flag()
+			if err != nil {
+				return nil, err
+			}
+			end, err := numbers(2)
+			if err != nil {
+				return nil, err
+			}
+			to := [2]float64{offset[0] + end[0], offset[1] + end[1]}
+			current = append(current, svgArc(position, to, values[0], values[1], values[2], largeArc, sweep, scale)...)
+			position = to
+		case 'z':
+			finish(true)
+			position = start
+		default:
+			return nil, fmt.Errorf("unknown path command %q", command)
+		}
+		lastCommand = command
+	}
+	finish(false)
+
+	return result, nil
+}
+
+// bezier returns the points of a quadratic or cubic bezier curve without its first point.
+// The amount of segments depends on the length of the control polygon.
+func bezier(points [][2]float64, scale float64) [][2]float64 {
+	length := 0.0
+	for i := 1; i < len(points); i++ {
+		length += math.Hypot(points[i][0]-points[i-1][0], points[i][1]-points[i-1][1])
+	}
+	segments := int(math.Min(math.Max(math.Ceil(length*scale/0.5), 4), 64))
+
+	result := make([][2]float64, segments)
+	for s := 1; s <= segments; s++ {
+		t := float64(s) / float64(segments)
+		// de Casteljau
+		current := append([][2]float64{}, points...)
+		for n := len(current) - 1; n > 0; n-- {
+			for i := 0; i < n; i++ {
+				current[i] = [2]float64{current[i][0] + (current[i+1][0]-current[i][0])*t, current[i][1] + (current[i+1][1]-current[i][1])*t}
+			}
+		}
+		result[s-1] = current[0]
+	}
+	return result
+}
+
+// svgArc returns the points of an elliptical arc without its first point.
+// It converts the endpoint parameterization of SVG to the center parameterization
+// (https://www.w3.org/TR/SVG11/implnote.html#ArcImplementationNotes).
+func svgArc(from, to [2]float64, rx, ry, rotation float64, largeArc, sweep bool, scale float64) [][2]float64 {
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 || from == to {
+		return [][2]float64{to}
+	}
+
+	sin, cos := math.Sincos(data.ToRadians(rotation))
+	dx, dy := (from[0]-to[0])/2, (from[1]-to[1])/2
+	x1, y1 := cos*dx+sin*dy, -sin*dx+cos*dy
+
+	// scale up the radii if they are too small
+	if lambda := x1*x1/(rx*rx) + y1*y1/(ry*ry); lambda > 1 {
+		rx, ry = rx*math.Sqrt(lambda), ry*math.Sqrt(lambda)
+	}
+
+	factor := math.Sqrt(math.Max(0, (rx*rx*ry*ry-rx*rx*y1*y1-ry*ry*x1*x1)/(rx*rx*y1*y1+ry*ry*x1*x1)))
+	if largeArc == sweep {
+		factor = -factor
+	}
+	cx1, cy1 := factor*rx*y1/ry, -factor*ry*x1/rx
+	cx, cy := cos*cx1-sin*cy1+(from[0]+to[0])/2, sin*cx1+cos*cy1+(from[1]+to[1])/2
+
+	angle := func(ux, uy, vx, vy float64) float64 {
+		return math.Atan2(ux*vy-uy*vx, ux*vx+uy*vy)
+	}
+	start := angle(1, 0, (x1-cx1)/rx, (y1-cy1)/ry)
+	delta := angle((x1-cx1)/rx, (y1-cy1)/ry, (-x1-cx1)/rx, (-y1-cy1)/ry)
+	if !sweep && delta > 0 {
+		delta -= 2 * math.Pi
+	} else if sweep && delta < 0 {
+		delta += 2 * math.Pi
+	}
+
+	segments := int(math.Ceil(math.Abs(delta) / segmentAngle(math.Max(rx, ry)*scale)))
+	result := make([][2]float64, segments)
+	for s := 1; s <= segments; s++ {
+		a := start + delta*float64(s)/float64(segments)
+		x, y := rx*math.Cos(a), ry*math.Sin(a)
+		result[s-1] = [2]float64{cos*x - sin*y + cx, sin*x + cos*y + cy}
+	}
+	result[segments-1] = to
+	return result
+}
@@ -0,0 +1,125 @@
+package reader
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+func TestReadSVG(t *testing.T) {
+	var testCases = map[string]struct {
+		svg           string
+		unit          float64
+		expectedFaces int
+		expectedError string
+		expectedMin   data.MicroVec3
+		expectedMax   data.MicroVec3
+	}{
+		"rectangle in mm": {
+			svg: `<svg xmlns="http://www.w3.org/2000/svg" width="20mm" height="10mm" viewBox="0 0 20 10">` +
+				`<rect x="0" y="0" width="20" height="10"/>` +
+				`</svg>`,
+			// 4 walls and 2 triangles for the top and the bottom
+			expectedFaces: 12,
+			expectedMin:   data.NewMicroVec3(0, -10000, 0),
+			expectedMax:   data.NewMicroVec3(20000, 0, 2000),
+		},
+		"path with hole and transform": {
+			svg: `<svg xmlns="http://www.w3.org/2000/svg" width="20mm" height="20mm" viewBox="0 0 20 20">` +
+				`<g transform="translate(10 0)">` +
+				`<path d="M0,0 h10 v10 h-10 z M2 2 L2 8 L8 8 L8 2 Z"/>` +
+				`</g>` +
+				`</svg>`,
+			// 8 walls and 8 triangles each for the top and the bottom
+			expectedFaces: 32,
+			expectedMin:   data.NewMicroVec3(10000, -10000, 0),
+			expectedMax:   data.NewMicroVec3(20000, 0, 2000),
+		},
+		"unit overrides the size": {
+			svg: `<svg xmlns="http://www.w3.org/2000/svg" width="20mm" height="10mm" viewBox="0 0 20 10">` +
+				`<polygon points="0,0 2,0 2,1"/>` +
+				`</svg>`,
+			unit:          25.4,
+			expectedFaces: 8,
+			expectedMin:   data.NewMicroVec3(0, -25400, 0),
+			expectedMax:   data.NewMicroVec3(50800, 0, 2000),
+		},
+		"shapes in defs are ignored": {
+			svg: `<svg xmlns="http://www.w3.org/2000/svg" width="20mm" height="10mm" viewBox="0 0 20 10">` +
+				`<defs><rect x="0" y="0" width="100" height="100"/></defs>` +
+				`<circle cx="5" cy="5" r="5"/>` +
+				`</svg>`,
+			expectedMin: data.NewMicroVec3(0, -10000, 0),
+			expectedMax: data.NewMicroVec3(10000, 0, 2000),
+		},
+		"open path only": {
+			svg: `<svg xmlns="http://www.w3.org/2000/svg">` +
+				`<path d="M0 0 L10 0 L10 10"/>` +
+				`</svg>`,
+			expectedError: "does not contain any closed outlines",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := readSVG(strings.NewReader(testCase.svg), 2, testCase.unit)
+
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error containing '%s' expected but got %v", testCase.expectedError, err)
+			continue
+		}
+
+		test.Ok(t, err)
+		if testCase.expectedFaces > 0 {
+			test.Equals(t, testCase.expectedFaces, model.FaceCount())
+		}
+		test.Assert(t, model.Min().Sub(testCase.expectedMin).Size() <= 20, "min should be %v but is %v", testCase.expectedMin, model.Min())
+		test.Assert(t, model.Max().Sub(testCase.expectedMax).Size() <= 20, "max should be %v but is %v", testCase.expectedMax, model.Max())
+	}
+}
+
+func TestParseSVGPath(t *testing.T) {
+	var testCases = map[string]struct {
+		d              string
+		expectedShapes int
+		expectedArea   float64
+	}{
+		"relative lines": {
+			d:              "m0 0 l10 0 0 10 -10 0z",
+			expectedShapes: 1,
+			expectedArea:   100,
+		},
+		"closed by the last point": {
+			d:              "M0 0 H10 V10 H0 V0",
+			expectedShapes: 1,
+			expectedArea:   100,
+		},
+		"circle from two arcs": {
+			d:              "M0 5 a5 5 0 1 0 10 0 a5 5 0 1 0 -10 0z",
+			expectedShapes: 1,
+			expectedArea:   78.5,
+		},
+		"compact numbers": {
+			d:              "M0-5L10-5 10 5-0.5.5z",
+			expectedShapes: 1,
+		},
+		"open path": {
+			d: "M0 0 L10 0 L10 10",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		shapes, err := parseSVGPath(testCase.d, 1)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedShapes, len(shapes))
+		if testCase.expectedArea > 0 {
+			area := shapes[0].area()
+			if area < 0 {
+				area = -area
+			}
+			test.Assert(t, area > testCase.expectedArea*0.99 && area < testCase.expectedArea*1.01, "the area should be %v but is %v", testCase.expectedArea, area)
+		}
+	}
+}
@@ -0,0 +1,276 @@
+// This file provides a reader for 3MF files.
+
+package reader
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"io/ioutil"
+	"path"
+	"strconv"
+	"strings"
+)
+
+const (
+	// threeMFModelRelationship is the relationship type of the model part of a 3MF package.
+	threeMFModelRelationship = "http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"
+
+	// threeMFDefaultModelPath is used if the package does not contain the relationships.
+	threeMFDefaultModelPath = "3D/3dmodel.model"
+)
+
+// threeMFRelationships is the xml structure of the _rels/.rels part.
+type threeMFRelationships struct {
+	Relationships []struct {
+		Target string `xml:"Target,attr"`
+		Type   string `xml:"Type,attr"`
+	} `xml:"Relationship"`
+}
+
+// threeMFModel is the xml structure of the model part.
+// Only the parts needed to get the meshes are read.
+type threeMFModel struct {
+	Unit    string          `xml:"unit,attr"`
+	Objects []threeMFObject `xml:"resources>object"`
+	Items   []struct {
+		ObjectID  int    `xml:"objectid,attr"`
+		Transform string `xml:"transform,attr"`
+	} `xml:"build>item"`
+}
+
+type threeMFObject struct {
+	ID       int `xml:"id,attr"`
+	Vertices []struct {
+		X float64 `xml:"x,attr"`
+		Y float64 `xml:"y,attr"`
+		Z float64 `xml:"z,attr"`
+	} `xml:"mesh>vertices>vertex"`
+	Triangles []struct {
+		V1 int `xml:"v1,attr"`
+		V2 int `xml:"v2,attr"`
+		V3 int `xml:"v3,attr"`
+	} `xml:"mesh>triangles>triangle"`
+	Components []struct {
+		ObjectID  int    `xml:"objectid,attr"`
+		Transform string `xml:"transform,attr"`
+	} `xml:"components>component"`
+}
+
+// threeMFTransform is the affine 3x4 matrix used by 3MF.
+// The points are row vectors, so the last row is the translation.
+type threeMFTransform [12]float64
+
+var threeMFIdentity = threeMFTransform{1, 0, 0, 0, 1, 0, 0, 0, 1, 0, 0, 0}
+
+func parseThreeMFTransform(s string) (threeMFTransform, error) {
+	if strings.TrimSpace(s) == "" {
+		return threeMFIdentity, nil
+	}
+
+	fields := strings.Fields(s)
+	if len(fields) != 12 {
+		return threeMFTransform{}, fmt.Errorf("3mf: invalid transform %q", s)
+	}
+
+	var t threeMFTransform
+	for i, field := range fields {
+		value, err := strconv.ParseFloat(field, 64)
+		if err != nil {
+			return threeMFTransform{}, fmt.Errorf("3mf: invalid transform %q", s)
+		}
+		t[i] = value
+	}
+	return t, nil
+}
+
+// apply transforms the point.
+func (t threeMFTransform) apply(x, y, z float64) (float64, float64, float64) {
+	return x*t[0] + y*t[3] + z*t[6] + t[9],
+		x*t[1] + y*t[4] + z*t[7] + t[10],
+		x*t[2] + y*t[5] + z*t[8] + t[11]
+}
+
+// then returns the transformation which first applies t and then other.
+func (t threeMFTransform) then(other threeMFTransform) threeMFTransform {
+	var result threeMFTransform
+	for row := 0; row < 4; row++ {
+		x, y, z := t[row*3], t[row*3+1], t[row*3+2]
+		if row < 3 {
+			// the linear part is not translated
+			result[row*3] = x*other[0] + y*other[3] + z*other[6]
+			result[row*3+1] = x*other[1] + y*other[4] + z*other[7]
+			result[row*3+2] = x*other[2] + y*other[5] + z*other[8]
+		} else {
+			result[row*3], result[row*3+1], result[row*3+2] = other.apply(x, y, z)
+		}
+	}
+	return result
+}
+
+// threeMFUnitScale returns the factor to convert the given 3MF unit into millimeter.
+func threeMFUnitScale(unit string) (float64, error) {
+	switch unit {
+	case "", "millimeter":
+		return 1, nil
+	case "micron":
+		return 0.001, nil
+	case "centimeter":
+		return 10, nil
+	case "inch":
+		return 25.4, nil
+	case "foot":
+		return 304.8, nil
+	case "meter":
+		return 1000, nil
+	default:
+		return 0, fmt.Errorf("3mf: unknown unit %q", unit)
+	}
+}
+
+// readThreeMF reads all objects of the build of a 3MF file.
+// The objects keep the position defined in the file, so several objects
+// are returned as one model with all faces.
+// If unit is bigger than 0, it replaces the unit of the file and is the factor to convert the coordinates into millimeter.
+func readThreeMF(r io.Reader, unit float64) (data.Model, error) {
+	// zip needs random access, so the whole package is read into memory
+	content, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	archive, err := zip.NewReader(bytes.NewReader(content), int64(len(content)))
+	if err != nil {
+		return nil, fmt.Errorf("3mf: %w", err)
+	}
+
+	files := make(map[string]*zip.File, len(archive.File))
+	for _, file := range archive.File {
+		files[strings.TrimPrefix(file.Name, "/")] = file
+	}
+
+	modelPath := threeMFDefaultModelPath
+	if rels, ok := files["_rels/.rels"]; ok {
+		var relationships threeMFRelationships
+		if err := decodeZIPFileXML(rels, &relationships); err != nil {
+			return nil, err
+		}
+		for _, relationship := range relationships.Relationships {
+			if relationship.Type == threeMFModelRelationship {
+				modelPath = strings.TrimPrefix(path.Clean(relationship.Target), "/")
+				break
+			}
+		}
+	}
+
+	modelFile, ok := files[modelPath]
+	if !ok {
+		return nil, fmt.Errorf("3mf: the package does not contain the model %q", modelPath)
+	}
+
+	var model threeMFModel
+	if err := decodeZIPFileXML(modelFile, &model); err != nil {
+		return nil, err
+	}
+
+	scale := unit
+	if scale <= 0 {
+		scale, err = threeMFUnitScale(model.Unit)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	objects := make(map[int]threeMFObject, len(model.Objects))
+	for _, object := range model.Objects {
+		objects[object.ID] = object
+	}
+
+	var faces []data.Face
+	for _, item := range model.Items {
+		transform, err := parseThreeMFTransform(item.Transform)
+		if err != nil {
+			return nil, err
+		}
+
+		faces, err = appendThreeMFObject(faces, objects, item.ObjectID, transform, scale, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if len(faces) == 0 {
+		return nil, errors.New("the 3mf file does not contain any faces")
+	}
+
+	return newModel(faces), nil
+}
+
+// appendThreeMFObject adds the faces of the object and all its components to the faces.
+func appendThreeMFObject(faces []data.Face, objects map[int]threeMFObject, id int, transform threeMFTransform, scale float64, depth int) ([]data.Face, error) {
+	// the components may not reference each other recursively
+	if depth > len(objects) {
+		return nil, errors.New("3mf: the components are recursive")
+	}
+
+	object, ok := objects[id]
+	if !ok {
+		return nil, fmt.Errorf("3mf: the object %d does not exist", id)
+	}
+
+	vertices := make([]data.MicroVec3, len(object.Vertices))
+	for i, v := range object.Vertices {
+		x, y, z := transform.apply(v.X, v.Y, v.Z)
+		vertices[i] = data.NewMicroVec3(
+			data.Millimeter(x*scale).ToMicrometer(),
+			data.Millimeter(y*scale).ToMicrometer(),
+			data.Millimeter(z*scale).ToMicrometer(),
+		)
+	}
+
+	for i, triangle := range object.Triangles {
+		for _, index := range [3]int{triangle.V1, triangle.V2, triangle.V3} {
+			if index < 0 || index >= len(vertices) {
+				return nil, fmt.Errorf("3mf: triangle %d of object %d references the not existing vertex %d", i, id, index)
+			}
+		}
+
+		faces = append(faces, face{vectors: [3]data.MicroVec3{
+			vertices[triangle.V1].Copy(),
+			vertices[triangle.V2].Copy(),
+			vertices[triangle.V3].Copy(),
+		}})
+	}
+
+	for _, component := range object.Components {
+		componentTransform, err := parseThreeMFTransform(component.Transform)
+		if err != nil {
+			return nil, err
+		}
+
+		faces, err = appendThreeMFObject(faces, objects, component.ObjectID, componentTransform.then(transform), scale, depth+1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return faces, nil
+}
+
+// decodeZIPFileXML decodes the xml content of the file into v.
+func decodeZIPFileXML(file *zip.File, v interface{}) error {
+	reader, err := file.Open()
+	if err != nil {
+		return fmt.Errorf("3mf: %w", err)
+	}
+	defer reader.Close()
+
+	if err := xml.NewDecoder(reader).Decode(v); err != nil {
+		return fmt.Errorf("3mf: %s: %w", file.Name, err)
+	}
+	return nil
+}
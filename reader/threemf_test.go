@@ -0,0 +1,112 @@
+package reader
+
+import (
+	"bytes"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"strings"
+	"testing"
+)
+
+const testThreeMFRels = `<?xml version="1.0" encoding="UTF-8"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+ <Relationship Target="/3D/model.model" Id="rel0" Type="http://schemas.microsoft.com/3dmanufacturing/2013/01/3dmodel"/>
+</Relationships>`
+
+const testThreeMFModel = `<?xml version="1.0" encoding="UTF-8"?>
+<model unit="centimeter" xmlns="http://schemas.microsoft.com/3dmanufacturing/core/2015/02">
+ <resources>
+  <object id="1" type="model">
+   <mesh>
+    <vertices>
+     <vertex x="0" y="0" z="0"/>
+     <vertex x="1" y="0" z="0"/>
+     <vertex x="0" y="1" z="0"/>
+    </vertices>
+    <triangles>
+     <triangle v1="0" v2="1" v3="2"/>
+    </triangles>
+   </mesh>
+  </object>
+  <object id="2" type="model">
+   <components>
+    <component objectid="1" transform="1 0 0 0 1 0 0 0 1 0 0 1"/>
+   </components>
+  </object>
+ </resources>
+ <build>
+  <item objectid="1"/>
+  <item objectid="2" transform="0 1 0 -1 0 0 0 0 1 5 0 0"/>
+ </build>
+</model>`
+
+func TestReadThreeMF(t *testing.T) {
+	var testCases = map[string]struct {
+		files         map[string]string
+		unit          float64
+		expectedError string
+		expected      [][3][3]data.Micrometer
+	}{
+		"objects with components and transforms": {
+			files: map[string]string{
+				"_rels/.rels":    testThreeMFRels,
+				"3D/model.model": testThreeMFModel,
+			},
+			expected: [][3][3]data.Micrometer{
+				{{0, 0, 0}, {10000, 0, 0}, {0, 10000, 0}},
+				// rotated by 90° around Z, moved by 5cm in X and by 1cm in Z
+				{{50000, 0, 10000}, {50000, 10000, 10000}, {40000, 0, 10000}},
+			},
+		},
+		"default model path": {
+			files: map[string]string{
+				"3D/3dmodel.model": testThreeMFModel,
+			},
+			expected: [][3][3]data.Micrometer{
+				{{0, 0, 0}, {10000, 0, 0}, {0, 10000, 0}},
+				{{50000, 0, 10000}, {50000, 10000, 10000}, {40000, 0, 10000}},
+			},
+		},
+		"unit override": {
+			files: map[string]string{
+				"3D/3dmodel.model": testThreeMFModel,
+			},
+			unit: 1,
+			expected: [][3][3]data.Micrometer{
+				{{0, 0, 0}, {1000, 0, 0}, {0, 1000, 0}},
+				{{5000, 0, 1000}, {5000, 1000, 1000}, {4000, 0, 1000}},
+			},
+		},
+		"missing model": {
+			files: map[string]string{
+				"_rels/.rels": testThreeMFRels,
+			},
+			expectedError: "does not contain the model",
+		},
+		"missing object": {
+			files: map[string]string{
+				"3D/3dmodel.model": strings.Replace(testThreeMFModel, `<item objectid="1"/>`, `<item objectid="3"/>`, 1),
+			},
+			expectedError: "the object 3 does not exist",
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		model, err := readThreeMF(bytes.NewReader(zipped(t, testCase.files)), testCase.unit)
+		if testCase.expectedError != "" {
+			test.Assert(t, err != nil && strings.Contains(err.Error(), testCase.expectedError), "error expected, got %v", err)
+			continue
+		}
+
+		test.Ok(t, err)
+		test.Equals(t, len(testCase.expected), model.FaceCount())
+		for i, expected := range testCase.expected {
+			var actual [3][3]data.Micrometer
+			for j, p := range model.Face(i).Points() {
+				actual[j] = [3]data.Micrometer{p.X(), p.Y(), p.Z()}
+			}
+			test.Equals(t, expected, actual)
+		}
+	}
+}
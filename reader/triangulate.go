@@ -0,0 +1,567 @@
+// This file provides the triangulation of the faces of STEP files in the parameter space of their surfaces.
+
+package reader
+
+import (
+	"math"
+	"sort"
+)
+
+// triangulateSurface triangulates a face bounded by the given loops of points on the surface.
+// The outer loop is the loop with the given index. If it is -1, the loop with the biggest area is used.
+// The triangles are counter clockwise in the parameter space, so their normal is the normal of the surface.
+//
+// On periodic surfaces, e.g. cylinders, a face may be bounded by two loops which go around the whole surface.
+// Such bands are connected directly. Other faces which go around the whole surface are not supported.
+func triangulateSurface(surface stepSurface, loops [][]stepVec, outer int) ([][3]stepVec, bool) {
+	var points []stepVec
+	var params [][2]float64
+	var rings [][]int
+	var wrapping []int
+
+	for _, loop := range loops {
+		loop = removeDuplicateVecs(loop)
+		if len(loop) < 2 {
+			continue
+		}
+
+		ring := make([]int, len(loop))
+		for i, v := range loop {
+			p := surface.param(v)
+			if surface.periodic && i > 0 {
+				// unwrap the angle so that the loop is continuous
+				previous := params[len(params)-1][0]
+				for p[0]-previous > surface.period()/2 {
+					p[0] -= surface.period()
+				}
+				for p[0]-previous < -surface.period()/2 {
+					p[0] += surface.period()
+				}
+			}
+
+			ring[i] = len(points)
+			points = append(points, v)
+			params = append(params, p)
+		}
+
+		if surface.periodic {
+			closing := params[ring[0]][0] - params[ring[len(ring)-1]][0]
+			if math.Abs(closing) > surface.period()/2 {
+				wrapping = append(wrapping, len(rings))
+			}
+		}
+		rings = append(rings, ring)
+	}
+
+	if len(rings) == 0 {
+		return nil, true
+	}
+
+	toVecs := func(triangles [][3]int) [][3]stepVec {
+		result := make([][3]stepVec, len(triangles))
+		for i, t := range triangles {
+			result[i] = [3]stepVec{points[t[0]], points[t[1]], points[t[2]]}
+		}
+		return result
+	}
+
+	switch {
+	case len(wrapping) == 2 && len(rings) == 2:
+		return toVecs(triangulateBand(params, rings[0], rings[1], surface.period())), true
+	case len(wrapping) > 0:
+		return nil, false
+	}
+
+	if outer < 0 || outer >= len(rings) || len(rings[outer]) < 3 {
+		outer = 0
+		for i := range rings {
+			if math.Abs(ringArea(params, rings[i])) > math.Abs(ringArea(params, rings[outer])) {
+				outer = i
+			}
+		}
+	}
+
+	var holes [][]int
+	for i, ring := range rings {
+		if i == outer || len(ring) < 3 {
+			continue
+		}
+
+		if surface.periodic {
+			// move the hole into the range of the outer loop
+			shift := math.Round((ringCenter(params, rings[outer]) - ringCenter(params, ring)) / surface.period())
+			for _, index := range ring {
+				params[index][0] += shift * surface.period()
+			}
+		}
+
+		if ringArea(params, ring) > 0 {
+			reverseInts(ring)
+		}
+		holes = append(holes, ring)
+	}
+
+	if ringArea(params, rings[outer]) < 0 {
+		reverseInts(rings[outer])
+	}
+
+	triangles := earcut(params, rings[outer], holes)
+	if !surface.periodic {
+		return toVecs(triangles), true
+	}
+
+	// The triangles of curved surfaces have to be refined as they may span a big angle.
+	var result [][3]stepVec
+	for _, t := range triangles {
+		var corners [3]surfacePoint
+		for i, index := range t {
+			corners[i] = surfacePoint{v: points[index], u: params[index][0], h: params[index][1], distance: surface.distance(points[index])}
+		}
+		result = refineTriangle(surface, corners, 0, result)
+	}
+	return result, true
+}
+
+// surfacePoint is a point on a periodic surface together with its parameters.
+type surfacePoint struct {
+	v        stepVec
+	u, h     float64
+	distance float64
+}
+
+// refineTriangle splits the triangle until no edge spans an angle bigger than surface.maxAngle.
+// As the decision to split only depends on the edge, the triangles next to it are split in the same way.
+// The edges of the face never have to be split, as the curves are discretized using the same angle.
+func refineTriangle(surface stepSurface, t [3]surfacePoint, depth int, result [][3]stepVec) [][3]stepVec {
+	var split [3]bool
+	count := 0
+	if depth < 16 {
+		for i := range t {
+			next := t[(i+1)%3]
+			split[i] = math.Abs(next.u-t[i].u)/surface.radius > surface.maxAngle*1.0001
+			if split[i] {
+				count++
+			}
+		}
+	}
+
+	if count == 0 {
+		return append(result, [3]stepVec{t[0].v, t[1].v, t[2].v})
+	}
+
+	mid := func(a, b surfacePoint) surfacePoint {
+		m := surfacePoint{u: (a.u + b.u) / 2, h: (a.h + b.h) / 2, distance: (a.distance + b.distance) / 2}
+		m.v = surface.point(m.u, m.h, m.distance)
+		return m
+	}
+
+	// rotate the triangle so that the first edge is split
+	for !split[0] {
+		t = [3]surfacePoint{t[1], t[2], t[0]}
+		split = [3]bool{split[1], split[2], split[0]}
+	}
+
+	m01 := mid(t[0], t[1])
+	switch {
+	case count == 1:
+		result = refineTriangle(surface, [3]surfacePoint{t[0], m01, t[2]}, depth+1, result)
+		return refineTriangle(surface, [3]surfacePoint{m01, t[1], t[2]}, depth+1, result)
+	case count == 3:
+		m12 := mid(t[1], t[2])
+		m20 := mid(t[2], t[0])
+		result = refineTriangle(surface, [3]surfacePoint{t[0], m01, m20}, depth+1, result)
+		result = refineTriangle(surface, [3]surfacePoint{m01, t[1], m12}, depth+1, result)
+		result = refineTriangle(surface, [3]surfacePoint{m20, m12, t[2]}, depth+1, result)
+		return refineTriangle(surface, [3]surfacePoint{m01, m12, m20}, depth+1, result)
+	case split[1]:
+		m12 := mid(t[1], t[2])
+		result = refineTriangle(surface, [3]surfacePoint{m01, t[1], m12}, depth+1, result)
+		result = refineTriangle(surface, [3]surfacePoint{t[0], m01, m12}, depth+1, result)
+		return refineTriangle(surface, [3]surfacePoint{t[0], m12, t[2]}, depth+1, result)
+	default:
+		m20 := mid(t[2], t[0])
+		result = refineTriangle(surface, [3]surfacePoint{t[0], m01, m20}, depth+1, result)
+		result = refineTriangle(surface, [3]surfacePoint{m01, t[1], t[2]}, depth+1, result)
+		return refineTriangle(surface, [3]surfacePoint{m01, t[2], m20}, depth+1, result)
+	}
+}
+
+// triangulateBand connects two loops which both go around a periodic surface.
+func triangulateBand(params [][2]float64, a, b []int, period float64) [][3]int {
+	a = bandOrder(params, a, period)
+	b = bandOrder(params, b, period)
+
+	// u returns the unwrapped first parameter of the i-th point of the band.
+	// After the last point the first one follows again with an offset of one period.
+	u := func(band []int, i int, offset float64) float64 {
+		return params[band[i%len(band)]][0] + float64(i/len(band))*period + offset
+	}
+
+	// align the start of b with the start of a
+	offsetB := math.Round((params[a[0]][0]-params[b[0]][0])/period) * period
+
+	var triangles [][3]int
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		var t [3]int
+		var tu [3][2]float64
+		if j == len(b) || i < len(a) && u(a, i+1, 0) <= u(b, j+1, offsetB) {
+			t = [3]int{a[i%len(a)], a[(i+1)%len(a)], b[j%len(b)]}
+			tu = [3][2]float64{
+				{u(a, i, 0), params[t[0]][1]},
+				{u(a, i+1, 0), params[t[1]][1]},
+				{u(b, j, offsetB), params[t[2]][1]},
+			}
+			i++
+		} else {
+			t = [3]int{b[j%len(b)], b[(j+1)%len(b)], a[i%len(a)]}
+			tu = [3][2]float64{
+				{u(b, j, offsetB), params[t[0]][1]},
+				{u(b, j+1, offsetB), params[t[1]][1]},
+				{u(a, i, 0), params[t[2]][1]},
+			}
+			j++
+		}
+
+		if cross2(tu[0], tu[1], tu[2]) < 0 {
+			t[1], t[2] = t[2], t[1]
+		}
+		triangles = append(triangles, t)
+	}
+	return triangles
+}
+
+// bandOrder returns the ring so that the first parameter increases and the ring starts at its smallest angle.
+// The parameters of the ring are changed to be in the range [start, start + period).
+func bandOrder(params [][2]float64, ring []int, period float64) []int {
+	first, last := params[ring[0]][0], params[ring[len(ring)-1]][0]
+	if last < first {
+		ring = append([]int(nil), ring...)
+		reverseInts(ring)
+	}
+
+	start := 0
+	for i := range ring {
+		if angleMod(params[ring[i]][0], period) < angleMod(params[ring[start]][0], period) {
+			start = i
+		}
+	}
+	ordered := append(append([]int(nil), ring[start:]...), ring[:start]...)
+
+	base := params[ordered[0]][0]
+	for _, index := range ordered[1:] {
+		for params[index][0] < base {
+			params[index][0] += period
+		}
+		for params[index][0] >= base+period {
+			params[index][0] -= period
+		}
+	}
+	sort.SliceStable(ordered[1:], func(i, j int) bool {
+		return params[ordered[1+i]][0] < params[ordered[1+j]][0]
+	})
+	return ordered
+}
+
+// angleMod returns the value in the range [0, period).
+func angleMod(value, period float64) float64 {
+	result := math.Mod(value, period)
+	if result < 0 {
+		result += period
+	}
+	return result
+}
+
+// earNode is a point of the polygon which is triangulated by earcut.
+type earNode struct {
+	index      int
+	x, y       float64
+	prev, next *earNode
+}
+
+func cross2(a, b, c [2]float64) float64 {
+	return (b[0]-a[0])*(c[1]-a[1]) - (b[1]-a[1])*(c[0]-a[0])
+}
+
+func nodeCross(a, b, c *earNode) float64 {
+	return (b.x-a.x)*(c.y-a.y) - (b.y-a.y)*(c.x-a.x)
+}
+
+func (n *earNode) equals(o *earNode) bool {
+	return n.x == o.x && n.y == o.y
+}
+
+// linkedRing creates a circular linked list of the ring and returns its first node.
+func linkedRing(params [][2]float64, ring []int) *earNode {
+	var first, last *earNode
+	for _, index := range ring {
+		n := &earNode{index: index, x: params[index][0], y: params[index][1]}
+		if first == nil {
+			first = n
+		} else {
+			last.next = n
+			n.prev = last
+		}
+		last = n
+	}
+	last.next = first
+	first.prev = last
+	return first
+}
+
+// earcut triangulates the counter clockwise outer ring with the clockwise holes using ear clipping.
+// The holes are connected to the outer ring by bridges first.
+func earcut(params [][2]float64, outer []int, holes [][]int) [][3]int {
+	start := linkedRing(params, outer)
+
+	holeNodes := make([]*earNode, len(holes))
+	for i, hole := range holes {
+		holeNodes[i] = linkedRing(params, hole)
+	}
+
+	// connect the holes starting with the one most to the right
+	sort.SliceStable(holeNodes, func(i, j int) bool {
+		return maxX(holeNodes[i]) > maxX(holeNodes[j])
+	})
+	for i, hole := range holeNodes {
+		bridgeHole(start, hole, holeNodes[i+1:])
+	}
+
+	var triangles [][3]int
+	ear := start
+	stop := ear
+	pass := 0
+	for ear.prev != ear.next {
+		prev, next := ear.prev, ear.next
+
+		if isEar(ear) {
+			triangles = append(triangles, [3]int{prev.index, ear.index, next.index})
+			removeNode(ear)
+			ear = next
+			stop = next
+			pass = 0
+			continue
+		}
+
+		ear = next
+		if ear != stop {
+			continue
+		}
+
+		// no ear was found in a whole round
+		if pass == 0 {
+			ear = filterNodes(ear)
+			stop = ear
+			pass = 1
+			continue
+		}
+
+		// Clip the most convex node anyway, so that the triangulation always finishes.
+		// This only happens for self intersecting or otherwise broken loops.
+		best := ear
+		for n := ear.next; n != ear; n = n.next {
+			if nodeCross(n.prev, n, n.next) > nodeCross(best.prev, best, best.next) {
+				best = n
+			}
+		}
+		if nodeCross(best.prev, best, best.next) > 0 {
+			triangles = append(triangles, [3]int{best.prev.index, best.index, best.next.index})
+		}
+		ear = best.next
+		removeNode(best)
+		stop = ear
+		pass = 0
+	}
+
+	return triangles
+}
+
+func maxX(ring *earNode) float64 {
+	result := ring.x
+	for n := ring.next; n != ring; n = n.next {
+		result = math.Max(result, n.x)
+	}
+	return result
+}
+
+// isEar checks if the triangle of the node and its neighbours can be cut off.
+func isEar(ear *earNode) bool {
+	a, b, c := ear.prev, ear, ear.next
+	if nodeCross(a, b, c) <= 0 {
+		return false
+	}
+
+	for p := c.next; p != a; p = p.next {
+		if p.equals(a) || p.equals(b) || p.equals(c) {
+			continue
+		}
+		if pointInTriangle(a, b, c, p) && nodeCross(p.prev, p, p.next) <= 0 {
+			return false
+		}
+	}
+	return true
+}
+
+func pointInTriangle(a, b, c, p *earNode) bool {
+	return nodeCross(a, b, p) >= 0 && nodeCross(b, c, p) >= 0 && nodeCross(c, a, p) >= 0
+}
+
+func removeNode(n *earNode) {
+	n.prev.next = n.next
+	n.next.prev = n.prev
+}
+
+// filterNodes removes duplicate and collinear nodes and returns a node which is still in the ring.
+func filterNodes(start *earNode) *earNode {
+	n := start
+	for {
+		if n.next == n.prev {
+			return n
+		}
+		if n.equals(n.next) || nodeCross(n.prev, n, n.next) == 0 {
+			removeNode(n)
+			n = n.prev
+			start = n
+			continue
+		}
+		n = n.next
+		if n == start {
+			return n
+		}
+	}
+}
+
+// locallyInside checks if the diagonal from a to b is inside of the polygon near a.
+func locallyInside(a, b *earNode) bool {
+	if nodeCross(a.prev, a, a.next) > 0 {
+		return nodeCross(a, b, a.next) <= 0 && nodeCross(a, a.prev, b) <= 0
+	}
+	return nodeCross(a, b, a.prev) > 0 || nodeCross(a, a.next, b) > 0
+}
+
+// segmentsCross checks if the segments p1-p2 and q1-q2 intersect at a point which is not an end point of both.
+func segmentsCross(p1, p2, q1, q2 *earNode) bool {
+	if p1.equals(q1) || p1.equals(q2) || p2.equals(q1) || p2.equals(q2) {
+		return false
+	}
+	d1 := nodeCross(p1, p2, q1)
+	d2 := nodeCross(p1, p2, q2)
+	d3 := nodeCross(q1, q2, p1)
+	d4 := nodeCross(q1, q2, p2)
+	return (d1 > 0) != (d2 > 0) && (d3 > 0) != (d4 > 0) && d1 != 0 && d2 != 0 && d3 != 0 && d4 != 0
+}
+
+// crossesRing checks if the segment a-b crosses any edge of the ring.
+func crossesRing(ring *earNode, a, b *earNode) bool {
+	n := ring
+	for {
+		if segmentsCross(a, b, n, n.next) {
+			return true
+		}
+		n = n.next
+		if n == ring {
+			return false
+		}
+	}
+}
+
+// bridgeHole connects the hole to the outer ring by the shortest possible bridge
+// which does not cross the outer ring or any of the remaining holes.
+func bridgeHole(outer *earNode, hole *earNode, remaining []*earNode) {
+	h := hole
+	for n := hole.next; n != hole; n = n.next {
+		if n.x > h.x {
+			h = n
+		}
+	}
+
+	var candidates []*earNode
+	for n := outer; ; {
+		candidates = append(candidates, n)
+		n = n.next
+		if n == outer {
+			break
+		}
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return distance2(candidates[i], h) < distance2(candidates[j], h)
+	})
+
+	bridge := candidates[0]
+	for _, c := range candidates {
+		if c.equals(h) || !locallyInside(c, h) || crossesRing(outer, c, h) || crossesRing(hole, c, h) {
+			continue
+		}
+
+		blocked := false
+		for _, other := range remaining {
+			if crossesRing(other, c, h) {
+				blocked = true
+				break
+			}
+		}
+		if !blocked {
+			bridge = c
+			break
+		}
+	}
+
+	// split the ring at the bridge: bridge -> h -> hole ... -> h copy -> bridge copy -> rest of the ring
+	bridge2 := &earNode{index: bridge.index, x: bridge.x, y: bridge.y}
+	h2 := &earNode{index: h.index, x: h.x, y: h.y}
+	bridgeNext := bridge.next
+	hPrev := h.prev
+
+	bridge.next = h
+	h.prev = bridge
+
+	bridge2.next = bridgeNext
+	bridgeNext.prev = bridge2
+
+	h2.next = bridge2
+	bridge2.prev = h2
+
+	hPrev.next = h2
+	h2.prev = hPrev
+}
+
+func distance2(a, b *earNode) float64 {
+	return (a.x-b.x)*(a.x-b.x) + (a.y-b.y)*(a.y-b.y)
+}
+
+// ringArea returns the signed area of the ring. It is positive for counter clockwise rings.
+func ringArea(params [][2]float64, ring []int) float64 {
+	var area float64
+	for i, index := range ring {
+		p := params[index]
+		q := params[ring[(i+1)%len(ring)]]
+		area += p[0]*q[1] - q[0]*p[1]
+	}
+	return area / 2
+}
+
+// ringCenter returns the mean of the first parameter of the ring.
+func ringCenter(params [][2]float64, ring []int) float64 {
+	var sum float64
+	for _, index := range ring {
+		sum += params[index][0]
+	}
+	return sum / float64(len(ring))
+}
+
+func removeDuplicateVecs(loop []stepVec) []stepVec {
+	var result []stepVec
+	for i, v := range loop {
+		if v.sub(loop[(i+1)%len(loop)]).length() < 1e-9 {
+			continue
+		}
+		result = append(result, v)
+	}
+	return result
+}
+
+func reverseInts(v []int) {
+	for i, j := 0, len(v)-1; i < j; i, j = i+1, j-1 {
+		v[i], v[j] = v[j], v[i]
+	}
+}
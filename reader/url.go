@@ -0,0 +1,119 @@
+// This file provides the download of models from http and https URLs.
+
+package reader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+)
+
+// IsURL returns true if the input path is a http or https URL.
+func IsURL(input string) bool {
+	lower := strings.ToLower(input)
+	return strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://")
+}
+
+// readURL reads the model while it is downloaded.
+// The format is detected by the file extension of the URL path, if there is none the configured input format is used.
+// If the fragment of the URL contains a checksum in the form "sha256=<hex>", the download is verified against it.
+// The fragment is not sent to the server.
+func (r reader) readURL(rawURL string) (data.Model, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, err
+	}
+
+	var checksum string
+	if u.Fragment != "" {
+		if !strings.HasPrefix(u.Fragment, "sha256=") {
+			return nil, fmt.Errorf("the checksum %q of the URL is not supported, only \"sha256=<hex>\" can be used", u.Fragment)
+		}
+		checksum = strings.ToLower(strings.TrimPrefix(u.Fragment, "sha256="))
+		u.Fragment = ""
+	}
+
+	client := r.client
+	if client == nil {
+		client = &http.Client{Timeout: time.Duration(r.options.GoSlice.DownloadTimeout) * time.Second}
+	}
+
+	response, err := client.Get(u.String())
+	if err != nil {
+		return nil, err
+	}
+	defer response.Body.Close()
+
+	if response.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("could not download %v: %v", u, response.Status)
+	}
+
+	maxSize := int64(r.options.GoSlice.MaxDownloadSize) * 1024 * 1024
+	if maxSize > 0 && response.ContentLength > maxSize {
+		return nil, fmt.Errorf("the model at %v has %v bytes which is more than the max download size of %vMB", u, response.ContentLength, r.options.GoSlice.MaxDownloadSize)
+	}
+
+	// the model is read while it is downloaded and the checksum and size are checked afterwards
+	download := &countingReader{r: response.Body}
+	var body io.Reader = download
+	if maxSize > 0 {
+		// read one byte more to detect if the body is too big
+		body = io.LimitReader(body, maxSize+1)
+	}
+	hash := sha256.New()
+	body = io.TeeReader(body, hash)
+
+	format := path.Ext(u.Path)
+	if strings.ToLower(format) == ".gz" {
+		// keep the format of the compressed model, e.g. ".stl.gz"
+		format = path.Ext(strings.TrimSuffix(u.Path, format)) + format
+	}
+	if format == "" {
+		format = r.options.GoSlice.InputFormat
+	}
+
+	model, err := r.ReadStream(body, format)
+	if err == nil {
+		// the reader may stop before the end, e.g. after the last triangle of a binary stl
+		_, err = io.Copy(ioutil.Discard, body)
+	}
+
+	if model != nil && (err != nil || (maxSize > 0 && download.count > maxSize)) {
+		closeModel(model)
+	}
+	if maxSize > 0 && download.count > maxSize {
+		return nil, fmt.Errorf("the model at %v is bigger than the max download size of %vMB", u, r.options.GoSlice.MaxDownloadSize)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if checksum != "" {
+		if actual := hex.EncodeToString(hash.Sum(nil)); actual != checksum {
+			closeModel(model)
+			return nil, fmt.Errorf("the sha256 checksum %v of the model at %v does not match the expected checksum %v", actual, u, checksum)
+		}
+	}
+
+	return model, nil
+}
+
+// countingReader counts the bytes read from r.
+type countingReader struct {
+	r     io.Reader
+	count int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.count += int64(n)
+	return n, err
+}
@@ -0,0 +1,90 @@
+package reader
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestReadURL(t *testing.T) {
+	obj := "v 0 0 0\nv 1 0 0\nv 0 1 0\nf 1 2 3\n"
+	sum := sha256.Sum256([]byte(obj))
+	checksum := hex.EncodeToString(sum[:])
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		switch req.URL.Path {
+		case "/model.obj", "/model":
+			_, _ = w.Write([]byte(obj))
+		case "/big.obj":
+			_, _ = w.Write([]byte(obj + strings.Repeat("#", 1024*1024)))
+		case "/stalled.obj":
+			_, _ = w.Write([]byte(obj))
+			w.(http.Flusher).Flush()
+			// never finish the download
+			<-req.Context().Done()
+		default:
+			http.NotFound(w, req)
+		}
+	}))
+	defer server.Close()
+
+	var testCases = map[string]struct {
+		path        string
+		inputFormat string
+		err         bool
+	}{
+		"format by extension": {
+			path: "/model.obj",
+		},
+		"format by option": {
+			path:        "/model",
+			inputFormat: "obj",
+		},
+		"matching checksum": {
+			path: "/model.obj#sha256=" + checksum,
+		},
+		"wrong checksum": {
+			path: "/model.obj#sha256=" + strings.Repeat("0", 64),
+			err:  true,
+		},
+		"unknown checksum": {
+			path: "/model.obj#md5=" + checksum,
+			err:  true,
+		},
+		"too big": {
+			path: "/big.obj",
+			err:  true,
+		},
+		"stalled": {
+			path: "/stalled.obj",
+			err:  true,
+		},
+		"not found": {
+			path: "/missing.obj",
+			err:  true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		options.GoSlice.MaxDownloadSize = 1
+		options.GoSlice.DownloadTimeout = 1
+		if testCase.inputFormat != "" {
+			options.GoSlice.InputFormat = testCase.inputFormat
+		}
+
+		model, err := Reader(&options).Read(server.URL + testCase.path)
+		if testCase.err {
+			test.Assert(t, err != nil, "an error should be returned")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, 1, model.FaceCount())
+	}
+}
@@ -0,0 +1,395 @@
+package repair
+
+import (
+	"github.com/aligator/goslice/data"
+)
+
+// meshFace is a face of the mesh defined by the indices of its vertices.
+type meshFace struct {
+	vertices [3]int
+
+	// flipped is true if the orientation was changed by the repair.
+	flipped bool
+
+	// filled is true if the face was added to close a hole.
+	filled bool
+
+	// component is the index of the connected part of the mesh the face belongs to.
+	component int
+}
+
+// flip reverses the orientation of the face.
+func (f *meshFace) flip() {
+	f.vertices[1], f.vertices[2] = f.vertices[2], f.vertices[1]
+	f.flipped = !f.flipped
+}
+
+// edge is a directed edge between two vertices.
+type edge [2]int
+
+// key returns the same value for both directions of the edge.
+func (e edge) key() edge {
+	if e[0] > e[1] {
+		return edge{e[1], e[0]}
+	}
+	return e
+}
+
+func (f meshFace) edges() [3]edge {
+	return [3]edge{
+		{f.vertices[0], f.vertices[1]},
+		{f.vertices[1], f.vertices[2]},
+		{f.vertices[2], f.vertices[0]},
+	}
+}
+
+// mesh is an indexed mesh in which touching faces share the same vertices.
+type mesh struct {
+	vertices []data.MicroVec3
+	faces    []meshFace
+}
+
+// weldCell is a cell of the grid used to find vertices within the weld distance.
+type weldCell [3]data.Micrometer
+
+func newWeldCell(p data.MicroVec3, size data.Micrometer) weldCell {
+	floor := func(v data.Micrometer) data.Micrometer {
+		if v < 0 {
+			return (v - size + 1) / size
+		}
+		return v / size
+	}
+	return weldCell{floor(p.X()), floor(p.Y()), floor(p.Z())}
+}
+
+// newMesh creates the mesh out of the model and welds all vertices within the weldDistance.
+func newMesh(m data.Model, weldDistance data.Micrometer, report *Report) *mesh {
+	if weldDistance < 1 {
+		weldDistance = 1
+	}
+
+	result := &mesh{
+		faces: make([]meshFace, 0, m.FaceCount()),
+	}
+	grid := map[weldCell][]int{}
+
+	for i := 0; i < m.FaceCount(); i++ {
+		var face meshFace
+
+	PointsLoop:
+		for j, p := range m.Face(i).Points() {
+			cell := newWeldCell(p, weldDistance)
+
+			// search the cell and all neighbours
+			for x := data.Micrometer(-1); x <= 1; x++ {
+				for y := data.Micrometer(-1); y <= 1; y++ {
+					for z := data.Micrometer(-1); z <= 1; z++ {
+						for _, index := range grid[weldCell{cell[0] + x, cell[1] + y, cell[2] + z}] {
+							existing := result.vertices[index]
+							if existing.Sub(p).ShorterThanOrEqual(weldDistance) {
+								if existing.X() != p.X() || existing.Y() != p.Y() || existing.Z() != p.Z() {
+									report.WeldedVertices++
+								}
+								face.vertices[j] = index
+								continue PointsLoop
+							}
+						}
+					}
+				}
+			}
+
+			face.vertices[j] = len(result.vertices)
+			grid[cell] = append(grid[cell], len(result.vertices))
+			result.vertices = append(result.vertices, p.Copy())
+		}
+
+		result.faces = append(result.faces, face)
+	}
+
+	return result
+}
+
+// removeDegenerateFaces removes all faces which use a vertex twice, e.g. after welding.
+// Faces with three different vertices on one line are kept, as they still connect
+// the touching faces at T-junctions.
+func (m *mesh) removeDegenerateFaces(report *Report) {
+	faces := m.faces[:0]
+	for _, face := range m.faces {
+		if face.vertices[0] == face.vertices[1] || face.vertices[1] == face.vertices[2] || face.vertices[0] == face.vertices[2] {
+			report.DegenerateFaces++
+			continue
+		}
+		faces = append(faces, face)
+	}
+	m.faces = faces
+}
+
+// removeDuplicateFaces removes all faces which use the same vertices as another face, regardless of the orientation.
+func (m *mesh) removeDuplicateFaces(report *Report) {
+	existing := make(map[[3]int]bool, len(m.faces))
+	faces := m.faces[:0]
+	for _, face := range m.faces {
+		key := face.vertices
+		// sort the three indices
+		if key[0] > key[1] {
+			key[0], key[1] = key[1], key[0]
+		}
+		if key[1] > key[2] {
+			key[1], key[2] = key[2], key[1]
+		}
+		if key[0] > key[1] {
+			key[0], key[1] = key[1], key[0]
+		}
+
+		if existing[key] {
+			report.DuplicateFaces++
+			continue
+		}
+		existing[key] = true
+		faces = append(faces, face)
+	}
+	m.faces = faces
+}
+
+// edgeFaces returns the indices of the faces using each edge.
+func (m *mesh) edgeFaces() map[edge][]int {
+	edges := make(map[edge][]int, len(m.faces)*3/2)
+	for i, face := range m.faces {
+		for _, e := range face.edges() {
+			edges[e.key()] = append(edges[e.key()], i)
+		}
+	}
+	return edges
+}
+
+// orient orients all faces consistently to the first face of each connected part.
+// Faces are only seen as connected if the edge between them is not used by other faces.
+// It also sets the component of each face.
+func (m *mesh) orient() {
+	edges := m.edgeFaces()
+	visited := make([]bool, len(m.faces))
+	component := 0
+
+	for start := range m.faces {
+		if visited[start] {
+			continue
+		}
+
+		visited[start] = true
+		m.faces[start].component = component
+		queue := []int{start}
+
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+
+			for _, e := range m.faces[current].edges() {
+				touching := edges[e.key()]
+				if len(touching) != 2 {
+					continue
+				}
+
+				other := touching[0]
+				if other == current {
+					other = touching[1]
+				}
+				if visited[other] {
+					continue
+				}
+
+				// a consistently oriented face uses the edge in the other direction
+				for _, otherEdge := range m.faces[other].edges() {
+					if otherEdge == e {
+						m.faces[other].flip()
+						break
+					}
+				}
+
+				visited[other] = true
+				m.faces[other].component = component
+				queue = append(queue, other)
+			}
+		}
+
+		component++
+	}
+}
+
+// boundaryEdges returns all edges which are used by only one face in the direction of that face.
+func (m *mesh) boundaryEdges() []edge {
+	edges := m.edgeFaces()
+	var boundary []edge
+	for _, face := range m.faces {
+		for _, e := range face.edges() {
+			if len(edges[e.key()]) == 1 {
+				boundary = append(boundary, e)
+			}
+		}
+	}
+	return boundary
+}
+
+// closeHoles closes all holes which do not have more than maxEdges edges.
+// A hole is a loop of boundary edges. It is closed with a fan of faces around the center of the hole.
+func (m *mesh) closeHoles(maxEdges int, report *Report) {
+	boundary := m.boundaryEdges()
+
+	// boundary edges starting at each vertex
+	outgoing := make(map[int][]edge, len(boundary))
+	for _, e := range boundary {
+		outgoing[e[0]] = append(outgoing[e[0]], e)
+	}
+	used := make(map[edge]bool, len(boundary))
+
+	// the faces of each boundary edge are needed to find the component of the hole
+	edgeFaces := m.edgeFaces()
+
+	for _, start := range boundary {
+		if used[start] {
+			continue
+		}
+
+		// follow the boundary until the start is reached again
+		loop := []edge{start}
+		used[start] = true
+		for loop[len(loop)-1][1] != start[0] {
+			var next edge
+			found := false
+			for _, candidate := range outgoing[loop[len(loop)-1][1]] {
+				if !used[candidate] {
+					next = candidate
+					found = true
+					break
+				}
+			}
+			if !found {
+				break
+			}
+
+			used[next] = true
+			loop = append(loop, next)
+		}
+
+		if loop[len(loop)-1][1] != start[0] || len(loop) < 3 || len(loop) > maxEdges {
+			continue
+		}
+
+		component := m.faces[edgeFaces[start.key()][0]].component
+		report.FilledHoles++
+
+		// a filled face uses each boundary edge in the other direction
+		if len(loop) == 3 {
+			m.faces = append(m.faces, meshFace{
+				vertices:  [3]int{loop[0][0], loop[2][0], loop[1][0]},
+				filled:    true,
+				component: component,
+			})
+			continue
+		}
+
+		var x, y, z int64
+		for _, e := range loop {
+			p := m.vertices[e[0]]
+			x += int64(p.X())
+			y += int64(p.Y())
+			z += int64(p.Z())
+		}
+		count := int64(len(loop))
+		center := len(m.vertices)
+		m.vertices = append(m.vertices, data.NewMicroVec3(data.Micrometer(x/count), data.Micrometer(y/count), data.Micrometer(z/count)))
+
+		for _, e := range loop {
+			m.faces = append(m.faces, meshFace{
+				vertices:  [3]int{e[1], e[0], center},
+				filled:    true,
+				component: component,
+			})
+		}
+	}
+}
+
+// orientOutwards flips all faces of each connected part if its volume is negative.
+// The volume is only negative if the normals point inwards.
+func (m *mesh) orientOutwards() {
+	volumes := map[int]float64{}
+	for _, face := range m.faces {
+		a, b, c := m.vertices[face.vertices[0]], m.vertices[face.vertices[1]], m.vertices[face.vertices[2]]
+		ax, ay, az := float64(a.X().ToMillimeter()), float64(a.Y().ToMillimeter()), float64(a.Z().ToMillimeter())
+		bx, by, bz := float64(b.X().ToMillimeter()), float64(b.Y().ToMillimeter()), float64(b.Z().ToMillimeter())
+		cx, cy, cz := float64(c.X().ToMillimeter()), float64(c.Y().ToMillimeter()), float64(c.Z().ToMillimeter())
+
+		// signed volume of the tetrahedron between the face and the origin
+		volumes[face.component] += ax*(by*cz-bz*cy) - ay*(bx*cz-bz*cx) + az*(bx*cy-by*cx)
+	}
+
+	for i := range m.faces {
+		if volumes[m.faces[i].component] < 0 {
+			m.faces[i].flip()
+		}
+	}
+}
+
+// flippedFaces returns the number of faces of the original model which have a changed orientation.
+func (m *mesh) flippedFaces() int {
+	count := 0
+	for _, face := range m.faces {
+		if face.flipped && !face.filled {
+			count++
+		}
+	}
+	return count
+}
+
+// model returns the mesh as data.Model.
+func (m *mesh) model() data.Model {
+	faces := make([]data.Face, len(m.faces))
+	for i, f := range m.faces {
+		faces[i] = face{points: [3]data.MicroVec3{
+			m.vertices[f.vertices[0]].Copy(),
+			m.vertices[f.vertices[1]].Copy(),
+			m.vertices[f.vertices[2]].Copy(),
+		}}
+	}
+
+	return &model{faces: faces}
+}
+
+type face struct {
+	points [3]data.MicroVec3
+}
+
+func (f face) Points() [3]data.MicroVec3 {
+	return f.points
+}
+
+type model struct {
+	faces []data.Face
+}
+
+func (m *model) FaceCount() int {
+	return len(m.faces)
+}
+
+func (m *model) Face(index int) data.Face {
+	return m.faces[index]
+}
+
+func (m *model) Min() data.MicroVec3 {
+	min := m.faces[0].Points()[0].Copy()
+	for _, f := range m.faces {
+		for _, p := range f.Points() {
+			min = data.NewMicroVec3(data.Min(min.X(), p.X()), data.Min(min.Y(), p.Y()), data.Min(min.Z(), p.Z()))
+		}
+	}
+	return min
+}
+
+func (m *model) Max() data.MicroVec3 {
+	max := m.faces[0].Points()[0].Copy()
+	for _, f := range m.faces {
+		for _, p := range f.Points() {
+			max = data.NewMicroVec3(data.Max(max.X(), p.X()), data.Max(max.Y(), p.Y()), data.Max(max.Z(), p.Z()))
+		}
+	}
+	return max
+}
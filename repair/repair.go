@@ -0,0 +1,126 @@
+// Package repair contains the built in model repair.
+//
+// How it works:
+// The faces of the model are converted into an indexed mesh in which touching faces share their vertices.
+// Based on this mesh several common errors, e.g. of 3d scans, are fixed which would otherwise result in
+// open polygons which are dropped while slicing:
+//  1. Welding vertices:
+//     Vertices which are within the weld distance are merged into one vertex.
+//  2. Removing degenerate faces:
+//     Faces which use the same vertex twice, e.g. after welding, are removed.
+//  3. Removing duplicate faces:
+//     Faces which use the same vertices as another face are removed.
+//  4. Fixing inverted normals:
+//     The faces are oriented consistently starting with one face and walking to the touching faces.
+//     Then each connected part is flipped completely if its volume is negative, so that all normals point outwards.
+//  5. Closing small holes:
+//     Loops of edges which are used by only one face are closed if they do not have more than the max amount of edges.
+//
+// The repair is only done if it is enabled in the options.
+package repair
+
+import (
+	"errors"
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+)
+
+// Report contains the number of errors fixed by the repair.
+type Report struct {
+	WeldedVertices  int
+	DegenerateFaces int
+	DuplicateFaces  int
+	FlippedFaces    int
+	FilledHoles     int
+
+	// OpenEdges is the number of edges still used by only one face after the repair.
+	OpenEdges int
+}
+
+func (r Report) String() string {
+	return fmt.Sprintf("welded %d vertices, removed %d degenerate and %d duplicate faces, flipped %d faces, closed %d holes, %d open edges left",
+		r.WeldedVertices, r.DegenerateFaces, r.DuplicateFaces, r.FlippedFaces, r.FilledHoles, r.OpenEdges)
+}
+
+// add adds the numbers of the other report.
+func (r *Report) add(other Report) {
+	r.WeldedVertices += other.WeldedVertices
+	r.DegenerateFaces += other.DegenerateFaces
+	r.DuplicateFaces += other.DuplicateFaces
+	r.FlippedFaces += other.FlippedFaces
+	r.FilledHoles += other.FilledHoles
+	r.OpenEdges += other.OpenEdges
+}
+
+type repairer struct {
+	options *data.Options
+}
+
+// NewRepairer provides a model repairer which repairs the model if it is enabled in the options.
+// A data.ModelGroup is repaired model by model and the model of data.ModelCopies only once.
+func NewRepairer(options *data.Options) handler.ModelRepairer {
+	return &repairer{
+		options: options,
+	}
+}
+
+func (r repairer) Repair(m data.Model) (data.Model, error) {
+	if !r.options.Slicing.Repair.Enabled {
+		return m, nil
+	}
+
+	var repaired data.Model
+	var report Report
+	var err error
+	if copies, ok := m.(data.ModelCopies); ok {
+		// the copies are equal, so it is enough to repair the model once
+		var original data.Model
+		original, report, err = Repair(copies.Original(), r.options.Slicing.Repair.WeldDistance, r.options.Slicing.Repair.MaxHoleEdges)
+		if err != nil {
+			return nil, err
+		}
+		repaired = data.NewModelCopies(original, len(copies.Models()))
+	} else if group, ok := m.(data.ModelGroup); ok {
+		models := make([]data.Model, len(group.Models()))
+		for i, model := range group.Models() {
+			var modelReport Report
+			models[i], modelReport, err = Repair(model, r.options.Slicing.Repair.WeldDistance, r.options.Slicing.Repair.MaxHoleEdges)
+			if err != nil {
+				return nil, fmt.Errorf("could not repair the model %d: %w", i, err)
+			}
+			report.add(modelReport)
+		}
+		repaired = data.NewModelGroup(models...)
+	} else {
+		repaired, report, err = Repair(m, r.options.Slicing.Repair.WeldDistance, r.options.Slicing.Repair.MaxHoleEdges)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	r.options.GoSlice.Logger.Printf("Model repaired: %v\n", report)
+	return repaired, nil
+}
+
+// Repair repairs the model and returns the repaired model with a report of the fixed errors.
+// Vertices within the weldDistance are merged and holes with up to maxHoleEdges edges are closed.
+// If no face is left after removing the degenerate faces, an error is returned.
+func Repair(m data.Model, weldDistance data.Micrometer, maxHoleEdges int) (data.Model, Report, error) {
+	var report Report
+
+	mesh := newMesh(m, weldDistance, &report)
+	mesh.removeDegenerateFaces(&report)
+	if len(mesh.faces) == 0 {
+		return nil, report, errors.New("the model has no faces left after removing the degenerate faces")
+	}
+	mesh.removeDuplicateFaces(&report)
+	mesh.orient()
+	mesh.closeHoles(maxHoleEdges, &report)
+	mesh.orientOutwards()
+
+	report.FlippedFaces = mesh.flippedFaces()
+	report.OpenEdges = len(mesh.boundaryEdges())
+
+	return mesh.model(), report, nil
+}
@@ -0,0 +1,120 @@
+package repair
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// cubeFaces returns the faces of a cube with the given size and outward normals.
+func cubeFaces(size data.Micrometer) []data.Face {
+	v := func(x, y, z data.Micrometer) data.MicroVec3 {
+		return data.NewMicroVec3(x*size, y*size, z*size)
+	}
+	quad := func(a, b, c, d data.MicroVec3) []data.Face {
+		return []data.Face{
+			face{points: [3]data.MicroVec3{a, b, c}},
+			face{points: [3]data.MicroVec3{a.Copy(), c.Copy(), d}},
+		}
+	}
+
+	var faces []data.Face
+	faces = append(faces, quad(v(0, 0, 0), v(0, 1, 0), v(1, 1, 0), v(1, 0, 0))...) // bottom
+	faces = append(faces, quad(v(0, 0, 1), v(1, 0, 1), v(1, 1, 1), v(0, 1, 1))...) // top
+	faces = append(faces, quad(v(0, 0, 0), v(1, 0, 0), v(1, 0, 1), v(0, 0, 1))...) // front
+	faces = append(faces, quad(v(0, 1, 0), v(0, 1, 1), v(1, 1, 1), v(1, 1, 0))...) // back
+	faces = append(faces, quad(v(0, 0, 0), v(0, 0, 1), v(0, 1, 1), v(0, 1, 0))...) // left
+	faces = append(faces, quad(v(1, 0, 0), v(1, 1, 0), v(1, 1, 1), v(1, 0, 1))...) // right
+	return faces
+}
+
+func flipped(f data.Face) data.Face {
+	points := f.Points()
+	return face{points: [3]data.MicroVec3{points[0], points[2], points[1]}}
+}
+
+func TestRepair(t *testing.T) {
+	var testCases = map[string]struct {
+		faces    func() []data.Face
+		expected Report
+		count    int
+	}{
+		"valid cube": {
+			faces:    func() []data.Face { return cubeFaces(10000) },
+			expected: Report{},
+			count:    12,
+		},
+		"inverted cube": {
+			faces: func() []data.Face {
+				faces := cubeFaces(10000)
+				for i, f := range faces {
+					faces[i] = flipped(f)
+				}
+				return faces
+			},
+			expected: Report{FlippedFaces: 12},
+			count:    12,
+		},
+		"flipped, duplicate and degenerate faces": {
+			faces: func() []data.Face {
+				faces := cubeFaces(10000)
+				faces[3] = flipped(faces[3])
+				faces = append(faces, faces[5])
+				return append(faces, face{points: [3]data.MicroVec3{
+					data.NewMicroVec3(0, 0, 0),
+					data.NewMicroVec3(1, 0, 0),
+					data.NewMicroVec3(10000, 10000, 10000),
+				}})
+			},
+			expected: Report{WeldedVertices: 1, DegenerateFaces: 1, DuplicateFaces: 1, FlippedFaces: 1},
+			count:    12,
+		},
+		"holes": {
+			faces: func() []data.Face {
+				faces := cubeFaces(10000)
+				// remove one triangle of the bottom and the whole top
+				return append(faces[1:2], faces[4:]...)
+			},
+			expected: Report{FilledHoles: 2},
+			// the top is filled by four faces around the center
+			count: 9 + 1 + 4,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		m, report, err := Repair(&model{faces: testCase.faces()}, 2, 4)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, report)
+		test.Equals(t, testCase.count, m.FaceCount())
+
+		// all faces have to be oriented outwards
+		repaired := newMesh(m, 1, &Report{})
+		repaired.orient()
+		repaired.orientOutwards()
+		test.Equals(t, 0, repaired.flippedFaces())
+	}
+}
+
+func TestRepairWithoutFaces(t *testing.T) {
+	var testCases = map[string]struct {
+		faces []data.Face
+	}{
+		"no faces": {},
+		"only degenerate faces": {
+			faces: []data.Face{
+				face{points: [3]data.MicroVec3{
+					data.NewMicroVec3(0, 0, 0),
+					data.NewMicroVec3(1, 0, 0),
+					data.NewMicroVec3(10000, 10000, 10000),
+				}},
+			},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		_, _, err := Repair(&model{faces: testCase.faces}, 2, 4)
+		test.Assert(t, err != nil, "an empty mesh should return an error")
+	}
+}
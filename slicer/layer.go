@@ -10,6 +10,7 @@ type layer struct {
 	faceToSegmentIndex map[int]int
 	polygons           data.Paths
 	closed             []bool
+	openPolygons       data.Paths
 	number             int
 }
 
@@ -38,6 +39,7 @@ func (l *layer) Polygons() data.Paths {
 // - Some polygons are already nearly finished (start and end point is near together). These just get closed.
 //
 // If there are still not closed polygons, just remove them. Also remove very small polygons.
+// The removed open polygons which are not too small are kept separately as they are printed in the surface modes.
 func (l *layer) makePolygons(om data.OptimizedModel, joinPolygonSnapDistance, finishPolygonSnapDistance data.Micrometer) {
 	// try for each segment to generate a slicePolygon with other segments
 	// if the segment is not already assigned to another slicePolygon
@@ -71,7 +73,8 @@ func (l *layer) makePolygons(om data.OptimizedModel, joinPolygonSnapDistance, fi
 			//   -> close it as a slicePolygon is finished
 			// * if the segment is already added just continue
 			// then set the next index to the touching segment
-			for _, touchingFaceIndex := range face.TouchingFaceIndices() {
+			// If none is found, the indirect touching faces (e.g. at T-vertices) are checked the same way.
+			checkTouchingFace := func(touchingFaceIndex int) {
 				touchingSegmentIndex, ok := l.faceToSegmentIndex[touchingFaceIndex]
 				if touchingFaceIndex > -1 && ok {
 					p1 := l.segments[touchingSegmentIndex].start
@@ -82,12 +85,20 @@ func (l *layer) makePolygons(om data.OptimizedModel, joinPolygonSnapDistance, fi
 							canClose = true
 						}
 						if l.segments[touchingSegmentIndex].addedToPolygon {
-							continue
+							return
 						}
 						nextIndex = touchingSegmentIndex
 					}
 				}
 			}
+			for _, touchingFaceIndex := range face.TouchingFaceIndices() {
+				checkTouchingFace(touchingFaceIndex)
+			}
+			if nextIndex == -1 {
+				for _, touchingFaceIndex := range face.IndirectTouchingFaceIndices() {
+					checkTouchingFace(touchingFaceIndex)
+				}
+			}
 
 			if nextIndex == -1 {
 				break
@@ -178,6 +189,8 @@ RerunConnectPolygons:
 		// remove already cleared polygons and filter also not closed / too small ones
 		if l.polygons[i] != nil && length > finishPolygonSnapDistance && l.closed[i] {
 			clearedPolygons = append(clearedPolygons, l.polygons[i])
+		} else if l.polygons[i] != nil && length > finishPolygonSnapDistance {
+			l.openPolygons = append(l.openPolygons, l.polygons[i])
 		}
 	}
 
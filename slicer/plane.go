@@ -0,0 +1,196 @@
+// This file provides the slicing planes which define the shape of the layers.
+
+package slicer
+
+import (
+	"fmt"
+	"github.com/aligator/goslice/data"
+	"math"
+)
+
+// SlicingPlane defines the shape of the layers.
+// The slicer always slices planar, so a SlicingPlane transforms the model
+// into a space in which the layers are planar and transforms the
+// sliced layers back into the real space when the GCode is generated.
+//
+// Both transformations may only change the Z coordinate.
+// As only the vertices of the faces are transformed, non-linear planes
+// (e.g. conical) are only approximated between the vertices.
+type SlicingPlane interface {
+	// Init is called once with the model before slicing.
+	// It can be used to calculate model specific values.
+	Init(model data.OptimizedModel)
+
+	// Transform transforms a point of the model into the slicing space.
+	Transform(p data.MicroVec3) data.MicroVec3
+
+	// Inverse transforms a point of the slicing space back into the real space.
+	Inverse(p data.MicroVec3) data.MicroVec3
+}
+
+// NewSlicingPlane returns the SlicingPlane configured in the options.
+// For the default planar slicing nil is returned.
+func NewSlicingPlane(options *data.Options) (SlicingPlane, error) {
+	switch options.Slicing.Plane.Type {
+	case "", "planar":
+		return nil, nil
+	case "conical":
+		return NewConicalPlane(options.Slicing.Plane.Angle, options.Print.InitialLayerThickness), nil
+	case "tilted":
+		return NewTiltedPlane(options.Slicing.Plane.Angle, options.Print.InitialLayerThickness), nil
+	default:
+		return nil, fmt.Errorf("unknown slicing plane %q", options.Slicing.Plane.Type)
+	}
+}
+
+// offsetPlane is a SlicingPlane which raises the layers by an offset depending on the X and Y position.
+// The offset is given by the zOffset function.
+type offsetPlane struct {
+	zOffset func(p data.MicroPoint) data.Micrometer
+
+	// init is called before the shift is calculated.
+	init func(model data.OptimizedModel)
+
+	// shift moves the transformed model so that its lowest point is at 0.
+	shift data.Micrometer
+
+	// minZ is the lowest Z returned by Inverse.
+	minZ data.Micrometer
+}
+
+func (o *offsetPlane) Init(model data.OptimizedModel) {
+	if o.init != nil {
+		o.init(model)
+	}
+
+	minZ := data.MaxMicrometer
+	for i := 0; i < model.FaceCount(); i++ {
+		for _, point := range model.Face(i).Points() {
+			if z := point.Z() - o.zOffset(point.PointXY()); z < minZ {
+				minZ = z
+			}
+		}
+	}
+	o.shift = -minZ
+}
+
+func (o *offsetPlane) Transform(p data.MicroVec3) data.MicroVec3 {
+	return data.NewMicroVec3(p.X(), p.Y(), p.Z()-o.zOffset(p.PointXY())+o.shift)
+}
+
+func (o *offsetPlane) Inverse(p data.MicroVec3) data.MicroVec3 {
+	return data.NewMicroVec3(p.X(), p.Y(), data.Max(p.Z()+o.zOffset(p.PointXY())-o.shift, o.minZ))
+}
+
+// NewConicalPlane returns a SlicingPlane which slices in cones around the center of the model.
+// With a positive angle the layers rise to the outside which makes outward overhangs printable without support.
+// With a negative angle the layers fall to the outside which helps with inward overhangs.
+// Positions which would be below minZ are raised to minZ. This happens for example for the skirt
+// or on big faces as the cone is only approximated between the vertices.
+func NewConicalPlane(angle int, minZ data.Micrometer) SlicingPlane {
+	var center data.MicroPoint
+	slope := math.Tan(data.ToRadians(float64(angle)))
+
+	return &offsetPlane{
+		minZ: minZ,
+		init: func(model data.OptimizedModel) {
+			min, max := model.Min(), model.Max()
+			center = data.NewMicroPoint((min.X()+max.X())/2, (min.Y()+max.Y())/2)
+		},
+		zOffset: func(p data.MicroPoint) data.Micrometer {
+			return data.Micrometer(float64(p.Sub(center).Size()) * slope)
+		},
+	}
+}
+
+// NewTiltedPlane returns a SlicingPlane which slices in planes tilted around the X axis by the given angle.
+// This can be used for belt printers where the layers are tilted in the direction of the belt (Y).
+// Positions which would be below minZ are raised to minZ.
+func NewTiltedPlane(angle int, minZ data.Micrometer) SlicingPlane {
+	slope := math.Tan(data.ToRadians(float64(angle)))
+
+	return &offsetPlane{
+		minZ: minZ,
+		zOffset: func(p data.MicroPoint) data.Micrometer {
+			return data.Micrometer(float64(p.Y()) * slope)
+		},
+	}
+}
+
+// planeModel wraps an OptimizedModel and transforms all faces using a SlicingPlane.
+type planeModel struct {
+	data.OptimizedModel
+	faces    []planeFace
+	min, max data.MicroVec3
+}
+
+// planeFace is a face transformed by a SlicingPlane.
+type planeFace struct {
+	data.OptimizedFace
+	points [3]data.MicroVec3
+}
+
+func (p planeFace) Points() [3]data.MicroVec3 {
+	return p.points
+}
+
+func (p planeFace) MinZ() data.Micrometer {
+	return data.Min(p.points[0].Z(), data.Min(p.points[1].Z(), p.points[2].Z()))
+}
+
+func (p planeFace) MaxZ() data.Micrometer {
+	return data.Max(p.points[0].Z(), data.Max(p.points[1].Z(), p.points[2].Z()))
+}
+
+// newPlaneModel returns the model transformed into the slicing space of the given plane.
+func newPlaneModel(model data.OptimizedModel, plane SlicingPlane) *planeModel {
+	plane.Init(model)
+
+	m := &planeModel{
+		OptimizedModel: model,
+		faces:          make([]planeFace, model.FaceCount()),
+	}
+
+	for i := range m.faces {
+		face := model.OptimizedFace(i)
+		m.faces[i].OptimizedFace = face
+		for j, point := range face.Points() {
+			m.faces[i].points[j] = plane.Transform(point)
+		}
+	}
+
+	for i, face := range m.faces {
+		for j, point := range face.points {
+			if i == 0 && j == 0 {
+				m.min = point.Copy()
+				m.max = point.Copy()
+				continue
+			}
+
+			m.min = data.NewMicroVec3(data.Min(m.min.X(), point.X()), data.Min(m.min.Y(), point.Y()), data.Min(m.min.Z(), point.Z()))
+			m.max = data.NewMicroVec3(data.Max(m.max.X(), point.X()), data.Max(m.max.Y(), point.Y()), data.Max(m.max.Z(), point.Z()))
+		}
+	}
+
+	return m
+}
+
+func (m *planeModel) Face(index int) data.Face {
+	return m.faces[index]
+}
+
+func (m *planeModel) OptimizedFace(index int) data.OptimizedFace {
+	return m.faces[index]
+}
+
+func (m *planeModel) Min() data.MicroVec3 {
+	return m.min
+}
+
+func (m *planeModel) Max() data.MicroVec3 {
+	return m.max
+}
+
+func (m *planeModel) Size() data.MicroVec3 {
+	return m.max.Sub(m.min)
+}
@@ -0,0 +1,97 @@
+package slicer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/optimizer"
+	"github.com/aligator/goslice/util/test"
+	"testing"
+)
+
+// halfSlope returns a plane which rises by half of the Y position.
+// The slope is exact, so faces which are parallel to the plane lie exactly on one height in the slicing space.
+func halfSlope() SlicingPlane {
+	return &offsetPlane{
+		zOffset: func(p data.MicroPoint) data.Micrometer {
+			return p.Y() / 2
+		},
+	}
+}
+
+// sheared raises all points of the faces by half of their Y position,
+// so that the horizontal faces become parallel to the halfSlope plane.
+func sheared(faces []data.Face) []data.Face {
+	result := make([]data.Face, len(faces))
+	for i, face := range faces {
+		var points testFace
+		for j, p := range face.Points() {
+			points[j] = data.NewMicroVec3(p.X(), p.Y(), p.Z()+p.Y()/2)
+		}
+		result[i] = points
+	}
+	return result
+}
+
+// pyramid returns the faces of a pyramid with a rectangular base and the apex above the center of the base.
+func pyramid(x0, y0, z0, x1, y1, z1 data.Micrometer) []data.Face {
+	base := [4]data.MicroVec3{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x1, y0, z0), data.NewMicroVec3(x1, y1, z0), data.NewMicroVec3(x0, y1, z0)}
+	apex := data.NewMicroVec3((x0+x1)/2, (y0+y1)/2, z1)
+
+	faces := []data.Face{
+		testFace{base[0], base[2], base[1]},
+		testFace{base[0], base[3], base[2]},
+	}
+	for i := range base {
+		faces = append(faces, testFace{base[i], base[(i+1)%4], apex})
+	}
+	return faces
+}
+
+func TestSlicePlane(t *testing.T) {
+	var testCases = map[string]struct {
+		faces []data.Face
+		// expectedParts contains the amount of parts of each layer
+		expectedParts []int
+		// expectedWidths contains the width in X of each layer, summed up over all parts
+		expectedWidths []data.Micrometer
+	}{
+		"face on the slicing plane": {
+			// the top face lies exactly on the top of the last layer
+			faces:          sheared(cuboid(0, 0, 0, 10000, 10000, 1000)),
+			expectedParts:  []int{1, 1, 1, 1, 1},
+			expectedWidths: []data.Micrometer{10000, 10000, 10000, 10000, 10000},
+		},
+		"vertex on the slicing plane": {
+			// the apex of the pyramid touches the top of the layer 2, which adds no part to it
+			faces: sheared(append(
+				cuboid(0, 0, 0, 10000, 10000, 1000),
+				pyramid(20000, 0, 0, 24000, 10000, 600)...,
+			)),
+			expectedParts:  []int{2, 2, 1, 1, 1},
+			expectedWidths: []data.Micrometer{10000 + 2667, 10000 + 1333, 10000, 10000, 10000},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		options := data.DefaultOptions()
+		model, err := optimizer.NewOptimizer(&options).Optimize(testModel(testCase.faces))
+		test.Ok(t, err)
+
+		layers, err := NewSlicer(&options, WithSlicingPlane(halfSlope())).Slice(model)
+		test.Ok(t, err)
+		test.Equals(t, len(testCase.expectedParts), len(layers))
+
+		for layerNr, layer := range layers {
+			parts := layer.LayerParts()
+			test.Equals(t, testCase.expectedParts[layerNr], len(parts))
+
+			var width data.Micrometer
+			for _, part := range parts {
+				min, max := part.Outline().Bounds()
+				width += max.X() - min.X()
+			}
+			diff := width - testCase.expectedWidths[layerNr]
+			test.Assert(t, diff >= -1 && diff <= 1, "the layer %v should be %v wide but is %v", layerNr, testCase.expectedWidths[layerNr], width)
+		}
+	}
+}
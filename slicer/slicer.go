@@ -23,19 +23,88 @@ import (
 
 type slicer struct {
 	options *data.Options
+	plane   SlicingPlane
+}
+
+type option func(s *slicer)
+
+// WithSlicingPlane sets the SlicingPlane which defines the shape of the layers.
+// By default the layers are planar.
+func WithSlicingPlane(plane SlicingPlane) option {
+	return func(s *slicer) {
+		s.plane = plane
+	}
 }
 
 // NewSlicer provides the built in slicer implementation.
-func NewSlicer(options *data.Options) handler.ModelSlicer {
-	return &slicer{options: options}
+func NewSlicer(options *data.Options, slicerOptions ...option) handler.ModelSlicer {
+	s := &slicer{options: options}
+
+	for _, option := range slicerOptions {
+		option(s)
+	}
+
+	return s
 }
 
 func (s slicer) Slice(m data.OptimizedModel) ([]data.PartitionedLayer, error) {
-	layerCount := (m.Size().Z()-s.options.Print.InitialLayerThickness)/s.options.Print.LayerThickness + 1
+	return s.SliceRange(m, 0, s.LayerCount(m))
+}
+
+// LayerCount returns the amount of layers of the model.
+func (s slicer) LayerCount(m data.OptimizedModel) int {
+	if s.plane != nil {
+		m = newPlaneModel(m, s.plane)
+	}
+
+	return s.options.Print.LayerHeights().Count(m.Size().Z())
+}
+
+// SliceRange slices only the layers from the layer number from (inclusive) to the layer number to (exclusive).
+func (s slicer) SliceRange(m data.OptimizedModel, from, to int) ([]data.PartitionedLayer, error) {
+	heights := s.options.Print.LayerHeights()
+
+	// models which do not keep their faces in memory provide only the faces of the range
+	if ranged, ok := m.(data.ZRangeModel); ok {
+		// the faces of the model may be read lazily
+		if err := data.ModelErr(ranged); err != nil {
+			return nil, err
+		}
+
+		minZ, maxZ := heights.Z(from), heights.Z(to-1)
+		if s.plane != nil {
+			// the layers are not planar, so they may need the faces of all heights
+			minZ, maxZ = ranged.Min().Z(), ranged.Max().Z()
+		}
+
+		var err error
+		m, err = ranged.ZRange(minZ, maxZ)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if s.plane != nil {
+		m = newPlaneModel(m, s.plane)
+	}
+
+	layers := make([]*layer, to-from)
+
+	// only the faces overlapping the layers have to be checked if the model can find them
+	faceCount := m.FaceCount()
+	var faceIndices []int
+	indexed, isIndexed := m.(data.ZIndexedModel)
+	if isIndexed {
+		faceIndices = indexed.FacesInZRange(heights.Z(from), heights.Z(to-1))
+		faceCount = len(faceIndices)
+	}
 
-	layers := make([]*layer, layerCount)
+	for n := 0; n < faceCount; n++ {
+		i := n
+		if isIndexed {
+			i = faceIndices[n]
+		}
 
-	for i := 0; i < m.FaceCount(); i++ {
 		points := m.Face(i).Points()
 		minZ := points[0].Z()
 		maxZ := points[0].Z()
@@ -55,20 +124,24 @@ func (s slicer) Slice(m data.OptimizedModel) ([]data.PartitionedLayer, error) {
 		}
 
 		// for each layerNr
-		for layerNr := int((minZ - s.options.Print.InitialLayerThickness) / s.options.Print.LayerThickness); data.Micrometer(layerNr) <= (maxZ-s.options.Print.InitialLayerThickness)/s.options.Print.LayerThickness; layerNr++ {
-			z := data.Micrometer(layerNr)*s.options.Print.LayerThickness + s.options.Print.InitialLayerThickness
+		firstLayer, lastLayer := heights.Range(minZ, maxZ)
+		for layerNr := firstLayer; layerNr <= lastLayer; layerNr++ {
+			z := heights.Z(layerNr)
 			if z < minZ {
 				continue
 			}
-			if layerNr < 0 {
+			if layerNr < from {
 				continue
 			}
+			if layerNr >= to {
+				break
+			}
 
-			if layers[layerNr] == nil {
-				layers[layerNr] = newLayer(layerNr, s.options)
+			if layers[layerNr-from] == nil {
+				layers[layerNr-from] = newLayer(layerNr, s.options)
 			}
 
-			layer := layers[layerNr]
+			layer := layers[layerNr-from]
 
 			var seg *segment
 			switch {
@@ -107,18 +180,35 @@ func (s slicer) Slice(m data.OptimizedModel) ([]data.PartitionedLayer, error) {
 		}
 	}
 
+	// layers without any face stay empty, e.g. the layers above a lower body of a multi material model
+	for i := range layers {
+		if layers[i] == nil {
+			layers[i] = newLayer(from+i, s.options)
+		}
+	}
+
+	// the layers are independent of each other now, so their polygons and parts are generated in parallel
 	retLayers := make([]data.PartitionedLayer, len(layers))
-	c := clip.NewClipper()
+	c := clip.NewClipper(clip.WithLogger(s.options.GoSlice.Logger))
 
-	for i, layer := range layers {
+	err := data.ForEachLayer(s.options.GoSlice.WorkerCount(), len(layers), func(i int) error {
+		layer := layers[i]
 		layer.makePolygons(m, s.options.Slicing.JoinPolygonSnapDistance, s.options.Slicing.FinishPolygonSnapDistance)
 		lp, ok := c.GenerateLayerParts(layer)
 
 		if !ok {
-			return nil, fmt.Errorf("partitioning failed at layer %v", i)
+			return fmt.Errorf("partitioning failed at layer %v", from+i)
+		}
+
+		if s.options.Slicing.SurfaceMode == "surface" || s.options.Slicing.SurfaceMode == "both" {
+			lp = newSurfaceLayer(lp, layer, s.options.Slicing.SurfaceMode == "surface")
 		}
 
 		retLayers[i] = lp
+		return nil
+	})
+	if err != nil {
+		return nil, err
 	}
 
 	return retLayers, nil
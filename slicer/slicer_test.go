@@ -0,0 +1,107 @@
+package slicer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/handler"
+	"github.com/aligator/goslice/optimizer"
+	"github.com/aligator/goslice/util/test"
+	"io"
+	"testing"
+)
+
+type testFace [3]data.MicroVec3
+
+func (f testFace) Points() [3]data.MicroVec3 {
+	return f
+}
+
+// testModel is a data.Model which calculates its bounds from its faces.
+type testModel []data.Face
+
+func (m testModel) FaceCount() int {
+	return len(m)
+}
+
+func (m testModel) Face(index int) data.Face {
+	return m[index]
+}
+
+func (m testModel) Min() data.MicroVec3 {
+	min := m[0].Points()[0]
+	for _, f := range m {
+		for _, p := range f.Points() {
+			min = data.NewMicroVec3(data.Min(min.X(), p.X()), data.Min(min.Y(), p.Y()), data.Min(min.Z(), p.Z()))
+		}
+	}
+	return min
+}
+
+func (m testModel) Max() data.MicroVec3 {
+	max := m[0].Points()[0]
+	for _, f := range m {
+		for _, p := range f.Points() {
+			max = data.NewMicroVec3(data.Max(max.X(), p.X()), data.Max(max.Y(), p.Y()), data.Max(max.Z(), p.Z()))
+		}
+	}
+	return max
+}
+
+// cuboid returns the faces of a cuboid with the normals pointing outside.
+func cuboid(x0, y0, z0, x1, y1, z1 data.Micrometer) []data.Face {
+	quads := [][4]data.MicroVec3{
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x1, y1, z0), data.NewMicroVec3(x1, y0, z0)},
+		{data.NewMicroVec3(x0, y0, z1), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x0, y1, z1)},
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x1, y0, z0), data.NewMicroVec3(x1, y0, z1), data.NewMicroVec3(x0, y0, z1)},
+		{data.NewMicroVec3(x0, y1, z0), data.NewMicroVec3(x0, y1, z1), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y1, z0)},
+		{data.NewMicroVec3(x0, y0, z0), data.NewMicroVec3(x0, y0, z1), data.NewMicroVec3(x0, y1, z1), data.NewMicroVec3(x0, y1, z0)},
+		{data.NewMicroVec3(x1, y0, z0), data.NewMicroVec3(x1, y1, z0), data.NewMicroVec3(x1, y1, z1), data.NewMicroVec3(x1, y0, z1)},
+	}
+
+	var faces []data.Face
+	for _, q := range quads {
+		faces = append(faces, testFace{q[0], q[1], q[2]}, testFace{q[0], q[2], q[3]})
+	}
+	return faces
+}
+
+func TestSliceRangeOfZRangeModel(t *testing.T) {
+	// the upper cuboid is smaller, so the layers differ at the step
+	model := testModel(append(
+		cuboid(0, 0, 0, 20000, 20000, 3000),
+		cuboid(5000, 5000, 3000, 15000, 15000, 9000)...,
+	))
+
+	options := data.DefaultOptions()
+	whole, err := optimizer.NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+	expected, err := NewSlicer(&options).Slice(whole)
+	test.Ok(t, err)
+
+	// the faces are only loaded for each range if the layers are processed in windows
+	options.GoSlice.LayerWindow = 5
+	m, err := optimizer.NewOptimizer(&options).Optimize(model)
+	test.Ok(t, err)
+	_, ok := m.(data.ZRangeModel)
+	test.Assert(t, ok, "the model should be optimized in ranges")
+	defer m.(io.Closer).Close()
+
+	s := NewSlicer(&options).(handler.ModelRangeSlicer)
+	test.Equals(t, len(expected), s.LayerCount(m))
+	for from := 0; from < len(expected); from += options.GoSlice.LayerWindow {
+		to := from + options.GoSlice.LayerWindow
+		if to > len(expected) {
+			to = len(expected)
+		}
+
+		layers, err := s.SliceRange(m, from, to)
+		test.Ok(t, err)
+		test.Equals(t, to-from, len(layers))
+		for i, layer := range layers {
+			expectedParts := expected[from+i].LayerParts()
+			test.Equals(t, len(expectedParts), len(layer.LayerParts()))
+			for j, part := range layer.LayerParts() {
+				test.Equals(t, expectedParts[j].Outline(), part.Outline(), microPointComparer())
+			}
+		}
+	}
+}
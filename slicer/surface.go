@@ -0,0 +1,70 @@
+// This file provides the layers of the surface modes which contain the slices of the surfaces as single lines.
+
+package slicer
+
+import (
+	"errors"
+	"github.com/aligator/goslice/data"
+)
+
+// surfaceLayer is a layer with the attribute "surface".
+type surfaceLayer struct {
+	data.PartitionedLayer
+	attributes map[string]interface{}
+}
+
+// newSurfaceLayer adds the open polygons of the sliced layer as the attribute "surface".
+// If surfaceOnly is true, the closed polygons are moved to the attribute too,
+// so that the layer has no parts which would get perimeters and infill.
+func newSurfaceLayer(partitioned data.PartitionedLayer, sliced *layer, surfaceOnly bool) data.PartitionedLayer {
+	var paths data.Paths
+	if surfaceOnly {
+		for _, polygon := range sliced.polygons {
+			// repeat the first point to print the polygon closed
+			closed := append(append(data.Path{}, polygon...), polygon[0])
+			paths = append(paths, closed)
+		}
+		partitioned = data.NewPartitionedLayer(nil)
+	}
+	paths = append(paths, sliced.openPolygons...)
+
+	return surfaceLayer{
+		PartitionedLayer: partitioned,
+		attributes: map[string]interface{}{
+			"surface": paths,
+		},
+	}
+}
+
+func (l surfaceLayer) Attributes() map[string]interface{} {
+	return l.attributes
+}
+
+func (l surfaceLayer) Bounds() (data.MicroPoint, data.MicroPoint) {
+	paths := data.Paths{}
+	for _, part := range l.LayerParts() {
+		paths = append(paths, part.Outline())
+	}
+	surface, _ := SurfacePaths(l)
+	paths = append(paths, surface...)
+
+	return paths.Bounds()
+}
+
+// SurfacePaths extracts the attribute "surface" from the layer.
+// It contains the slices of the surfaces which are printed as single lines if a surface mode is enabled.
+// Closed polygons end with their first point.
+// If it has the wrong type, a error is returned.
+// If it doesn't exist, (nil, nil) is returned.
+func SurfacePaths(layer data.PartitionedLayer) (data.Paths, error) {
+	if attr, ok := layer.Attributes()["surface"]; ok {
+		paths, ok := attr.(data.Paths)
+		if !ok {
+			return nil, errors.New("the attribute surface has the wrong datatype")
+		}
+
+		return paths, nil
+	}
+
+	return nil, nil
+}
@@ -0,0 +1,98 @@
+package slicer
+
+import (
+	"github.com/aligator/goslice/data"
+	"github.com/aligator/goslice/util/test"
+	"github.com/google/go-cmp/cmp"
+	"testing"
+)
+
+// microPointComparer returns a cmp.Comparer which can handle data.MicroPoint.
+func microPointComparer() cmp.Option {
+	return cmp.Comparer(func(p1, p2 data.MicroPoint) bool {
+		return p1.X() == p2.X() && p1.Y() == p2.Y()
+	})
+}
+
+func TestNewSurfaceLayer(t *testing.T) {
+	square := data.Path{
+		data.NewMicroPoint(0, 0),
+		data.NewMicroPoint(10000, 0),
+		data.NewMicroPoint(10000, 10000),
+		data.NewMicroPoint(0, 10000),
+	}
+	open := data.Path{
+		data.NewMicroPoint(20000, 0),
+		data.NewMicroPoint(30000, 5000),
+	}
+
+	var testCases = map[string]struct {
+		surfaceOnly     bool
+		expectedParts   int
+		expectedSurface data.Paths
+	}{
+		"surface and solid": {
+			surfaceOnly:     false,
+			expectedParts:   1,
+			expectedSurface: data.Paths{open},
+		},
+		"surface only": {
+			surfaceOnly:     true,
+			expectedParts:   0,
+			expectedSurface: data.Paths{append(append(data.Path{}, square...), square[0]), open},
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		sliced := &layer{
+			polygons:     data.Paths{square},
+			openPolygons: data.Paths{open},
+		}
+		partitioned := data.NewPartitionedLayer([]data.LayerPart{data.NewBasicLayerPart(square, nil)})
+
+		result := newSurfaceLayer(partitioned, sliced, testCase.surfaceOnly)
+		test.Equals(t, testCase.expectedParts, len(result.LayerParts()))
+		test.Assert(t, !data.IsEmptyLayer(result), "the layer with the surface should not be empty")
+
+		surface, err := SurfacePaths(result)
+		test.Ok(t, err)
+		test.Equals(t, testCase.expectedSurface, surface, microPointComparer())
+
+		// the bounds contain the parts and the surface
+		min, max := result.Bounds()
+		test.Equals(t, data.NewMicroPoint(0, 0), min, microPointComparer())
+		test.Equals(t, data.NewMicroPoint(30000, 10000), max, microPointComparer())
+	}
+}
+
+func TestSurfacePaths(t *testing.T) {
+	var testCases = map[string]struct {
+		attributes  map[string]interface{}
+		expected    data.Paths
+		expectedErr bool
+	}{
+		"without surface": {
+			attributes: map[string]interface{}{},
+		},
+		"with surface": {
+			attributes: map[string]interface{}{"surface": data.Paths{{data.NewMicroPoint(0, 0), data.NewMicroPoint(1000, 0)}}},
+			expected:   data.Paths{{data.NewMicroPoint(0, 0), data.NewMicroPoint(1000, 0)}},
+		},
+		"wrong type": {
+			attributes:  map[string]interface{}{"surface": []data.LayerPart{}},
+			expectedErr: true,
+		},
+	}
+
+	for testName, testCase := range testCases {
+		t.Log("testCase:", testName)
+		paths, err := SurfacePaths(surfaceLayer{PartitionedLayer: data.NewPartitionedLayer(nil), attributes: testCase.attributes})
+		if testCase.expectedErr {
+			test.Assert(t, err != nil, "an error should be returned")
+			continue
+		}
+		test.Ok(t, err)
+		test.Equals(t, testCase.expected, paths, microPointComparer())
+	}
+}
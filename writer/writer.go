@@ -1,19 +1,42 @@
 package writer
 
 import (
+	"fmt"
 	"github.com/aligator/goslice/handler"
+	"io"
 	"os"
 )
 
-type writer struct{}
+type writer struct {
+	overwrite bool
+}
+
+type option func(w *writer)
+
+// WithOverwrite sets if existing files may be overwritten.
+// By default existing files are overwritten.
+func WithOverwrite(overwrite bool) option {
+	return func(w *writer) {
+		w.overwrite = overwrite
+	}
+}
 
 // Writer can write gcode to a file.
-func Writer() handler.GCodeWriter {
-	return &writer{}
+// The returned writer also implements handler.GCodeStreamWriter.
+func Writer(writerOptions ...option) handler.GCodeWriter {
+	w := &writer{
+		overwrite: true,
+	}
+
+	for _, option := range writerOptions {
+		option(w)
+	}
+
+	return w
 }
 
 func (w writer) Write(gcode string, filename string) error {
-	buf, err := os.Create(filename)
+	buf, err := w.create(filename)
 	if err != nil {
 		return err
 	}
@@ -23,3 +46,21 @@ func (w writer) Write(gcode string, filename string) error {
 	_, err = buf.WriteString(gcode)
 	return err
 }
+
+// Open creates the file to write the gcode in several parts.
+func (w writer) Open(filename string) (io.WriteCloser, error) {
+	return w.create(filename)
+}
+
+// create creates the file.
+// It fails if it would overwrite an existing regular file which may not be overwritten.
+// Other files, such as devices or pipes, can always be written.
+func (w writer) create(filename string) (*os.File, error) {
+	if !w.overwrite {
+		if info, err := os.Stat(filename); err == nil && info.Mode().IsRegular() {
+			return nil, fmt.Errorf("the output file %v already exists", filename)
+		}
+	}
+
+	return os.Create(filename)
+}